@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"github.com/git-lfs/git-lfs/git"
+)
+
+// autotrackAction is what the clean filter should do with a file, given the
+// automatic tracking configuration.
+type autotrackAction int
+
+const (
+	// autotrackClean converts the file to a pointer, as usual.
+	autotrackClean autotrackAction = iota
+	// autotrackPassthrough leaves the file unchanged, as it is matched by
+	// a pattern with the lfs-autotrack attribute but is smaller than
+	// lfs.autotrack.size.
+	autotrackPassthrough
+)
+
+// autotracker decides whether files reaching the clean filter should be
+// converted to pointers, when patterns in .gitattributes which track them
+// also have the lfs-autotrack attribute, so that only large files are.
+type autotracker struct {
+	size     uint64
+	patterns []git.AttributePath
+}
+
+// newAutotracker returns an autotracker for the current repository, or nil if
+// "lfs.autotrack.size" is unset or no pattern has the lfs-autotrack
+// attribute, in which case all files reaching the filter are converted.
+func newAutotracker() *autotracker {
+	if !cfg.InRepo() {
+		return nil
+	}
+
+	size := cfg.AutotrackSize()
+	if size == 0 {
+		return nil
+	}
+
+	patterns := getAllKnownPatterns()
+	for _, p := range patterns {
+		if p.Autotrack {
+			return &autotracker{size: size, patterns: patterns}
+		}
+	}
+	return nil
+}
+
+// Action returns what should be done with the file at "name", relative to the
+// root of the working tree, which is "size" bytes long, or a negative number
+// if its size is not known.
+func (a *autotracker) Action(name string, size int64) autotrackAction {
+	if a == nil {
+		return autotrackClean
+	}
+
+	// Only the pattern which determines whether the file is tracked
+	// decides whether its size matters, so that later patterns may
+	// override an automatic one, as with "-filter".
+	match := trackPatternFor(a.patterns, name)
+	if match == nil || !match.Tracked || !match.Autotrack {
+		return autotrackClean
+	}
+
+	if size >= 0 && uint64(size) < a.size {
+		return autotrackPassthrough
+	}
+	return autotrackClean
+}
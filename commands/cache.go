@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"sort"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// evictCache removes the least recently used objects from the local object
+// store until it is no larger than "lfs.cache.maxsize". Objects needed by the
+// current checkout, as well as those which have not been pushed, are never
+// removed.
+func evictCache() {
+	maxSize := cfg.CacheMaxSize()
+	if maxSize == 0 {
+		return
+	}
+
+	var objects []fs.Object
+	var total uint64
+	cfg.EachLFSObject(func(obj fs.Object) error {
+		objects = append(objects, obj)
+		total += uint64(obj.Size)
+		return nil
+	})
+
+	if total <= maxSize {
+		return
+	}
+
+	retained, err := retainedObjects(lfs.NewFetchPruneConfig(cfg.Git), false)
+	if err != nil {
+		Error("cache: unable to evict objects: %s", err)
+		return
+	}
+
+	candidates := make([]fs.Object, 0, len(objects))
+	for _, obj := range objects {
+		if !retained.Contains(obj.Oid) {
+			candidates = append(candidates, obj)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ModTime.Before(candidates[j].ModTime)
+	})
+
+	var evicted int
+	var freed uint64
+	for _, obj := range candidates {
+		if total <= maxSize {
+			break
+		}
+
+		if err := cfg.ObjectStore().RemoveObject(obj.Oid); err != nil {
+			tracelog.Printf("cache: unable to evict %s: %s", obj.Oid, err)
+			continue
+		}
+		tracelog.Printf("cache: evicted %s, last used %s", obj.Oid, obj.ModTime)
+
+		total -= uint64(obj.Size)
+		freed += uint64(obj.Size)
+		evicted++
+	}
+
+	if evicted > 0 {
+		Print("cache: evicted %d object(s) (%s) to stay within lfs.cache.maxsize", evicted, humanize.FormatBytes(freed))
+	}
+	if total > maxSize {
+		Print("cache: %s of objects are in use, more than lfs.cache.maxsize (%s)", humanize.FormatBytes(total), humanize.FormatBytes(maxSize))
+	}
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveFormatArg string
+	archivePrefixArg string
+	archiveOutputArg string
+)
+
+// archiveCommand writes an archive of a tree with "git archive", holding the
+// contents of the Git LFS files in it rather than their pointers. The objects
+// which are missing locally are downloaded in a single batch beforehand.
+func archiveCommand(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Exit("Usage: git lfs archive [--format=<fmt>] [--prefix=<prefix>] [-o <file>] <tree-ish> [<path>...]")
+	}
+	setupRepository()
+
+	if len(archiveOutputArg) == 0 {
+		// The archive itself is written to standard output, so
+		// progress must not be.
+		OutputWriter = newMultiWriter(os.Stderr, ErrorBuffer)
+	}
+
+	treeish, paths := args[0], args[1:]
+	filter := filepathfilter.New(paths, nil, filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+	if !fetchRef(treeish, filter) {
+		c := getAPIClient()
+		e := c.Endpoints.Endpoint("download", cfg.Remote())
+		exitTransferFailed("error: failed to fetch some objects from '%s'", e.Url)
+	}
+
+	var gitargs []string
+	if len(archiveFormatArg) > 0 {
+		gitargs = append(gitargs, "--format="+archiveFormatArg)
+	}
+	if len(archivePrefixArg) > 0 {
+		gitargs = append(gitargs, "--prefix="+archivePrefixArg)
+	}
+	if len(archiveOutputArg) > 0 {
+		gitargs = append(gitargs, "--output="+archiveOutputArg)
+	}
+	gitargs = append(gitargs, treeish)
+	if len(paths) > 0 {
+		gitargs = append(append(gitargs, "--"), paths...)
+	}
+
+	if err := git.ArchiveWithFilters(gitargs...); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func init() {
+	RegisterCommand("archive", archiveCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVar(&archiveFormatArg, "format", "", "Format of the archive: tar, zip, tar.gz or tgz")
+		cmd.Flags().StringVar(&archivePrefixArg, "prefix", "", "Prepend this prefix to each path in the archive")
+		cmd.Flags().StringVarP(&archiveOutputArg, "output", "o", "", "Write the archive to this file")
+	})
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+// bundleObjectsSuffix is appended to the path of a Git bundle to give the path
+// of the archive holding the Git LFS objects it needs.
+const bundleObjectsSuffix = ".lfs"
+
+// bundleCreateCommand writes a Git bundle with "git bundle create", and
+// alongside it a tar archive of the Git LFS objects referenced by the commits
+// in the bundle, so that both can be carried to a repository which cannot
+// reach the remote.
+func bundleCreateCommand(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		Exit("Usage: git lfs bundle create <file> <git-rev-list-args>...")
+	}
+	setupRepository()
+
+	bundlePath := args[0]
+	if err := git.CreateBundle(bundlePath, args[1:]...); err != nil {
+		ExitWithError(err)
+	}
+
+	bundle, err := git.ReadBundle(bundlePath)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	pointers, err := bundlePointers(bundle)
+	if err != nil {
+		os.Remove(bundlePath)
+		ExitWithError(err)
+	}
+
+	var missing []*lfs.WrappedPointer
+	for _, p := range pointers {
+		if !cfg.LFSObjectExists(p.Oid, p.Size) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		os.Remove(bundlePath)
+		for _, p := range missing {
+			Error("bundle: missing object: %s (%s)", p.Name, p.Oid)
+		}
+		Error("bundle: %d object(s) are missing from the local object store; run `git lfs fetch` first", len(missing))
+		exit(exitCodeLocalCorruption)
+	}
+
+	size, err := writeBundleObjects(bundlePath+bundleObjectsSuffix, pointers)
+	if err != nil {
+		os.Remove(bundlePath)
+		ExitWithError(err)
+	}
+
+	Print("bundle: wrote %d Git LFS object(s) (%s) to %s", len(pointers), humanize.FormatBytes(uint64(size)), bundlePath+bundleObjectsSuffix)
+}
+
+// bundleUnbundleCommand stores the Git LFS objects from the archive written
+// alongside a Git bundle by "git lfs bundle create" in the local object store.
+func bundleUnbundleCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Exit("Usage: git lfs bundle unbundle <file>")
+	}
+	setupRepository()
+
+	archivePath := args[0] + bundleObjectsSuffix
+	f, err := os.Open(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			Exit("No Git LFS objects found for bundle %s: %s does not exist", args[0], archivePath)
+		}
+		ExitWithError(err)
+	}
+	defer f.Close()
+
+	var imported, skipped int
+	var size int64
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			ExitWithError(errors.Wrapf(err, "could not read %s", archivePath))
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		oid := path.Base(hdr.Name)
+		if len(oid) != tools.NewLfsContentHashFor(oid).Size()*2 {
+			ExitWithError(errors.NewCodedError(fmt.Errorf("invalid object %q in bundle", hdr.Name), errors.CodeCorruption))
+		}
+		if cfg.LFSObjectExists(oid, hdr.Size) {
+			skipped++
+			continue
+		}
+		if err := unbundleObject(oid, hdr.Size, tr); err != nil {
+			ExitWithError(err)
+		}
+		imported++
+		size += hdr.Size
+	}
+
+	Print("bundle: imported %d Git LFS object(s) (%s), %d already present", imported, humanize.FormatBytes(uint64(size)), skipped)
+}
+
+// bundlePointers returns the pointers, without duplicates, in the commits
+// contained in the given bundle, which are those reachable from its refs but
+// not from its prerequisites.
+func bundlePointers(bundle *git.Bundle) ([]*lfs.WrappedPointer, error) {
+	var pointers []*lfs.WrappedPointer
+	var errs []error
+	seen := make(map[string]bool)
+
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	defer gitscanner.Close()
+
+	err := gitscanner.ScanRefs(bundle.Heads(), bundle.Prerequisites, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if seen[p.Oid] {
+			return
+		}
+		seen[p.Oid] = true
+		pointers = append(pointers, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pointers, errors.Combine(errs)
+}
+
+// writeBundleObjects writes a tar archive to archivePath of the objects of the
+// given pointers, in the same layout as the local object store, and returns
+// their total size.
+func writeBundleObjects(archivePath string, pointers []*lfs.WrappedPointer) (int64, error) {
+	tmp, err := tools.TempFile(cfg.TempDir(), "bundle", cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	var size int64
+	tw := tar.NewWriter(tmp)
+	for _, p := range pointers {
+		if err := writeBundleObject(tw, p); err != nil {
+			tmp.Close()
+			return 0, err
+		}
+		size += p.Size
+	}
+
+	err = tw.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return size, os.Rename(tmp.Name(), archivePath)
+}
+
+func writeBundleObject(tw *tar.Writer, p *lfs.WrappedPointer) error {
+	r, err := cfg.ObjectStore().OpenObject(p.Oid)
+	if err != nil {
+		return errors.Wrapf(err, "could not open object %s", p.Oid)
+	}
+	defer r.Close()
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     path.Join("objects", p.Oid[0:2], p.Oid[2:4], p.Oid),
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     p.Size,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tw, r); err != nil {
+		return errors.Wrapf(err, "could not write object %s", p.Oid)
+	}
+	return nil
+}
+
+// unbundleObject stores the object "oid" of the given size read from r in the
+// local object store, once its contents have been checked against its OID.
+func unbundleObject(oid string, size int64, r io.Reader) error {
+	tmp, err := tools.TempFile(cfg.TempDir(), oid+"-", cfg)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hr := tools.NewHashingReaderFor(r, oid)
+	written, err := io.Copy(tmp, hr)
+	if err != nil {
+		return errors.Wrapf(err, "could not read object %s", oid)
+	}
+	if written != size || hr.Hash() != oid {
+		return errors.NewCodedError(fmt.Errorf("object %s in bundle is corrupt", oid), errors.CodeCorruption)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return cfg.ObjectStore().WriteObject(oid, tmp)
+}
+
+func init() {
+	RegisterCommand("bundle", nil, func(cmd *cobra.Command) {
+		create := NewCommand("create", bundleCreateCommand)
+		// Arguments such as "--all" are passed on to "git bundle
+		// create".
+		create.DisableFlagParsing = true
+
+		cmd.AddCommand(create, NewCommand("unbundle", bundleUnbundleCommand))
+	})
+}
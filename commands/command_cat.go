@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/spf13/cobra"
+)
+
+// catOidRE matches the OID of a Git LFS object, as opposed to a path in a
+// ref.
+var catOidRE = regexp.MustCompile(`\A(?:[0-9a-f]{64}|[0-9a-f]{128})\z`)
+
+// catCommand writes the contents of the Git LFS object given by OID, or of the
+// file at "<ref>:<path>", to standard output, downloading the object if it is
+// not present locally. Files which are not stored as Git LFS objects are
+// written as they are.
+func catCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(args) != 1 {
+		Exit("Usage: git lfs cat <oid>|<ref>:<path>")
+	}
+
+	var ptr *lfs.Pointer
+	name := args[0]
+	if catOidRE.MatchString(name) {
+		ptr = catPointerForOid(name)
+	} else {
+		ptr = catPointerForPath(name)
+		if ptr == nil {
+			return
+		}
+	}
+
+	gf := lfs.NewGitFilter(cfg)
+	if _, err := gf.Smudge(os.Stdout, ptr, name, true, getTransferManifest(), nil); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// catPointerForOid returns a pointer to the object with the given OID. Objects
+// which are not present locally must be referenced somewhere in the
+// repository's history, so that their size is known in order to download
+// them.
+func catPointerForOid(oid string) *lfs.Pointer {
+	if size, err := cfg.ObjectStore().ObjectSize(oid); err == nil {
+		return lfs.NewPointer(oid, size, nil)
+	}
+
+	var ptr *lfs.Pointer
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	err := gitscanner.ScanAll(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Panic(err, "Could not scan for Git LFS files")
+		}
+		if ptr == nil && p.Oid == oid {
+			ptr = p.Pointer
+		}
+	})
+	gitscanner.Close()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if ptr == nil {
+		Exit("Object %s is neither present locally nor referenced in this repository", oid)
+	}
+	return ptr
+}
+
+// catPointerForPath returns the pointer stored in the file named by spec, a
+// "<ref>:<path>". If the file is not a pointer, its contents are written to
+// standard output as they are and nil is returned instead.
+func catPointerForPath(spec string) *lfs.Pointer {
+	blob, r, err := git.BlobReader(spec)
+	if err != nil {
+		Exit("Could not read %s: %v", spec, err)
+	}
+	defer blob.Wait()
+
+	ptr, contents, err := lfs.DecodeFrom(r)
+	if err == nil {
+		// Drain the rest of a pointer-sized blob so that git can exit.
+		io.Copy(ioutil.Discard, r)
+		return ptr
+	}
+
+	if _, err := io.Copy(os.Stdout, contents); err != nil {
+		ExitWithError(err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterCommand("cat", catCommand, nil)
+}
@@ -52,7 +52,7 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 	)
 	meter := tq.NewMeter(cfg)
 	meter.Direction = tq.Checkout
-	meter.Logger = meter.LoggerFromEnv(cfg.Os)
+	setProgressOutputs(meter)
 	logger.Enqueue(meter)
 	chgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
@@ -62,29 +62,36 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 
 		totalBytes += p.Size
 		meter.Add(p.Size)
-		meter.StartTransfer(p.Name)
 		pointers = append(pointers, p)
 	})
 
-	chgitscanner.Filter = filepathfilter.New(rootedPaths(args), nil)
+	chgitscanner.Filter = filepathfilter.New(rootedPaths(args), nil, filepathfilter.IgnoreCase(cfg.IgnoreCase()))
 
 	if err := chgitscanner.ScanTree(ref.Sha); err != nil {
 		ExitWithError(err)
 	}
 	chgitscanner.Close()
 
+	singleCheckout.OnFinish(func(p *lfs.WrappedPointer) {
+		// The original format of GIT_LFS_PROGRESS gives the size of
+		// all the files being checked out as the size of each.
+		total := p.Size
+		if meter.Logger.Version() < 2 {
+			total = totalBytes
+		}
+		meter.TransferBytes("checkout", p.Name, p.Size, total, int(p.Size))
+		meter.FinishTransfer(p.Name)
+	})
+
 	meter.Start()
 	for _, p := range pointers {
+		meter.StartTransfer(p.Name)
 		singleCheckout.Run(p)
-
-		// not strictly correct (parallel) but we don't have a callback & it's just local
-		// plus only 1 slot in channel so it'll block & be close
-		meter.TransferBytes("checkout", p.Name, p.Size, totalBytes, int(p.Size))
-		meter.FinishTransfer(p.Name)
 	}
 
-	meter.Finish()
 	singleCheckout.Close()
+	meter.Finish()
+	logger.Close()
 }
 
 func checkoutConflict(file string, stage git.IndexStage) {
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
@@ -22,24 +23,37 @@ import (
 //
 // If the object read from "from" is _already_ a clean pointer, then it will be
 // written out verbatim to "to", without trying to make it a pointer again.
-func clean(gf *lfs.GitFilter, to io.Writer, from io.Reader, fileName string, fileSize int64) (*lfs.Pointer, error) {
+//
+// If "at" is non-nil, files matched by patterns with the lfs-autotrack
+// attribute which are smaller than lfs.autotrack.size are also written out
+// verbatim.
+func clean(gf *lfs.GitFilter, at *autotracker, to io.Writer, from io.Reader, fileName string, fileSize int64) (*lfs.Pointer, error) {
 	var cb tools.CopyCallback
 	var file *os.File
+	var exists bool
 
 	if len(fileName) > 0 {
 		stat, err := os.Stat(fileName)
 		if err == nil && stat != nil {
+			exists = true
 			if fileSize < 0 {
 				fileSize = stat.Size()
 			}
+		}
+	}
 
-			localCb, localFile, err := gf.CopyCallbackFile("clean", fileName, 1, 1)
-			if err != nil {
-				Error(err.Error())
-			} else {
-				cb = localCb
-				file = localFile
-			}
+	if at.Action(fileName, fileSize) == autotrackPassthrough {
+		_, err := io.Copy(to, from)
+		return nil, err
+	}
+
+	if exists {
+		localCb, localFile, err := gf.CopyCallbackFile("clean", fileName, 1, 1)
+		if err != nil {
+			Error(err.Error())
+		} else {
+			cb = localCb
+			file = localFile
 		}
 	}
 
@@ -65,29 +79,85 @@ func clean(gf *lfs.GitFilter, to io.Writer, from io.Reader, fileName string, fil
 		ExitWithError(errors.Wrap(err, "Error cleaning LFS object"))
 	}
 
+	if _, ok := cfg.ObjectStore().(*fs.Filesystem); !ok {
+		if err := storeCleanedObject(cleaned.Oid, cleaned.Filename); err != nil {
+			Panic(err, "Unable to store %s", cleaned.Oid)
+		}
+		_, err = lfs.EncodePointer(to, cleaned.Pointer)
+		return cleaned.Pointer, err
+	}
+
 	tmpfile := cleaned.Filename
 	mediafile, err := gf.ObjectPath(cleaned.Oid)
 	if err != nil {
 		Panic(err, "Unable to get local media path.")
 	}
 
-	if stat, _ := os.Stat(mediafile); stat != nil {
-		if stat.Size() != cleaned.Size && len(cleaned.Pointer.Extensions) == 0 {
+	if size, _, err := fs.ObjectFileSize(mediafile); err == nil {
+		if size != cleaned.Size && len(cleaned.Pointer.Extensions) == 0 {
 			Exit("Files don't match:\n%s\n%s", mediafile, tmpfile)
 		}
 		Debug("%s exists", mediafile)
 	} else {
-		if err := os.Rename(tmpfile, mediafile); err != nil {
+		// The object was hashed as it was written to the temporary
+		// file, so it only needs to be renamed into place, rather than
+		// being copied again.
+		written, err := commitCleanedObject(cfg.Filesystem(), tmpfile, mediafile, cleaned.Pointer)
+		if err != nil {
 			Panic(err, "Unable to move %s to %s\n", tmpfile, mediafile)
 		}
-
-		Debug("Writing %s", mediafile)
+		if !written {
+			Debug("%s exists", mediafile)
+		} else {
+			Debug("Writing %s", mediafile)
+			if cfg.Filesystem().Compress {
+				if _, err := cfg.Filesystem().CompressObject(cleaned.Oid); err != nil {
+					Debug("Unable to compress %s: %s", mediafile, err)
+				}
+			}
+		}
 	}
 
 	_, err = lfs.EncodePointer(to, cleaned.Pointer)
 	return cleaned.Pointer, err
 }
 
+// commitCleanedObject renames "tmpfile", to which the object of pointer "p" was
+// written, to "mediafile" in the local object store, and returns whether it did
+// so.  If the rename fails because another process stored the same object in
+// the meantime, as can happen on Windows, that copy is used instead, and false
+// is returned.
+func commitCleanedObject(filesystem *fs.Filesystem, tmpfile, mediafile string, p *lfs.Pointer) (bool, error) {
+	err := filesystem.CommitFile(tmpfile, mediafile)
+	if err == nil {
+		return true, nil
+	}
+
+	size, _, serr := fs.ObjectFileSize(mediafile)
+	if serr != nil || (size != p.Size && len(p.Extensions) == 0) {
+		return false, err
+	}
+	return false, nil
+}
+
+// storeCleanedObject writes the object "oid", cleaned to the temporary file
+// "tmpfile", to the configured object store, when that is not the local object
+// store on disk, unless it is already there.
+func storeCleanedObject(oid, tmpfile string) error {
+	store := cfg.ObjectStore()
+	if _, err := store.ObjectSize(oid); err == nil {
+		Debug("%s exists", oid)
+		return nil
+	}
+
+	file, err := os.Open(tmpfile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return store.WriteObject(oid, file)
+}
+
 func cleanCommand(cmd *cobra.Command, args []string) {
 	requireStdin("This command should be run by the Git 'clean' filter")
 	setupRepository()
@@ -99,7 +169,8 @@ func cleanCommand(cmd *cobra.Command, args []string) {
 	}
 
 	gitfilter := lfs.NewGitFilter(cfg)
-	ptr, err := clean(gitfilter, os.Stdout, os.Stdin, fileName, -1)
+	gitfilter.SetProgressStream(getProgressStream())
+	ptr, err := clean(gitfilter, newAutotracker(), os.Stdout, os.Stdin, fileName, -1)
 	if err != nil {
 		Error(err.Error())
 	}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitCleanedObjectRenames(t *testing.T) {
+	filesystem, tmpfile, mediafile := setupCommitCleanedObject(t)
+	require.Nil(t, ioutil.WriteFile(tmpfile, []byte("abc"), 0644))
+
+	written, err := commitCleanedObject(filesystem, tmpfile, mediafile, &lfs.Pointer{Size: 3})
+	assert.Nil(t, err)
+	assert.True(t, written)
+
+	data, err := ioutil.ReadFile(mediafile)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(data))
+}
+
+func TestCommitCleanedObjectUsesConcurrentCopy(t *testing.T) {
+	filesystem, tmpfile, mediafile := setupCommitCleanedObject(t)
+	require.Nil(t, ioutil.WriteFile(mediafile, []byte("abc"), 0644))
+
+	// The temporary file is missing, so the rename fails, but another
+	// process has stored the object already.
+	written, err := commitCleanedObject(filesystem, tmpfile, mediafile, &lfs.Pointer{Size: 3})
+	assert.Nil(t, err)
+	assert.False(t, written)
+}
+
+func TestCommitCleanedObjectFailsWithMismatchedCopy(t *testing.T) {
+	filesystem, tmpfile, mediafile := setupCommitCleanedObject(t)
+	require.Nil(t, ioutil.WriteFile(mediafile, []byte("abcdef"), 0644))
+
+	written, err := commitCleanedObject(filesystem, tmpfile, mediafile, &lfs.Pointer{Size: 3})
+	assert.NotNil(t, err)
+	assert.False(t, written)
+}
+
+func TestCommitCleanedObjectFailsWithoutCopy(t *testing.T) {
+	filesystem, tmpfile, mediafile := setupCommitCleanedObject(t)
+
+	written, err := commitCleanedObject(filesystem, tmpfile, mediafile, &lfs.Pointer{Size: 3})
+	assert.NotNil(t, err)
+	assert.False(t, written)
+}
+
+func setupCommitCleanedObject(t *testing.T) (*fs.Filesystem, string, string) {
+	dir, err := ioutil.TempDir("", "commit-cleaned-object")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return &fs.Filesystem{}, filepath.Join(dir, "tmpfile"), filepath.Join(dir, "mediafile")
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -84,7 +85,8 @@ func cloneCommand(cmd *cobra.Command, args []string) {
 			// If --no-checkout or --bare then we shouldn't check out, just fetch instead
 			fetchRef(ref.Name, filter)
 		} else {
-			pull(filter)
+			pull(ref, filter)
+			setLockableFilesReadOnly()
 			err := postCloneSubmodules(args)
 			if err != nil {
 				Exit("Error performing 'git lfs pull' for submodules: %v", err)
@@ -102,6 +104,25 @@ func cloneCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// setLockableFilesReadOnly makes the lockable files of the new clone
+// read-only, since the post-checkout hook which would otherwise do so is not
+// installed until after the clone has been checked out.
+func setLockableFilesReadOnly() {
+	if !cfg.SetLockableFilesReadOnly() {
+		return
+	}
+
+	lockClient := newLockClient()
+	if len(lockClient.GetLockablePatterns()) == 0 {
+		return
+	}
+
+	tracelog.Printf("clone: checking write flags for all lockable files")
+	if err := lockClient.FixAllLockableFileWriteFlags(); err != nil {
+		LoggedError(err, "Warning: unable to make lockable files read-only: %v", err)
+	}
+}
+
 func postCloneSubmodules(args []string) error {
 	// In git 2.9+ the filter option will have been passed through to submodules
 	// So we need to lfs pull inside each
@@ -0,0 +1,423 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/gitattr"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	completionPatternsArg bool
+)
+
+// completionShells lists the shells supported by "git lfs completion".
+var completionShells = []string{"bash", "fish", "powershell", "zsh"}
+
+// completionArgs gives the kind of values completed for the positional
+// arguments of commands which take something other than paths.
+var completionArgs = map[string]string{
+	"completion": "shells",
+	"fetch":      "remotes",
+	"pull":       "remotes",
+	"push":       "remotes",
+	"untrack":    "patterns",
+	"verify":     "remotes",
+}
+
+// completionEntry describes a subcommand of git-lfs, and how to complete
+// its flags and arguments.
+type completionEntry struct {
+	Name string
+	// Args is the kind of value completed for positional arguments:
+	// "remotes", "patterns", "shells", or empty to complete paths.
+	Args  string
+	Flags []*completionFlag
+}
+
+type completionFlag struct {
+	Long  string
+	Short string
+	Usage string
+	// Remote is true if the value of the flag is the name of a remote.
+	Remote bool
+}
+
+// names returns the flag as it is given on the command line, in both its long
+// and short forms.
+func (f *completionFlag) names() []string {
+	names := []string{"--" + f.Long}
+	if len(f.Short) > 0 {
+		names = append(names, "-"+f.Short)
+	}
+	return names
+}
+
+// completionCommand prints a script which completes git-lfs commands and
+// flags for the given shell, or the patterns tracked in the current repository
+// for use by those scripts.
+func completionCommand(cmd *cobra.Command, args []string) {
+	if completionPatternsArg {
+		completionPrintPatterns()
+		return
+	}
+
+	if len(args) != 1 {
+		Exit("Usage: git lfs completion (%s)", strings.Join(completionShells, "|"))
+	}
+
+	commands := completionCommands(cmd.Root())
+	switch args[0] {
+	case "bash":
+		completionBash(os.Stdout, commands)
+	case "fish":
+		completionFish(os.Stdout, commands)
+	case "powershell":
+		completionPowerShell(os.Stdout, commands)
+	case "zsh":
+		completionZsh(os.Stdout, commands)
+	default:
+		Exit("Unsupported shell %q: expected one of %s", args[0], strings.Join(completionShells, ", "))
+	}
+}
+
+// completionCommands returns the visible subcommands of root, and their
+// visible flags, including those inherited from root, sorted by name.
+func completionCommands(root *cobra.Command) []*completionEntry {
+	var commands []*completionEntry
+	for _, sub := range root.Commands() {
+		if sub.Hidden {
+			continue
+		}
+
+		c := &completionEntry{Name: sub.Name(), Args: completionArgs[sub.Name()]}
+		visit := func(f *pflag.Flag) {
+			if f.Hidden || f.Name == "help" {
+				return
+			}
+			c.Flags = append(c.Flags, &completionFlag{
+				Long:   f.Name,
+				Short:  f.Shorthand,
+				Usage:  f.Usage,
+				Remote: f.Name == "remote",
+			})
+		}
+		sub.LocalFlags().VisitAll(visit)
+		sub.InheritedFlags().VisitAll(visit)
+		commands = append(commands, c)
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// completionPrintPatterns prints each pattern tracked by Git LFS in the
+// current repository, once.
+func completionPrintPatterns() {
+	if !cfg.InRepo() {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, p := range git.GetAttributePaths(gitattr.NewMacroProcessor(), cfg.LocalWorkingDir(), cfg.LocalGitDir()) {
+		if p.Tracked && !seen[p.Path] {
+			seen[p.Path] = true
+			patterns = append(patterns, p.Path)
+		}
+	}
+	sort.Strings(patterns)
+
+	for _, p := range patterns {
+		Print(p)
+	}
+}
+
+// completionFlagWords returns the flags of c as a list of words, and the
+// names of those which take a remote.
+func completionFlagWords(c *completionEntry) (flags, remoteFlags []string) {
+	for _, f := range c.Flags {
+		flags = append(flags, f.names()...)
+		if f.Remote {
+			remoteFlags = append(remoteFlags, f.names()...)
+		}
+	}
+	return flags, remoteFlags
+}
+
+func completionCommandNames(commands []*completionEntry) []string {
+	names := make([]string, 0, len(commands))
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func completionBash(w io.Writer, commands []*completionEntry) {
+	fmt.Fprint(w, `# bash completion for git-lfs
+#
+# Load it in the current shell with:
+#   source <(git lfs completion bash)
+
+__git_lfs_complete() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	local i start=0 cmd=""
+	for ((i = 0; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		lfs|git-lfs|*/git-lfs) start=$((i + 1)); break ;;
+		esac
+	done
+	for ((i = start; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	local words="" args="" rflags=""
+	case "$cmd" in
+`)
+	fmt.Fprintf(w, "\t\"\") words=%q ;;\n", strings.Join(completionCommandNames(commands), " "))
+	for _, c := range commands {
+		flags, remoteFlags := completionFlagWords(c)
+		fmt.Fprintf(w, "\t%s) words=%q; args=%q; rflags=%q ;;\n",
+			c.Name, strings.Join(flags, " "), c.Args, strings.Join(remoteFlags, " "))
+	}
+	fmt.Fprintf(w, `	esac
+
+	if [[ -n "$rflags" && " $rflags " == *" $prev "* ]]; then
+		args=remotes
+	elif [[ -z "$cmd" || "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$words" -- "$cur"))
+		return
+	fi
+
+	case "$args" in
+	remotes) words="$(git remote 2>/dev/null)" ;;
+	patterns) words="$(git lfs completion --patterns 2>/dev/null)" ;;
+	shells) words=%q ;;
+	*) return ;;
+	esac
+	COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+# Called by Git's own completion for "git lfs".
+_git_lfs() {
+	__git_lfs_complete
+}
+
+complete -o bashdefault -o default -F __git_lfs_complete git-lfs
+`, strings.Join(completionShells, " "))
+}
+
+func completionZsh(w io.Writer, commands []*completionEntry) {
+	fmt.Fprint(w, `#compdef git-lfs
+#
+# zsh completion for git-lfs
+#
+# Load it in the current shell with:
+#   source <(git lfs completion zsh)
+# or save it as _git-lfs in a directory in $fpath.
+
+_git-lfs() {
+	local cur="${words[CURRENT]}" prev="${words[CURRENT-1]}"
+	local i start=1 cmd=""
+	for ((i = 1; i < CURRENT; i++)); do
+		case "${words[i]}" in
+		lfs|git-lfs|*/git-lfs) start=$((i + 1)); break ;;
+		esac
+	done
+	for ((i = start; i < CURRENT; i++)); do
+		case "${words[i]}" in
+		-*) ;;
+		*) cmd="${words[i]}"; break ;;
+		esac
+	done
+
+	local -a candidates
+	local args="" rflags=""
+	case "$cmd" in
+`)
+	fmt.Fprintf(w, "\t\"\") candidates=(%s) ;;\n", strings.Join(completionCommandNames(commands), " "))
+	for _, c := range commands {
+		flags, remoteFlags := completionFlagWords(c)
+		fmt.Fprintf(w, "\t%s) candidates=(%s); args=%q; rflags=%q ;;\n",
+			c.Name, strings.Join(flags, " "), c.Args, strings.Join(remoteFlags, " "))
+	}
+	fmt.Fprintf(w, `	esac
+
+	if [[ -n "$rflags" && " $rflags " == *" $prev "* ]]; then
+		args=remotes
+	elif [[ -z "$cmd" || "$cur" == -* ]]; then
+		compadd -- "${candidates[@]}"
+		return
+	fi
+
+	case "$args" in
+	remotes) compadd -- ${(f)"$(git remote 2>/dev/null)"} ;;
+	patterns) compadd -- ${(f)"$(git lfs completion --patterns 2>/dev/null)"} ;;
+	shells) compadd -- %s ;;
+	*) _files ;;
+	esac
+}
+
+if [ "$funcstack[1]" = "_git-lfs" ]; then
+	_git-lfs "$@"
+else
+	compdef _git-lfs git-lfs
+fi
+`, strings.Join(completionShells, " "))
+}
+
+func completionFish(w io.Writer, commands []*completionEntry) {
+	fmt.Fprint(w, `# fish completion for git-lfs
+#
+# Load it in the current shell with:
+#   git lfs completion fish | source
+
+# Prints the git-lfs subcommand being completed, if any, and fails if the
+# command line does not run git-lfs.
+function __git_lfs_command
+    set -l words (commandline -opc)
+    set -l start 0
+    for i in (seq (count $words))
+        switch $words[$i]
+            case lfs git-lfs '*/git-lfs'
+                set start (math $i + 1)
+                break
+        end
+    end
+    test $start -gt 0; or return 1
+    for i in (seq $start (count $words))
+        switch $words[$i]
+            case '-*'
+            case '*'
+                echo $words[$i]
+                return 0
+        end
+    end
+end
+
+function __git_lfs_needs_command
+    set -l cmd (__git_lfs_command); or return 1
+    test -z "$cmd"
+end
+
+function __git_lfs_using_command
+    set -l cmd (__git_lfs_command); or return 1
+    test "$cmd" = "$argv[1]"
+end
+
+`)
+	for _, bin := range []string{"git-lfs", "git"} {
+		fmt.Fprintf(w, "complete -c %s -f -n __git_lfs_needs_command -a %s\n",
+			bin, fishQuote(strings.Join(completionCommandNames(commands), " ")))
+		for _, c := range commands {
+			cond := fishQuote("__git_lfs_using_command " + c.Name)
+			switch c.Args {
+			case "remotes":
+				fmt.Fprintf(w, "complete -c %s -f -n %s -a '(git remote 2>/dev/null)'\n", bin, cond)
+			case "patterns":
+				fmt.Fprintf(w, "complete -c %s -f -n %s -a '(git lfs completion --patterns 2>/dev/null)'\n", bin, cond)
+			case "shells":
+				fmt.Fprintf(w, "complete -c %s -f -n %s -a %s\n", bin, cond, fishQuote(strings.Join(completionShells, " ")))
+			}
+			for _, f := range c.Flags {
+				fmt.Fprintf(w, "complete -c %s -n %s -l %s", bin, cond, f.Long)
+				if len(f.Short) > 0 {
+					fmt.Fprintf(w, " -s %s", f.Short)
+				}
+				if f.Remote {
+					fmt.Fprint(w, " -x -a '(git remote 2>/dev/null)'")
+				}
+				if len(f.Usage) > 0 {
+					fmt.Fprintf(w, " -d %s", fishQuote(f.Usage))
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	}
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `'`, `\'`, -1)
+	return "'" + s + "'"
+}
+
+func completionPowerShell(w io.Writer, commands []*completionEntry) {
+	fmt.Fprint(w, `# PowerShell completion for git-lfs
+#
+# Load it in the current session with:
+#   git lfs completion powershell | Out-String | Invoke-Expression
+
+Register-ArgumentCompleter -Native -CommandName 'git-lfs' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '') {
+        $words = @($words | Select-Object -First ($words.Count - 1))
+    }
+    $cmd = $words | Where-Object { -not $_.StartsWith('-') } | Select-Object -First 1
+    $prev = if ($words.Count -gt 0) { $words[-1] } else { '' }
+
+    $candidates = @()
+    $kind = ''
+    $remoteFlags = @()
+    if (-not $cmd) {
+`)
+	fmt.Fprintf(w, "        $candidates = @(%s)\n", powerShellList(completionCommandNames(commands)))
+	fmt.Fprint(w, "    }\n    switch ($cmd) {\n")
+	for _, c := range commands {
+		flags, remoteFlags := completionFlagWords(c)
+		fmt.Fprintf(w, "        '%s' { $candidates = @(%s); $kind = '%s'; $remoteFlags = @(%s) }\n",
+			c.Name, powerShellList(flags), c.Args, powerShellList(remoteFlags))
+	}
+	fmt.Fprintf(w, `    }
+
+    if ($remoteFlags -contains $prev) {
+        $kind = 'remotes'
+    } elseif (-not $cmd -or $wordToComplete.StartsWith('-')) {
+        $kind = ''
+    } else {
+        $candidates = @()
+    }
+
+    switch ($kind) {
+        'remotes' { $candidates = @(git remote 2>$null) }
+        'patterns' { $candidates = @(git lfs completion --patterns 2>$null) }
+        'shells' { $candidates = @(%s) }
+    }
+
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`, powerShellList(completionShells))
+}
+
+// powerShellList formats words as the elements of a PowerShell array.
+func powerShellList(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, "'"+strings.Replace(word, "'", "''", -1)+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func init() {
+	RegisterCommand("completion", completionCommand, func(cmd *cobra.Command) {
+		cmd.PreRun = nil
+		cmd.Flags().BoolVarP(&completionPatternsArg, "patterns", "", false, "Print the patterns tracked in this repository.")
+		cmd.Flags().MarkHidden("patterns")
+	})
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compressDecompressArg bool
+)
+
+func compressCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	count, failed, saved, err := compressLocalObjects("compress", compressDecompressArg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if compressDecompressArg {
+		Print("compress: decompressed %d object(s)", count)
+	} else {
+		Print("compress: compressed %d object(s), saving %s", count, humanize.FormatBytes(uint64(saved)))
+	}
+
+	if failed > 0 {
+		Exit("compress: %d object(s) could not be processed", failed)
+	}
+}
+
+// compressLocalObjects compresses, or if decompress is set decompresses, each
+// object in the local store, reporting failures prefixed by the given command
+// name. It returns the number of objects changed, the number which could not
+// be processed, and the number of bytes saved by compression.
+func compressLocalObjects(name string, decompress bool) (count, failed int, saved int64, err error) {
+	err = cfg.EachLFSObject(func(obj fs.Object) error {
+		if decompress {
+			ok, err := cfg.Filesystem().DecompressObject(obj.Oid)
+			if err != nil {
+				Error("%s: unable to decompress %s: %s", name, obj.Oid, err)
+				failed++
+			} else if ok {
+				count++
+			}
+			return nil
+		}
+
+		n, err := cfg.Filesystem().CompressObject(obj.Oid)
+		if err != nil {
+			Error("%s: unable to compress %s: %s", name, obj.Oid, err)
+			failed++
+		} else if n > 0 {
+			count++
+			saved += n
+		}
+		return nil
+	})
+	return count, failed, saved, err
+}
+
+func init() {
+	RegisterCommand("compress", compressCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&compressDecompressArg, "decompress", "d", false, "Decompress all local objects")
+	})
+}
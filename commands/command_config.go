@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configValidateArg bool
+)
+
+// configProblem is the data of a "problem" event written by "git lfs config
+// --validate --json".
+type configProblem struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Scope   string `json:"scope,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+}
+
+// configResult is the result written by "git lfs config --validate --json".
+type configResult struct {
+	Problems int `json:"problems"`
+}
+
+// configCommand checks the "lfs.*" settings for unknown names, invalid values
+// and conflicts, and prints a warning for each problem found.
+func configCommand(cmd *cobra.Command, args []string) {
+	if !configValidateArg {
+		Exit("Usage: git lfs config --validate")
+	}
+
+	problems := cfg.Validate()
+	for _, p := range problems {
+		if jsonOutput {
+			err := PrintEvent("problem", &configProblem{
+				Key:     p.Key,
+				Value:   p.Value,
+				Scope:   string(p.Origin.Scope),
+				Source:  p.Origin.Source,
+				Message: p.Message,
+			})
+			if err != nil {
+				ExitWithError(err)
+			}
+			continue
+		}
+		Print("warning: %s", p)
+	}
+
+	if jsonOutput {
+		if err := PrintResult(&configResult{Problems: len(problems)}); err != nil {
+			ExitWithError(err)
+		}
+	} else if len(problems) == 0 {
+		Print("config: no problems found")
+	} else {
+		Print("config: %d problem(s) found", len(problems))
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	RegisterCommand("config", configCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVar(&configValidateArg, "validate", false, "Check the Git LFS settings for problems")
+	})
+}
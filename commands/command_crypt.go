@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// cryptExtensionName is the name of the pointer extension which
+	// encrypts objects, recorded in pointers as "ext-<priority>-crypt".
+	cryptExtensionName = "crypt"
+)
+
+var (
+	cryptPriority int
+)
+
+func cryptCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	ext, ok := cfg.Extensions()[cryptExtensionName]
+	if ok {
+		Print("Extension: %s (priority %d)", ext.Name, ext.Priority)
+	} else {
+		Print("Extension: not installed; run `git lfs crypt install`")
+	}
+
+	if _, err := lfs.LoadCryptKey(cfg); err != nil {
+		Print("Key: %s", err)
+	} else {
+		Print("Key: OK")
+	}
+}
+
+func cryptCleanCommand(cmd *cobra.Command, args []string) {
+	requireStdin("This command should be run by the Git LFS clean filter")
+	setupRepository()
+
+	key, err := lfs.LoadCryptKey(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := key.Encrypt(cfg, w, os.Stdin); err != nil {
+		ExitWithError(err)
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func cryptSmudgeCommand(cmd *cobra.Command, args []string) {
+	requireStdin("This command should be run by the Git LFS smudge filter")
+	setupRepository()
+
+	key, err := lfs.LoadCryptKey(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := key.Decrypt(w, bufio.NewReader(os.Stdin)); err != nil {
+		ExitWithError(err)
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func cryptInstallCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	prefix := "lfs.extension." + cryptExtensionName
+	for key, value := range map[string]string{
+		prefix + ".clean":    "git-lfs crypt clean %f",
+		prefix + ".smudge":   "git-lfs crypt smudge %f",
+		prefix + ".priority": strconv.Itoa(cryptPriority),
+	} {
+		if _, err := cfg.SetGitLocalKey(key, value); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	Print("Git LFS crypt extension installed.")
+	if _, err := lfs.LoadCryptKey(cfg); err != nil {
+		Print("warning: %s", err)
+	}
+}
+
+func cryptGenerateKeyCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Exit("Usage: git lfs crypt generate-key <path>")
+	}
+
+	path, err := tools.ExpandPath(args[0], false)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		Exit("%s already exists; not overwriting it", path)
+	}
+
+	secret, err := lfs.GenerateCryptKey()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(secret+"\n"), 0600); err != nil {
+		ExitWithError(err)
+	}
+	Print("Wrote a new key to %s", path)
+}
+
+func init() {
+	RegisterCommand("crypt", cryptCommand, func(cmd *cobra.Command) {
+		install := NewCommand("install", cryptInstallCommand)
+		install.Flags().IntVarP(&cryptPriority, "priority", "p", 0, "")
+
+		cmd.AddCommand(
+			NewCommand("clean", cryptCleanCommand),
+			NewCommand("smudge", cryptSmudgeCommand),
+			NewCommand("generate-key", cryptGenerateKeyCommand),
+			install,
+		)
+	})
+}
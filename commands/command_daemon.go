@@ -0,0 +1,458 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tracelog"
+	"github.com/spf13/cobra"
+)
+
+// JSON-RPC 2.0 error codes returned by "git lfs daemon".
+const (
+	daemonParseError     = -32700
+	daemonInvalidRequest = -32600
+	daemonMethodNotFound = -32601
+	daemonInvalidParams  = -32602
+	// daemonOperationFailed is the code of an operation which was run,
+	// but failed.
+	daemonOperationFailed = -32000
+)
+
+// daemonNullID is the ID of the response to a request which could not be read.
+var daemonNullID = json.RawMessage("null")
+
+// daemonProgressInterval is how often the progress of a running operation is
+// checked for new events.
+const daemonProgressInterval = 50 * time.Millisecond
+
+// daemonRequest is a JSON-RPC 2.0 request, or a notification if it has no ID.
+type daemonRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// daemonParams are the parameters of every method, each of which uses only
+// some of them.
+type daemonParams struct {
+	Remote  string   `json:"remote"`
+	Refs    []string `json:"refs"`
+	All     bool     `json:"all"`
+	Include string   `json:"include"`
+	Exclude string   `json:"exclude"`
+	Path    string   `json:"path"`
+	ID      string   `json:"id"`
+	Force   bool     `json:"force"`
+	Local   bool     `json:"local"`
+	Verify  bool     `json:"verify"`
+}
+
+type daemonResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *daemonError     `json:"error,omitempty"`
+}
+
+type daemonError struct {
+	Code    int              `json:"code"`
+	Message string           `json:"message"`
+	Data    *daemonErrorData `json:"data,omitempty"`
+}
+
+// daemonErrorData describes why an operation failed.
+type daemonErrorData struct {
+	// ExitCode is the exit status the operation would have had as a
+	// command.
+	ExitCode int `json:"exit_code"`
+	// Code is the class of the failure, as in the "code" of errors written
+	// with --json, if it has one.
+	Code string `json:"code,omitempty"`
+}
+
+// daemonNotification is a JSON-RPC 2.0 notification sent to the client.
+type daemonNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// daemonProgress is the parameters of a "progress" notification, which gives
+// an event in the progress of the request with the given ID, in the format of
+// GIT_LFS_PROGRESS_JSON.
+type daemonProgress struct {
+	ID       json.RawMessage `json:"id"`
+	Progress json.RawMessage `json:"progress"`
+}
+
+// daemonMethods maps each method to a function returning the arguments of the
+// Git LFS command which performs it.
+var daemonMethods = map[string]func(p *daemonParams) ([]string, error){
+	"status": func(p *daemonParams) ([]string, error) {
+		return []string{"status", "--json"}, nil
+	},
+	"fetch": func(p *daemonParams) ([]string, error) {
+		args := []string{"fetch"}
+		if p.All {
+			args = append(args, "--all")
+		}
+		if len(p.Include) > 0 {
+			args = append(args, "--include", p.Include)
+		}
+		if len(p.Exclude) > 0 {
+			args = append(args, "--exclude", p.Exclude)
+		}
+		remote := p.Remote
+		if len(remote) == 0 && len(p.Refs) > 0 {
+			remote = cfg.Remote()
+		}
+		if len(remote) == 0 {
+			return args, nil
+		}
+		// The remote and refs are given after "--", so that
+		// they are never taken as options.
+		args = append(args, "--", remote)
+		return append(args, p.Refs...), nil
+	},
+	"lock": func(p *daemonParams) ([]string, error) {
+		if len(p.Path) == 0 {
+			return nil, errors.New("missing path")
+		}
+		return append(daemonLockArgs("lock", p), "--", p.Path), nil
+	},
+	"unlock": func(p *daemonParams) ([]string, error) {
+		args := daemonLockArgs("unlock", p)
+		if p.Force {
+			args = append(args, "--force")
+		}
+		if len(p.ID) > 0 {
+			return append(args, "--id", p.ID), nil
+		}
+		if len(p.Path) == 0 {
+			return nil, errors.New("missing path or id")
+		}
+		return append(args, "--", p.Path), nil
+	},
+	"locks": func(p *daemonParams) ([]string, error) {
+		args := daemonLockArgs("locks", p)
+		if len(p.Path) > 0 {
+			args = append(args, "--path", p.Path)
+		}
+		if len(p.ID) > 0 {
+			args = append(args, "--id", p.ID)
+		}
+		if p.Local {
+			args = append(args, "--local")
+		}
+		if p.Verify {
+			args = append(args, "--verify")
+		}
+		return args, nil
+	},
+}
+
+func daemonLockArgs(command string, p *daemonParams) []string {
+	args := []string{command, "--json"}
+	if len(p.Remote) > 0 {
+		args = append(args, "--remote", p.Remote)
+	}
+	return args
+}
+
+// daemonServer reads requests from its input and writes responses and
+// notifications to its output.  Requests are performed concurrently, each by
+// running Git LFS as a separate process, so that the daemon is unaffected by
+// the failure of any one of them.
+type daemonServer struct {
+	executable string
+	out        io.Writer
+	outMu      sync.Mutex
+	wg         sync.WaitGroup
+}
+
+func daemonCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	executable, err := os.Executable()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, "unable to find git-lfs"))
+	}
+
+	s := &daemonServer{executable: executable, out: os.Stdout}
+	if err := s.serve(os.Stdin); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// serve handles each request read from r, one per line, until r is closed or a
+// "shutdown" request is read, and then waits for those which are running to
+// finish.
+func (s *daemonServer) serve(r io.Reader) error {
+	defer s.wg.Wait()
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 && !s.handle(line) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading request")
+		}
+	}
+}
+
+// handle starts performing the request in line, and returns whether more
+// requests should be read.
+func (s *daemonServer) handle(line []byte) bool {
+	var req daemonRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.respondError(daemonNullID, daemonParseError, err.Error(), nil)
+		return true
+	}
+	if req.JSONRPC != "2.0" || len(req.Method) == 0 {
+		id := req.ID
+		if len(id) == 0 {
+			id = daemonNullID
+		}
+		s.respondError(id, daemonInvalidRequest, "invalid request", nil)
+		return true
+	}
+
+	if req.Method == "shutdown" {
+		s.wg.Wait()
+		s.respond(req.ID, json.RawMessage("null"))
+		return false
+	}
+
+	argsFor, ok := daemonMethods[req.Method]
+	if !ok {
+		s.respondError(req.ID, daemonMethodNotFound, fmt.Sprintf("unknown method %q", req.Method), nil)
+		return true
+	}
+
+	var params daemonParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.respondError(req.ID, daemonInvalidParams, err.Error(), nil)
+			return true
+		}
+	}
+	args, err := argsFor(&params)
+	if err != nil {
+		s.respondError(req.ID, daemonInvalidParams, err.Error(), nil)
+		return true
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(req.ID, args)
+	}()
+	return true
+}
+
+// run runs Git LFS with the given arguments, sending "progress" notifications
+// as it reports its progress, and responds with the JSON it writes, or with its
+// error.
+func (s *daemonServer) run(id json.RawMessage, args []string) {
+	tracelog.Printf("daemon: running %v", args)
+
+	progress, err := ioutil.TempFile(cfg.TempDir(), "daemon-progress")
+	if err != nil {
+		s.respondError(id, daemonOperationFailed, err.Error(), nil)
+		return
+	}
+	defer os.Remove(progress.Name())
+	defer progress.Close()
+
+	progressPath, err := filepath.Abs(progress.Name())
+	if err != nil {
+		s.respondError(id, daemonOperationFailed, err.Error(), nil)
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.executable, append([]string{"--noninteractive"}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(daemonEnviron(), "GIT_LFS_PROGRESS_JSON="+progressPath)
+
+	// Notifications are given no response, so nor is their progress
+	// reported.
+	done := make(chan struct{})
+	followed := make(chan struct{})
+	go func() {
+		defer close(followed)
+		if len(id) > 0 {
+			s.followProgress(id, progress, done)
+		}
+	}()
+
+	err = cmd.Run()
+	close(done)
+	<-followed
+
+	if err != nil {
+		exitCode := exitCodeError
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if ws, ok := exitErr.ProcessState.Sys().(syscall.WaitStatus); ok {
+				exitCode = ws.ExitStatus()
+			}
+		}
+
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) == 0 {
+			msg = err.Error()
+		}
+		s.respondError(id, daemonOperationFailed, msg, &daemonErrorData{
+			ExitCode: exitCode,
+			Code:     string(errorCodeForExitCode(exitCode)),
+		})
+		return
+	}
+
+	result := json.RawMessage("null")
+	if args[0] != "fetch" {
+		data, err := daemonResult(stdout.Bytes())
+		if err != nil {
+			s.respondError(id, daemonOperationFailed, fmt.Sprintf("invalid output from git lfs %s", args[0]), nil)
+			return
+		}
+		if len(data) > 0 {
+			result = data
+		}
+	}
+	s.respond(id, result)
+}
+
+// daemonResult returns the data of the "result" message among the JSON
+// messages, one per line, written by a command run with --json, or nil if
+// there is none.
+func daemonResult(out []byte) (json.RawMessage, error) {
+	var result json.RawMessage
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, err
+		}
+		if msg.Type == "result" {
+			result = msg.Data
+		}
+	}
+	return result, nil
+}
+
+// followProgress sends each event appended to f as a "progress" notification
+// for the request with the given ID, until done is closed and every event has
+// been sent.
+func (s *daemonServer) followProgress(id json.RawMessage, f *os.File, done <-chan struct{}) {
+	br := bufio.NewReader(f)
+	var partial []byte
+	exited := false
+	for {
+		line, err := br.ReadBytes('\n')
+		partial = append(partial, line...)
+		if err == nil {
+			if event := bytes.TrimSpace(partial); json.Valid(event) {
+				s.notify("progress", &daemonProgress{ID: id, Progress: json.RawMessage(event)})
+			}
+			partial = nil
+			continue
+		}
+		if err != io.EOF {
+			return
+		}
+
+		select {
+		case <-done:
+			// Once the process has exited, nothing more is
+			// written, so stop after reading what remains.
+			if exited {
+				return
+			}
+			exited = true
+		case <-time.After(daemonProgressInterval):
+		}
+	}
+}
+
+// daemonEnviron returns the environment of the commands run by the daemon,
+// without any progress stream of its own.
+func daemonEnviron() []string {
+	env := os.Environ()
+	filtered := env[:0]
+	for _, e := range env {
+		if !strings.HasPrefix(e, "GIT_LFS_PROGRESS_JSON=") {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// respond sends the result of the request with the given ID.  Requests without
+// an ID are notifications, to which no response is sent.
+func (s *daemonServer) respond(id json.RawMessage, result json.RawMessage) {
+	if len(id) == 0 {
+		return
+	}
+	s.write(&daemonResponse{JSONRPC: "2.0", ID: id, Result: &result})
+}
+
+// respondError sends an error in response to the request with the given ID,
+// unless it is a notification.
+func (s *daemonServer) respondError(id json.RawMessage, code int, msg string, data *daemonErrorData) {
+	if len(id) == 0 {
+		return
+	}
+	s.write(&daemonResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &daemonError{Code: code, Message: msg, Data: data},
+	})
+}
+
+func (s *daemonServer) notify(method string, params interface{}) {
+	s.write(&daemonNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *daemonServer) write(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		// Every message can be marshaled, so this is a bug.
+		panic(fmt.Sprintf("unable to marshal daemon message: %s", err))
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.out.Write(append(data, '\n'))
+}
+
+func init() {
+	RegisterCommand("daemon", daemonCommand, nil)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tools"
@@ -110,8 +111,18 @@ func dedup(p *lfs.WrappedPointer) (success bool, err error) {
 		return false, err
 	}
 
-	// Do clone
-	srcFile := cfg.Filesystem().ObjectPathname(p.Oid)
+	// Do clone. Only objects in the local object store on disk can be
+	// cloned.
+	store, ok := cfg.ObjectStore().(*fs.Filesystem)
+	if !ok {
+		return false, errors.New("mediafile is not stored on disk")
+	}
+	srcFile := store.ObjectPathname(p.Oid)
+	if _, compressed, err := fs.ObjectFileSize(srcFile); err != nil {
+		return false, err
+	} else if compressed {
+		return false, errors.New("mediafile is compressed")
+	}
 	dstFile := filepath.Join(cfg.LocalWorkingDir(), p.Name)
 
 	// Clone the file. This overwrites the destination if it exists.
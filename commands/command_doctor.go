@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/gitattr"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorOfflineArg bool
+)
+
+// doctorMinFreeSpace is the amount of free disk space below which "git lfs
+// doctor" warns that downloads may fail.
+const doctorMinFreeSpace = 1024 * 1024 * 1024
+
+// doctorProbeOid is the OID of the empty object, which is asked about to check
+// that the endpoint can be reached.
+const doctorProbeOid = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// doctor collects the results of the checks made by "git lfs doctor".
+type doctor struct {
+	warnings int
+	errors   int
+}
+
+// doctorCheck is the data of a "check" event written by "git lfs doctor
+// --json".
+type doctorCheck struct {
+	// Status is "ok", "warning" or "error".
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// doctorResult is the result written by "git lfs doctor --json".
+type doctorResult struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+func (d *doctor) ok(format string, args ...interface{}) {
+	d.report("ok", "", format, args...)
+}
+
+// warn reports a problem which may cause Git LFS to misbehave, and how to fix
+// it.
+func (d *doctor) warn(fix, format string, args ...interface{}) {
+	d.warnings++
+	d.report("warning", fix, format, args...)
+}
+
+// fail reports a problem which prevents Git LFS from working, and how to fix
+// it.
+func (d *doctor) fail(fix, format string, args ...interface{}) {
+	d.errors++
+	d.report("error", fix, format, args...)
+}
+
+func (d *doctor) report(status, fix, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if jsonOutput {
+		if err := PrintEvent("check", &doctorCheck{Status: status, Message: message, Fix: fix}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	Print("%s: %s", status, message)
+	if len(fix) > 0 {
+		Print("  fix: %s", fix)
+	}
+}
+
+// doctorCommand checks the Git LFS installation and the current repository for
+// common problems, and describes how to fix those it finds.
+func doctorCommand(cmd *cobra.Command, args []string) {
+	d := &doctor{}
+
+	d.checkBinary()
+	d.checkFilters()
+
+	if cfg.InRepo() {
+		setupRepository()
+
+		d.checkHooks()
+		d.checkAttributes()
+		d.checkCaseCollisions()
+		d.checkDiskSpace()
+		if !doctorOfflineArg {
+			d.checkEndpoint()
+		}
+	}
+
+	if jsonOutput {
+		if err := PrintResult(&doctorResult{Errors: d.errors, Warnings: d.warnings}); err != nil {
+			ExitWithError(err)
+		}
+	} else if d.errors+d.warnings == 0 {
+		Print("doctor: no problems found")
+	} else {
+		Print("doctor: %d error(s), %d warning(s)", d.errors, d.warnings)
+	}
+	if d.errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkBinary checks that Git can find git-lfs to run the filters.
+func (d *doctor) checkBinary() {
+	path, err := subprocess.LookPath("git-lfs")
+	if err != nil {
+		d.fail("add the directory containing git-lfs to your PATH", "git-lfs was not found on your PATH")
+		return
+	}
+	d.ok("git-lfs found at %s", path)
+}
+
+// checkFilters checks that the Git LFS filters are configured.
+func (d *doctor) checkFilters() {
+	var missing []string
+	found := true
+	for _, key := range []string{"filter.lfs.clean", "filter.lfs.smudge", "filter.lfs.process"} {
+		value, ok := cfg.Git.Get(key)
+		if !ok || len(value) == 0 {
+			missing = append(missing, key)
+			found = false
+		} else if !strings.Contains(value, "git-lfs") {
+			d.fail("run `git lfs install --force`", "%s is set to %q, which does not run Git LFS", key, value)
+			found = false
+		}
+	}
+	if len(missing) > 0 {
+		d.fail("run `git lfs install`", "the Git LFS filters are not configured (missing %s)", strings.Join(missing, ", "))
+	}
+	if !found {
+		return
+	}
+
+	if required, _ := cfg.Git.Get("filter.lfs.required"); required != "true" {
+		d.warn("run `git lfs install`", "filter.lfs.required is not true, so errors in the filters are ignored")
+	}
+
+	process, _ := cfg.Git.Get("filter.lfs.process")
+	if strings.Contains(process, "--skip") {
+		d.warn("run `git lfs pull` to download them, or `git lfs install` to stop skipping them",
+			"Git LFS files are not downloaded when they are checked out")
+		return
+	}
+	d.ok("Git LFS filters are configured")
+}
+
+// checkHooks checks that the Git LFS hooks are installed, and that any other
+// hooks in their place also run Git LFS.
+func (d *doctor) checkHooks() {
+	hookDir, err := cfg.HookDir()
+	if err != nil {
+		d.fail("", "unable to find the hooks directory: %v", err)
+		return
+	}
+
+	var missing []string
+	installed := true
+	for _, h := range lfs.LoadHooks(hookDir, cfg) {
+		if !h.Exists() {
+			missing = append(missing, h.Type)
+			installed = false
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(h.Path())
+		if err != nil {
+			d.warn("", "unable to read the %s hook: %v", h.Type, err)
+			installed = false
+			continue
+		}
+		if !strings.Contains(string(contents), "git lfs "+h.Type) && !strings.Contains(string(contents), "git-lfs "+h.Type) {
+			d.warn(fmt.Sprintf("add `git lfs %s \"$@\"` to %s", h.Type, h.Path()),
+				"the %s hook does not run Git LFS", h.Type)
+			installed = false
+		}
+	}
+	if len(missing) > 0 {
+		d.warn("run `git lfs install`", "these Git LFS hooks are not installed: %s", strings.Join(missing, ", "))
+	}
+	if installed {
+		d.ok("Git LFS hooks are installed")
+	}
+}
+
+// checkAttributes checks for patterns which are tracked by Git LFS in one
+// attributes file, but given a different filter in another.
+func (d *doctor) checkAttributes() {
+	tracked := make(map[string]string)
+	other := make(map[string]string)
+	for _, p := range git.GetAttributePaths(gitattr.NewMacroProcessor(), cfg.LocalWorkingDir(), cfg.LocalGitDir()) {
+		if p.Tracked {
+			tracked[p.Path] = p.Source.Path
+		} else if p.Filter {
+			other[p.Path] = p.Source.Path
+		}
+	}
+
+	var conflicts []string
+	for pattern := range tracked {
+		if _, ok := other[pattern]; ok {
+			conflicts = append(conflicts, pattern)
+		}
+	}
+	sort.Strings(conflicts)
+
+	for _, pattern := range conflicts {
+		d.warn("remove the pattern from one of the files, or run `git lfs track` or `git lfs untrack` on it",
+			"%q is tracked by Git LFS in %s, but given another filter in %s", pattern, tracked[pattern], other[pattern])
+	}
+	if len(conflicts) == 0 {
+		d.ok("no conflicting Git LFS attributes")
+	}
+}
+
+// checkCaseCollisions checks for files in the index whose paths differ only in
+// case, which cannot both be checked out on case-insensitive file systems.
+func (d *doctor) checkCaseCollisions() {
+	if len(cfg.LocalWorkingDir()) == 0 {
+		return
+	}
+
+	files, err := git.NewLsFiles(cfg.LocalWorkingDir(), false, false)
+	if err != nil {
+		d.warn("", "unable to list files in the index: %v", err)
+		return
+	}
+
+	byLower := make(map[string][]string)
+	for path := range files.Files {
+		lower := strings.ToLower(path)
+		byLower[lower] = append(byLower[lower], path)
+	}
+
+	var collisions []string
+	for _, paths := range byLower {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			collisions = append(collisions, strings.Join(paths, ", "))
+		}
+	}
+	sort.Strings(collisions)
+
+	for _, c := range collisions {
+		d.warn("rename all but one of them with `git mv`",
+			"these paths differ only in case, and cannot all be checked out on case-insensitive file systems: %s", c)
+	}
+	if len(collisions) == 0 {
+		d.ok("no paths differ only in case")
+	}
+}
+
+// checkDiskSpace checks that there is space to download objects to the local
+// object store.
+func (d *doctor) checkDiskSpace() {
+	dir := cfg.LFSStorageDir()
+	if err := tools.MkdirAll(dir, cfg); err != nil {
+		d.warn("", "unable to create %s: %v", dir, err)
+		return
+	}
+
+	available, err := tools.AvailableDiskSpace(dir)
+	if err != nil {
+		d.warn("", "unable to determine the free disk space in %s: %v", dir, err)
+		return
+	}
+	if available < doctorMinFreeSpace {
+		d.warn("free up disk space, or remove unneeded objects with `git lfs prune`",
+			"only %s is free in %s", humanize.FormatBytes(available), dir)
+		return
+	}
+	d.ok("%s is free in %s", humanize.FormatBytes(available), dir)
+}
+
+// checkEndpoint checks that the Git LFS endpoint of the default remote can be
+// reached, by asking it about an object.
+func (d *doctor) checkEndpoint() {
+	remote := cfg.Remote()
+	endpoint := getAPIClient().Endpoints.Endpoint("download", remote)
+	if len(endpoint.Url) == 0 {
+		d.warn("add a remote with `git remote add`, or set lfs.url", "no Git LFS endpoint is configured for %q", remote)
+		return
+	}
+
+	access := getAPIClient().Endpoints.AccessFor(endpoint.Url)
+	_, err := tq.Batch(getTransferManifest(), tq.Download, remote, currentRemoteRef(), []*tq.Transfer{
+		&tq.Transfer{Oid: doctorProbeOid, Size: 0},
+	})
+	if err == nil {
+		d.ok("reached %s (auth=%s)", endpoint.Url, access.Mode())
+		return
+	}
+
+	if errors.IsAuthError(err) {
+		d.fail("check the credentials stored by your credential helper, and that you have access to the repository",
+			"authentication to %s failed", endpoint.Url)
+		return
+	}
+	d.fail("check the URL shown by `git lfs env` and any proxy settings",
+		"unable to reach %s: %v", endpoint.Url, errors.Cause(err))
+}
+
+func init() {
+	RegisterCommand("doctor", doctorCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVar(&doctorOfflineArg, "offline", false, "Skip the checks which contact the server")
+	})
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+func duCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	retained, err := retainedObjects(lfs.NewFetchPruneConfig(cfg.Git), true)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	stats, err := cfg.Filesystem().Stats(retained.Contains)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if jsonOutput {
+		err := PrintResult(&duResult{
+			Objects:   newDuStats(stats.Objects),
+			Retained:  newDuStats(stats.Referenced),
+			Prunable:  newDuStats(stats.Unreferenced),
+			Temporary: newDuStats(stats.Temporary),
+		})
+		if err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	Print("objects:   %s", formatObjectStats(stats.Objects))
+	Print("retained:  %s", formatObjectStats(stats.Referenced))
+	Print("prunable:  %s", formatObjectStats(stats.Unreferenced))
+	Print("temporary: %s", formatObjectStats(stats.Temporary))
+}
+
+// duResult is the result written by "git lfs du --json".
+type duResult struct {
+	Objects   duStats `json:"objects"`
+	Retained  duStats `json:"retained"`
+	Prunable  duStats `json:"prunable"`
+	Temporary duStats `json:"temporary"`
+}
+
+type duStats struct {
+	Count int   `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+func newDuStats(s fs.ObjectStats) duStats {
+	return duStats{Count: s.Count, Size: s.Size}
+}
+
+func formatObjectStats(s fs.ObjectStats) string {
+	return fmt.Sprintf("%d (%s)", s.Count, humanize.FormatBytes(uint64(s.Size)))
+}
+
+func init() {
+	RegisterCommand("du", duCommand, nil)
+}
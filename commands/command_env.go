@@ -1,20 +1,35 @@
 package commands
 
 import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/lfshttp"
 	"github.com/spf13/cobra"
 )
 
 func envCommand(cmd *cobra.Command, args []string) {
-	config.ShowConfigWarnings = true
+	for _, warning := range cfg.ConfigWarnings() {
+		fmt.Fprintln(os.Stderr, warning)
+	}
 
 	gitV, err := git.Version()
 	if err != nil {
 		gitV = "Error getting git version: " + err.Error()
 	}
 
+	if jsonOutput {
+		envPrintJSON(gitV)
+		return
+	}
+
 	Print(config.VersionDesc)
 	Print(gitV)
 	Print("")
@@ -44,6 +59,13 @@ func envCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	limits := loadServerLimits()
+	for _, host := range sortedServerLimitHosts(limits) {
+		for _, l := range limits[host].Limits {
+			Print("Limit (%s) %s=%s (as of %s)", host, l.Name, l, limits[host].Updated.Format(time.RFC3339))
+		}
+	}
+
 	for _, env := range lfs.Environ(cfg, getTransferManifest(), oldEnv) {
 		Print(env)
 	}
@@ -54,6 +76,176 @@ func envCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+type envJSONEndpoint struct {
+	Remote  string `json:"remote"`
+	Default bool   `json:"default"`
+	URL     string `json:"url"`
+	Access  string `json:"access"`
+	SSH     string `json:"ssh,omitempty"`
+	Proxy   string `json:"proxy,omitempty"`
+}
+
+type envJSONConfigValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Source is the origin of the value as reported by Git, such as
+	// "file:.git/config", or ".lfsconfig" if it was read from there.
+	Source string `json:"source,omitempty"`
+	// Scope is the configuration layer of the value, such as "global" or
+	// "lfsconfig"; see git-lfs-config(5).
+	Scope string `json:"scope,omitempty"`
+}
+
+type envJSONOutput struct {
+	Version    string                   `json:"version"`
+	GitVersion string                   `json:"git_version"`
+	Endpoints  []envJSONEndpoint        `json:"endpoints"`
+	Access     map[string]string        `json:"access"`
+	Proxy      map[string]string        `json:"proxy"`
+	Transfers  map[string][]string      `json:"transfers"`
+	Paths      map[string]interface{}   `json:"paths"`
+	Config     []envJSONConfigValue     `json:"config"`
+	Limits     map[string]*serverLimits `json:"limits,omitempty"`
+	Env        map[string]string        `json:"env"`
+}
+
+// envPrintJSON prints the Git LFS environment as a single JSON object.
+func envPrintJSON(gitV string) {
+	apiClient := getAPIClient()
+	manifest := getTransferManifest()
+	download := apiClient.Endpoints.AccessFor(apiClient.Endpoints.Endpoint("download", cfg.Remote()).Url)
+	upload := apiClient.Endpoints.AccessFor(apiClient.Endpoints.Endpoint("upload", cfg.PushRemote()).Url)
+
+	out := envJSONOutput{
+		Version:    config.VersionDesc,
+		GitVersion: gitV,
+		Endpoints:  make([]envJSONEndpoint, 0),
+		Access: map[string]string{
+			"download": string(download.Mode()),
+			"upload":   string(upload.Mode()),
+		},
+		Proxy: make(map[string]string),
+		Transfers: map[string][]string{
+			"download": manifest.GetDownloadAdapterNames(),
+			"upload":   manifest.GetUploadAdapterNames(),
+		},
+		Paths: map[string]interface{}{
+			"local_working_dir":     cfg.LocalWorkingDir(),
+			"local_git_dir":         cfg.LocalGitDir(),
+			"local_git_storage_dir": cfg.LocalGitStorageDir(),
+			"local_media_dir":       cfg.LFSObjectDir(),
+			"local_reference_dirs":  append([]string{}, cfg.LocalReferenceDirs()...),
+			"temp_dir":              cfg.TempDir(),
+			"lfs_storage_dir":       cfg.LFSStorageDir(),
+		},
+		Config: make([]envJSONConfigValue, 0),
+		Limits: loadServerLimits(),
+		Env:    make(map[string]string),
+	}
+	sort.Strings(out.Transfers["download"])
+	sort.Strings(out.Transfers["upload"])
+
+	defaultRemote := ""
+	if cfg.IsDefaultRemote() {
+		defaultRemote = cfg.Remote()
+	}
+	remotes := append([]string{}, cfg.Remotes()...)
+	sort.Strings(remotes)
+	for _, remote := range remotes {
+		endpoint := apiClient.Endpoints.Endpoint("download", remote)
+		access := apiClient.Endpoints.AccessFor(endpoint.Url)
+		if remote == defaultRemote && len(endpoint.Url) == 0 {
+			continue
+		}
+		out.Endpoints = append(out.Endpoints, envJSONEndpoint{
+			Remote:  remote,
+			Default: remote == defaultRemote,
+			URL:     lfs.RedactURL(endpoint.Url),
+			Access:  string(access.Mode()),
+			SSH:     envSSHMetadata(endpoint),
+			Proxy:   envProxyFor(endpoint.Url),
+		})
+	}
+
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+		if val, ok := cfg.Os.Get(key); ok {
+			out.Proxy[key] = lfs.RedactURL(val)
+		}
+	}
+
+	keys := make([]string, 0)
+	for key := range cfg.Git.All() {
+		if envJSONConfigKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, _ := cfg.Git.Get(key)
+		origin, _ := cfg.Origin(key)
+		source := origin.Source
+		if origin.Scope == config.ScopeLfsconfig {
+			source = ".lfsconfig"
+		}
+		out.Config = append(out.Config, envJSONConfigValue{
+			Key: key, Value: lfs.RedactURL(value), Source: source, Scope: string(origin.Scope),
+		})
+	}
+
+	for _, e := range os.Environ() {
+		pieces := strings.SplitN(e, "=", 2)
+		if !strings.HasPrefix(pieces[0], "GIT_") || len(pieces) < 2 {
+			continue
+		}
+		val := pieces[1]
+		if old, ok := oldEnv[pieces[0]]; ok {
+			val = old
+		}
+		out.Env[pieces[0]] = lfs.RedactEnv(pieces[0], val)
+	}
+
+	if err := PrintResult(&out); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func envSSHMetadata(endpoint lfshttp.Endpoint) string {
+	if len(endpoint.SSHMetadata.UserAndHost) == 0 {
+		return ""
+	}
+	return endpoint.SSHMetadata.UserAndHost + ":" + endpoint.SSHMetadata.Path
+}
+
+// envProxyFor returns the proxy which would be used to reach the given
+// endpoint URL, or the empty string if none would be.
+func envProxyFor(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || len(u.Host) == 0 {
+		return ""
+	}
+	proxy, err := getAPIClient().ProxyFor(u)
+	if err != nil || proxy == nil {
+		return ""
+	}
+	return lfs.RedactURL(proxy.String())
+}
+
+// envJSONConfigKey returns whether the git config key affects Git LFS and
+// should be reported by "git lfs env --json".
+func envJSONConfigKey(key string) bool {
+	parts := strings.Split(key, ".")
+	last := parts[len(parts)-1]
+	switch {
+	case parts[0] == "lfs", strings.HasPrefix(key, "filter.lfs."):
+		return true
+	case parts[0] == "remote" && (last == "lfsurl" || last == "lfspushurl"):
+		return true
+	case parts[0] == "http" && (last == "proxy" || last == "sslverify" || last == "sslcainfo"):
+		return true
+	}
+	return false
+}
+
 func init() {
 	RegisterCommand("env", envCommand, nil)
 }
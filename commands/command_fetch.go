@@ -2,15 +2,17 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/metrics"
 	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +20,18 @@ var (
 	fetchRecentArg bool
 	fetchAllArg    bool
 	fetchPruneArg  bool
+	fetchDryRunArg bool
+
+	// fetchRecentDaysArg, fetchRecentRefsDaysArg and fetchIncludeTagsArg
+	// override lfs.fetchrecentcommitsdays, lfs.fetchrecentrefsdays and
+	// lfs.fetchrecenttags when given.
+	fetchRecentDaysArg     int
+	fetchRecentRefsDaysArg int
+	fetchIncludeTagsArg    bool
+
+	// fetchDryRunReport lists the objects which would be downloaded, with
+	// --dry-run.
+	fetchDryRunReport *dryRunReport
 )
 
 func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
@@ -46,11 +60,7 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 	}
 
 	if len(args) > 1 {
-		resolvedrefs, err := git.ResolveRefs(args[1:])
-		if err != nil {
-			Panic(err, "Invalid ref argument: %v", args[1:])
-		}
-		refs = resolvedrefs
+		refs = resolveCommitRefs(args[1:])
 	} else if !fetchAllArg {
 		ref, err := git.CurrentRef()
 		if err != nil {
@@ -59,12 +69,17 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		refs = []*git.Ref{ref}
 	}
 
+	if fetchDryRunArg {
+		fetchDryRunReport = newDryRunReport("fetch", tq.Download)
+	}
+
 	success := true
 	gitscanner := lfs.NewGitScanner(cfg, nil)
 	defer gitscanner.Close()
 
 	include, exclude := getIncludeExcludeArgs(cmd)
 	fetchPruneCfg := lfs.NewFetchPruneConfig(cfg.Git)
+	applyFetchRecentArgs(cmd, &fetchPruneCfg)
 
 	if fetchAllArg {
 		if fetchRecentArg {
@@ -105,14 +120,65 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 
 	if fetchPruneArg {
 		verify := fetchPruneCfg.PruneVerifyRemoteAlways
-		// no dry-run or verbose options in fetch, assume false
-		prune(fetchPruneCfg, verify, false, false)
+		// no verbose option in fetch, assume false
+		prune(fetchPruneCfg, verify, fetchDryRunArg, false)
+	}
+
+	if fetchDryRunReport != nil {
+		fetchDryRunReport.Finish()
+	} else {
+		evictCache()
 	}
 
 	if !success {
 		c := getAPIClient()
 		e := c.Endpoints.Endpoint("download", cfg.Remote())
-		Exit("error: failed to fetch some objects from '%s'", e.Url)
+		exitTransferFailed("error: failed to fetch some objects from '%s'", e.Url)
+	}
+}
+
+// resolveCommitRefs resolves the given refs, or IDs of commits, to the commits
+// they name, exiting if any names no commit in the repository.  Objects can
+// only be fetched for commits which are present locally, so one which is not
+// must first be fetched from the remote with "git fetch".
+func resolveCommitRefs(names []string) []*git.Ref {
+	refs := make([]*git.Ref, 0, len(names))
+	for _, name := range names {
+		ref, err := git.ResolveRef(name)
+		if err != nil {
+			Exit("Invalid ref argument: %q is not a commit in this repository", name)
+		}
+
+		sha, err := git.ResolveCommit(ref.Sha)
+		if err != nil {
+			Exit("Invalid ref argument: %q is not a commit in this repository", name)
+		}
+		ref.Sha = sha
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// applyFetchRecentArgs overrides the recency settings of the given config with
+// any given on the command line, each of which implies --recent.
+func applyFetchRecentArgs(cmd *cobra.Command, fetchPruneCfg *lfs.FetchPruneConfig) {
+	if cmd.Flag("recent-days").Changed {
+		if fetchRecentDaysArg < 0 {
+			Exit("Invalid --recent-days value %d: must not be negative", fetchRecentDaysArg)
+		}
+		fetchPruneCfg.FetchRecentCommitsDays = fetchRecentDaysArg
+		fetchRecentArg = true
+	}
+	if cmd.Flag("recent-refs-days").Changed {
+		if fetchRecentRefsDaysArg < 0 {
+			Exit("Invalid --recent-refs-days value %d: must not be negative", fetchRecentRefsDaysArg)
+		}
+		fetchPruneCfg.FetchRecentRefsDays = fetchRecentRefsDaysArg
+		fetchRecentArg = true
+	}
+	if cmd.Flag("include-tags").Changed {
+		fetchPruneCfg.FetchRecentTags = fetchIncludeTagsArg
+		fetchRecentArg = true
 	}
 }
 
@@ -176,7 +242,7 @@ func pointersToFetchForRefs(refs []string) ([]*lfs.WrappedPointer, error) {
 		}
 
 		numObjs++
-		task.Logf("fetch: %d object(s) found", numObjs)
+		task.Logf(tr.Tr.Get("fetch: %d object(s) found"), numObjs)
 		pointers = append(pointers, p)
 	})
 
@@ -236,7 +302,7 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 	if fetchconf.FetchRecentRefsDays > 0 {
 		Print("fetch: Fetching recent branches within %v days", fetchconf.FetchRecentRefsDays)
 		refsSince := time.Now().AddDate(0, 0, -fetchconf.FetchRecentRefsDays)
-		refs, err := git.RecentBranches(refsSince, fetchconf.FetchRecentRefsIncludeRemotes, cfg.Remote())
+		refs, err := git.RecentBranches(refsSince, fetchconf.FetchRecentRefsIncludeRemotes, cfg.Remote(), fetchconf.FetchRecentTags)
 		if err != nil {
 			Panic(err, "Could not scan for recent refs")
 		}
@@ -244,7 +310,7 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 			// Don't fetch for the same SHA twice
 			if prevRefName, ok := uniqueRefShas[ref.Sha]; ok {
 				if ref.Name != prevRefName {
-					tracerx.Printf("Skipping fetch for %v, already fetched via %v", ref.Name, prevRefName)
+					tracelog.Printf("Skipping fetch for %v, already fetched via %v", ref.Name, prevRefName)
 				}
 			} else {
 				uniqueRefShas[ref.Sha] = ref.Name
@@ -273,62 +339,18 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 	return ok
 }
 
-func fetchAll() bool {
-	pointers := scanAll()
-	Print("fetch: Fetching all references...")
-	return fetchAndReportToChan(pointers, nil, nil)
-}
-
-func scanAll() []*lfs.WrappedPointer {
-	// This could be a long process so use the chan version & report progress
-	task := tasklog.NewSimpleTask()
-	defer task.Complete()
-
-	logger := tasklog.NewLogger(OutputWriter,
-		tasklog.ForceProgress(cfg.ForceProgress()),
-	)
-	logger.Enqueue(task)
-	var numObjs int64
-
-	// use temp gitscanner to collect pointers
-	var pointers []*lfs.WrappedPointer
-	var multiErr error
-	tempgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
-		if err != nil {
-			if multiErr != nil {
-				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
-			} else {
-				multiErr = err
-			}
-			return
-		}
-
-		numObjs++
-		task.Logf("fetch: %d object(s) found", numObjs)
-		pointers = append(pointers, p)
-	})
-
-	if err := tempgitscanner.ScanAll(nil); err != nil {
-		Panic(err, "Could not scan for Git LFS files")
-	}
-
-	tempgitscanner.Close()
-
-	if multiErr != nil {
-		Panic(multiErr, "Could not scan for Git LFS files")
-	}
-
-	return pointers
-}
-
 // Fetch and report completion of each OID to a channel (optional, pass nil to skip)
 // Returns true if all completed with no errors, false if errors were written to stderr/log
 func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter, out chan<- *lfs.WrappedPointer) bool {
-	ready, pointers, meter := readyAndMissingPointers(allpointers, filter)
+	meter := newFetchMeter()
+	ready, pointers := readyAndMissingPointers(allpointers, filter, meter)
 	q := newDownloadQueue(
 		getTransferManifestOperationRemote("download", cfg.Remote()),
-		cfg.Remote(), tq.WithProgress(meter),
+		cfg.Remote(), tq.WithProgress(meter), tq.DryRun(fetchDryRunReport != nil),
 	)
+	if fetchDryRunReport != nil {
+		fetchDryRunReport.Watch(q)
+	}
 
 	if out != nil {
 		// If we already have it, or it won't be fetched
@@ -362,34 +384,36 @@ func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfil
 	}
 
 	for _, p := range pointers {
-		tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
+		tracelog.Printf("fetch %v [%v]", p.Name, p.Oid)
 
 		q.Add(downloadTransfer(p))
 	}
 
 	processQueue := time.Now()
 	q.Wait()
-	tracerx.PerformanceSince("process queue", processQueue)
+	meter.Finish()
+	tracelog.PerformanceSince("process queue", processQueue)
 
-	ok := true
-	for _, err := range q.Errors() {
-		ok = false
-		FullError(err)
-	}
-	return ok
+	return reportTransferErrors(q.Errors())
 }
 
-func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, []*lfs.WrappedPointer, *tq.Meter) {
-	logger := tasklog.NewLogger(os.Stdout,
+// newFetchMeter returns a meter for the objects to be fetched, which is shown
+// until it is finished.
+func newFetchMeter() *tq.Meter {
+	logger := tasklog.NewLogger(OutputWriter,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
-	meter := buildProgressMeter(false, tq.Download)
+	meter := buildProgressMeter(fetchDryRunReport != nil, tq.Download)
 	logger.Enqueue(meter)
+	return meter
+}
 
+// readyAndMissingPointers splits the given pointers into those whose objects
+// are present locally, and those which must be fetched, adding the latter to
+// the meter.
+func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter, meter *tq.Meter) ([]*lfs.WrappedPointer, []*lfs.WrappedPointer) {
 	seen := make(map[string]bool, len(allpointers))
-	missing := make([]*lfs.WrappedPointer, 0, len(allpointers))
-	ready := make([]*lfs.WrappedPointer, 0, len(allpointers))
-
+	unique := make([]*lfs.WrappedPointer, 0, len(allpointers))
 	for _, p := range allpointers {
 		// no need to download the same object multiple times
 		if seen[p.Oid] {
@@ -397,19 +421,38 @@ func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepath
 		}
 
 		seen[p.Oid] = true
+		unique = append(unique, p)
+	}
+
+	// Objects copied from reference or secondary storage are hashed to
+	// verify them, so several are checked at once.
+	exists := make([]bool, len(unique))
+	pool := tools.NewWorkerPool(cfg.HashWorkers())
+	for i, p := range unique {
+		i, p := i, p
+		pool.Go(func() {
+			lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+			exists[i] = cfg.LFSObjectExists(p.Oid, p.Size)
+		})
+	}
+	pool.Wait()
 
+	missing := make([]*lfs.WrappedPointer, 0, len(unique))
+	ready := make([]*lfs.WrappedPointer, 0, len(unique))
+	for i, p := range unique {
 		// no need to download objects that exist locally already
-		lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
-		if cfg.LFSObjectExists(p.Oid, p.Size) {
+		if exists[i] {
+			metrics.Count("objects.cache", 1, metrics.Tags{"result": "hit"})
 			ready = append(ready, p)
 			continue
 		}
 
+		metrics.Count("objects.cache", 1, metrics.Tags{"result": "miss"})
 		missing = append(missing, p)
 		meter.Add(p.Size)
 	}
 
-	return ready, missing, meter
+	return ready, missing
 }
 
 func init() {
@@ -419,5 +462,9 @@ func init() {
 		cmd.Flags().BoolVarP(&fetchRecentArg, "recent", "r", false, "Fetch recent refs & commits")
 		cmd.Flags().BoolVarP(&fetchAllArg, "all", "a", false, "Fetch all LFS files ever referenced")
 		cmd.Flags().BoolVarP(&fetchPruneArg, "prune", "p", false, "After fetching, prune old data")
+		cmd.Flags().BoolVarP(&fetchDryRunArg, "dry-run", "d", false, "List the objects which would be fetched, without fetching them")
+		cmd.Flags().IntVar(&fetchRecentDaysArg, "recent-days", 0, "Fetch changes within this many days of each recent ref; implies --recent")
+		cmd.Flags().IntVar(&fetchRecentRefsDaysArg, "recent-refs-days", 0, "Fetch refs with commits within this many days; implies --recent")
+		cmd.Flags().BoolVar(&fetchIncludeTagsArg, "include-tags", false, "Fetch recent tags as well as branches; implies --recent")
 	})
 }
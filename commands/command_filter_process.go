@@ -7,12 +7,15 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/pktline"
 	"github.com/spf13/cobra"
 )
@@ -28,14 +31,30 @@ const (
 	// `*git.PacketWriter`'s internal buffer when the filter protocol
 	// dictates the "smudge" command.
 	smudgeFilterBufferCapacity = pktline.MaxPacketLength
+
+	// configCheckInterval is how often the filter checks whether the Git
+	// configuration files have changed, so that settings such as
+	// lfs.fetchexclude take effect without restarting Git.
+	configCheckInterval = time.Second
 )
 
-// filterSmudgeSkip is a command-line flag owned by the `filter-process` command
-// dictating whether or not to skip the smudging process, leaving pointers as-is
-// in the working tree.
-var filterSmudgeSkip bool
+var (
+	// filterSmudgeSkip is a command-line flag owned by the
+	// `filter-process` command dictating whether or not to skip the
+	// smudging process, leaving pointers as-is in the working tree.
+	filterSmudgeSkip bool
+
+	// filterStatus asks for the status of the running filter processes of
+	// the repository, rather than running one.
+	filterStatus bool
+)
 
 func filterCommand(cmd *cobra.Command, args []string) {
+	if filterStatus {
+		filterStatusCommand()
+		return
+	}
+
 	requireStdin("This command should be run by the Git filter process")
 	setupRepository()
 	installHooks(false)
@@ -60,7 +79,8 @@ func filterCommand(cmd *cobra.Command, args []string) {
 	}
 
 	skip := filterSmudgeSkip || cfg.Os.Bool("GIT_LFS_SKIP_SMUDGE", false)
-	filter := filepathfilter.New(cfg.FetchIncludePaths(), cfg.FetchExcludePaths())
+	filter := filepathfilter.New(cfg.FetchIncludePaths(), cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+	lastConfigCheck := time.Now()
 
 	ptrs := make(map[string]*lfs.Pointer)
 
@@ -70,13 +90,54 @@ func filterCommand(cmd *cobra.Command, args []string) {
 	var closeOnce *sync.Once
 	var available chan *tq.Transfer
 	gitfilter := lfs.NewGitFilter(cfg)
-	for s.Scan() {
+	gitfilter.SetProgressStream(getProgressStream())
+	autotrack := newAutotracker()
+
+	// A signal asks the filter to stop once it has answered the request
+	// it is working on, if any, rather than exiting at once.  A second
+	// signal does not wait.
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	setSignalHandler(func(sig os.Signal) bool {
+		handled := false
+		stopOnce.Do(func() {
+			tracelog.Printf("filter-process: received %q signal, stopping", sig)
+			close(stop)
+			handled = true
+		})
+		return handled
+	})
+	defer setSignalHandler(nil)
+
+	idleTimeout := cfg.FilterProcessIdleTimeout()
+	monitor := newFilterMonitor(idleTimeout)
+	defer monitor.Close()
+
+	requests := newFilterRequests(s)
+	for requests.Next(stop, idleTimeout) {
 		var n int64
 		var err error
 		var delayed bool
 		var w *pktline.PktlineWriter
 
 		req := s.Request()
+		monitor.setState(filterStateBusy)
+
+		if time.Since(lastConfigCheck) >= configCheckInterval {
+			lastConfigCheck = time.Now()
+			if cfg.GitConfigChanged() {
+				tracelog.Printf("filter-process: git config changed, reloading")
+				cfg.ReloadGitConfig()
+
+				skip = filterProcessSkip() || cfg.Os.Bool("GIT_LFS_SKIP_SMUDGE", false)
+				filter = filepathfilter.New(cfg.FetchIncludePaths(), cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+				autotrack = newAutotracker()
+				idleTimeout = cfg.FilterProcessIdleTimeout()
+				monitor.update(func(st *filterProcessStatus) {
+					st.IdleTimeout = int64(idleTimeout / time.Second)
+				})
+			}
+		}
 
 		switch req.Header["command"] {
 		case "clean":
@@ -84,7 +145,7 @@ func filterCommand(cmd *cobra.Command, args []string) {
 			w = pktline.NewPktlineWriter(os.Stdout, cleanFilterBufferCapacity)
 
 			var ptr *lfs.Pointer
-			ptr, err = clean(gitfilter, w, req.Payload, req.Header["pathname"], -1)
+			ptr, err = clean(gitfilter, autotrack, w, req.Payload, req.Header["pathname"], -1)
 
 			if ptr != nil {
 				n = ptr.Size
@@ -119,7 +180,7 @@ func filterCommand(cmd *cobra.Command, args []string) {
 					break
 				}
 
-				n, err = smudge(gitfilter, w, from, req.Header["pathname"], skip, filter)
+				n, err = smudge(gitfilter, w, from, req.Header["pathname"], skip, filter, config.SmudgeFallbackFetchTrue)
 				if err == nil {
 					delete(ptrs, req.Header["pathname"])
 				}
@@ -202,6 +263,24 @@ func filterCommand(cmd *cobra.Command, args []string) {
 		}
 
 		s.WriteStatus(status)
+
+		monitor.update(func(st *filterProcessStatus) {
+			st.Delayed = len(ptrs)
+		})
+		monitor.setState(filterStateIdle)
+	}
+
+	if requests.Stopped() {
+		monitor.setState(filterStateStopping)
+		if q != nil {
+			// Finish downloading the objects of the delayed
+			// files, so that they are in the local store when Git
+			// asks for them again.
+			tracelog.Printf("filter-process: finishing %d delayed download(s)", len(ptrs))
+			closeOnce.Do(func() { go q.Wait() })
+			for range available {
+			}
+		}
 	}
 
 	if len(malformed) > 0 {
@@ -221,11 +300,129 @@ func filterCommand(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "\nSee: `git lfs help smudge` for more details.\n")
 	}
 
+	if requests.Stopped() {
+		return
+	}
 	if err := s.Err(); err != nil && err != io.EOF {
 		ExitWithError(err)
 	}
 }
 
+// filterRequests reads the requests of Git in the background, so that the
+// filter may stop waiting for the next one.
+type filterRequests struct {
+	s        *git.FilterProcessScanner
+	requests chan bool
+	next     chan struct{}
+	started  bool
+	stopped  bool
+}
+
+func newFilterRequests(s *git.FilterProcessScanner) *filterRequests {
+	r := &filterRequests{
+		s:        s,
+		requests: make(chan bool),
+		next:     make(chan struct{}),
+	}
+
+	go func() {
+		for r.s.Scan() {
+			r.requests <- true
+			<-r.next
+		}
+		close(r.requests)
+	}()
+	return r
+}
+
+// Next waits for the next request, which is then given by the scanner's
+// Request method.  It returns false if Git has no more requests, if stop is
+// closed, or if no request arrives within the given timeout, unless it is
+// zero.  The previous request must have been answered.
+func (r *filterRequests) Next(stop <-chan struct{}, timeout time.Duration) bool {
+	if r.started {
+		r.next <- struct{}{}
+	}
+	r.started = true
+
+	var idle <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	select {
+	case ok := <-r.requests:
+		return ok
+	case <-stop:
+	case <-idle:
+		tracelog.Printf("filter-process: no request for %s, stopping", timeout)
+	}
+	r.stopped = true
+	return false
+}
+
+// Stopped returns true if Next returned false before Git had sent all of its
+// requests.
+func (r *filterRequests) Stopped() bool {
+	return r.stopped
+}
+
+// filterStatusCommand reports the status of the running filter processes of
+// the repository, as `git lfs filter-process --status` does.
+func filterStatusCommand() {
+	setupRepository()
+
+	statuses, err := runningFilterProcesses()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, "Could not read filter process status"))
+	}
+
+	if jsonOutput {
+		if statuses == nil {
+			statuses = []*filterProcessStatus{}
+		}
+		if err := PrintResult(&filterStatusResult{Processes: statuses}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	if len(statuses) == 0 {
+		Print("No filter processes are running")
+		return
+	}
+
+	for _, st := range statuses {
+		since := st.Started
+		if st.LastRequest != nil {
+			since = *st.LastRequest
+		}
+		Print("%d\t%s for %s\trequests: %d\tdelayed: %d\t%s",
+			st.PID, st.State, time.Since(since).Round(time.Second),
+			st.Requests, st.Delayed, st.WorkingDir)
+	}
+}
+
+// filterStatusResult is the result written by
+// "git lfs filter-process --status --json".
+type filterStatusResult struct {
+	Processes []*filterProcessStatus `json:"processes"`
+}
+
+// filterProcessSkip returns whether smudging should be skipped once the Git
+// configuration has been reloaded.  Since "git lfs install --skip-smudge" and
+// "git lfs install" change the command run as filter.lfs.process, whether it
+// includes --skip decides, unless it does not run filter-process at all.
+func filterProcessSkip() bool {
+	process, _ := cfg.Git.Get("filter.lfs.process")
+	if !strings.Contains(process, "filter-process") {
+		return filterSmudgeSkip
+	}
+	return strings.Contains(process, "--skip")
+}
+
 // infiniteTransferBuffer streams the results of q.Watch() into "available" as
 // if available had an infinite channel buffer.
 func infiniteTransferBuffer(q *tq.TransferQueue, available chan<- *tq.Transfer) {
@@ -379,5 +576,6 @@ func delayedStatusFromErr(err error) git.FilterProcessStatus {
 func init() {
 	RegisterCommand("filter-process", filterCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&filterSmudgeSkip, "skip", "s", false, "")
+		cmd.Flags().BoolVarP(&filterStatus, "status", "", false, "")
 	})
 }
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterRequestsReadsUntilEOF(t *testing.T) {
+	given := "0012command=clean\n0013pathname=a.dat\n0000" + "0007hi\n0000"
+	s := git.NewFilterProcessScanner(strings.NewReader(given), ioutil.Discard)
+	requests := newFilterRequests(s)
+
+	require.True(t, requests.Next(nil, 0))
+	assert.Equal(t, "clean", s.Request().Header["command"])
+	assert.Equal(t, "a.dat", s.Request().Header["pathname"])
+	payload, err := ioutil.ReadAll(s.Request().Payload)
+	require.Nil(t, err)
+	assert.Equal(t, "hi\n", string(payload))
+
+	assert.False(t, requests.Next(nil, 0))
+	assert.False(t, requests.Stopped())
+}
+
+func TestFilterRequestsStopsWhenIdle(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	s := git.NewFilterProcessScanner(r, &bytes.Buffer{})
+	requests := newFilterRequests(s)
+
+	assert.False(t, requests.Next(nil, 10*time.Millisecond))
+	assert.True(t, requests.Stopped())
+}
+
+func TestFilterRequestsStopsWhenAsked(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	s := git.NewFilterProcessScanner(r, &bytes.Buffer{})
+	requests := newFilterRequests(s)
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, requests.Next(stop, 0))
+	assert.True(t, requests.Stopped())
+}
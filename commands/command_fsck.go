@@ -1,16 +1,18 @@
 package commands
 
 import (
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tools"
@@ -21,6 +23,7 @@ var (
 	fsckDryRun   bool
 	fsckObjects  bool
 	fsckPointers bool
+	fsckRepair   bool
 )
 
 type corruptPointer struct {
@@ -72,20 +75,24 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if fsckRepair && fsckDryRun {
+		Exit("Cannot combine --repair with --dry-run")
+	}
+
 	if !fsckPointers && !fsckObjects {
 		fsckPointers = true
 		fsckObjects = true
 	}
 
 	ok := true
-	var corruptOids []string
+	var corruptObjects []*lfs.WrappedPointer
 	var corruptPointers []corruptPointer
 	if fsckObjects {
-		corruptOids = doFsckObjects(start, end, useIndex)
-		ok = ok && len(corruptOids) == 0
+		corruptObjects = doFsckObjects(start, end, useIndex)
+		ok = ok && len(corruptObjects) == 0
 	}
 	if fsckPointers {
-		corruptPointers = doFsckPointers(start, end)
+		corruptPointers = doFsckPointers(start, end, useIndex)
 		ok = ok && len(corruptPointers) == 0
 	}
 
@@ -94,8 +101,8 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if fsckDryRun || len(corruptOids) == 0 {
-		os.Exit(1)
+	if fsckDryRun || len(corruptObjects) == 0 {
+		os.Exit(exitCodeLocalCorruption)
 	}
 
 	badDir := filepath.Join(cfg.LFSStorageDir(), "bad")
@@ -105,24 +112,73 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		ExitWithError(err)
 	}
 
-	for _, oid := range corruptOids {
-		badFile := filepath.Join(badDir, oid)
-		if err := os.Rename(cfg.Filesystem().ObjectPathname(oid), badFile); err != nil {
+	for _, p := range corruptObjects {
+		file, _, err := fs.ObjectFile(cfg.Filesystem().ObjectPathname(p.Oid))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			err = os.Rename(file, filepath.Join(badDir, filepath.Base(file)))
+		}
+		if err != nil && !os.IsNotExist(err) {
+			ExitWithError(err)
+		}
+	}
+
+	if !fsckRepair || !fsckRepairObjects(corruptObjects) {
+		os.Exit(exitCodeLocalCorruption)
+	}
+
+	if len(corruptPointers) > 0 {
+		os.Exit(exitCodeLocalCorruption)
+	}
+}
+
+// fsckRepairObjects downloads the given objects from the remote again, and
+// returns whether all of them were downloaded and are now correct.
+func fsckRepairObjects(pointers []*lfs.WrappedPointer) bool {
+	Print("objects: repair: downloading %d object(s) from %s", len(pointers), cfg.Remote())
+
+	ok := fetchAndReportToChan(pointers, nil, nil)
+
+	var repaired int
+	for _, p := range pointers {
+		if !cfg.LFSObjectExists(p.Oid, p.Size) {
+			ok = false
+			continue
+		}
+
+		pointerOk, err := fsckPointer(p.Name, p.Oid, p.Size)
+		if err != nil {
 			ExitWithError(err)
 		}
+		if pointerOk {
+			repaired++
+		} else {
+			ok = false
+		}
 	}
-	os.Exit(1)
+
+	Print("objects: repair: repaired %d of %d object(s)", repaired, len(pointers))
+	return ok
 }
 
-// doFsckObjects checks that the objects in the given ref are correct and exist.
-func doFsckObjects(start, end string, useIndex bool) []string {
-	var corruptOids []string
+// doFsckObjects checks that the objects in the given ref are correct and exist,
+// returning a pointer to each which is not.
+func doFsckObjects(start, end string, useIndex bool) []*lfs.WrappedPointer {
+	var corruptObjects []*lfs.WrappedPointer
+	seen := make(map[string]struct{})
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err == nil {
+			if _, ok := seen[p.Oid]; ok {
+				return
+			}
+			seen[p.Oid] = struct{}{}
+
 			var pointerOk bool
 			pointerOk, err = fsckPointer(p.Name, p.Oid, p.Size)
 			if !pointerOk {
-				corruptOids = append(corruptOids, p.Oid)
+				corruptObjects = append(corruptObjects, p)
 			}
 		}
 
@@ -136,7 +192,7 @@ func doFsckObjects(start, end string, useIndex bool) []string {
 	// objects), the "missing" ones will fail the fsck.
 	//
 	// Attach a filepathfilter to avoid _only_ the excluded paths.
-	gitscanner.Filter = filepathfilter.New(nil, cfg.FetchExcludePaths())
+	gitscanner.Filter = filepathfilter.New(nil, cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
 
 	if start == "" {
 		if err := gitscanner.ScanRef(end, nil); err != nil {
@@ -155,36 +211,74 @@ func doFsckObjects(start, end string, useIndex bool) []string {
 	}
 
 	gitscanner.Close()
-	return corruptOids
+	return corruptObjects
 }
 
-// doFsckPointers checks that the pointers in the given ref are correct and canonical.
-func doFsckPointers(start, end string) []corruptPointer {
+// doFsckPointers checks that the pointers in the given ref, and in the index if
+// useIndex is set, are correct and canonical, and that their objects exist
+// either locally or on the remote.
+func doFsckPointers(start, end string, useIndex bool) []corruptPointer {
 	var corruptPointers []corruptPointer
+	var mu sync.Mutex
+	requireSignatures := lfs.VerifiesPointerSignatures(cfg)
+	checked := make(map[string]struct{})
+	missing := make(map[string]*lfs.WrappedPointer)
+	fetchFilter := filepathfilter.New(nil, cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+
+	report := func(cp corruptPointer) {
+		Print("pointer: %s", cp.String())
+		corruptPointers = append(corruptPointers, cp)
+	}
+
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
 		if p != nil {
 			Debug("Examining %v (%v)", p.Oid, p.Name)
 			if !p.Canonical {
-				cp := corruptPointer{
+				report(corruptPointer{
 					blobOid: p.Sha1,
 					lfsOid:  p.Oid,
 					message: fmt.Sprintf("Pointer for %s (blob %s) was not canonical", p.Oid, p.Sha1),
 					kind:    "nonCanonicalPointer",
+				})
+			}
+
+			_, signed := p.Metadata[lfs.MetaSignature]
+			if _, seen := checked[p.Sha1]; !seen && (signed || requireSignatures) {
+				checked[p.Sha1] = struct{}{}
+				if err := lfs.VerifyPointerSignature(cfg, p.Pointer); err != nil {
+					report(corruptPointer{
+						blobOid: p.Sha1,
+						lfsOid:  p.Oid,
+						message: fmt.Sprintf("Pointer for %s (blob %s) has no valid signature: %s", p.Oid, p.Sha1, err),
+						kind:    "badSignature",
+					})
+				}
+			}
+
+			if p.Size > 0 && fetchFilter.Allows(p.Name) && !cfg.LFSObjectExists(p.Oid, p.Size) {
+				if _, ok := missing[p.Oid]; !ok {
+					missing[p.Oid] = p
 				}
-				Print("pointer: %s", cp.String())
-				corruptPointers = append(corruptPointers, cp)
 			}
 		} else if errors.IsPointerScanError(err) {
 			psErr, ok := err.(errors.PointerScanError)
-			if ok {
-				cp := corruptPointer{
+			if ok && errors.IsMalformedPointerError(err) {
+				report(corruptPointer{
+					treeOid: psErr.OID(),
+					path:    psErr.Path(),
+					message: fmt.Sprintf("%q (treeish %s) is a malformed pointer: %s", psErr.Path(), psErr.OID(), errors.Cause(err)),
+					kind:    "malformedPointer",
+				})
+			} else if ok {
+				report(corruptPointer{
 					treeOid: psErr.OID(),
 					path:    psErr.Path(),
 					message: fmt.Sprintf("%q (treeish %s) should have been a pointer but was not", psErr.Path(), psErr.OID()),
 					kind:    "unexpectedGitObject",
-				}
-				Print("pointer: %s", cp.String())
-				corruptPointers = append(corruptPointers, cp)
+				})
 			}
 		} else {
 			Panic(err, "Error checking Git LFS files")
@@ -201,7 +295,64 @@ func doFsckPointers(start, end string) []corruptPointer {
 		}
 	}
 
+	if useIndex {
+		// The index may not be written as a tree while it has
+		// unmerged entries, in which case we check only HEAD.  If it
+		// matches HEAD, it has been checked already.
+		tree, err := git.WriteTree()
+		if err != nil {
+			Error("pointer: index could not be checked: %v", err)
+		} else if head, err := git.ResolveRef(end + "^{tree}"); err != nil || head.Sha != tree {
+			if err := gitscanner.ScanTreeForPointers(tree, nil); err != nil {
+				ExitWithError(err)
+			}
+		}
+	}
+
 	gitscanner.Close()
+
+	for _, cp := range fsckMissingPointers(missing) {
+		report(cp)
+	}
+	return corruptPointers
+}
+
+// fsckMissingPointers asks the remote about the objects of the given pointers,
+// which are missing locally, and returns an entry for each which the remote
+// does not have either.
+func fsckMissingPointers(missing map[string]*lfs.WrappedPointer) []corruptPointer {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sizes := make(map[string]int64, len(missing))
+	for oid, p := range missing {
+		sizes[oid] = p.Size
+	}
+
+	absent, err := remoteMissingObjects(cfg.Remote(), nil, sizes)
+	if err != nil {
+		Error("pointer: %d object(s) missing locally could not be checked on %s: %v", len(missing), cfg.Remote(), errors.Cause(err))
+		return nil
+	}
+
+	oids := make([]string, 0, len(absent))
+	for oid := range absent {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+
+	var corruptPointers []corruptPointer
+	for _, oid := range oids {
+		p := missing[oid]
+		corruptPointers = append(corruptPointers, corruptPointer{
+			blobOid: p.Sha1,
+			lfsOid:  p.Oid,
+			path:    p.Name,
+			message: fmt.Sprintf("%s (%s) is missing locally and from %s", p.Name, p.Oid, cfg.Remote()),
+			kind:    "missingObject",
+		})
+	}
 	return corruptPointers
 }
 
@@ -210,7 +361,7 @@ func fsckPointer(name, oid string, size int64) (bool, error) {
 
 	Debug("Examining %v (%v)", name, path)
 
-	f, err := os.Open(path)
+	f, err := cfg.ObjectStore().OpenObject(oid)
 	if pErr, pOk := err.(*os.PathError); pOk {
 		// This is an empty file.  No problem here.
 		if size == 0 {
@@ -223,12 +374,18 @@ func fsckPointer(name, oid string, size int64) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	defer f.Close()
 
-	oidHash := sha256.New()
-	_, err = io.Copy(oidHash, f)
-	f.Close()
-	if err != nil {
-		return false, err
+	if actual, err := cfg.ObjectStore().ObjectSize(oid); err == nil && actual < size {
+		Print("objects: truncatedObject: %s (%s) is truncated: %d of %d bytes present", name, oid, actual, size)
+		return false, nil
+	}
+
+	oidHash := tools.NewLfsContentHashFor(oid)
+	if _, err = io.Copy(oidHash, f); err != nil {
+		// Compressed objects which are damaged fail to decompress.
+		Print("objects: corruptObject: %s (%s) could not be read: %s", name, oid, err)
+		return false, nil
 	}
 
 	recalculatedOid := hex.EncodeToString(oidHash.Sum(nil))
@@ -245,5 +402,6 @@ func init() {
 		cmd.Flags().BoolVarP(&fsckDryRun, "dry-run", "d", false, "List corrupt objects without deleting them.")
 		cmd.Flags().BoolVarP(&fsckObjects, "objects", "", false, "Fsck objects.")
 		cmd.Flags().BoolVarP(&fsckPointers, "pointers", "", false, "Fsck pointers.")
+		cmd.Flags().BoolVarP(&fsckRepair, "repair", "", false, "Download corrupt objects again from the remote.")
 	})
 }
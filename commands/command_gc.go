@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDryRunArg   bool
+	gcVerboseArg  bool
+	gcCompressArg bool
+	gcNoPruneArg  bool
+	gcNoLocksArg  bool
+)
+
+// gcCommand performs all of the housekeeping on the local Git LFS storage in
+// one go: removing stale temporary files, pruning unreferenced objects,
+// compressing those which remain if asked to, and refreshing the lock cache.
+func gcCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(args) > 0 {
+		Exit("Usage: git lfs gc [options]")
+	}
+
+	if gcNoPruneArg {
+		if !gcDryRunArg {
+			logger := tasklog.NewLogger(OutputWriter,
+				tasklog.ForceProgress(cfg.ForceProgress()),
+			)
+			pruneStaleFiles("gc", logger)
+			logger.Close()
+		}
+	} else {
+		fetchPruneConfig := lfs.NewFetchPruneConfig(cfg.Git)
+		prune(fetchPruneConfig, fetchPruneConfig.PruneVerifyRemoteAlways, gcDryRunArg, gcVerboseArg)
+	}
+
+	if gcDryRunArg {
+		return
+	}
+
+	var failed int
+	if gcCompressArg || cfg.Git.Bool("lfs.gc.compress", false) {
+		var count int
+		var saved int64
+		var err error
+		count, failed, saved, err = compressLocalObjects("gc", false)
+		if err != nil {
+			ExitWithError(err)
+		}
+		if count > 0 {
+			Print("gc: compressed %d object(s), saving %s", count, humanize.FormatBytes(uint64(saved)))
+		}
+	}
+
+	if !gcNoLocksArg {
+		gcRefreshLockCache()
+	}
+
+	if failed > 0 {
+		Exit("gc: %d object(s) could not be compressed", failed)
+	}
+}
+
+// gcRefreshLockCache drops locks which the server no longer holds from the
+// local lock cache. Repositories which have never cached a lock are left alone
+// so that those not using locking do not contact the server.
+func gcRefreshLockCache() {
+	lockClient := newLockClient()
+	defer lockClient.Close()
+
+	cached, err := lockClient.SearchLocks(nil, 0, true, false)
+	if err != nil || len(cached) == 0 {
+		return
+	}
+
+	refUpdate := git.NewRefUpdate(cfg.Git, cfg.PushRemote(), cfg.CurrentRef(), nil)
+	lockClient.RemoteRef = refUpdate.Right()
+
+	removed, err := lockClient.RefreshCache()
+	if err != nil {
+		if !errors.IsNotImplementedError(err) {
+			Error("gc: unable to refresh the lock cache: %s", errors.Cause(err))
+		}
+		return
+	}
+	if removed > 0 {
+		Print("gc: removed %d stale cached lock(s)", removed)
+	}
+}
+
+func init() {
+	RegisterCommand("gc", gcCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&gcDryRunArg, "dry-run", "d", false, "Don't delete or change anything, just report")
+		cmd.Flags().BoolVarP(&gcVerboseArg, "verbose", "v", false, "Print full details of what is/would be pruned")
+		cmd.Flags().BoolVarP(&gcCompressArg, "compress", "c", false, "Compress the objects which remain")
+		cmd.Flags().BoolVar(&gcNoPruneArg, "no-prune", false, "Don't prune unreferenced objects")
+		cmd.Flags().BoolVar(&gcNoLocksArg, "no-locks", false, "Don't refresh the lock cache")
+	})
+}
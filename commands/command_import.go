@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFromArg string
+	importCopyArg bool
+	importAllArg  bool
+)
+
+// importCommand copies, or hard links, the objects referenced by the given
+// refs from the object store of another local repository, so that they need
+// not be downloaded.
+func importCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(importFromArg) == 0 {
+		Exit("Usage: git lfs import --from <path> [<ref>...]")
+	}
+
+	source, err := importSourceStore(importFromArg)
+	if err != nil {
+		Exit("Cannot import from %s: %v", importFromArg, err)
+	}
+	if same, _ := sameDirectory(source.LFSObjectDir(), cfg.LFSObjectDir()); same {
+		Exit("Cannot import from %s: it uses the same object store as this repository", importFromArg)
+	}
+
+	include, exclude := getIncludeExcludeArgs(cmd)
+	if importAllArg {
+		if len(args) > 0 {
+			Exit("Cannot combine --all with refs")
+		}
+		if include != nil || exclude != nil {
+			Exit("Cannot combine --all with --include or --exclude")
+		}
+	}
+
+	pointers := importPointers(args, buildFilepathFilter(cfg, include, exclude, true))
+
+	var wanted, imported int
+	var size int64
+	seen := make(map[string]bool, len(pointers))
+	for _, p := range pointers {
+		if seen[p.Oid] || cfg.LFSObjectExists(p.Oid, p.Size) {
+			continue
+		}
+		seen[p.Oid] = true
+		wanted++
+
+		if !source.ObjectExists(p.Oid, p.Size) {
+			continue
+		}
+
+		if err := importObject(source, p.Oid, p.Size); err != nil {
+			Error("import: unable to import %s: %s", p.Oid, err)
+			continue
+		}
+
+		imported++
+		size += p.Size
+	}
+
+	if jsonOutput {
+		if err := PrintResult(&importResult{Imported: imported, Missing: wanted, Size: size}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+	Print("import: imported %d of %d missing object(s) (%s)", imported, wanted, humanize.FormatBytes(uint64(size)))
+}
+
+// importObject copies, or hard links, the object "oid" from the "source" store
+// into this repository's object store, once its contents have been checked
+// against its object ID and size. Objects can only be linked into the local
+// object store on disk; other stores are given a copy of the contents.
+func importObject(source *fs.Filesystem, oid string, size int64) error {
+	if err := verifyImportObject(source, oid, size); err != nil {
+		return err
+	}
+
+	store, ok := cfg.ObjectStore().(*fs.Filesystem)
+	if !ok {
+		r, err := source.OpenObject(oid)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return cfg.ObjectStore().WriteObject(oid, r)
+	}
+
+	dst, err := store.ObjectPath(oid)
+	if err != nil {
+		return err
+	}
+	src, compressed, err := fs.ObjectFile(source.ObjectPathname(oid))
+	if err != nil {
+		return err
+	}
+	if compressed {
+		dst = fs.CompressedObjectPath(dst)
+	}
+	if importCopyArg {
+		return lfs.CopyFileContents(cfg, src, dst)
+	}
+	return lfs.LinkOrCopy(cfg, src, dst)
+}
+
+// verifyImportObject hashes the object "oid" in the "source" store, and returns
+// an error if its contents do not match its object ID and size, so that
+// corrupt objects in the other repository are not imported.
+func verifyImportObject(source *fs.Filesystem, oid string, size int64) error {
+	r, err := source.OpenObject(oid)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	hr := tools.NewHashingReaderFor(r, oid)
+	n, err := io.Copy(ioutil.Discard, hr)
+	if err != nil {
+		return err
+	}
+	if n != size || hr.Hash() != oid {
+		return errors.Errorf("object %s is corrupt in %s", oid, source.LFSObjectDir())
+	}
+	return nil
+}
+
+// importResult is the result written by "git lfs import --json".
+type importResult struct {
+	Imported int   `json:"imported"`
+	Missing  int   `json:"missing"`
+	Size     int64 `json:"size"`
+}
+
+// importSourceStore returns the Git LFS object store of the repository at
+// path, which may be its working tree, its Git directory, or the object store
+// itself. The repository's "lfs.storage" setting is honored, as it is when
+// that repository runs Git LFS itself.
+func importSourceStore(path string) (*fs.Filesystem, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Base(path) == "objects" && filepath.Base(filepath.Dir(path)) == "lfs" && tools.DirExists(path) {
+		return fs.New(cfg.Os, "", "", filepath.Dir(path), 0), nil
+	}
+
+	for _, gitdir := range []string{filepath.Join(path, ".git"), path} {
+		if !tools.DirExists(gitdir) || !tools.FileExists(filepath.Join(gitdir, "HEAD")) {
+			continue
+		}
+
+		lfsdir := git.NewReadOnlyConfig("", gitdir).Find("lfs.storage")
+		store := fs.New(cfg.Os, gitdir, "", lfsdir, 0)
+		if tools.DirExists(filepath.Join(store.LFSStorageDir, "objects")) {
+			return store, nil
+		}
+	}
+	return nil, errors.New("no Git LFS object store found")
+}
+
+// sameDirectory returns whether the directories a and b are the same.
+func sameDirectory(a, b string) (bool, error) {
+	afi, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bfi, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(afi, bfi), nil
+}
+
+// importPointers returns the pointers in the trees of the given refs, or of the
+// current ref if none are given, which pass the filter. With --all, it returns
+// every pointer in the repository's history instead.
+func importPointers(refs []string, filter *filepathfilter.Filter) []*lfs.WrappedPointer {
+	var pointers []*lfs.WrappedPointer
+	if importAllArg {
+		gitscanner := lfs.NewGitScanner(cfg, nil)
+		err := gitscanner.ScanAll(func(p *lfs.WrappedPointer, err error) {
+			if err != nil {
+				Panic(err, "Could not scan for Git LFS files")
+			}
+			pointers = append(pointers, p)
+		})
+		gitscanner.Close()
+		if err != nil {
+			ExitWithError(err)
+		}
+		return pointers
+	}
+
+	var resolved []*git.Ref
+	if len(refs) > 0 {
+		var err error
+		resolved, err = git.ResolveRefs(refs)
+		if err != nil {
+			Panic(err, "Invalid ref argument: %v", refs)
+		}
+	} else {
+		ref, err := git.CurrentRef()
+		if err != nil {
+			Panic(err, "Could not import")
+		}
+		resolved = []*git.Ref{ref}
+	}
+
+	for _, ref := range resolved {
+		refPointers, err := pointersToFetchForRef(ref.Sha, filter)
+		if err != nil {
+			Panic(err, "Could not scan for Git LFS files")
+		}
+		pointers = append(pointers, refPointers...)
+	}
+	return pointers
+}
+
+func init() {
+	RegisterCommand("import", importCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVar(&importFromArg, "from", "", "Import objects from the repository at this path")
+		cmd.Flags().BoolVar(&importCopyArg, "copy", false, "Copy objects rather than hard linking them")
+		cmd.Flags().BoolVarP(&importAllArg, "all", "a", false, "Import objects for all refs and history")
+		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
+		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
+	})
+}
@@ -1,14 +1,16 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/locking"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
 )
@@ -19,11 +21,11 @@ var (
 )
 
 func lockCommand(cmd *cobra.Command, args []string) {
-	if len(args) != 1 {
-		Exit("Usage: git lfs lock <path>")
+	if len(args) < 1 {
+		Exit("Usage: git lfs lock <path>...")
 	}
 
-	path, err := lockPath(args[0])
+	paths, err := lockPathsFromArgs(args)
 	if err != nil {
 		Exit(err.Error())
 	}
@@ -37,19 +39,143 @@ func lockCommand(cmd *cobra.Command, args []string) {
 	lockClient.RemoteRef = refUpdate.Right()
 	defer lockClient.Close()
 
+	if len(paths) > 1 {
+		lockMany(lockClient, paths)
+		return
+	}
+
+	path := paths[0]
 	lock, err := lockClient.LockFile(path)
 	if err != nil {
-		Exit("Lock failed: %v", errors.Cause(err))
+		exitWithCode(err, "Lock failed: %v", errors.Cause(err))
 	}
 
-	if locksCmdFlags.JSON {
-		if err := json.NewEncoder(os.Stdout).Encode(lock); err != nil {
-			Error(err.Error())
+	if jsonOutput {
+		if err := PrintResult(lock); err != nil {
+			ExitWithError(err)
 		}
 		return
 	}
 
-	Print("Locked %s", path)
+	Print("Locked %s%s", path, lockExpirySuffix(lock))
+}
+
+// lockExpirySuffix describes when a lock just taken expires, if it does.
+func lockExpirySuffix(lock locking.Lock) string {
+	if desc := describeLockExpiry(lock, time.Now()); len(desc) > 0 {
+		return fmt.Sprintf(" (%s)", desc)
+	}
+	return ""
+}
+
+// lockMany locks each of the given repository-relative paths, making up to
+// lfs.concurrenttransfers requests of the server at once, and reports which
+// succeeded and which failed, for instance because they were already locked.
+func lockMany(lockClient *locking.Client, paths []string) {
+	locks := make([]locking.Lock, len(paths))
+	errs := forEachLockTarget(paths, func(i int, path string) error {
+		lock, err := lockClient.LockFile(path)
+		if err != nil {
+			return err
+		}
+		locks[i] = lock
+		return nil
+	})
+
+	var locked []locking.Lock
+	for i, path := range paths {
+		if errs[i] != nil {
+			Error("Unable to lock %s: %v", path, errors.Cause(errs[i]))
+			continue
+		}
+
+		locked = append(locked, locks[i])
+		if !jsonOutput {
+			Print("Locked %s%s", path, lockExpirySuffix(locks[i]))
+		}
+	}
+
+	if jsonOutput {
+		if locked == nil {
+			locked = []locking.Lock{}
+		}
+		if err := PrintResult(locked); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	if failed := len(paths) - len(locked); failed > 0 {
+		Exit("Locked %d of %d files; %d failed.", len(locked), len(paths), failed)
+	}
+	if !jsonOutput {
+		Print("Locked %d files.", len(locked))
+	}
+}
+
+// forEachLockTarget calls fn for each of the given paths or lock IDs, at most
+// lfs.concurrenttransfers at a time, and returns the error from each call in
+// the order of the targets.
+func forEachLockTarget(targets []string, fn func(i int, target string) error) []error {
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, tools.MaxInt(getAPIClient().ConcurrentTransfers(), 1))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(i, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// lockPathsFromArgs returns the repository-relative path of each of the given
+// arguments, as given by lockPath.  Arguments which do not name an existing
+// file but contain glob characters are expanded to the files, but not
+// directories, which they match, so that quoted patterns may be locked on
+// platforms whose shells do not expand them.
+func lockPathsFromArgs(args []string) ([]string, error) {
+	paths := make([]string, 0, len(args))
+	seen := make(map[string]bool, len(args))
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if _, err := os.Stat(arg); os.IsNotExist(err) && strings.ContainsAny(arg, "*?[") {
+			globbed, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("lfs: invalid pattern %q: %v", arg, err)
+			}
+
+			matches = matches[:0]
+			for _, match := range globbed {
+				if stat, err := os.Stat(match); err == nil && !stat.IsDir() {
+					matches = append(matches, match)
+				}
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("lfs: no files match %q", arg)
+			}
+		}
+
+		for _, match := range matches {
+			path, err := lockPath(match)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
 }
 
 // lockPaths relativizes the given filepath such that it is relative to the root
@@ -114,6 +240,5 @@ func lockPath(file string) (string, error) {
 func init() {
 	RegisterCommand("lock", lockCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&lockRemote, "remote", "r", "", lockRemoteHelp)
-		cmd.Flags().BoolVarP(&locksCmdFlags.JSON, "json", "", false, "print output in json")
 	})
 }
@@ -1,15 +1,16 @@
 package commands
 
 import (
-	"io"
-	"os"
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/locking"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +33,42 @@ func locksCommand(cmd *cobra.Command, args []string) {
 	lockClient.RemoteRef = refUpdate.Right()
 	defer lockClient.Close()
 
+	if locksCmdFlags.Renew || locksCmdFlags.RenewEvery > 0 {
+		if len(filters) > 0 || locksCmdFlags.Limit > 0 || len(locksCmdFlags.Cursor) > 0 ||
+			locksCmdFlags.Local || locksCmdFlags.Cached || locksCmdFlags.Verify ||
+			locksCmdFlags.Sync || jsonOutput {
+			Exit("--renew option can't be combined with other options")
+		}
+
+		renewLocks(lockClient, refUpdate, locksCmdFlags.RenewEvery)
+		return
+	}
+
+	if locksCmdFlags.Sync {
+		if locksCmdFlags.Limit > 0 {
+			Exit("--sync option can't be combined with --limit")
+		}
+		if len(filters) > 0 {
+			Exit("--sync option can't be combined with filters")
+		}
+		if locksCmdFlags.Local {
+			Exit("--sync option can't be combined with --local")
+		}
+		if locksCmdFlags.Cached {
+			Exit("--sync option can't be combined with --cached")
+		}
+		if len(locksCmdFlags.Cursor) > 0 {
+			Exit("--sync option can't be combined with --cursor")
+		}
+
+		syncLockCache(lockClient)
+
+		// List the locks just written to the cache, marking our own,
+		// rather than asking the server a second time.
+		locksCmdFlags.Verify = true
+		locksCmdFlags.Cached = true
+	}
+
 	if locksCmdFlags.Cached {
 		if locksCmdFlags.Limit > 0 {
 			Exit("--cached option can't be combined with --limit")
@@ -44,6 +81,18 @@ func locksCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if len(locksCmdFlags.Cursor) > 0 {
+		if locksCmdFlags.Cached {
+			Exit("--cursor option can't be combined with --cached")
+		}
+		if locksCmdFlags.Local {
+			Exit("--cursor option can't be combined with --local")
+		}
+		if locksCmdFlags.Verify {
+			Exit("--cursor option can't be combined with --verify")
+		}
+	}
+
 	if locksCmdFlags.Verify {
 		if len(filters) > 0 {
 			Exit("--verify option can't be combined with filters")
@@ -55,31 +104,61 @@ func locksCommand(cmd *cobra.Command, args []string) {
 
 	var locks []locking.Lock
 	var locksOwned map[locking.Lock]bool
-	var jsonWriteFunc func(io.Writer) error
+	var locksBlocking map[locking.Lock]bool
+	var result interface{}
+	var nextCursor string
 	if locksCmdFlags.Verify {
 		var ourLocks, theirLocks []locking.Lock
 		ourLocks, theirLocks, err = lockClient.SearchLocksVerifiable(locksCmdFlags.Limit, locksCmdFlags.Cached)
-		jsonWriteFunc = func(writer io.Writer) error {
-			return lockClient.EncodeLocksVerifiable(ourLocks, theirLocks, writer)
-		}
 
 		locks = append(ourLocks, theirLocks...)
 		locksOwned = make(map[locking.Lock]bool)
 		for _, lock := range ourLocks {
 			locksOwned[lock] = true
 		}
+
+		locksBlocking = make(map[locking.Lock]bool)
+		blockingLocks := make([]locking.Lock, 0)
+		pending := pendingPushPaths(refUpdate)
+		for _, lock := range theirLocks {
+			if pending[lock.Path] {
+				locksBlocking[lock] = true
+				blockingLocks = append(blockingLocks, lock)
+			}
+		}
+
+		result = &verifiedLocks{
+			Ours:     ourLocks,
+			Theirs:   theirLocks,
+			Blocking: blockingLocks,
+		}
+	} else if !locksCmdFlags.Local && !locksCmdFlags.Cached && (locksCmdFlags.Limit > 0 || len(locksCmdFlags.Cursor) > 0) {
+		locks, nextCursor, err = lockClient.SearchLocksFrom(filters, locksCmdFlags.Limit, locksCmdFlags.Cursor)
 	} else {
 		locks, err = lockClient.SearchLocks(filters, locksCmdFlags.Limit, locksCmdFlags.Local, locksCmdFlags.Cached)
-		jsonWriteFunc = func(writer io.Writer) error {
-			return lockClient.EncodeLocks(locks, writer)
-		}
 	}
 
 	// Print any we got before exiting
 
-	if locksCmdFlags.JSON {
-		if err := jsonWriteFunc(os.Stdout); err != nil {
-			Error(err.Error())
+	if locksOwned == nil {
+		locksOwned = ownLocks(lockClient, locks, locksCmdFlags.Local)
+	}
+	defer warnExpiringLocks(locks, locksOwned)
+
+	if jsonOutput {
+		if result == nil {
+			if locks == nil {
+				locks = []locking.Lock{}
+			}
+			result = locks
+		}
+		if len(nextCursor) > 0 {
+			if err := PrintEvent("cursor", &locksCursor{Cursor: nextCursor}); err != nil {
+				ExitWithError(err)
+			}
+		}
+		if err := PrintResult(result); err != nil {
+			ExitWithError(err)
 		}
 		return
 	}
@@ -97,6 +176,7 @@ func locksCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	now := time.Now()
 	sort.Strings(lockPaths)
 	for _, lockPath := range lockPaths {
 		var ownerName string
@@ -108,25 +188,105 @@ func locksCommand(cmd *cobra.Command, args []string) {
 		pathPadding := tools.MaxInt(maxPathLen-len(lock.Path), 0)
 		namePadding := tools.MaxInt(maxNameLen-len(ownerName), 0)
 		kind := ""
-		if locksOwned != nil {
+		if locksCmdFlags.Verify {
 			if locksOwned[lock] {
 				kind = "O "
+			} else if locksBlocking[lock] {
+				kind = "B "
 			} else {
 				kind = "  "
 			}
 		}
 
-		Print("%s%s%s\t%s%s\tID:%s", kind, lock.Path, strings.Repeat(" ", pathPadding),
+		expiry := ""
+		if desc := describeLockExpiry(lock, now); len(desc) > 0 {
+			expiry = "\t" + desc
+		}
+
+		Print("%s%s%s\t%s%s\tID:%s%s", kind, lock.Path, strings.Repeat(" ", pathPadding),
 			ownerName, strings.Repeat(" ", namePadding),
-			lock.Id,
+			lock.Id, expiry,
 		)
 	}
 
+	printNextLocksCursor(nextCursor)
+
 	if err != nil {
 		Exit("Error while retrieving locks: %v", errors.Cause(err))
 	}
 }
 
+// verifiedLocks is the JSON output of `git lfs locks --verify`, which lists
+// the locks held by others on files changed by the pending push alongside our
+// own locks and those of others.
+type verifiedLocks struct {
+	Ours     []locking.Lock `json:"ours"`
+	Theirs   []locking.Lock `json:"theirs"`
+	Blocking []locking.Lock `json:"blocking"`
+}
+
+// pendingPushPaths returns the set of files changed by the commits which the
+// current ref has but its remote-tracking ref lacks, which are those a push of
+// the current ref would update. If the ref has never been pushed, no files are
+// returned, since there is no remote state to compare against.
+func pendingPushPaths(update *git.RefUpdate) map[string]bool {
+	paths := make(map[string]bool)
+	if update.Left() == nil || update.Right() == nil {
+		return paths
+	}
+
+	tracking, err := git.ResolveRef(fmt.Sprintf("refs/remotes/%s/%s",
+		cfg.PushRemote(), update.Right().Name))
+	if err != nil || tracking == nil || len(tracking.Sha) == 0 {
+		tracelog.Printf("locks: no remote-tracking ref to find pending changes: %v", err)
+		return paths
+	}
+
+	changed, err := git.ChangedPaths(tracking.Sha, update.LeftCommitish())
+	if err != nil {
+		tracelog.Printf("locks: unable to find pending changes: %v", err)
+		return paths
+	}
+	for _, path := range changed {
+		paths[path] = true
+	}
+	return paths
+}
+
+// syncLockCache refreshes the local lock cache from the server and brings the
+// write flags of lockable files in line with it, so that later commands can
+// rely on the cache instead of querying the server.
+func syncLockCache(lockClient *locking.Client) {
+	removed, err := lockClient.RefreshCache()
+	if err != nil {
+		Exit("Error while synchronizing locks: %v", errors.Cause(err))
+	}
+	if removed > 0 {
+		Error("Removed %d stale cached lock(s)", removed)
+	}
+
+	if !cfg.SetLockableFilesReadOnly() || len(lockClient.GetLockablePatterns()) == 0 {
+		return
+	}
+	if err := lockClient.FixAllLockableFileWriteFlags(); err != nil {
+		LoggedError(err, "Warning: unable to update write flags of lockable files: %v", err)
+	}
+}
+
+// printNextLocksCursor tells the user how to continue a search which was cut
+// short by --limit, if there are more locks to list.
+// locksCursor is the data of the "cursor" event written by `git lfs locks`
+// with --json when more locks are available than were listed.
+type locksCursor struct {
+	Cursor string `json:"cursor"`
+}
+
+func printNextLocksCursor(cursor string) {
+	if len(cursor) > 0 {
+		Error("More locks are available; use --cursor=%s to list them.", cursor)
+	}
+}
+
 // locksFlags wraps up and holds all of the flags that can be given to the
 // `git lfs locks` command.
 type locksFlags struct {
@@ -136,20 +296,36 @@ type locksFlags struct {
 	// Id is an optional filter parameter used to filtere against the lock's
 	// ID.
 	Id string
+	// Owner is an optional filter parameter used to filter against the
+	// name of the lock's owner.
+	Owner string
+	// PathPrefix is an optional filter parameter used to filter against
+	// the directory containing the lock's path.
+	PathPrefix string
+	// Cursor is an optional request parameter sent to the server to resume
+	// a previous search which was cut short by Limit.
+	Cursor string
 	// limit is an optional request parameter sent to the server used to
 	// limit the
 	Limit int
 	// local limits the scope of lock reporting to the locally cached record
 	// of locks for the current user & doesn't query the server
 	Local bool
-	// JSON is an optional parameter to output data in json format.
-	JSON bool
 	// for non-local queries, report cached query results from the last query
 	// instead of actually querying the server again
 	Cached bool
 	// for non-local queries, verify lock owner on server and
 	// denote our locks in output
 	Verify bool
+	// refresh the cached lock information from the server, drop stale
+	// locks and update the write flags of lockable files accordingly
+	Sync bool
+	// renew the leases of our own expiring locks on files with pending
+	// changes
+	Renew bool
+	// keep renewing the leases of such locks at this interval, for as
+	// long as there are any
+	RenewEvery time.Duration
 }
 
 // Filters produces a filter based on locksFlags instance.
@@ -167,6 +343,20 @@ func (l *locksFlags) Filters() (map[string]string, error) {
 	if l.Id != "" {
 		filters["id"] = l.Id
 	}
+	if l.Owner != "" {
+		filters["owner"] = l.Owner
+	}
+	if l.PathPrefix != "" {
+		// lockPath refuses directories, but still resolves them.
+		path, err := lockPath(l.PathPrefix)
+		if len(path) == 0 && err != nil {
+			return nil, err
+		}
+
+		if path != "." {
+			filters["path_prefix"] = path
+		}
+	}
 
 	return filters, nil
 }
@@ -176,10 +366,15 @@ func init() {
 		cmd.Flags().StringVarP(&lockRemote, "remote", "r", "", lockRemoteHelp)
 		cmd.Flags().StringVarP(&locksCmdFlags.Path, "path", "p", "", "filter locks results matching a particular path")
 		cmd.Flags().StringVarP(&locksCmdFlags.Id, "id", "i", "", "filter locks results matching a particular ID")
+		cmd.Flags().StringVarP(&locksCmdFlags.Owner, "owner", "", "", "filter locks results held by a particular owner")
+		cmd.Flags().StringVarP(&locksCmdFlags.PathPrefix, "path-prefix", "", "", "filter locks results beneath a particular directory")
+		cmd.Flags().StringVarP(&locksCmdFlags.Cursor, "cursor", "", "", "resume listing locks from a cursor given by a previous --limit")
 		cmd.Flags().IntVarP(&locksCmdFlags.Limit, "limit", "l", 0, "optional limit for number of results to return")
 		cmd.Flags().BoolVarP(&locksCmdFlags.Local, "local", "", false, "only list cached local record of own locks")
 		cmd.Flags().BoolVarP(&locksCmdFlags.Cached, "cached", "", false, "list cached lock information from the last remote query, instead of actually querying the server")
 		cmd.Flags().BoolVarP(&locksCmdFlags.Verify, "verify", "", false, "verify lock owner on server and mark own locks by 'O'")
-		cmd.Flags().BoolVarP(&locksCmdFlags.JSON, "json", "", false, "print output in json")
+		cmd.Flags().BoolVarP(&locksCmdFlags.Sync, "sync", "", false, "refresh the local lock cache from the server and update write flags of lockable files")
+		cmd.Flags().BoolVarP(&locksCmdFlags.Renew, "renew", "", false, "renew our own expiring locks on files with pending changes")
+		cmd.Flags().DurationVarP(&locksCmdFlags.RenewEvery, "renew-every", "", 0, "keep renewing our own expiring locks at this interval while their files have pending changes")
 	})
 }
@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/gitattr"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -17,8 +22,30 @@ var (
 	lsFilesShowSize     = false
 	lsFilesShowNameOnly = false
 	debug               = false
+
+	// lsFilesWorktreeSizes holds the sizes of the files in the working
+	// tree which are unchanged from the index, as Git recorded them there,
+	// if Git uses a file system monitor. Other files are examined one at a
+	// time.
+	lsFilesWorktreeSizes map[string]int64
 )
 
+type lsFilesJSONEntry struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	Checkout    bool   `json:"checkout"`
+	Downloaded  bool   `json:"downloaded"`
+	OidType     string `json:"oid_type"`
+	Oid         string `json:"oid"`
+	Version     string `json:"version"`
+	ContentType string `json:"content_type"`
+	Pattern     string `json:"pattern,omitempty"`
+}
+
+type lsFilesJSONOutput struct {
+	Files []lsFilesJSONEntry `json:"files"`
+}
+
 func lsFilesCommand(cmd *cobra.Command, args []string) {
 	setupRepository()
 
@@ -55,6 +82,14 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if cfg.FSMonitor() && len(cfg.LocalWorkingDir()) > 0 {
+		sizes, err := git.WorktreeSizes()
+		if err != nil {
+			tracelog.Printf("unable to read working tree state from the index: %v", err)
+		}
+		lsFilesWorktreeSizes = sizes
+	}
+
 	showOidLen := 10
 	if longOIDs {
 		showOidLen = 64
@@ -62,6 +97,13 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 
 	seen := make(map[string]struct{})
 
+	var out lsFilesJSONOutput
+	var attrPaths []git.AttributePath
+	if jsonOutput {
+		out.Files = make([]lsFilesJSONEntry, 0)
+		attrPaths = git.GetAttributePaths(gitattr.NewMacroProcessor(), cfg.LocalWorkingDir(), cfg.LocalGitDir())
+	}
+
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			Exit("Could not scan for Git LFS tree: %s", err)
@@ -74,8 +116,21 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 			}
 		}
 
-		if debug {
-			Print(
+		if jsonOutput {
+			downloaded := cfg.LFSObjectExists(p.Oid, p.Size)
+			out.Files = append(out.Files, lsFilesJSONEntry{
+				Name:        p.Name,
+				Size:        p.Size,
+				Checkout:    fileExistsOfSize(p),
+				Downloaded:  downloaded,
+				OidType:     p.OidType,
+				Oid:         p.Oid,
+				Version:     p.Version,
+				ContentType: lsFilesContentType(p, downloaded),
+				Pattern:     lsFilesTrackPattern(attrPaths, p.Name),
+			})
+		} else if debug {
+			msg := fmt.Sprintf(
 				"filepath: %s\n"+
 					"    size: %d\n"+
 					"checkout: %v\n"+
@@ -89,6 +144,10 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 				p.OidType,
 				p.Oid,
 				p.Version)
+			if v, ok := p.Metadata[lfs.MetaContentType]; ok {
+				msg += fmt.Sprintf("    type: %s\n", v)
+			}
+			Print("%s", msg)
 		} else {
 			msg := []string{p.Oid[:showOidLen], lsFilesMarker(p), p.Name}
 			if lsFilesShowNameOnly {
@@ -137,10 +196,49 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 			Exit("Could not scan for Git LFS tree: %s", err)
 		}
 	}
+
+	if jsonOutput {
+		if err := PrintResult(&out); err != nil {
+			ExitWithError(err)
+		}
+	}
+}
+
+// lsFilesContentType returns the content type of the Git LFS file p: that
+// recorded in its pointer, if any, or else that detected from its object, if
+// it has been downloaded, and its name.
+func lsFilesContentType(p *lfs.WrappedPointer, downloaded bool) string {
+	if v, ok := p.Metadata[lfs.MetaContentType]; ok {
+		return v
+	}
+
+	var data []byte
+	if downloaded {
+		if r, err := cfg.ObjectStore().OpenObject(p.Oid); err == nil {
+			buf := make([]byte, tools.ContentTypeSniffLen)
+			n, _ := io.ReadFull(r, buf)
+			r.Close()
+			data = buf[:n]
+		}
+	}
+	return tools.DetectContentType(p.Name, data)
+}
+
+// lsFilesTrackPattern returns the gitattributes pattern which applies the LFS
+// filter to "name", or the empty string if none does.
+func lsFilesTrackPattern(paths []git.AttributePath, name string) string {
+	if match := trackPatternFor(paths, name); match != nil && match.Tracked {
+		return match.Path
+	}
+	return ""
 }
 
 // Returns true if a pointer appears to be properly smudge on checkout
 func fileExistsOfSize(p *lfs.WrappedPointer) bool {
+	if size, ok := lsFilesWorktreeSizes[p.Name]; ok {
+		return size == p.Size
+	}
+
 	path := cfg.Filesystem().DecodePathname(p.Name)
 	info, err := os.Stat(path)
 	return err == nil && info.Size() == p.Size
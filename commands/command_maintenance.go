@@ -0,0 +1,362 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	maintenanceTaskArgs    []string
+	maintenanceScheduleArg string
+)
+
+// maintenanceRepoKey is the multi-valued global configuration key listing the
+// repositories whose Git LFS maintenance is run on a schedule, just as
+// "maintenance.repo" lists those of Git's own.
+const maintenanceRepoKey = "lfs.maintenance.repo"
+
+// maintenanceSchedule is how often a maintenance task runs. Greater values run
+// more often, so that running the tasks of one schedule also runs those of
+// every more frequent one, as "git maintenance run --schedule" does.
+type maintenanceSchedule int
+
+const (
+	scheduleNone maintenanceSchedule = iota
+	scheduleWeekly
+	scheduleDaily
+	scheduleHourly
+)
+
+var maintenanceSchedules = map[string]maintenanceSchedule{
+	"hourly": scheduleHourly,
+	"daily":  scheduleDaily,
+	"weekly": scheduleWeekly,
+}
+
+// maintenanceTask is a piece of housekeeping run by "git lfs maintenance run".
+// It is enabled and scheduled by "lfs.maintenance.<name>.enabled" and
+// "lfs.maintenance.<name>.schedule", in the same way as Git's own tasks.
+type maintenanceTask struct {
+	name     string
+	schedule maintenanceSchedule
+	run      func() bool
+}
+
+// maintenanceTasks are the tasks in the order in which they run: temporary
+// files are removed before objects are downloaded, and objects are pruned
+// last so that those just downloaded are counted as recent.
+var maintenanceTasks = []*maintenanceTask{
+	{name: "temp", schedule: scheduleDaily, run: maintenanceTemp},
+	{name: "prefetch", schedule: scheduleHourly, run: maintenancePrefetch},
+	{name: "prune", schedule: scheduleWeekly, run: maintenancePrune},
+}
+
+func (t *maintenanceTask) enabled() bool {
+	return cfg.Git.Bool(fmt.Sprintf("lfs.maintenance.%s.enabled", t.name), true)
+}
+
+func (t *maintenanceTask) scheduled() maintenanceSchedule {
+	value, _ := cfg.Git.Get(fmt.Sprintf("lfs.maintenance.%s.schedule", t.name))
+	if s, ok := maintenanceSchedules[value]; ok {
+		return s
+	}
+	return t.schedule
+}
+
+// maintenanceRunCommand runs the enabled maintenance tasks, or only those named
+// with --task, or only those due with --schedule.
+func maintenanceRunCommand(cmd *cobra.Command, args []string) {
+	if len(args) > 0 {
+		Exit("Usage: git lfs maintenance run [--task=<task>]... [--schedule=<frequency>]")
+	}
+	setupRepository()
+
+	var schedule maintenanceSchedule
+	if len(maintenanceScheduleArg) > 0 {
+		var ok bool
+		if schedule, ok = maintenanceSchedules[maintenanceScheduleArg]; !ok {
+			Exit("Invalid schedule %q; expected \"hourly\", \"daily\" or \"weekly\"", maintenanceScheduleArg)
+		}
+		if len(maintenanceTaskArgs) > 0 {
+			Exit("Cannot combine --task with --schedule")
+		}
+	}
+
+	var tasks []*maintenanceTask
+	if len(maintenanceTaskArgs) > 0 {
+		for _, name := range maintenanceTaskArgs {
+			t := findMaintenanceTask(name)
+			if t == nil {
+				Exit("Invalid maintenance task %q; expected \"prefetch\", \"temp\" or \"prune\"", name)
+			}
+			tasks = append(tasks, t)
+		}
+	} else {
+		for _, t := range maintenanceTasks {
+			if !t.enabled() {
+				continue
+			}
+			if schedule != scheduleNone && t.scheduled() < schedule {
+				continue
+			}
+			tasks = append(tasks, t)
+		}
+	}
+
+	var failed []string
+	for _, t := range tasks {
+		if !t.run() {
+			failed = append(failed, t.name)
+		}
+	}
+	if len(failed) > 0 {
+		Exit("maintenance: task(s) failed: %s", strings.Join(failed, ", "))
+	}
+}
+
+func findMaintenanceTask(name string) *maintenanceTask {
+	for _, t := range maintenanceTasks {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// maintenanceTemp removes temporary files and partial downloads left behind
+// by interrupted commands.
+func maintenanceTemp() bool {
+	logger := tasklog.NewLogger(OutputWriter,
+		tasklog.ForceProgress(cfg.ForceProgress()),
+	)
+	pruneStaleFiles("maintenance", logger)
+	logger.Close()
+	return true
+}
+
+// maintenancePrefetch downloads the objects of the current ref and of recent
+// refs and commits, as "git lfs fetch --recent" does, so that they are present
+// before they are checked out. Repositories without remotes are skipped.
+func maintenancePrefetch() bool {
+	if len(cfg.Remotes()) == 0 {
+		return true
+	}
+
+	ref, err := git.CurrentRef()
+	if err != nil {
+		Error("maintenance: could not find the current ref: %v", err)
+		return false
+	}
+
+	fetchPruneCfg := lfs.NewFetchPruneConfig(cfg.Git)
+	filter := buildFilepathFilter(cfg, nil, nil, true)
+
+	Print("fetch: Fetching reference %s", ref.Refspec())
+	ok := fetchRef(ref.Sha, filter)
+	ok = fetchRecent(fetchPruneCfg, []*git.Ref{ref}, filter) && ok
+	evictCache()
+	return ok
+}
+
+// maintenancePrune deletes objects which are no longer needed, as "git lfs
+// prune" does.
+func maintenancePrune() bool {
+	fetchPruneCfg := lfs.NewFetchPruneConfig(cfg.Git)
+	prune(fetchPruneCfg, fetchPruneCfg.PruneVerifyRemoteAlways, false, false)
+	return true
+}
+
+// maintenanceRegisterCommand registers the repository for Git's scheduled
+// maintenance, and for that of Git LFS.
+func maintenanceRegisterCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+	maintenanceRegister()
+}
+
+// maintenanceUnregisterCommand removes the repository from Git's scheduled
+// maintenance, and from that of Git LFS.
+func maintenanceUnregisterCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	repo := maintenanceRepo()
+	for _, r := range cfg.GitConfig().FindAllGlobal(maintenanceRepoKey) {
+		if r == repo {
+			if _, err := cfg.GitConfig().UnsetGlobalValue(maintenanceRepoKey, repo); err != nil {
+				ExitWithError(err)
+			}
+			break
+		}
+	}
+
+	if err := git.Maintenance("unregister"); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// maintenanceStartCommand registers the repository, starts Git's scheduled
+// maintenance, and schedules that of Git LFS alongside it with cron(8).
+func maintenanceStartCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+	maintenanceRegister()
+
+	if err := git.Maintenance("start"); err != nil {
+		ExitWithError(err)
+	}
+
+	schedule, err := maintenanceCrontab()
+	if err != nil {
+		ExitWithError(err)
+	}
+	if err := updateCrontab(schedule); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// maintenanceStopCommand removes the schedule of Git LFS maintenance and stops
+// Git's scheduled maintenance. Registered repositories stay registered.
+func maintenanceStopCommand(cmd *cobra.Command, args []string) {
+	if err := updateCrontab(""); err != nil {
+		ExitWithError(err)
+	}
+	if err := git.Maintenance("stop"); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func maintenanceRegister() {
+	if err := git.Maintenance("register"); err != nil {
+		ExitWithError(err)
+	}
+
+	repo := maintenanceRepo()
+	for _, r := range cfg.GitConfig().FindAllGlobal(maintenanceRepoKey) {
+		if r == repo {
+			return
+		}
+	}
+	if _, err := cfg.GitConfig().AddGlobal(maintenanceRepoKey, repo); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// maintenanceRepo returns the path by which the repository is registered,
+// which is that of its working tree, or of the repository itself if it is
+// bare.
+func maintenanceRepo() string {
+	dir := cfg.LocalWorkingDir()
+	if len(dir) == 0 {
+		dir = cfg.LocalGitDir()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.ToSlash(dir)
+}
+
+const (
+	crontabBegin = "# BEGIN GIT LFS MAINTENANCE SCHEDULE"
+	crontabEnd   = "# END GIT LFS MAINTENANCE SCHEDULE"
+)
+
+// maintenanceCrontab returns the crontab(5) entries which run the maintenance
+// of every registered repository: hourly tasks each hour, daily ones each day
+// but Sunday, and weekly ones on Sundays, at a random minute so that not
+// every machine contacts its servers at once.
+func maintenanceCrontab() (string, error) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return "", err
+	}
+	gitPath, _ = filepath.Abs(gitPath)
+	lfsPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	command := fmt.Sprintf("PATH=\"%s:$PATH\" \"%s\" for-each-repo --config=%s lfs maintenance run",
+		filepath.Dir(lfsPath), gitPath, maintenanceRepoKey)
+	minute := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(60)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, crontabBegin)
+	fmt.Fprintln(&b, "# The following schedule was created by Git LFS")
+	fmt.Fprintln(&b, "# Any edits made in this region might be")
+	fmt.Fprintln(&b, "# replaced in the future by a Git LFS command.")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "%d 1-23 * * * %s --schedule=hourly\n", minute, command)
+	fmt.Fprintf(&b, "%d 0 * * 1-6 %s --schedule=daily\n", minute, command)
+	fmt.Fprintf(&b, "%d 0 * * 0 %s --schedule=weekly\n", minute, command)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, crontabEnd)
+	return b.String(), nil
+}
+
+// updateCrontab replaces the Git LFS maintenance schedule in the user's
+// crontab with the given one, or removes it if schedule is empty, leaving the
+// rest of the crontab, including Git's own schedule, as it was.
+func updateCrontab(schedule string) error {
+	// "crontab -l" fails if the user has no crontab yet.
+	current, _ := subprocess.SimpleExec("crontab", "-l")
+
+	var b bytes.Buffer
+	if len(current) > 0 {
+		skipping := false
+		for _, line := range strings.Split(current, "\n") {
+			switch {
+			case line == crontabBegin:
+				skipping = true
+			case line == crontabEnd:
+				skipping = false
+			case !skipping:
+				fmt.Fprintln(&b, line)
+			}
+		}
+	}
+	b.WriteString(schedule)
+
+	f, err := ioutil.TempFile("", "git-lfs-crontab")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := subprocess.SimpleExec("crontab", f.Name()); err != nil {
+		return fmt.Errorf("could not update the crontab: %v", err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterCommand("maintenance", nil, func(cmd *cobra.Command) {
+		run := NewCommand("run", maintenanceRunCommand)
+		run.Flags().StringSliceVar(&maintenanceTaskArgs, "task", nil, "Run only the named task(s)")
+		run.Flags().StringVar(&maintenanceScheduleArg, "schedule", "", "Run the tasks due \"hourly\", \"daily\" or \"weekly\"")
+
+		cmd.AddCommand(run,
+			NewCommand("register", maintenanceRegisterCommand),
+			NewCommand("unregister", maintenanceUnregisterCommand),
+			NewCommand("start", maintenanceStartCommand),
+			NewCommand("stop", maintenanceStopCommand),
+		)
+	})
+}
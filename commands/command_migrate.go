@@ -6,10 +6,12 @@ import (
 	"io"
 	"strings"
 
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/git/githistory"
 	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/git-lfs/git-lfs/tr"
 	"github.com/git-lfs/gitobj/v2"
 	"github.com/spf13/cobra"
 )
@@ -250,7 +252,7 @@ func getRemoteRefs(l *tasklog.Logger) (map[string][]*git.Ref, error) {
 	}
 
 	if !migrateSkipFetch {
-		w := l.Waiter("migrate: Fetching remote refs")
+		w := l.Waiter(tr.Tr.Get("migrate: Fetching remote refs"))
 		if err := git.Fetch(remotes...); err != nil {
 			return nil, err
 		}
@@ -327,6 +329,8 @@ func ensureWorkingCopyClean(in io.Reader, out io.Writer) {
 	var proceed bool
 	if migrateYes {
 		proceed = true
+	} else if cfg.NonInteractive() {
+		Exit("migrate: working copy must not be dirty; pass --yes to override your uncommitted changes, as %s is set", config.NonInteractiveEnv)
 	} else {
 		answer := bufio.NewReader(in)
 	L:
@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/git/githistory"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tr"
 	"github.com/git-lfs/gitobj/v2"
 	"github.com/spf13/cobra"
 )
@@ -56,7 +59,7 @@ func migrateExportCommand(cmd *cobra.Command, args []string) {
 				return nil, err
 			}
 
-			downloadPath, err := gitfilter.ObjectPath(ptr.Oid)
+			downloadPath, err := cfg.Filesystem().DecompressedObjectPath(ptr.Oid)
 			if err != nil {
 				return nil, err
 			}
@@ -135,7 +138,7 @@ func migrateExportCommand(cmd *cobra.Command, args []string) {
 				return
 			}
 
-			if _, err := os.Stat(downloadPath); os.IsNotExist(err) {
+			if _, _, err := fs.ObjectFileSize(downloadPath); os.IsNotExist(err) {
 				q.Add(p.Name, downloadPath, p.Oid, p.Size, false, nil)
 			}
 		})
@@ -157,7 +160,7 @@ func migrateExportCommand(cmd *cobra.Command, args []string) {
 
 	// Only perform `git-checkout(1) -f` if the repository is non-bare.
 	if bare, _ := git.IsBare(); !bare {
-		t := l.Waiter("migrate: checkout")
+		t := l.Waiter(tr.Tr.Get("migrate: checkout"))
 		err := git.Checkout("", nil, true)
 		t.Complete()
 
@@ -186,12 +189,23 @@ func migrateExportCommand(cmd *cobra.Command, args []string) {
 func trackedFromExportFilter(filter *filepathfilter.Filter) *tools.OrderedSet {
 	tracked := tools.NewOrderedSet()
 
+	// Git doesn't allow negated patterns in .gitattributes, so a negated
+	// pattern is written as the pattern itself with the opposite
+	// attributes, which take precedence as they come later in the file.
 	for _, include := range filter.Include() {
-		tracked.Add(fmt.Sprintf("%s !text !filter !merge !diff", escapeAttrPattern(include)))
+		if pattern := strings.TrimPrefix(include, "!"); pattern != include {
+			tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(pattern)))
+		} else {
+			tracked.Add(fmt.Sprintf("%s !text !filter !merge !diff", escapeAttrPattern(include)))
+		}
 	}
 
 	for _, exclude := range filter.Exclude() {
-		tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(exclude)))
+		if pattern := strings.TrimPrefix(exclude, "!"); pattern != exclude {
+			tracked.Add(fmt.Sprintf("%s !text !filter !merge !diff", escapeAttrPattern(pattern)))
+		} else {
+			tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(exclude)))
+		}
 	}
 
 	return tracked
@@ -18,6 +18,7 @@ import (
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tr"
 	"github.com/git-lfs/gitobj/v2"
 	"github.com/spf13/cobra"
 )
@@ -68,7 +69,11 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 			ExitWithError(errors.Errorf("fatal: no Git LFS filters found in .gitattributes"))
 		}
 
+		// The blobs cleaned are not the files in the working tree,
+		// so the clean cache must not describe them.
 		gf := lfs.NewGitFilter(cfg)
+		gf.DisableCleanCache()
+		gf.SetProgressStream(getProgressStream())
 
 		for _, file := range args {
 			if !filter.Allows(file) {
@@ -132,6 +137,8 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 	tracked := trackedFromFilter(rewriter.Filter())
 	exts := tools.NewOrderedSet()
 	gitfilter := lfs.NewGitFilter(cfg)
+	gitfilter.DisableCleanCache()
+	gitfilter.SetProgressStream(getProgressStream())
 
 	var fixups *gitattr.Tree
 	above, err := humanize.ParseBytes(migrateImportAboveFmt)
@@ -166,7 +173,7 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 
 			var buf bytes.Buffer
 
-			if _, err := clean(gitfilter, &buf, b.Contents, path, b.Size); err != nil {
+			if _, err := clean(gitfilter, nil, &buf, b.Contents, path, b.Size); err != nil {
 				return nil, err
 			}
 
@@ -276,7 +283,7 @@ func checkoutNonBare(l *tasklog.Logger) error {
 		return nil
 	}
 
-	t := l.Waiter("migrate: checkout")
+	t := l.Waiter(tr.Tr.Get("migrate: checkout"))
 	defer t.Complete()
 
 	return git.Checkout("", nil, true)
@@ -288,12 +295,23 @@ func checkoutNonBare(l *tasklog.Logger) error {
 func trackedFromFilter(filter *filepathfilter.Filter) *tools.OrderedSet {
 	tracked := tools.NewOrderedSet()
 
+	// Git doesn't allow negated patterns in .gitattributes, so a negated
+	// pattern is written as the pattern itself with the opposite
+	// attributes, which take precedence as they come later in the file.
 	for _, include := range filter.Include() {
-		tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(include)))
+		if pattern := strings.TrimPrefix(include, "!"); pattern != include {
+			tracked.Add(fmt.Sprintf("%s !text -filter -merge -diff", escapeAttrPattern(pattern)))
+		} else {
+			tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(include)))
+		}
 	}
 
 	for _, exclude := range filter.Exclude() {
-		tracked.Add(fmt.Sprintf("%s !text -filter -merge -diff", escapeAttrPattern(exclude)))
+		if pattern := strings.TrimPrefix(exclude, "!"); pattern != exclude {
+			tracked.Add(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", escapeAttrPattern(pattern)))
+		} else {
+			tracked.Add(fmt.Sprintf("%s !text -filter -merge -diff", escapeAttrPattern(exclude)))
+		}
 	}
 
 	return tracked
@@ -396,7 +414,7 @@ func rewriteTree(gf *lfs.GitFilter, db *gitobj.ObjectDatabase, root []byte, path
 
 		var buf bytes.Buffer
 
-		if _, err := clean(gf, &buf, blob.Contents, blobEntry.Name, blob.Size); err != nil {
+		if _, err := clean(gf, nil, &buf, blob.Contents, blobEntry.Name, blob.Size); err != nil {
 			return nil, err
 		}
 
@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools/fuse"
+	"github.com/spf13/cobra"
+)
+
+// mountServer is the mount made by "git lfs mount", which is unmounted by
+// Cleanup if the command is interrupted.
+var mountServer *fuse.Server
+
+// mountCommand mounts the tree of a ref read-only at a directory, where the
+// contents of Git LFS files are downloaded only when they are first read, and
+// serves it until it is unmounted.
+func mountCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		Exit("Usage: git lfs mount <ref> <directory>")
+	}
+	setupRepository()
+
+	ref, err := git.ResolveRef(args[0])
+	if err != nil {
+		Exit("Could not resolve %q: %v", args[0], err)
+	}
+
+	m := &mounter{
+		gitfilter: lfs.NewGitFilter(cfg),
+		fetching:  make(map[string]*sync.Mutex),
+	}
+	root, err := m.tree(ref.Sha)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	mountServer, err = fuse.Mount(args[1], root)
+	if err != nil {
+		Exit("Could not mount %s: %v", args[1], err)
+	}
+
+	Print("Mounted %s at %s; unmount it or press Ctrl-C to stop", args[0], args[1])
+	err = mountServer.Serve()
+	mountServer = nil
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
+// unmount unmounts the tree mounted by "git lfs mount", if any.
+func unmount() {
+	if mountServer != nil {
+		if err := mountServer.Unmount(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// mounter builds the tree of a ref to be mounted by "git lfs mount", and opens
+// its files.
+type mounter struct {
+	gitfilter *lfs.GitFilter
+
+	// fetching holds a lock for each object, so that it is downloaded
+	// only once when it is opened by several processes at once.
+	mu       sync.Mutex
+	fetching map[string]*sync.Mutex
+}
+
+// tree returns the tree of files at the commit sha, in which Git LFS files
+// have the sizes of their objects.
+func (m *mounter) tree(sha string) (*fuse.Node, error) {
+	pointers := make(map[string]*lfs.WrappedPointer)
+	var errs []error
+
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		pointers[p.Name] = p
+	})
+	err := gitscanner.ScanTree(sha)
+	gitscanner.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.Combine(errs); err != nil {
+		return nil, err
+	}
+
+	lstree, err := git.LsTree(sha, true)
+	if err != nil {
+		return nil, err
+	}
+	lstree.Stdin.Close()
+
+	root := fuse.NewDir()
+	scanner := git.NewLsTreeScanner(lstree.Stdout)
+	for scanner.Scan() {
+		t := scanner.TreeBlob()
+		if t == nil {
+			continue
+		}
+
+		n, err := m.node(t, pointers[t.Filename])
+		if err != nil {
+			return nil, err
+		}
+		if err := root.Add(t.Filename, n); err != nil {
+			return nil, err
+		}
+	}
+
+	stderr, _ := ioutil.ReadAll(lstree.Stderr)
+	if err := lstree.Wait(); err != nil {
+		return nil, fmt.Errorf("error in git ls-tree: %v %s", err, stderr)
+	}
+	return root, nil
+}
+
+// node returns the node for the blob t, which is the Git LFS file p if p is not
+// nil.
+func (m *mounter) node(t *git.TreeBlob, p *lfs.WrappedPointer) (*fuse.Node, error) {
+	perm := os.FileMode(0644)
+	if t.Mode&0111 != 0 {
+		perm = 0755
+	}
+
+	switch {
+	case t.Mode == 0120000:
+		target, err := mountReadBlob(t.Oid)
+		if err != nil {
+			return nil, err
+		}
+		return &fuse.Node{Mode: os.ModeSymlink | 0777, Target: string(target)}, nil
+	case p != nil:
+		return &fuse.Node{
+			Mode: perm,
+			Size: p.Size,
+			Open: func() (fuse.File, error) { return m.openObject(p) },
+		}, nil
+	default:
+		oid, name := t.Oid, t.Filename
+		return &fuse.Node{
+			Mode: perm,
+			Size: t.Size,
+			Open: func() (fuse.File, error) {
+				data, err := mountReadBlob(oid)
+				if err != nil {
+					Error("mount: could not read %s: %v", name, err)
+					return nil, err
+				}
+				return nopCloser{bytes.NewReader(data)}, nil
+			},
+		}, nil
+	}
+}
+
+// openObject opens the object of the Git LFS file p, downloading it first if
+// it is not present locally.
+func (m *mounter) openObject(p *lfs.WrappedPointer) (fuse.File, error) {
+	if p.Size == 0 {
+		return nopCloser{bytes.NewReader(nil)}, nil
+	}
+	if err := m.fetch(p); err != nil {
+		Error("mount: could not download %s: %v", p.Name, err)
+		return nil, err
+	}
+
+	r, err := cfg.ObjectStore().OpenObject(p.Oid)
+	if err != nil {
+		Error("mount: could not open %s: %v", p.Name, err)
+		return nil, err
+	}
+	if f, ok := r.(*os.File); ok {
+		return f, nil
+	}
+	return &sequentialReaderAt{oid: p.Oid, r: r}, nil
+}
+
+func (m *mounter) fetch(p *lfs.WrappedPointer) error {
+	m.mu.Lock()
+	l, ok := m.fetching[p.Oid]
+	if !ok {
+		l = &sync.Mutex{}
+		m.fetching[p.Oid] = l
+	}
+	m.mu.Unlock()
+
+	l.Lock()
+	defer l.Unlock()
+
+	if cfg.LFSObjectExists(p.Oid, p.Size) {
+		return nil
+	}
+	_, err := m.gitfilter.Smudge(ioutil.Discard, p.Pointer, p.Name, true, getTransferManifest(), nil)
+	return err
+}
+
+// mountReadBlob returns the contents of the blob oid.
+func mountReadBlob(oid string) ([]byte, error) {
+	cmd, r, err := git.BlobReader(oid)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	if werr := cmd.Wait(); err == nil {
+		err = werr
+	}
+	return data, err
+}
+
+type nopCloser struct {
+	io.ReaderAt
+}
+
+func (nopCloser) Close() error { return nil }
+
+// sequentialReaderAt reads an object which can only be read from start to end,
+// such as a compressed one, at the offsets requested. Reads are expected to be
+// mostly sequential; the object is opened again to read before the current
+// offset.
+type sequentialReaderAt struct {
+	oid string
+
+	mu  sync.Mutex
+	r   io.ReadCloser
+	off int64
+}
+
+func (s *sequentialReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if off < s.off {
+		r, err := cfg.ObjectStore().OpenObject(s.oid)
+		if err != nil {
+			return 0, err
+		}
+		s.r.Close()
+		s.r, s.off = r, 0
+	}
+	if off > s.off {
+		n, err := io.CopyN(ioutil.Discard, s.r, off-s.off)
+		s.off += n
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(s.r, p)
+	s.off += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *sequentialReaderAt) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.r.Close()
+}
+
+func init() {
+	RegisterCommand("mount", mountCommand, nil)
+}
@@ -2,7 +2,6 @@ package commands
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +22,7 @@ var (
 	pointerCheck    bool
 	pointerStrict   bool
 	pointerNoStrict bool
+	pointerFix      bool
 )
 
 func pointerCommand(cmd *cobra.Command, args []string) {
@@ -30,6 +31,17 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	buildOid := ""
 	compareOid := ""
 
+	if pointerFix {
+		if pointerCheck || len(pointerCompare) > 0 {
+			ExitWithError(fmt.Errorf("fatal: cannot combine --fix with --check or --pointer"))
+		}
+		if len(pointerFile) > 0 == pointerStdin {
+			ExitWithError(fmt.Errorf("fatal: must specify either --file or --stdin with --fix"))
+		}
+		fixPointer()
+		return
+	}
+
 	if pointerCheck {
 		var r io.ReadCloser
 		var err error
@@ -46,14 +58,14 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 			if pointerStdin {
 				ExitWithError(fmt.Errorf("fatal: with --check, --file cannot be combined with --stdin"))
 			}
-			r, err = os.Open(pointerFile)
+			r, _, err = pointerFileReader()
 			if err != nil {
 				ExitWithError(err)
 			}
 		} else if pointerStdin {
 			r = ioutil.NopCloser(os.Stdin)
 		} else {
-			ExitWithError(fmt.Errorf("fatal: must specify either --file or --stdin with --compare"))
+			ExitWithError(fmt.Errorf("fatal: must specify either --file or --stdin with --check"))
 		}
 
 		p, err := lfs.DecodePointer(r)
@@ -73,13 +85,29 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 
 	if len(pointerFile) > 0 {
 		something = true
-		buildFile, err := os.Open(pointerFile)
+		if pointerFile == "-" && pointerStdin {
+			ExitWithError(fmt.Errorf("fatal: cannot read both --file=- and --stdin from STDIN"))
+		}
+
+		buildFile, buildName, err := pointerFileReader()
 		if err != nil {
 			Error(err.Error())
 			os.Exit(1)
 		}
 
-		oidHash := sha256.New()
+		// Only consult "lfs.hashalgorithm" within a repository, since
+		// reading the Git configuration elsewhere produces warnings.
+		algo := tools.HashAlgorithmSHA256
+		if cfg.InRepo() {
+			algo = cfg.HashAlgorithm()
+		}
+
+		oidHash := tools.NewLfsContentHashAlgorithm(algo)
+		if oidHash == nil {
+			buildFile.Close()
+			Error("Unsupported lfs.hashalgorithm: %q", algo)
+			os.Exit(1)
+		}
 		size, err := io.Copy(oidHash, buildFile)
 		buildFile.Close()
 
@@ -89,7 +117,7 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 		}
 
 		ptr := lfs.NewPointer(hex.EncodeToString(oidHash.Sum(nil)), size, nil)
-		fmt.Fprintf(os.Stderr, "Git LFS pointer for %s\n\n", pointerFile)
+		fmt.Fprintf(os.Stderr, "Git LFS pointer for %s\n\n", buildName)
 		buf := &bytes.Buffer{}
 		lfs.EncodePointer(io.MultiWriter(os.Stdout, buf), ptr)
 
@@ -151,6 +179,59 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// fixPointer repairs the damaged pointer in the file given by --file in place,
+// or writes the repaired pointer read from STDIN to STDOUT.
+func fixPointer() {
+	var r io.ReadCloser = os.Stdin
+	name := "STDIN"
+	if len(pointerFile) > 0 {
+		f, err := os.Open(pointerFile)
+		if err != nil {
+			ExitWithError(err)
+		}
+		r = f
+		name = pointerFile
+	}
+
+	p, damage, err := lfs.DecodePointerLenient(r)
+	r.Close()
+	if err != nil {
+		Error("%s is not a Git LFS pointer: %s", name, err)
+		os.Exit(1)
+	}
+
+	if pointerStdin {
+		lfs.EncodePointer(os.Stdout, p)
+	} else if !p.Canonical {
+		stat, err := os.Stat(pointerFile)
+		if err != nil {
+			ExitWithError(err)
+		}
+		if err := ioutil.WriteFile(pointerFile, []byte(p.Encoded()), stat.Mode()); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	switch {
+	case damage != 0:
+		fmt.Fprintf(os.Stderr, "Repaired pointer from %s (%s)\n", name, damage)
+	case !p.Canonical:
+		fmt.Fprintf(os.Stderr, "Repaired pointer from %s (not canonical)\n", name)
+	}
+}
+
+// pointerFileReader opens the file given by --file, or STDIN if it is "-",
+// returning it along with its name for display.
+func pointerFileReader() (io.ReadCloser, string, error) {
+	if pointerFile == "-" {
+		requireStdin("The --file=- flag expects file contents from STDIN.")
+		return ioutil.NopCloser(os.Stdin), "STDIN", nil
+	}
+
+	f, err := os.Open(pointerFile)
+	return f, pointerFile, err
+}
+
 func pointerReader() (io.ReadCloser, error) {
 	if len(pointerCompare) > 0 {
 		if pointerStdin {
@@ -173,5 +254,6 @@ func init() {
 		cmd.Flags().BoolVarP(&pointerCheck, "check", "", false, "Check whether the given file is a Git LFS pointer.")
 		cmd.Flags().BoolVarP(&pointerStrict, "strict", "", false, "Check whether the given Git LFS pointer is canonical.")
 		cmd.Flags().BoolVarP(&pointerNoStrict, "no-strict", "", false, "Don't check whether the given Git LFS pointer is canonical.")
+		cmd.Flags().BoolVarP(&pointerFix, "fix", "", false, "Repair a Git LFS pointer damaged by CRLF conversion or byte order mark insertion.")
 	})
 }
@@ -5,7 +5,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/locking"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -39,7 +39,7 @@ func postCheckoutCommand(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
-	if args[2] == "1" && args[0] != "0000000000000000000000000000000000000000" {
+	if args[2] == "1" && !git.IsZeroObjectID(args[0]) {
 		postCheckoutRevChange(lockClient, args[0], args[1])
 	} else {
 		postCheckoutFileChange(lockClient)
@@ -48,7 +48,7 @@ func postCheckoutCommand(cmd *cobra.Command, args []string) {
 }
 
 func postCheckoutRevChange(client *locking.Client, pre, post string) {
-	tracerx.Printf("post-checkout: changes between %v and %v", pre, post)
+	tracelog.Printf("post-checkout: changes between %v and %v", pre, post)
 	// We can speed things up by looking at the difference between previous HEAD
 	// and current HEAD, and only checking lockable files that are different
 	files, err := git.GetFilesChanged(pre, post)
@@ -57,7 +57,7 @@ func postCheckoutRevChange(client *locking.Client, pre, post string) {
 		LoggedError(err, "Warning: post-checkout rev diff %v:%v failed: %v\nFalling back on full scan.", pre, post, err)
 		postCheckoutFileChange(client)
 	}
-	tracerx.Printf("post-checkout: checking write flags on %v", files)
+	tracelog.Printf("post-checkout: checking write flags on %v", files)
 	err = client.FixLockableFileWriteFlags(files)
 	if err != nil {
 		LoggedError(err, "Warning: post-checkout locked file check failed: %v", err)
@@ -66,7 +66,7 @@ func postCheckoutRevChange(client *locking.Client, pre, post string) {
 }
 
 func postCheckoutFileChange(client *locking.Client) {
-	tracerx.Printf("post-checkout: checking write flags for all lockable files")
+	tracelog.Printf("post-checkout: checking write flags for all lockable files")
 	// Sadly we don't get any information about what files were checked out,
 	// so we have to check the entire repo
 	err := client.FixAllLockableFileWriteFlags()
@@ -4,7 +4,7 @@ import (
 	"os"
 
 	"github.com/git-lfs/git-lfs/git"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +31,7 @@ func postCommitCommand(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
-	tracerx.Printf("post-commit: checking file write flags at HEAD")
+	tracelog.Printf("post-commit: checking file write flags at HEAD")
 	// We can speed things up by looking at what changed in
 	// HEAD, and only checking those lockable files
 	files, err := git.GetFilesChanged("HEAD", "")
@@ -40,7 +40,7 @@ func postCommitCommand(cmd *cobra.Command, args []string) {
 		LoggedError(err, "Warning: post-commit failed: %v", err)
 		os.Exit(1)
 	}
-	tracerx.Printf("post-commit: checking write flags on %v", files)
+	tracelog.Printf("post-commit: checking write flags on %v", files)
 	err = lockClient.FixLockableFileWriteFlags(files)
 	if err != nil {
 		LoggedError(err, "Warning: post-commit locked file check failed: %v", err)
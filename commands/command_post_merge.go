@@ -3,7 +3,7 @@ package commands
 import (
 	"os"
 
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -36,7 +36,7 @@ func postMergeCommand(cmd *cobra.Command, args []string) {
 	// Whether it's squash or not is irrelevant, either way it could have
 	// reset the read-only flag on files that got merged.
 
-	tracerx.Printf("post-merge: checking write flags for all lockable files")
+	tracelog.Printf("post-merge: checking write flags for all lockable files")
 	// Sadly we don't get any information about what files were checked out,
 	// so we have to check the entire repo
 	err := lockClient.FixAllLockableFileWriteFlags()
@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/git"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -80,7 +80,7 @@ func prePushRefs(r io.Reader) []*git.RefUpdate {
 			continue
 		}
 
-		tracerx.Printf("pre-push: %s", line)
+		tracelog.Printf("pre-push: %s", line)
 
 		left, right := decodeRefs(line)
 		if git.IsZeroObjectID(left.Sha) {
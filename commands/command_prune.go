@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,11 +16,13 @@ import (
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/semaphore"
 )
@@ -29,6 +34,11 @@ var (
 	pruneRecentArg      bool
 	pruneForceArg       bool
 	pruneDoNotVerifyArg bool
+
+	// prunePrintRetainedArg is set when prune is run from another
+	// repository sharing this one's storage directory, which needs to know
+	// the objects that this repository retains.
+	prunePrintRetainedArg bool
 )
 
 func pruneCommand(cmd *cobra.Command, args []string) {
@@ -60,24 +70,49 @@ type PruneProgress struct {
 }
 type PruneProgressChan chan PruneProgress
 
+// retainedObject is an object which must not be pruned, and why.
+type retainedObject struct {
+	Oid    string
+	Reason string
+}
+
+// retain sends "oid" to "retainChan", to be retained for "reason".
+func retain(retainChan chan retainedObject, oid, reason string) {
+	retainChan <- retainedObject{Oid: oid, Reason: reason}
+	tracelog.Printf("RETAIN: %v %v", oid, reason)
+}
+
 func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose bool) {
 	localObjects := make([]fs.Object, 0, 100)
 	retainedObjects := tools.NewStringSetWithCapacity(100)
+	retainReasons := make(map[string]string)
+
+	var output io.Writer = OutputWriter
+	if prunePrintRetainedArg {
+		output = ioutil.Discard
+	}
 
-	logger := tasklog.NewLogger(OutputWriter,
+	logger := tasklog.NewLogger(output,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	defer logger.Close()
 
+	// Objects in a shared storage directory must also be retained for the
+	// other repositories using it.
+	shared := cfg.Filesystem().IsShared() && !prunePrintRetainedArg
+	if shared {
+		registerSharedStorage()
+	}
+
 	var reachableObjects tools.StringSet
 	var taskwait sync.WaitGroup
 
 	// Add all the base funcs to the waitgroup before starting them, in case
 	// one completes really fast & hits 0 unexpectedly
 	// each main process can Add() to the wg itself if it subdivides the task
-	taskwait.Add(5) // 1..5: localObjects, current & recent refs, unpushed, worktree, stashes
+	taskwait.Add(1) // localObjects, the retaining tasks add themselves
 	if verifyRemote {
-		taskwait.Add(1) // 6
+		taskwait.Add(1)
 	}
 
 	progressChan := make(PruneProgressChan, 100)
@@ -93,17 +128,12 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	go pruneTaskGetLocalObjects(&localObjects, progressChan, &taskwait)
 
 	// Now find files to be retained from many sources
-	retainChan := make(chan string, 100)
-
-	gitscanner := lfs.NewGitScanner(cfg, nil)
-	gitscanner.Filter = filepathfilter.New(nil, cfg.FetchExcludePaths())
+	retainChan := make(chan retainedObject, 100)
 
+	gitscanner := newRetainGitScanner()
 	sem := semaphore.NewWeighted(int64(runtime.NumCPU() * 2))
 
-	go pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner, fetchPruneConfig, retainChan, errorChan, &taskwait, sem)
-	go pruneTaskGetRetainedUnpushed(gitscanner, fetchPruneConfig, retainChan, errorChan, &taskwait, sem)
-	go pruneTaskGetRetainedWorktree(gitscanner, fetchPruneConfig, retainChan, errorChan, &taskwait, sem)
-	go pruneTaskGetRetainedStashed(gitscanner, retainChan, errorChan, &taskwait, sem)
+	pruneStartRetainTasks(gitscanner, fetchPruneConfig, true, shared, retainChan, errorChan, &taskwait, sem)
 	if verifyRemote {
 		reachableObjects = tools.NewStringSetWithCapacity(100)
 		go pruneTaskGetReachableObjects(gitscanner, &reachableObjects, errorChan, &taskwait, sem)
@@ -112,7 +142,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	// Now collect all the retained objects, on separate wait
 	var retainwait sync.WaitGroup
 	retainwait.Add(1)
-	go pruneTaskCollectRetained(&retainedObjects, retainChan, progressChan, &retainwait)
+	go pruneTaskCollectRetained(&retainedObjects, retainReasons, retainChan, progressChan, &retainwait)
 
 	// Report progress
 	var progresswait sync.WaitGroup
@@ -151,7 +181,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 		go func() {
 			for t := range verifyc {
 				verifiedObjects.Add(t.Oid)
-				tracerx.Printf("VERIFIED: %v", t.Oid)
+				tracelog.Printf("VERIFIED: %v", t.Oid)
 				progressChan <- PruneProgress{PruneProgressTypeVerify, 1}
 			}
 			verifywait.Done()
@@ -171,7 +201,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 			}
 
 			if verifyRemote {
-				tracerx.Printf("VERIFYING: %v", file.Oid)
+				tracelog.Printf("VERIFYING: %v", file.Oid)
 
 				verifyQueue.Add(downloadTransfer(&lfs.WrappedPointer{
 					Pointer: lfs.NewPointer(file.Oid, file.Size, nil),
@@ -191,6 +221,32 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 		progresswait.Wait()
 	}
 
+	if prunePrintRetainedArg {
+		for oid := range retainedObjects.Iter() {
+			fmt.Println(oid)
+		}
+		return
+	}
+
+	if !dryRun {
+		pruneStaleFiles("prune", logger)
+	}
+
+	var retainedOutput []string
+	if dryRun && verbose {
+		verboseOutput, retainedOutput = pruneDryRunReport(localObjects, retainReasons, verifiedObjects)
+	}
+
+	if len(retainedOutput) > 0 {
+		info := tasklog.NewSimpleTask()
+		logger.Enqueue(info)
+		info.Logf(tr.Tr.Get("prune: %d file(s) would be retained"), len(retainedOutput))
+		for _, item := range retainedOutput {
+			info.Logf("\n * %s", item)
+		}
+		info.Complete()
+	}
+
 	if len(prunableObjects) == 0 {
 		return
 	}
@@ -198,7 +254,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	info := tasklog.NewSimpleTask()
 	logger.Enqueue(info)
 	if dryRun {
-		info.Logf("prune: %d file(s) would be pruned (%s)", len(prunableObjects), humanize.FormatBytes(uint64(totalSize)))
+		info.Logf(tr.Tr.Get("prune: %d file(s) would be pruned (%s)"), len(prunableObjects), humanize.FormatBytes(uint64(totalSize)))
 		for _, item := range verboseOutput {
 			info.Logf("\n * %s", item)
 		}
@@ -213,6 +269,62 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	}
 }
 
+// pruneDryRunReport describes the local objects for a verbose dry run. Objects
+// which would be pruned are described by the most recent commit referencing
+// them, and whether they were verified on the remote, if "verifiedObjects" is
+// given. Retained objects are described by the reason they were retained.
+func pruneDryRunReport(localObjects []fs.Object, retainReasons map[string]string, verifiedObjects tools.StringSet) (prunable, retained []string) {
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	gitscanner.Filter = filepathfilter.New(nil, cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+	defer gitscanner.Close()
+
+	refs, err := gitscanner.ScanLastReferences()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	for _, file := range localObjects {
+		desc := fmt.Sprintf("%s (%s)", file.Oid, humanize.FormatBytes(uint64(file.Size)))
+		if reason, ok := retainReasons[file.Oid]; ok {
+			retained = append(retained, fmt.Sprintf("%s: %s", desc, reason))
+			continue
+		}
+
+		if ref, ok := refs[file.Oid]; ok {
+			desc += fmt.Sprintf(": %s, last referenced in %s", ref.Name, ref.Commit[:7])
+		} else {
+			desc += ": not referenced"
+		}
+		if verifiedObjects != nil {
+			if verifiedObjects.Contains(file.Oid) {
+				desc += ", verified on remote"
+			} else {
+				desc += ", not on remote"
+			}
+		}
+		prunable = append(prunable, desc)
+	}
+	return prunable, retained
+}
+
+// pruneStaleFiles removes temporary files and partial downloads left behind by
+// interrupted commands, reporting them as the named command.
+func pruneStaleFiles(name string, logger *tasklog.Logger) {
+	stats, err := cfg.Filesystem().CleanupStale()
+	if err != nil {
+		Error("%s: unable to remove stale temporary files: %s", name, err)
+		return
+	}
+	if stats.Files == 0 {
+		return
+	}
+
+	info := tasklog.NewSimpleTask()
+	logger.Enqueue(info)
+	info.Logf(tr.Tr.Get("%s: removed %d stale temporary file(s) (%s)"), name, stats.Files, humanize.FormatBytes(uint64(stats.Size)))
+	info.Complete()
+}
+
 func pruneCheckVerified(prunableObjects []string, reachableObjects, verifiedObjects tools.StringSet) {
 	// There's no issue if an object is not reachable and missing, only if reachable & missing
 	var problems bytes.Buffer
@@ -223,7 +335,7 @@ func pruneCheckVerified(prunableObjects []string, reachableObjects, verifiedObje
 				problems.WriteString(fmt.Sprintf(" * %v\n", oid))
 			} else {
 				// Just to indicate why it doesn't matter that we didn't verify
-				tracerx.Printf("UNREACHABLE: %v", oid)
+				tracelog.Printf("UNREACHABLE: %v", oid)
 			}
 		}
 	}
@@ -274,14 +386,17 @@ func pruneTaskDisplayProgress(progressChan PruneProgressChan, waitg *sync.WaitGr
 	}
 }
 
-func pruneTaskCollectRetained(outRetainedObjects *tools.StringSet, retainChan chan string,
+func pruneTaskCollectRetained(outRetainedObjects *tools.StringSet, outReasons map[string]string, retainChan chan retainedObject,
 	progressChan PruneProgressChan, retainwait *sync.WaitGroup) {
 
 	defer retainwait.Done()
 
-	for oid := range retainChan {
-		if outRetainedObjects.Add(oid) {
-			progressChan <- PruneProgress{PruneProgressTypeRetain, 1}
+	for obj := range retainChan {
+		if outRetainedObjects.Add(obj.Oid) {
+			outReasons[obj.Oid] = obj.Reason
+			if progressChan != nil {
+				progressChan <- PruneProgress{PruneProgressTypeRetain, 1}
+			}
 		}
 	}
 
@@ -296,20 +411,15 @@ func pruneTaskCollectErrors(outtaskErrors *[]error, errorChan chan error, errorw
 }
 
 func pruneDeleteFiles(prunableObjects []string, logger *tasklog.Logger) {
-	task := logger.Percentage("prune: Deleting objects", uint64(len(prunableObjects)))
+	task := logger.Percentage(tr.Tr.Get("prune: Deleting objects"), uint64(len(prunableObjects)))
 
 	var problems bytes.Buffer
 	// In case we fail to delete some
 	var deletedFiles int
+	store := cfg.ObjectStore()
 	for _, oid := range prunableObjects {
-		mediaFile, err := cfg.Filesystem().ObjectPath(oid)
-		if err != nil {
-			problems.WriteString(fmt.Sprintf("Unable to find media path for %v: %v\n", oid, err))
-			continue
-		}
-		err = os.Remove(mediaFile)
-		if err != nil {
-			problems.WriteString(fmt.Sprintf("Failed to remove file %v: %v\n", mediaFile, err))
+		if err := store.RemoveObject(oid); err != nil {
+			problems.WriteString(fmt.Sprintf("Failed to remove object %v: %v\n", oid, err))
 			continue
 		}
 		deletedFiles++
@@ -333,7 +443,7 @@ func pruneTaskGetLocalObjects(outLocalObjects *[]fs.Object, progChan PruneProgre
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetRetainedAtRef(gitscanner *lfs.GitScanner, ref string, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetRetainedAtRef(gitscanner *lfs.GitScanner, ref, reason string, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	sem.Acquire(context.Background(), 1)
 	defer sem.Release(1)
 	defer waitg.Done()
@@ -345,8 +455,7 @@ func pruneTaskGetRetainedAtRef(gitscanner *lfs.GitScanner, ref string, retainCha
 			return
 		}
 
-		retainChan <- p.Oid
-		tracerx.Printf("RETAIN: %v via ref %v", p.Oid, ref)
+		retain(retainChan, p.Oid, reason)
 	})
 
 	if err != nil {
@@ -355,7 +464,7 @@ func pruneTaskGetRetainedAtRef(gitscanner *lfs.GitScanner, ref string, retainCha
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetPreviousVersionsOfRef(gitscanner *lfs.GitScanner, ref string, since time.Time, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetPreviousVersionsOfRef(gitscanner *lfs.GitScanner, ref string, since time.Time, reason string, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	sem.Acquire(context.Background(), 1)
 	defer sem.Release(1)
 	defer waitg.Done()
@@ -367,8 +476,7 @@ func pruneTaskGetPreviousVersionsOfRef(gitscanner *lfs.GitScanner, ref string, s
 			return
 		}
 
-		retainChan <- p.Oid
-		tracerx.Printf("RETAIN: %v via ref %v >= %v", p.Oid, ref, since)
+		retain(retainChan, p.Oid, reason)
 	})
 
 	if err != nil {
@@ -378,12 +486,13 @@ func pruneTaskGetPreviousVersionsOfRef(gitscanner *lfs.GitScanner, ref string, s
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
 
 	// We actually increment the waitg in this func since we kick off sub-goroutines
 	// Make a list of what unique commits to keep, & search backward from
 	commits := tools.NewStringSet()
+	refNames := make(map[string]string)
 	// Do current first
 	ref, err := git.CurrentRef()
 	if err != nil {
@@ -391,26 +500,28 @@ func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchc
 		return
 	}
 	commits.Add(ref.Sha)
+	refNames[ref.Sha] = ref.Name
 	if !fetchconf.PruneForce {
 		waitg.Add(1)
-		go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, retainChan, errorChan, waitg, sem)
+		go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, "at HEAD", retainChan, errorChan, waitg, sem)
 	}
 
 	// Now recent
 	if !fetchconf.PruneRecent && fetchconf.FetchRecentRefsDays > 0 {
 		pruneRefDays := fetchconf.FetchRecentRefsDays + fetchconf.PruneOffsetDays
-		tracerx.Printf("PRUNE: Retaining non-HEAD refs within %d (%d+%d) days", pruneRefDays, fetchconf.FetchRecentRefsDays, fetchconf.PruneOffsetDays)
+		tracelog.Printf("PRUNE: Retaining non-HEAD refs within %d (%d+%d) days", pruneRefDays, fetchconf.FetchRecentRefsDays, fetchconf.PruneOffsetDays)
 		refsSince := time.Now().AddDate(0, 0, -pruneRefDays)
 		// Keep all recent refs including any recent remote branches
-		refs, err := git.RecentBranches(refsSince, fetchconf.FetchRecentRefsIncludeRemotes, "")
+		refs, err := git.RecentBranches(refsSince, fetchconf.FetchRecentRefsIncludeRemotes, "", fetchconf.FetchRecentTags)
 		if err != nil {
 			Panic(err, "Could not scan for recent refs")
 		}
 		for _, ref := range refs {
 			if commits.Add(ref.Sha) {
 				// A new commit
+				refNames[ref.Sha] = ref.Name
 				waitg.Add(1)
-				go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, retainChan, errorChan, waitg, sem)
+				go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, fmt.Sprintf("at recent ref %v", ref.Name), retainChan, errorChan, waitg, sem)
 			}
 		}
 	}
@@ -428,21 +539,20 @@ func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchc
 			}
 			commitsSince := summ.CommitDate.AddDate(0, 0, -pruneCommitDays)
 			waitg.Add(1)
-			go pruneTaskGetPreviousVersionsOfRef(gitscanner, commit, commitsSince, retainChan, errorChan, waitg, sem)
+			go pruneTaskGetPreviousVersionsOfRef(gitscanner, commit, commitsSince, fmt.Sprintf("in recent commits on %v", refNames[commit]), retainChan, errorChan, waitg, sem)
 		}
 	}
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetRetainedUnpushed(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetRetainedUnpushed(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
 
 	err := gitscanner.ScanUnpushed(fetchconf.PruneRemoteName, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			errorChan <- err
 		} else {
-			retainChan <- p.Pointer.Oid
-			tracerx.Printf("RETAIN: %v unpushed", p.Pointer.Oid)
+			retain(retainChan, p.Pointer.Oid, fmt.Sprintf("not pushed to %v", fetchconf.PruneRemoteName))
 		}
 	})
 
@@ -453,7 +563,7 @@ func pruneTaskGetRetainedUnpushed(gitscanner *lfs.GitScanner, fetchconf lfs.Fetc
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetRetainedWorktree(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetRetainedWorktree(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
 
 	if fetchconf.PruneForce {
@@ -482,28 +592,172 @@ func pruneTaskGetRetainedWorktree(gitscanner *lfs.GitScanner, fetchconf lfs.Fetc
 			// Worktree is on a different commit
 			waitg.Add(1)
 			// Don't need to 'cd' to worktree since we share same repo
-			go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, retainChan, errorChan, waitg, sem)
+			go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, fmt.Sprintf("at worktree HEAD %v", ref.Name), retainChan, errorChan, waitg, sem)
 		}
 	}
 }
 
 // Background task, must call waitg.Done() once at end
-func pruneTaskGetRetainedStashed(gitscanner *lfs.GitScanner, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+func pruneTaskGetRetainedStashed(gitscanner *lfs.GitScanner, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
 
 	err := gitscanner.ScanStashed(func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			errorChan <- err
 		} else {
-			retainChan <- p.Pointer.Oid
-			tracerx.Printf("RETAIN: %v stashed", p.Pointer.Oid)
+			retain(retainChan, p.Pointer.Oid, "stashed")
+		}
+	})
+
+	if err != nil {
+		errorChan <- err
+		return
+	}
+}
+
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedIndex(gitscanner *lfs.GitScanner, retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup) {
+	defer waitg.Done()
+
+	err := gitscanner.ScanIndex("HEAD", func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errorChan <- err
+		} else {
+			retain(retainChan, p.Pointer.Oid, "in the index")
 		}
 	})
 
+	if err != nil {
+		errorChan <- err
+	}
+}
+
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedShared(retainChan chan retainedObject, errorChan chan error, waitg *sync.WaitGroup) {
+	defer waitg.Done()
+
+	repos, err := cfg.Filesystem().OtherRepositories()
 	if err != nil {
 		errorChan <- err
 		return
 	}
+
+	for _, repo := range repos {
+		if _, err := os.Stat(repo.Dir); os.IsNotExist(err) {
+			tracelog.Printf("PRUNE: forgetting %v, which no longer exists", repo.Dir)
+			if err := cfg.Filesystem().UnregisterRepository(repo.ID); err != nil {
+				errorChan <- err
+			}
+			continue
+		}
+
+		// Ask the other repository which objects it retains, according
+		// to its own configuration.
+		cmd := subprocess.ExecCommand("git", "lfs", "prune", "--print-retained", "--no-verify-remote")
+		cmd.Dir = repo.Dir
+		cmd.Env = sharedRepositoryEnv(cmd.Env)
+
+		out, err := cmd.Output()
+		if err != nil {
+			errorChan <- fmt.Errorf("couldn't find objects retained by %v, which shares this repository's storage: %v", repo.Dir, err)
+			continue
+		}
+
+		for _, oid := range strings.Fields(string(out)) {
+			retain(retainChan, oid, fmt.Sprintf("used by %v", repo.Dir))
+		}
+	}
+}
+
+// sharedRepositoryEnv removes the variables locating this repository from the
+// environment "env", so that Git locates the repository in which it is run.
+func sharedRepositoryEnv(env []string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		switch strings.SplitN(kv, "=", 2)[0] {
+		case "GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_COMMON_DIR", "GIT_OBJECT_DIRECTORY":
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
+
+// newRetainGitScanner returns a GitScanner with which to find the objects to be
+// retained, which skips the paths excluded by lfs.fetchexclude, as their
+// objects are never fetched.
+func newRetainGitScanner() *lfs.GitScanner {
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	gitscanner.Filter = filepathfilter.New(nil, cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
+	return gitscanner
+}
+
+// pruneStartRetainTasks starts the tasks which send the objects to be retained
+// to "retainChan", adding each of them to "taskwait".  Those needed by recent
+// refs and commits are retained if "recent" is true, or else only those at
+// HEAD and in the index, along with those which have not been pushed, those
+// of other worktrees and stashes, and, if "shared" is true, those of the other
+// repositories sharing the storage directory.
+func pruneStartRetainTasks(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, recent, shared bool, retainChan chan retainedObject, errorChan chan error, taskwait *sync.WaitGroup, sem *semaphore.Weighted) {
+	taskwait.Add(3)
+	if recent {
+		taskwait.Add(1)
+		go pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner, fetchconf, retainChan, errorChan, taskwait, sem)
+	} else {
+		taskwait.Add(1)
+		go pruneTaskGetRetainedIndex(gitscanner, retainChan, errorChan, taskwait)
+		if ref, err := git.CurrentRef(); err != nil {
+			errorChan <- err
+		} else {
+			taskwait.Add(1)
+			go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, "at HEAD", retainChan, errorChan, taskwait, sem)
+		}
+	}
+	go pruneTaskGetRetainedUnpushed(gitscanner, fetchconf, retainChan, errorChan, taskwait, sem)
+	go pruneTaskGetRetainedWorktree(gitscanner, fetchconf, retainChan, errorChan, taskwait, sem)
+	go pruneTaskGetRetainedStashed(gitscanner, retainChan, errorChan, taskwait, sem)
+	if shared {
+		taskwait.Add(1)
+		go pruneTaskGetRetainedShared(retainChan, errorChan, taskwait)
+	}
+}
+
+// retainedObjects returns the objects which must not be removed from the local
+// object store, found by the same tasks as prune uses.  If "recent" is true,
+// objects are retained as by prune; otherwise only those needed by the
+// checkouts of the current repository and any other sharing its storage, and
+// those which have not been pushed, are retained.
+func retainedObjects(fetchconf lfs.FetchPruneConfig, recent bool) (tools.StringSet, error) {
+	retained := tools.NewStringSet()
+
+	retainChan := make(chan retainedObject, 100)
+	errorChan := make(chan error, 10)
+
+	var errs []error
+	var errorwait sync.WaitGroup
+	errorwait.Add(1)
+	go pruneTaskCollectErrors(&errs, errorChan, &errorwait)
+
+	var retainwait sync.WaitGroup
+	retainwait.Add(1)
+	go pruneTaskCollectRetained(&retained, make(map[string]string), retainChan, nil, &retainwait)
+
+	gitscanner := newRetainGitScanner()
+	sem := semaphore.NewWeighted(int64(runtime.NumCPU() * 2))
+
+	var taskwait sync.WaitGroup
+	pruneStartRetainTasks(gitscanner, fetchconf, recent, cfg.Filesystem().IsShared(), retainChan, errorChan, &taskwait, sem)
+	taskwait.Wait()
+	gitscanner.Close()
+	close(retainChan)
+	retainwait.Wait()
+
+	close(errorChan)
+	errorwait.Wait()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return retained, nil
 }
 
 // Background task, must call waitg.Done() once at end
@@ -534,5 +788,7 @@ func init() {
 		cmd.Flags().BoolVarP(&pruneForceArg, "force", "f", false, "Prune everything that has been pushed")
 		cmd.Flags().BoolVarP(&pruneVerifyArg, "verify-remote", "c", false, "Verify that remote has LFS files before deleting")
 		cmd.Flags().BoolVar(&pruneDoNotVerifyArg, "no-verify-remote", false, "Override lfs.pruneverifyremotealways and don't verify")
+		cmd.Flags().BoolVar(&prunePrintRetainedArg, "print-retained", false, "")
+		cmd.Flags().MarkHidden("print-retained")
 	})
 }
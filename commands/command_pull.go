@@ -9,9 +9,10 @@ import (
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/metrics"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -19,34 +20,87 @@ func pullCommand(cmd *cobra.Command, args []string) {
 	requireGitVersion()
 	setupRepository()
 
+	// Paths to pull may follow "--", as in "git lfs pull origin -- dir".
+	var paths []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, paths = args[:dash], args[dash:]
+	}
 	if len(args) > 0 {
 		// Remote is first arg
 		if err := cfg.SetValidRemote(args[0]); err != nil {
 			Exit("Invalid remote name %q: %s", args[0], err)
 		}
 	}
+	if len(args) > 2 {
+		Exit("Only one ref can be pulled at a time")
+	}
 
-	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
-	filter := buildFilepathFilter(cfg, includeArg, excludeArg, true)
-	pull(filter)
-}
-
-func pull(filter *filepathfilter.Filter) {
 	ref, err := git.CurrentRef()
 	if err != nil {
 		Panic(err, "Could not pull")
 	}
+	if len(args) > 1 {
+		// A path given where a ref is expected was most likely meant
+		// to follow "--".
+		if _, err := git.ResolveRef(args[1]); err != nil {
+			if _, serr := os.Stat(args[1]); serr == nil {
+				Exit("Too many arguments: paths to pull must follow \"--\"")
+			}
+		}
+		ref = resolveCommitRefs(args[1:])[0]
+	}
+
+	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
+	if len(paths) > 0 && includeArg != nil {
+		Exit("Cannot use --include with paths")
+	}
+
+	include, exclude := determineIncludeExcludePaths(cfg, includeArg, excludeArg, true)
+	if len(paths) > 0 {
+		// The paths replace lfs.fetchinclude, as --include would.
+		include = rootedPaths(paths)
+	}
+	pull(ref, filepathfilter.New(include, exclude, filepathfilter.IgnoreCase(cfg.IgnoreCase())))
+}
+
+// pull downloads the objects of the files at the given ref which match the
+// filter.  If the ref is the commit which is checked out, the files are also
+// checked out; otherwise the working tree holds other files, so the objects
+// are only downloaded, as by "git lfs fetch".
+func pull(ref *git.Ref, filter *filepathfilter.Filter) {
+	checkout := true
+	if head, err := git.CurrentRef(); err != nil || head.Sha != ref.Sha {
+		checkout = false
+	}
 
 	pointers := newPointerMap()
 	logger := tasklog.NewLogger(os.Stdout,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	meter := tq.NewMeter(cfg)
-	meter.Logger = meter.LoggerFromEnv(cfg.Os)
+	setProgressOutputs(meter)
 	logger.Enqueue(meter)
 	remote := cfg.Remote()
-	singleCheckout := newSingleCheckout(cfg.Git, remote)
+	var singleCheckout abstractCheckout
+	if checkout {
+		singleCheckout = newSingleCheckout(cfg.Git, remote)
+	} else {
+		singleCheckout = &noOpCheckout{manifest: getTransferManifestOperationRemote("download", remote)}
+	}
 	q := newDownloadQueue(singleCheckout.Manifest(), remote, tq.WithProgress(meter))
+
+	// Files whose objects are present already are counted by the meter as
+	// they are checked out, so that it shows the progress of both phases.
+	var metered sync.Map
+	if checkout {
+		meter.AddPhase(tq.Checkout)
+		singleCheckout.OnFinish(func(p *lfs.WrappedPointer) {
+			if _, ok := metered.Load(p.Name); ok {
+				meter.TransferBytes(tq.Checkout.String(), p.Name, p.Size, p.Size, int(p.Size))
+				meter.FinishTransfer(p.Name)
+			}
+		})
+	}
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			LoggedError(err, "Scanner error: %s", err)
@@ -60,12 +114,19 @@ func pull(filter *filepathfilter.Filter) {
 		// no need to download objects that exist locally already
 		lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
 		if cfg.LFSObjectExists(p.Oid, p.Size) {
+			metrics.Count("objects.cache", 1, metrics.Tags{"result": "hit"})
+			if checkout {
+				metered.Store(p.Name, true)
+				meter.Add(p.Size)
+				meter.StartTransfer(p.Name)
+			}
 			singleCheckout.Run(p)
 			return
 		}
 
+		metrics.Count("objects.cache", 1, metrics.Tags{"result": "miss"})
 		meter.Add(p.Size)
-		tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
+		tracelog.Printf("fetch %v [%v]", p.Name, p.Oid)
 		pointers.Add(p)
 		q.Add(downloadTransfer(p))
 	})
@@ -94,24 +155,21 @@ func pull(filter *filepathfilter.Filter) {
 	meter.Start()
 	gitscanner.Close()
 	q.Wait()
+	meter.Finish()
 	wg.Wait()
-	tracerx.PerformanceSince("process queue", processQueue)
+	tracelog.PerformanceSince("process queue", processQueue)
 
 	singleCheckout.Close()
 
-	success := true
-	for _, err := range q.Errors() {
-		success = false
-		FullError(err)
-	}
-
-	if !success {
+	if !reportTransferErrors(q.Errors()) {
 		c := getAPIClient()
 		e := c.Endpoints.Endpoint("download", remote)
-		Exit("error: failed to fetch some objects from '%s'", e.Url)
+		exitTransferFailed("error: failed to fetch some objects from '%s'", e.Url)
 	}
 
-	if singleCheckout.Skip() {
+	evictCache()
+
+	if checkout && singleCheckout.Skip() {
 		fmt.Println("Skipping object checkout, Git LFS is not installed.")
 	}
 }
@@ -1,13 +1,16 @@
 package commands
 
 import (
+	"bufio"
 	"os"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -42,26 +45,47 @@ func pushCommand(cmd *cobra.Command, args []string) {
 		Exit("Invalid remote name %q: %s", args[0], err)
 	}
 
+	argList := args[1:]
+	if useStdin {
+		if len(argList) > 0 {
+			Print("Further command line arguments are ignored with --stdin")
+		}
+		argList = readPushArgsFromStdin()
+	}
+
 	ctx := newUploadContext(pushDryRun)
 	if pushObjectIDs {
-		if len(args) < 2 {
+		if len(argList) < 1 {
 			Print("Usage: git lfs push --object-id <remote> <lfs-object-id> [lfs-object-id] ...")
 			return
 		}
 
-		uploadsWithObjectIDs(ctx, args[1:])
+		uploadsWithObjectIDs(ctx, argList)
 	} else {
-		if len(args) < 1 {
-			Print("Usage: git lfs push --dry-run <remote> [ref]")
-			return
-		}
+		uploadsBetweenRefAndRemote(ctx, argList)
+	}
+}
+
+// readPushArgsFromStdin returns the refs or object IDs given on standard input,
+// one per line, ignoring blank lines.
+func readPushArgsFromStdin() []string {
+	requireStdin("Pass refs or object IDs on standard input, or leave the --stdin flag off.")
 
-		uploadsBetweenRefAndRemote(ctx, args[1:])
+	var argList []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			argList = append(argList, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		ExitWithError(errors.Wrap(err, "Error reading from stdin:"))
 	}
+	return argList
 }
 
 func uploadsBetweenRefAndRemote(ctx *uploadContext, refnames []string) {
-	tracerx.Printf("Upload refs %v to remote %v", refnames, ctx.Remote)
+	tracelog.Printf("Upload refs %v to remote %v", refnames, ctx.Remote)
 
 	updates, err := lfsPushRefs(refnames, pushAll)
 	if err != nil {
@@ -82,7 +106,7 @@ func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
 			ExitWithError(errors.Wrap(err, "Unable to find local media path:"))
 		}
 
-		stat, err := os.Stat(mp)
+		size, _, err := fs.ObjectFileSize(mp)
 		if err != nil {
 			ExitWithError(errors.Wrap(err, "Unable to stat local media path"))
 		}
@@ -91,7 +115,7 @@ func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
 			Name: mp,
 			Pointer: &lfs.Pointer{
 				Oid:  oid,
-				Size: stat.Size(),
+				Size: size,
 			},
 		}
 	}
@@ -142,5 +166,6 @@ func init() {
 		cmd.Flags().BoolVarP(&pushDryRun, "dry-run", "d", false, "Do everything except actually send the updates")
 		cmd.Flags().BoolVarP(&pushObjectIDs, "object-id", "o", false, "Push LFS object ID(s)")
 		cmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all objects for the current ref to the remote.")
+		cmd.Flags().BoolVar(&useStdin, "stdin", false, "Read object IDs or refs from stdin")
 	})
 }
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/git-lfs/git-lfs/lfshttp/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListenArg string
+)
+
+func serveCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Exit("Usage: git lfs serve [--listen=<address>] <directory>")
+	}
+
+	owner, _ := cfg.CurrentCommitter()
+	if len(owner) == 0 {
+		owner = "git-lfs"
+	}
+
+	srv, err := server.New(cfg.Os, args[0], cfg.RepositoryPermissions(false), owner)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	listener, err := net.Listen("tcp", serveListenArg)
+	if err != nil {
+		Exit("Could not listen on %q: %s", serveListenArg, err)
+	}
+
+	Print("Serving Git LFS objects from %s", args[0])
+	Print("Set lfs.url to %s to use this server", fmt.Sprintf("http://%s", listener.Addr()))
+
+	if err := http.Serve(listener, srv); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func init() {
+	RegisterCommand("serve", serveCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&serveListenArg, "listen", "l", "localhost:8080", "Address to listen on")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
@@ -25,6 +26,8 @@ var (
 // `*tq.TransferQueue` "q" if the file is not present locally, passes the given
 // filepathfilter, and is not skipped. If the pointer is malformed, or already
 // exists, it streams the contents to be written into the working copy to "to".
+// Skipped pointers, and excluded pointers whose objects are missing, are
+// written unchanged.
 //
 // delayedSmudge returns the number of bytes written, whether the checkout was
 // delayed, the *lfs.Pointer that was smudged, and an error, if one occurred.
@@ -52,26 +55,34 @@ func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer,
 
 	lfs.LinkOrCopyFromReference(cfg, ptr.Oid, ptr.Size)
 
-	path, err := cfg.Filesystem().ObjectPath(ptr.Oid)
-	if err != nil {
-		return 0, false, nil, err
-	}
+	if !skip {
+		missing := ptr.Size != 0 && !cfg.LFSObjectExists(ptr.Oid, ptr.Size)
+
+		// Excluded paths are only left as pointers if their objects
+		// would have to be downloaded, as in smudge().
+		if !missing || filter.Allows(filename) {
+			if missing {
+				path, err := downloadPath(ptr.Oid)
+				if err != nil {
+					return 0, false, nil, err
+				}
+				q.Add(filename, path, ptr.Oid, ptr.Size, false, nil)
+				return 0, true, ptr, nil
+			}
 
-	if !skip && filter.Allows(filename) {
-		if _, statErr := os.Stat(path); statErr != nil && ptr.Size != 0 {
-			q.Add(filename, path, ptr.Oid, ptr.Size, false, err)
-			return 0, true, ptr, nil
-		}
+			// Write 'statusFromErr(nil)', since the object is
+			// already present in the local cache, we will write the
+			// object's contents without delaying.
+			if err := s.WriteStatus(statusFromErr(nil)); err != nil {
+				return 0, false, nil, err
+			}
 
-		// Write 'statusFromErr(nil)', since the object is already
-		// present in the local cache, we will write the object's
-		// contents without delaying.
-		if err := s.WriteStatus(statusFromErr(nil)); err != nil {
-			return 0, false, nil, err
+			n, err := gf.Smudge(to, ptr, filename, false, nil, nil)
+			if err != nil {
+				Error(err.Error())
+			}
+			return n, false, ptr, err
 		}
-
-		n, err := gf.Smudge(to, ptr, filename, false, nil, nil)
-		return n, false, ptr, err
 	}
 
 	if err := s.WriteStatus(statusFromErr(nil)); err != nil {
@@ -97,7 +108,11 @@ func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer,
 // Any errors encountered along the way will be returned immediately if they
 // were non-fatal, otherwise execution will halt and the process will be
 // terminated by using the `commands.Panic()` func.
-func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, skip bool, filter *filepathfilter.Filter) (int64, error) {
+//
+// The "fallback" policy decides whether objects missing from the local cache
+// may be downloaded at all. If it forbids the download, the pointer is written
+// in place of the object's contents and the smudge fails.
+func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, skip bool, filter *filepathfilter.Filter, fallback config.SmudgeFallbackFetch) (int64, error) {
 	ptr, pbuf, perr := lfs.DecodeFrom(from)
 	if perr != nil {
 		n, err := tools.Spool(to, pbuf, cfg.TempDir())
@@ -124,6 +139,12 @@ func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, sk
 		download = filter.Allows(filename)
 	}
 
+	var declined bool
+	if download && ptr.Size > 0 && !cfg.LFSObjectExists(ptr.Oid, ptr.Size) {
+		download = allowsFallbackFetch(fallback, filename, ptr)
+		declined = !download
+	}
+
 	n, err := gf.Smudge(to, ptr, filename, download, getTransferManifestOperationRemote("download", cfg.Remote()), cb)
 	if file != nil {
 		file.Close()
@@ -131,13 +152,20 @@ func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, sk
 
 	if err != nil {
 		ptr.Encode(to)
-		// Download declined error is ok to skip if we weren't requesting download
-		if !(errors.IsDownloadDeclinedError(err) && !download) {
-			var oid string = ptr.Oid
-			if len(oid) >= 7 {
-				oid = oid[:7]
-			}
 
+		var oid string = ptr.Oid
+		if len(oid) >= 7 {
+			oid = oid[:7]
+		}
+
+		if declined {
+			fmt.Fprintf(os.Stderr, "Object %s (%s) is not present locally, and lfs.smudge.fallbackfetch is %q; not downloading\n", filename, oid, fallback)
+			if !cfg.SkipDownloadErrors() {
+				os.Exit(2)
+			}
+		} else if !(errors.IsDownloadDeclinedError(err) && !download) {
+			// Download declined error is ok to skip if we weren't
+			// requesting download
 			LoggedError(err, "Error downloading object: %s (%s): %s", filename, oid, err)
 			if !cfg.SkipDownloadErrors() {
 				os.Exit(2)
@@ -148,6 +176,20 @@ func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, sk
 	return n, nil
 }
 
+// allowsFallbackFetch returns whether the object referenced by "ptr" may be
+// downloaded during a smudge, according to the given policy.
+func allowsFallbackFetch(fallback config.SmudgeFallbackFetch, filename string, ptr *lfs.Pointer) bool {
+	switch fallback {
+	case config.SmudgeFallbackFetchFalse:
+		return false
+	case config.SmudgeFallbackFetchPrompt:
+		return promptYesNo("Git LFS: download %s (%s) from the remote?",
+			filename, humanize.FormatBytes(uint64(ptr.Size)))
+	default:
+		return true
+	}
+}
+
 func smudgeCommand(cmd *cobra.Command, args []string) {
 	requireStdin("This command should be run by the Git 'smudge' filter")
 	setupRepository()
@@ -156,10 +198,11 @@ func smudgeCommand(cmd *cobra.Command, args []string) {
 	if !smudgeSkip && cfg.Os.Bool("GIT_LFS_SKIP_SMUDGE", false) {
 		smudgeSkip = true
 	}
-	filter := filepathfilter.New(cfg.FetchIncludePaths(), cfg.FetchExcludePaths())
+	filter := filepathfilter.New(cfg.FetchIncludePaths(), cfg.FetchExcludePaths(), filepathfilter.IgnoreCase(cfg.IgnoreCase()))
 	gitfilter := lfs.NewGitFilter(cfg)
+	gitfilter.SetProgressStream(getProgressStream())
 
-	if n, err := smudge(gitfilter, os.Stdout, os.Stdin, smudgeFilename(args), smudgeSkip, filter); err != nil {
+	if n, err := smudge(gitfilter, os.Stdout, os.Stdin, smudgeFilename(args), smudgeSkip, filter, cfg.SmudgeFallbackFetch()); err != nil {
 		if errors.IsNotAPointerError(err) {
 			fmt.Fprintln(os.Stderr, err.Error())
 		} else {
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsLimit int
+)
+
+func statsCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	history := loadTransferHistory()
+	recent := history
+	if statsLimit > 0 && len(recent) > statsLimit {
+		recent = recent[len(recent)-statsLimit:]
+	}
+
+	totals := []*statsTotal{{Direction: "upload"}, {Direction: "download"}}
+	for _, entry := range history {
+		for _, t := range totals {
+			if t.Direction == entry.Direction {
+				t.add(entry)
+			}
+		}
+	}
+
+	if jsonOutput {
+		for _, entry := range recent {
+			if err := PrintEvent("transfer", entry); err != nil {
+				ExitWithError(err)
+			}
+		}
+		if err := PrintResult(&statsResult{Totals: totals}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	if len(history) == 0 {
+		Print("No transfers recorded")
+		return
+	}
+
+	for _, entry := range recent {
+		Print("%s  %-9s %-8s %-8s %s", entry.Start.Local().Format("2006-01-02 15:04:05"),
+			entry.Command, entry.Direction, adapterOrNone(entry.Adapter),
+			formatTransferStats(entry.Objects, entry.Bytes, entry.Duration, entry.Retries, entry.Errors))
+	}
+
+	Print("")
+	for _, t := range totals {
+		Print("%-9s %d run(s), %s", t.Direction+":", t.Runs,
+			formatTransferStats(t.Objects, t.Bytes, t.Duration, t.Retries, t.Errors))
+	}
+}
+
+// statsResult is the result written by "git lfs stats --json".  The recorded
+// runs themselves are written before it as "transfer" events.
+type statsResult struct {
+	Totals []*statsTotal `json:"totals"`
+}
+
+// statsTotal sums the recorded runs of transfers in one direction.
+type statsTotal struct {
+	Direction string        `json:"direction"`
+	Runs      int           `json:"runs"`
+	Objects   int           `json:"objects"`
+	Bytes     int64         `json:"bytes"`
+	Retries   int           `json:"retries"`
+	Errors    int           `json:"errors"`
+	Duration  time.Duration `json:"duration"`
+}
+
+func (t *statsTotal) add(entry *transferHistoryEntry) {
+	t.Runs++
+	t.Objects += entry.Objects
+	t.Bytes += entry.Bytes
+	t.Retries += entry.Retries
+	t.Errors += entry.Errors
+	t.Duration += entry.Duration
+}
+
+func adapterOrNone(name string) string {
+	if len(name) == 0 {
+		return "-"
+	}
+	return name
+}
+
+func formatTransferStats(objects int, bytes int64, d time.Duration, retries, errs int) string {
+	return fmt.Sprintf("%d object(s), %s in %s (%s), %d retries, %d error(s)",
+		objects, humanize.FormatBytes(uint64(bytes)), d.Round(time.Millisecond),
+		humanize.FormatByteRate(uint64(bytes), d), retries, errs)
+}
+
+func init() {
+	RegisterCommand("stats", statsCommand, func(cmd *cobra.Command) {
+		cmd.Flags().IntVarP(&statsLimit, "limit", "l", 20, "")
+	})
+}
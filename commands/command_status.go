@@ -1,8 +1,6 @@
 package commands
 
 import (
-	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -16,8 +14,7 @@ import (
 )
 
 var (
-	porcelain  = false
-	statusJson = false
+	porcelain = false
 )
 
 func statusCommand(cmd *cobra.Command, args []string) {
@@ -39,8 +36,8 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	if porcelain {
 		porcelainStagedPointers(scanIndexAt)
 		return
-	} else if statusJson {
-		jsonStagedPointers(scanner, scanIndexAt)
+	} else if jsonOutput {
+		jsonStagedPointers(scanner, ref, scanIndexAt)
 		return
 	}
 
@@ -157,7 +154,11 @@ func blobInfo(s *lfs.PointerScanner, blobSha, name string) (sha, from string, er
 		return "deleted", "File", nil
 	}
 
-	shasum := sha256.New()
+	// The file is hashed as it would be cleaned.
+	shasum := tools.NewLfsContentHashAlgorithm(cfg.HashAlgorithm())
+	if shasum == nil {
+		shasum = tools.NewLfsContentHash()
+	}
 	if _, err = io.Copy(shasum, f); err != nil {
 		return "", "", err
 	}
@@ -166,7 +167,21 @@ func blobInfo(s *lfs.PointerScanner, blobSha, name string) (sha, from string, er
 }
 
 func scanIndex(ref string) (staged, unstaged []*lfs.DiffIndexEntry, err error) {
-	uncached, err := lfs.NewDiffIndexScanner(ref, false, true)
+	// If Git uses a file system monitor, the files changed in the working
+	// tree are found with its help, rather than by refreshing the index,
+	// which would examine every file and write the index.  The entries of
+	// other files are then left out, as without the refresh, those whose
+	// stat data is out of date are listed even if they have not changed.
+	var changed map[string]struct{}
+	refresh := true
+	if cfg.FSMonitor() {
+		if changed, err = git.WorktreeChanges(); err != nil {
+			return nil, nil, err
+		}
+		refresh = false
+	}
+
+	uncached, err := lfs.NewDiffIndexScanner(ref, false, refresh)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -188,9 +203,26 @@ func scanIndex(ref string) (staged, unstaged []*lfs.DiffIndexEntry, err error) {
 		return nil, nil, err
 	}
 
+	if changed != nil {
+		unstaged = worktreeChangedEntries(unstaged, changed)
+	}
 	return
 }
 
+// worktreeChangedEntries returns those of "entries" with either path in
+// "changed".
+func worktreeChangedEntries(entries []*lfs.DiffIndexEntry, changed map[string]struct{}) []*lfs.DiffIndexEntry {
+	var filtered []*lfs.DiffIndexEntry
+	for _, entry := range entries {
+		_, src := changed[entry.SrcName]
+		_, dst := changed[entry.DstName]
+		if src || dst {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
 func drainScanner(cache map[string]struct{}, scanner *lfs.DiffIndexScanner) ([]*lfs.DiffIndexEntry, error) {
 	var to []*lfs.DiffIndexEntry
 
@@ -232,21 +264,30 @@ func statusScanRefRange(ref *git.Ref) {
 		return
 	}
 
+	Print("Objects to be pushed to %s:\n", remoteRef.Name)
+	for _, p := range statusObjectsToPush(ref, remoteRef) {
+		Print("\t%s (%s)", p.Name, p.Oid)
+	}
+}
+
+// statusObjectsToPush returns the LFS objects referenced by commits on "ref"
+// which are not on "remoteRef".
+func statusObjectsToPush(ref, remoteRef *git.Ref) []*lfs.WrappedPointer {
+	var pointers []*lfs.WrappedPointer
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			Panic(err, "Could not scan for Git LFS objects")
 			return
 		}
 
-		Print("\t%s (%s)", p.Name, p.Oid)
+		pointers = append(pointers, p)
 	})
 	defer gitscanner.Close()
 
-	Print("Objects to be pushed to %s:\n", remoteRef.Name)
 	if err := gitscanner.ScanRefRange(ref.Sha, remoteRef.Sha, nil); err != nil {
 		Panic(err, "Could not scan for Git LFS objects")
 	}
-
+	return pointers
 }
 
 type JSONStatusEntry struct {
@@ -254,19 +295,50 @@ type JSONStatusEntry struct {
 	From   string `json:"from,omitempty"`
 }
 
+type JSONStatusObject struct {
+	Name string `json:"name"`
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+type JSONStatusPush struct {
+	Remote  string             `json:"remote"`
+	Objects []JSONStatusObject `json:"objects"`
+}
+
 type JSONStatus struct {
+	// Files gives the status of each changed file, which is that of its
+	// staged change if it has both a staged and an unstaged one.
 	Files map[string]JSONStatusEntry `json:"files"`
+
+	// Staged and Unstaged give the status of each file with changes in
+	// the index, and of each with changes in the working tree which are
+	// not in the index, so that a file may appear in both.
+	Staged   map[string]JSONStatusEntry `json:"staged"`
+	Unstaged map[string]JSONStatusEntry `json:"unstaged"`
+
+	// Push lists the objects to be pushed to the remote tracking branch,
+	// and is omitted if there is none.
+	Push *JSONStatusPush `json:"push,omitempty"`
+
+	// Missing lists the objects to be pushed which are not present in the
+	// local object store.
+	Missing []JSONStatusObject `json:"missing,omitempty"`
 }
 
-func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
-	staged, unstaged, err := scanIndex(ref)
+func jsonStagedPointers(scanner *lfs.PointerScanner, ref *git.Ref, scanIndexAt string) {
+	staged, unstaged, err := scanIndex(scanIndexAt)
 	if err != nil {
 		ExitWithError(err)
 	}
 
-	status := JSONStatus{Files: make(map[string]JSONStatusEntry)}
+	status := JSONStatus{
+		Files:    make(map[string]JSONStatusEntry),
+		Staged:   make(map[string]JSONStatusEntry),
+		Unstaged: make(map[string]JSONStatusEntry),
+	}
 
-	for _, entry := range append(unstaged, staged...) {
+	for i, entry := range append(unstaged, staged...) {
 		_, fromSrc, err := blobInfoFrom(scanner, entry)
 		if err != nil {
 			ExitWithError(err)
@@ -276,23 +348,42 @@ func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
 			continue
 		}
 
+		name := entry.SrcName
+		jsonEntry := JSONStatusEntry{Status: string(entry.Status)}
 		switch entry.Status {
 		case lfs.StatusRename, lfs.StatusCopy:
-			status.Files[entry.DstName] = JSONStatusEntry{
-				Status: string(entry.Status), From: entry.SrcName,
+			name = entry.DstName
+			jsonEntry.From = entry.SrcName
+		}
+
+		status.Files[name] = jsonEntry
+		if i >= len(unstaged) {
+			status.Staged[name] = jsonEntry
+		} else {
+			status.Unstaged[name] = jsonEntry
+		}
+	}
+
+	if ref != nil {
+		if remoteRef, err := cfg.GitConfig().CurrentRemoteRef(); err == nil {
+			status.Push = &JSONStatusPush{
+				Remote:  remoteRef.Name,
+				Objects: make([]JSONStatusObject, 0),
 			}
-		default:
-			status.Files[entry.SrcName] = JSONStatusEntry{
-				Status: string(entry.Status),
+
+			for _, p := range statusObjectsToPush(ref, remoteRef) {
+				obj := JSONStatusObject{Name: p.Name, Oid: p.Oid, Size: p.Size}
+				status.Push.Objects = append(status.Push.Objects, obj)
+				if !cfg.LFSObjectExists(p.Oid, p.Size) {
+					status.Missing = append(status.Missing, obj)
+				}
 			}
 		}
 	}
 
-	ret, err := json.Marshal(status)
-	if err != nil {
+	if err := PrintResult(&status); err != nil {
 		ExitWithError(err)
 	}
-	Print(string(ret))
 }
 
 func porcelainStagedPointers(ref string) {
@@ -364,6 +455,5 @@ func relativize(from, to string) string {
 func init() {
 	RegisterCommand("status", statusCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&porcelain, "porcelain", "p", false, "Give the output in an easy-to-parse format for scripts.")
-		cmd.Flags().BoolVarP(&statusJson, "json", "j", false, "Give the output in a stable json format for scripts.")
 	})
 }
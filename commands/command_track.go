@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/git/gitattr"
 	"github.com/git-lfs/git-lfs/tools"
@@ -23,11 +25,14 @@ var (
 
 	trackLockableFlag       bool
 	trackNotLockableFlag    bool
+	trackAutotrackFlag      bool
 	trackVerboseLoggingFlag bool
 	trackDryRunFlag         bool
 	trackNoModifyAttrsFlag  bool
 	trackNoExcludedFlag     bool
 	trackFilenameFlag       bool
+	trackDirnameFlag        bool
+	trackQueryFlag          bool
 )
 
 func trackCommand(cmd *cobra.Command, args []string) {
@@ -38,11 +43,20 @@ func trackCommand(cmd *cobra.Command, args []string) {
 		installHooks(false)
 	}
 
+	if trackQueryFlag {
+		queryPatterns(args)
+		return
+	}
+
 	if len(args) == 0 {
 		listPatterns()
 		return
 	}
 
+	if trackFilenameFlag && trackDirnameFlag {
+		Exit("Only one of --filename and --dirname options can be specified.")
+	}
+
 	mp := gitattr.NewMacroProcessor()
 
 	// Intentionally do _not_ consider global- and system-level
@@ -71,7 +85,12 @@ ArgsLoop:
 
 		// Generate the new / changed attrib line for merging
 		var encodedArg string
-		if trackFilenameFlag {
+		if trackDirnameFlag {
+			// Track everything beneath the directory, whose name is
+			// taken literally.
+			encodedArg = escapeGlobCharacters(strings.TrimSuffix(pattern, "/")) + "/**"
+			pattern = unescapeAttrPattern(encodedArg)
+		} else if trackFilenameFlag {
 			encodedArg = escapeGlobCharacters(pattern)
 			pattern = escapeGlobCharacters(pattern)
 		} else {
@@ -83,7 +102,8 @@ ArgsLoop:
 				if unescapeAttrPattern(known.Path) == filepath.Join(relpath, pattern) &&
 					((trackLockableFlag && known.Lockable) || // enabling lockable & already lockable (no change)
 						(trackNotLockableFlag && !known.Lockable) || // disabling lockable & not lockable (no change)
-						(!trackLockableFlag && !trackNotLockableFlag)) { // leave lockable as-is in all cases
+						(!trackLockableFlag && !trackNotLockableFlag)) && // leave lockable as-is in all cases
+					(!trackAutotrackFlag || known.Autotrack) { // enabling autotrack & already autotrack
 					Print("%q already supported", pattern)
 					continue ArgsLoop
 				}
@@ -95,7 +115,12 @@ ArgsLoop:
 			lockableArg = " " + git.LockableAttrib
 		}
 
-		changedAttribLines[pattern] = fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text%v%s", encodedArg, lockableArg, lineEnd)
+		autotrackArg := ""
+		if trackAutotrackFlag {
+			autotrackArg = " " + git.AutotrackAttrib
+		}
+
+		changedAttribLines[pattern] = fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text%v%v%s", encodedArg, lockableArg, autotrackArg, lineEnd)
 
 		if trackLockableFlag {
 			readOnlyPatterns = append(readOnlyPatterns, pattern)
@@ -228,10 +253,15 @@ func listPatterns() {
 
 	Print("Listing tracked patterns")
 	for _, t := range knownPatterns {
+		var notes string
 		if t.Lockable {
-			Print("    %s [lockable] (%s)", t.Path, t.Source)
-		} else if t.Tracked {
-			Print("    %s (%s)", t.Path, t.Source)
+			notes += " [lockable]"
+		}
+		if t.Tracked && t.Autotrack {
+			notes += " [autotrack]"
+		}
+		if t.Lockable || t.Tracked {
+			Print("    %s%s (%s)", t.Path, notes, t.Source)
 		}
 	}
 
@@ -247,6 +277,94 @@ func listPatterns() {
 	}
 }
 
+// queryPatterns reports the pattern, if any, which determines whether each
+// of the given paths is tracked by Git LFS, along with its source.
+func queryPatterns(args []string) {
+	if len(args) == 0 {
+		Exit("--query requires at least one path.")
+	}
+
+	knownPatterns := getAllKnownPatterns()
+	for i, name := range rootedPaths(args) {
+		match := trackPatternFor(knownPatterns, name)
+		switch {
+		case match == nil:
+			Print("%s: not tracked by any pattern", args[i])
+		case match.Tracked:
+			Print("%s: tracked by %q (%s)", args[i], match.Path, match.Source)
+		default:
+			Print("%s: not tracked, overridden by %q (%s)", args[i], match.Path, match.Source)
+		}
+	}
+}
+
+// trackPatternFor returns the gitattributes entry which sets or unsets the
+// filter attribute of "name", or nil if none does.  As in Git, patterns in
+// more deeply nested files take precedence over those in files nearer the
+// root, and global and system files, as do later patterns within a file.
+// The patterns must be ordered as by getAllKnownPatterns.
+func trackPatternFor(paths []git.AttributePath, name string) *git.AttributePath {
+	var source *git.AttributeSource
+	var match *git.AttributePath
+	for i, path := range paths {
+		if path.Source != source {
+			if match != nil {
+				break
+			}
+			source = path.Source
+		}
+		if path.Filter && attributePatternMatches(path, name) {
+			match = &paths[i]
+		}
+	}
+	return match
+}
+
+// attributePatternMatches returns whether the gitattributes entry "attr"
+// applies to "name", relative to the root of the working tree.  As in Git, a
+// pattern in a .gitattributes file is relative to that file's directory, and
+// a pattern without a slash matches the basename of a path at any depth.
+func attributePatternMatches(attr git.AttributePath, name string) bool {
+	name = filepath.ToSlash(name)
+	pattern := filepath.ToSlash(attr.Path)
+
+	// Entries have the directory of their source file prepended.
+	if dir := filepath.ToSlash(filepath.Dir(attr.Source.Path)); dir != "." {
+		pattern = strings.TrimPrefix(pattern, dir+"/")
+
+		inTree := filepath.Base(attr.Source.Path) == ".gitattributes" &&
+			!strings.HasPrefix(dir, "../")
+		if inTree {
+			if !strings.HasPrefix(name, dir+"/") {
+				return false
+			}
+			name = strings.TrimPrefix(name, dir+"/")
+		}
+	}
+
+	return compiledAttributePattern(pattern).Match(name)
+}
+
+var (
+	attributePatternsMu sync.Mutex
+	attributePatterns   = make(map[string]filepathfilter.Pattern)
+)
+
+// compiledAttributePattern returns the gitattributes pattern "pattern"
+// compiled for matching, compiling it only the first time it is seen, since
+// the same patterns are matched against every file in the working tree.
+func compiledAttributePattern(pattern string) filepathfilter.Pattern {
+	attributePatternsMu.Lock()
+	defer attributePatternsMu.Unlock()
+
+	p, ok := attributePatterns[pattern]
+	if !ok {
+		p = filepathfilter.NewPattern(pattern, filepathfilter.Strict(true), filepathfilter.CaseFold(cfg.IgnoreCase()))
+		attributePatterns[pattern] = p
+	}
+	return p
+}
+
 func getAllKnownPatterns() []git.AttributePath {
 	mp := gitattr.NewMacroProcessor()
 
@@ -330,10 +448,13 @@ func init() {
 	RegisterCommand("track", trackCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&trackLockableFlag, "lockable", "l", false, "make pattern lockable, i.e. read-only unless locked")
 		cmd.Flags().BoolVarP(&trackNotLockableFlag, "not-lockable", "", false, "remove lockable attribute from pattern")
+		cmd.Flags().BoolVarP(&trackAutotrackFlag, "autotrack", "", false, "only convert files matching the pattern which are at least lfs.autotrack.size")
 		cmd.Flags().BoolVarP(&trackVerboseLoggingFlag, "verbose", "v", false, "log which files are being tracked and modified")
 		cmd.Flags().BoolVarP(&trackDryRunFlag, "dry-run", "d", false, "preview results of running `git lfs track`")
 		cmd.Flags().BoolVarP(&trackNoModifyAttrsFlag, "no-modify-attrs", "", false, "skip modifying .gitattributes file")
 		cmd.Flags().BoolVarP(&trackNoExcludedFlag, "no-excluded", "", false, "skip listing excluded paths")
 		cmd.Flags().BoolVarP(&trackFilenameFlag, "filename", "", false, "treat this pattern as a literal filename")
+		cmd.Flags().BoolVarP(&trackQueryFlag, "query", "q", false, "show the pattern and file which determine whether each path is tracked")
+		cmd.Flags().BoolVarP(&trackDirnameFlag, "dirname", "", false, "treat this pattern as a literal directory name, tracking all files beneath it")
 	})
 }
@@ -5,13 +5,32 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	uninstallHooksOnly   = false
+	uninstallFiltersOnly = false
+)
+
 // uninstallCmd removes any configuration and hooks set by Git LFS.
 func uninstallCommand(cmd *cobra.Command, args []string) {
+	switch {
+	case uninstallHooksOnly && uninstallFiltersOnly:
+		Exit("Only one of --hooks-only and --filters-only options can be specified.")
+	case uninstallHooksOnly && skipRepoInstall:
+		Exit("Only one of --hooks-only and --skip-repo options can be specified.")
+	}
+
+	if uninstallHooksOnly {
+		requireGitVersion()
+		requireInRepo()
+		uninstallHooksCommand(cmd, args)
+		return
+	}
+
 	if err := cmdInstallOptions().Uninstall(); err != nil {
 		Print("WARNING: %s", err.Error())
 	}
 
-	if !skipRepoInstall && (localInstall || worktreeInstall || cfg.InRepo()) {
+	if !uninstallFiltersOnly && !skipRepoInstall && (localInstall || worktreeInstall || cfg.InRepo()) {
 		uninstallHooksCommand(cmd, args)
 	}
 
@@ -39,6 +58,8 @@ func init() {
 		}
 		cmd.Flags().BoolVarP(&systemInstall, "system", "", false, "Remove the Git LFS config in system-wide scope.")
 		cmd.Flags().BoolVarP(&skipRepoInstall, "skip-repo", "", false, "Skip repo setup, just uninstall global filters.")
+		cmd.Flags().BoolVarP(&uninstallHooksOnly, "hooks-only", "", false, "Remove only the Git LFS hooks of the current repository, leaving the filters configured.")
+		cmd.Flags().BoolVarP(&uninstallFiltersOnly, "filters-only", "", false, "Remove only the Git LFS filters, leaving the hooks of the current repository installed.")
 		cmd.AddCommand(NewCommand("hooks", uninstallHooksCommand))
 	})
 }
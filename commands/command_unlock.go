@@ -1,8 +1,9 @@
 package commands
 
 import (
-	"encoding/json"
 	"os"
+	pathpkg "path"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
@@ -16,23 +17,31 @@ var (
 
 // unlockFlags holds the flags given to the `git lfs unlock` command
 type unlockFlags struct {
-	// Id is the Id of the lock that is being unlocked.
-	Id string
+	// Ids are the Ids of the locks that are being unlocked.
+	Ids []string
 	// Force specifies whether or not the `lfs unlock` command was invoked
 	// with "--force", signifying the user's intent to break another
 	// individual's lock(s).
 	Force bool
 }
 
-var unlockUsage = "Usage: git lfs unlock (--id my-lock-id | <path>)"
+var unlockUsage = "Usage: git lfs unlock (--id my-lock-id... | <path>...)"
+
+// unlockResult is the outcome of unlocking a single lock, as reported by
+// `git lfs unlock --json` when more than one lock is given.
+type unlockResult struct {
+	Id       string `json:"id,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Unlocked bool   `json:"unlocked"`
+	Error    string `json:"error,omitempty"`
+}
 
 func unlockCommand(cmd *cobra.Command, args []string) {
 	hasPath := len(args) > 0
-	hasId := len(unlockCmdFlags.Id) > 0
-	if hasPath == hasId || len(args) > 1 {
+	hasId := len(unlockCmdFlags.Ids) > 0
+	if hasPath == hasId {
 		// If there is both an `--id` AND a `<path>`, or there is
-		// neither, or there are multiple paths, print the usage and
-		// quit.
+		// neither, print the usage and quit.
 		Exit(unlockUsage)
 	}
 
@@ -45,6 +54,11 @@ func unlockCommand(cmd *cobra.Command, args []string) {
 	lockClient.RemoteRef = refUpdate.Right()
 	defer lockClient.Close()
 
+	if len(args) > 1 || len(unlockCmdFlags.Ids) > 1 || (hasPath && isUnlockPattern(args[0])) {
+		unlockMany(lockClient, unlockTargets(lockClient, args))
+		return
+	}
+
 	if hasPath {
 		path, err := lockPath(args[0])
 		if err != nil {
@@ -59,39 +73,191 @@ func unlockCommand(cmd *cobra.Command, args []string) {
 
 		err = lockClient.UnlockFile(path, unlockCmdFlags.Force)
 		if err != nil {
-			Exit("%s", errors.Cause(err))
+			exitWithCode(err, "%s", errors.Cause(err))
 		}
 
-		if !locksCmdFlags.JSON {
+		if !jsonOutput {
 			Print("Unlocked %s", path)
 			return
 		}
-	} else if unlockCmdFlags.Id != "" {
+	} else if id := unlockCmdFlags.Ids[0]; id != "" {
 		// This call can early-out
-		unlockAbortIfFileModifiedById(unlockCmdFlags.Id, lockClient)
+		unlockAbortIfFileModifiedById(id, lockClient)
 
-		err := lockClient.UnlockFileById(unlockCmdFlags.Id, unlockCmdFlags.Force)
+		err := lockClient.UnlockFileById(id, unlockCmdFlags.Force)
 		if err != nil {
-			Exit("Unable to unlock %v: %v", unlockCmdFlags.Id, errors.Cause(err))
+			exitWithCode(err, "Unable to unlock %v: %v", id, errors.Cause(err))
 		}
 
-		if !locksCmdFlags.JSON {
-			Print("Unlocked Lock %s", unlockCmdFlags.Id)
+		if !jsonOutput {
+			Print("Unlocked Lock %s", id)
 			return
 		}
 	} else {
 		Error(unlockUsage)
 	}
 
-	if err := json.NewEncoder(os.Stdout).Encode(struct {
+	if err := PrintResult(struct {
 		Unlocked bool `json:"unlocked"`
 	}{true}); err != nil {
-		Error(err.Error())
+		ExitWithError(err)
+	}
+}
+
+// isUnlockPattern returns whether the given argument to `git lfs unlock` is a
+// pattern to match against the paths of existing locks, rather than a path.
+func isUnlockPattern(arg string) bool {
+	if !strings.ContainsAny(arg, "*?[") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return os.IsNotExist(err)
+}
+
+// unlockTargets returns the locks named by the given paths and patterns, or
+// else by the --id flag.  Patterns are matched against the paths of our own
+// locks, or with --force, against those of all locks, since the files of
+// locks held by others may not exist in this working tree.
+func unlockTargets(lockClient *locking.Client, args []string) []unlockResult {
+	var targets []unlockResult
+	seen := make(map[string]bool)
+	add := func(target unlockResult) {
+		key := target.Id + "\x00" + target.Path
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, target)
+		}
+	}
+
+	for _, id := range unlockCmdFlags.Ids {
+		add(unlockResult{Id: id, Path: unlockPathById(id, lockClient)})
+	}
+
+	var candidates []locking.Lock
+	for _, arg := range args {
+		path, err := lockPath(arg)
+		if err != nil {
+			if !unlockCmdFlags.Force {
+				Exit("Unable to determine path: %v", err.Error())
+			}
+			path = arg
+		}
+
+		if !isUnlockPattern(arg) {
+			add(unlockResult{Path: path})
+			continue
+		}
+
+		if candidates == nil {
+			candidates = unlockCandidates(lockClient)
+		}
+
+		var matched bool
+		for _, lock := range candidates {
+			if ok, err := pathpkg.Match(path, lock.Path); err != nil {
+				Exit("Invalid pattern %q: %v", arg, err)
+			} else if ok {
+				matched = true
+				add(unlockResult{Id: lock.Id, Path: lock.Path})
+			}
+		}
+		if !matched {
+			Exit("No locks match %q", arg)
+		}
+	}
+	return targets
+}
+
+// unlockCandidates returns the locks which a pattern given to `git lfs unlock`
+// may match.
+func unlockCandidates(lockClient *locking.Client) []locking.Lock {
+	if unlockCmdFlags.Force {
+		locks, err := lockClient.SearchLocks(nil, 0, false, false)
+		if err != nil {
+			Exit("Error while retrieving locks: %v", errors.Cause(err))
+		}
+		return locks
+	}
+
+	ourLocks, _, err := lockClient.SearchLocksVerifiable(0, false)
+	if err != nil {
+		Exit("Error while retrieving locks: %v", errors.Cause(err))
+	}
+	return ourLocks
+}
+
+// unlockMany unlocks each of the given targets, making up to
+// lfs.concurrenttransfers requests of the server at once, and reports which
+// succeeded and which failed.  Targets whose files have uncommitted changes
+// are not unlocked unless --force is given.
+func unlockMany(lockClient *locking.Client, targets []unlockResult) {
+	errs := make([]error, len(targets))
+	pending := make([]string, 0, len(targets))
+	indexes := make([]int, 0, len(targets))
+	for i, target := range targets {
+		if len(target.Path) > 0 {
+			if errs[i] = unlockCheckModified(target.Path); errs[i] != nil {
+				continue
+			}
+		}
+		pending = append(pending, target.Id)
+		indexes = append(indexes, i)
+	}
+
+	unlockErrs := forEachLockTarget(pending, func(i int, id string) error {
+		if len(id) > 0 {
+			return lockClient.UnlockFileById(id, unlockCmdFlags.Force)
+		}
+		return lockClient.UnlockFile(targets[indexes[i]].Path, unlockCmdFlags.Force)
+	})
+	for i, err := range unlockErrs {
+		errs[indexes[i]] = err
+	}
+
+	var unlocked int
+	for i := range targets {
+		target := &targets[i]
+		name := target.Path
+		if len(name) == 0 {
+			name = target.Id
+		}
+
+		if errs[i] != nil {
+			target.Error = errors.Cause(errs[i]).Error()
+			Error("Unable to unlock %s: %s", name, target.Error)
+			continue
+		}
+
+		target.Unlocked = true
+		unlocked++
+		if !jsonOutput {
+			Print("Unlocked %s", name)
+		}
+	}
+
+	if jsonOutput {
+		if err := PrintResult(targets); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	if failed := len(targets) - unlocked; failed > 0 {
+		Exit("Unlocked %d of %d locks; %d failed.", unlocked, len(targets), failed)
+	}
+	if !jsonOutput {
+		Print("Unlocked %d locks.", unlocked)
 	}
-	return
 }
 
 func unlockAbortIfFileModified(path string) {
+	if err := unlockCheckModified(path); err != nil {
+		Exit(err.Error())
+	}
+}
+
+// unlockCheckModified returns an error if the file at the given path has
+// uncommitted changes and may not be unlocked.  With --force, it only warns.
+func unlockCheckModified(path string) error {
 	modified, err := git.IsFileModified(path)
 
 	if err != nil {
@@ -102,9 +268,9 @@ func unlockAbortIfFileModified(path string) {
 			//
 			// Unlocking a files that does not exist with
 			// --force is OK.
-			return
+			return nil
 		}
-		Exit(err.Error())
+		return err
 	}
 
 	if modified {
@@ -112,13 +278,26 @@ func unlockAbortIfFileModified(path string) {
 			// Only a warning
 			Error("Warning: unlocking with uncommitted changes because --force")
 		} else {
-			Exit("Cannot unlock file with uncommitted changes")
+			return errors.New("Cannot unlock file with uncommitted changes")
 		}
 
 	}
+	return nil
 }
 
 func unlockAbortIfFileModifiedById(id string, lockClient *locking.Client) {
+	path := unlockPathById(id, lockClient)
+	if len(path) == 0 {
+		// Don't block if we can't determine the path, may be cleaning up old data
+		return
+	}
+
+	unlockAbortIfFileModified(path)
+}
+
+// unlockPathById returns the path of the lock with the given id, or an empty
+// string if it cannot be found.
+func unlockPathById(id string, lockClient *locking.Client) string {
 	// Get the path so we can check the status
 	filter := map[string]string{"id": id}
 	// try local cache first
@@ -129,18 +308,15 @@ func unlockAbortIfFileModifiedById(id string, lockClient *locking.Client) {
 	}
 
 	if len(locks) == 0 {
-		// Don't block if we can't determine the path, may be cleaning up old data
-		return
+		return ""
 	}
-
-	unlockAbortIfFileModified(locks[0].Path)
+	return locks[0].Path
 }
 
 func init() {
 	RegisterCommand("unlock", unlockCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&lockRemote, "remote", "r", "", lockRemoteHelp)
-		cmd.Flags().StringVarP(&unlockCmdFlags.Id, "id", "i", "", "unlock a lock by its ID")
+		cmd.Flags().StringArrayVarP(&unlockCmdFlags.Ids, "id", "i", nil, "unlock a lock by its ID")
 		cmd.Flags().BoolVarP(&unlockCmdFlags.Force, "force", "f", false, "forcibly break another user's lock(s)")
-		cmd.Flags().BoolVarP(&locksCmdFlags.JSON, "json", "", false, "print output in json")
 	})
 }
@@ -4,13 +4,28 @@ import (
 	"bufio"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/gitattr"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
 )
 
-// untrackCommand takes a list of paths as an argument, and removes each path from the
-// default attributes file (.gitattributes), if it exists.
+var (
+	untrackRestoreFlag bool
+)
+
+// untrackCommand takes a list of paths as an argument, and removes each path
+// from the attributes file (.gitattributes) in which it is tracked, which may
+// be that of the current directory or of any other directory in the working
+// tree.
 func untrackCommand(cmd *cobra.Command, args []string) {
 	setupWorkingCopy()
 
@@ -21,51 +36,196 @@ func untrackCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	data, err := ioutil.ReadFile(".gitattributes")
+	wd, _ := tools.Getwd()
+	wd = tools.ResolveSymlinks(wd)
+	relpath, err := filepath.Rel(cfg.LocalWorkingDir(), wd)
 	if err != nil {
-		return
+		Exit("Current directory %q outside of git working directory %q.", wd, cfg.LocalWorkingDir())
+	}
+
+	// Patterns are compared relative to the root of the working tree, so
+	// that they may be removed from whichever attributes file has them.
+	targets := make([]string, 0, len(args))
+	for _, arg := range args {
+		pattern := unescapeAttrPattern(escapeAttrPattern(cleanRootPath(arg)))
+		targets = append(targets, untrackRootedPattern(relpath, pattern))
+	}
+
+	var removed []git.AttributePath
+	for _, source := range untrackAttributeFiles(relpath) {
+		removed = append(removed, untrackFromFile(source, relpath, targets)...)
 	}
 
-	attributes := strings.NewReader(string(data))
+	if untrackRestoreFlag && len(removed) > 0 {
+		restoreUntracked(removed)
+	}
+}
 
-	attributesFile, err := os.Create(".gitattributes")
+// untrackAttributeFiles returns the paths, relative to the root of the
+// working tree, of the .gitattributes files which may track patterns, always
+// including that of the current directory, if it exists.
+func untrackAttributeFiles(relpath string) []string {
+	current := filepath.Join(relpath, ".gitattributes")
+	sources := []string{current}
+	seen := map[string]bool{current: true}
+
+	for _, p := range git.GetAttributePaths(gitattr.NewMacroProcessor(), cfg.LocalWorkingDir(), cfg.LocalGitDir()) {
+		name := p.Source.Path
+		if seen[name] || filepath.Base(name) != ".gitattributes" || strings.HasPrefix(filepath.ToSlash(name), "../") {
+			continue
+		}
+		seen[name] = true
+		sources = append(sources, name)
+	}
+	return sources
+}
+
+// untrackFromFile rewrites the attributes file at "source", relative to the
+// root of the working tree, omitting the LFS patterns which are among
+// "targets", and returns the entries it removed.  Patterns removed from files
+// other than that of the current directory, "relpath", are shown with the
+// name of their file.
+func untrackFromFile(source, relpath string, targets []string) []git.AttributePath {
+	filename := filepath.Join(cfg.LocalWorkingDir(), source)
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		Print("Error opening .gitattributes for writing")
-		return
+		return nil
 	}
-	defer attributesFile.Close()
 
-	scanner := bufio.NewScanner(attributes)
+	dir := filepath.Dir(source)
+	ignoreCase := cfg.IgnoreCase()
+
+	var removed []git.AttributePath
+	var kept strings.Builder
 
 	// Iterate through each line of the attributes file and rewrite it,
 	// if the path was meant to be untracked, omit it, and print a message instead.
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
 	for scanner.Scan() {
 		line := scanner.Text()
 		if !strings.Contains(line, "filter=lfs") {
-			attributesFile.WriteString(line + "\n")
+			kept.WriteString(line + "\n")
 			continue
 		}
 
-		path := strings.Fields(line)[0]
-		if removePath(path, args) {
-			Print("Untracking %q", unescapeAttrPattern(path))
+		pattern := strings.Fields(line)[0]
+		if !untrackMatches(untrackRootedPattern(dir, unescapeAttrPattern(pattern)), targets, ignoreCase) {
+			kept.WriteString(line + "\n")
+			continue
+		}
+
+		if dir == relpath {
+			Print("Untracking %q", unescapeAttrPattern(pattern))
 		} else {
-			attributesFile.WriteString(line + "\n")
+			Print("Untracking %q (%s)", unescapeAttrPattern(pattern), filepath.ToSlash(source))
 		}
+
+		removed = append(removed, git.AttributePath{
+			Path:    filepath.Join(dir, trimCurrentPrefix(pattern)),
+			Source:  &git.AttributeSource{Path: source},
+			Tracked: true,
+			Filter:  true,
+		})
 	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(kept.String()), 0660); err != nil {
+		Print("Error opening %s for writing", source)
+		return nil
+	}
+	return removed
+}
+
+// untrackRootedPattern returns "pattern", which is relative to the directory
+// "dir", relative to the root of the working tree.
+func untrackRootedPattern(dir, pattern string) string {
+	return path.Join(filepath.ToSlash(dir), trimCurrentPrefix(pattern))
 }
 
-func removePath(path string, args []string) bool {
-	withoutCurrentDir := trimCurrentPrefix(path)
-	for _, t := range args {
-		if withoutCurrentDir == escapeAttrPattern(trimCurrentPrefix(t)) {
+func untrackMatches(pattern string, targets []string, ignoreCase bool) bool {
+	for _, t := range targets {
+		if pattern == t || (ignoreCase && strings.EqualFold(pattern, t)) {
 			return true
 		}
 	}
+	return false
+}
+
+// restoreUntracked converts the files in the index which were tracked by the
+// "removed" patterns, and are no longer tracked by any other, back to plain
+// Git content, first replacing any pointers in the working tree with their
+// objects.
+func restoreUntracked(removed []git.AttributePath) {
+	lsFiles, err := git.NewLsFiles(cfg.LocalWorkingDir(), false, false)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, "Could not list files in the index"))
+	}
+
+	knownPatterns := getAllKnownPatterns()
+	gitfilter := lfs.NewGitFilter(cfg)
+	manifest := getTransferManifest()
+
+	names := make([]string, 0, len(lsFiles.Files))
+	for name := range lsFiles.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
+	var restored []string
+	for _, name := range names {
+		if !untrackRemovedFrom(removed, name) {
+			continue
+		}
+		if match := trackPatternFor(knownPatterns, name); match != nil && match.Tracked {
+			continue
+		}
+
+		filename := filepath.Join(cfg.LocalWorkingDir(), name)
+		ptr, err := lfs.DecodePointerFromFile(filename)
+		if err == nil {
+			if !cfg.LFSObjectExists(ptr.Oid, ptr.Size) {
+				Error("Unable to restore %q: object %s not found locally. Use fetch to download.", name, ptr.Oid)
+				continue
+			}
+			if err := gitfilter.SmudgeToFile(filename, ptr, false, manifest, nil); err != nil {
+				LoggedError(err, "Unable to restore %q: %s", name, err)
+				continue
+			}
+		} else if os.IsNotExist(err) {
+			continue
+		}
+
+		// Make the file stat-dirty, so that Git re-reads its contents
+		// rather than trusting the index.
+		now := time.Now()
+		if err := os.Chtimes(filename, now, now); err != nil {
+			LoggedError(err, "Error marking %q modified: %s", name, err)
+			continue
+		}
+
+		Print("Restoring %q", name)
+		restored = append(restored, filename)
+	}
+
+	if err := git.Add(restored); err != nil {
+		ExitWithError(errors.Wrap(err, "Could not update the index"))
+	}
+}
+
+func untrackRemovedFrom(removed []git.AttributePath, name string) bool {
+	for _, attr := range removed {
+		if attributePatternMatches(attr, name) {
+			return true
+		}
+	}
 	return false
 }
 
 func init() {
-	RegisterCommand("untrack", untrackCommand, nil)
+	RegisterCommand("untrack", untrackCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&untrackRestoreFlag, "restore", "", false, "convert matching files back to plain Git content")
+	})
 }
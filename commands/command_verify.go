@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"os"
+	"sort"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyRefArg string
+)
+
+// verifyBatchSize is the number of objects asked about in each batch request
+// by remoteMissingObjects, as the transfer queue asks by default.
+const verifyBatchSize = 100
+
+// verifyCommand checks that the remote has every object referenced by the
+// tree of a ref, and reports the paths of those which it does not.
+func verifyCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(args) > 1 {
+		Exit("Usage: git lfs verify [--ref <ref>] [<remote>]")
+	}
+	if len(args) > 0 {
+		if err := cfg.SetValidRemote(args[0]); err != nil {
+			Exit("Invalid remote name %q: %s", args[0], err)
+		}
+	}
+
+	var ref *git.Ref
+	var err error
+	if len(verifyRefArg) > 0 {
+		ref, err = git.ResolveRef(verifyRefArg)
+	} else {
+		ref, err = git.CurrentRef()
+	}
+	if err != nil {
+		Exit("Could not resolve ref: %v", err)
+	}
+
+	sizes := make(map[string]int64)
+	names := make(map[string][]string)
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Panic(err, "Could not scan for Git LFS files")
+		}
+		sizes[p.Oid] = p.Size
+		names[p.Oid] = append(names[p.Oid], p.Name)
+	})
+	if err := gitscanner.ScanTree(ref.Sha); err != nil {
+		ExitWithError(err)
+	}
+	gitscanner.Close()
+
+	missing, err := remoteMissingObjects(cfg.Remote(), ref, sizes)
+	if err != nil {
+		Exit("Could not check objects on %s: %v", cfg.Remote(), errors.Cause(err))
+	}
+
+	var paths []string
+	oids := make(map[string]string)
+	for oid := range missing {
+		for _, name := range names[oid] {
+			paths = append(paths, name)
+			oids[name] = oid
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if jsonOutput {
+			if err := PrintEvent("missing", &verifyMissing{Path: path, Oid: oids[path]}); err != nil {
+				ExitWithError(err)
+			}
+		} else {
+			Print("missing: %s (%s)", path, oids[path])
+		}
+	}
+
+	if jsonOutput {
+		err := PrintResult(&verifyResult{
+			Ref: ref.Name, Remote: cfg.Remote(), Objects: len(sizes), Missing: len(missing),
+		})
+		if err != nil {
+			ExitWithError(err)
+		}
+	} else if len(missing) > 0 {
+		Print("%d of %d object(s) in %s are missing from %s", len(missing), len(sizes), ref.Name, cfg.Remote())
+	} else {
+		Print("All %d object(s) in %s are present on %s", len(sizes), ref.Name, cfg.Remote())
+	}
+	if len(missing) > 0 {
+		os.Exit(exitCodeMissingOnServer)
+	}
+}
+
+// verifyMissing is the data of a "missing" event written by "git lfs verify
+// --json", for each file whose object is missing from the remote.
+type verifyMissing struct {
+	Path string `json:"path"`
+	Oid  string `json:"oid"`
+}
+
+// verifyResult is the result written by "git lfs verify --json".
+type verifyResult struct {
+	Ref     string `json:"ref"`
+	Remote  string `json:"remote"`
+	Objects int    `json:"objects"`
+	Missing int    `json:"missing"`
+}
+
+// remoteMissingObjects asks the given remote, in batches, about the objects
+// with the given OIDs and sizes, and returns the set of OIDs of those which it
+// does not have.
+func remoteMissingObjects(remote string, ref *git.Ref, sizes map[string]int64) (map[string]bool, error) {
+	oids := make([]string, 0, len(sizes))
+	for oid := range sizes {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+
+	manifest := getTransferManifest()
+	missing := make(map[string]bool)
+	for len(oids) > 0 {
+		n := verifyBatchSize
+		if n > len(oids) {
+			n = len(oids)
+		}
+
+		transfers := make([]*tq.Transfer, 0, n)
+		for _, oid := range oids[:n] {
+			transfers = append(transfers, &tq.Transfer{Oid: oid, Size: sizes[oid]})
+		}
+		oids = oids[n:]
+
+		res, err := tq.Batch(manifest, tq.Download, remote, ref, transfers)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range res.Objects {
+			if t.Error != nil && (t.Error.Code == 404 || t.Error.Code == 410) {
+				missing[t.Oid] = true
+			}
+		}
+	}
+	return missing, nil
+}
+
+func init() {
+	RegisterCommand("verify", verifyCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&verifyRefArg, "ref", "", "", "Check the objects in this ref rather than the current one.")
+	})
+}
@@ -1,7 +1,13 @@
 package commands
 
 import (
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +15,52 @@ var (
 	lovesComics bool
 )
 
+// versionFeatures lists the optional parts of the Git LFS protocols which
+// this client supports, by the names given in "git lfs version --json".
+var versionFeatures = []string{
+	// batch-ref: the current ref is sent with batch API requests.
+	"batch-ref",
+	// hash-algo: objects hashed with other algorithms than SHA-256 are
+	// transferred, with the algorithm named in batch API requests.
+	"hash-algo",
+	// locking: the file locking API is used.
+	"locking",
+	// ssh-protocol: objects are transferred over SSH with
+	// git-lfs-transfer, where the server supports it.
+	"ssh-protocol",
+	// gzip: compressed API responses are accepted.
+	"gzip",
+}
+
+// versionJSONOutput is the output of "git lfs version --json".
+type versionJSONOutput struct {
+	Version          string              `json:"version"`
+	UserAgent        string              `json:"user_agent"`
+	Build            versionJSONBuild    `json:"build"`
+	TransferAdapters versionJSONAdapters `json:"transfer_adapters"`
+	HashAlgorithms   []string            `json:"hash_algorithms"`
+	Features         []string            `json:"features"`
+}
+
+type versionJSONBuild struct {
+	Vendor    string `json:"vendor"`
+	GitCommit string `json:"git_commit"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+type versionJSONAdapters struct {
+	Download []string `json:"download"`
+	Upload   []string `json:"upload"`
+}
+
 func versionCommand(cmd *cobra.Command, args []string) {
+	if jsonOutput {
+		versionPrintJSON()
+		return
+	}
+
 	Print(lfshttp.UserAgent)
 
 	if lovesComics {
@@ -17,6 +68,38 @@ func versionCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// versionPrintJSON prints the version, build and capabilities of this client
+// in a stable JSON format, so that scripts can detect features without parsing
+// the user agent.
+func versionPrintJSON() {
+	manifest := getTransferManifest()
+	download := manifest.GetDownloadAdapterNames()
+	upload := manifest.GetUploadAdapterNames()
+	sort.Strings(download)
+	sort.Strings(upload)
+
+	err := PrintResult(versionJSONOutput{
+		Version:   config.Version,
+		UserAgent: lfshttp.UserAgent,
+		Build: versionJSONBuild{
+			Vendor:    config.Vendor,
+			GitCommit: config.GitCommit,
+			GoVersion: strings.TrimPrefix(runtime.Version(), "go"),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+		},
+		TransferAdapters: versionJSONAdapters{
+			Download: download,
+			Upload:   upload,
+		},
+		HashAlgorithms: []string{tools.HashAlgorithmSHA256, tools.HashAlgorithmSHA512},
+		Features:       versionFeatures,
+	})
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
 func init() {
 	RegisterCommand("version", versionCommand, func(cmd *cobra.Command) {
 		cmd.PreRun = nil
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -22,6 +23,8 @@ import (
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // Populate man pages
@@ -33,12 +36,16 @@ var (
 	ErrorWriter  = newMultiWriter(os.Stderr, ErrorBuffer)
 	OutputWriter = newMultiWriter(os.Stdout, ErrorBuffer)
 	ManPages     = make(map[string]string, 20)
+	ManDocs      = make(map[string]*ManDoc, 20)
 	tqManifest   = make(map[string]*tq.Manifest)
 
 	cfg       *config.Configuration
 	apiClient *lfsapi.Client
 	global    sync.Mutex
 
+	progressStream     *tq.ProgressStream
+	progressStreamOnce sync.Once
+
 	oldEnv = make(map[string]string)
 
 	includeArg string
@@ -63,11 +70,31 @@ func getTransferManifestOperationRemote(operation, remote string) *tq.Manifest {
 	k := fmt.Sprintf("%s.%s", operation, remote)
 	if tqManifest[k] == nil {
 		tqManifest[k] = tq.NewManifest(cfg.Filesystem(), c, operation, remote)
+		if tqManifest[k] != nil {
+			tqManifest[k].SetStatsFunc(recordTransferStats)
+			tqManifest[k].SetObjectStore(cfg.ObjectStore())
+			tqManifest[k].SetWarningOut(os.Stderr)
+			tqManifest[k].SetProgressStream(getProgressStream())
+		}
 	}
 
 	return tqManifest[k]
 }
 
+// getProgressStream returns the JSON progress stream named by
+// GIT_LFS_PROGRESS_JSON, or nil if there is none.  It is opened once, and
+// shared by every manifest, meter and filter of the command.
+func getProgressStream() *tq.ProgressStream {
+	progressStreamOnce.Do(func() {
+		s, err := tq.NewProgressStreamFromEnv(cfg.Os)
+		if err != nil {
+			Error("Error creating JSON progress stream: %s", err)
+		}
+		progressStream = s
+	})
+	return progressStream
+}
+
 func getAPIClient() *lfsapi.Client {
 	global.Lock()
 	defer global.Unlock()
@@ -77,6 +104,7 @@ func getAPIClient() *lfsapi.Client {
 		if err != nil {
 			ExitWithError(err)
 		}
+		c.SetWarningOut(os.Stderr)
 		apiClient = c
 	}
 	return apiClient
@@ -88,6 +116,7 @@ func closeAPIClient() error {
 	if apiClient == nil {
 		return nil
 	}
+	saveServerLimits(apiClient.Limits())
 	return apiClient.Close()
 }
 
@@ -130,14 +159,22 @@ func currentRemoteRef() *git.Ref {
 
 func buildFilepathFilter(config *config.Configuration, includeArg, excludeArg *string, useFetchOptions bool) *filepathfilter.Filter {
 	inc, exc := determineIncludeExcludePaths(config, includeArg, excludeArg, useFetchOptions)
-	return filepathfilter.New(inc, exc)
+	return filepathfilter.New(inc, exc, filepathfilter.IgnoreCase(config.IgnoreCase()))
 }
 
 func downloadTransfer(p *lfs.WrappedPointer) (name, path, oid string, size int64, missing bool, err error) {
-	path, err = cfg.Filesystem().ObjectPath(p.Oid)
+	path, err = downloadPath(p.Oid)
 	return p.Name, path, p.Oid, p.Size, false, err
 }
 
+// downloadPath returns the path at which the object "oid" is staged as it is
+// downloaded. The transfer queue moves it into the object store once it has
+// been verified, which leaves it in place if that is the local object store on
+// disk.
+func downloadPath(oid string) (string, error) {
+	return cfg.Filesystem().ObjectPath(oid)
+}
+
 // Get user-readable manual install steps for hooks
 func getHookInstallSteps() string {
 	hookDir, err := cfg.HookDir()
@@ -191,8 +228,23 @@ func uninstallHooks() error {
 }
 
 // Error prints a formatted message to Stderr.  It also gets printed to the
-// panic log if one is created for this command.
+// panic log if one is created for this command.  The format is translated
+// into the user's language first, if the message catalog has it.
+//
+// With --json, it writes the message to Stdout as an error message instead,
+// untranslated, so that scripts may rely on its text.
 func Error(format string, args ...interface{}) {
+	printError("", format, args...)
+}
+
+// printError prints a formatted message as Error does, giving the code of the
+// error in the message written with --json.
+func printError(code errors.Code, format string, args ...interface{}) {
+	if jsonOutput {
+		writeJSONMessage(&jsonMessage{Type: "error", Error: formatMessage(format, args...), Code: string(code)})
+		return
+	}
+	format = tr.Tr.Get(format)
 	if len(args) == 0 {
 		fmt.Fprintln(ErrorWriter, format)
 		return
@@ -201,8 +253,17 @@ func Error(format string, args ...interface{}) {
 }
 
 // Print prints a formatted message to Stdout.  It also gets printed to the
-// panic log if one is created for this command.
+// panic log if one is created for this command.  As with Error, the format is
+// translated first.
+//
+// With --json, it writes the message, untranslated, as a "message" event
+// instead.
 func Print(format string, args ...interface{}) {
+	if jsonOutput {
+		PrintEvent("message", &jsonTextMessage{Message: formatMessage(format, args...)})
+		return
+	}
+	format = tr.Tr.Get(format)
 	if len(args) == 0 {
 		fmt.Fprintln(OutputWriter, format)
 		return
@@ -213,19 +274,32 @@ func Print(format string, args ...interface{}) {
 // Exit prints a formatted message and exits.
 func Exit(format string, args ...interface{}) {
 	Error(format, args...)
-	os.Exit(2)
+	exit(exitCodeError)
+}
+
+// exitWithCode prints a formatted message and exits with the exit code for the
+// class of the given error.
+func exitWithCode(err error, format string, args ...interface{}) {
+	printError(errors.ErrorCode(err), format, args...)
+	exit(exitCodeForError(err))
 }
 
 // ExitWithError either panics with a full stack trace for fatal errors, or
-// simply prints the error message and exits immediately.
+// simply prints the error message and exits immediately, with the exit code
+// for the class of the error.
 func ExitWithError(err error) {
-	errorWith(err, Panic, Exit)
+	errorWith(err, Panic, func(format string, args ...interface{}) {
+		printError(errors.ErrorCode(err), format, args...)
+		exit(exitCodeForError(err))
+	})
 }
 
 // FullError prints either a full stack trace for fatal errors, or just the
 // error message.
 func FullError(err error) {
-	errorWith(err, LoggedError, Error)
+	errorWith(err, LoggedError, func(format string, args ...interface{}) {
+		printError(errors.ErrorCode(err), format, args...)
+	})
 }
 
 func errorWith(err error, fatalErrFn func(error, string, ...interface{}), errFn func(string, ...interface{})) {
@@ -254,12 +328,15 @@ func Debug(format string, args ...interface{}) {
 // It also writes a stack trace for the error to a log file without exiting.
 func LoggedError(err error, format string, args ...interface{}) {
 	if len(format) > 0 {
-		Error(format, args...)
+		printError(errors.ErrorCode(err), format, args...)
+	}
+	if err != nil {
+		tracelog.Errorf("%s", err)
 	}
 	file := handlePanic(err)
 
 	if len(file) > 0 {
-		fmt.Fprintf(os.Stderr, "\nErrors logged to %s\nUse `git lfs logs last` to view the log.\n", file)
+		fmt.Fprint(os.Stderr, tr.Tr.Get("\nErrors logged to %s\nUse `git lfs logs last` to view the log.\n", file))
 	}
 }
 
@@ -267,15 +344,42 @@ func LoggedError(err error, format string, args ...interface{}) {
 // a log file before exiting.
 func Panic(err error, format string, args ...interface{}) {
 	LoggedError(err, format, args...)
-	os.Exit(2)
+	exit(exitCodeError)
 }
 
 func Cleanup() {
+	unmount()
 	if err := cfg.Cleanup(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error clearing old temp files: %s\n", err)
 	}
 }
 
+// signalHandler, if set, is called when Git LFS is asked to stop by a signal,
+// and returns true if the running command will stop by itself, rather than Git
+// LFS exiting at once.
+var (
+	signalHandler   func(sig os.Signal) bool
+	signalHandlerMu sync.Mutex
+)
+
+// setSignalHandler sets the function called by HandleSignal.
+func setSignalHandler(fn func(sig os.Signal) bool) {
+	signalHandlerMu.Lock()
+	defer signalHandlerMu.Unlock()
+	signalHandler = fn
+}
+
+// HandleSignal gives the running command the chance to stop gracefully when
+// Git LFS receives the given signal.  It returns false if Git LFS should clean
+// up and exit instead.
+func HandleSignal(sig os.Signal) bool {
+	signalHandlerMu.Lock()
+	fn := signalHandler
+	signalHandlerMu.Unlock()
+
+	return fn != nil && fn(sig)
+}
+
 func PipeMediaCommand(name string, args ...string) error {
 	return PipeCommand("bin/"+name, args...)
 }
@@ -333,6 +437,30 @@ func setupRepository() {
 	if !bare {
 		changeToWorkingCopy()
 	}
+	registerSharedStorage()
+	cleanupStaleFiles()
+}
+
+// cleanupStaleFiles removes temporary files and partial downloads left behind
+// by interrupted commands, at most once a day.
+func cleanupStaleFiles() {
+	if _, err := cfg.Filesystem().CleanupStaleIfDue(); err != nil {
+		tracelog.Printf("unable to remove stale temporary files: %s", err)
+	}
+}
+
+// registerSharedStorage records the current repository as a user of the LFS
+// storage directory, if it is shared with other repositories, so that pruning
+// from those repositories retains the objects needed by this one.
+func registerSharedStorage() {
+	dir := cfg.LocalWorkingDir()
+	if len(dir) == 0 {
+		dir = cfg.LocalGitDir()
+	}
+
+	if err := cfg.Filesystem().RegisterRepository(dir); err != nil {
+		Error("warning: unable to register repository with shared storage %s: %s", cfg.LFSStorageDir(), err)
+	}
 }
 
 func verifyRepositoryVersion() {
@@ -406,6 +534,11 @@ func logPanic(loggedError error) string {
 		lineEnding string    = "\n"
 	)
 
+	logToWriter := logPanicToWriter
+	if logFormat() == tracelog.JSONFormat {
+		logToWriter = logPanicJSONToWriter
+	}
+
 	now := time.Now()
 	name := now.Format("20060102T150405.999999999")
 	full := filepath.Join(cfg.LocalLogDir(), name+".log")
@@ -418,7 +551,7 @@ func logPanic(loggedError error) string {
 		full = ""
 		defer func() {
 			fmt.Fprintf(fmtWriter, "Unable to log panic to %s\n\n", filename)
-			logPanicToWriter(fmtWriter, err, lineEnding)
+			logToWriter(fmtWriter, err, lineEnding)
 		}()
 	} else {
 		fmtWriter = file
@@ -426,7 +559,7 @@ func logPanic(loggedError error) string {
 		defer file.Close()
 	}
 
-	logPanicToWriter(fmtWriter, loggedError, lineEnding)
+	logToWriter(fmtWriter, loggedError, lineEnding)
 
 	return full
 }
@@ -516,6 +649,56 @@ func logPanicToWriter(w io.Writer, loggedError error, le string) {
 	}
 }
 
+// errorLog is the record of an error written by logPanicJSONToWriter.
+type errorLog struct {
+	Time          string            `json:"time"`
+	Command       string            `json:"command"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Version       string            `json:"version"`
+	GitVersion    string            `json:"git_version"`
+	Args          []string          `json:"args"`
+	Output        string            `json:"output"`
+	Error         string            `json:"error"`
+	Context       map[string]string `json:"context,omitempty"`
+	Env           []string          `json:"env"`
+	IPAddresses   []string          `json:"ip_addresses"`
+}
+
+// logPanicJSONToWriter writes the same information as logPanicToWriter, as a
+// single JSON record, for lfs.logformat=json.
+func logPanicJSONToWriter(w io.Writer, loggedError error, le string) {
+	gitV, err := git.Version()
+	if err != nil {
+		gitV = "Error getting git version: " + err.Error()
+	}
+
+	record := &errorLog{
+		Time:          time.Now().UTC().Format(time.RFC3339Nano),
+		Command:       jsonCommand,
+		CorrelationID: tracelog.CorrelationID(),
+		Version:       config.VersionDesc,
+		GitVersion:    gitV,
+		Args:          append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...),
+		Output:        ErrorBuffer.String(),
+		Error:         fmt.Sprintf("%+v", loggedError),
+		Env:           lfs.Environ(cfg, getTransferManifest(), oldEnv),
+		IPAddresses:   ipAddresses(),
+	}
+	if ctx := errors.Context(loggedError); len(ctx) > 0 {
+		record.Context = make(map[string]string, len(ctx))
+		for key, val := range ctx {
+			record.Context[key] = fmt.Sprintf("%v", val)
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		fmt.Fprintf(w, "Unable to encode error log: %s%s", err, le)
+		return
+	}
+	w.Write(append(data, le...))
+}
+
 func determineIncludeExcludePaths(config *config.Configuration, includeArg, excludeArg *string, useFetchOptions bool) (include, exclude []string) {
 	if includeArg == nil {
 		if useFetchOptions {
@@ -540,12 +723,23 @@ func determineIncludeExcludePaths(config *config.Configuration, includeArg, excl
 
 func buildProgressMeter(dryRun bool, d tq.Direction) *tq.Meter {
 	m := tq.NewMeter(cfg)
-	m.Logger = m.LoggerFromEnv(cfg.Os)
+	setProgressOutputs(m)
 	m.DryRun = dryRun
 	m.Direction = d
 	return m
 }
 
+// setProgressOutputs gives the meter the progress log named by
+// GIT_LFS_PROGRESS, and the JSON progress stream, if either is set.
+func setProgressOutputs(m *tq.Meter) {
+	logger, err := m.LoggerFromEnv(cfg.Os)
+	if err != nil {
+		Error("Error creating progress logger: %s", err)
+	}
+	m.Logger = logger
+	m.Stream = getProgressStream()
+}
+
 func requireGitVersion() {
 	minimumGit := "1.8.2"
 
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"sync"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+// dryRunReport lists the objects which a dry run of "git lfs push" or "git lfs
+// fetch" would transfer, and their total size.  Objects are only listed once
+// the server has said in reply to a batch API request that it needs them, or
+// has them to give, so the total is the amount of data which would be sent.
+type dryRunReport struct {
+	verb      string
+	direction tq.Direction
+
+	seen  tools.StringSet
+	count int
+	size  int64
+	mu    sync.Mutex
+	wg    sync.WaitGroup
+}
+
+func newDryRunReport(verb string, direction tq.Direction) *dryRunReport {
+	return &dryRunReport{
+		verb:      verb,
+		direction: direction,
+		seen:      tools.NewStringSet(),
+	}
+}
+
+// Watch lists each object which the given dry run queue would transfer.
+func (r *dryRunReport) Watch(q *tq.TransferQueue) {
+	transfers := q.Watch()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for t := range transfers {
+			r.add(t)
+		}
+	}()
+}
+
+func (r *dryRunReport) add(t *tq.Transfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A queue reports an object once for each path at which it was
+	// found, but it is only transferred once.
+	if r.seen.Contains(t.Oid) {
+		return
+	}
+	r.seen.Add(t.Oid)
+	r.count++
+	r.size += t.Size
+
+	Print("%s %s => %s (%s)", r.verb, t.Oid, t.Name, humanize.FormatBytes(uint64(t.Size)))
+}
+
+// Finish waits for the objects of the watched queues, which must all have
+// finished, to be listed, and prints their number and total size.
+func (r *dryRunReport) Finish() {
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	verb := "download"
+	if r.direction == tq.Upload {
+		verb = "upload"
+	}
+	Print("%s: dry run would %s %d object(s), %s", r.verb, verb, r.count, humanize.FormatBytes(uint64(r.size)))
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"os"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/metrics"
+)
+
+// Exit codes which let scripts tell classes of failure apart without parsing
+// error messages.  They are documented in the EXIT STATUS section of
+// git-lfs(1), and must not be changed, nor may a failure be moved from one
+// class to another; new classes are given new codes.
+const (
+	// exitCodeError is the exit code of failures which fall into none of
+	// the classes below, such as invalid arguments.
+	exitCodeError = 2
+	// exitCodeTransferFailed is the exit code of a command which failed to
+	// transfer some objects, for reasons other than those below, such as
+	// network errors.
+	exitCodeTransferFailed = 3
+	// exitCodeAuthFailed is the exit code of a command which failed
+	// because the server rejected, or was not given, credentials.
+	exitCodeAuthFailed = 4
+	// exitCodeMissingOnServer is the exit code of a command which failed
+	// because objects it needed were missing from the server.
+	exitCodeMissingOnServer = 5
+	// exitCodeLocalCorruption is the exit code of a command which failed
+	// because objects or pointers in the local repository are missing or
+	// corrupt.
+	exitCodeLocalCorruption = 6
+	// exitCodeLockConflict is the exit code of a command which failed
+	// because a file is locked by another user.
+	exitCodeLockConflict = 7
+	// exitCodeQuotaExceeded is the exit code of a command which failed
+	// because the server's storage or bandwidth quota was exceeded.
+	exitCodeQuotaExceeded = 8
+	// exitCodePolicyRejected is the exit code of a command which failed
+	// because a policy, such as the upload scanner, rejected some objects.
+	exitCodePolicyRejected = 9
+)
+
+var (
+	// transferErrors holds the errors of the failed transfers reported
+	// with reportTransferErrors.
+	transferErrors   []error
+	transferErrorsMu sync.Mutex
+)
+
+// reportTransferErrors prints the given errors of failed transfers, and keeps
+// them so that exitTransferFailed can choose the exit code.  It returns
+// whether there were no errors.
+func reportTransferErrors(errs []error) bool {
+	transferErrorsMu.Lock()
+	defer transferErrorsMu.Unlock()
+
+	for _, err := range errs {
+		FullError(err)
+		transferErrors = append(transferErrors, err)
+	}
+	return len(errs) == 0
+}
+
+// exitTransferFailed prints a formatted message and exits with the code for
+// the errors reported with reportTransferErrors.
+func exitTransferFailed(format string, args ...interface{}) {
+	Error(format, args...)
+
+	transferErrorsMu.Lock()
+	defer transferErrorsMu.Unlock()
+	exit(exitCodeForTransferErrors(transferErrors))
+}
+
+// exit sends any metrics held back, and exits with the given code.
+func exit(code int) {
+	metrics.Flush()
+	os.Exit(code)
+}
+
+// exitCodeForTransferErrors returns the exit code for a command which failed
+// to transfer some objects with the given errors.  An authentication failure
+// is reported in preference to the others, since the server may deny that
+// objects exist to those who may not see them, and a missing object in
+// preference to an exceeded quota, local corruption or a rejected object.
+func exitCodeForTransferErrors(errs []error) int {
+	code := exitCodeTransferFailed
+	for _, err := range errs {
+		switch errors.ErrorCode(err) {
+		case errors.CodeAuth:
+			return exitCodeAuthFailed
+		case errors.CodeNotFound:
+			code = exitCodeMissingOnServer
+		case errors.CodeQuota:
+			if code != exitCodeMissingOnServer {
+				code = exitCodeQuotaExceeded
+			}
+		case errors.CodeCorruption:
+			if code == exitCodeTransferFailed {
+				code = exitCodeLocalCorruption
+			}
+		case errors.CodePolicy:
+			if code == exitCodeTransferFailed {
+				code = exitCodePolicyRejected
+			}
+		}
+	}
+	return code
+}
+
+// exitCodeForError returns the exit code for a command which failed with the
+// given error.
+func exitCodeForError(err error) int {
+	switch errors.ErrorCode(err) {
+	case errors.CodeNetwork:
+		return exitCodeTransferFailed
+	case errors.CodeAuth:
+		return exitCodeAuthFailed
+	case errors.CodeNotFound:
+		return exitCodeMissingOnServer
+	case errors.CodeCorruption:
+		return exitCodeLocalCorruption
+	case errors.CodeLockConflict:
+		return exitCodeLockConflict
+	case errors.CodeQuota:
+		return exitCodeQuotaExceeded
+	case errors.CodePolicy:
+		return exitCodePolicyRejected
+	default:
+		return exitCodeError
+	}
+}
+
+// errorCodeForExitCode returns the class of failure reported by the given exit
+// code, as given in the "code" of errors written with --json, or an empty
+// Code if it reports none.
+func errorCodeForExitCode(code int) errors.Code {
+	switch code {
+	case exitCodeTransferFailed:
+		return errors.CodeNetwork
+	case exitCodeAuthFailed:
+		return errors.CodeAuth
+	case exitCodeMissingOnServer:
+		return errors.CodeNotFound
+	case exitCodeLocalCorruption:
+		return errors.CodeCorruption
+	case exitCodeLockConflict:
+		return errors.CodeLockConflict
+	case exitCodeQuotaExceeded:
+		return errors.CodeQuota
+	case exitCodePolicyRejected:
+		return errors.CodePolicy
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,361 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// fetchAllCheckpointFile is the name of the file, relative to the LFS storage
+// directory, which records the references whose objects have all been fetched
+// by an interrupted or failed "git lfs fetch --all".
+const fetchAllCheckpointFile = "fetch-all-checkpoint"
+
+// fetchAllRefBatchSize is the number of references whose history is scanned
+// with a single git-rev-list(1), and which are recorded in the checkpoint
+// together once all of their objects have been fetched.
+const fetchAllRefBatchSize = 100
+
+// fetchAllCheckpoint records which references have been completely fetched,
+// so that an interrupted "git lfs fetch --all" can resume where it left off.
+type fetchAllCheckpoint struct {
+	path string
+	done map[string]string
+
+	mu sync.Mutex
+}
+
+func newFetchAllCheckpoint() *fetchAllCheckpoint {
+	c := &fetchAllCheckpoint{
+		path: filepath.Join(cfg.LFSStorageDir(), fetchAllCheckpointFile),
+		done: make(map[string]string),
+	}
+
+	f, err := os.Open(c.path)
+	if err != nil {
+		return c
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		c.done[parts[1]] = parts[0]
+	}
+	return c
+}
+
+// Contains returns whether the given reference was completely fetched at its
+// current object ID.
+func (c *fetchAllCheckpoint) Contains(ref *git.Ref) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sha, ok := c.done[ref.Refspec()]
+	return ok && sha == ref.Sha
+}
+
+// Shas returns the object IDs of the references which were completely
+// fetched, so that their history need not be scanned again.
+func (c *fetchAllCheckpoint) Shas() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shas := make([]string, 0, len(c.done))
+	for _, sha := range c.done {
+		shas = append(shas, sha)
+	}
+	return shas
+}
+
+// Add records the given references as completely fetched, with a single
+// write to the checkpoint.
+func (c *fetchAllCheckpoint) Add(refs []*git.Ref) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var buf strings.Builder
+	for _, ref := range refs {
+		c.done[ref.Refspec()] = ref.Sha
+		fmt.Fprintf(&buf, "%s %s\n", ref.Sha, ref.Refspec())
+	}
+
+	if err := tools.MkdirAll(filepath.Dir(c.path), cfg); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(buf.String())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Remove discards the checkpoint once all references have been fetched.
+func (c *fetchAllCheckpoint) Remove() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// fetchAllBatch is a batch of references, and the objects they need which
+// have yet to be fetched.
+type fetchAllBatch struct {
+	refs    []*git.Ref
+	pending map[string]bool
+}
+
+// fetchAllTracker follows the transfers of the queue shared by every batch of
+// a "git lfs fetch --all", and calls done for each batch once all of its
+// objects have been fetched.
+type fetchAllTracker struct {
+	fetched map[string]bool
+	batches []*fetchAllBatch
+	done    func(refs []*git.Ref)
+
+	mu sync.Mutex
+}
+
+func newFetchAllTracker(done func(refs []*git.Ref)) *fetchAllTracker {
+	return &fetchAllTracker{
+		fetched: make(map[string]bool),
+		done:    done,
+	}
+}
+
+// Add adds a batch of references, which need the objects with the given
+// object IDs.  It must be called before those objects are added to the queue.
+func (t *fetchAllTracker) Add(refs []*git.Ref, oids []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := &fetchAllBatch{refs: refs, pending: make(map[string]bool, len(oids))}
+	for _, oid := range oids {
+		if !t.fetched[oid] {
+			b.pending[oid] = true
+		}
+	}
+
+	if len(b.pending) == 0 {
+		t.done(b.refs)
+		return
+	}
+	t.batches = append(t.batches, b)
+}
+
+// Fetched records that the object with the given object ID has been fetched.
+func (t *fetchAllTracker) Fetched(oid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.fetched[oid] = true
+
+	remaining := t.batches[:0]
+	for _, b := range t.batches {
+		delete(b.pending, oid)
+		if len(b.pending) == 0 {
+			t.done(b.refs)
+			continue
+		}
+		remaining = append(remaining, b)
+	}
+	t.batches = remaining
+}
+
+// fetchAll fetches all objects referenced by any reference in the repository.
+//
+// References are scanned in batches of fetchAllRefBatchSize. The history of
+// each batch is scanned with a single git-rev-list(1), which leaves out the
+// history of the references already fetched, and its objects are added to a
+// single transfer queue shared by every batch, so that each object is fetched
+// once, and the transfers of one batch overlap with the scan of the next.
+// Once all of the objects of a batch are present locally, the batch is
+// reported and recorded in a checkpoint, so that an interrupted fetch skips
+// those references when it is run again.
+func fetchAll() bool {
+	checkpoint := newFetchAllCheckpoint()
+
+	refs, err := fetchAllRefs()
+	if err != nil {
+		Panic(err, "Could not scan for Git LFS files")
+	}
+
+	var todo []*git.Ref
+	for _, ref := range refs {
+		if checkpoint.Contains(ref) {
+			tracelog.Printf("fetch: skipping %s, already fetched at %s", ref.Refspec(), ref.Sha)
+			continue
+		}
+		todo = append(todo, ref)
+	}
+
+	if skipped := len(refs) - len(todo); skipped > 0 {
+		Print("fetch: Skipping %d reference(s) fetched by a previous run", skipped)
+	}
+
+	Print("fetch: Fetching all references...")
+
+	meter := newFetchMeter()
+	q := newDownloadQueue(
+		getTransferManifestOperationRemote("download", cfg.Remote()),
+		cfg.Remote(), tq.WithProgress(meter), tq.DryRun(fetchDryRunReport != nil),
+	)
+	if fetchDryRunReport != nil {
+		fetchDryRunReport.Watch(q)
+	}
+
+	completed := 0
+	tracker := newFetchAllTracker(func(refs []*git.Ref) {
+		if fetchDryRunReport != nil {
+			// Nothing is fetched, so there is nothing to resume
+			// from.
+			return
+		}
+
+		if err := checkpoint.Add(refs); err != nil {
+			tracelog.Printf("fetch: unable to record checkpoint: %s", err)
+		}
+		for _, ref := range refs {
+			completed++
+			Print("fetch: Fetched reference %s (%d/%d)", ref.Refspec(), completed, len(todo))
+		}
+	})
+
+	watch := q.Watch()
+	tracked := make(chan struct{})
+	go func() {
+		for t := range watch {
+			tracker.Fetched(t.Oid)
+		}
+		close(tracked)
+	}()
+
+	queued := make(map[string]bool)
+	for start := 0; start < len(todo); start += fetchAllRefBatchSize {
+		end := start + fetchAllRefBatchSize
+		if end > len(todo) {
+			end = len(todo)
+		}
+		batch := todo[start:end]
+
+		pointers := scanRefsForFetch(batch, checkpoint.Shas())
+
+		// Objects queued by an earlier batch are still needed by
+		// this one, but are neither counted nor queued again.
+		oids := make([]string, 0, len(pointers))
+		fresh := make([]*lfs.WrappedPointer, 0, len(pointers))
+		for _, p := range pointers {
+			oids = append(oids, p.Oid)
+			if !queued[p.Oid] {
+				fresh = append(fresh, p)
+			}
+		}
+
+		ready, missing := readyAndMissingPointers(fresh, nil, meter)
+		for _, p := range ready {
+			queued[p.Oid] = true
+			tracker.Fetched(p.Oid)
+		}
+		tracker.Add(batch, oids)
+
+		for _, p := range missing {
+			queued[p.Oid] = true
+			tracelog.Printf("fetch %v [%v]", p.Name, p.Oid)
+			q.Add(downloadTransfer(p))
+		}
+	}
+
+	processQueue := time.Now()
+	q.Wait()
+	meter.Finish()
+	tracelog.PerformanceSince("process queue", processQueue)
+	<-tracked
+
+	ok := reportTransferErrors(q.Errors())
+	if fetchDryRunReport != nil {
+		return ok
+	}
+	if ok && completed == len(todo) {
+		if err := checkpoint.Remove(); err != nil {
+			tracelog.Printf("fetch: unable to remove checkpoint: %s", err)
+		}
+		return true
+	}
+
+	Print("fetch: %d of %d reference(s) fetched; run `git lfs fetch --all` again to resume", completed, len(todo))
+	return false
+}
+
+// fetchAllRefs returns every reference in the repository, along with HEAD if
+// it is detached.
+func fetchAllRefs() ([]*git.Ref, error) {
+	refs, err := git.AllRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	if head, err := git.CurrentRef(); err == nil && head.Type == git.RefTypeHEAD {
+		refs = append(refs, head)
+	}
+	return refs, nil
+}
+
+// scanRefsForFetch collects all pointers reachable from the given references,
+// but not from any of the commits in exclude, with a single scan of their
+// history.  Its progress is shown by the meter of the transfer queue, whose
+// totals grow as the objects found are queued.
+func scanRefsForFetch(refs []*git.Ref, exclude []string) []*lfs.WrappedPointer {
+	var pointers []*lfs.WrappedPointer
+	var multiErr error
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		pointers = append(pointers, p)
+	})
+
+	include := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		include = append(include, ref.Sha)
+	}
+
+	if err := gitscanner.ScanRefs(include, exclude, nil); err != nil {
+		Panic(err, "Could not scan for Git LFS files")
+	}
+
+	gitscanner.Close()
+
+	if multiErr != nil {
+		Panic(multiErr, "Could not scan for Git LFS files")
+	}
+	tracelog.Printf("fetch: %d object(s) found in %d reference(s)", len(pointers), len(refs))
+	return pointers
+}
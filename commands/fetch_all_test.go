@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchAllTrackerCompletesBatchesOnceFetched(t *testing.T) {
+	var done []string
+	tracker := newFetchAllTracker(func(refs []*git.Ref) {
+		for _, ref := range refs {
+			done = append(done, ref.Name)
+		}
+	})
+
+	a := []*git.Ref{{Name: "a"}}
+	b := []*git.Ref{{Name: "b"}}
+	c := []*git.Ref{{Name: "c"}}
+
+	tracker.Add(a, []string{"1", "2"})
+	// The second batch shares an object with the first, which is still
+	// being fetched.
+	tracker.Add(b, []string{"2", "3"})
+	assert.Empty(t, done)
+
+	tracker.Fetched("3")
+	assert.Empty(t, done)
+	tracker.Fetched("2")
+	assert.Equal(t, []string{"b"}, done)
+	tracker.Fetched("1")
+	assert.Equal(t, []string{"b", "a"}, done)
+
+	// A batch whose objects have all been fetched is complete at once.
+	tracker.Add(c, []string{"1", "3"})
+	assert.Equal(t, []string{"b", "a", "c"}, done)
+}
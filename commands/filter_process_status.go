@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// filterStatusInterval is how often a running filter-process records its
+// status, if it has changed.
+const filterStatusInterval = time.Second
+
+// The states of a running filter-process.
+const (
+	filterStateIdle     = "idle"
+	filterStateBusy     = "busy"
+	filterStateStopping = "stopping"
+)
+
+// filterProcessStatus is the status of a running filter-process, which it
+// records in a file so that `git lfs filter-process --status` can report it.
+type filterProcessStatus struct {
+	PID        int    `json:"pid"`
+	WorkingDir string `json:"working_dir"`
+	// State is "idle" while waiting for a request from Git, "busy" while
+	// answering one, and "stopping" while finishing its downloads before
+	// exiting.
+	State       string     `json:"state"`
+	Started     time.Time  `json:"started"`
+	LastRequest *time.Time `json:"last_request,omitempty"`
+	Requests    int64      `json:"requests"`
+	// Delayed is the number of files whose smudge was delayed, and which
+	// Git has not yet collected.
+	Delayed int `json:"delayed"`
+	// IdleTimeout is the number of seconds after which the process stops
+	// if Git sends no request, or zero if it waits for as long as Git
+	// runs.
+	IdleTimeout int64 `json:"idle_timeout"`
+}
+
+// filterStatusDir returns the directory in which running filter processes
+// record their status.
+func filterStatusDir() string {
+	return filepath.Join(cfg.LFSStorageDir(), "filter-process")
+}
+
+// filterMonitor keeps the status of the running filter-process, and records it
+// at most once every filterStatusInterval.
+type filterMonitor struct {
+	mu     sync.Mutex
+	status filterProcessStatus
+	dirty  bool
+
+	// writeMu is held while the status is written, so that it is never
+	// replaced by an older one.
+	writeMu sync.Mutex
+	path    string
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newFilterMonitor returns a filterMonitor for this process, and starts
+// recording its status.
+func newFilterMonitor(idleTimeout time.Duration) *filterMonitor {
+	m := &filterMonitor{
+		status: filterProcessStatus{
+			PID:         os.Getpid(),
+			WorkingDir:  cfg.LocalWorkingDir(),
+			State:       filterStateIdle,
+			Started:     time.Now(),
+			IdleTimeout: int64(idleTimeout / time.Second),
+		},
+		dirty: true,
+		path:  filepath.Join(filterStatusDir(), strconv.Itoa(os.Getpid())+".json"),
+		done:  make(chan struct{}),
+	}
+
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+func (m *filterMonitor) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(filterStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		m.record()
+		select {
+		case <-ticker.C:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// update changes the status with fn, which is recorded in due course.
+func (m *filterMonitor) update(fn func(s *filterProcessStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn(&m.status)
+	m.dirty = true
+}
+
+// setState sets the state of the process, which is recorded at once if it is
+// stopping.
+func (m *filterMonitor) setState(state string) {
+	m.update(func(s *filterProcessStatus) {
+		s.State = state
+		if state == filterStateBusy {
+			now := time.Now()
+			s.LastRequest = &now
+			s.Requests++
+		}
+	})
+	if state == filterStateStopping {
+		m.record()
+	}
+}
+
+// record writes the status to its file if it has changed.
+func (m *filterMonitor) record() {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	m.mu.Lock()
+	if !m.dirty {
+		m.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(&m.status)
+	m.dirty = false
+	m.mu.Unlock()
+
+	if err == nil {
+		err = m.write(data)
+	}
+	if err != nil {
+		tracelog.Printf("filter-process: unable to record status: %s", err)
+	}
+}
+
+func (m *filterMonitor) write(data []byte) error {
+	dir := filepath.Dir(m.path)
+	if err := tools.MkdirAll(dir, cfg); err != nil {
+		return err
+	}
+
+	f, err := tools.TempFile(dir, "status", cfg)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return tools.RobustRename(f.Name(), m.path)
+}
+
+// Close stops recording the status, and removes its file.
+func (m *filterMonitor) Close() {
+	close(m.done)
+	m.wg.Wait()
+	os.Remove(m.path)
+}
+
+// runningFilterProcesses returns the status of each running filter-process
+// of this repository, ordered by process ID.  The files of processes which
+// have exited without removing them are removed.
+func runningFilterProcesses() ([]*filterProcessStatus, error) {
+	dir := filterStatusDir()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var statuses []*filterProcessStatus
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		status := &filterProcessStatus{}
+		if err := json.Unmarshal(data, status); err != nil {
+			tracelog.Printf("filter-process: ignoring malformed status %q: %s", path, err)
+			continue
+		}
+
+		if !processRunning(status.PID) {
+			os.Remove(path)
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PID < statuses[j].PID
+	})
+	return statuses, nil
+}
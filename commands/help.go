@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// helpWidth is the width help text is wrapped to.
+const helpWidth = 80
+
+// ManDoc is the structured documentation of a command, which is generated from
+// its man page along with its text in ManPages.
+type ManDoc struct {
+	// Summary is the one-line description of the command.
+	Summary string
+	// Synopsis holds the forms of the command's command line.
+	Synopsis []string
+	// Options holds the command's options, in the order in which they are
+	// documented.
+	Options []ManOption
+}
+
+// ManOption is an option of a command, with the first sentence of its
+// description.
+type ManOption struct {
+	Flags   string
+	Summary string
+}
+
+// printUsage writes the short usage of the given command to w, as for
+// `git lfs <command> -h`: the forms of its command line, its summary and its
+// options.  The usage of the root command lists the commands instead of its
+// options.  It returns false if the command has no documentation.
+func printUsage(w io.Writer, cmd *cobra.Command) bool {
+	name := cmd.Name()
+	doc, ok := ManDocs[name]
+	if !ok {
+		return false
+	}
+
+	for i, synopsis := range doc.Synopsis {
+		prefix := "usage: "
+		if i > 0 {
+			prefix = "   or: "
+		}
+		fmt.Fprintf(w, "%s%s\n", prefix, synopsis)
+	}
+	if len(doc.Summary) > 0 {
+		fmt.Fprintf(w, "\n%s\n", doc.Summary)
+	}
+
+	if !cmd.HasParent() {
+		fmt.Fprintf(w, "\nCommands:\n")
+		for _, sub := range documentedCommands(cmd) {
+			fmt.Fprintf(w, "%s\n", wrapHelp(fmt.Sprintf("   %-16s  ", sub), ManDocs[sub].Summary, strings.Repeat(" ", 21)))
+		}
+		fmt.Fprintf(w, "\nSee 'git lfs help <command>' for more details.\n")
+		return true
+	}
+
+	if len(doc.Options) > 0 {
+		fmt.Fprintf(w, "\nOptions:\n")
+		for _, opt := range doc.Options {
+			fmt.Fprintf(w, "    %s\n", opt.Flags)
+			if len(opt.Summary) > 0 {
+				fmt.Fprintf(w, "%s\n", wrapHelp("        ", opt.Summary, "        "))
+			}
+		}
+	}
+	fmt.Fprintf(w, "\nSee 'git lfs help %s' for more details.\n", name)
+	return true
+}
+
+// documentedCommands returns the sorted names of the subcommands of the given
+// command which have documentation, leaving out hidden commands.
+func documentedCommands(cmd *cobra.Command) []string {
+	var names []string
+	for _, sub := range cmd.Commands() {
+		if _, ok := ManDocs[sub.Name()]; ok && !sub.Hidden {
+			names = append(names, sub.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wrapHelp wraps the given text to helpWidth columns, starting its first line
+// with prefix and its other lines with indent.
+func wrapHelp(prefix, text, indent string) string {
+	var lines []string
+	line := prefix
+	empty := true
+	for _, word := range strings.Fields(text) {
+		if !empty && len(line)+1+len(word) > helpWidth {
+			lines = append(lines, line)
+			line, empty = indent, true
+		}
+		if !empty {
+			line += " "
+		}
+		line += word
+		empty = false
+	}
+	return strings.Join(append(lines, line), "\n")
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintUsageOfCommand(t *testing.T) {
+	ManDocs["test-usage"] = &ManDoc{
+		Summary:  "Test the usage",
+		Synopsis: []string{"git lfs test-usage [options]", "git lfs test-usage --all"},
+		Options: []ManOption{
+			{Flags: "--all -a", Summary: "Test all of the usage, which takes a long description to explain, so that it is wrapped."},
+			{Flags: "--quiet"},
+		},
+	}
+	defer delete(ManDocs, "test-usage")
+
+	root := &cobra.Command{Use: "git-lfs"}
+	cmd := &cobra.Command{Use: "test-usage"}
+	root.AddCommand(cmd)
+
+	var buf bytes.Buffer
+	assert.True(t, printUsage(&buf, cmd))
+	assert.Equal(t, strings.Join([]string{
+		"usage: git lfs test-usage [options]",
+		"   or: git lfs test-usage --all",
+		"",
+		"Test the usage",
+		"",
+		"Options:",
+		"    --all -a",
+		"        Test all of the usage, which takes a long description to explain, so",
+		"        that it is wrapped.",
+		"    --quiet",
+		"",
+		"See 'git lfs help test-usage' for more details.",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestPrintUsageOfRootListsCommands(t *testing.T) {
+	root := &cobra.Command{Use: "git-lfs"}
+	root.AddCommand(
+		&cobra.Command{Use: "track"},
+		&cobra.Command{Use: "env"},
+		&cobra.Command{Use: "undocumented"},
+		&cobra.Command{Use: "pointer", Hidden: true},
+	)
+
+	var buf bytes.Buffer
+	assert.True(t, printUsage(&buf, root))
+	assert.Contains(t, buf.String(), "usage: git lfs <command> [<args>]\n")
+	assert.Contains(t, buf.String(), "Commands:\n   env               "+ManDocs["env"].Summary+"\n   track             "+ManDocs["track"].Summary+"\n\n")
+	assert.NotContains(t, buf.String(), "undocumented")
+	assert.NotContains(t, buf.String(), "pointer")
+}
+
+func TestPrintUsageOfUndocumentedCommand(t *testing.T) {
+	var buf bytes.Buffer
+	assert.False(t, printUsage(&buf, &cobra.Command{Use: "undocumented"}))
+	assert.Empty(t, buf.String())
+}
+
+func TestManDocsHaveSummaries(t *testing.T) {
+	for name, doc := range ManDocs {
+		assert.NotEmpty(t, doc.Summary, name)
+		for _, opt := range doc.Options {
+			assert.NotEmpty(t, opt.Flags, name)
+		}
+	}
+}
+
+func TestWrapHelp(t *testing.T) {
+	assert.Equal(t, "  a b", wrapHelp("  ", "a   b", "    "))
+	assert.Equal(t,
+		"> "+strings.Repeat("x", 40)+"\n    "+strings.Repeat("y", 40),
+		wrapHelp("> ", strings.Repeat("x", 40)+" "+strings.Repeat("y", 40), "    "))
+}
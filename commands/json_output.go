@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/spf13/cobra"
+)
+
+// jsonOutputVersion is the version of the format of the messages written with
+// the global --json flag.  It is incremented whenever a change is made to the
+// format which is not backwards compatible, such as the removal of a field.
+const jsonOutputVersion = 1
+
+var (
+	// jsonOutput is true if the global --json flag was given, in which case
+	// the output of commands is written as JSON messages, one per line,
+	// rather than as text.
+	jsonOutput bool
+
+	// jsonCommand is the name of the command being run, as given in each
+	// JSON message.
+	jsonCommand string
+)
+
+// jsonMessage is a single line of output written with --json.
+type jsonMessage struct {
+	Version int    `json:"version"`
+	Command string `json:"command"`
+	// Type is "event" for output written while the command runs, "result"
+	// for the final result of the command, or "error".
+	Type string `json:"type"`
+	// Event names the kind of an event, which determines the form of its
+	// Data.  Text printed by commands which do not write typed events is
+	// written as "message" events.
+	Event string      `json:"event,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	// Error is the message of an error.
+	Error string `json:"error,omitempty"`
+	// Code is the errors.Code of an error, if it has one, such as
+	// "network" or "auth".
+	Code string `json:"code,omitempty"`
+}
+
+// jsonTextMessage is the data of a "message" event.
+type jsonTextMessage struct {
+	Message string `json:"message"`
+}
+
+// PrintEvent writes an event of the given kind to Stdout with --json.  Without
+// --json it does nothing, and callers print their text output instead.
+func PrintEvent(event string, data interface{}) error {
+	return writeJSONMessage(&jsonMessage{Type: "event", Event: event, Data: data})
+}
+
+// PrintResult writes the final result of a command to Stdout with --json.
+// Without --json it does nothing, and callers print their text output
+// instead.
+func PrintResult(data interface{}) error {
+	return writeJSONMessage(&jsonMessage{Type: "result", Data: data})
+}
+
+// writeJSONMessage writes "msg" to Stdout with --json, returning an error if
+// it cannot be marshaled, in which case nothing is written.
+func writeJSONMessage(msg *jsonMessage) error {
+	if !jsonOutput {
+		return nil
+	}
+
+	msg.Version = jsonOutputVersion
+	msg.Command = jsonCommand
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write output of %s", jsonCommand)
+	}
+	fmt.Fprintln(OutputWriter, string(data))
+	return nil
+}
+
+// formatMessage formats a message given to Print or Error.
+func formatMessage(format string, args ...interface{}) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// setupJSONOutput registers the global --json flag on the root command.
+func setupJSONOutput(root *cobra.Command) {
+	root.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Write output as JSON messages")
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/locking"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// lockExpiryWarning returns how long before one of our locks expires Git LFS
+// starts warning about it, as configured by "lfs.lockexpirywarning" in
+// seconds.
+func lockExpiryWarning() time.Duration {
+	return time.Duration(cfg.Git.Int("lfs.lockexpirywarning", 900)) * time.Second
+}
+
+// describeLockExpiry returns a description of the time left on the lease of
+// the given lock at the given time, or the empty string if it doesn't expire.
+func describeLockExpiry(lock locking.Lock, now time.Time) string {
+	left, ok := lock.ExpiresIn(now)
+	if !ok {
+		return ""
+	}
+	if left <= 0 {
+		return "expired"
+	}
+	return fmt.Sprintf("expires in %s", formatLockDuration(left))
+}
+
+// formatLockDuration formats d to the nearest minute, or second if it is less
+// than a minute, as "1h5m", "45m" or "30s".
+func formatLockDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Round(time.Second)/time.Second))
+	}
+
+	d = d.Round(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+// ownLocks returns the set of the given locks which are our own, being those
+// in the local lock cache. If all is true, the locks all came from that cache.
+func ownLocks(lockClient *locking.Client, locks []locking.Lock, all bool) map[locking.Lock]bool {
+	owned := make(map[locking.Lock]bool, len(locks))
+	if all {
+		for _, lock := range locks {
+			owned[lock] = true
+		}
+		return owned
+	}
+
+	cached, err := lockClient.SearchLocks(nil, 0, true, false)
+	if err != nil {
+		tracelog.Printf("locks: unable to read the local lock cache: %v", err)
+		return owned
+	}
+
+	ids := make(map[string]bool, len(cached))
+	for _, lock := range cached {
+		ids[lock.Id] = true
+	}
+	for _, lock := range locks {
+		if ids[lock.Id] {
+			owned[lock] = true
+		}
+	}
+	return owned
+}
+
+// warnExpiringLocks warns about those of the given locks which are our own
+// and which have expired or will within lfs.lockexpirywarning.
+func warnExpiringLocks(locks []locking.Lock, owned map[locking.Lock]bool) {
+	now := time.Now()
+	warning := lockExpiryWarning()
+
+	var expiring int
+	for _, lock := range locks {
+		left, ok := lock.ExpiresIn(now)
+		if !ok || !owned[lock] || left > warning {
+			continue
+		}
+
+		expiring++
+		if left <= 0 {
+			Error("Warning: your lock on %s has expired", lock.Path)
+		} else {
+			Error("Warning: your lock on %s expires in %s", lock.Path, formatLockDuration(left))
+		}
+	}
+
+	if expiring > 0 {
+		Error("Run `git lfs locks --renew` to renew locks on files you are changing.")
+	}
+}
+
+// renewLocks renews the leases of our own expiring locks on files which have
+// changes not yet pushed, either in the working tree or in local commits. If
+// every is non-zero, it does so repeatedly at that interval until no such
+// locks remain, so that locks don't lapse during long edits.
+func renewLocks(lockClient *locking.Client, update *git.RefUpdate, every time.Duration) {
+	for {
+		locks := renewableLocks(lockClient, update)
+		if len(locks) == 0 {
+			Print("No locks to renew")
+			return
+		}
+
+		var renewed int
+		for _, lock := range locks {
+			renewal, err := lockClient.RenewLock(lock.Id)
+			if errors.IsNotImplementedError(err) {
+				Exit("Lock renewal is not supported by the server")
+			}
+			if err != nil {
+				Error("Unable to renew lock on %s: %v", lock.Path, errors.Cause(err))
+				continue
+			}
+
+			renewed++
+			Print("Renewed lock on %s, which %s", lock.Path, describeLockExpiry(renewal, time.Now()))
+		}
+
+		if every == 0 {
+			if renewed < len(locks) {
+				Exit("Renewed %d of %d locks; %d failed.", renewed, len(locks), len(locks)-renewed)
+			}
+			return
+		}
+		time.Sleep(every)
+	}
+}
+
+// renewableLocks returns our own cached locks which expire and whose files have
+// changes not yet pushed.
+func renewableLocks(lockClient *locking.Client, update *git.RefUpdate) []locking.Lock {
+	cached, err := lockClient.SearchLocks(nil, 0, true, false)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, "unable to read the local lock cache"))
+	}
+
+	changed := pendingPushPaths(update)
+	modified, err := git.ModifiedPaths("HEAD")
+	if err != nil {
+		tracelog.Printf("locks: unable to find modified files: %v", err)
+	}
+	for _, path := range modified {
+		changed[path] = true
+	}
+
+	var locks []locking.Lock
+	for _, lock := range cached {
+		if lock.ExpiresAt != nil && changed[lock.Path] {
+			locks = append(locks, lock)
+		}
+	}
+	return locks
+}
@@ -108,7 +108,7 @@ func (lv *lockVerifier) Contains(name string) bool {
 
 func (lv *lockVerifier) LockedByThem(name string) bool {
 	if lock, ok := lv.theirLocks[name]; ok {
-		lv.unownedLocks = append(lv.unownedLocks, lock)
+		lv.unownedLocks = appendRefLock(lv.unownedLocks, lock)
 		return true
 	}
 	return false
@@ -116,12 +116,28 @@ func (lv *lockVerifier) LockedByThem(name string) bool {
 
 func (lv *lockVerifier) LockedByUs(name string) bool {
 	if lock, ok := lv.ourLocks[name]; ok {
-		lv.ownedLocks = append(lv.ownedLocks, lock)
+		lv.ownedLocks = appendRefLock(lv.ownedLocks, lock)
 		return true
 	}
 	return false
 }
 
+// appendRefLock appends the given lock to the list, unless it is already there
+// because the locked file was changed more than once.
+func appendRefLock(locks []*refLock, lock *refLock) []*refLock {
+	for _, l := range locks {
+		if l == lock {
+			return locks
+		}
+	}
+	return append(locks, lock)
+}
+
+// HasLocks returns whether any files are locked on the verified refs.
+func (lv *lockVerifier) HasLocks() bool {
+	return len(lv.ourLocks) > 0 || len(lv.theirLocks) > 0
+}
+
 func (lv *lockVerifier) UnownedLocks() []*refLock {
 	return lv.unownedLocks
 }
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"io"
+	"os"
+
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tracelog"
+	isatty "github.com/mattn/go-isatty"
+)
+
+// pagerCommand returns the command to page long output through, chosen as Git
+// does from GIT_PAGER, core.pager and PAGER, defaulting to less.  It returns
+// the empty string if standard output is not a terminal, or if paging is
+// disabled by choosing an empty pager or cat.
+func pagerCommand() string {
+	fd := os.Stdout.Fd()
+	if !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd) {
+		return ""
+	}
+
+	pager, ok := cfg.Os.Get("GIT_PAGER")
+	if !ok {
+		pager, ok = cfg.Git.Get("core.pager")
+	}
+	if !ok {
+		pager, ok = cfg.Os.Get("PAGER")
+	}
+	if !ok {
+		pager = "less"
+	}
+
+	if pager == "cat" {
+		return ""
+	}
+	return pager
+}
+
+// withPager calls fn with a writer connected to the pager given by
+// pagerCommand, and waits for the pager to exit.  If there is no pager, or it
+// cannot be started, fn writes to standard output instead.
+func withPager(fn func(w io.Writer)) {
+	pager := pagerCommand()
+	if len(pager) == 0 {
+		fn(os.Stdout)
+		return
+	}
+
+	name, args := subprocess.FormatForShell(pager, "")
+	cmd := subprocess.ExecCommand(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// As Git does, have less quit if the output fits on one screen, and
+	// show colors, unless the user has chosen otherwise.
+	for key, value := range map[string]string{"LESS": "FRX", "LV": "-c"} {
+		if _, ok := cfg.Os.Get(key); !ok {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+
+	w, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		tracelog.Printf("Error starting pager %q: %s", pager, err)
+		fn(os.Stdout)
+		return
+	}
+
+	fn(w)
+	w.Close()
+	cmd.Wait()
+}
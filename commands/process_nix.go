@@ -0,0 +1,11 @@
+// +build !windows
+
+package commands
+
+import "syscall"
+
+// processRunning returns true if a process with the given ID is running.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package commands
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code of a process which has not exited.
+const stillActive = 259
+
+// processRunning returns true if a process with the given ID is running.
+func processRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// A process we may not query is running, but one which has
+		// exited cannot be found.
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/tr"
+)
+
+// promptYesNo asks the user the given question on the controlling terminal and
+// returns whether they answered affirmatively, in English or in their own
+// language. Since the standard streams of a filter are connected to Git, the
+// terminal is opened directly. If there is no terminal to prompt on, or Git LFS
+// is running non-interactively, promptYesNo returns false.
+func promptYesNo(format string, args ...interface{}) bool {
+	question := fmt.Sprintf(tr.Tr.Get(format), args...)
+	if cfg.NonInteractive() {
+		Error("Git LFS: not prompting, as %s is set: %s [assuming no]",
+			config.NonInteractiveEnv, question)
+		return false
+	}
+
+	in, out := "/dev/tty", "/dev/tty"
+	if runtime.GOOS == "windows" {
+		in, out = "CONIN$", "CONOUT$"
+	}
+
+	r, err := os.Open(in)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(out, os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	defer w.Close()
+
+	fmt.Fprintf(w, "%s %s ", question, tr.Tr.Get("[y/N]"))
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && len(answer) == 0 {
+		return false
+	}
+
+	switch a := strings.ToLower(strings.TrimSpace(answer)); a {
+	case "y", "yes":
+		return true
+	default:
+		// Accept the answers of the user's language, too.
+		return a == tr.Tr.Get("y") || a == tr.Tr.Get("yes")
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tq"
 )
 
@@ -36,14 +38,20 @@ func newSingleCheckout(gitEnv config.Environment, remote string) abstractCheckou
 		gitIndexer:    &gitIndexer{},
 		pathConverter: pathConverter,
 		manifest:      manifest,
+		workers:       cfg.CheckoutWorkers(),
 	}
 }
 
 type abstractCheckout interface {
 	Manifest() *tq.Manifest
 	Skip() bool
+	// Run checks out the given pointer, possibly once Run has returned;
+	// Close waits for every file to be checked out.
 	Run(*lfs.WrappedPointer)
 	RunToPath(*lfs.WrappedPointer, string) error
+	// OnFinish sets a function which is called, from any goroutine, once
+	// each pointer given to Run has been handled.
+	OnFinish(func(*lfs.WrappedPointer))
 	Close()
 }
 
@@ -51,6 +59,19 @@ type singleCheckout struct {
 	gitIndexer    *gitIndexer
 	pathConverter lfs.PathConverter
 	manifest      *tq.Manifest
+	onFinish      func(*lfs.WrappedPointer)
+
+	// workers is the number of files checked out at once.  If it is
+	// more than one, pointers given to Run are queued for as many
+	// goroutines, which are started by the first call.
+	workers   int
+	queue     chan *lfs.WrappedPointer
+	startOnce sync.Once
+	wg        sync.WaitGroup
+
+	// inUse counts the files which were not checked out because other
+	// processes held them open.
+	inUse uint32
 }
 
 func (c *singleCheckout) Manifest() *tq.Manifest {
@@ -61,7 +82,36 @@ func (c *singleCheckout) Skip() bool {
 	return false
 }
 
+func (c *singleCheckout) OnFinish(fn func(*lfs.WrappedPointer)) {
+	c.onFinish = fn
+}
+
 func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
+	if c.workers <= 1 {
+		c.run(p)
+		return
+	}
+
+	c.startOnce.Do(func() {
+		c.queue = make(chan *lfs.WrappedPointer, c.workers)
+		c.wg.Add(c.workers)
+		for i := 0; i < c.workers; i++ {
+			go func() {
+				defer c.wg.Done()
+				for p := range c.queue {
+					c.run(p)
+				}
+			}()
+		}
+	})
+	c.queue <- p
+}
+
+func (c *singleCheckout) run(p *lfs.WrappedPointer) {
+	if c.onFinish != nil {
+		defer c.onFinish(p)
+	}
+
 	cwdfilepath := c.pathConverter.Convert(p.Name)
 
 	// Check the content - either missing or still this pointer (not exist is ok)
@@ -86,6 +136,11 @@ func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 		if errors.IsDownloadDeclinedError(err) {
 			// acceptable error, data not local (fetch not run or include/exclude)
 			Error("Skipped checkout for %q, content not local. Use fetch to download.", p.Name)
+		} else if tools.IsFileInUseError(err) {
+			// Leave the file for a later checkout, and carry on
+			// with the others.
+			atomic.AddUint32(&c.inUse, 1)
+			Error("Skipped checkout for %q: %s", p.Name, errors.Cause(err))
 		} else {
 			FullError(fmt.Errorf("could not check out %q", p.Name))
 		}
@@ -106,9 +161,18 @@ func (c *singleCheckout) RunToPath(p *lfs.WrappedPointer, path string) error {
 }
 
 func (c *singleCheckout) Close() {
+	if c.queue != nil {
+		close(c.queue)
+		c.wg.Wait()
+	}
+
 	if err := c.gitIndexer.Close(); err != nil {
 		LoggedError(err, "Error updating the git index:\n%s", c.gitIndexer.Output())
 	}
+
+	if n := atomic.LoadUint32(&c.inUse); n > 0 {
+		Error("%d file(s) in use by other programs were not checked out.  Close those programs and run `git lfs checkout` to check the files out.", n)
+	}
 }
 
 type noOpCheckout struct {
@@ -127,8 +191,9 @@ func (c *noOpCheckout) RunToPath(p *lfs.WrappedPointer, path string) error {
 	return nil
 }
 
-func (c *noOpCheckout) Run(p *lfs.WrappedPointer) {}
-func (c *noOpCheckout) Close()                    {}
+func (c *noOpCheckout) Run(p *lfs.WrappedPointer)             {}
+func (c *noOpCheckout) OnFinish(fn func(*lfs.WrappedPointer)) {}
+func (c *noOpCheckout) Close()                                {}
 
 // Don't fire up the update-index command until we have at least one file to
 // give it. Otherwise git interprets the lack of arguments to mean param-less update-index
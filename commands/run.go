@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -10,7 +11,11 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/metrics"
+	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +23,8 @@ var (
 	commandFuncs []func() *cobra.Command
 	commandMu    sync.Mutex
 
-	rootVersion bool
+	rootVersion        bool
+	rootNonInteractive bool
 )
 
 // NewCommand creates a new 'git-lfs' sub command, given a command name and
@@ -57,6 +63,10 @@ func RegisterCommand(name string, runFn func(cmd *cobra.Command, args []string),
 func Run() int {
 	log.SetOutput(ErrorWriter)
 
+	// Hold trace messages until lfs.logformat has been read, so they are
+	// all written in the same format.
+	tracelog.Hold()
+
 	root := NewCommand("git-lfs", gitlfsCommand)
 	root.PreRun = nil
 
@@ -79,7 +89,7 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 				c.Printf("Unknown help topic %#q\n", args)
 				c.Root().Usage()
 			} else {
-				c.HelpFunc()(cmd, args)
+				helpCommand(cmd, args)
 			}
 		},
 	}
@@ -87,10 +97,25 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 	root.SetHelpCommand(helpcmd)
 
 	root.SetHelpTemplate("{{.UsageString}}")
-	root.SetHelpFunc(helpCommand)
+	root.SetHelpFunc(flagHelpCommand)
 	root.SetUsageFunc(usageCommand)
 
 	root.Flags().BoolVarP(&rootVersion, "version", "v", false, "")
+	setupJSONOutput(root)
+	root.PersistentFlags().BoolVar(&rootNonInteractive, "noninteractive", false, "Never prompt for credentials or confirmation")
+	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		jsonCommand = cmd.Name()
+		setupLocale()
+		setupTraceLog(cmd.Name())
+		setupMetrics(cmd.Name())
+		if rootNonInteractive {
+			// Set the environment variable, rather than only the
+			// configuration, so that the Git LFS filters run by
+			// Git are non-interactive too.
+			os.Setenv(config.NonInteractiveEnv, "1")
+			subprocess.ResetEnvironment()
+		}
+	}
 
 	canonicalizeEnvironment()
 
@@ -104,6 +129,13 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 
 	err := root.Execute()
 	closeAPIClient()
+	metrics.Flush()
+
+	if len(jsonCommand) == 0 {
+		// Release any trace messages held because no command was
+		// run.
+		setupTraceLog("")
+	}
 
 	if err != nil {
 		return 127
@@ -118,6 +150,8 @@ func gitlfsCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// helpCommand prints the full documentation of the command named by args, or
+// of Git LFS itself, for `git lfs help`.
 func helpCommand(cmd *cobra.Command, args []string) {
 	if len(args) == 0 {
 		printHelp("git-lfs")
@@ -126,20 +160,96 @@ func helpCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// flagHelpCommand prints the short usage of the given command, when it is run
+// with -h or --help.
+func flagHelpCommand(cmd *cobra.Command, args []string) {
+	usageCommand(cmd)
+}
+
+// usageCommand prints the short usage of the given command, falling back on
+// its full documentation if it has no structured documentation.
 func usageCommand(cmd *cobra.Command) error {
-	printHelp(cmd.Name())
+	if !printUsage(os.Stdout, cmd) {
+		printHelp(cmd.Name())
+	}
 	return nil
 }
 
+// printHelp prints the full documentation of the named command, through the
+// user's pager if standard output is a terminal.
 func printHelp(commandName string) {
 	if commandName == "--help" {
 		commandName = "git-lfs"
 	}
-	if txt, ok := ManPages[commandName]; ok {
-		fmt.Fprintf(os.Stdout, "%s\n", strings.TrimSpace(txt))
-	} else {
+	txt, ok := ManPages[commandName]
+	if !ok {
 		fmt.Fprintf(os.Stdout, "Sorry, no usage text found for %q\n", commandName)
+		return
 	}
+
+	withPager(func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n", strings.TrimSpace(txt))
+	})
+}
+
+// setupLocale translates messages into the language given by lfs.locale, if it
+// is set, rather than that of the locale chosen by the environment.  Like
+// lfs.logformat, the setting is read on its own from the Git configuration,
+// but not from .lfsconfig.
+func setupLocale() {
+	tr.SetLocale(cfg.GitConfig().Find("lfs.locale"))
+}
+
+// setupTraceLog writes trace messages in the format given by lfs.logformat,
+// naming the given command in JSON records.  The setting is read on its own,
+// rather than with the rest of the configuration, which commands read when
+// they are ready to, and only when tracing is enabled.
+func setupTraceLog(name string) {
+	format := tracelog.TextFormat
+	if tracelog.Enabled() {
+		format = logFormat()
+	}
+
+	tracelog.Configure(format, name)
+	if format == tracelog.JSONFormat {
+		// Pass the correlation id on to the Git LFS processes run by
+		// the Git commands we run.
+		subprocess.ResetEnvironment()
+	}
+}
+
+// logFormat returns the format given by lfs.logformat, which is read from the
+// Git configuration, but not from .lfsconfig.
+func logFormat() tracelog.Format {
+	format, _ := tracelog.ParseFormat(cfg.GitConfig().Find("lfs.logformat"))
+	return format
+}
+
+// setupMetrics sends metrics to the exporters enabled by the lfs.metrics.*
+// settings, tagging them with the given command.  The settings are read when
+// the first metric is emitted.
+func setupMetrics(name string) {
+	metrics.Setup(func() []metrics.Exporter {
+		var exporters []metrics.Exporter
+
+		prefix, ok := cfg.Git.Get("lfs.metrics.prefix")
+		if !ok {
+			prefix = "git_lfs"
+		}
+		tags := metrics.Tags{"command": name}
+
+		if addr, ok := cfg.Git.Get("lfs.metrics.statsd"); ok && len(addr) > 0 {
+			if e, err := metrics.NewStatsdExporter(addr, prefix, tags); err != nil {
+				tracelog.Printf("metrics: unable to send to statsd at %s: %s", addr, err)
+			} else {
+				exporters = append(exporters, e)
+			}
+		}
+		if url, ok := cfg.Git.Get("lfs.metrics.otlp"); ok && len(url) > 0 {
+			exporters = append(exporters, metrics.NewOTLPExporter(url, prefix, tags))
+		}
+		return exporters
+	})
 }
 
 func setupHTTPLogger(cmd *cobra.Command, args []string) {
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// serverLimitsFile is the name of the file, relative to the LFS storage
+// directory, which records the most recent quota and rate limits advertised by
+// each server, so that they can be shown by "git lfs env".
+const serverLimitsFile = "server-limits.json"
+
+// serverLimits are the limits advertised by a single host, along with the time
+// at which they were seen.
+type serverLimits struct {
+	Updated time.Time        `json:"updated"`
+	Limits  []*lfshttp.Limit `json:"limits"`
+}
+
+// loadServerLimits returns the recorded limits of each host, keyed by host.
+func loadServerLimits() map[string]*serverLimits {
+	limits := make(map[string]*serverLimits)
+	if !cfg.InRepo() {
+		return limits
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(cfg.LFSStorageDir(), serverLimitsFile))
+	if err != nil {
+		return limits
+	}
+
+	if err := json.Unmarshal(data, &limits); err != nil {
+		tracelog.Printf("unable to parse %s: %s", serverLimitsFile, err)
+	}
+	return limits
+}
+
+// saveServerLimits records the given limits, replacing any previously
+// recorded for the same hosts.
+func saveServerLimits(seen map[string][]*lfshttp.Limit) {
+	if len(seen) == 0 || !cfg.InRepo() {
+		return
+	}
+
+	limits := loadServerLimits()
+	now := time.Now().UTC()
+	for host, l := range seen {
+		limits[host] = &serverLimits{Updated: now, Limits: l}
+	}
+
+	data, err := json.Marshal(limits)
+	if err != nil {
+		tracelog.Printf("unable to encode %s: %s", serverLimitsFile, err)
+		return
+	}
+
+	dir := cfg.LFSStorageDir()
+	if err := tools.MkdirAll(dir, cfg); err != nil {
+		tracelog.Printf("unable to create %s: %s", dir, err)
+		return
+	}
+
+	path := filepath.Join(dir, serverLimitsFile)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		tracelog.Printf("unable to write %s: %s", path, err)
+		os.Remove(path)
+	}
+}
+
+// sortedServerLimitHosts returns the hosts of the given limits in order.
+func sortedServerLimitHosts(limits map[string]*serverLimits) []string {
+	hosts := make([]string, 0, len(limits))
+	for host := range limits {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
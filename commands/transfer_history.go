@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+const (
+	// transferHistoryFile is the name of the file, relative to the LFS
+	// storage directory, which records a summary of each run of transfers,
+	// so that they can be shown by "git lfs stats".  It holds one JSON
+	// object per line.
+	transferHistoryFile = "transfer-history.log"
+
+	// transferHistoryMaxSize is the size beyond which the history file is
+	// moved aside, replacing any older history, before it is written to.
+	transferHistoryMaxSize = 1024 * 1024
+)
+
+// transferHistoryMu serializes the writes of this process to the history.
+var transferHistoryMu sync.Mutex
+
+// transferHistoryEntry is the summary of a single run of transfers, as recorded
+// in the history.
+type transferHistoryEntry struct {
+	// Command is the name of the command which made the transfers.
+	Command string `json:"command"`
+	*tq.TransferStats
+}
+
+// recordTransferStats appends the given statistics of a finished transfer
+// queue to the history, unless it did nothing, or the history is disabled with
+// lfs.transferhistory.  It is given to each tq.Manifest.
+func recordTransferStats(stats *tq.TransferStats) {
+	if stats.Objects == 0 && stats.Errors == 0 {
+		return
+	}
+	if !cfg.InRepo() || !cfg.Git.Bool("lfs.transferhistory", true) {
+		return
+	}
+
+	data, err := json.Marshal(&transferHistoryEntry{
+		Command:       jsonCommand,
+		TransferStats: stats,
+	})
+	if err != nil {
+		tracelog.Printf("unable to encode %s: %s", transferHistoryFile, err)
+		return
+	}
+
+	transferHistoryMu.Lock()
+	defer transferHistoryMu.Unlock()
+
+	dir := cfg.LFSStorageDir()
+	if err := tools.MkdirAll(dir, cfg); err != nil {
+		tracelog.Printf("unable to create %s: %s", dir, err)
+		return
+	}
+
+	path := filepath.Join(dir, transferHistoryFile)
+	if fi, err := os.Stat(path); err == nil && fi.Size() > transferHistoryMaxSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			tracelog.Printf("unable to rotate %s: %s", path, err)
+		}
+	}
+
+	// Each entry is written with a single call in append mode, so that
+	// the entries of processes running at the same time are not mixed.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		tracelog.Printf("unable to open %s: %s", path, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		tracelog.Printf("unable to write %s: %s", path, err)
+	}
+}
+
+// loadTransferHistory returns the recorded summaries of runs of transfers,
+// oldest first.  Lines which cannot be parsed are skipped.
+func loadTransferHistory() []*transferHistoryEntry {
+	var entries []*transferHistoryEntry
+	if !cfg.InRepo() {
+		return entries
+	}
+
+	path := filepath.Join(cfg.LFSStorageDir(), transferHistoryFile)
+	for _, p := range []string{path + ".1", path} {
+		f, err := os.Open(p)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			entry := &transferHistoryEntry{}
+			if err := json.Unmarshal(scanner.Bytes(), entry); err != nil || entry.TransferStats == nil {
+				tracelog.Printf("unable to parse %s: %s", p, err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		f.Close()
+	}
+	return entries
+}
@@ -11,12 +11,13 @@ import (
 	"sync"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 func uploadForRefUpdates(ctx *uploadContext, updates []*git.RefUpdate, pushAll bool) error {
@@ -31,6 +32,9 @@ func uploadForRefUpdates(ctx *uploadContext, updates []*git.RefUpdate, pushAll b
 	}()
 
 	verifyLocksForUpdates(ctx.lockVerifier, updates)
+	for _, update := range updates {
+		ctx.checkDeletedLockables(update)
+	}
 	rightSides := make([]string, 0, len(updates))
 	for _, update := range updates {
 		right := update.Right().Sha
@@ -38,13 +42,23 @@ func uploadForRefUpdates(ctx *uploadContext, updates []*git.RefUpdate, pushAll b
 			rightSides = append(rightSides, right)
 		}
 	}
+	// When pushing all refs, each ref's history is scanned only as far as
+	// the refs scanned before it, so that history shared between refs is
+	// read only once.
+	scanned := make([]string, 0, len(updates))
 	for _, update := range updates {
+		bases := rightSides
+		if pushAll {
+			bases = scanned
+		}
+
 		// initialized here to prevent looped defer
 		q := ctx.NewQueue(
 			tq.RemoteRef(update.Right()),
 		)
-		err := uploadLeftOrAll(gitscanner, ctx, q, rightSides, update, pushAll)
+		err := uploadLeftOrAll(gitscanner, ctx, q, bases, update, pushAll)
 		ctx.CollectErrors(q)
+		scanned = append(scanned, update.LeftCommitish())
 
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("ref %s:", update.Left().Name))
@@ -57,7 +71,7 @@ func uploadForRefUpdates(ctx *uploadContext, updates []*git.RefUpdate, pushAll b
 func uploadLeftOrAll(g *lfs.GitScanner, ctx *uploadContext, q *tq.TransferQueue, bases []string, update *git.RefUpdate, pushAll bool) error {
 	cb := ctx.gitScannerCallback(q)
 	if pushAll {
-		if err := g.ScanRefWithDeleted(update.LeftCommitish(), cb); err != nil {
+		if err := g.ScanRefs([]string{update.LeftCommitish()}, bases, cb); err != nil {
 			return err
 		}
 	} else {
@@ -83,11 +97,20 @@ type uploadContext struct {
 	logger *tasklog.Logger
 	meter  *tq.Meter
 
+	// dryRunReport lists the objects which would be uploaded, if DryRun
+	// is set.
+	dryRunReport *dryRunReport
+
 	committerName  string
 	committerEmail string
 
 	lockVerifier *lockVerifier
 
+	// preparing runs uploadTransfer for several objects at once, since
+	// it may hash an object again from the working tree or decompress
+	// it.
+	preparing *tools.WorkerPool
+
 	// allowMissing specifies whether pushes containing missing/corrupt
 	// pointers should allow pushing Git blobs
 	allowMissing bool
@@ -112,6 +135,7 @@ func newUploadContext(dryRun bool) *uploadContext {
 		uploadedOids: tools.NewStringSet(),
 		gitfilter:    lfs.NewGitFilter(cfg),
 		lockVerifier: newLockVerifier(manifest),
+		preparing:    tools.NewWorkerPool(cfg.HashWorkers()),
 		allowMissing: cfg.Git.Bool("lfs.allowincompletepush", false),
 		missing:      make(map[string]string),
 		corrupt:      make(map[string]string),
@@ -121,6 +145,7 @@ func newUploadContext(dryRun bool) *uploadContext {
 	var sink io.Writer = os.Stdout
 	if dryRun {
 		sink = ioutil.Discard
+		ctx.dryRunReport = newDryRunReport("push", tq.Upload)
 	}
 
 	ctx.logger = tasklog.NewLogger(sink,
@@ -133,10 +158,14 @@ func newUploadContext(dryRun bool) *uploadContext {
 }
 
 func (c *uploadContext) NewQueue(options ...tq.Option) *tq.TransferQueue {
-	return tq.NewTransferQueue(tq.Upload, c.Manifest, c.Remote, append(options,
+	q := tq.NewTransferQueue(tq.Upload, c.Manifest, c.Remote, append(options,
 		tq.DryRun(c.DryRun),
 		tq.WithProgress(c.meter),
 	)...)
+	if c.dryRunReport != nil {
+		c.dryRunReport.Watch(q)
+	}
+	return q
 }
 
 func (c *uploadContext) scannerError() error {
@@ -164,6 +193,35 @@ func (c *uploadContext) buildGitScanner() (*lfs.GitScanner, error) {
 	return gitscanner, gitscanner.RemoteForPush(c.Remote)
 }
 
+// checkDeletedLockables checks the files which the given update deletes against
+// the verified locks, since the scanner only finds the files which are added
+// or modified, and deleting a file locked by another user is as much a change
+// to it as modifying it.
+func (c *uploadContext) checkDeletedLockables(update *git.RefUpdate) {
+	if !c.lockVerifier.HasLocks() {
+		return
+	}
+
+	// A new branch deletes nothing on the remote.
+	right := update.Right().Sha
+	if len(right) == 0 || git.IsZeroObjectID(right) {
+		return
+	}
+
+	paths, err := git.DeletedPaths(right, update.LeftCommitish())
+	if err != nil {
+		// The remote ref may point to a commit which has not been
+		// fetched, in which case the push will be rejected anyway.
+		tracelog.Printf("commands: unable to check deleted files for locks: %v", err)
+		return
+	}
+
+	for _, path := range paths {
+		c.lockVerifier.LockedByThem(path)
+		c.lockVerifier.LockedByUs(path)
+	}
+}
+
 func (c *uploadContext) gitScannerCallback(tqueue *tq.TransferQueue) func(*lfs.WrappedPointer, error) {
 	return func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
@@ -238,32 +296,23 @@ func (c *uploadContext) prepareUpload(unfiltered ...*lfs.WrappedPointer) []*lfs.
 }
 
 func (c *uploadContext) UploadPointers(q *tq.TransferQueue, unfiltered ...*lfs.WrappedPointer) {
-	if c.DryRun {
-		for _, p := range unfiltered {
-			if c.HasUploaded(p.Oid) {
-				continue
-			}
-
-			Print("push %s => %s", p.Oid, p.Name)
-			c.SetUploaded(p.Oid)
-		}
-
-		return
-	}
-
 	pointers := c.prepareUpload(unfiltered...)
 	for _, p := range pointers {
-		t, err := c.uploadTransfer(p)
-		if err != nil && !errors.IsCleanPointerError(err) {
-			ExitWithError(err)
-		}
-
-		q.Add(t.Name, t.Path, t.Oid, t.Size, t.Missing, nil)
+		p := p
 		c.SetUploaded(p.Oid)
+		c.preparing.Go(func() {
+			t, err := c.uploadTransfer(p)
+			if err != nil && !errors.IsCleanPointerError(err) {
+				ExitWithError(err)
+			}
+
+			q.AddTransfer(t)
+		})
 	}
 }
 
 func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
+	c.preparing.Wait()
 	tqueue.Wait()
 
 	for _, err := range tqueue.Errors() {
@@ -281,6 +330,9 @@ func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
 
 func (c *uploadContext) ReportErrors() {
 	c.meter.Finish()
+	if c.dryRunReport != nil {
+		c.dryRunReport.Finish()
+	}
 
 	for _, err := range c.otherErrs {
 		FullError(err)
@@ -308,12 +360,12 @@ func (c *uploadContext) ReportErrors() {
 				"hint: You can disable this check with: 'git config lfs.allowincompletepush true'",
 			}
 			Print(strings.Join(pushMissingHint, "\n"))
-			os.Exit(2)
+			exit(exitCodeLocalCorruption)
 		}
 	}
 
 	if len(c.otherErrs) > 0 {
-		os.Exit(2)
+		exit(exitCodeForTransferErrors(c.otherErrs))
 	}
 
 	if c.lockVerifier.HasUnownedLocks() {
@@ -367,22 +419,35 @@ func (c *uploadContext) uploadTransfer(p *lfs.WrappedPointer) (*tq.Transfer, err
 	}
 
 	return &tq.Transfer{
-		Name:    filename,
-		Path:    localMediaPath,
-		Oid:     oid,
-		Size:    p.Size,
-		Missing: missing,
+		Name:        filename,
+		Path:        localMediaPath,
+		Oid:         oid,
+		Size:        p.Size,
+		Missing:     missing,
+		ContentType: p.Metadata[lfs.MetaContentType],
 	}, nil
 }
 
 // ensureFile makes sure that the cleanPath exists before pushing it.  If it
-// does not exist, it attempts to clean it by reading the file at smudgePath.
+// does not exist, it attempts to clean it by reading the file at smudgePath,
+// unless this is a dry run.
 func (c *uploadContext) ensureFile(smudgePath, cleanPath, oid string) (bool, error) {
-	if _, err := os.Stat(cleanPath); err == nil {
+	if _, _, err := fs.ObjectFileSize(cleanPath); err == nil {
 		return false, nil
 	}
 
 	localPath := filepath.Join(cfg.LocalWorkingDir(), smudgePath)
+	if c.DryRun {
+		// A dry run does no cleaning, and only reports whether there
+		// is a file from which the object would be cleaned, whether or
+		// not missing objects are allowed, as nothing is sent to the
+		// server.
+		if _, err := os.Stat(localPath); err != nil {
+			return true, nil
+		}
+		return false, nil
+	}
+
 	file, err := os.Open(localPath)
 	if err != nil {
 		return !c.allowMissing, nil
@@ -412,7 +477,7 @@ func (c *uploadContext) ensureFile(smudgePath, cleanPath, oid string) (bool, err
 func supportsLockingAPI(rawurl string) bool {
 	u, err := url.Parse(rawurl)
 	if err != nil {
-		tracerx.Printf("commands: unable to parse %q to determine locking support: %v", rawurl, err)
+		tracelog.Printf("commands: unable to parse %q to determine locking support: %v", rawurl, err)
 		return false
 	}
 
@@ -429,7 +494,7 @@ func supportsLockingAPI(rawurl string) bool {
 // disableFor disables lock verification for the given lfsapi.Endpoint,
 // "endpoint".
 func disableFor(rawurl string) error {
-	tracerx.Printf("commands: disabling lock verification for %q", rawurl)
+	tracelog.Printf("commands: disabling lock verification for %q", rawurl)
 
 	key := strings.Join([]string{"lfs", rawurl, "locksverify"}, ".")
 
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,11 +17,11 @@ import (
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var (
-	ShowConfigWarnings     = false
 	defaultRemote          = "origin"
 	gitConfigWarningPrefix = "lfs."
 )
@@ -46,12 +47,17 @@ type Configuration struct {
 	ref        *git.Ref
 	remoteRef  *git.Ref
 	fs         *fs.Filesystem
+	store      fs.ObjectStore
 	gitDir     *string
 	workDir    string
 	loading    sync.Mutex // guards initialization of gitConfig and remotes
 	loadingGit sync.Mutex // guards initialization of local git and working dirs
+	readMu     sync.Mutex // guards remotes, extensions and gitFetcher, which are replaced when the Git configuration is reloaded
 	remotes    []string
 	extensions map[string]Extension
+	gitFetcher *GitFetcher
+	stamps     map[string]fileStamp
+	stampsMu   sync.Mutex
 	mask       int
 	maskOnce   sync.Once
 	timestamp  time.Time
@@ -80,6 +86,7 @@ func NewIn(workdir, gitdir string) *Configuration {
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error reading git config: %s\n", err)
 			}
+			c.stampConfigFiles(sources)
 			return c.readGitConfig(sources...)
 		},
 	}
@@ -120,12 +127,17 @@ func (c *Configuration) getMask() int {
 
 func (c *Configuration) readGitConfig(gitconfigs ...*git.ConfigurationSource) Environment {
 	gf, extensions, uniqRemotes := readGitConfig(gitconfigs...)
-	c.extensions = extensions
-	c.remotes = make([]string, 0, len(uniqRemotes))
+	remotes := make([]string, 0, len(uniqRemotes))
 	for remote := range uniqRemotes {
-		c.remotes = append(c.remotes, remote)
+		remotes = append(remotes, remote)
 	}
 
+	c.readMu.Lock()
+	c.gitFetcher = gf
+	c.extensions = extensions
+	c.remotes = remotes
+	c.readMu.Unlock()
+
 	return EnvironmentOf(gf)
 }
 
@@ -205,7 +217,7 @@ func (c *Configuration) CurrentRef() *git.Ref {
 	if c.ref == nil {
 		r, err := git.CurrentRef()
 		if err != nil {
-			tracerx.Printf("Error loading current ref: %s", err)
+			tracelog.Printf("Error loading current ref: %s", err)
 			c.ref = &git.Ref{}
 		} else {
 			c.ref = r
@@ -304,11 +316,30 @@ func (c *Configuration) SetPushRemote(name string) {
 
 func (c *Configuration) Remotes() []string {
 	c.loadGitConfig()
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
 	return c.remotes
 }
 
+// PromisorRemote returns the name of the remote from which Git fetches the
+// objects missing from a partial clone, or the empty string if the repository
+// is not a partial clone.
+func (c *Configuration) PromisorRemote() string {
+	if remote, ok := c.Git.Get("extensions.partialclone"); ok && len(remote) > 0 {
+		return remote
+	}
+	for _, remote := range c.Remotes() {
+		if c.Git.Bool(fmt.Sprintf("remote.%s.promisor", remote), false) {
+			return remote
+		}
+	}
+	return ""
+}
+
 func (c *Configuration) Extensions() map[string]Extension {
 	c.loadGitConfig()
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
 	return c.extensions
 }
 
@@ -317,16 +348,204 @@ func (c *Configuration) SortedExtensions() ([]Extension, error) {
 	return SortExtensions(c.Extensions())
 }
 
+// NonInteractiveEnv is the environment variable which, if true, stops Git LFS
+// and the programs it runs from prompting for credentials or confirmation.
+// The --noninteractive flag sets it, so that it also applies to the Git LFS
+// filters run by Git.
+const NonInteractiveEnv = "GIT_LFS_FORCE_NONINTERACTIVE"
+
+// NonInteractive returns whether Git LFS must not prompt the user, as given by
+// NonInteractiveEnv.
+func (c *Configuration) NonInteractive() bool {
+	return c.Os.Bool(NonInteractiveEnv, false)
+}
+
 func (c *Configuration) SkipDownloadErrors() bool {
-	return c.Os.Bool("GIT_LFS_SKIP_DOWNLOAD_ERRORS", false) || c.Git.Bool("lfs.skipdownloaderrors", false)
+	return c.settingBool("lfs.skipdownloaderrors", false)
+}
+
+// SmudgeFallbackFetch is the policy deciding whether an individual smudge run
+// outside of the filter-process protocol (for example, during "git archive" or
+// "git checkout-index") may download objects that are missing locally.
+type SmudgeFallbackFetch string
+
+const (
+	// SmudgeFallbackFetchTrue downloads missing objects without asking.
+	SmudgeFallbackFetchTrue SmudgeFallbackFetch = "true"
+	// SmudgeFallbackFetchFalse refuses to download missing objects.
+	SmudgeFallbackFetchFalse SmudgeFallbackFetch = "false"
+	// SmudgeFallbackFetchPrompt asks on the terminal before downloading
+	// missing objects, and refuses if no terminal is available.
+	SmudgeFallbackFetchPrompt SmudgeFallbackFetch = "prompt"
+)
+
+// SmudgeFallbackFetch returns the policy given by "lfs.smudge.fallbackfetch",
+// defaulting to SmudgeFallbackFetchTrue if unset.
+func (c *Configuration) SmudgeFallbackFetch() SmudgeFallbackFetch {
+	v, _ := c.Git.Get("lfs.smudge.fallbackfetch")
+	if strings.ToLower(v) == string(SmudgeFallbackFetchPrompt) {
+		return SmudgeFallbackFetchPrompt
+	}
+	if Bool(v, true) {
+		return SmudgeFallbackFetchTrue
+	}
+	return SmudgeFallbackFetchFalse
+}
+
+// HashAlgorithm returns the name of the hash algorithm used to compute the
+// object IDs of new pointers, as given by "lfs.hashalgorithm", defaulting to
+// "sha256" if unset.
+func (c *Configuration) HashAlgorithm() string {
+	if v, ok := c.Git.Get("lfs.hashalgorithm"); ok && len(v) > 0 {
+		return strings.ToLower(v)
+	}
+	return "sha256"
+}
+
+// PointerMetadata returns whether the clean filter should record optional
+// metadata, such as the content type and original file name, in new pointers,
+// as given by "lfs.pointer.metadata".
+func (c *Configuration) PointerMetadata() bool {
+	return c.Git.Bool("lfs.pointer.metadata", false)
+}
+
+// CleanCache returns whether the clean filter should record the object IDs of
+// the files it cleans, so that unchanged files need not be hashed again, as
+// given by "lfs.cleancache".
+func (c *Configuration) CleanCache() bool {
+	return c.Git.Bool("lfs.cleancache", false)
+}
+
+// CheckoutWorkers returns the number of files which are checked out at once by
+// commands such as "git lfs checkout" and "git lfs pull", as given by
+// "lfs.checkout.workers". Like Git's "checkout.workers", a value less than one
+// means the number of logical CPUs, which is also the default.
+func (c *Configuration) CheckoutWorkers() int {
+	if n := c.Git.Int("lfs.checkout.workers", 0); n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
+
+// HashWorkers returns the number of objects which are hashed at once while
+// preparing to push and verifying fetched objects, as given by
+// "lfs.hash.workers", or the number of logical CPUs if it is unset or less
+// than one.
+func (c *Configuration) HashWorkers() int {
+	if n := c.Git.Int("lfs.hash.workers", 0); n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
+
+// FSMonitor returns whether Git is configured to use a file system monitor to
+// find the files which have changed in the working tree, as given by
+// "core.fsmonitor", which is either a boolean, for Git's builtin daemon, or
+// the path of a hook.
+func (c *Configuration) FSMonitor() bool {
+	v, _ := c.Git.Get("core.fsmonitor")
+	switch strings.ToLower(v) {
+	case "", "false", "no", "off", "0":
+		return false
+	}
+	return true
+}
+
+// AutotrackSize returns the size, in bytes, below which files matched by
+// patterns with the "lfs-autotrack" attribute are stored in Git rather than
+// converted to pointers, as given by "lfs.autotrack.size". It returns 0, so
+// that all such files are converted, if the value is unset or invalid.
+func (c *Configuration) AutotrackSize() uint64 {
+	v, ok := c.Git.Get("lfs.autotrack.size")
+	if !ok || len(v) == 0 {
+		return 0
+	}
+
+	size, err := humanize.ParseBytes(v)
+	if err != nil {
+		tracelog.Printf("invalid lfs.autotrack.size %q: %s", v, err)
+		return 0
+	}
+	return size
+}
+
+// CacheMaxSize returns the size, in bytes, beyond which the least recently
+// used objects in the local object store are evicted, as given by
+// "lfs.cache.maxsize". It returns 0, leaving the store's size unlimited, if the
+// value is unset or invalid.
+func (c *Configuration) CacheMaxSize() uint64 {
+	v, ok := c.Git.Get("lfs.cache.maxsize")
+	if !ok || len(v) == 0 {
+		return 0
+	}
+
+	size, err := humanize.ParseBytes(v)
+	if err != nil {
+		tracelog.Printf("invalid lfs.cache.maxsize %q: %s", v, err)
+		return 0
+	}
+	return size
+}
+
+// TempMaxAge returns the age beyond which files in the temporary directory are
+// removed, as given by "lfs.tmpmaxage", or fs.DefaultTempMaxAge if the value
+// is unset or invalid.
+func (c *Configuration) TempMaxAge() time.Duration {
+	return c.durationSetting("lfs.tmpmaxage", fs.DefaultTempMaxAge)
+}
+
+// IncompleteMaxAge returns the age beyond which partially downloaded objects
+// are removed, rather than being kept so that their download can be resumed,
+// as given by "lfs.incompletemaxage", or fs.DefaultIncompleteMaxAge if the
+// value is unset or invalid.
+func (c *Configuration) IncompleteMaxAge() time.Duration {
+	return c.durationSetting("lfs.incompletemaxage", fs.DefaultIncompleteMaxAge)
+}
+
+// FilterProcessIdleTimeout returns how long "git lfs filter-process" waits for
+// a request from Git before shutting down, as given by
+// "lfs.filterprocess.idletimeout", or zero, meaning that it waits for as long
+// as Git runs, if the value is unset or invalid.
+func (c *Configuration) FilterProcessIdleTimeout() time.Duration {
+	return c.durationSetting("lfs.filterprocess.idletimeout", 0)
+}
+
+func (c *Configuration) durationSetting(key string, def time.Duration) time.Duration {
+	v, ok := c.Git.Get(key)
+	if !ok || len(v) == 0 {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		tracelog.Printf("invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// IgnoreCase returns whether include, exclude and tracking patterns match paths
+// without regard to case. See IgnoreCase for details.
+func (c *Configuration) IgnoreCase() bool {
+	return IgnoreCase(c.Git)
+}
+
+// IgnoreCase returns whether include, exclude and tracking patterns match paths
+// without regard to case according to the given Git configuration, as set by
+// "lfs.ignorecase".  It defaults to "core.ignorecase", which Git sets when a
+// repository is on a case-insensitive filesystem, and otherwise to whether the
+// system's filesystems usually are, being true on Windows and macOS.
+func IgnoreCase(gitEnv Environment) bool {
+	def := runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+	return gitEnv.Bool("lfs.ignorecase", gitEnv.Bool("core.ignorecase", def))
 }
 
 func (c *Configuration) SetLockableFilesReadOnly() bool {
-	return c.Os.Bool("GIT_LFS_SET_LOCKABLE_READONLY", true) && c.Git.Bool("lfs.setlockablereadonly", true)
+	return c.settingBool("lfs.setlockablereadonly", true)
 }
 
 func (c *Configuration) ForceProgress() bool {
-	return c.Os.Bool("GIT_LFS_FORCE_PROGRESS", false) || c.Git.Bool("lfs.forceprogress", false)
+	return c.settingBool("lfs.forceprogress", false)
 }
 
 // HookDir returns the location of the hooks owned by this repository. If the
@@ -374,7 +593,7 @@ func (c *Configuration) loadGitDirs() {
 	gitdir, workdir, err := git.GitAndRootDirs()
 	if err != nil {
 		errMsg := err.Error()
-		tracerx.Printf("Error running 'git rev-parse': %s", errMsg)
+		tracelog.Printf("Error running 'git rev-parse': %s", errMsg)
 		if !strings.Contains(strings.ToLower(errMsg),
 			"not a git repository") {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", errMsg)
@@ -404,11 +623,11 @@ func (c *Configuration) LFSObjectDir() string {
 }
 
 func (c *Configuration) LFSObjectExists(oid string, size int64) bool {
-	return c.Filesystem().ObjectExists(oid, size)
+	return c.ObjectStore().ObjectExists(oid, size)
 }
 
 func (c *Configuration) EachLFSObject(fn func(fs.Object) error) error {
-	return c.Filesystem().EachObject(fn)
+	return c.ObjectStore().EachObject(fn)
 }
 
 func (c *Configuration) LocalLogDir() string {
@@ -433,11 +652,38 @@ func (c *Configuration) Filesystem() *fs.Filesystem {
 			lfsdir,
 			c.RepositoryPermissions(false),
 		)
+		c.fs.Compress = c.Git.Bool("lfs.compressobjects", false)
+		c.fs.Fsync = c.Git.Bool("lfs.fsyncobjects", false)
+		c.fs.TempMaxAge = c.TempMaxAge()
+		c.fs.IncompleteMaxAge = c.IncompleteMaxAge()
+		c.fs.SetSecondaryStorage(c.Git.GetAll("lfs.secondarystorage"))
 	}
 
 	return c.fs
 }
 
+// ObjectStore returns the store of LFS objects, which is the local object store
+// on disk unless another has been set with SetObjectStore.
+func (c *Configuration) ObjectStore() fs.ObjectStore {
+	c.loading.Lock()
+	store := c.store
+	c.loading.Unlock()
+
+	if store == nil {
+		return c.Filesystem()
+	}
+	return store
+}
+
+// SetObjectStore replaces the store of LFS objects, for programs embedding Git
+// LFS.
+func (c *Configuration) SetObjectStore(store fs.ObjectStore) {
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	c.store = store
+}
+
 func (c *Configuration) Cleanup() error {
 	if c == nil {
 		return nil
@@ -528,6 +774,15 @@ func (c *Configuration) loadGitConfig() {
 	}
 }
 
+// loadGitFetcher returns the *GitFetcher which the Git configuration was read
+// into, reading it if necessary, or nil if there is none.
+func (c *Configuration) loadGitFetcher() *GitFetcher {
+	c.loadGitConfig()
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	return c.gitFetcher
+}
+
 var (
 	// dateFormats is a list of all the date formats that Git accepts,
 	// except for the built-in one, which is handled below.
@@ -20,6 +20,32 @@ func TestRemoteDefault(t *testing.T) {
 	assert.Equal(t, "origin", cfg.PushRemote())
 }
 
+func TestPromisorRemote(t *testing.T) {
+	cfg := NewFrom(Values{
+		Git: map[string][]string{
+			"remote.origin.url":       []string{"https://example.com/a"},
+			"remote.partial.url":      []string{"https://example.com/b"},
+			"remote.partial.promisor": []string{"true"},
+		},
+	})
+	assert.Equal(t, "partial", cfg.PromisorRemote())
+
+	cfg = NewFrom(Values{
+		Git: map[string][]string{
+			"remote.origin.url":       []string{"https://example.com/a"},
+			"extensions.partialclone": []string{"origin"},
+		},
+	})
+	assert.Equal(t, "origin", cfg.PromisorRemote())
+
+	cfg = NewFrom(Values{
+		Git: map[string][]string{
+			"remote.origin.url": []string{"https://example.com/a"},
+		},
+	})
+	assert.Equal(t, "", cfg.PromisorRemote())
+}
+
 func TestRemoteBranchConfig(t *testing.T) {
 	cfg := NewFrom(Values{
 		Git: map[string][]string{
@@ -159,6 +185,112 @@ func TestTusTransfersAllowedInvalidValue(t *testing.T) {
 	assert.Equal(t, false, b)
 }
 
+func TestSmudgeFallbackFetchDefault(t *testing.T) {
+	cfg := NewFrom(Values{})
+
+	assert.Equal(t, SmudgeFallbackFetchTrue, cfg.SmudgeFallbackFetch())
+}
+
+func TestSmudgeFallbackFetchSetValue(t *testing.T) {
+	for value, expected := range map[string]SmudgeFallbackFetch{
+		"true":   SmudgeFallbackFetchTrue,
+		"1":      SmudgeFallbackFetchTrue,
+		"false":  SmudgeFallbackFetchFalse,
+		"0":      SmudgeFallbackFetchFalse,
+		"prompt": SmudgeFallbackFetchPrompt,
+		"Prompt": SmudgeFallbackFetchPrompt,
+	} {
+		cfg := NewFrom(Values{
+			Git: map[string][]string{
+				"lfs.smudge.fallbackfetch": []string{value},
+			},
+		})
+
+		assert.Equal(t, expected, cfg.SmudgeFallbackFetch(), value)
+	}
+}
+
+func TestHashAlgorithmDefault(t *testing.T) {
+	cfg := NewFrom(Values{})
+
+	assert.Equal(t, "sha256", cfg.HashAlgorithm())
+}
+
+func TestHashAlgorithmSetValue(t *testing.T) {
+	cfg := NewFrom(Values{
+		Git: map[string][]string{
+			"lfs.hashalgorithm": []string{"SHA512"},
+		},
+	})
+
+	assert.Equal(t, "sha512", cfg.HashAlgorithm())
+}
+
+func TestPointerMetadata(t *testing.T) {
+	assert.False(t, NewFrom(Values{}).PointerMetadata())
+
+	cfg := NewFrom(Values{
+		Git: map[string][]string{
+			"lfs.pointer.metadata": []string{"true"},
+		},
+	})
+	assert.True(t, cfg.PointerMetadata())
+}
+
+func TestFSMonitor(t *testing.T) {
+	assert.False(t, NewFrom(Values{}).FSMonitor())
+
+	for value, expected := range map[string]bool{
+		"false":                       false,
+		"0":                           false,
+		"true":                        true,
+		".git/hooks/query-watchman":   true,
+		"/usr/bin/fsmonitor-watchman": true,
+	} {
+		cfg := NewFrom(Values{
+			Git: map[string][]string{
+				"core.fsmonitor": []string{value},
+			},
+		})
+		assert.Equal(t, expected, cfg.FSMonitor(), value)
+	}
+}
+
+func TestAutotrackSize(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"":      0,
+		"100":   100,
+		"10MB":  10 * 1000 * 1000,
+		"1 GiB": 1024 * 1024 * 1024,
+		"huge":  0,
+	} {
+		cfg := NewFrom(Values{
+			Git: map[string][]string{
+				"lfs.autotrack.size": []string{value},
+			},
+		})
+		assert.Equal(t, expected, cfg.AutotrackSize(), value)
+	}
+
+	assert.Equal(t, uint64(0), NewFrom(Values{}).AutotrackSize())
+}
+
+func TestCacheMaxSize(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"":      0,
+		"5GB":   5 * 1000 * 1000 * 1000,
+		"512MB": 512 * 1000 * 1000,
+		"lots":  0,
+	} {
+		cfg := NewFrom(Values{
+			Git: map[string][]string{
+				"lfs.cache.maxsize": []string{value},
+			},
+		})
+		assert.Equal(t, expected, cfg.CacheMaxSize(), value)
+	}
+}
+
 func TestLoadValidExtension(t *testing.T) {
 	cfg := NewFrom(Values{
 		Git: map[string][]string{
@@ -198,6 +330,78 @@ func TestFetchIncludeExcludesAreCleaned(t *testing.T) {
 	assert.Equal(t, []string{"/other/path/to/clean"}, cfg.FetchExcludePaths())
 }
 
+func TestIgnoreCase(t *testing.T) {
+	for _, test := range []struct {
+		git      map[string][]string
+		expected bool
+	}{
+		{map[string][]string{"core.ignorecase": []string{"true"}}, true},
+		{map[string][]string{"core.ignorecase": []string{"false"}}, false},
+		{map[string][]string{"core.ignorecase": []string{"false"}, "lfs.ignorecase": []string{"true"}}, true},
+		{map[string][]string{"core.ignorecase": []string{"true"}, "lfs.ignorecase": []string{"false"}}, false},
+	} {
+		cfg := NewFrom(Values{Git: test.git})
+		assert.Equal(t, test.expected, cfg.IgnoreCase(), "%v", test.git)
+	}
+}
+
+func TestEnvironmentOverridesGitConfig(t *testing.T) {
+	cfg := NewFrom(Values{
+		Git: map[string][]string{"lfs.forceprogress": []string{"true"}},
+		Os:  map[string][]string{"GIT_LFS_FORCE_PROGRESS": []string{"false"}},
+	})
+	assert.False(t, cfg.ForceProgress())
+
+	origin, ok := cfg.Origin("lfs.forceprogress")
+	assert.True(t, ok)
+	assert.Equal(t, Origin{Scope: ScopeEnvironment, Source: "GIT_LFS_FORCE_PROGRESS"}, origin)
+
+	cfg = NewFrom(Values{
+		Git: map[string][]string{"lfs.setlockablereadonly": []string{"false"}},
+		Os:  map[string][]string{"GIT_LFS_SET_LOCKABLE_READONLY": []string{"1"}},
+	})
+	assert.True(t, cfg.SetLockableFilesReadOnly())
+
+	cfg = NewFrom(Values{
+		Git: map[string][]string{"lfs.skipdownloaderrors": []string{"true"}},
+	})
+	assert.True(t, cfg.SkipDownloadErrors())
+
+	origin, ok = cfg.Origin("lfs.skipdownloaderrors")
+	assert.True(t, ok)
+	assert.Equal(t, ScopeUnknown, origin.Scope)
+}
+
+func TestSetLockableFilesReadOnlyPrecedence(t *testing.T) {
+	for desc, test := range map[string]struct {
+		git      string
+		env      string
+		expected bool
+	}{
+		"default":                {"", "", true},
+		"git config false":       {"false", "", false},
+		"environment false":      {"", "0", false},
+		"environment overrides":  {"false", "1", true},
+		"environment false wins": {"true", "false", false},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			values := Values{
+				Git: map[string][]string{},
+				Os:  map[string][]string{},
+			}
+			if len(test.git) > 0 {
+				values.Git["lfs.setlockablereadonly"] = []string{test.git}
+			}
+			if len(test.env) > 0 {
+				values.Os["GIT_LFS_SET_LOCKABLE_READONLY"] = []string{test.env}
+			}
+
+			cfg := NewFrom(values)
+			assert.Equal(t, test.expected, cfg.SetLockableFilesReadOnly())
+		})
+	}
+}
+
 func TestRepositoryPermissions(t *testing.T) {
 	perms := 0666 & ^umask()
 
@@ -18,35 +18,30 @@ type delayedEnvironment struct {
 // Get is shorthand for calling the e.Load(), and then returning
 // `e.env.Get(key)`.
 func (e *delayedEnvironment) Get(key string) (string, bool) {
-	e.Load()
-	return e.env.Get(key)
+	return e.load().Get(key)
 }
 
 // Get is shorthand for calling the e.Load(), and then returning
 // `e.env.GetAll(key)`.
 func (e *delayedEnvironment) GetAll(key string) []string {
-	e.Load()
-	return e.env.GetAll(key)
+	return e.load().GetAll(key)
 }
 
 // Get is shorthand for calling the e.Load(), and then returning
 // `e.env.Bool(key, def)`.
 func (e *delayedEnvironment) Bool(key string, def bool) bool {
-	e.Load()
-	return e.env.Bool(key, def)
+	return e.load().Bool(key, def)
 }
 
 // Get is shorthand for calling the e.Load(), and then returning
 // `e.env.Int(key, def)`.
 func (e *delayedEnvironment) Int(key string, def int) int {
-	e.Load()
-	return e.env.Int(key, def)
+	return e.load().Int(key, def)
 }
 
 // All returns a copy of all the key/value pairs for the current git config.
 func (e *delayedEnvironment) All() map[string][]string {
-	e.Load()
-	return e.env.All()
+	return e.load().All()
 }
 
 // Load reads and parses the .gitconfig by calling ReadGitConfig. It
@@ -57,12 +52,27 @@ func (e *delayedEnvironment) All() map[string][]string {
 //
 // Load is safe to call across multiple goroutines.
 func (e *delayedEnvironment) Load() {
+	e.load()
+}
+
+// load reads the configuration, if it has not been read already, and returns
+// it.  Callers use the returned Environment rather than e.env, so that they are
+// not affected by a concurrent call to reset.
+func (e *delayedEnvironment) load() Environment {
 	e.loading.Lock()
 	defer e.loading.Unlock()
 
-	if e.env != nil {
-		return
+	if e.env == nil {
+		e.env = e.callback()
 	}
+	return e.env
+}
+
+// reset discards the configuration which has been read, so that it is read
+// again when next used.
+func (e *delayedEnvironment) reset() {
+	e.loading.Lock()
+	defer e.loading.Unlock()
 
-	e.env = e.callback()
+	e.env = nil
 }
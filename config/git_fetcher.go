@@ -11,21 +11,31 @@ import (
 )
 
 type GitFetcher struct {
-	vmu  sync.RWMutex
-	vals map[string][]string
+	vmu      sync.RWMutex
+	vals     map[string][]string
+	origins  map[string]Origin
+	warnings []string
 }
 
+// readGitConfig reads the given configuration sources, which are in increasing
+// order of precedence.  The values of a key from one source replace those from
+// sources of lower precedence, rather than being merged with them, so that, for
+// instance, a multi-valued key in the Git configuration is not combined with
+// the same key in .lfsconfig.
 func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensions map[string]Extension, uniqRemotes map[string]bool) {
 	vals := make(map[string][]string)
+	origins := make(map[string]Origin)
+	sourceOf := make(map[string]int)
 	ignored := make([]string, 0)
+	var warnings []string
 
 	extensions = make(map[string]Extension)
 	uniqRemotes = make(map[string]bool)
 
-	for _, gc := range configs {
+	for i, gc := range configs {
 		uniqKeys := make(map[string]string)
 
-		for _, line := range gc.Lines {
+		for j, line := range gc.Lines {
 			pieces := strings.SplitN(line, "=", 2)
 			if len(pieces) < 2 {
 				continue
@@ -38,10 +48,10 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 			key, val := pieces[0], pieces[1]
 
 			if origKey, ok := uniqKeys[key]; ok {
-				if ShowConfigWarnings && len(vals[key]) > 0 && vals[key][len(vals[key])-1] != val && strings.HasPrefix(key, gitConfigWarningPrefix) {
-					fmt.Fprintf(os.Stderr, "WARNING: These git config values clash:\n")
-					fmt.Fprintf(os.Stderr, "  git config %q = %q\n", origKey, vals[key])
-					fmt.Fprintf(os.Stderr, "  git config %q = %q\n", pieces[0], val)
+				if len(vals[key]) > 0 && vals[key][len(vals[key])-1] != val && strings.HasPrefix(key, gitConfigWarningPrefix) {
+					warnings = append(warnings, fmt.Sprintf(
+						"WARNING: These git config values clash:\n  git config %q = %q\n  git config %q = %q",
+						origKey, vals[key], pieces[0], val))
 				}
 			} else {
 				uniqKeys[key] = pieces[0]
@@ -96,7 +106,12 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 				continue
 			}
 
+			if src, ok := sourceOf[key]; ok && src != i {
+				vals[key] = nil
+			}
+			sourceOf[key] = i
 			vals[key] = append(vals[key], val)
+			origins[key] = lineOrigin(gc, j)
 		}
 	}
 
@@ -107,11 +122,44 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 		}
 	}
 
-	gf = &GitFetcher{vals: vals}
+	gf = &GitFetcher{vals: vals, origins: origins, warnings: warnings}
 
 	return
 }
 
+// lineOrigin returns the origin of the line at the given index of the source.
+// Sources limited to safe keys are .lfsconfig files.
+func lineOrigin(gc *git.ConfigurationSource, i int) Origin {
+	var origin git.ConfigurationOrigin
+	if i < len(gc.Origins) {
+		origin = gc.Origins[i]
+	}
+
+	if gc.OnlySafeKeys {
+		return Origin{Scope: ScopeLfsconfig, Source: origin.Source}
+	}
+	return Origin{Scope: Scope(origin.Scope), Source: origin.Source}
+}
+
+// Origin returns where the effective value of the given key was read from, and
+// whether the key is set.
+func (g *GitFetcher) Origin(key string) (Origin, bool) {
+	g.vmu.RLock()
+	defer g.vmu.RUnlock()
+
+	origin, ok := g.origins[g.caseFoldKey(key)]
+	return origin, ok
+}
+
+// Warnings returns a message for each "lfs.*" key given clashing values in
+// the same configuration source.
+func (g *GitFetcher) Warnings() []string {
+	g.vmu.RLock()
+	defer g.vmu.RUnlock()
+
+	return g.warnings
+}
+
 // Get implements the Fetcher interface, and returns the value associated with
 // a given key and true, signaling that the value was present. Otherwise, an
 // empty string and false will be returned, signaling that the value was
@@ -184,6 +232,7 @@ func keyIsUnsafe(key string) bool {
 
 var safeKeys = []string{
 	"lfs.allowincompletepush",
+	"lfs.autotrack.size",
 	"lfs.fetchexclude",
 	"lfs.fetchinclude",
 	"lfs.gitprotocol",
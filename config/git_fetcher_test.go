@@ -3,6 +3,7 @@ package config
 import (
 	"testing"
 
+	"github.com/git-lfs/git-lfs/git"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,3 +24,55 @@ func TestGetCanonicalization(t *testing.T) {
 	assert.Equal(t, []string{"X-Foo: Bar"}, fetcher.GetAll("http.https://example.com/BIG-TEXT.git.extraHeader"))
 	assert.Equal(t, []string(nil), fetcher.GetAll("http.https://example.com/big-text.git.extraHeader"))
 }
+
+func TestReadGitConfigPrecedence(t *testing.T) {
+	lfsconfig := &git.ConfigurationSource{
+		Lines:        []string{"lfs.url=https://lfsconfig.example.com", "lfs.fetchinclude=a", "lfs.fetchinclude=b", "lfs.fetchexclude=c"},
+		OnlySafeKeys: true,
+	}
+	lfsconfig.Origins = make([]git.ConfigurationOrigin, len(lfsconfig.Lines))
+	for i := range lfsconfig.Origins {
+		lfsconfig.Origins[i].Source = "blob:HEAD:.lfsconfig"
+	}
+	gitconfig := &git.ConfigurationSource{
+		Lines: []string{"lfs.fetchinclude=d", "lfs.url=https://global.example.com", "lfs.url=https://local.example.com"},
+		Origins: []git.ConfigurationOrigin{
+			{Scope: "global", Source: "file:/home/user/.gitconfig"},
+			{Scope: "global", Source: "file:/home/user/.gitconfig"},
+			{Scope: "local", Source: "file:.git/config"},
+		},
+	}
+
+	fetcher, _, _ := readGitConfig(lfsconfig, gitconfig)
+
+	// Values from .lfsconfig are replaced, not merged, by the Git
+	// configuration, whose own values are kept in order.
+	assert.Equal(t, []string{"d"}, fetcher.GetAll("lfs.fetchinclude"))
+	assert.Equal(t, []string{"https://global.example.com", "https://local.example.com"}, fetcher.GetAll("lfs.url"))
+	assert.Equal(t, []string{"c"}, fetcher.GetAll("lfs.fetchexclude"))
+
+	origin, ok := fetcher.Origin("lfs.url")
+	assert.True(t, ok)
+	assert.Equal(t, Origin{Scope: ScopeLocal, Source: "file:.git/config"}, origin)
+
+	origin, ok = fetcher.Origin("lfs.fetchexclude")
+	assert.True(t, ok)
+	assert.Equal(t, Origin{Scope: ScopeLfsconfig, Source: "blob:HEAD:.lfsconfig"}, origin)
+
+	_, ok = fetcher.Origin("lfs.pushurl")
+	assert.False(t, ok)
+}
+
+func TestReadGitConfigWarnings(t *testing.T) {
+	gitconfig := &git.ConfigurationSource{
+		Lines: []string{"lfs.url=https://a.example.com", "lfs.url=https://b.example.com", "core.editor=vi", "core.editor=emacs"},
+	}
+
+	fetcher, _, _ := readGitConfig(gitconfig)
+
+	assert.Equal(t, []string{
+		"WARNING: These git config values clash:\n" +
+			"  git config \"lfs.url\" = [\"https://a.example.com\"]\n" +
+			"  git config \"lfs.url\" = \"https://b.example.com\"",
+	}, fetcher.Warnings())
+}
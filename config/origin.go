@@ -0,0 +1,92 @@
+package config
+
+import "fmt"
+
+// Scope is a layer of Git LFS configuration.  When a setting is given in more
+// than one layer, the value from the layer of highest precedence is used.  In
+// increasing order of precedence, the layers are:
+//
+//  1. ScopeLfsconfig, the .lfsconfig file in the repository.
+//  2. ScopeSystem, ScopeGlobal, ScopeLocal and ScopeWorktree, the Git
+//     configuration files.
+//  3. ScopeCommand, values given with "git -c".
+//  4. ScopeEnvironment, environment variables which override settings, such
+//     as GIT_LFS_FORCE_PROGRESS for "lfs.forceprogress".
+type Scope string
+
+const (
+	ScopeUnknown     Scope = ""
+	ScopeLfsconfig   Scope = "lfsconfig"
+	ScopeSystem      Scope = "system"
+	ScopeGlobal      Scope = "global"
+	ScopeLocal       Scope = "local"
+	ScopeWorktree    Scope = "worktree"
+	ScopeCommand     Scope = "command"
+	ScopeEnvironment Scope = "env"
+)
+
+// Origin describes where the value of a setting came from.
+type Origin struct {
+	Scope Scope
+	// Source is the file, blob or environment variable the value was read
+	// from, such as "file:.git/config", "blob:HEAD:.lfsconfig" or
+	// "GIT_LFS_FORCE_PROGRESS".
+	Source string
+}
+
+func (o Origin) String() string {
+	switch {
+	case o.Scope == ScopeUnknown:
+		return o.Source
+	case len(o.Source) == 0:
+		return string(o.Scope)
+	default:
+		return fmt.Sprintf("%s (%s)", o.Scope, o.Source)
+	}
+}
+
+// envOverrides maps the settings which may be overridden by an environment
+// variable to that variable.
+var envOverrides = map[string]string{
+	"lfs.forceprogress":       "GIT_LFS_FORCE_PROGRESS",
+	"lfs.setlockablereadonly": "GIT_LFS_SET_LOCKABLE_READONLY",
+	"lfs.skipdownloaderrors":  "GIT_LFS_SKIP_DOWNLOAD_ERRORS",
+}
+
+// Origin returns where the effective value of the given setting came from,
+// and whether it is set at all.
+func (c *Configuration) Origin(key string) (Origin, bool) {
+	if env, ok := envOverrides[key]; ok {
+		if v, ok := c.Os.Get(env); ok && len(v) > 0 {
+			return Origin{Scope: ScopeEnvironment, Source: env}, true
+		}
+	}
+
+	gf := c.loadGitFetcher()
+	if gf == nil {
+		return Origin{}, false
+	}
+	return gf.Origin(key)
+}
+
+// ConfigWarnings returns a message for each "lfs.*" key in the Git
+// configuration which is given clashing values in the same file, so that
+// callers can decide whether and where to show them.
+func (c *Configuration) ConfigWarnings() []string {
+	gf := c.loadGitFetcher()
+	if gf == nil {
+		return nil
+	}
+	return gf.Warnings()
+}
+
+// settingBool returns the boolean value of the given setting, taking the
+// environment variable which overrides it into account.
+func (c *Configuration) settingBool(key string, def bool) bool {
+	if env, ok := envOverrides[key]; ok {
+		if v, ok := c.Os.Get(env); ok && len(v) > 0 {
+			return Bool(v, def)
+		}
+	}
+	return c.Git.Bool(key, def)
+}
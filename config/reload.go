@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+// fileStamp is the state of a configuration file when the Git configuration
+// was read, used to tell whether the file has since changed.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stampFile(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: fi.Size(), modTime: fi.ModTime()}
+}
+
+// stampConfigFiles records the state of the files the given sources were read
+// from, along with that of the files which Git and Git LFS would read their
+// configuration from were they created, so that GitConfigChanged can tell when
+// any of them changes.
+func (c *Configuration) stampConfigFiles(sources []*git.ConfigurationSource) {
+	paths := make(map[string]bool)
+	for _, source := range sources {
+		for _, origin := range source.Origins {
+			if !strings.HasPrefix(origin.Source, "file:") {
+				continue
+			}
+
+			// Git gives paths relative to the directory it was
+			// run in, which is the current directory unless the
+			// configuration is for a given Git directory.
+			path := strings.TrimPrefix(origin.Source, "file:")
+			if !filepath.IsAbs(path) && c.gitConfig != nil && len(c.gitConfig.GitDir) > 0 {
+				path = filepath.Join(c.gitConfig.GitDir, path)
+			}
+			paths[path] = true
+		}
+	}
+
+	if gitdir := c.LocalGitDir(); len(gitdir) > 0 {
+		paths[filepath.Join(gitdir, "config")] = true
+	}
+	if workdir := c.LocalWorkingDir(); len(workdir) > 0 {
+		paths[filepath.Join(workdir, ".lfsconfig")] = true
+	}
+	if global, ok := c.Os.Get("GIT_CONFIG_GLOBAL"); ok && len(global) > 0 {
+		paths[global] = true
+	} else {
+		if home, err := tools.ExpandPath("~/.gitconfig", false); err == nil {
+			paths[home] = true
+		}
+		if xdg, err := tools.ExpandConfigPath("", "git/config"); err == nil {
+			paths[xdg] = true
+		}
+	}
+
+	// The paths are made absolute, so that the files are still found if
+	// the current directory changes before they are checked.
+	stamps := make(map[string]fileStamp, len(paths))
+	for path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		stamps[path] = stampFile(path)
+	}
+
+	c.stampsMu.Lock()
+	c.stamps = stamps
+	c.stampsMu.Unlock()
+}
+
+// GitConfigChanged returns whether any of the files the Git configuration was
+// read from, or any file it would be read from which did not exist at the
+// time, has changed since the configuration was read.  It returns false if the
+// configuration has not been read yet.
+func (c *Configuration) GitConfigChanged() bool {
+	c.stampsMu.Lock()
+	defer c.stampsMu.Unlock()
+
+	for path, stamp := range c.stamps {
+		if stampFile(path) != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadGitConfig discards the Git configuration which has been read, so that
+// it is read again when it is next used.  Settings which are only read once,
+// such as the location of the local object store, are not changed.  The
+// remotes and extensions are replaced, under the lock which guards them, when
+// the configuration is read again.
+func (c *Configuration) ReloadGitConfig() {
+	if g, ok := c.Git.(*delayedEnvironment); ok {
+		g.reset()
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadGitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-reload")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, exec.Command("git", "init", "-q", dir).Run())
+
+	cfg := NewIn(dir, filepath.Join(dir, ".git"))
+	assert.False(t, cfg.GitConfigChanged())
+
+	assert.Empty(t, cfg.FetchExcludePaths())
+	assert.False(t, cfg.GitConfigChanged())
+
+	require.Nil(t, exec.Command("git", "-C", dir, "config", "lfs.fetchexclude", "*.bin").Run())
+	assert.True(t, cfg.GitConfigChanged())
+	assert.Empty(t, cfg.FetchExcludePaths())
+
+	cfg.ReloadGitConfig()
+	assert.Equal(t, []string{"*.bin"}, cfg.FetchExcludePaths())
+	assert.False(t, cfg.GitConfigChanged())
+
+	lfsconfig := filepath.Join(dir, ".lfsconfig")
+	require.Nil(t, ioutil.WriteFile(lfsconfig, []byte("[lfs]\n\tfetchinclude = *.dat\n"), 0644))
+	assert.True(t, cfg.GitConfigChanged())
+
+	cfg.ReloadGitConfig()
+	assert.Equal(t, []string{"*.dat"}, cfg.FetchIncludePaths())
+	assert.False(t, cfg.GitConfigChanged())
+}
+
+func TestGitConfigChangedAfterChangingDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-reload")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, exec.Command("git", "init", "-q", dir).Run())
+
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	defer os.Chdir(wd)
+
+	// Git gives the origin of the local configuration relative to the
+	// current directory.
+	require.Nil(t, os.Chdir(dir))
+	cfg := New()
+	assert.Empty(t, cfg.FetchExcludePaths())
+
+	require.Nil(t, os.Chdir(os.TempDir()))
+	assert.False(t, cfg.GitConfigChanged())
+
+	require.Nil(t, exec.Command("git", "-C", dir, "config", "lfs.fetchexclude", "*.bin").Run())
+	assert.True(t, cfg.GitConfigChanged())
+}
+
+func TestReloadGitConfigConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-reload")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, exec.Command("git", "init", "-q", dir).Run())
+	require.Nil(t, exec.Command("git", "-C", dir, "remote", "add", "origin", "https://example.com/repo.git").Run())
+
+	cfg := NewIn(dir, filepath.Join(dir, ".git"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				cfg.ReloadGitConfig()
+				assert.Equal(t, []string{"origin"}, cfg.Remotes())
+				assert.Empty(t, cfg.Extensions())
+				cfg.ConfigWarnings()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGitConfigChangedWithoutFiles(t *testing.T) {
+	cfg := NewFrom(Values{Git: map[string][]string{"lfs.url": []string{"https://example.com"}}})
+
+	_, _ = cfg.Git.Get("lfs.url")
+	assert.False(t, cfg.GitConfigChanged())
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools/humanize"
+)
+
+// Problem is a problem with a Git LFS setting found by Validate.
+type Problem struct {
+	Key   string
+	Value string
+	// Origin is where the value of the setting came from.
+	Origin  Origin
+	Message string
+}
+
+func (p *Problem) String() string {
+	if p.Origin == (Origin{}) {
+		return fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s (from %s)", p.Key, p.Message, p.Origin)
+}
+
+// checkFunc checks the value of a setting, returning a description of what is
+// wrong with it, or an empty string if it is valid.
+type checkFunc func(value string) string
+
+func anyValue(value string) string {
+	return ""
+}
+
+func boolValue(value string) string {
+	switch strings.ToLower(value) {
+	case "", "true", "1", "on", "yes", "t", "false", "0", "off", "no", "f":
+		return ""
+	}
+	return fmt.Sprintf("%q is not a boolean, and is treated as false", value)
+}
+
+func fallbackFetchValue(value string) string {
+	if strings.EqualFold(value, string(SmudgeFallbackFetchPrompt)) {
+		return ""
+	}
+	return boolValue(value)
+}
+
+func intValue(value string) string {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Sprintf("%q is not an integer, and is ignored", value)
+	}
+	return ""
+}
+
+// minIntValue returns a checkFunc for integers which are at least min.
+func minIntValue(min int) checkFunc {
+	return func(value string) string {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("%q is not an integer, and is ignored", value)
+		}
+		if i < min {
+			return fmt.Sprintf("%d is less than %d, and is ignored", i, min)
+		}
+		return ""
+	}
+}
+
+func sizeValue(value string) string {
+	if _, err := humanize.ParseBytes(value); err != nil {
+		return fmt.Sprintf("%q is not a size, such as \"100MB\", and is ignored", value)
+	}
+	return ""
+}
+
+func durationValue(value string) string {
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Sprintf("%q is not a duration, such as \"24h\", and is ignored", value)
+	}
+	return ""
+}
+
+// oneOf returns a checkFunc for values which are one of the given choices,
+// ignoring case.
+func oneOf(choices ...string) checkFunc {
+	return func(value string) string {
+		for _, choice := range choices {
+			if strings.EqualFold(value, choice) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is not one of %s", value, strings.Join(choices, ", "))
+	}
+}
+
+// knownSettings are the Git LFS settings, and the checks made of their values.
+var knownSettings = map[string]checkFunc{
+	"lfs.activitytimeout":            intValue,
+	"lfs.allowincompletepush":        boolValue,
+	"lfs.autotrack.size":             sizeValue,
+	"lfs.basictransfersonly":         boolValue,
+	"lfs.cache.maxsize":              sizeValue,
+	"lfs.cachecredentials":           boolValue,
+	"lfs.checkout.workers":           intValue,
+	"lfs.circuitbreaker":             intValue,
+	"lfs.cleancache":                 boolValue,
+	"lfs.compressobjects":            boolValue,
+	"lfs.concurrenttransfers":        minIntValue(1),
+	"lfs.contenttype":                boolValue,
+	"lfs.crypt.keyfile":              anyValue,
+	"lfs.defaulttokenttl":            intValue,
+	"lfs.dialtimeout":                intValue,
+	"lfs.fetchexclude":               anyValue,
+	"lfs.fetchinclude":               anyValue,
+	"lfs.fetchrecentalways":          boolValue,
+	"lfs.fetchrecentcommitsdays":     intValue,
+	"lfs.fetchrecentrefsdays":        intValue,
+	"lfs.fetchrecentremoterefs":      boolValue,
+	"lfs.fetchrecenttags":            boolValue,
+	"lfs.filterprocess.idletimeout":  durationValue,
+	"lfs.forceprogress":              boolValue,
+	"lfs.fsyncobjects":               boolValue,
+	"lfs.gc.compress":                boolValue,
+	"lfs.gitprotocol":                anyValue,
+	"lfs.hash.workers":               intValue,
+	"lfs.hashalgorithm":              oneOf("sha256", "sha512"),
+	"lfs.hooks.posttransfer":         anyValue,
+	"lfs.hooks.pretransfer":          anyValue,
+	"lfs.idletimeout":                intValue,
+	"lfs.ignorecase":                 boolValue,
+	"lfs.incompletemaxage":           durationValue,
+	"lfs.keepalive":                  intValue,
+	"lfs.largefilewarning":           boolValue,
+	"lfs.lockexpirywarning":          intValue,
+	"lfs.lockhelper":                 anyValue,
+	"lfs.lockignoredfiles":           boolValue,
+	"lfs.locale":                     anyValue,
+	"lfs.locksverify":                boolValue,
+	"lfs.logformat":                  oneOf("text", "json"),
+	"lfs.metrics.otlp":               anyValue,
+	"lfs.metrics.prefix":             anyValue,
+	"lfs.metrics.statsd":             anyValue,
+	"lfs.pointer.metadata":           boolValue,
+	"lfs.pointer.sign":               boolValue,
+	"lfs.pointer.verifysignatures":   boolValue,
+	"lfs.pruneoffsetdays":            intValue,
+	"lfs.pruneremotetocheck":         anyValue,
+	"lfs.pruneverifyremotealways":    boolValue,
+	"lfs.pushurl":                    anyValue,
+	"lfs.repositoryformatversion":    intValue,
+	"lfs.responseheadertimeout":      intValue,
+	"lfs.secondarystorage":           anyValue,
+	"lfs.setlockablereadonly":        boolValue,
+	"lfs.skipdownloaderrors":         boolValue,
+	"lfs.smudge.fallbackfetch":       fallbackFetchValue,
+	"lfs.ssh.automultiplex":          boolValue,
+	"lfs.ssh.retries":                intValue,
+	"lfs.standalonetransferagent":    anyValue,
+	"lfs.storage":                    anyValue,
+	"lfs.tlstimeout":                 intValue,
+	"lfs.tmpmaxage":                  durationValue,
+	"lfs.transfer.enablehrefrewrite": boolValue,
+	"lfs.transfer.maxretries":        minIntValue(1),
+	"lfs.transfer.maxretrydelay":     minIntValue(0),
+	"lfs.transfer.maxverifies":       minIntValue(1),
+	"lfs.transferhistory":            boolValue,
+	"lfs.tustransfers":               boolValue,
+	"lfs.url":                        anyValue,
+}
+
+// scopedSettings are the settings which may also be given for a single URL, as
+// "lfs.<url>.<setting>", or a single remote, as "lfs.<remote>.<setting>".
+var scopedSettings = map[string]checkFunc{
+	"access":                  oneOf("basic", "negotiate", "none", "private"),
+	"activitytimeout":         intValue,
+	"basictransfersonly":      boolValue,
+	"concurrenttransfers":     minIntValue(1),
+	"contenttype":             boolValue,
+	"dialtimeout":             intValue,
+	"hooks.posttransfer":      anyValue,
+	"hooks.pretransfer":       anyValue,
+	"idletimeout":             intValue,
+	"keepalive":               intValue,
+	"lockhelper":              anyValue,
+	"locksverify":             boolValue,
+	"responseheadertimeout":   intValue,
+	"standalonetransferagent": anyValue,
+	"tlstimeout":              intValue,
+	"transfer.maxretries":     minIntValue(1),
+	"transfer.maxretrydelay":  minIntValue(0),
+	"transfer.maxverifies":    minIntValue(1),
+	"tustransfers":            boolValue,
+}
+
+var (
+	customTransferSettings = map[string]checkFunc{
+		"path":       anyValue,
+		"args":       anyValue,
+		"concurrent": boolValue,
+		"direction":  oneOf("upload", "download", "both"),
+	}
+	extensionSettings = map[string]checkFunc{
+		"clean":    anyValue,
+		"smudge":   anyValue,
+		"priority": minIntValue(0),
+	}
+)
+
+// Validate checks the "lfs.*" settings for names which Git LFS does not know,
+// values which it would ignore or misread, and settings which conflict with
+// each other, and returns the problems found, ordered by key.
+func (c *Configuration) Validate() []*Problem {
+	gf := c.loadGitFetcher()
+
+	var problems []*Problem
+	add := func(key, value, format string, args ...interface{}) {
+		// Environment variables which override settings are not
+		// checked, so the origin is always in the Git configuration.
+		var origin Origin
+		if gf != nil {
+			origin, _ = gf.Origin(key)
+		}
+		problems = append(problems, &Problem{
+			Key:     key,
+			Value:   value,
+			Origin:  origin,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	all := c.Git.All()
+	for key, values := range all {
+		if !strings.HasPrefix(key, "lfs.") || len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+
+		check, suggestion := settingCheck(key)
+		if check == nil {
+			if len(suggestion) > 0 {
+				add(key, value, "unknown setting; did you mean %s?", suggestion)
+			} else {
+				add(key, value, "unknown setting")
+			}
+			continue
+		}
+		if msg := check(value); len(msg) > 0 {
+			add(key, value, "%s", msg)
+		}
+	}
+
+	if c.Git.Bool("lfs.basictransfersonly", false) && c.Git.Bool("lfs.tustransfers", false) {
+		v, _ := c.Git.Get("lfs.tustransfers")
+		add("lfs.tustransfers", v, "has no effect, since lfs.basictransfersonly is set")
+	}
+
+	for key, values := range all {
+		if !strings.HasPrefix(key, "lfs.") || !strings.HasSuffix(key, ".standalonetransferagent") || len(values) == 0 {
+			continue
+		}
+		agent := values[len(values)-1]
+		if len(agent) == 0 || agent == "lfs-standalone-file" || agent == "lfs-standalone-oci" {
+			continue
+		}
+		if _, ok := c.Git.Get(fmt.Sprintf("lfs.customtransfer.%s.path", agent)); !ok {
+			add(key, agent, "names a transfer agent without lfs.customtransfer.%s.path", agent)
+		}
+	}
+
+	for key, values := range all {
+		parts := strings.Split(key, ".")
+		if len(parts) != 4 || parts[0] != "lfs" || parts[1] != "customtransfer" || parts[3] == "path" {
+			continue
+		}
+		if _, ok := c.Git.Get(fmt.Sprintf("lfs.customtransfer.%s.path", parts[2])); !ok {
+			add(key, values[len(values)-1], "has no effect without lfs.customtransfer.%s.path", parts[2])
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Key < problems[j].Key
+	})
+	return problems
+}
+
+// settingCheck returns the check of the value of the given "lfs.*" setting, or
+// nil and the name of a similar setting, if any, when the setting is unknown.
+func settingCheck(key string) (checkFunc, string) {
+	if check, ok := knownSettings[key]; ok {
+		return check, ""
+	}
+
+	parts := strings.Split(key, ".")
+	if len(parts) == 4 {
+		var settings map[string]checkFunc
+		switch parts[1] {
+		case "customtransfer":
+			settings = customTransferSettings
+		case "extension":
+			settings = extensionSettings
+		}
+		if settings != nil {
+			if check, ok := settings[parts[3]]; ok {
+				return check, ""
+			}
+			if s := similarName(parts[3], settings); len(s) > 0 {
+				return nil, strings.Join(append(parts[:3], s), ".")
+			}
+			return nil, ""
+		}
+	}
+
+	// The subsection of a scoped setting is a URL or remote name, which
+	// may itself contain dots.
+	for name, check := range scopedSettings {
+		if strings.HasSuffix(key, "."+name) && len(key) > len("lfs.")+len(name)+1 {
+			return check, ""
+		}
+	}
+
+	if len(parts) == 2 {
+		if s := similarName(key, knownSettings); len(s) > 0 {
+			return nil, s
+		}
+		return nil, ""
+	}
+	last := parts[len(parts)-1]
+	if s := similarName(last, scopedSettings); len(s) > 0 {
+		return nil, strings.TrimSuffix(key, last) + s
+	}
+	if s := similarName(key, knownSettings); len(s) > 0 {
+		return nil, s
+	}
+	return nil, ""
+}
+
+// similarName returns the name in the given settings closest to the given
+// name, if it is close enough to be a likely misspelling of it.
+func similarName(name string, settings map[string]checkFunc) string {
+	best, bestDistance := "", 3
+	for candidate := range settings {
+		if d := editDistance(name, candidate); d < bestDistance || (d == bestDistance && len(best) > 0 && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAcceptsValidSettings(t *testing.T) {
+	cfg := NewFrom(Values{Git: map[string][]string{
+		"lfs.concurrenttransfers":                          []string{"4"},
+		"lfs.fetchinclude":                                 []string{"*.psd"},
+		"lfs.cache.maxsize":                                []string{"10GB"},
+		"lfs.tmpmaxage":                                    []string{"24h"},
+		"lfs.smudge.fallbackfetch":                         []string{"prompt"},
+		"lfs.origin.transfer.maxretries":                   []string{"3"},
+		"lfs.https://example.com/repo.git/info/lfs.access": []string{"basic"},
+		"lfs.customtransfer.agent.path":                    []string{"agent"},
+		"lfs.customtransfer.agent.direction":               []string{"upload"},
+		"lfs.standalonetransferagent":                      []string{"agent"},
+		"lfs.extension.foo.priority":                       []string{"0"},
+		"core.bogus":                                       []string{"ignored"},
+	}})
+
+	assert.Empty(t, cfg.Validate())
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	cfg := NewFrom(Values{Git: map[string][]string{
+		"lfs.concurentransfers":              []string{"4"},
+		"lfs.fetchrecentalways":              []string{"sometimes"},
+		"lfs.concurrenttransfers":            []string{"0"},
+		"lfs.hashalgorithm":                  []string{"md5"},
+		"lfs.https://example.com/.acess":     []string{"basic"},
+		"lfs.customtransfer.agent.args":      []string{"--flag"},
+		"lfs.basictransfersonly":             []string{"true"},
+		"lfs.tustransfers":                   []string{"true"},
+		"lfs.origin.standalonetransferagent": []string{"missing"},
+		"lfs.somethingentirelydifferent":     []string{"1"},
+	}})
+
+	messages := make(map[string]string)
+	for _, p := range cfg.Validate() {
+		messages[p.Key] = p.Message
+	}
+
+	assert.Equal(t, map[string]string{
+		"lfs.concurentransfers":              "unknown setting; did you mean lfs.concurrenttransfers?",
+		"lfs.fetchrecentalways":              `"sometimes" is not a boolean, and is treated as false`,
+		"lfs.concurrenttransfers":            "0 is less than 1, and is ignored",
+		"lfs.hashalgorithm":                  `"md5" is not one of sha256, sha512`,
+		"lfs.https://example.com/.acess":     "unknown setting; did you mean lfs.https://example.com/.access?",
+		"lfs.customtransfer.agent.args":      "has no effect without lfs.customtransfer.agent.path",
+		"lfs.tustransfers":                   "has no effect, since lfs.basictransfersonly is set",
+		"lfs.origin.standalonetransferagent": "names a transfer agent without lfs.customtransfer.missing.path",
+		"lfs.somethingentirelydifferent":     "unknown setting",
+	}, messages)
+}
+
+func TestValidateOrdersProblemsByKey(t *testing.T) {
+	cfg := NewFrom(Values{Git: map[string][]string{
+		"lfs.zzz": []string{"1"},
+		"lfs.aaa": []string{"1"},
+		"lfs.mmm": []string{"1"},
+	}})
+
+	problems := cfg.Validate()
+	if assert.Len(t, problems, 3) {
+		assert.Equal(t, "lfs.aaa", problems[0].Key)
+		assert.Equal(t, "lfs.mmm", problems[1].Key)
+		assert.Equal(t, "lfs.zzz", problems[2].Key)
+	}
+}
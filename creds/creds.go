@@ -11,9 +11,10 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/metrics"
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // CredentialHelperWrapper is used to contain the encapsulate the information we need for credential handling during auth.
@@ -42,12 +43,29 @@ func (credWrapper *CredentialHelperWrapper) FillCreds() error {
 		} else {
 			errmsg = fmt.Sprintf("%s.", errmsg)
 		}
-		err = errors.New(errmsg)
+		err = &NotFoundError{msg: errmsg}
 	}
 	credWrapper.Creds = creds
 	return err
 }
 
+// NotFoundError is returned by FillCreds when no credentials could be found
+// for a URL.  It is not an authentication error, since no request was made
+// with which the server could reject them.
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.msg
+}
+
+// ErrorCode returns errors.CodeAuth, since the request could not be made
+// without credentials.
+func (e *NotFoundError) ErrorCode() errors.Code {
+	return errors.CodeAuth
+}
+
 // Creds represents a set of key/value pairs that are passed to 'git credential'
 // as input.
 type Creds map[string]string
@@ -88,9 +106,10 @@ func NewCredentialHelperContext(gitEnv config.Environment, osEnv config.Environm
 	}
 	askpassfile, err := tools.TranslateCygwinPath(askpass)
 	if err != nil {
-		tracerx.Printf("Error reading askpass helper %q: %v", askpassfile, err)
+		tracelog.Printf("Error reading askpass helper %q: %v", askpassfile, err)
 	}
-	if len(askpassfile) > 0 {
+	nonInteractive := osEnv.Bool(config.NonInteractiveEnv, false)
+	if len(askpassfile) > 0 && !nonInteractive {
 		c.askpassCredHelper = &AskPassCredentialHelper{
 			Program: askpassfile,
 		}
@@ -102,7 +121,8 @@ func NewCredentialHelperContext(gitEnv config.Environment, osEnv config.Environm
 	}
 
 	c.commandCredHelper = &commandCredentialHelper{
-		SkipPrompt: osEnv.Bool("GIT_TERMINAL_PROMPT", false),
+		SkipPrompt:     osEnv.Bool("GIT_TERMINAL_PROMPT", false),
+		NonInteractive: nonInteractive,
 	}
 
 	return c
@@ -114,12 +134,12 @@ func NewCredentialHelperContext(gitEnv config.Environment, osEnv config.Environm
 // It returns an error if any configuration was invalid, or otherwise
 // un-useable.
 func (ctxt *CredentialHelperContext) GetCredentialHelper(helper CredentialHelper, u *url.URL) CredentialHelperWrapper {
-	rawurl := fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path)
+	rawurl := credentialConfigURL(u)
 	input := Creds{"protocol": u.Scheme, "host": u.Host}
 	if u.User != nil && u.User.Username() != "" {
 		input["username"] = u.User.Username()
 	}
-	if u.Scheme == "cert" || ctxt.urlConfig.Bool("credential", rawurl, "usehttppath", false) {
+	if ctxt.usesPath(u, rawurl) {
 		input["path"] = strings.TrimPrefix(u.Path, "/")
 	}
 
@@ -143,6 +163,31 @@ func (ctxt *CredentialHelperContext) GetCredentialHelper(helper CredentialHelper
 	return CredentialHelperWrapper{CredentialHelper: NewCredentialHelpers(append(helpers, ctxt.commandCredHelper)), Input: input, Url: u}
 }
 
+// credentialConfigURL returns the URL against which "credential.<url>.*"
+// configuration is matched for the given URL. Like Git, this includes the
+// username, so that configuration scoped to a particular user only applies
+// to that user, but never includes the password.
+func credentialConfigURL(u *url.URL) string {
+	var user string
+	if u.User != nil && u.User.Username() != "" {
+		user = url.PathEscape(u.User.Username()) + "@"
+	}
+	return fmt.Sprintf("%s://%s%s%s", u.Scheme, user, u.Host, u.Path)
+}
+
+// usesPath returns whether the path of the given URL should be passed to
+// credential helpers. As in Git, the path is always passed for protocols other
+// than HTTP and HTTPS, and only passed for HTTP and HTTPS when
+// "credential.useHttpPath" (or its URL-specific variant) is enabled.
+func (ctxt *CredentialHelperContext) usesPath(u *url.URL, rawurl string) bool {
+	switch u.Scheme {
+	case "http", "https":
+		return ctxt.urlConfig.Bool("credential", rawurl, "usehttppath", false)
+	default:
+		return true
+	}
+}
+
 // AskPassCredentialHelper implements the CredentialHelper type for GIT_ASKPASS
 // and 'core.askpass' configuration values.
 type AskPassCredentialHelper struct {
@@ -242,7 +287,7 @@ func (a *AskPassCredentialHelper) getFromProgram(valueType credValueType, u *url
 	cmd.Stderr = &err
 	cmd.Stdout = &value
 
-	tracerx.Printf("creds: filling with GIT_ASKPASS: %s", strings.Join(cmd.Args, " "))
+	tracelog.Printf("creds: filling with GIT_ASKPASS: %s", strings.Join(cmd.Args, " "))
 	if err := cmd.Run(); err != nil {
 		return "", err
 	}
@@ -276,10 +321,14 @@ func (a *AskPassCredentialHelper) args(prompt string) []string {
 
 type commandCredentialHelper struct {
 	SkipPrompt bool
+	// NonInteractive stops "git credential" from prompting on the terminal
+	// or with an askpass program, so that it fails instead if no
+	// credential helper has the credentials.
+	NonInteractive bool
 }
 
 func (h *commandCredentialHelper) Fill(creds Creds) (Creds, error) {
-	tracerx.Printf("creds: git credential fill (%q, %q, %q)",
+	tracelog.Printf("creds: git credential fill (%q, %q, %q)",
 		creds["protocol"], creds["host"], creds["path"])
 	return h.exec("fill", creds)
 }
@@ -290,7 +339,7 @@ func (h *commandCredentialHelper) Reject(creds Creds) error {
 }
 
 func (h *commandCredentialHelper) Approve(creds Creds) error {
-	tracerx.Printf("creds: git credential approve (%q, %q, %q)",
+	tracelog.Printf("creds: git credential approve (%q, %q, %q)",
 		creds["protocol"], creds["host"], creds["path"])
 	_, err := h.exec("approve", creds)
 	return err
@@ -301,6 +350,13 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 	cmd := subprocess.ExecCommand("git", "credential", subcommand)
 	cmd.Stdin = bufferCreds(input)
 	cmd.Stdout = output
+	if h.NonInteractive {
+		// An empty GIT_ASKPASS stops Git from falling back to
+		// core.askpass or SSH_ASKPASS, and GCM_INTERACTIVE stops Git
+		// Credential Manager from showing its own dialogs.
+		cmd.Env = append(append([]string(nil), cmd.Env...),
+			"GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "GCM_INTERACTIVE=never")
+	}
 	/*
 	   There is a reason we don't read from stderr here:
 	   Git's credential cache daemon helper does not close its stderr, so if this
@@ -319,6 +375,10 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 	}
 
 	if _, ok := err.(*exec.ExitError); ok {
+		if h.NonInteractive && subcommand == "fill" {
+			return nil, fmt.Errorf("credentials for %s://%s are required, but %s is set, so Git LFS will not prompt for them; configure a credential helper or a netrc file which provides them",
+				input["protocol"], input["host"], config.NonInteractiveEnv)
+		}
 		if h.SkipPrompt {
 			return nil, fmt.Errorf("change the GIT_TERMINAL_PROMPT env var to be prompted to enter your credentials for %s://%s",
 				input["protocol"], input["host"])
@@ -372,11 +432,13 @@ func (c *credentialCacher) Fill(what Creds) (Creds, error) {
 	c.mu.Unlock()
 
 	if ok {
-		tracerx.Printf("creds: git credential cache (%q, %q, %q)",
+		tracelog.Printf("creds: git credential cache (%q, %q, %q)",
 			what["protocol"], what["host"], what["path"])
+		metrics.Count("credentials.cache", 1, metrics.Tags{"result": "hit"})
 		return cached, nil
 	}
 
+	metrics.Count("credentials.cache", 1, metrics.Tags{"result": "miss"})
 	return nil, credHelperNoOp
 }
 
@@ -448,7 +510,7 @@ func (s *CredentialHelpers) Fill(what Creds) (Creds, error) {
 		if err != nil {
 			if err != credHelperNoOp {
 				s.skip(i)
-				tracerx.Printf("credential fill error: %s", err)
+				tracelog.Printf("credential fill error: %s", err)
 				errs = append(errs, err.Error())
 			}
 			continue
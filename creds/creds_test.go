@@ -2,8 +2,10 @@ package creds
 
 import (
 	"errors"
+	"net/url"
 	"testing"
 
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -264,3 +266,78 @@ func TestCredHelperSetAllFillErrors(t *testing.T) {
 	assert.Equal(t, 0, len(helper1.reject))
 	assert.Equal(t, 0, len(helper2.reject))
 }
+
+func TestGetCredentialHelperUseHttpPath(t *testing.T) {
+	for desc, c := range map[string]struct {
+		URL      string
+		Config   map[string]string
+		Expected Creds
+	}{
+		"http without usehttppath": {
+			URL:      "https://example.com/repo.git",
+			Expected: Creds{"protocol": "https", "host": "example.com"},
+		},
+		"http with usehttppath": {
+			URL:      "https://example.com/repo.git",
+			Config:   map[string]string{"credential.usehttppath": "true"},
+			Expected: Creds{"protocol": "https", "host": "example.com", "path": "repo.git"},
+		},
+		"http with url-specific usehttppath": {
+			URL:      "https://example.com/repo.git",
+			Config:   map[string]string{"credential.https://example.com.usehttppath": "true"},
+			Expected: Creds{"protocol": "https", "host": "example.com", "path": "repo.git"},
+		},
+		"http with url-specific usehttppath overriding global": {
+			URL: "https://example.com/repo.git",
+			Config: map[string]string{
+				"credential.usehttppath":                     "true",
+				"credential.https://example.com.usehttppath": "false",
+			},
+			Expected: Creds{"protocol": "https", "host": "example.com"},
+		},
+		"http with usehttppath for another user": {
+			URL:      "https://alice@example.com/repo.git",
+			Config:   map[string]string{"credential.https://bob@example.com.usehttppath": "true"},
+			Expected: Creds{"protocol": "https", "host": "example.com", "username": "alice"},
+		},
+		"http with usehttppath for the same user": {
+			URL:      "https://alice@example.com/repo.git",
+			Config:   map[string]string{"credential.https://alice@example.com.usehttppath": "true"},
+			Expected: Creds{"protocol": "https", "host": "example.com", "username": "alice", "path": "repo.git"},
+		},
+		"non-http always uses path": {
+			URL:      "cert:///path/to/cert.pem",
+			Expected: Creds{"protocol": "cert", "host": "", "path": "path/to/cert.pem"},
+		},
+	} {
+		m := make(map[string][]string)
+		for k, v := range c.Config {
+			m[k] = []string{v}
+		}
+		ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(m)),
+			config.EnvironmentOf(config.MapFetcher(nil)))
+
+		u, err := url.Parse(c.URL)
+		assert.Nil(t, err, desc)
+
+		wrapper := ctxt.GetCredentialHelper(nil, u)
+		assert.Equal(t, c.Expected, wrapper.Input, desc)
+	}
+}
+
+func TestNewCredentialHelperContextNonInteractive(t *testing.T) {
+	osEnv := config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"GIT_ASKPASS": []string{"askpass"},
+	}))
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(nil)), osEnv)
+	assert.NotNil(t, ctxt.askpassCredHelper)
+	assert.False(t, ctxt.commandCredHelper.NonInteractive)
+
+	osEnv = config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"GIT_ASKPASS":                  []string{"askpass"},
+		"GIT_LFS_FORCE_NONINTERACTIVE": []string{"1"},
+	}))
+	ctxt = NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(nil)), osEnv)
+	assert.Nil(t, ctxt.askpassCredHelper)
+	assert.True(t, ctxt.commandCredHelper.NonInteractive)
+}
@@ -8,8 +8,8 @@ import (
 	"sync"
 
 	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/go-netrc/netrc"
-	"github.com/rubyist/tracerx"
 )
 
 type NetrcFinder interface {
@@ -51,7 +51,7 @@ var defaultNetrcFinder = &noFinder{}
 func newNetrcCredentialHelper(osEnv config.Environment) *netrcCredentialHelper {
 	netrcFinder, netrcfile, err := ParseNetrc(osEnv)
 	if err != nil {
-		tracerx.Printf("bad netrc file %s: %s", netrcfile, err)
+		tracelog.Printf("bad netrc file %s: %s", netrcfile, err)
 		return nil
 	}
 
@@ -81,7 +81,7 @@ func (c *netrcCredentialHelper) Fill(what Creds) (Creds, error) {
 		creds["scheme"] = what["scheme"]
 		creds["path"] = what["path"]
 		creds["source"] = "netrc"
-		tracerx.Printf("netrc: git credential fill (%q, %q, %q)",
+		tracelog.Printf("netrc: git credential fill (%q, %q, %q)",
 			what["protocol"], what["host"], what["path"])
 		return creds, nil
 	}
@@ -93,7 +93,7 @@ func getNetrcHostname(hostname string) (string, error) {
 	if strings.Contains(hostname, ":") {
 		host, _, err := net.SplitHostPort(hostname)
 		if err != nil {
-			tracerx.Printf("netrc: error parsing %q: %s", hostname, err)
+			tracelog.Printf("netrc: error parsing %q: %s", hostname, err)
 			return "", err
 		}
 		return host, nil
@@ -108,7 +108,7 @@ func (c *netrcCredentialHelper) Approve(what Creds) error {
 		if err != nil {
 			return credHelperNoOp
 		}
-		tracerx.Printf("netrc: git credential approve (%q, %q, %q)",
+		tracelog.Printf("netrc: git credential approve (%q, %q, %q)",
 			what["protocol"], what["host"], what["path"])
 		c.mu.Lock()
 		c.skip[host] = false
@@ -125,7 +125,7 @@ func (c *netrcCredentialHelper) Reject(what Creds) error {
 			return credHelperNoOp
 		}
 
-		tracerx.Printf("netrc: git credential reject (%q, %q, %q)",
+		tracelog.Printf("netrc: git credential reject (%q, %q, %q)",
 			what["protocol"], what["host"], what["path"])
 		c.mu.Lock()
 		c.skip[host] = true
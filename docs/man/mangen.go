@@ -46,6 +46,132 @@ var (
 	verbose = flag.Bool("verbose", false, "Show verbose output.")
 )
 
+// manOption is an option documented by a man page, with the first sentence of
+// its description.
+type manOption struct {
+	flags   string
+	summary string
+}
+
+// manDoc is the structured documentation of a command, taken from the title,
+// SYNOPSIS and OPTIONS sections of its man page.
+type manDoc struct {
+	summary  string
+	synopsis []string
+	options  []*manOption
+}
+
+var (
+	titleregex = regexp.MustCompile(`^git-lfs(?:-[a-z\-]+)?\(\d\)\s+--?\s+(.*)$`)
+	// links to URLs, whose text is kept
+	urlregex = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// plain removes the markup of the given line of ronn text.
+func plain(line string) string {
+	line = urlregex.ReplaceAllString(line, "$1")
+	return strings.TrimSpace(strings.Replace(strings.Replace(line, "`", "", -1), "<br>", "", -1))
+}
+
+// firstSentence returns the first sentence of the given text.
+func firstSentence(text string) string {
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+// readManDoc reads the structured documentation of a command from its man
+// page.
+func readManDoc(path string) (*manDoc, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc := &manDoc{}
+	var section string
+	var option *manOption
+	var description []string
+	endOption := func() {
+		if option != nil {
+			option.summary = firstSentence(strings.TrimSpace(strings.Join(description, " ")))
+			doc.options = append(doc.options, option)
+		}
+		option, description = nil, nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first {
+			if match := titleregex.FindStringSubmatch(line); match != nil {
+				doc.summary = plain(match[1])
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			endOption()
+			section = strings.ToLower(strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+
+		switch section {
+		case "synopsis":
+			if text := plain(line); len(text) > 0 {
+				doc.synopsis = append(doc.synopsis, text)
+			}
+		case "options":
+			switch {
+			case strings.HasPrefix(line, "* "):
+				flags := strings.TrimSuffix(plain(line[2:]), ":")
+				if option != nil && len(description) == 0 {
+					// Options listed together share their
+					// description.
+					option.flags += " " + flags
+					continue
+				}
+				endOption()
+				option = &manOption{flags: flags}
+			case option == nil:
+			case len(strings.TrimSpace(line)) == 0:
+				// Only the first paragraph of the description
+				// is summarized.
+				if len(description) > 0 {
+					description = append(description, "")
+				}
+			case len(description) == 0 || description[len(description)-1] != "":
+				description = append(description, plain(line))
+			}
+		}
+	}
+	endOption()
+	return doc, scanner.Err()
+}
+
+// write writes the statement adding the documentation of the given command to
+// ManDocs.
+func (d *manDoc) write(out io.Writer, cmd string) {
+	fmt.Fprintf(out, "\tManDocs[%q] = &ManDoc{\n", cmd)
+	fmt.Fprintf(out, "\t\tSummary: %q,\n", d.summary)
+	if len(d.synopsis) > 0 {
+		fmt.Fprintf(out, "\t\tSynopsis: []string{\n")
+		for _, s := range d.synopsis {
+			fmt.Fprintf(out, "\t\t\t%q,\n", s)
+		}
+		fmt.Fprintf(out, "\t\t},\n")
+	}
+	if len(d.options) > 0 {
+		fmt.Fprintf(out, "\t\tOptions: []ManOption{\n")
+		for _, o := range d.options {
+			fmt.Fprintf(out, "\t\t\t{Flags: %q, Summary: %q},\n", o.flags, o.summary)
+		}
+		fmt.Fprintf(out, "\t\t},\n")
+	}
+	fmt.Fprintf(out, "\t}\n")
+}
+
 // Reads all .ronn files & and converts them to string literals
 // triggered by "go generate" comment
 // Literals are inserted into a map using an init function, this means
@@ -160,6 +286,13 @@ func main() {
 			}
 			out.WriteString("`\n")
 			contentf.Close()
+
+			doc, err := readManDoc(filepath.Join(manDir, f.Name()))
+			if err != nil {
+				warnf(os.Stderr, "Failed to read %v: %v\n", f.Name(), err)
+				os.Exit(2)
+			}
+			doc.write(out, cmd)
 			count++
 		}
 	}
@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// Code is the category of an error, which is reported in the JSON output of
+// commands and chooses their exit status, so that programs which run Git LFS
+// can tell classes of failure apart without matching error messages.  The
+// values of the Code constants are part of the JSON output, and must not be
+// changed.
+type Code string
+
+const (
+	// CodeNetwork is the code of errors connecting to, or communicating
+	// with, a server.
+	CodeNetwork Code = "network"
+	// CodeAuth is the code of errors for requests which the server
+	// rejected because of the credentials given, or which required
+	// credentials which could not be found.
+	CodeAuth Code = "auth"
+	// CodeNotFound is the code of errors for objects or repositories which
+	// the server does not have.
+	CodeNotFound Code = "not-found"
+	// CodeCorruption is the code of errors for objects or pointers which
+	// are missing or corrupt in the local repository.
+	CodeCorruption Code = "corruption"
+	// CodeLockConflict is the code of errors for files which could not be
+	// locked or unlocked because of another user's lock.
+	CodeLockConflict Code = "lock-conflict"
+	// CodeQuota is the code of errors for requests which the server
+	// refused because a storage or bandwidth quota was exceeded.
+	CodeQuota Code = "quota"
+	// CodePolicy is the code of errors for objects which were not
+	// transferred because a policy, such as the upload scanner, rejected
+	// them.
+	CodePolicy Code = "policy"
+)
+
+// ErrorCode returns the code of the given error, or an empty Code if it falls
+// into none of the categories.  The code is that given to the error, or to one
+// of the errors it wraps, with NewCodedError, or returned by an ErrorCode()
+// method of one of them; otherwise, authentication errors have the code
+// CodeAuth, and network errors the code CodeNetwork.
+func ErrorCode(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	if code := codeOf(err); len(code) > 0 {
+		return code
+	}
+	if e, ok := Cause(err).(interface {
+		ErrorCode() Code
+	}); ok && len(e.ErrorCode()) > 0 {
+		return e.ErrorCode()
+	}
+	if IsAuthError(err) {
+		return CodeAuth
+	}
+	if _, ok := Cause(err).(net.Error); ok {
+		return CodeNetwork
+	}
+	return ""
+}
+
+func codeOf(err error) Code {
+	if e, ok := err.(interface {
+		ErrorCode() Code
+	}); ok {
+		if code := e.ErrorCode(); len(code) > 0 {
+			return code
+		}
+	}
+	if parent := parentOf(err); parent != nil {
+		return codeOf(parent)
+	}
+	return ""
+}
+
+// Definitions for ErrorCode()
+
+type codedError struct {
+	*wrappedError
+	code Code
+}
+
+func (e codedError) ErrorCode() Code {
+	return e.code
+}
+
+// NewCodedError returns an error with the message of the given error, and
+// the given code.
+func NewCodedError(err error, code Code) error {
+	if err == nil {
+		err = errors.New("Error")
+	}
+
+	// parentOf() looks through two causes, as for errors.Wrap(), so that
+	// the error keeps its message, and the errors it wraps are still found.
+	ewc := errors.WithStack(errors.WithStack(err)).(errorWithCause)
+	return codedError{
+		wrappedError: &wrappedError{
+			errorWithCause: ewc,
+			context:        make(map[string]interface{}),
+		},
+		code: code,
+	}
+}
@@ -0,0 +1,66 @@
+package errors_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type quotaError struct{}
+
+func (e quotaError) Error() string {
+	return "quota exceeded"
+}
+
+func (e quotaError) ErrorCode() errors.Code {
+	return errors.CodeQuota
+}
+
+func TestErrorCodeOfNil(t *testing.T) {
+	assert.Equal(t, errors.Code(""), errors.ErrorCode(nil))
+}
+
+func TestErrorCodeOfPlainError(t *testing.T) {
+	assert.Equal(t, errors.Code(""), errors.ErrorCode(errors.New("plain")))
+}
+
+func TestCodedErrorKeepsMessage(t *testing.T) {
+	err := errors.NewCodedError(errors.New("locked"), errors.CodeLockConflict)
+
+	assert.Equal(t, "locked", err.Error())
+	assert.Equal(t, errors.CodeLockConflict, errors.ErrorCode(err))
+}
+
+func TestCodedErrorThroughWrapping(t *testing.T) {
+	err := errors.Wrap(errors.NewCodedError(errors.New("locked"), errors.CodeLockConflict), "api")
+
+	assert.Equal(t, "api: locked", err.Error())
+	assert.Equal(t, errors.CodeLockConflict, errors.ErrorCode(err))
+}
+
+func TestCodedErrorKeepsWrappedTypes(t *testing.T) {
+	err := errors.NewCodedError(errors.NewFatalError(errors.New("fatal")), errors.CodeQuota)
+
+	assert.True(t, errors.IsFatalError(err))
+	assert.Equal(t, errors.CodeQuota, errors.ErrorCode(err))
+}
+
+func TestErrorCodeFromMethodOfCause(t *testing.T) {
+	err := errors.Wrap(quotaError{}, "upload")
+
+	assert.Equal(t, errors.CodeQuota, errors.ErrorCode(err))
+}
+
+func TestErrorCodeOfAuthError(t *testing.T) {
+	err := errors.NewAuthError(errors.New("denied"))
+
+	assert.Equal(t, errors.CodeAuth, errors.ErrorCode(err))
+}
+
+func TestErrorCodeOfNetworkError(t *testing.T) {
+	err := errors.Wrap(&net.OpError{Op: "dial", Err: errors.New("refused")}, "connect")
+
+	assert.Equal(t, errors.CodeNetwork, errors.ErrorCode(err))
+}
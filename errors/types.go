@@ -90,6 +90,20 @@ func IsNotAPointerError(err error) bool {
 	return false
 }
 
+// IsMalformedPointerError indicates the parsed data appears to be an LFS
+// pointer, but could not be decoded as one.
+func IsMalformedPointerError(err error) bool {
+	if e, ok := err.(interface {
+		MalformedPointerError() bool
+	}); ok {
+		return e.MalformedPointerError()
+	}
+	if parent := parentOf(err); parent != nil {
+		return IsMalformedPointerError(parent)
+	}
+	return false
+}
+
 // IsNotAPointerError indicates the parsed data is not an LFS pointer.
 func IsPointerScanError(err error) bool {
 	if e, ok := err.(interface {
@@ -346,6 +360,20 @@ func NewNotAPointerError(err error) error {
 	return notAPointerError{newWrappedError(err, "Pointer file error")}
 }
 
+// Definitions for IsMalformedPointerError()
+
+type malformedPointerError struct {
+	*wrappedError
+}
+
+func (e malformedPointerError) MalformedPointerError() bool {
+	return true
+}
+
+func NewMalformedPointerError(err error) error {
+	return malformedPointerError{newWrappedError(err, "Malformed pointer")}
+}
+
 // Definitions for IsPointerScanError()
 
 type PointerScanError struct {
@@ -4,8 +4,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/wildmatch"
-	"github.com/rubyist/tracerx"
 )
 
 type Pattern interface {
@@ -19,10 +19,14 @@ type Filter struct {
 	include      []Pattern
 	exclude      []Pattern
 	defaultValue bool
+
+	includeIndex *patternIndex
+	excludeIndex *patternIndex
 }
 
 type options struct {
 	defaultValue bool
+	patterns     []patternOption
 }
 
 type option func(*options)
@@ -35,18 +39,38 @@ func DefaultValue(val bool) option {
 	}
 }
 
+// IgnoreCase is an option representing whether the patterns of a
+// filepathfilter match filenames without regard to case.  If this option is
+// not provided, patterns are case-insensitive only on systems whose filesystems
+// usually are, that is, Windows and macOS.
+func IgnoreCase(val bool) option {
+	return func(args *options) {
+		args.patterns = append(args.patterns, CaseFold(val))
+	}
+}
+
 func NewFromPatterns(include, exclude []Pattern, setters ...option) *Filter {
 	args := &options{defaultValue: true}
 	for _, setter := range setters {
 		setter(args)
 	}
-	return &Filter{include: include, exclude: exclude, defaultValue: args.defaultValue}
+	return &Filter{
+		include:      include,
+		exclude:      exclude,
+		defaultValue: args.defaultValue,
+		includeIndex: newPatternIndex(include),
+		excludeIndex: newPatternIndex(exclude),
+	}
 }
 
 func New(include, exclude []string, setters ...option) *Filter {
+	args := &options{}
+	for _, setter := range setters {
+		setter(args)
+	}
 	return NewFromPatterns(
-		convertToWildmatch(include),
-		convertToWildmatch(exclude), setters...)
+		convertToWildmatch(include, args.patterns...),
+		convertToWildmatch(exclude, args.patterns...), setters...)
 }
 
 // Include returns the result of calling String() on each Pattern in the
@@ -73,15 +97,10 @@ func (f *Filter) Allows(filename string) bool {
 		return true
 	}
 
-	var included bool
-	for _, inc := range f.include {
-		if included = inc.Match(filename); included {
-			break
-		}
-	}
+	included := f.includeIndex.match(filename) != nil
 
 	if !included && len(f.include) > 0 {
-		tracerx.Printf("filepathfilter: rejecting %q via %v", filename, f.include)
+		tracelog.Printf("filepathfilter: rejecting %q via %v", filename, f.include)
 		return false
 	}
 
@@ -90,30 +109,53 @@ func (f *Filter) Allows(filename string) bool {
 	// traversing the exclude patterns because the return value will always
 	// be false; we'd do extra work for no functional benefit.
 	if !included && !f.defaultValue {
-		tracerx.Printf("filepathfilter: rejecting %q", filename)
+		tracelog.Printf("filepathfilter: rejecting %q", filename)
 		return false
 	}
 
-	for _, ex := range f.exclude {
-		if ex.Match(filename) {
-			tracerx.Printf("filepathfilter: rejecting %q via %q", filename, ex.String())
-			return false
-		}
+	if ex := f.excludeIndex.match(filename); ex != nil {
+		tracelog.Printf("filepathfilter: rejecting %q via %q", filename, ex.String())
+		return false
 	}
 
 	// No patterns matched and our default value is true.
-	tracerx.Printf("filepathfilter: accepting %q", filename)
+	tracelog.Printf("filepathfilter: accepting %q", filename)
 	return true
 }
 
+// negation is a pattern given as "!pattern" in a set of patterns, which
+// excludes the filenames it matches from those matched by earlier patterns.
+type negation struct {
+	Pattern
+}
+
+func (n *negation) String() string {
+	return "!" + n.Pattern.String()
+}
+
 type wm struct {
-	w    *wildmatch.Wildmatch
-	p    string
-	dirs bool
+	w *wildmatch.Wildmatch
+	// fold indicates that filenames are matched against the lower-cased
+	// pattern in lower case.
+	fold bool
+	// key is the key under which the pattern is found in a patternIndex.
+	key indexKey
+	// contents, if non-nil, matches the files beneath the directories
+	// matched by w.
+	contents *wildmatch.Wildmatch
+	p        string
+	dirs     bool
 }
 
 func (w *wm) Match(filename string) bool {
-	return w.w.Match(w.chomp(filename))
+	filename = w.chomp(filename)
+	if w.fold {
+		filename = strings.ToLower(filename)
+	}
+	if w.w.Match(filename) {
+		return true
+	}
+	return w.contents != nil && w.contents.Match(filename)
 }
 
 func (w *wm) chomp(filename string) string {
@@ -129,9 +171,14 @@ const (
 )
 
 type patternOptions struct {
-	strict bool
+	strict   bool
+	caseFold bool
 }
 
+// systemCaseFold indicates whether patterns match without regard to case by
+// default, as they do on systems with case-insensitive filesystems.
+var systemCaseFold = wildmatch.NewWildmatch("a", wildmatch.SystemCase).Match("A")
+
 type patternOption func(*patternOptions)
 
 // Strict is an option representing whether to strictly match wildmatch patterns
@@ -143,8 +190,17 @@ func Strict(val bool) patternOption {
 	}
 }
 
+// CaseFold is an option representing whether a pattern matches filenames
+// without regard to case.  If disabled, case is significant even on systems
+// with case-insensitive filesystems.
+func CaseFold(val bool) patternOption {
+	return func(args *patternOptions) {
+		args.caseFold = val
+	}
+}
+
 func NewPattern(p string, setters ...patternOption) Pattern {
-	args := &patternOptions{strict: false}
+	args := &patternOptions{strict: false, caseFold: systemCaseFold}
 	for _, setter := range setters {
 		setter(args)
 	}
@@ -152,8 +208,14 @@ func NewPattern(p string, setters ...patternOption) Pattern {
 	pp := p
 
 	dirs := strings.Contains(pp, string(sep))
+	var contents string
 
-	if !args.strict {
+	if args.strict {
+		// As in a .gitattributes file, a pattern without a directory
+		// separator matches the basename of a path at any depth, and a
+		// leading separator anchors the pattern to the root.
+		pp = strings.TrimPrefix(pp, string(sep))
+	} else {
 
 		// Special case: the below patterns match anything according to existing
 		// behavior.
@@ -176,6 +238,10 @@ func NewPattern(p string, setters ...patternOption) Pattern {
 				// rewrite "pp" as a substring match.
 				if !wild {
 					pp = join("**", pp, "**")
+				} else if !strings.HasSuffix(pp, "**") {
+					// Also match the contents of any
+					// directories the pattern matches.
+					contents = join(pp, "**")
 				}
 			} else {
 				if rooted {
@@ -192,16 +258,30 @@ func NewPattern(p string, setters ...patternOption) Pattern {
 			}
 		}
 	}
-	tracerx.Printf("filepathfilter: rewrite %q as %q (strict: %v)", p, pp, args.strict)
+	tracelog.Printf("filepathfilter: rewrite %q as %q (strict: %v, case fold: %v)", p, pp, args.strict, args.caseFold)
+
+	if args.caseFold {
+		pp, contents = strings.ToLower(pp), strings.ToLower(contents)
+	}
 
-	return &wm{
-		p: p,
-		w: wildmatch.NewWildmatch(
-			pp,
-			wildmatch.SystemCase,
-		),
+	w := &wm{
+		p:    p,
+		fold: args.caseFold,
+		key:  newIndexKey(p, args.strict, strings.Contains(p, string(sep))),
 		dirs: dirs,
 	}
+	if args.caseFold {
+		w.key.s = strings.ToLower(w.key.s)
+	}
+	if args.strict && !dirs {
+		w.w = wildmatch.NewWildmatch(pp, wildmatch.Basename)
+	} else {
+		w.w = wildmatch.NewWildmatch(pp)
+	}
+	if len(contents) > 0 {
+		w.contents = wildmatch.NewWildmatch(contents)
+	}
+	return w
 }
 
 // join joins path elements together via the separator "sep" and produces valid
@@ -220,10 +300,24 @@ func join(paths ...string) string {
 	return joined
 }
 
+// convertToWildmatch converts each of the given patterns, which may be negated
+// by a leading "!", to a Pattern.  A leading "!" which is part of the pattern
+// must be escaped as "\!".
 func convertToWildmatch(rawpatterns []string, setters ...patternOption) []Pattern {
 	patterns := make([]Pattern, len(rawpatterns))
 	for i, raw := range rawpatterns {
-		patterns[i] = NewPattern(raw, setters...)
+		switch {
+		case strings.HasPrefix(raw, "!"):
+			patterns[i] = &negation{NewPattern(raw[1:], setters...)}
+		case strings.HasPrefix(raw, `\!`):
+			p := NewPattern(raw[1:], setters...)
+			// Report the pattern as given, so that it is not
+			// mistaken for a negation.
+			p.(*wm).p = raw
+			patterns[i] = p
+		default:
+			patterns[i] = NewPattern(raw, setters...)
+		}
 	}
 	return patterns
 }
@@ -106,6 +106,49 @@ func TestPatternMatch(t *testing.T) {
 	assertPatternMatch(t, ".", "path.txt")
 	assertPatternMatch(t, "./", "path.txt")
 	assertPatternMatch(t, ".\\", "path.txt")
+
+	// Character classes, escapes and "**"
+	assertPatternMatch(t, "file[0-9].txt", "file1.txt", "sub/file1.txt")
+	refutePatternMatch(t, "file[0-9].txt", "filea.txt")
+	assertPatternMatch(t, "file[[:digit:]].txt", "sub/file1.txt")
+	assertPatternMatch(t, "file[!0-9].txt", "filea.txt")
+	assertPatternMatch(t, "file?.txt", "sub/file1.txt")
+	assertPatternMatch(t, `\*.txt`, "*.txt")
+	refutePatternMatch(t, `\*.txt`, "a.txt")
+	assertPatternMatch(t, "a/**/b", "a/b", "a/x/y/b", "a/x/y/b/c.txt")
+	refutePatternMatch(t, "a/**/b", "a/x/bc.txt")
+	assertPatternMatch(t, "sub/*", "sub/a.txt", "sub/dir/a.txt")
+}
+
+func TestStrictPatternMatch(t *testing.T) {
+	// Patterns match as they would in a .gitattributes file.
+	assertStrictPatternMatch(t, "*.txt", "a.txt", "sub/a.txt", "a/b/c.txt")
+	refuteStrictPatternMatch(t, "*.txt", "a.txt/b.dat")
+	assertStrictPatternMatch(t, "file[0-9].txt", "file1.txt", "sub/file1.txt")
+	refuteStrictPatternMatch(t, "file[0-9].txt", "filea.txt")
+	assertStrictPatternMatch(t, "file?.txt", "sub/file1.txt")
+	assertStrictPatternMatch(t, "sub/*.txt", "sub/a.txt")
+	refuteStrictPatternMatch(t, "sub/*.txt", "top/sub/a.txt", "sub/dir/a.txt")
+	assertStrictPatternMatch(t, "/a.txt", "a.txt")
+	refuteStrictPatternMatch(t, "/a.txt", "sub/a.txt")
+	assertStrictPatternMatch(t, "a/**/b.txt", "a/b.txt", "a/x/y/b.txt")
+	assertStrictPatternMatch(t, "**/b.txt", "b.txt", "a/x/b.txt")
+	assertStrictPatternMatch(t, `\[ab\].txt`, "[ab].txt")
+	refuteStrictPatternMatch(t, `\[ab\].txt`, "a.txt")
+}
+
+func assertStrictPatternMatch(t *testing.T, pattern string, filenames ...string) {
+	p := NewPattern(pattern, Strict(true))
+	for _, filename := range filenames {
+		assert.True(t, p.Match(filename), "%q should match strict pattern %q", filename, pattern)
+	}
+}
+
+func refuteStrictPatternMatch(t *testing.T, pattern string, filenames ...string) {
+	p := NewPattern(pattern, Strict(true))
+	for _, filename := range filenames {
+		assert.False(t, p.Match(filename), "%q should not match strict pattern %q", filename, pattern)
+	}
 }
 
 func assertPatternMatch(t *testing.T, pattern string, filenames ...string) {
@@ -140,3 +183,73 @@ func TestFilterReportsExcludePatterns(t *testing.T) {
 
 	assert.Equal(t, []string{"*.baz", "*.quux"}, filter.Exclude())
 }
+
+func TestFilterNegatedIncludePatterns(t *testing.T) {
+	filter := New([]string{"assets/", "!assets/previews/"}, nil)
+
+	assert.True(t, filter.Allows("assets/model.bin"))
+	assert.True(t, filter.Allows("assets/textures/wood.png"))
+	assert.False(t, filter.Allows("assets/previews/model.png"))
+	assert.False(t, filter.Allows("docs/readme.md"))
+}
+
+func TestFilterNegatedExcludePatterns(t *testing.T) {
+	filter := New(nil, []string{"*.psd", "!keep/*.psd"})
+
+	assert.False(t, filter.Allows("art/cover.psd"))
+	assert.True(t, filter.Allows("keep/cover.psd"))
+	assert.True(t, filter.Allows("art/cover.png"))
+}
+
+func TestFilterNegatedPatternsAreOrdered(t *testing.T) {
+	// The last pattern to match a filename decides whether it matches.
+	filter := New([]string{"assets/", "!assets/previews/", "assets/previews/hero.png"}, nil)
+
+	assert.True(t, filter.Allows("assets/model.bin"))
+	assert.False(t, filter.Allows("assets/previews/model.png"))
+	assert.True(t, filter.Allows("assets/previews/hero.png"))
+
+	// A negation only excludes what earlier patterns matched.
+	filter = New([]string{"!assets/previews/", "assets/"}, nil)
+
+	assert.True(t, filter.Allows("assets/previews/model.png"))
+}
+
+func TestFilterEscapedNegationPattern(t *testing.T) {
+	filter := New([]string{`\!important.txt`}, nil)
+
+	assert.True(t, filter.Allows("!important.txt"))
+	assert.False(t, filter.Allows("important.txt"))
+	assert.Equal(t, []string{`\!important.txt`}, filter.Include())
+}
+
+func TestFilterReportsNegatedPatterns(t *testing.T) {
+	filter := New([]string{"assets/", "!assets/previews/"}, []string{"*.psd", "!keep/*.psd"})
+
+	assert.Equal(t, []string{"assets/", "!assets/previews/"}, filter.Include())
+	assert.Equal(t, []string{"*.psd", "!keep/*.psd"}, filter.Exclude())
+}
+
+func TestFilterIgnoreCase(t *testing.T) {
+	filter := New([]string{"*.png", "Assets/"}, []string{"assets/Raw/"}, IgnoreCase(true))
+
+	assert.True(t, filter.Allows("image.PNG"))
+	assert.True(t, filter.Allows("sub/Image.Png"))
+	assert.True(t, filter.Allows("assets/model.bin"))
+	assert.False(t, filter.Allows("ASSETS/RAW/model.bin"))
+	assert.Equal(t, []string{"*.png", "Assets/"}, filter.Include())
+}
+
+func TestFilterRespectCase(t *testing.T) {
+	filter := New([]string{"*.png"}, nil, IgnoreCase(false))
+
+	assert.True(t, filter.Allows("image.png"))
+	assert.False(t, filter.Allows("image.PNG"))
+}
+
+func TestStrictPatternCaseFold(t *testing.T) {
+	p := NewPattern("*.PNG", Strict(true), CaseFold(true))
+
+	assert.True(t, p.Match("sub/image.png"))
+	assert.False(t, NewPattern("*.PNG", Strict(true), CaseFold(false)).Match("sub/image.png"))
+}
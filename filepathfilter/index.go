@@ -0,0 +1,194 @@
+package filepathfilter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// keyKind describes how a pattern with an index key matches filenames.
+type keyKind int
+
+const (
+	// noKey patterns must be matched with their wildmatch.
+	noKey keyKind = iota
+	// basenameKey patterns match filenames whose basename is the key.
+	basenameKey
+	// suffixKey patterns match filenames whose basename ends with the key.
+	suffixKey
+	// componentKey patterns match filenames with a directory or basename
+	// which is the key.
+	componentKey
+)
+
+// indexKey is the key under which a pattern is looked up in a patternIndex,
+// for patterns simple enough to be matched without their wildmatch, such as
+// "*.psd" or "build".
+type indexKey struct {
+	kind keyKind
+	s    string
+}
+
+// newIndexKey returns the index key of the pattern "p", after the leading "!"
+// of a negation is removed, given whether it is strict and has no directory
+// separators.
+func newIndexKey(p string, strict, dirs bool) indexKey {
+	if dirs || strings.HasSuffix(p, string(sep)) {
+		return indexKey{}
+	}
+
+	if strings.HasPrefix(p, "*") {
+		if lit := p[1:]; len(lit) > 0 && !hasMeta(lit) {
+			return indexKey{kind: suffixKey, s: lit}
+		}
+		return indexKey{}
+	}
+
+	switch {
+	case hasMeta(p), p == "", p == ".":
+		return indexKey{}
+	case strict:
+		return indexKey{kind: basenameKey, s: p}
+	default:
+		return indexKey{kind: componentKey, s: p}
+	}
+}
+
+// hasMeta returns whether the given pattern contains any wildmatch
+// metacharacters.
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// patternIndex matches filenames against an ordered set of patterns without
+// trying each pattern in turn.  Patterns with an index key are found with a
+// constant number of map lookups per filename component, so that matching is
+// fast even with thousands of patterns; the remaining patterns are matched
+// with their wildmatch.
+type patternIndex struct {
+	patterns []Pattern
+
+	exact  *keyLookup
+	folded *keyLookup
+	others []int
+}
+
+// keyLookup maps the index keys of patterns to their indices in the set.
+type keyLookup struct {
+	basenames  map[string][]int
+	suffixes   map[string][]int
+	components map[string][]int
+}
+
+func newKeyLookup() *keyLookup {
+	return &keyLookup{
+		basenames:  make(map[string][]int),
+		suffixes:   make(map[string][]int),
+		components: make(map[string][]int),
+	}
+}
+
+func (l *keyLookup) empty() bool {
+	return len(l.basenames)+len(l.suffixes)+len(l.components) == 0
+}
+
+func newPatternIndex(patterns []Pattern) *patternIndex {
+	idx := &patternIndex{
+		patterns: patterns,
+		exact:    newKeyLookup(),
+		folded:   newKeyLookup(),
+	}
+
+	for i, p := range patterns {
+		if neg, ok := p.(*negation); ok {
+			p = neg.Pattern
+		}
+
+		w, ok := p.(*wm)
+		if !ok || w.key.kind == noKey {
+			idx.others = append(idx.others, i)
+			continue
+		}
+
+		lookup := idx.exact
+		if w.fold {
+			lookup = idx.folded
+		}
+		switch w.key.kind {
+		case basenameKey:
+			lookup.basenames[w.key.s] = append(lookup.basenames[w.key.s], i)
+		case suffixKey:
+			lookup.suffixes[w.key.s] = append(lookup.suffixes[w.key.s], i)
+		case componentKey:
+			lookup.components[w.key.s] = append(lookup.components[w.key.s], i)
+		}
+	}
+	return idx
+}
+
+// match returns the pattern which decides that the given filename matches the
+// set of patterns, or nil if it does not.  As in a .gitignore file, the last
+// pattern to match the filename wins, so that a negated pattern (one given as
+// "!pattern") excludes filenames matched by earlier patterns, and a later
+// pattern may match them again.
+func (idx *patternIndex) match(filename string) Pattern {
+	if len(idx.patterns) == 0 {
+		return nil
+	}
+
+	filename = strings.TrimSuffix(filename, string(filepath.Separator))
+
+	matches := idx.exact.find(filename, nil)
+	if !idx.folded.empty() {
+		matches = idx.folded.find(strings.ToLower(filename), matches)
+	}
+	for _, i := range idx.others {
+		if idx.patterns[i].Match(filename) {
+			matches = append(matches, i)
+		}
+	}
+	sort.Ints(matches)
+
+	var matched Pattern
+	for _, i := range matches {
+		p := idx.patterns[i]
+		if _, negated := p.(*negation); negated {
+			matched = nil
+			continue
+		}
+		if matched == nil {
+			matched = p
+		}
+	}
+	return matched
+}
+
+// find appends the indices of the patterns in the lookup which match the given
+// filename to "matches".
+func (l *keyLookup) find(filename string, matches []int) []int {
+	if l.empty() {
+		return matches
+	}
+
+	base := filename
+	if i := strings.LastIndexByte(filename, sep); i >= 0 {
+		base = filename[i+1:]
+	}
+
+	matches = append(matches, l.basenames[base]...)
+	if len(l.suffixes) > 0 {
+		for i := range base {
+			matches = append(matches, l.suffixes[base[i:]]...)
+		}
+	}
+	if len(l.components) > 0 {
+		seen := make(map[string]bool)
+		for _, component := range strings.Split(filename, string(sep)) {
+			if !seen[component] {
+				seen[component] = true
+				matches = append(matches, l.components[component]...)
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,94 @@
+package filepathfilter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// matchInOrder matches the filename against each of the patterns in turn, as
+// a patternIndex does without its lookup tables.
+func matchInOrder(patterns []Pattern, filename string) Pattern {
+	var matched Pattern
+	for _, p := range patterns {
+		if _, negated := p.(*negation); negated {
+			if matched != nil && p.Match(filename) {
+				matched = nil
+			}
+			continue
+		}
+		if matched == nil && p.Match(filename) {
+			matched = p
+		}
+	}
+	return matched
+}
+
+func TestPatternIndexKeys(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		strict  bool
+		key     indexKey
+	}{
+		{"*.psd", false, indexKey{suffixKey, ".psd"}},
+		{"*.psd", true, indexKey{suffixKey, ".psd"}},
+		{"build", false, indexKey{componentKey, "build"}},
+		{"build", true, indexKey{basenameKey, "build"}},
+		{"*", false, indexKey{}},
+		{".", false, indexKey{}},
+		{"./", false, indexKey{}},
+		{`.\`, false, indexKey{}},
+		{"a/*.psd", false, indexKey{}},
+		{"build/", false, indexKey{}},
+		{"file[0-9]", false, indexKey{}},
+		{"*.ps?", false, indexKey{}},
+		{`*\*`, false, indexKey{}},
+	} {
+		p := NewPattern(test.pattern, Strict(test.strict), CaseFold(false))
+		assert.Equal(t, test.key, p.(*wm).key, "%q (strict: %v)", test.pattern, test.strict)
+	}
+}
+
+func TestPatternIndexMatchesInOrder(t *testing.T) {
+	raw := []string{
+		"*.psd", "!keep/*.psd", "build", "*.PNG", "a/**/b", "file[0-9].txt",
+		"!build/keep", "docs/", "*", "/top", "\\!bang", "keep/*.psd", "!*.jpg",
+		"*.jpg", "!x.jpg",
+	}
+	filenames := []string{
+		"a.psd", "keep/a.psd", "deep/keep/a.psd", "build", "build/x", "x/build/y",
+		"build/keep", "image.png", "image.PNG", "a/b", "a/x/b/c", "file1.txt",
+		"sub/file1.txt", "docs/readme.md", "top/x", "x/top", "!bang", "sub/!bang",
+		"x.jpg", "y.jpg", "sub/x.jpg", "builder", "a.psd/", "/abs/path/a.psd",
+	}
+
+	for _, strict := range []bool{false, true} {
+		for _, fold := range []bool{false, true} {
+			for n := 1; n <= len(raw); n++ {
+				patterns := convertToWildmatch(raw[:n], Strict(strict), CaseFold(fold))
+				idx := newPatternIndex(patterns)
+
+				for _, filename := range filenames {
+					expected := matchInOrder(patterns, filename)
+					actual := idx.match(filename)
+					assert.Equal(t, expected, actual,
+						"%q against %q (strict: %v, fold: %v)", filename, raw[:n], strict, fold)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFilterManyPatterns(b *testing.B) {
+	include := make([]string, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		include = append(include, fmt.Sprintf("*.ext%d", i), fmt.Sprintf("dir%d", i))
+	}
+	filter := New(include, []string{"*.tmp", "build"})
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		filter.Allows("some/deep/path/to/dir999/file.ext500")
+	}
+}
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,10 +9,116 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
+const (
+	// DefaultTempMaxAge is the age beyond which files in the temporary
+	// directory are removed, unless configured otherwise.
+	DefaultTempMaxAge = time.Hour
+
+	// DefaultIncompleteMaxAge is the age beyond which partially downloaded
+	// objects are removed, unless configured otherwise.
+	DefaultIncompleteMaxAge = 7 * 24 * time.Hour
+
+	// staleCleanupInterval is how often CleanupStaleIfDue removes stale
+	// files.
+	staleCleanupInterval = 24 * time.Hour
+)
+
+// CleanupStats counts the files removed by a cleanup, and their total size.
+type CleanupStats struct {
+	Files int
+	Size  int64
+}
+
+func (s *CleanupStats) remove(path string, info os.FileInfo) {
+	if err := os.RemoveAll(path); err != nil {
+		tracelog.Printf("Unable to remove %s: %s", path, err)
+		return
+	}
+	s.Files++
+	s.Size += info.Size()
+}
+
+// CleanupStale removes stale files from the temporary directory, and partially
+// downloaded objects older than f.IncompleteMaxAge, which are left behind by
+// interrupted or crashed commands.
+func (f *Filesystem) CleanupStale() (CleanupStats, error) {
+	var stats CleanupStats
+	if err := f.cleanupTmpFiles(&stats); err != nil {
+		return stats, err
+	}
+	if err := f.cleanupIncomplete(&stats); err != nil {
+		return stats, err
+	}
+
+	stamp := f.cleanupStampPath()
+	now := time.Now()
+	if err := os.Chtimes(stamp, now, now); os.IsNotExist(err) {
+		if file, err := os.Create(stamp); err == nil {
+			file.Close()
+		}
+	}
+	return stats, nil
+}
+
+// CleanupStaleIfDue calls CleanupStale if it has not been called within the
+// last day, so that it can be run cheaply whenever a command starts.
+func (f *Filesystem) CleanupStaleIfDue() (CleanupStats, error) {
+	if fi, err := os.Stat(f.cleanupStampPath()); err == nil && time.Since(fi.ModTime()) < staleCleanupInterval {
+		return CleanupStats{}, nil
+	}
+	return f.CleanupStale()
+}
+
+func (f *Filesystem) cleanupStampPath() string {
+	return filepath.Join(f.LFSStorageDir, "cleanup-stamp")
+}
+
+func (f *Filesystem) tempMaxAge() time.Duration {
+	if f.TempMaxAge > 0 {
+		return f.TempMaxAge
+	}
+	return DefaultTempMaxAge
+}
+
+func (f *Filesystem) incompleteMaxAge() time.Duration {
+	if f.IncompleteMaxAge > 0 {
+		return f.IncompleteMaxAge
+	}
+	return DefaultIncompleteMaxAge
+}
+
+// cleanupIncomplete removes partially downloaded objects older than
+// f.IncompleteMaxAge.
+func (f *Filesystem) cleanupIncomplete(stats *CleanupStats) error {
+	dir := filepath.Join(f.LFSStorageDir, "incomplete")
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	maxAge := f.incompleteMaxAge()
+	for _, info := range entries {
+		if info.IsDir() || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+
+		path := filepath.Join(dir, info.Name())
+		tracelog.Printf("Removing old incomplete object file: %s", path)
+		stats.remove(path, info)
+	}
+	return nil
+}
+
 func (f *Filesystem) cleanupTmp() error {
+	return f.cleanupTmpFiles(&CleanupStats{})
+}
+
+func (f *Filesystem) cleanupTmpFiles(stats *CleanupStats) error {
 	tmpdir := f.TempDir()
 	if len(tmpdir) == 0 {
 		return nil
@@ -22,7 +129,9 @@ func (f *Filesystem) cleanupTmp() error {
 		return nil
 	}
 
+	maxAge := f.tempMaxAge()
 	traversedDirectories := &sync.Map{}
+	var mu sync.Mutex
 
 	var walkErr error
 	tools.FastWalkDir(tmpdir, func(parentDir string, info os.FileInfo, err error) {
@@ -39,16 +148,17 @@ func (f *Filesystem) cleanupTmp() error {
 		}
 		parts := strings.SplitN(info.Name(), "-", 2)
 		oid := parts[0]
-		if len(parts) == 2 && len(oid) == 64 {
-			fi, err := os.Stat(f.ObjectPathname(oid))
-			if err == nil && !fi.IsDir() {
-				tracerx.Printf("Removing existing tmp object file: %s", path)
-				os.RemoveAll(path)
+		if len(parts) == 2 && (len(oid) == 64 || len(oid) == 128) {
+			if _, _, err := ObjectFileSize(f.ObjectPathname(oid)); err == nil {
+				tracelog.Printf("Removing existing tmp object file: %s", path)
+				mu.Lock()
+				stats.remove(path, info)
+				mu.Unlock()
 				return
 			}
 		}
 
-		// Don't prune items in a directory younger than an hour.  These
+		// Don't prune items in a directory younger than maxAge.  These
 		// items could be hard links to files from other repositories,
 		// which would have an older timestamp but which are still in
 		// use by some active process.  Exempt the main temporary from
@@ -67,14 +177,16 @@ func (f *Filesystem) cleanupTmp() error {
 				traversedDirectories.Store(path, dirInfo)
 			}
 
-			if time.Since(dirInfo.ModTime()) <= time.Hour {
+			if time.Since(dirInfo.ModTime()) <= maxAge {
 				return
 			}
 		}
 
-		if time.Since(info.ModTime()) > time.Hour {
-			tracerx.Printf("Removing old tmp object file: %s", path)
-			os.RemoveAll(path)
+		if time.Since(info.ModTime()) > maxAge {
+			tracelog.Printf("Removing old tmp object file: %s", path)
+			mu.Lock()
+			stats.remove(path, info)
+			mu.Unlock()
 			return
 		}
 	})
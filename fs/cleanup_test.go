@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-cleanup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), IncompleteMaxAge: 24 * time.Hour, repoPerms: 0644}
+	incomplete := filepath.Join(f.LFSStorageDir, "incomplete")
+	require.Nil(t, os.MkdirAll(incomplete, 0755))
+
+	old := time.Now().Add(-48 * time.Hour)
+	writeFile := func(path, contents string, mtime time.Time) {
+		require.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		require.Nil(t, os.Chtimes(path, mtime, mtime))
+	}
+	writeFile(filepath.Join(f.TempDir(), "stale"), "abc", old)
+	writeFile(filepath.Join(f.TempDir(), "fresh"), "abc", time.Now())
+	writeFile(filepath.Join(incomplete, "stale"), "abcdef", old)
+	writeFile(filepath.Join(incomplete, "fresh"), "abcdef", time.Now())
+
+	stats, err := f.CleanupStaleIfDue()
+	require.Nil(t, err)
+	assert.Equal(t, CleanupStats{Files: 2, Size: 9}, stats)
+
+	assert.FileExists(t, filepath.Join(f.TempDir(), "fresh"))
+	assert.FileExists(t, filepath.Join(incomplete, "fresh"))
+	_, err = os.Stat(filepath.Join(incomplete, "stale"))
+	assert.True(t, os.IsNotExist(err))
+
+	writeFile(filepath.Join(incomplete, "stale"), "abcdef", old)
+	stats, err = f.CleanupStaleIfDue()
+	require.Nil(t, err)
+	assert.Equal(t, CleanupStats{}, stats)
+	assert.FileExists(t, filepath.Join(incomplete, "stale"))
+
+	stats, err = f.CleanupStale()
+	require.Nil(t, err)
+	assert.Equal(t, CleanupStats{Files: 1, Size: 6}, stats)
+}
@@ -0,0 +1,294 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedSuffix is appended to the name of an object's file in the local
+// object store when the object is stored compressed, so that whether it is
+// compressed is never guessed from its contents.
+const compressedSuffix = ".zst"
+
+// compressedMagic begins every compressed object file. It is followed by the
+// size of the uncompressed object, as a 64-bit big-endian integer, and then by
+// the object's zstd-compressed contents.
+const compressedMagic = "git-lfs-zstd\x00"
+
+const compressedHeaderSize = len(compressedMagic) + 8
+
+// CompressedObjectPath returns the path of the file which holds the object
+// stored uncompressed at "path" once it is compressed.
+func CompressedObjectPath(path string) string {
+	return path + compressedSuffix
+}
+
+// ObjectFile returns the path of the file holding the object which is stored
+// at "path", or compressed alongside it, and whether it is compressed. An
+// uncompressed object is preferred. If neither exists, the error satisfies
+// os.IsNotExist.
+func ObjectFile(path string) (string, bool, error) {
+	_, err := os.Stat(path)
+	if !os.IsNotExist(err) {
+		return path, false, err
+	}
+
+	cpath := CompressedObjectPath(path)
+	if _, cerr := os.Stat(cpath); cerr != nil {
+		if os.IsNotExist(cerr) {
+			return "", false, err
+		}
+		return "", false, cerr
+	}
+	return cpath, true, nil
+}
+
+// ObjectFileSize returns the size of the object stored at "path", or
+// compressed alongside it, once decompressed, and whether it is compressed.
+func ObjectFileSize(path string) (int64, bool, error) {
+	file, compressed, err := ObjectFile(path)
+	if err != nil {
+		return 0, false, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	if stat.IsDir() {
+		return 0, false, errors.Errorf("%s is a directory", file)
+	}
+	if !compressed {
+		return stat.Size(), false, nil
+	}
+
+	size, err := readCompressedHeader(f, file)
+	if err != nil {
+		return 0, false, err
+	}
+	return size, true, nil
+}
+
+// OpenObjectFile opens the object stored at "path", or compressed alongside
+// it, for reading, decompressing it if it is compressed. Uncompressed objects
+// are returned as an *os.File.
+func OpenObjectFile(path string) (io.ReadCloser, error) {
+	file, compressed, err := ObjectFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := tools.RobustOpen(file)
+	if err != nil || !compressed {
+		return f, err
+	}
+
+	if _, err := readCompressedHeader(f, file); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	dec, err := zstd.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &decompressedObject{Decoder: dec, f: f}, nil
+}
+
+// CompressObject compresses the object "oid", if it is stored uncompressed and
+// compressing it saves space, replacing its file with one named with
+// compressedSuffix. It returns the number of bytes saved.
+func (f *Filesystem) CompressObject(oid string) (int64, error) {
+	path := f.ObjectPathname(oid)
+	size, compressed, err := ObjectFileSize(path)
+	if err != nil || compressed {
+		return 0, err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	tmp, err := tools.TempFile(f.TempDir(), oid+"-", f)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeCompressed(tmp, src, size)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	stat, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if stat.Size() >= size {
+		return 0, nil
+	}
+
+	src.Close()
+	if err := f.CommitFile(tmp.Name(), CompressedObjectPath(path)); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+	return size - stat.Size(), nil
+}
+
+// DecompressObject decompresses the object "oid" in place, if it is stored
+// compressed, and returns whether it was.
+func (f *Filesystem) DecompressObject(oid string) (bool, error) {
+	path := f.ObjectPathname(oid)
+	file, compressed, err := ObjectFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || !compressed {
+		return false, err
+	}
+
+	tmp, err := tools.TempFile(f.TempDir(), oid+"-", f)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := decompressTo(tmp, path); err != nil {
+		return false, err
+	}
+	if err := f.CommitFile(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, os.Remove(file)
+}
+
+// DecompressedObjectPath returns the path of a file holding the uncompressed
+// contents of the object "oid". This is the object's own path if it is stored
+// uncompressed, or else a temporary file, which is removed when the
+// Filesystem is cleaned up.
+func (f *Filesystem) DecompressedObjectPath(oid string) (string, error) {
+	path := f.ObjectPathname(oid)
+	_, compressed, err := ObjectFile(path)
+	if os.IsNotExist(err) || (err == nil && !compressed) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := tools.TempFile(f.TempDir(), oid+"-", f)
+	if err != nil {
+		return "", err
+	}
+	if err := decompressTo(tmp, path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// DecompressedObjectFile returns the path of a file holding the uncompressed
+// contents of the object stored at "path", or compressed alongside it, and a
+// function which removes that file once it is no longer needed. This is
+// "path" itself unless the object is compressed, in which case it is
+// decompressed to a new temporary file in "tempdir".
+func DecompressedObjectFile(path, tempdir string) (string, func(), error) {
+	_, compressed, err := ObjectFile(path)
+	if err != nil || !compressed {
+		return path, func() {}, err
+	}
+
+	tmp, err := ioutil.TempFile(tempdir, filepath.Base(path)+"-")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := decompressTo(tmp, path); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+}
+
+// decompressTo writes the uncompressed contents of the object stored
+// compressed alongside "path" to "tmp", and closes it.
+func decompressTo(tmp *os.File, path string) error {
+	r, err := OpenObjectFile(path)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not decompress object %s", filepath.Base(path))
+	}
+	return nil
+}
+
+func writeCompressed(w io.Writer, r io.Reader, size int64) error {
+	header := make([]byte, compressedHeaderSize)
+	copy(header, compressedMagic)
+	binary.BigEndian.PutUint64(header[len(compressedMagic):], uint64(size))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	enc, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if _, err := enc.ReadFrom(r); err != nil {
+		enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
+// readCompressedHeader reads the header of the compressed object file "name"
+// from "r", returning the size of the uncompressed object.
+func readCompressedHeader(r io.Reader, name string) (int64, error) {
+	header := make([]byte, compressedHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte(compressedMagic)) {
+		return 0, errors.Errorf("%s is not a compressed object", name)
+	}
+	return int64(binary.BigEndian.Uint64(header[len(compressedMagic):])), nil
+}
+
+// decompressedObject reads the decompressed contents of a compressed object,
+// closing both the decoder and the underlying file when it is closed.
+type decompressedObject struct {
+	*zstd.Decoder
+	f *os.File
+}
+
+func (d *decompressedObject) Close() error {
+	d.Decoder.Close()
+	return d.f.Close()
+}
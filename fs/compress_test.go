@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-compress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+	oid := "aa00000000000000000000000000000000000000000000000000000000000000"
+	contents := bytes.Repeat([]byte("compressible "), 1024)
+
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, contents, 0644))
+
+	saved, err := f.CompressObject(oid)
+	require.Nil(t, err)
+	assert.True(t, saved > 0)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(CompressedObjectPath(path))
+	assert.Nil(t, err)
+
+	size, compressed, err := ObjectFileSize(path)
+	require.Nil(t, err)
+	assert.True(t, compressed)
+	assert.EqualValues(t, len(contents), size)
+	assert.True(t, f.ObjectExists(oid, int64(len(contents))))
+
+	r, err := OpenObjectFile(path)
+	require.Nil(t, err)
+	actual, err := ioutil.ReadAll(r)
+	r.Close()
+	require.Nil(t, err)
+	assert.Equal(t, contents, actual)
+
+	tmp, err := f.DecompressedObjectPath(oid)
+	require.Nil(t, err)
+	assert.NotEqual(t, path, tmp)
+	actual, err = ioutil.ReadFile(tmp)
+	require.Nil(t, err)
+	assert.Equal(t, contents, actual)
+
+	ok, err := f.DecompressObject(oid)
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	actual, err = ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, contents, actual)
+	_, err = os.Stat(CompressedObjectPath(path))
+	assert.True(t, os.IsNotExist(err))
+
+	tmp, err = f.DecompressedObjectPath(oid)
+	require.Nil(t, err)
+	assert.Equal(t, path, tmp)
+}
+
+func TestCompressObjectIncompressible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-compress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+	oid := "bb00000000000000000000000000000000000000000000000000000000000000"
+
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, []byte("x"), 0644))
+
+	saved, err := f.CompressObject(oid)
+	require.Nil(t, err)
+	assert.EqualValues(t, 0, saved)
+
+	_, compressed, err := ObjectFileSize(path)
+	require.Nil(t, err)
+	assert.False(t, compressed)
+}
+
+func TestObjectFileWithCompressedHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-compress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+	oid := "cc00000000000000000000000000000000000000000000000000000000000000"
+
+	// An ordinary object which happens to begin like a compressed one is
+	// read as it is.
+	contents := append([]byte(compressedMagic), bytes.Repeat([]byte{0xff}, 16)...)
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, contents, 0644))
+
+	size, compressed, err := ObjectFileSize(path)
+	require.Nil(t, err)
+	assert.False(t, compressed)
+	assert.EqualValues(t, len(contents), size)
+	assert.True(t, f.ObjectExists(oid, int64(len(contents))))
+
+	r, err := OpenObjectFile(path)
+	require.Nil(t, err)
+	actual, err := ioutil.ReadAll(r)
+	r.Close()
+	require.Nil(t, err)
+	assert.Equal(t, contents, actual)
+}
@@ -11,9 +11,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var oidRE = regexp.MustCompile(`\A[[:alnum:]]{64}`)
@@ -30,17 +31,30 @@ type Environment interface {
 type Object struct {
 	Oid  string
 	Size int64
+	// ModTime is the time at which the object was last modified, or
+	// used, if the store records it.
+	ModTime time.Time
 }
 
 type Filesystem struct {
 	GitStorageDir string   // parent of objects/lfs (may be same as GitDir but may not)
 	LFSStorageDir string   // parent of lfs objects and tmp dirs. Default: ".git/lfs"
 	ReferenceDirs []string // alternative local media dirs (relative to clone reference repo)
-	lfsobjdir     string
-	tmpdir        string
-	logdir        string
-	repoPerms     os.FileMode
-	mu            sync.Mutex
+	SecondaryDirs []string // read-only media dirs consulted before downloading
+	Compress      bool     // whether new objects are stored compressed
+	Fsync         bool     // whether new objects are flushed to disk
+
+	// TempMaxAge and IncompleteMaxAge are the ages beyond which temporary
+	// files and partially downloaded objects are removed. If zero,
+	// DefaultTempMaxAge and DefaultIncompleteMaxAge are used.
+	TempMaxAge       time.Duration
+	IncompleteMaxAge time.Duration
+
+	lfsobjdir string
+	tmpdir    string
+	logdir    string
+	repoPerms os.FileMode
+	mu        sync.Mutex
 }
 
 func (f *Filesystem) EachObject(fn func(Object) error) error {
@@ -53,15 +67,33 @@ func (f *Filesystem) EachObject(fn func(Object) error) error {
 		if eachErr != nil || info.IsDir() {
 			return
 		}
-		if oidRE.MatchString(info.Name()) {
-			fn(Object{Oid: info.Name(), Size: info.Size()})
+		if !oidRE.MatchString(info.Name()) {
+			return
+		}
+		oid := info.Name()
+		if strings.HasSuffix(oid, compressedSuffix) {
+			// An object which is also stored uncompressed is
+			// only listed once.
+			oid = strings.TrimSuffix(oid, compressedSuffix)
+			if _, err := os.Stat(filepath.Join(parentDir, oid)); err == nil {
+				return
+			}
 		}
+		fn(Object{Oid: oid, Size: info.Size(), ModTime: info.ModTime()})
 	})
 	return eachErr
 }
 
 func (f *Filesystem) ObjectExists(oid string, size int64) bool {
-	return tools.FileExistsOfSize(f.ObjectPathname(oid), size)
+	path := f.ObjectPathname(oid)
+	if tools.FileExistsOfSize(path, size) {
+		return true
+	}
+
+	// The size of a compressed object is recorded in the header of its
+	// file.
+	actual, compressed, err := ObjectFileSize(path)
+	return err == nil && compressed && actual == size
 }
 
 func (f *Filesystem) ObjectPath(oid string) (string, error) {
@@ -69,12 +101,76 @@ func (f *Filesystem) ObjectPath(oid string) (string, error) {
 		return "", fmt.Errorf("too short object ID: %q", oid)
 	}
 	dir := f.localObjectDir(oid)
+
+	var created []string
+	if f.Fsync {
+		created = missingDirs(dir, f.LFSObjectDir())
+	}
 	if err := tools.MkdirAll(dir, f); err != nil {
 		return "", fmt.Errorf("error trying to create local storage directory in %q: %s", dir, err)
 	}
+	if err := syncCreatedDirs(created); err != nil {
+		return "", err
+	}
 	return filepath.Join(dir, oid), nil
 }
 
+// missingDirs returns "dir" and each of its parents up to and including "root"
+// which do not exist, deepest first.
+func missingDirs(dir, root string) []string {
+	var missing []string
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return missing
+}
+
+// syncCreatedDirs flushes the newly created directories "dirs", deepest first,
+// and then the directory containing the last of them, so that a crash cannot
+// lose the entries of any of them.
+func syncCreatedDirs(dirs []string) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := tools.SyncDir(dir); err != nil {
+			return err
+		}
+	}
+	return tools.SyncDir(filepath.Dir(dirs[len(dirs)-1]))
+}
+
+// CommitFile moves the file "src" into place at "dst", replacing any existing
+// file. If f.Fsync is set, the contents of "src" are flushed to disk before it
+// is renamed, and the directory containing "dst" afterwards, so that a crash
+// cannot leave a truncated file at "dst".  Directories created for objects by
+// ObjectPath are flushed there.
+func (f *Filesystem) CommitFile(src, dst string) error {
+	if f.Fsync {
+		if err := tools.SyncFile(src); err != nil {
+			return err
+		}
+	}
+
+	if err := tools.RenameFileCopyPermissions(src, dst); err != nil {
+		return err
+	}
+
+	if f.Fsync {
+		return tools.SyncDir(filepath.Dir(dst))
+	}
+	return nil
+}
+
 func (f *Filesystem) ObjectPathname(oid string) string {
 	return filepath.Join(f.localObjectDir(oid), oid)
 }
@@ -144,7 +240,13 @@ func (f *Filesystem) LFSObjectDir() string {
 
 	if len(f.lfsobjdir) == 0 {
 		f.lfsobjdir = filepath.Join(f.LFSStorageDir, "objects")
+
+		var created []string
+		if f.Fsync {
+			created = missingDirs(f.lfsobjdir, f.LFSStorageDir)
+		}
 		tools.MkdirAll(f.lfsobjdir, f)
+		syncCreatedDirs(created)
 	}
 
 	return f.lfsobjdir
@@ -224,7 +326,7 @@ func resolveReferenceDirs(env Environment, gitStorageDir string) []string {
 	if tools.FileExists(cloneReferencePath) {
 		f, err := os.Open(cloneReferencePath)
 		if err != nil {
-			tracerx.Printf("could not open %s: %s",
+			tracelog.Printf("could not open %s: %s",
 				cloneReferencePath, err)
 			return nil
 		}
@@ -243,7 +345,7 @@ func resolveReferenceDirs(env Environment, gitStorageDir string) []string {
 		}
 
 		if err := scanner.Err(); err != nil {
-			tracerx.Printf("could not scan %s: %s",
+			tracelog.Printf("could not scan %s: %s",
 				cloneReferencePath, err)
 		}
 	}
@@ -0,0 +1,86 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// SecondaryObjectPaths returns the paths at which the object "oid" would be
+// found in each of the secondary object directories.
+func (f *Filesystem) SecondaryObjectPaths(oid string) []string {
+	var paths []string
+	for _, dir := range f.SecondaryDirs {
+		paths = append(paths, filepath.Join(dir, oid[0:2], oid[2:4], oid))
+	}
+	return paths
+}
+
+// CopyFromSecondary copies the object "oid" into the local object store from
+// the first secondary object directory which has it with the given size and
+// correct contents, and returns whether it did so. Secondary directories are
+// only read from, never written to.
+func (f *Filesystem) CopyFromSecondary(oid string, size int64) bool {
+	for _, path := range f.SecondaryObjectPaths(oid) {
+		if actual, _, err := ObjectFileSize(path); err != nil || actual != size {
+			continue
+		}
+
+		if err := f.copyFromSecondary(oid, path); err != nil {
+			tracelog.Printf("unable to copy %s from secondary storage: %s", path, err)
+			continue
+		}
+		tracelog.Printf("copied %s from secondary storage", path)
+		return true
+	}
+	return false
+}
+
+func (f *Filesystem) copyFromSecondary(oid, path string) error {
+	r, err := OpenObjectFile(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	hasher := tools.NewHashingReaderFor(r, oid)
+	return f.writeObject(oid, hasher, func() error {
+		if actual := hasher.Hash(); actual != oid {
+			return errors.Errorf("expected OID %s, got %s", oid, actual)
+		}
+		return nil
+	})
+}
+
+// SetSecondaryStorage sets the secondary storage directories, which are laid
+// out like the LFS storage directory, and are relative to f.GitStorageDir
+// unless absolute.
+func (f *Filesystem) SetSecondaryStorage(dirs []string) {
+	f.SecondaryDirs = resolveSecondaryDirs(dirs, f.GitStorageDir)
+}
+
+// resolveSecondaryDirs returns the object directories within the given
+// secondary storage directories, which are relative to gitStorageDir unless
+// absolute, omitting those which do not exist.
+func resolveSecondaryDirs(dirs []string, gitStorageDir string) []string {
+	var resolved []string
+	for _, dir := range dirs {
+		if len(dir) == 0 {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gitStorageDir, dir)
+		}
+
+		objects := filepath.Join(dir, "objects")
+		if fi, err := os.Stat(objects); err != nil || !fi.IsDir() {
+			tracelog.Printf("ignoring secondary storage %s: no objects directory", dir)
+			continue
+		}
+		resolved = append(resolved, objects)
+	}
+	return resolved
+}
@@ -0,0 +1,41 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFromSecondary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-secondary")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	secondary := &Filesystem{GitStorageDir: filepath.Join(dir, "secondary.git"), LFSStorageDir: filepath.Join(dir, "secondary"), repoPerms: 0644}
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	// The SHA-256 hash of "test".
+	oid := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	path, err := secondary.ObjectPath(oid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, []byte("test"), 0644))
+
+	bad := "0000000000000000000000000000000000000000000000000000000000000000"
+	path, err = secondary.ObjectPath(bad)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, []byte("test"), 0644))
+
+	f.SetSecondaryStorage([]string{secondary.LFSStorageDir, filepath.Join(dir, "missing")})
+	assert.Equal(t, []string{filepath.Join(secondary.LFSStorageDir, "objects")}, f.SecondaryDirs)
+
+	assert.False(t, f.CopyFromSecondary(oid, 5))
+	assert.True(t, f.CopyFromSecondary(oid, 4))
+	assert.True(t, f.ObjectExists(oid, 4))
+
+	assert.False(t, f.CopyFromSecondary(bad, 4))
+	assert.False(t, f.ObjectExists(bad, 4))
+}
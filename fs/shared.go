@@ -0,0 +1,109 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+// SharedRepository is a repository registered as using a shared LFS storage
+// directory.
+type SharedRepository struct {
+	// ID identifies the repository's registration.
+	ID string
+	// Dir is the repository's working tree, or its Git directory if it is
+	// bare.
+	Dir string
+}
+
+// IsShared returns whether the LFS storage directory lies outside of the
+// repository's Git directory, as when "lfs.storage" is an absolute path, such
+// that it may be shared with other repositories.
+func (f *Filesystem) IsShared() bool {
+	rel, err := filepath.Rel(f.GitStorageDir, f.LFSStorageDir)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// RegisterRepository records that this repository, whose working tree (or Git
+// directory, if it is bare) is "dir", uses the shared storage directory, so
+// that pruning from any other repository sharing it retains the objects this
+// one needs. It does nothing if the storage directory is not shared.
+func (f *Filesystem) RegisterRepository(dir string) error {
+	if !f.IsShared() {
+		return nil
+	}
+
+	path := filepath.Join(f.repositoriesDir(), f.repositoryID())
+	contents := []byte(dir + "\n")
+	if existing, err := ioutil.ReadFile(path); err == nil && string(existing) == string(contents) {
+		return nil
+	}
+
+	if err := tools.MkdirAll(filepath.Dir(path), f); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, f.RepositoryPermissions(false))
+}
+
+// UnregisterRepository removes the registration with the given ID, such as
+// that of a repository which no longer exists.
+func (f *Filesystem) UnregisterRepository(id string) error {
+	err := os.Remove(filepath.Join(f.repositoriesDir(), id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// OtherRepositories returns the repositories other than this one which are
+// registered as using the shared storage directory.
+func (f *Filesystem) OtherRepositories() ([]SharedRepository, error) {
+	entries, err := ioutil.ReadDir(f.repositoriesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	self := f.repositoryID()
+
+	var repos []SharedRepository
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == self {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(f.repositoriesDir(), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		dir := strings.TrimSpace(string(contents))
+		if len(dir) == 0 {
+			continue
+		}
+		repos = append(repos, SharedRepository{ID: entry.Name(), Dir: dir})
+	}
+	return repos, nil
+}
+
+func (f *Filesystem) repositoriesDir() string {
+	return filepath.Join(f.LFSStorageDir, "repos")
+}
+
+// repositoryID identifies this repository by its Git storage directory, which
+// is shared between its worktrees.
+func (f *Filesystem) repositoryID() string {
+	dir, err := filepath.Abs(f.GitStorageDir)
+	if err != nil {
+		dir = f.GitStorageDir
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(dir)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsShared(t *testing.T) {
+	assert.False(t, (&Filesystem{GitStorageDir: "/repo/.git", LFSStorageDir: "/repo/.git/lfs"}).IsShared())
+	assert.False(t, (&Filesystem{GitStorageDir: "/repo/.git", LFSStorageDir: "/repo/.git/other/lfs"}).IsShared())
+	assert.True(t, (&Filesystem{GitStorageDir: "/repo/.git", LFSStorageDir: "/repo/lfs"}).IsShared())
+	assert.True(t, (&Filesystem{GitStorageDir: "/repo/.git", LFSStorageDir: "/srv/lfs"}).IsShared())
+}
+
+func TestRegisterRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-shared")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := filepath.Join(dir, "storage")
+	a := &Filesystem{GitStorageDir: filepath.Join(dir, "a", ".git"), LFSStorageDir: storage, repoPerms: 0644}
+	b := &Filesystem{GitStorageDir: filepath.Join(dir, "b.git"), LFSStorageDir: storage, repoPerms: 0644}
+
+	require.Nil(t, a.RegisterRepository(filepath.Join(dir, "a")))
+	require.Nil(t, a.RegisterRepository(filepath.Join(dir, "a")))
+	require.Nil(t, b.RegisterRepository(filepath.Join(dir, "b.git")))
+
+	others, err := a.OtherRepositories()
+	require.Nil(t, err)
+	require.Len(t, others, 1)
+	assert.Equal(t, filepath.Join(dir, "b.git"), others[0].Dir)
+
+	others, err = b.OtherRepositories()
+	require.Nil(t, err)
+	require.Len(t, others, 1)
+	assert.Equal(t, filepath.Join(dir, "a"), others[0].Dir)
+
+	require.Nil(t, b.UnregisterRepository(others[0].ID))
+	others, err = b.OtherRepositories()
+	require.Nil(t, err)
+	assert.Empty(t, others)
+}
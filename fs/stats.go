@@ -0,0 +1,67 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ObjectStats counts a number of objects, or files, and their total size.
+type ObjectStats struct {
+	Count int
+	Size  int64
+}
+
+func (s *ObjectStats) add(size int64) {
+	s.Count++
+	s.Size += size
+}
+
+// StoreStats describes the contents of the LFS storage directory. Sizes are
+// those of the files on disk, which may be compressed.
+type StoreStats struct {
+	// Objects counts every object in the local object store, and is the
+	// sum of Referenced and Unreferenced.
+	Objects      ObjectStats
+	Referenced   ObjectStats
+	Unreferenced ObjectStats
+
+	// Temporary counts temporary files and partially downloaded objects.
+	Temporary ObjectStats
+}
+
+// Stats walks the LFS storage directory, counting the objects for which
+// "referenced" returns true separately from the rest.
+func (f *Filesystem) Stats(referenced func(oid string) bool) (StoreStats, error) {
+	var stats StoreStats
+	err := f.EachObject(func(obj Object) error {
+		stats.Objects.add(obj.Size)
+		if referenced(obj.Oid) {
+			stats.Referenced.add(obj.Size)
+		} else {
+			stats.Unreferenced.add(obj.Size)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for _, dir := range []string{f.TempDir(), filepath.Join(f.LFSStorageDir, "incomplete")} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.IsDir() {
+				stats.Temporary.add(info.Size())
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
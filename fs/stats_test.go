@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-stats")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	referenced := "aa00000000000000000000000000000000000000000000000000000000000000"
+	unreferenced := "bb00000000000000000000000000000000000000000000000000000000000000"
+	for oid, contents := range map[string]string{referenced: "abc", unreferenced: "abcdef"} {
+		path, err := f.ObjectPath(oid)
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	require.Nil(t, ioutil.WriteFile(filepath.Join(f.TempDir(), "tmp"), []byte("a"), 0644))
+	require.Nil(t, os.MkdirAll(filepath.Join(f.LFSStorageDir, "incomplete"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(f.LFSStorageDir, "incomplete", "part"), []byte("ab"), 0644))
+
+	stats, err := f.Stats(func(oid string) bool { return oid == referenced })
+	require.Nil(t, err)
+	assert.Equal(t, StoreStats{
+		Objects:      ObjectStats{Count: 2, Size: 9},
+		Referenced:   ObjectStats{Count: 1, Size: 3},
+		Unreferenced: ObjectStats{Count: 1, Size: 6},
+		Temporary:    ObjectStats{Count: 2, Size: 3},
+	}, stats)
+}
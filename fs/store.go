@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"io"
+	"os"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+// ObjectStore is a store of LFS objects, keyed by their object IDs. The local
+// object store on disk, a *Filesystem, is the default implementation, but
+// programs embedding Git LFS may provide their own, backed by a database,
+// network cache, or chunk store, with config.Configuration.SetObjectStore.
+//
+// Transfers always stage objects on disk: once verified, downloaded objects are
+// written to the ObjectStore with WriteObject.
+type ObjectStore interface {
+	// OpenObject opens the object "oid" for reading. If the object does
+	// not exist, the returned error satisfies os.IsNotExist.
+	OpenObject(oid string) (io.ReadCloser, error)
+
+	// WriteObject stores the contents read from "r" as the object "oid",
+	// replacing any existing object. Callers are responsible for
+	// verifying that the contents match the object ID.
+	WriteObject(oid string, r io.Reader) error
+
+	// ObjectExists returns whether the object "oid" exists with the given
+	// size.
+	ObjectExists(oid string, size int64) bool
+
+	// ObjectSize returns the size of the object "oid". If the object does
+	// not exist, the returned error satisfies os.IsNotExist.
+	ObjectSize(oid string) (int64, error)
+
+	// RemoveObject removes the object "oid" from the store. If the object
+	// does not exist, the returned error satisfies os.IsNotExist.
+	RemoveObject(oid string) error
+
+	// EachObject calls "fn" with each object in the store.
+	EachObject(fn func(Object) error) error
+}
+
+var _ ObjectStore = (*Filesystem)(nil)
+
+// OpenObject implements ObjectStore.OpenObject, decompressing the object if it
+// is stored compressed.
+func (f *Filesystem) OpenObject(oid string) (io.ReadCloser, error) {
+	return OpenObjectFile(f.ObjectPathname(oid))
+}
+
+// WriteObject implements ObjectStore.WriteObject, compressing the object if
+// f.Compress is set.
+func (f *Filesystem) WriteObject(oid string, r io.Reader) error {
+	return f.writeObject(oid, r, nil)
+}
+
+// writeObject stores the contents read from "r" as the object "oid". If
+// "check" is given, it is called once all of the contents have been read, and
+// the object is only stored if it returns nil.
+func (f *Filesystem) writeObject(oid string, r io.Reader, check func() error) error {
+	path, err := f.ObjectPath(oid)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := tools.TempFile(f.TempDir(), oid+"-", f)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not write object %s", oid)
+	}
+
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	if err := f.CommitFile(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	if f.Compress {
+		if _, err := f.CompressObject(oid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectSize implements ObjectStore.ObjectSize, returning the size of the
+// object once decompressed.
+func (f *Filesystem) ObjectSize(oid string) (int64, error) {
+	size, _, err := ObjectFileSize(f.ObjectPathname(oid))
+	return size, err
+}
+
+// RemoveObject implements ObjectStore.RemoveObject, removing the object
+// whether or not it is stored compressed.
+func (f *Filesystem) RemoveObject(oid string) error {
+	path := f.ObjectPathname(oid)
+	err := os.Remove(path)
+	if cerr := os.Remove(CompressedObjectPath(path)); os.IsNotExist(err) {
+		err = cerr
+	}
+	return err
+}
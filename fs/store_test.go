@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystemWriteObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+	oid := "cc00000000000000000000000000000000000000000000000000000000000000"
+	contents := []byte("contents")
+
+	_, err = f.ObjectSize(oid)
+	assert.True(t, os.IsNotExist(err))
+	_, err = f.OpenObject(oid)
+	assert.True(t, os.IsNotExist(err))
+
+	require.Nil(t, f.WriteObject(oid, bytes.NewReader(contents)))
+
+	size, err := f.ObjectSize(oid)
+	require.Nil(t, err)
+	assert.EqualValues(t, len(contents), size)
+	assert.True(t, f.ObjectExists(oid, size))
+
+	r, err := f.OpenObject(oid)
+	require.Nil(t, err)
+	actual, err := ioutil.ReadAll(r)
+	r.Close()
+	require.Nil(t, err)
+	assert.Equal(t, contents, actual)
+}
+
+func TestFilesystemCommitFileFsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), Fsync: true, repoPerms: 0644}
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.Nil(t, ioutil.WriteFile(src, []byte("contents"), 0644))
+	require.Nil(t, ioutil.WriteFile(dst, []byte("old"), 0644))
+
+	require.Nil(t, f.CommitFile(src, dst))
+
+	actual, err := ioutil.ReadFile(dst)
+	require.Nil(t, err)
+	assert.Equal(t, "contents", string(actual))
+	_, err = os.Stat(src)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFilesystemObjectPathFsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: filepath.Join(dir, ".git"), LFSStorageDir: filepath.Join(dir, "lfs"), Fsync: true, repoPerms: 0644}
+	root := filepath.Join(dir, "lfs", "objects")
+	oid := "cc00000000000000000000000000000000000000000000000000000000000000"
+
+	assert.Equal(t, []string{
+		filepath.Join(root, "cc", "00"),
+		filepath.Join(root, "cc"),
+		root,
+	}, missingDirs(filepath.Join(root, "cc", "00"), root))
+
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(root, "cc", "00", oid), path)
+	assert.Empty(t, missingDirs(filepath.Join(root, "cc", "00"), root))
+
+	other := filepath.Join(root, "cc", "11")
+	assert.Equal(t, []string{other}, missingDirs(other, root))
+}
@@ -11,14 +11,17 @@ import (
 )
 
 func main() {
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, os.Kill)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM)
 
 	var once sync.Once
 
 	go func() {
 		for {
 			sig := <-c
+			if commands.HandleSignal(sig) {
+				continue
+			}
 			once.Do(commands.Cleanup)
 			fmt.Fprintf(os.Stderr, "\nExiting because of %q signal.\n", sig)
 
@@ -0,0 +1,30 @@
+package git
+
+import (
+	"fmt"
+	"os"
+)
+
+// ArchiveWithFilters runs "git archive" with the given arguments, writing the
+// archive to standard output unless they name an output file. The Git LFS
+// filters are configured and smudging is enabled whatever the repository's
+// configuration, so that the archive holds the contents of Git LFS files
+// rather than their pointers.
+func ArchiveWithFilters(args ...string) error {
+	cmdargs := append([]string{
+		"-c", "filter.lfs.smudge=git-lfs smudge -- %f",
+		"-c", "filter.lfs.process=git-lfs filter-process",
+		"-c", "filter.lfs.required=true",
+		"archive",
+	}, args...)
+
+	cmd := git(cmdargs...)
+	cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=0")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git archive failed: %v", err)
+	}
+	return nil
+}
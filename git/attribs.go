@@ -9,12 +9,15 @@ import (
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git/gitattr"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 const (
 	LockableAttrib = "lockable"
 	FilterAttrib   = "filter"
+	// AutotrackAttrib marks a tracked pattern whose files are only
+	// converted to pointers if they are at least lfs.autotrack.size long.
+	AutotrackAttrib = "lfs-autotrack"
 )
 
 // AttributePath is a path entry in a gitattributes file which has the LFS filter
@@ -27,6 +30,10 @@ type AttributePath struct {
 	Lockable bool
 	// Path is handled by Git LFS (i.e., filter=lfs)
 	Tracked bool
+	// Path sets or unsets the filter attribute, whether or not to "lfs"
+	Filter bool
+	// Path also has the 'lfs-autotrack' attribute
+	Autotrack bool
 }
 
 type AttributeSource struct {
@@ -106,6 +113,12 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 
 	relfile, _ := filepath.Rel(workingDir, path)
 	reldir := filepath.Dir(relfile)
+	if filepath.Base(path) != ".gitattributes" {
+		// Patterns in other attributes files, such as
+		// $GIT_DIR/info/attributes, are relative to the root of the
+		// working tree rather than to their own directory.
+		reldir = ""
+	}
 	source := &AttributeSource{Path: relfile}
 
 	lines, eol, err := gitattr.ParseLines(rdr)
@@ -119,6 +132,7 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 		lockable := false
 		tracked := false
 		hasFilter := false
+		autotrack := false
 
 		for _, attr := range line.Attrs {
 			if attr.K == FilterAttrib {
@@ -126,6 +140,8 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 				tracked = attr.V == "lfs"
 			} else if attr.K == LockableAttrib && attr.V == "true" {
 				lockable = true
+			} else if attr.K == AutotrackAttrib && attr.V == "true" {
+				autotrack = true
 			}
 		}
 
@@ -139,10 +155,12 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 		}
 
 		paths = append(paths, AttributePath{
-			Path:     pattern,
-			Source:   source,
-			Lockable: lockable,
-			Tracked:  tracked,
+			Path:      pattern,
+			Source:    source,
+			Lockable:  lockable,
+			Tracked:   tracked,
+			Filter:    hasFilter,
+			Autotrack: autotrack,
 		})
 	}
 
@@ -177,15 +195,15 @@ func findAttributeFiles(workingDir, gitDir string) []attrFile {
 		paths = append(paths, attrFile{path: repoAttributes, readMacros: true})
 	}
 
-	lsFiles, err := NewLsFiles(workingDir, true)
+	lsFiles, err := NewLsFiles(workingDir, true, true)
 	if err != nil {
-		tracerx.Printf("Error finding .gitattributes: %v", err)
+		tracelog.Printf("Error finding .gitattributes: %v", err)
 		return paths
 	}
 
 	if gitattributesFiles, present := lsFiles.FilesByName[".gitattributes"]; present {
 		for _, f := range gitattributesFiles {
-			tracerx.Printf("findAttributeFiles: located %s", f.FullPath)
+			tracelog.Printf("findAttributeFiles: located %s", f.FullPath)
 			paths = append(paths, attrFile{
 				path:       filepath.Join(workingDir, f.FullPath),
 				readMacros: f.FullPath == ".gitattributes", // Read macros from the top-level attributes
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Bundle is the header of a Git bundle, as written by "git bundle create".
+type Bundle struct {
+	// Refs are the refs contained in the bundle.
+	Refs []*Ref
+	// Prerequisites are the IDs of the commits which a repository must
+	// already contain for the bundle to be unbundled into it.
+	Prerequisites []string
+}
+
+// Heads returns the object IDs of the refs contained in the bundle.
+func (b *Bundle) Heads() []string {
+	heads := make([]string, 0, len(b.Refs))
+	for _, r := range b.Refs {
+		heads = append(heads, r.Sha)
+	}
+	return heads
+}
+
+// CreateBundle runs "git bundle create" to write a bundle to path containing
+// the commits selected by the given "git rev-list" arguments.
+func CreateBundle(path string, args ...string) error {
+	cmd := gitNoLFS(append([]string{"bundle", "create", path}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git bundle create failed: %v", err)
+	}
+	return nil
+}
+
+// ReadBundle reads the header of the Git bundle at path.
+func ReadBundle(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseBundle(f)
+}
+
+// ParseBundle parses the header of a version 2 or 3 Git bundle read from r,
+// stopping at the blank line which separates it from the packfile.
+func ParseBundle(r io.Reader) (*Bundle, error) {
+	br := bufio.NewReader(r)
+
+	signature, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %v", err)
+	}
+	switch strings.TrimSuffix(signature, "\n") {
+	case "# v2 git bundle", "# v3 git bundle":
+	default:
+		return nil, fmt.Errorf("invalid bundle: unknown signature %q", strings.TrimSpace(signature))
+	}
+
+	b := &Bundle{}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case len(line) == 0:
+			return b, nil
+		case strings.HasPrefix(line, "@"):
+			// Capabilities of a version 3 bundle, none of
+			// which affect which commits it contains.
+		case strings.HasPrefix(line, "-"):
+			fields := strings.SplitN(line[1:], " ", 2)
+			b.Prerequisites = append(b.Prerequisites, fields[0])
+		default:
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid bundle: malformed ref %q", line)
+			}
+			b.Refs = append(b.Refs, ParseRef(fields[1], fields[0]))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBundle(t *testing.T) {
+	header := strings.Join([]string{
+		"# v3 git bundle",
+		"@object-format=sha1",
+		"-1111111111111111111111111111111111111111 base commit",
+		"2222222222222222222222222222222222222222 refs/heads/main",
+		"3333333333333333333333333333333333333333 refs/tags/v1.0",
+		"",
+		"PACK...",
+	}, "\n")
+
+	b, err := ParseBundle(strings.NewReader(header))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"1111111111111111111111111111111111111111"}, b.Prerequisites)
+	assert.Equal(t, []*Ref{
+		{Name: "main", Type: RefTypeLocalBranch, Sha: "2222222222222222222222222222222222222222"},
+		{Name: "v1.0", Type: RefTypeLocalTag, Sha: "3333333333333333333333333333333333333333"},
+	}, b.Refs)
+	assert.Equal(t, []string{
+		"2222222222222222222222222222222222222222",
+		"3333333333333333333333333333333333333333",
+	}, b.Heads())
+}
+
+func TestParseBundleInvalid(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"PACK",
+		"# v4 git bundle\n\n",
+		"# v2 git bundle\n2222222222222222222222222222222222222222\n\n",
+		"# v2 git bundle\n2222222222222222222222222222222222222222 refs/heads/main\n",
+	} {
+		_, err := ParseBundle(strings.NewReader(header))
+		assert.NotNil(t, err, "header: %q", header)
+	}
+}
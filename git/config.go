@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -56,10 +57,33 @@ func ParseConfigLines(lines string, onlySafeKeys bool) *ConfigurationSource {
 }
 
 type ConfigurationSource struct {
-	Lines        []string
+	Lines []string
+	// Origins holds where each of the Lines was read from, if known.
+	Origins      []ConfigurationOrigin
 	OnlySafeKeys bool
 }
 
+// ConfigurationOrigin describes where a line of a ConfigurationSource was read
+// from.
+type ConfigurationOrigin struct {
+	// Scope is the Git configuration scope of the line, such as "system",
+	// "global", "local", "worktree" or "command", or empty if it was read
+	// from a file or blob which is not part of the Git configuration.
+	Scope string
+	// Source is the origin of the line as reported by Git, such as
+	// "file:.git/config", "command line:" or "blob:HEAD:.lfsconfig".
+	Source string
+}
+
+// withOrigin returns the source with all its lines given the same origin.
+func (s *ConfigurationSource) withOrigin(scope, source string) *ConfigurationSource {
+	s.Origins = make([]ConfigurationOrigin, len(s.Lines))
+	for i := range s.Origins {
+		s.Origins[i] = ConfigurationOrigin{Scope: scope, Source: source}
+	}
+	return s
+}
+
 // Find returns the git config value for the key
 func (c *Configuration) Find(val string) string {
 	output, _ := c.gitConfig(val)
@@ -110,6 +134,27 @@ func (c *Configuration) SetWorktree(key, val string) (string, error) {
 	return c.gitConfigWrite("--worktree", "--replace-all", key, val)
 }
 
+// AddGlobal adds a value for the key to the global config, alongside any it
+// already has
+func (c *Configuration) AddGlobal(key, val string) (string, error) {
+	return c.gitConfigWrite("--global", "--add", key, val)
+}
+
+// FindAllGlobal returns every value of the key in the global config
+func (c *Configuration) FindAllGlobal(key string) []string {
+	output, _ := c.gitConfig("--global", "--get-all", key)
+	if len(output) == 0 {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// UnsetGlobalValue removes the given value of the key from the global config,
+// leaving any others it has
+func (c *Configuration) UnsetGlobalValue(key, val string) (string, error) {
+	return c.gitConfigWrite("--global", "--unset-all", key, "^"+regexp.QuoteMeta(val)+"$")
+}
+
 // UnsetGlobalSection removes the entire named section from the global config
 func (c *Configuration) UnsetGlobalSection(key string) (string, error) {
 	return c.gitConfigWrite("--global", "--remove-section", key)
@@ -135,6 +180,47 @@ func (c *Configuration) UnsetLocalKey(key string) (string, error) {
 	return c.gitConfigWrite("--unset", key)
 }
 
+// UnsetGlobalKey removes the git config value for the key from the global config
+func (c *Configuration) UnsetGlobalKey(key string) (string, error) {
+	return c.gitConfigWrite("--global", "--unset", key)
+}
+
+// UnsetSystemKey removes the git config value for the key from the system config
+func (c *Configuration) UnsetSystemKey(key string) (string, error) {
+	return c.gitConfigWrite("--system", "--unset", key)
+}
+
+// UnsetWorktreeKey removes the git config value for the key from the worktree or local config, depending on whether multiple worktrees are in use
+func (c *Configuration) UnsetWorktreeKey(key string) (string, error) {
+	return c.gitConfigWrite("--worktree", "--unset", key)
+}
+
+// FindGlobalSection returns the keys and values of the named section in the global config, one per line, or an error if the config could not be read
+func (c *Configuration) FindGlobalSection(section string) (string, error) {
+	return c.gitConfigFind("--global", "--get-regexp", sectionRegexp(section))
+}
+
+// FindSystemSection returns the keys and values of the named section in the system config, one per line, or an error if the config could not be read
+func (c *Configuration) FindSystemSection(section string) (string, error) {
+	return c.gitConfigFind("--system", "--get-regexp", sectionRegexp(section))
+}
+
+// FindLocalSection returns the keys and values of the named section in the local config, one per line, or an error if the config could not be read
+func (c *Configuration) FindLocalSection(section string) (string, error) {
+	return c.gitConfigFind("--local", "--get-regexp", sectionRegexp(section))
+}
+
+// FindWorktreeSection returns the keys and values of the named section in the worktree or local config, one per line, depending on whether multiple worktrees are in use, or an error if the config could not be read
+func (c *Configuration) FindWorktreeSection(section string) (string, error) {
+	return c.gitConfigFind("--worktree", "--get-regexp", sectionRegexp(section))
+}
+
+// sectionRegexp returns a regular expression matching the keys of the named
+// section.
+func sectionRegexp(section string) string {
+	return "^" + regexp.QuoteMeta(section) + `\.`
+}
+
 func (c *Configuration) Sources(dir string, optionalFilename string) ([]*ConfigurationSource, error) {
 	gitconfig, err := c.Source()
 	if err != nil {
@@ -179,7 +265,7 @@ func (c *Configuration) FileSource(filename string) (*ConfigurationSource, error
 	if err != nil {
 		return nil, err
 	}
-	return ParseConfigLines(out, true), nil
+	return ParseConfigLines(out, true).withOrigin("", "file:"+filename), nil
 }
 
 func (c *Configuration) RevisionSource(revision string) (*ConfigurationSource, error) {
@@ -187,15 +273,47 @@ func (c *Configuration) RevisionSource(revision string) (*ConfigurationSource, e
 	if err != nil {
 		return nil, err
 	}
-	return ParseConfigLines(out, true), nil
+	return ParseConfigLines(out, true).withOrigin("", "blob:"+revision), nil
 }
 
+// Source returns the Git configuration from all scopes.  With Git 2.26 or
+// later, the origin of each line is included.
 func (c *Configuration) Source() (*ConfigurationSource, error) {
-	out, err := c.gitConfig("-l")
+	if !IsGitVersionAtLeast("2.26.0") {
+		out, err := c.gitConfig("-l")
+		if err != nil {
+			return nil, err
+		}
+		return ParseConfigLines(out, false), nil
+	}
+
+	out, err := c.gitConfig("-l", "-z", "--show-scope", "--show-origin")
 	if err != nil {
 		return nil, err
 	}
-	return ParseConfigLines(out, false), nil
+	return parseConfigOrigins(out), nil
+}
+
+// parseConfigOrigins parses the output of "git config -l -z --show-scope
+// --show-origin", in which each entry is a scope, origin and key followed by a
+// newline and the value, separated by NUL bytes.
+func parseConfigOrigins(out string) *ConfigurationSource {
+	source := &ConfigurationSource{}
+
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		line := fields[i+2]
+		if kv := strings.SplitN(line, "\n", 2); len(kv) == 2 {
+			line = kv[0] + "=" + kv[1]
+		}
+
+		source.Lines = append(source.Lines, line)
+		source.Origins = append(source.Origins, ConfigurationOrigin{
+			Scope:  fields[i],
+			Source: fields[i+1],
+		})
+	}
+	return source
 }
 
 func (c *Configuration) gitConfig(args ...string) (string, error) {
@@ -207,6 +325,21 @@ func (c *Configuration) gitConfig(args ...string) (string, error) {
 	return subprocess.Output(cmd)
 }
 
+// gitConfigFind runs "git config" to look up values, returning an error only if
+// the config could not be read, and not if there were no values to find.
+func (c *Configuration) gitConfigFind(args ...string) (string, error) {
+	args = append([]string{"config", "--includes"}, args...)
+	cmd := subprocess.ExecCommand("git", args...)
+	if len(c.GitDir) > 0 {
+		cmd.Dir = c.GitDir
+	}
+	output, err := subprocess.Output(cmd)
+	if err != nil && cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == 1 {
+		return "", nil
+	}
+	return output, err
+}
+
 func (c *Configuration) gitConfigWrite(args ...string) (string, error) {
 	if c.readOnly {
 		return "", ErrReadOnly
@@ -1,10 +1,16 @@
 package git_test // to avoid import cycles
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	. "github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadOnlyConfig(t *testing.T) {
@@ -12,3 +18,61 @@ func TestReadOnlyConfig(t *testing.T) {
 	_, err := cfg.SetLocal("lfs.this.should", "fail")
 	assert.Equal(t, err, ErrReadOnly)
 }
+
+func TestConfigSourceOrigins(t *testing.T) {
+	if !IsGitVersionAtLeast("2.26.0") {
+		t.Skip("git config --show-scope requires Git 2.26")
+	}
+
+	dir, err := ioutil.TempDir("", "git-config-source")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, exec.Command("git", "init", "-q", dir).Run())
+
+	cfg := NewConfig(dir, filepath.Join(dir, ".git"))
+	_, err = cfg.SetLocal("lfs.fetchinclude", "a\nb")
+	require.Nil(t, err)
+
+	source, err := cfg.Source()
+	require.Nil(t, err)
+	require.Equal(t, len(source.Lines), len(source.Origins))
+
+	for i, line := range source.Lines {
+		if line == "lfs.fetchinclude=a\nb" {
+			assert.Equal(t, ConfigurationOrigin{Scope: "local", Source: "file:config"}, source.Origins[i])
+			return
+		}
+	}
+	t.Errorf("lfs.fetchinclude not found in %v", source.Lines)
+}
+
+func TestConfigGlobalMultipleValues(t *testing.T) {
+	if !IsGitVersionAtLeast("2.32.0") {
+		t.Skip("GIT_CONFIG_GLOBAL requires Git 2.32")
+	}
+
+	dir, err := ioutil.TempDir("", "git-config-global")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(global string) {
+		os.Setenv("GIT_CONFIG_GLOBAL", global)
+		subprocess.ResetEnvironment()
+	}(os.Getenv("GIT_CONFIG_GLOBAL"))
+	os.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, "gitconfig"))
+	subprocess.ResetEnvironment()
+
+	cfg := NewConfig("", "")
+	assert.Empty(t, cfg.FindAllGlobal("lfs.maintenance.repo"))
+
+	for _, repo := range []string{"/a", "/b.c", "/bxc"} {
+		_, err = cfg.AddGlobal("lfs.maintenance.repo", repo)
+		require.Nil(t, err)
+	}
+	assert.Equal(t, []string{"/a", "/b.c", "/bxc"}, cfg.FindAllGlobal("lfs.maintenance.repo"))
+
+	_, err = cfg.UnsetGlobalValue("lfs.maintenance.repo", "/b.c")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"/a", "/bxc"}, cfg.FindAllGlobal("lfs.maintenance.repo"))
+}
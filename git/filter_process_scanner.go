@@ -8,8 +8,8 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/pktline"
-	"github.com/rubyist/tracerx"
 )
 
 // FilterProcessScanner provides a scanner-like interface capable of
@@ -68,7 +68,7 @@ func NewFilterProcessScanner(r io.Reader, w io.Writer) *FilterProcessScanner {
 // If there was an error reading or writing any of the packets below, an error
 // will be returned.
 func (o *FilterProcessScanner) Init() error {
-	tracerx.Printf("Initialize filter-process")
+	tracelog.Printf("Initialize filter-process")
 	reqVer := "version=2"
 
 	initMsg, err := o.pl.ReadPacketText()
@@ -26,8 +26,8 @@ import (
 	lfserrors "github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/gitobj/v2"
-	"github.com/rubyist/tracerx"
 )
 
 type RefType int
@@ -280,15 +280,48 @@ func LsRemote(remote, remoteRef string) (string, error) {
 	return gitNoLFSSimple("ls-remote", remote, remoteRef)
 }
 
-func LsTree(ref string) (*subprocess.BufferedCmd, error) {
-	return gitNoLFSBuffered(
+// LsTree lists the objects in the tree of ref and its subtrees, with their
+// sizes if sizes is true.
+func LsTree(ref string, sizes bool) (*subprocess.BufferedCmd, error) {
+	args := []string{
 		"ls-tree",
 		"-r",          // recurse
-		"-l",          // report object size (we'll need this)
 		"-z",          // null line termination
 		"--full-tree", // start at the root regardless of where we are in it
-		ref,
-	)
+	}
+	if sizes {
+		// Report object sizes.  In a partial clone, Git fetches each
+		// missing blob separately to find its size.
+		args = append(args, "-l")
+	}
+	return gitNoLFSBuffered(append(args, ref)...)
+}
+
+// FetchPromisorObjects fetches the objects with the given IDs, which are
+// missing from a partial clone, from its promisor remote in a single request,
+// as Git does when it needs several missing objects at once.
+func FetchPromisorObjects(remote string, oids []string) error {
+	cmd := gitNoLFS("-c", "fetch.negotiationAlgorithm=noop",
+		"fetch", remote,
+		"--no-tags", "--no-write-fetch-head", "--recurse-submodules=no",
+		"--filter=blob:none", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(oids, "\n") + "\n")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to fetch missing objects from %q: %v: %s", remote, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WriteTree writes the contents of the index as a tree object, and returns
+// its ID.  Objects missing from the repository are permitted.
+func WriteTree() (string, error) {
+	tree, err := gitNoLFSSimple("write-tree", "--missing-ok")
+	if err != nil {
+		return "", fmt.Errorf("could not write index tree: %v", err)
+	}
+	return tree, nil
 }
 
 func ResolveRef(ref string) (*Ref, error) {
@@ -329,6 +362,70 @@ func ResolveRefs(refnames []string) ([]*Ref, error) {
 	return refs, nil
 }
 
+// DeletedPaths returns the paths of the files which exist in the tree of the
+// commit "from" but not in that of the commit "to".  Renames are not detected,
+// so the old path of a renamed file is included.
+func DeletedPaths(from, to string) ([]string, error) {
+	paths, err := diffPaths("--diff-filter=D", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not find deleted files between %q and %q: %v", from, to, err)
+	}
+	return paths, nil
+}
+
+// ChangedPaths returns the paths of the files which were added, modified or
+// deleted between the trees of the commits "from" and "to".  Renames are not
+// detected, so both the old and new paths of a renamed file are included.
+func ChangedPaths(from, to string) ([]string, error) {
+	paths, err := diffPaths(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not find changed files between %q and %q: %v", from, to, err)
+	}
+	return paths, nil
+}
+
+// ModifiedPaths returns the paths of the files in the working tree or index
+// which differ from the tree of the commit "ref".  The working tree is
+// compared with the LFS filters enabled, so that files are compared by their
+// cleaned content rather than as they are checked out.
+func ModifiedPaths(ref string) ([]string, error) {
+	paths, err := splitPaths(gitSimple("diff", "--name-only", "--no-renames", "-z", ref, "--"))
+	if err != nil {
+		return nil, fmt.Errorf("could not find modified files since %q: %v", ref, err)
+	}
+	return paths, nil
+}
+
+func diffPaths(args ...string) ([]string, error) {
+	args = append([]string{"diff", "--name-only", "--no-renames", "-z"}, args...)
+	return splitPaths(gitNoLFSSimple(append(args, "--")...))
+}
+
+// splitPaths splits the NUL-separated output of a Git command listing paths.
+func splitPaths(outp string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range strings.Split(outp, "\x00") {
+		if len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// ResolveCommit returns the ID of the commit named by the given revision,
+// peeling any tag, or an error if it names no commit in the repository.
+func ResolveCommit(rev string) (string, error) {
+	outp, err := gitNoLFSSimple("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil || outp == "" {
+		return "", fmt.Errorf("Git can't resolve commit: %q", rev)
+	}
+	return outp, nil
+}
+
 func CurrentRef() (*Ref, error) {
 	return ResolveRef("HEAD")
 }
@@ -470,7 +567,7 @@ func LocalRefs() ([]*Ref, error) {
 		line := strings.TrimSpace(scanner.Text())
 		parts := strings.SplitN(line, " ", 2)
 		if len(parts) != 2 || !HasValidObjectIDLength(parts[0]) || len(parts[1]) < 1 {
-			tracerx.Printf("Invalid line from git show-ref: %q", line)
+			tracelog.Printf("Invalid line from git show-ref: %q", line)
 			continue
 		}
 
@@ -588,7 +685,8 @@ func UpdateIndexFromStdin() *subprocess.Cmd {
 // since: refs with commits on or after this date will be included
 // includeRemoteBranches: true to include refs on remote branches
 // onlyRemote: set to non-blank to only include remote branches on a single remote
-func RecentBranches(since time.Time, includeRemoteBranches bool, onlyRemote string) ([]*Ref, error) {
+// includeTags: true to include tags, both lightweight and annotated
+func RecentBranches(since time.Time, includeRemoteBranches bool, onlyRemote string, includeTags bool) ([]*Ref, error) {
 	cmd := gitNoLFS("for-each-ref",
 		`--sort=-committerdate`,
 		`--format=%(refname) %(objectname) %(committerdate:iso)`,
@@ -608,7 +706,7 @@ func RecentBranches(since time.Time, includeRemoteBranches bool, onlyRemote stri
 
 	// Output is ordered by latest commit date first, so we can stop at the threshold
 	regex := regexp.MustCompile(fmt.Sprintf(`^(refs/[^/]+/\S+)\s+(%s)\s+(\d{4}-\d{2}-\d{2}\s+\d{2}\:\d{2}\:\d{2}\s+[\+\-]\d{4})`, ObjectIDRegex))
-	tracerx.Printf("RECENT: Getting refs >= %v", since)
+	tracelog.Printf("RECENT: Getting refs >= %v", since)
 	var ret []*Ref
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -616,6 +714,12 @@ func RecentBranches(since time.Time, includeRemoteBranches bool, onlyRemote stri
 			fullref := match[1]
 			sha := match[2]
 			reftype, ref := ParseRefToTypeAndName(fullref)
+			if reftype == RefTypeLocalTag {
+				// Tags are found by recentTags, since
+				// annotated tags have no commit date of
+				// their own.
+				continue
+			}
 			if reftype == RefTypeRemoteBranch {
 				if !includeRemoteBranches {
 					continue
@@ -634,15 +738,64 @@ func RecentBranches(since time.Time, includeRemoteBranches bool, onlyRemote stri
 				// the end
 				break
 			}
-			tracerx.Printf("RECENT: %v (%v)", ref, commitDate)
+			tracelog.Printf("RECENT: %v (%v)", ref, commitDate)
 			ret = append(ret, &Ref{ref, reftype, sha})
 		}
 	}
 
+	if includeTags {
+		tags, err := recentTags(since)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, tags...)
+	}
 	return ret, nil
 
 }
 
+// recentTags returns the tags of commits with commit dates on or after the
+// given date/time.  Annotated tags are peeled, so the Sha of each ref is that of
+// a commit.  Tags of other objects are skipped.
+func recentTags(since time.Time) ([]*Ref, error) {
+	outp, err := gitNoLFSSimple("for-each-ref",
+		`--format=%(refname)%00%(objectname)%00%(committerdate:iso)%00%(*objectname)%00%(*committerdate:iso)`,
+		"refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to call git for-each-ref: %v", err)
+	}
+
+	var ret []*Ref
+	for _, line := range strings.Split(outp, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+
+		sha, date := fields[1], fields[2]
+		if len(fields[3]) > 0 {
+			// An annotated tag, so use the commit it tags.
+			sha, date = fields[3], fields[4]
+		}
+		if len(date) == 0 {
+			continue
+		}
+
+		commitDate, err := ParseGitDate(date)
+		if err != nil {
+			return ret, err
+		}
+		if commitDate.Before(since) {
+			continue
+		}
+
+		reftype, ref := ParseRefToTypeAndName(fields[0])
+		tracelog.Printf("RECENT: %v (%v)", ref, commitDate)
+		ret = append(ret, &Ref{ref, reftype, sha})
+	}
+	return ret, nil
+}
+
 // Get the type & name of a git reference
 func ParseRefToTypeAndName(fullref string) (t RefType, name string) {
 	const localPrefix = "refs/heads/"
@@ -842,7 +995,7 @@ func GetAllWorkTreeHEADs(storageDir string) ([]*Ref, error) {
 				headfile := filepath.Join(worktreesdir, dirfi.Name(), "HEAD")
 				ref, err := parseRefFile(headfile)
 				if err != nil {
-					tracerx.Printf("Error reading %v for worktree, skipping: %v", headfile, err)
+					tracelog.Printf("Error reading %v for worktree, skipping: %v", headfile, err)
 					continue
 				}
 				worktrees = append(worktrees, ref)
@@ -859,7 +1012,7 @@ func GetAllWorkTreeHEADs(storageDir string) ([]*Ref, error) {
 	if err == nil {
 		worktrees = append(worktrees, ref)
 	} else if !os.IsNotExist(err) { // ok if not exists, probably bare repo
-		tracerx.Printf("Error reading %v for main checkout, skipping: %v", headfile, err)
+		tracelog.Printf("Error reading %v for main checkout, skipping: %v", headfile, err)
 	}
 
 	return worktrees, nil
@@ -1101,6 +1254,17 @@ func Checkout(treeish string, paths []string, force bool) error {
 	return err
 }
 
+// Add performs an invocation of `git-add(1)` to stage the given paths, which
+// are relative to the current working directory.
+func Add(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	_, err := gitNoLFSSimple(append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
 // CachedRemoteRefs returns the list of branches & tags for a remote which are
 // currently cached locally. No remote request is made to verify them.
 func CachedRemoteRefs(remoteName string) ([]*Ref, error) {
@@ -1384,6 +1548,51 @@ func IsFileModified(filepath string) (bool, error) {
 	return matched, nil
 }
 
+// IndexBlob returns the contents of the blob staged in the index at the given
+// path, relative to the root of the working tree. It returns nil, and no
+// error, if there is no such blob, or if it is larger than "limit" bytes.
+func IndexBlob(path string, limit int64) ([]byte, error) {
+	spec := ":" + filepath.ToSlash(path)
+
+	size, err := gitNoLFSSimple("cat-file", "-s", spec)
+	if err != nil {
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(size, 10, 64); err != nil || n > limit {
+		return nil, nil
+	}
+
+	out, err := gitNoLFS("cat-file", "blob", spec).Output()
+	if err != nil {
+		return nil, lfserrors.Wrap(err, "Failed to call git cat-file")
+	}
+	return out, nil
+}
+
+// BlobReader starts reading the contents of the blob named by "spec", which may
+// be given in any form understood by git-cat-file(1), such as "<ref>:<path>".
+// It returns an error if "spec" does not name a blob. The returned command must
+// be waited for once the contents have been read.
+func BlobReader(spec string) (*subprocess.Cmd, io.Reader, error) {
+	kind, err := gitNoLFSSimple("cat-file", "-t", spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%q does not name a file", spec)
+	}
+	if kind != "blob" {
+		return nil, nil, fmt.Errorf("%q is a %s, not a file", spec, kind)
+	}
+
+	cmd := gitNoLFS("cat-file", "blob", spec)
+	outp, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to call git cat-file: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to call git cat-file: %v", err)
+	}
+	return cmd, outp, nil
+}
+
 // IsWorkingCopyDirty returns true if and only if the working copy in which the
 // command was executed is dirty as compared to the index.
 //
@@ -11,6 +11,7 @@ import (
 	. "github.com/git-lfs/git-lfs/git"
 	test "github.com/git-lfs/git-lfs/t/cmd/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRefString(t *testing.T) {
@@ -150,6 +151,130 @@ func TestCurrentRefAndCurrentRemoteRef(t *testing.T) {
 	}, ref)
 }
 
+func TestResolveCommit(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{{Filename: "file1.txt", Size: 20}}},
+	})
+	test.RunGitCommand(t, true, "tag", "-a", "-m", "annotated", "v1.0")
+
+	sha, err := ResolveCommit("v1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, outputs[0].Sha, sha)
+
+	sha, err = ResolveCommit(outputs[0].Sha[:10])
+	assert.Nil(t, err)
+	assert.Equal(t, outputs[0].Sha, sha)
+
+	_, err = ResolveCommit("0123456789012345678901234567890123456789")
+	assert.NotNil(t, err)
+
+	_, err = ResolveCommit("no-such-branch")
+	assert.NotNil(t, err)
+}
+
+func TestDeletedPaths(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{
+			{Filename: "kept.txt", Size: 20},
+			{Filename: "deleted.txt", Size: 21},
+			{Filename: "dir/renamed.txt", Size: 22},
+		}},
+	})
+	test.RunGitCommand(t, true, "rm", "-q", "deleted.txt")
+	test.RunGitCommand(t, true, "mv", "dir/renamed.txt", "new.txt")
+	test.RunGitCommand(t, true, "commit", "-q", "-m", "delete and rename")
+
+	paths, err := DeletedPaths(outputs[0].Sha, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deleted.txt", "dir/renamed.txt"}, paths)
+
+	paths, err = DeletedPaths("HEAD", outputs[0].Sha)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"new.txt"}, paths)
+
+	paths, err = DeletedPaths("HEAD", "HEAD")
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestChangedPaths(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{
+			{Filename: "kept.txt", Size: 20},
+			{Filename: "modified.txt", Size: 21},
+			{Filename: "deleted.txt", Size: 22},
+		}},
+		{Files: []*test.FileInput{
+			{Filename: "modified.txt", Size: 23},
+			{Filename: "added.txt", Size: 24},
+		}},
+	})
+	test.RunGitCommand(t, true, "rm", "-q", "deleted.txt")
+	test.RunGitCommand(t, true, "commit", "-q", "-m", "delete")
+
+	paths, err := ChangedPaths(outputs[0].Sha, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"added.txt", "deleted.txt", "modified.txt"}, paths)
+
+	paths, err = ChangedPaths(outputs[1].Sha, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deleted.txt"}, paths)
+
+	paths, err = ChangedPaths("HEAD", "HEAD")
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestModifiedPaths(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{
+			{Filename: "kept.txt", Size: 20},
+			{Filename: "modified.txt", Size: 21},
+			{Filename: "staged.txt", Size: 22},
+		}},
+	})
+
+	paths, err := ModifiedPaths("HEAD")
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+
+	require.Nil(t, ioutil.WriteFile("modified.txt", []byte("modified"), 0644))
+	require.Nil(t, ioutil.WriteFile("staged.txt", []byte("staged"), 0644))
+	test.RunGitCommand(t, true, "add", "staged.txt")
+
+	paths, err = ModifiedPaths("HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"modified.txt", "staged.txt"}, paths)
+}
+
 func TestRecentBranches(t *testing.T) {
 	repo := test.NewRepo(t)
 	repo.Pushd()
@@ -218,7 +343,7 @@ func TestRecentBranches(t *testing.T) {
 	test.RunGitCommand(t, true, "push", "upstream", "included_branch_2")
 
 	// Recent, local only
-	refs, err := RecentBranches(now.AddDate(0, 0, -7), false, "")
+	refs, err := RecentBranches(now.AddDate(0, 0, -7), false, "", false)
 	assert.Equal(t, nil, err)
 	expectedRefs := []*Ref{
 		{
@@ -240,7 +365,7 @@ func TestRecentBranches(t *testing.T) {
 	assert.Equal(t, expectedRefs, refs, "Refs should be correct")
 
 	// Recent, remotes too (all of them)
-	refs, err = RecentBranches(now.AddDate(0, 0, -7), true, "")
+	refs, err = RecentBranches(now.AddDate(0, 0, -7), true, "", false)
 	assert.Equal(t, nil, err)
 	expectedRefs = []*Ref{
 		{
@@ -285,7 +410,7 @@ func TestRecentBranches(t *testing.T) {
 	assert.Equal(t, expectedRefs, refs, "Refs should be correct")
 
 	// Recent, only single remote
-	refs, err = RecentBranches(now.AddDate(0, 0, -7), true, "origin")
+	refs, err = RecentBranches(now.AddDate(0, 0, -7), true, "origin", false)
 	assert.Equal(t, nil, err)
 	expectedRefs = []*Ref{
 		{
@@ -318,6 +443,40 @@ func TestRecentBranches(t *testing.T) {
 	sort.Sort(test.RefsByName(expectedRefs))
 	sort.Sort(test.RefsByName(refs))
 	assert.Equal(t, expectedRefs, refs, "Refs should be correct")
+
+	// Recent, local only, with tags
+	test.RunGitCommand(t, true, "tag", "included_tag", outputs[3].Sha)
+	test.RunGitCommand(t, true, "tag", "-a", "-m", "annotated", "included_annotated_tag", outputs[4].Sha)
+	refs, err = RecentBranches(now.AddDate(0, 0, -7), false, "", true)
+	assert.Equal(t, nil, err)
+	expectedRefs = []*Ref{
+		{
+			Name: "master",
+			Type: RefTypeLocalBranch,
+			Sha:  outputs[5].Sha,
+		},
+		{
+			Name: "included_branch_2",
+			Type: RefTypeLocalBranch,
+			Sha:  outputs[4].Sha,
+		},
+		{
+			Name: "included_branch",
+			Type: RefTypeLocalBranch,
+			Sha:  outputs[3].Sha,
+		},
+		{
+			Name: "included_annotated_tag",
+			Type: RefTypeLocalTag,
+			Sha:  outputs[4].Sha,
+		},
+		{
+			Name: "included_tag",
+			Type: RefTypeLocalTag,
+			Sha:  outputs[3].Sha,
+		},
+	}
+	assert.Equal(t, expectedRefs, refs, "Refs should be correct")
 }
 
 func TestResolveEmptyCurrentRef(t *testing.T) {
@@ -448,6 +607,48 @@ func TestGitAndRootDirs(t *testing.T) {
 	assert.True(t, os.SameFile(expected, actual))
 }
 
+func TestGitAndRootDirsInSubmodule(t *testing.T) {
+	sub := test.NewRepo(t)
+	defer sub.Cleanup()
+	sub.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+	})
+
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Submodules: []*test.SubmoduleInput{
+				{Path: "sub", Repo: sub},
+			},
+		},
+	})
+
+	oldwd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(filepath.Join(repo.Path, "sub")))
+	defer os.Chdir(oldwd)
+
+	git, root, err := GitAndRootDirs()
+	require.Nil(t, err)
+
+	expected, err := os.Stat(filepath.Join(repo.GitDir, "modules", "sub"))
+	require.Nil(t, err)
+	actual, err := os.Stat(git)
+	require.Nil(t, err)
+	assert.True(t, os.SameFile(expected, actual))
+
+	expected, err = os.Stat(filepath.Join(repo.Path, "sub"))
+	require.Nil(t, err)
+	actual, err = os.Stat(root)
+	require.Nil(t, err)
+	assert.True(t, os.SameFile(expected, actual))
+}
+
 func TestGetTrackedFiles(t *testing.T) {
 	repo := test.NewRepo(t)
 	repo.Pushd()
@@ -608,6 +809,149 @@ func TestLocalRefs(t *testing.T) {
 	}
 }
 
+func TestLocalRefsInBareRepo(t *testing.T) {
+	bare := test.NewBareRepo(t)
+	defer bare.Cleanup()
+
+	repo := bare.Clone()
+	defer repo.Cleanup()
+
+	repo.Pushd()
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+			Tags:            []string{"annotated"},
+			LightweightTags: []string{"lightweight"},
+		},
+	})
+	test.RunGitCommand(t, true, "push", "--quiet", "--tags", "origin", "HEAD:refs/heads/main")
+	repo.Popd()
+
+	bare.Pushd()
+	defer bare.Popd()
+
+	refs, err := LocalRefs()
+	require.Nil(t, err)
+
+	actual := make(map[string]RefType)
+	for _, r := range refs {
+		actual[r.Name] = r.Type
+	}
+	assert.Equal(t, map[string]RefType{
+		"main":        RefTypeLocalBranch,
+		"annotated":   RefTypeLocalTag,
+		"lightweight": RefTypeLocalTag,
+	}, actual)
+}
+
+func TestRefsInSHA256Repo(t *testing.T) {
+	if !IsGitVersionAtLeast("2.29.0") {
+		t.Skip("git version does not support SHA-256 repositories")
+	}
+
+	repo := test.NewRepoWithObjectFormat(t, "sha256")
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+			Tags: []string{"v1"},
+		},
+		{
+			NewBranch: "branch",
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 25},
+			},
+		},
+	})
+	require.Len(t, outputs[1].Sha, SHA256HexSize)
+	assert.Equal(t, []string{outputs[0].Sha}, outputs[1].Parents)
+
+	ref, err := CurrentRef()
+	require.Nil(t, err)
+	assert.Equal(t, &Ref{
+		Name: "branch",
+		Type: RefTypeLocalBranch,
+		Sha:  outputs[1].Sha,
+	}, ref)
+
+	ref, err = ResolveRef(outputs[0].Sha)
+	require.Nil(t, err)
+	assert.Equal(t, &Ref{
+		Name: outputs[0].Sha,
+		Type: RefTypeOther,
+		Sha:  outputs[0].Sha,
+	}, ref)
+
+	sha, err := ResolveCommit("v1")
+	require.Nil(t, err)
+	assert.Equal(t, outputs[0].Sha, sha)
+
+	refs, err := LocalRefs()
+	require.Nil(t, err)
+	actual := make(map[string]string)
+	for _, r := range refs {
+		actual[r.Name] = r.Sha
+	}
+	assert.Equal(t, outputs[0].Sha, actual["master"])
+	assert.Equal(t, outputs[1].Sha, actual["branch"])
+	assert.Contains(t, actual, "v1")
+
+	repo.AddRemote("origin")
+	test.RunGitCommand(t, true, "push", "--quiet", "origin", "branch")
+
+	refs, err = RemoteRefs("origin")
+	require.Nil(t, err)
+	assert.Equal(t, []*Ref{{Name: "branch", Type: RefTypeRemoteBranch, Sha: outputs[1].Sha}}, refs)
+
+	refs, err = CachedRemoteRefs("origin")
+	require.Nil(t, err)
+	assert.Equal(t, []*Ref{{Name: "branch", Type: RefTypeRemoteBranch, Sha: outputs[1].Sha}}, refs)
+
+	assert.Len(t, EmptyTree(), SHA256HexSize)
+}
+
+func TestGetCommitSummaryOfSignedCommit(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.ConfigureSigning()
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 30},
+			},
+			Tags:   []string{"v1"},
+			Signed: true,
+		},
+	})
+	test.RunGitCommand(t, true, "verify-commit", "HEAD")
+	test.RunGitCommand(t, true, "verify-tag", "v1")
+
+	commit, err := GetCommitSummary("HEAD")
+	require.Nil(t, err)
+	assert.Equal(t, outputs[1].Sha, commit.Sha)
+	assert.Equal(t, []string{outputs[0].Sha}, commit.Parents)
+	assert.Equal(t, "Test commit 1", commit.Subject)
+}
+
 func TestGetFilesChanges(t *testing.T) {
 	repo := test.NewRepo(t)
 	repo.Pushd()
@@ -572,8 +572,6 @@ func (r *Rewriter) scannerOpts() *git.ScanRefsOptions {
 		CommitsOnly: true,
 
 		SkippedRefs: make([]string, 0),
-		Mutex:       new(sync.Mutex),
-		Names:       make(map[string]string),
 	}
 
 	if root, ok := r.db.Root(); ok {
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+// WorktreeChanges returns the paths, relative to the root of the working tree,
+// of the files in the working tree which differ from their entries in the
+// index, including deleted and unmerged files.
+//
+// They are found by git-status(1), which asks Git's file system monitor which
+// files have changed, if "core.fsmonitor" is set, rather than examining every
+// file, so that the state of a large working tree can be found without walking
+// it.  Optional locks are not taken, so the index is not written.
+func WorktreeChanges() (map[string]struct{}, error) {
+	// The output is not trimmed, as each line begins with the status in
+	// the index, which is a space if the file is unchanged there.
+	out, err := git("--no-optional-locks", "status", "--porcelain", "-z",
+		"--untracked-files=no", "--no-renames", "--ignore-submodules=all").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to run git status")
+	}
+	return parseStatusPorcelain(string(out)), nil
+}
+
+// parseStatusPorcelain reads the output of "git status --porcelain -z
+// --no-renames", in which each path is preceded by its status in the index
+// and in the working tree, and returns the paths which have changed in the
+// working tree.
+func parseStatusPorcelain(out string) map[string]struct{} {
+	changed := make(map[string]struct{})
+	for _, entry := range strings.Split(out, "\x00") {
+		if len(entry) < 4 || entry[1] == ' ' {
+			continue
+		}
+		changed[entry[3:]] = struct{}{}
+	}
+	return changed
+}
+
+// WorktreeSizes returns the size of each file in the working tree which Git's
+// file system monitor reports as unchanged since its entry in the index was
+// written, as recorded in that entry, keyed by its path relative to the root
+// of the working tree.  Other files, whose entries may be out of date, are
+// omitted, as are all files if "core.fsmonitor" is not set.  The index is not
+// written.  It must be run from the root of the working tree.
+func WorktreeSizes() (map[string]int64, error) {
+	cmd, err := gitNoLFSBuffered("ls-files", "--cached", "--debug", "-f", "-z")
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Stdin.Close(); err != nil {
+		return nil, err
+	}
+
+	sizes, err := parseIndexDebug(cmd.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Wait(); err != nil {
+		return nil, errors.Wrap(err, "Failed to run git ls-files")
+	}
+	return sizes, nil
+}
+
+// parseIndexDebug reads the output of "git ls-files --debug -f -z", in which
+// each path is preceded by a tag and a space, and followed by a NUL and five
+// lines of the stat data of its entry in the index, and returns the size of
+// each path whose tag is in lower case, marking its entry as valid according
+// to the file system monitor.
+func parseIndexDebug(r io.Reader) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	br := bufio.NewReader(r)
+	for {
+		name, err := br.ReadString(0)
+		if err == io.EOF && len(name) == 0 {
+			return sizes, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading index entry")
+		}
+		name = name[:len(name)-1]
+		if len(name) < 3 || name[1] != ' ' {
+			return nil, errors.Errorf("invalid index entry %q", name)
+		}
+		valid := unicode.IsLower(rune(name[0]))
+		name = name[2:]
+
+		size := int64(-1)
+		for i := 0; i < 5; i++ {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading stat data of %q", name)
+			}
+
+			for _, field := range strings.Split(strings.TrimSpace(line), "\t") {
+				if !strings.HasPrefix(field, "size: ") {
+					continue
+				}
+				if size, err = strconv.ParseInt(strings.TrimPrefix(field, "size: "), 10, 64); err != nil {
+					return nil, errors.Wrapf(err, "parsing size of %q", name)
+				}
+			}
+		}
+		if size < 0 {
+			return nil, errors.Errorf("no size in stat data of %q", name)
+		}
+		if valid {
+			sizes[name] = size
+		}
+	}
+}
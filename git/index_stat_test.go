@@ -0,0 +1,62 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIndexDebug(t *testing.T) {
+	out := "h a.dat\x00" +
+		"  ctime: 1792076993:682931843\n" +
+		"  mtime: 1792076993:682931843\n" +
+		"  dev: 65024\tino: 16040369\n" +
+		"  uid: 0\tgid: 0\n" +
+		"  size: 1024\tflags: 0\n" +
+		"h dir/b c.dat\x00" +
+		"  ctime: 1792076993:682931843\n" +
+		"  mtime: 1792076993:682931843\n" +
+		"  dev: 65024\tino: 16040385\n" +
+		"  uid: 0\tgid: 0\n" +
+		"  size: 130\tflags: 0\n" +
+		"H changed.dat\x00" +
+		"  ctime: 1792076993:682931843\n" +
+		"  mtime: 1792076993:682931843\n" +
+		"  dev: 65024\tino: 16040386\n" +
+		"  uid: 0\tgid: 0\n" +
+		"  size: 20\tflags: 0\n"
+
+	sizes, err := parseIndexDebug(strings.NewReader(out))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int64{"a.dat": 1024, "dir/b c.dat": 130}, sizes)
+}
+
+func TestParseIndexDebugEmpty(t *testing.T) {
+	sizes, err := parseIndexDebug(strings.NewReader(""))
+	require.Nil(t, err)
+	assert.Empty(t, sizes)
+}
+
+func TestParseIndexDebugTruncated(t *testing.T) {
+	_, err := parseIndexDebug(strings.NewReader("h a.dat\x00  ctime: 1:2\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseStatusPorcelain(t *testing.T) {
+	out := " M a.dat\x00" +
+		"M  staged.dat\x00" +
+		"MD dir/b c.dat\x00" +
+		"UU conflict.dat\x00"
+
+	assert.Equal(t, map[string]struct{}{
+		"a.dat":        struct{}{},
+		"dir/b c.dat":  struct{}{},
+		"conflict.dat": struct{}{},
+	}, parseStatusPorcelain(out))
+}
+
+func TestParseStatusPorcelainEmpty(t *testing.T) {
+	assert.Empty(t, parseStatusPorcelain(""))
+}
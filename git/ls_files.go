@@ -8,7 +8,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type lsFileInfo struct {
@@ -20,22 +20,28 @@ type LsFiles struct {
 	FilesByName map[string][]*lsFileInfo
 }
 
-func NewLsFiles(workingDir string, standardExclude bool) (*LsFiles, error) {
+// NewLsFiles lists the files in the index of the repository at "workingDir",
+// along with untracked files if "untracked" is set, excluding those ignored
+// by the standard Git exclusions if "standardExclude" is set.
+func NewLsFiles(workingDir string, standardExclude bool, untracked bool) (*LsFiles, error) {
 
 	args := []string{
 		"ls-files",
 		"-z", // Use a NUL separator. This also disables the escaping of special characters.
-		"--others",
 		"--cached",
 	}
 
+	if untracked {
+		args = append(args, "--others")
+	}
+
 	if standardExclude {
 		args = append(args, "--exclude-standard")
 	}
 	cmd := gitNoLFS(args...)
 	cmd.Dir = workingDir
 
-	tracerx.Printf("NewLsFiles: running in %s git %s",
+	tracelog.Printf("NewLsFiles: running in %s git %s",
 		workingDir, strings.Join(args, " "))
 
 	// Capture stdout and stderr
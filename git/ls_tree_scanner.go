@@ -10,7 +10,11 @@ import (
 
 // An entry from ls-tree or rev-list including a blob sha and tree path
 type TreeBlob struct {
-	Oid      string
+	Oid string
+	// Mode is the file mode of the blob, such as 0100644, or 0 if it is
+	// not known.
+	Mode int32
+	// Size is the size of the blob, or -1 if it is not known.
 	Size     int64
 	Filename string
 }
@@ -49,7 +53,7 @@ func (s *LsTreeScanner) next() (*TreeBlob, bool) {
 	}
 
 	attrs := strings.SplitN(parts[0], " ", 4)
-	if len(attrs) < 4 {
+	if len(attrs) < 3 {
 		return nil, hasNext
 	}
 
@@ -57,14 +61,24 @@ func (s *LsTreeScanner) next() (*TreeBlob, bool) {
 		return nil, hasNext
 	}
 
-	sz, err := strconv.ParseInt(strings.TrimSpace(attrs[3]), 10, 64)
+	// The size is only listed by "git ls-tree -l".
+	sz := int64(-1)
+	if len(attrs) == 4 {
+		var err error
+		sz, err = strconv.ParseInt(strings.TrimSpace(attrs[3]), 10, 64)
+		if err != nil {
+			return nil, hasNext
+		}
+	}
+
+	mode, err := strconv.ParseInt(attrs[0], 8, 32)
 	if err != nil {
 		return nil, hasNext
 	}
 
 	oid := attrs[2]
 	filename := parts[1]
-	return &TreeBlob{Oid: oid, Size: sz, Filename: filename}, hasNext
+	return &TreeBlob{Oid: oid, Mode: int32(mode), Size: sz, Filename: filename}, hasNext
 }
 
 func scanNullLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
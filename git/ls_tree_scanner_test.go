@@ -31,6 +31,30 @@ func TestLsTreeParser(t *testing.T) {
 	assertScannerDone(t, scanner)
 }
 
+func TestLsTreeParserWithoutSizes(t *testing.T) {
+	stdout := "100644 blob d899f6551a51cf19763c5955c7a06a2726f018e9	.gitattributes\000040000 tree 8e5d2c1bd1a4ee4ee3b1a2b2e6d0b7d2f4c6a8e0	dir\000100644 blob 4d343e022e11a8618db494dc3c501e80c7e18197	dir/a.dat"
+	scanner := NewLsTreeScanner(strings.NewReader(stdout))
+
+	assertNextTreeBlob(t, scanner, "d899f6551a51cf19763c5955c7a06a2726f018e9", ".gitattributes")
+	assert.Equal(t, int64(-1), scanner.TreeBlob().Size)
+	assertNextScan(t, scanner)
+	assert.Nil(t, scanner.TreeBlob())
+	assertNextTreeBlob(t, scanner, "4d343e022e11a8618db494dc3c501e80c7e18197", "dir/a.dat")
+	assert.Equal(t, int64(-1), scanner.TreeBlob().Size)
+	assertScannerDone(t, scanner)
+}
+
+func TestLsTreeParserModes(t *testing.T) {
+	stdout := "100755 blob d899f6551a51cf19763c5955c7a06a2726f018e9      42	run.sh\000120000 blob 4d343e022e11a8618db494dc3c501e80c7e18197       6	link"
+	scanner := NewLsTreeScanner(strings.NewReader(stdout))
+
+	assertNextTreeBlob(t, scanner, "d899f6551a51cf19763c5955c7a06a2726f018e9", "run.sh")
+	assert.Equal(t, int32(0100755), scanner.TreeBlob().Mode)
+	assertNextTreeBlob(t, scanner, "4d343e022e11a8618db494dc3c501e80c7e18197", "link")
+	assert.Equal(t, int32(0120000), scanner.TreeBlob().Mode)
+	assertScannerDone(t, scanner)
+}
+
 func assertNextTreeBlob(t *testing.T, scanner *LsTreeScanner, oid, filename string) {
 	assertNextScan(t, scanner)
 	b := scanner.TreeBlob()
@@ -0,0 +1,24 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Maintenance runs "git maintenance" with the given subcommand, such as
+// "register" or "start", for the current repository.
+func Maintenance(subcommand string) error {
+	if !IsGitVersionAtLeast("2.30.0") {
+		return errors.New("git maintenance requires Git 2.30 or later")
+	}
+
+	cmd := gitNoLFS("maintenance", subcommand)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git maintenance %s failed: %v", subcommand, err)
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/git-lfs/gitobj/v2"
 	"github.com/git-lfs/gitobj/v2/errors"
@@ -33,6 +36,12 @@ type ObjectScanner struct {
 	err error
 
 	gitobj *gitobj.ObjectDatabase
+	// reopen, if set, opens the object database afresh.
+	reopen func() (*gitobj.ObjectDatabase, error)
+	// packDir is the directory of the object database's packs, and
+	// packModTime its modification time when the database was opened.
+	packDir     string
+	packModTime time.Time
 }
 
 // NewObjectScanner constructs a new instance of the `*ObjectScanner` type and
@@ -47,12 +56,20 @@ func NewObjectScanner(gitEnv, osEnv Environment) (*ObjectScanner, error) {
 		return nil, err
 	}
 
-	gitobj, err := ObjectDatabase(osEnv, gitEnv, gitdir, "")
+	reopen := func() (*gitobj.ObjectDatabase, error) {
+		return ObjectDatabase(osEnv, gitEnv, gitdir, "")
+	}
+	packDir := filepath.Join(gitdir, "objects", "pack")
+	packModTime := modTime(packDir)
+
+	gitobj, err := reopen()
 	if err != nil {
 		return nil, err
 	}
 
-	return NewObjectScannerFrom(gitobj), nil
+	s := NewObjectScannerFrom(gitobj)
+	s.reopen, s.packDir, s.packModTime = reopen, packDir, packModTime
+	return s, nil
 }
 
 // NewObjectScannerFrom returns a new `*ObjectScanner` populated with data from
@@ -153,6 +170,37 @@ func IsMissingObject(err error) bool {
 	return ok
 }
 
+// refresh opens the object database afresh if packs have been added to it since
+// it was opened, for instance by Git fetching objects missing from a partial
+// clone, since they are otherwise not found.  It returns whether it did.
+func (s *ObjectScanner) refresh() bool {
+	if s.reopen == nil {
+		return false
+	}
+	mtime := modTime(s.packDir)
+	if !mtime.After(s.packModTime) {
+		return false
+	}
+
+	db, err := s.reopen()
+	if err != nil {
+		return false
+	}
+	s.gitobj.Close()
+	s.gitobj, s.packModTime = db, mtime
+	return true
+}
+
+// modTime returns the modification time of path, or the zero time if it cannot
+// be found.
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
 func mustDecode(oid string) []byte {
 	x, _ := hex.DecodeString(oid)
 	return x
@@ -166,6 +214,9 @@ func (s *ObjectScanner) scan(oid string) (*object, error) {
 	)
 
 	obj, err := s.gitobj.Object(mustDecode(oid))
+	if err != nil && errors.IsNoSuchObject(err) && s.refresh() {
+		obj, err = s.gitobj.Object(mustDecode(oid))
+	}
 	if err != nil {
 		if errors.IsNoSuchObject(err) {
 			return nil, &missingErr{oid: oid}
@@ -3,7 +3,7 @@ package git
 import (
 	"fmt"
 
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type RefUpdate struct {
@@ -65,7 +65,7 @@ func defaultRemoteRef(g Env, remote string, left *Ref) *Ref {
 		// receiving end.
 		return left
 	default:
-		tracerx.Printf("WARNING: %q push mode not supported", pushMode)
+		tracelog.Printf("WARNING: %q push mode not supported", pushMode)
 		return left
 	}
 }
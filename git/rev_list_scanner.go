@@ -8,10 +8,9 @@ import (
 	"io/ioutil"
 	"regexp"
 	"strings"
-	"sync"
 
 	"github.com/git-lfs/git-lfs/errors"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // ScanningMode is a constant type that allows for variation in the range of
@@ -100,34 +99,10 @@ type ScanRefsOptions struct {
 
 	// SkippedRefs provides a list of refs to ignore.
 	SkippedRefs []string
-	// Mutex guards names.
-	Mutex *sync.Mutex
-	// Names maps Git object IDs (encoded as hex using
-	// hex.EncodeString()) to their names, i.e., a directory name
-	// (fully-qualified) for trees, or a pathspec for blob tree entries.
-	Names map[string]string
-}
-
-// GetName returns the name associated with a given blob/tree sha and "true" if
-// it exists, or ("", false) if it doesn't.
-//
-// GetName is guarded by a use of o.Mutex, and is goroutine safe.
-func (o *ScanRefsOptions) GetName(sha string) (string, bool) {
-	o.Mutex.Lock()
-	defer o.Mutex.Unlock()
-
-	name, ok := o.Names[sha]
-	return name, ok
-}
-
-// SetName sets the name associated with a given blob/tree sha.
-//
-// SetName is guarded by a use of o.Mutex, and is therefore goroutine safe.
-func (o *ScanRefsOptions) SetName(sha, name string) {
-	o.Mutex.Lock()
-	defer o.Mutex.Unlock()
-
-	o.Names[sha] = name
+	// PrintMissing specifies whether or not objects missing from a
+	// partial clone should be listed, without their names, rather than
+	// fetched one at a time from the promisor remote.
+	PrintMissing bool
 }
 
 // RevListScanner is a Scanner type that parses through results of the `git
@@ -183,7 +158,7 @@ func NewRevListScanner(include, excluded []string, opt *ScanRefsOptions) (*RevLi
 		return nil, err
 	}
 
-	tracerx.Printf("run_command: git %s", strings.Join(args, " "))
+	tracelog.Printf("run_command: git %s", strings.Join(args, " "))
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
@@ -226,6 +201,10 @@ func revListArgs(include, exclude []string, opt *ScanRefsOptions) (io.Reader, []
 		args = append(args, "--objects")
 	}
 
+	if opt.PrintMissing {
+		args = append(args, "--missing=print")
+	}
+
 	if opt.Reverse {
 		args = append(args, "--reverse")
 	}
@@ -342,7 +321,9 @@ func (s *RevListScanner) scan() ([]byte, string, error) {
 		return nil, "", s.s.Err()
 	}
 
-	line := strings.TrimSpace(s.s.Text())
+	// Missing objects are listed as "?<oid>" when PrintMissing is
+	// given.
+	line := strings.TrimPrefix(strings.TrimSpace(s.s.Text()), "?")
 	if len(line) < ObjectIDLengths[0] {
 		return nil, "", nil
 	}
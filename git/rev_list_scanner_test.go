@@ -153,6 +153,14 @@ func TestRevListArgs(t *testing.T) {
 			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
 			ExpectedArgs:  []string{"rev-list", "--objects", "--reverse", "--do-walk", "--stdin", "--"},
 		},
+		"scan print missing": {
+			Include: []string{s1}, Exclude: []string{s2}, Opt: &ScanRefsOptions{
+				Mode:         ScanRefsMode,
+				PrintMissing: true,
+			},
+			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
+			ExpectedArgs:  []string{"rev-list", "--objects", "--missing=print", "--do-walk", "--stdin", "--"},
+		},
 	} {
 		t.Run(desc, c.Assert)
 	}
@@ -217,3 +225,40 @@ func TestRevListScannerParsesLinesWithoutName(t *testing.T) {
 	assert.Nil(t, s.OID())
 	assert.Nil(t, s.Err())
 }
+
+func TestRevListScannerParsesMissingLines(t *testing.T) {
+	given := "?aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	s := &RevListScanner{
+		s: bufio.NewScanner(strings.NewReader(given)),
+	}
+
+	assert.True(t, s.Scan())
+	assert.Equal(t, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", hex.EncodeToString(s.OID()))
+	assert.Equal(t, "", s.Name())
+	assert.Nil(t, s.Err())
+
+	assert.False(t, s.Scan())
+	assert.Nil(t, s.OID())
+	assert.Nil(t, s.Err())
+}
+
+func TestRevListScannerParsesSHA256Lines(t *testing.T) {
+	oid := strings.Repeat("b", SHA256HexSize)
+	given := oid + " name.dat\n?" + oid
+	s := &RevListScanner{
+		s: bufio.NewScanner(strings.NewReader(given)),
+	}
+
+	assert.True(t, s.Scan())
+	assert.Equal(t, oid, hex.EncodeToString(s.OID()))
+	assert.Equal(t, "name.dat", s.Name())
+	assert.Nil(t, s.Err())
+
+	assert.True(t, s.Scan())
+	assert.Equal(t, oid, hex.EncodeToString(s.OID()))
+	assert.Equal(t, "", s.Name())
+	assert.Nil(t, s.Err())
+
+	assert.False(t, s.Scan())
+	assert.Nil(t, s.Err())
+}
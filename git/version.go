@@ -6,7 +6,7 @@ import (
 	"sync"
 
 	"github.com/git-lfs/git-lfs/subprocess"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var (
@@ -28,7 +28,7 @@ func Version() (string, error) {
 func IsGitVersionAtLeast(ver string) bool {
 	gitver, err := Version()
 	if err != nil {
-		tracerx.Printf("Error getting git version: %v", err)
+		tracelog.Printf("Error getting git version: %v", err)
 		return false
 	}
 	return IsVersionAtLeast(gitver, ver)
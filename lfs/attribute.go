@@ -165,17 +165,96 @@ func (a *Attribute) set(gitConfig *git.Configuration, key, value string, upgrade
 	return nil
 }
 
-// Uninstall removes all properties in the path of this property.
+// Uninstall removes the properties of this Attribute which are set to the
+// values given by Install, or to any of their upgradeable values, leaving any
+// others alone.  If no properties are left in the Section, the Section is
+// removed as well.
 func (a *Attribute) Uninstall(opt *FilterOptions) error {
+	// Reading the section first reports any error in the scope, such
+	// as --worktree being used without the worktreeConfig extension,
+	// which looking up single keys would not.
+	if _, err := opt.findSection(a.Section); err != nil {
+		return err
+	}
+
+	for k, v := range a.Properties {
+		key := a.normalizeKey(k)
+		value := opt.find(key)
+		if len(value) == 0 {
+			continue
+		}
+		if value != v && !shouldReset(value, a.Upgradeables[k]) {
+			continue
+		}
+		if err := opt.unset(key); err != nil {
+			return err
+		}
+	}
+
+	remaining, err := opt.findSection(a.Section)
+	if err != nil {
+		return err
+	}
+	if len(remaining) == 0 {
+		// Unsetting the last key of a section leaves its header
+		// behind, so remove that too.  This fails if the section
+		// was never there, which is fine.
+		opt.unsetSection(a.Section)
+	}
+	return nil
+}
+
+// find returns the value of the key in the scope given by these options.
+func (o *FilterOptions) find(key string) string {
+	if o.Local {
+		return o.GitConfig.FindLocal(key)
+	} else if o.Worktree {
+		return o.GitConfig.FindWorktree(key)
+	} else if o.System {
+		return o.GitConfig.FindSystem(key)
+	}
+	return o.GitConfig.FindGlobal(key)
+}
+
+// unset removes the key from the scope given by these options.
+func (o *FilterOptions) unset(key string) error {
 	var err error
-	if opt.Local {
-		_, err = opt.GitConfig.UnsetLocalSection(a.Section)
-	} else if opt.Worktree {
-		_, err = opt.GitConfig.UnsetWorktreeSection(a.Section)
-	} else if opt.System {
-		_, err = opt.GitConfig.UnsetSystemSection(a.Section)
+	if o.Local {
+		_, err = o.GitConfig.UnsetLocalKey(key)
+	} else if o.Worktree {
+		_, err = o.GitConfig.UnsetWorktreeKey(key)
+	} else if o.System {
+		_, err = o.GitConfig.UnsetSystemKey(key)
+	} else {
+		_, err = o.GitConfig.UnsetGlobalKey(key)
+	}
+	return err
+}
+
+// findSection returns the keys and values of the section in the scope given by
+// these options, or an error if they could not be read.
+func (o *FilterOptions) findSection(section string) (string, error) {
+	if o.Local {
+		return o.GitConfig.FindLocalSection(section)
+	} else if o.Worktree {
+		return o.GitConfig.FindWorktreeSection(section)
+	} else if o.System {
+		return o.GitConfig.FindSystemSection(section)
+	}
+	return o.GitConfig.FindGlobalSection(section)
+}
+
+// unsetSection removes the section from the scope given by these options.
+func (o *FilterOptions) unsetSection(section string) error {
+	var err error
+	if o.Local {
+		_, err = o.GitConfig.UnsetLocalSection(section)
+	} else if o.Worktree {
+		_, err = o.GitConfig.UnsetWorktreeSection(section)
+	} else if o.System {
+		_, err = o.GitConfig.UnsetSystemSection(section)
 	} else {
-		_, err = opt.GitConfig.UnsetGlobalSection(a.Section)
+		_, err = o.GitConfig.UnsetGlobalSection(section)
 	}
 	return err
 }
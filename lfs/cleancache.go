@@ -0,0 +1,285 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// cleanCacheEntry records the object ID of the contents of a file in the
+// working tree, along with the size, modification time and inode of the file
+// when it was cleaned. As with the stat information in Git's index, a file
+// whose status still matches its entry is assumed to be unchanged, so that it
+// need not be hashed again.
+type cleanCacheEntry struct {
+	path      string
+	size      int64
+	mtime     int64
+	inode     uint64
+	algorithm string
+	oid       string
+}
+
+// statCleanCacheEntry returns an entry, without an object ID, describing the
+// current status of the regular file "fileName", or nil if it cannot be
+// cached.
+func statCleanCacheEntry(fileName, algorithm string) *cleanCacheEntry {
+	if len(fileName) == 0 {
+		return nil
+	}
+
+	path, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	return &cleanCacheEntry{
+		path:      path,
+		size:      fi.Size(),
+		mtime:     fi.ModTime().UnixNano(),
+		inode:     tools.Inode(fi),
+		algorithm: algorithm,
+	}
+}
+
+// matches returns whether e and other describe the same file in the same
+// status.
+func (e *cleanCacheEntry) matches(other *cleanCacheEntry) bool {
+	return e.path == other.path &&
+		e.size == other.size &&
+		e.mtime == other.mtime &&
+		e.inode == other.inode &&
+		e.algorithm == other.algorithm
+}
+
+// unchanged returns whether the file described by e still has the status it
+// had when e was taken.
+func (e *cleanCacheEntry) unchanged() bool {
+	current := statCleanCacheEntry(e.path, e.algorithm)
+	return current != nil && current.matches(e)
+}
+
+// racy returns whether the file could have been modified again, without
+// changing its status, at the given time. Like Git, modification times are
+// only trusted to the second, so that coarse filesystem timestamps are
+// handled.
+func (e *cleanCacheEntry) racy(now time.Time) bool {
+	return !time.Unix(0, e.mtime).Before(now.Truncate(time.Second))
+}
+
+func (e *cleanCacheEntry) String() string {
+	return fmt.Sprintf("%s %s %d %d %d %s\n", e.algorithm, e.oid, e.size, e.mtime, e.inode, e.path)
+}
+
+func parseCleanCacheEntry(data string) (*cleanCacheEntry, bool) {
+	fields := strings.SplitN(strings.TrimSuffix(data, "\n"), " ", 6)
+	if len(fields) != 6 {
+		return nil, false
+	}
+
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	mtime, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	inode, err := strconv.ParseUint(fields[4], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &cleanCacheEntry{
+		algorithm: fields[0],
+		oid:       fields[1],
+		size:      size,
+		mtime:     mtime,
+		inode:     inode,
+		path:      fields[5],
+	}, true
+}
+
+// cleanCachePath returns the path of the file holding the clean cache entry
+// for the file at the absolute path "path".
+func (f *GitFilter) cleanCachePath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(f.fs.LFSStorageDir, "cache", "clean", hex.EncodeToString(sum[:]))
+}
+
+// cachedOid returns the object ID recorded for the file described by e, if
+// its entry in the clean cache matches e and the object is in local storage.
+func (f *GitFilter) cachedOid(e *cleanCacheEntry) (string, bool) {
+	data, err := ioutil.ReadFile(f.cleanCachePath(e.path))
+	if err != nil {
+		return "", false
+	}
+
+	cached, ok := parseCleanCacheEntry(string(data))
+	if !ok || !cached.matches(e) || !f.fs.ObjectExists(cached.oid, cached.size) {
+		return "", false
+	}
+
+	tracelog.Printf("clean cache: %s is unchanged as %s", e.path, cached.oid)
+	return cached.oid, true
+}
+
+// cacheOid records oid as the object ID of the file described by e, unless the
+// file has been modified since e was taken, or may yet be modified without its
+// status changing. Failures are only traced, since the cache is an
+// optimization.
+func (f *GitFilter) cacheOid(e *cleanCacheEntry, oid string) {
+	if !e.unchanged() || e.racy(time.Now()) {
+		return
+	}
+	e.oid = oid
+
+	path := f.cleanCachePath(e.path)
+	if err := f.writeCleanCacheEntry(path, e); err != nil {
+		tracelog.Printf("unable to write clean cache entry for %s: %v", e.path, err)
+	}
+}
+
+func (f *GitFilter) writeCleanCacheEntry(path string, e *cleanCacheEntry) error {
+	if err := tools.MkdirAll(filepath.Dir(path), f.fs); err != nil {
+		return err
+	}
+
+	tmp, err := TempFile(f.cfg, "cleancache")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(e.String())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fileMatcher compares the contents cleaned from a stream with those of the
+// file they are cleaned for, since the clean cache may only describe the file
+// itself.
+type fileMatcher struct {
+	file    *os.File
+	read    int64
+	differs bool
+	buf     []byte
+}
+
+func newFileMatcher(path string) (*fileMatcher, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileMatcher{file: file}, nil
+}
+
+// compare returns whether p holds the next contents of the file.
+func (m *fileMatcher) compare(p []byte) bool {
+	if m.differs {
+		return false
+	}
+
+	if cap(m.buf) < len(p) {
+		m.buf = make([]byte, len(p))
+	}
+	buf := m.buf[:len(p)]
+	if n, _ := io.ReadFull(m.file, buf); n != len(p) || !bytes.Equal(buf, p) {
+		m.differs = true
+		return false
+	}
+	m.read += int64(len(p))
+	return true
+}
+
+// matched returns whether all of the contents compared were those of the
+// file, and the whole of the file has been compared.
+func (m *fileMatcher) matched() bool {
+	if m.differs {
+		return false
+	}
+
+	var b [1]byte
+	n, _ := m.file.ReadAt(b[:], m.read)
+	return n == 0
+}
+
+// reader returns a reader of r which compares the contents read with those of
+// the file.
+func (m *fileMatcher) reader(r io.Reader) io.Reader {
+	return &matchingReader{r: r, m: m}
+}
+
+// consume reads r for as long as its contents are those of the file. It
+// returns true if they all were, or otherwise a reader from which all of the
+// contents of r may be read again.
+func (m *fileMatcher) consume(r io.Reader, size int64, cb tools.CopyCallback) (bool, io.Reader, error) {
+	if size <= 0 {
+		cb = nil
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if !m.compare(buf[:n]) {
+				// Only the contents which matched were read
+				// from the file, so they may be read from it
+				// again.
+				return false, io.MultiReader(io.NewSectionReader(m.file, 0, m.read), bytes.NewReader(buf[:n]), r), nil
+			}
+			if cb != nil {
+				if cerr := cb(size, m.read, n); cerr != nil {
+					return false, nil, cerr
+				}
+			}
+		}
+		if err == io.EOF {
+			if m.matched() {
+				return true, nil, nil
+			}
+			return false, io.NewSectionReader(m.file, 0, m.read), nil
+		}
+		if err != nil {
+			return false, nil, err
+		}
+	}
+}
+
+func (m *fileMatcher) Close() error {
+	return m.file.Close()
+}
+
+type matchingReader struct {
+	r io.Reader
+	m *fileMatcher
+}
+
+func (r *matchingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.m.compare(p[:n])
+	}
+	return n, err
+}
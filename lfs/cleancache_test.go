@@ -0,0 +1,123 @@
+package lfs_test // to avoid import cycles
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/lfs"
+	test "github.com/git-lfs/git-lfs/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanFile(t *testing.T, gf *lfs.GitFilter, fileName string, contents []byte) (string, *lfs.Pointer) {
+	cleaned, err := gf.Clean(bytes.NewReader(contents), fileName, int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer cleaned.Teardown()
+
+	if len(cleaned.Filename) > 0 {
+		mediafile, err := gf.ObjectPath(cleaned.Oid)
+		require.Nil(t, err)
+		require.Nil(t, os.Rename(cleaned.Filename, mediafile))
+	}
+	return cleaned.Filename, cleaned.Pointer
+}
+
+func TestCleanCacheSkipsUnchangedFiles(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	contents := bytes.Repeat([]byte("large file\n"), 100)
+	require.Nil(t, ioutil.WriteFile("a.dat", contents, 0644))
+	old := time.Now().Add(-time.Hour)
+	require.Nil(t, os.Chtimes("a.dat", old, old))
+
+	test.RunGitCommand(t, true, "config", "lfs.cleancache", "true")
+	gf := lfs.NewGitFilter(config.NewIn(repo.Path, ""))
+
+	tmp, first := cleanFile(t, gf, "a.dat", contents)
+	assert.NotEmpty(t, tmp)
+
+	tmp, second := cleanFile(t, gf, "a.dat", contents)
+	assert.Empty(t, tmp)
+	assert.Equal(t, first.Oid, second.Oid)
+	assert.Equal(t, first.Size, second.Size)
+
+	changed := bytes.Repeat([]byte("LARGE FILE\n"), 100)
+	require.Nil(t, ioutil.WriteFile("a.dat", changed, 0644))
+	require.Nil(t, os.Chtimes("a.dat", old, old.Add(time.Second)))
+
+	tmp, third := cleanFile(t, gf, "a.dat", changed)
+	assert.NotEmpty(t, tmp)
+	assert.NotEqual(t, first.Oid, third.Oid)
+}
+
+func TestCleanCacheSkipsRacyFiles(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	contents := bytes.Repeat([]byte("racy file\n"), 100)
+	require.Nil(t, ioutil.WriteFile("a.dat", contents, 0644))
+	now := time.Now().Add(time.Second)
+	require.Nil(t, os.Chtimes("a.dat", now, now))
+
+	test.RunGitCommand(t, true, "config", "lfs.cleancache", "true")
+	gf := lfs.NewGitFilter(config.NewIn(repo.Path, ""))
+
+	tmp, _ := cleanFile(t, gf, "a.dat", contents)
+	assert.NotEmpty(t, tmp)
+
+	tmp, _ = cleanFile(t, gf, "a.dat", contents)
+	assert.NotEmpty(t, tmp)
+}
+
+func TestCleanCacheComparesContentsWithFile(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	contents := bytes.Repeat([]byte("large file\n"), 100)
+	require.Nil(t, ioutil.WriteFile("a.dat", contents, 0644))
+	old := time.Now().Add(-time.Hour)
+	require.Nil(t, os.Chtimes("a.dat", old, old))
+
+	test.RunGitCommand(t, true, "config", "lfs.cleancache", "true")
+	gf := lfs.NewGitFilter(config.NewIn(repo.Path, ""))
+
+	// Other contents of the same size, cleaned for the file, are neither
+	// recorded for it nor given its cached object ID.
+	other := bytes.Repeat([]byte("other file\n"), 100)
+	tmp, first := cleanFile(t, gf, "a.dat", other)
+	assert.NotEmpty(t, tmp)
+
+	tmp, second := cleanFile(t, gf, "a.dat", contents)
+	assert.NotEmpty(t, tmp)
+	assert.NotEqual(t, first.Oid, second.Oid)
+
+	tmp, third := cleanFile(t, gf, "a.dat", other)
+	assert.NotEmpty(t, tmp)
+	assert.Equal(t, first.Oid, third.Oid)
+
+	tmp, fourth := cleanFile(t, gf, "a.dat", contents)
+	assert.Empty(t, tmp)
+	assert.Equal(t, second.Oid, fourth.Oid)
+
+	gf.DisableCleanCache()
+	tmp, _ = cleanFile(t, gf, "a.dat", contents)
+	assert.NotEmpty(t, tmp)
+}
@@ -14,6 +14,9 @@ type FetchPruneConfig struct {
 	FetchRecentCommitsDays int
 	// Whether to always fetch recent even without --recent
 	FetchRecentAlways bool
+	// Makes the FetchRecentRefsDays option apply to tags as well as
+	// branches (default false)
+	FetchRecentTags bool
 	// Number of days added to FetchRecent*; data outside combined window will be
 	// deleted when prune is run. (default 3)
 	PruneOffsetDays int
@@ -38,6 +41,7 @@ func NewFetchPruneConfig(git config.Environment) FetchPruneConfig {
 		FetchRecentRefsIncludeRemotes: git.Bool("lfs.fetchrecentremoterefs", true),
 		FetchRecentCommitsDays:        git.Int("lfs.fetchrecentcommitsdays", 0),
 		FetchRecentAlways:             git.Bool("lfs.fetchrecentalways", false),
+		FetchRecentTags:               git.Bool("lfs.fetchrecenttags", false),
 		PruneOffsetDays:               git.Int("lfs.pruneoffsetdays", 3),
 		PruneVerifyRemoteAlways:       git.Bool("lfs.pruneverifyremotealways", false),
 		PruneRemoteName:               pruneRemote,
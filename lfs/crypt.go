@@ -0,0 +1,212 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+const (
+	// CryptKeySize is the size, in bytes, of the secret used to encrypt
+	// objects with the "crypt" extension.
+	CryptKeySize = 32
+
+	// cryptMagic begins every object encrypted by the "crypt" extension,
+	// and is followed by a single version byte.
+	cryptMagic   = "git-lfs-crypt\x00"
+	cryptVersion = 1
+)
+
+var cryptHeader = append([]byte(cryptMagic), cryptVersion)
+
+// CryptKey encrypts and decrypts the contents of objects for the "crypt"
+// pointer extension.
+//
+// Objects are encrypted with AES-256 in CTR mode and authenticated with
+// HMAC-SHA256. The IV is derived from an HMAC of the plaintext, so that
+// cleaning the same file twice with the same key produces the same object, and
+// thus the same pointer, as Git expects of a clean filter.
+type CryptKey struct {
+	enc []byte
+	mac []byte
+	iv  []byte
+}
+
+// NewCryptKey returns a CryptKey which derives its encryption, authentication
+// and IV keys from the given secret, which must be CryptKeySize bytes long.
+func NewCryptKey(secret []byte) (*CryptKey, error) {
+	if len(secret) != CryptKeySize {
+		return nil, errors.Errorf("crypt: key must be %d bytes, not %d", CryptKeySize, len(secret))
+	}
+
+	return &CryptKey{
+		enc: deriveCryptKey(secret, "encryption"),
+		mac: deriveCryptKey(secret, "authentication"),
+		iv:  deriveCryptKey(secret, "iv"),
+	}, nil
+}
+
+func deriveCryptKey(secret []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("git-lfs crypt " + purpose))
+	return h.Sum(nil)
+}
+
+// LoadCryptKey returns the CryptKey configured by the hex-encoded secret in
+// the GIT_LFS_CRYPT_KEY environment variable, or else in the file named by
+// "lfs.crypt.keyfile".
+func LoadCryptKey(cfg *config.Configuration) (*CryptKey, error) {
+	encoded, _ := cfg.Os.Get("GIT_LFS_CRYPT_KEY")
+	if len(encoded) == 0 {
+		path, _ := cfg.Git.Get("lfs.crypt.keyfile")
+		if len(path) == 0 {
+			return nil, errors.New("crypt: no key configured; set lfs.crypt.keyfile or GIT_LFS_CRYPT_KEY")
+		}
+
+		path, err := tools.ExpandPath(path, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "crypt: key file")
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "crypt: key file")
+		}
+		encoded = string(data)
+	}
+
+	secret, err := hex.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.Wrap(err, "crypt: key must be hex-encoded")
+	}
+	return NewCryptKey(secret)
+}
+
+// GenerateCryptKey returns a new random hex-encoded secret suitable for
+// NewCryptKey.
+func GenerateCryptKey() (string, error) {
+	secret := make([]byte, CryptKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Encrypt reads plaintext from "r" and writes the encrypted object to "w". The
+// plaintext is spooled to a temporary file, since it must be read once to
+// derive the IV before it can be encrypted.
+func (k *CryptKey) Encrypt(cfg *config.Configuration, w io.Writer, r io.Reader) error {
+	tmp, err := TempFile(cfg, "crypt")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	ivMac := hmac.New(sha256.New, k.iv)
+	if _, err := io.Copy(io.MultiWriter(tmp, ivMac), r); err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return k.encrypt(w, tmp, ivMac.Sum(nil)[:aes.BlockSize])
+}
+
+func (k *CryptKey) encrypt(w io.Writer, r io.Reader, iv []byte) error {
+	block, err := aes.NewCipher(k.enc)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, k.mac)
+	out := io.MultiWriter(w, mac)
+
+	if _, err := out.Write(cryptHeader); err != nil {
+		return err
+	}
+	if _, err := out.Write(iv); err != nil {
+		return err
+	}
+
+	sw := &cipher.StreamWriter{S: cipher.NewCTR(block, iv), W: out}
+	if _, err := io.Copy(sw, r); err != nil {
+		return err
+	}
+
+	_, err = w.Write(mac.Sum(nil))
+	return err
+}
+
+// Decrypt reads an encrypted object from "r" and writes its plaintext to "w".
+// The plaintext is written as it is decrypted, before the object has been
+// authenticated, so callers must discard everything written to "w" if an error
+// is returned.
+func (k *CryptKey) Decrypt(w io.Writer, r io.Reader) error {
+	header := make([]byte, len(cryptHeader)+aes.BlockSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return errors.New("crypt: object is truncated or not encrypted")
+	}
+
+	if !bytes.Equal(header[:len(cryptHeader)], cryptHeader) {
+		return errors.New("crypt: object is not encrypted in a supported format")
+	}
+	iv := header[len(cryptHeader):]
+
+	block, err := aes.NewCipher(k.enc)
+	if err != nil {
+		return err
+	}
+	stream := cipher.NewCTR(block, iv)
+
+	mac := hmac.New(sha256.New, k.mac)
+	mac.Write(header)
+
+	// The MAC trails the ciphertext, so hold back the last sha256.Size
+	// bytes read until the end of the object is reached.
+	buf := make([]byte, 32*1024+sha256.Size)
+	var n int
+	for {
+		m, err := r.Read(buf[n:])
+		n += m
+
+		if n > sha256.Size {
+			data := buf[:n-sha256.Size]
+			mac.Write(data)
+			stream.XORKeyStream(data, data)
+			if _, werr := w.Write(data); werr != nil {
+				return werr
+			}
+
+			copy(buf, buf[n-sha256.Size:n])
+			n = sha256.Size
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if n != sha256.Size || !hmac.Equal(buf[:n], mac.Sum(nil)) {
+		return errors.New("crypt: object failed authentication; the key is wrong or the object is corrupt")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package lfs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCryptKey(t *testing.T, b byte) *CryptKey {
+	key, err := NewCryptKey(bytes.Repeat([]byte{b}, CryptKeySize))
+	require.Nil(t, err)
+	return key
+}
+
+func newTestCryptConfig(t *testing.T) *config.Configuration {
+	return config.NewFrom(config.Values{
+		Git: map[string][]string{"lfs.storage": []string{t.TempDir()}},
+	})
+}
+
+func TestCryptRoundTrip(t *testing.T) {
+	cfg := newTestCryptConfig(t)
+	key := newTestCryptKey(t, 1)
+
+	for _, size := range []int{0, 1, 31, 32, 33, 32 * 1024, 100*1024 + 7} {
+		plaintext := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+
+		var encrypted bytes.Buffer
+		require.Nil(t, key.Encrypt(cfg, &encrypted, bytes.NewReader(plaintext)))
+		assert.Equal(t, len(cryptHeader)+16+size+32, encrypted.Len())
+		if size > 16 {
+			assert.False(t, bytes.Contains(encrypted.Bytes(), plaintext))
+		}
+
+		var decrypted bytes.Buffer
+		require.Nil(t, key.Decrypt(&decrypted, bytes.NewReader(encrypted.Bytes())))
+		assert.Equal(t, string(plaintext), decrypted.String())
+	}
+}
+
+func TestCryptIsDeterministic(t *testing.T) {
+	cfg := newTestCryptConfig(t)
+	key := newTestCryptKey(t, 1)
+
+	var a, b, c bytes.Buffer
+	require.Nil(t, key.Encrypt(cfg, &a, strings.NewReader("hello")))
+	require.Nil(t, key.Encrypt(cfg, &b, strings.NewReader("hello")))
+	require.Nil(t, key.Encrypt(cfg, &c, strings.NewReader("hellp")))
+
+	assert.Equal(t, a.Bytes(), b.Bytes())
+	assert.NotEqual(t, a.Bytes(), c.Bytes())
+}
+
+func TestCryptDecryptWithWrongKey(t *testing.T) {
+	cfg := newTestCryptConfig(t)
+
+	var encrypted bytes.Buffer
+	require.Nil(t, newTestCryptKey(t, 1).Encrypt(cfg, &encrypted, strings.NewReader("secret")))
+
+	var decrypted bytes.Buffer
+	err := newTestCryptKey(t, 2).Decrypt(&decrypted, &encrypted)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed authentication")
+}
+
+func TestCryptDecryptCorrupt(t *testing.T) {
+	cfg := newTestCryptConfig(t)
+	key := newTestCryptKey(t, 1)
+
+	var encrypted bytes.Buffer
+	require.Nil(t, key.Encrypt(cfg, &encrypted, strings.NewReader("secret")))
+
+	corrupt := encrypted.Bytes()
+	corrupt[len(cryptHeader)+16] ^= 1
+
+	assert.NotNil(t, key.Decrypt(&bytes.Buffer{}, bytes.NewReader(corrupt)))
+	assert.NotNil(t, key.Decrypt(&bytes.Buffer{}, bytes.NewReader(corrupt[:len(corrupt)-1])))
+	assert.NotNil(t, key.Decrypt(&bytes.Buffer{}, strings.NewReader("not encrypted at all, not at all")))
+}
+
+func TestNewCryptKeyRequiresSize(t *testing.T) {
+	_, err := NewCryptKey([]byte("short"))
+	assert.NotNil(t, err)
+}
+
+func TestLoadCryptKeyFromEnv(t *testing.T) {
+	secret, err := GenerateCryptKey()
+	require.Nil(t, err)
+
+	cfg := config.NewFrom(config.Values{
+		Os: map[string][]string{"GIT_LFS_CRYPT_KEY": []string{secret}},
+	})
+	_, err = LoadCryptKey(cfg)
+	assert.Nil(t, err)
+
+	_, err = LoadCryptKey(config.NewFrom(config.Values{}))
+	assert.NotNil(t, err)
+}
@@ -2,7 +2,6 @@ package lfs
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tools"
 )
 
 type pipeRequest struct {
@@ -19,6 +19,7 @@ type pipeRequest struct {
 	reader     io.Reader
 	fileName   string
 	extensions []config.Extension
+	oidType    string
 }
 
 type pipeResponse struct {
@@ -58,6 +59,14 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 		}
 	}()
 
+	newHash := func() hash.Hash {
+		return tools.NewLfsContentHashAlgorithm(request.oidType)
+	}
+	if newHash() == nil {
+		err = fmt.Errorf("unsupported hash algorithm: %q", request.oidType)
+		return
+	}
+
 	for _, e := range request.extensions {
 		var pieces []string
 		switch request.action {
@@ -80,7 +89,7 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 		extcmds = append(extcmds, ec)
 	}
 
-	hasher := sha256.New()
+	hasher := newHash()
 	pipeReader, pipeWriter := io.Pipe()
 	multiWriter := io.MultiWriter(hasher, pipeWriter)
 
@@ -96,7 +105,11 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 
 	last := len(extcmds) - 1
 	for i, ec := range extcmds {
-		ec.hasher = sha256.New()
+		ec.hasher = newHash()
+
+		var errBuff bytes.Buffer
+		ec.err = &errBuff
+		ec.cmd.Stderr = ec.err
 
 		if i == last {
 			ec.cmd.Stdout = io.MultiWriter(ec.hasher, output)
@@ -119,10 +132,6 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 		ec.out = nextStdin
 
 		input = stdout
-
-		var errBuff bytes.Buffer
-		ec.err = &errBuff
-		ec.cmd.Stderr = ec.err
 	}
 
 	for _, ec := range extcmds {
@@ -141,7 +150,7 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 	for _, ec := range extcmds {
 		if err = ec.cmd.Wait(); err != nil {
 			if ec.err != nil {
-				errStr := ec.err.String()
+				errStr := strings.TrimSpace(ec.err.String())
 				err = fmt.Errorf("extension '%s' failed with: %s", ec.result.name, errStr)
 			}
 			return
@@ -4,17 +4,34 @@ import (
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/tq"
 )
 
 // GitFilter provides clean and smudge capabilities
 type GitFilter struct {
 	cfg *config.Configuration
 	fs  *fs.Filesystem
+
+	cleanCache     bool
+	progressStream *tq.ProgressStream
 }
 
 // NewGitFilter initializes a new *GitFilter
 func NewGitFilter(cfg *config.Configuration) *GitFilter {
-	return &GitFilter{cfg: cfg, fs: cfg.Filesystem()}
+	return &GitFilter{cfg: cfg, fs: cfg.Filesystem(), cleanCache: cfg.CleanCache()}
+}
+
+// DisableCleanCache stops the filter from using or recording entries in the
+// clean cache, as when it cleans contents which are not those of the files in
+// the working tree.
+func (f *GitFilter) DisableCleanCache() {
+	f.cleanCache = false
+}
+
+// SetProgressStream sets the stream to which the progress of the files being
+// cleaned and smudged is written.
+func (f *GitFilter) SetProgressStream(s *tq.ProgressStream) {
+	f.progressStream = s
 }
 
 func (f *GitFilter) ObjectPath(oid string) (string, error) {
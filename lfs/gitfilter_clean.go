@@ -2,12 +2,14 @@ package lfs
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/tools"
 )
 
@@ -26,8 +28,49 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 	var size int64
 	var tmp *os.File
 	var exts []*PointerExtension
+	var entry *cleanCacheEntry
+	if len(extensions) == 0 && f.cleanCache {
+		// Objects transformed by extensions are not cached, since
+		// their object IDs depend upon the extensions as well as the
+		// contents of the file.
+		entry = statCleanCacheEntry(fileName, f.cfg.HashAlgorithm())
+	}
+
+	var matcher *fileMatcher
+	var replayed *cleanCacheEntry
+	if entry != nil {
+		// Git may clean contents other than those of the file, as
+		// with "git hash-object --stdin --path", so they are compared
+		// with the file before its entry is used or recorded.
+		if matcher, err = newFileMatcher(entry.path); err != nil {
+			entry = nil
+		} else {
+			defer matcher.Close()
+		}
+	}
+
+	if entry != nil {
+		if cached, ok := f.cachedOid(entry); ok {
+			matched, replay, err := matcher.consume(reader, entry.size, cb)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				return f.cleanFromCache(fileName, cached, entry)
+			}
+
+			// The contents read so far are read again, and no
+			// entry is recorded for them.
+			reader = replay
+			replayed, entry = entry, nil
+		}
+	}
+	if entry != nil {
+		reader = matcher.reader(reader)
+	}
+
 	if len(extensions) > 0 {
-		request := &pipeRequest{"clean", reader, fileName, extensions}
+		request := &pipeRequest{"clean", reader, fileName, extensions, f.cfg.HashAlgorithm()}
 
 		var response pipeResponse
 		if response, err = pipeExtensions(f.cfg, request); err != nil {
@@ -53,13 +96,103 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 		if err != nil {
 			return nil, err
 		}
+		if replayed != nil && !replayed.unchanged() {
+			// Some of the contents were read again from the
+			// file, which has since changed.
+			os.Remove(tmp.Name())
+			return nil, errors.Errorf("%s changed while being cleaned", fileName)
+		}
+		if entry != nil && entry.size == size && matcher.matched() {
+			f.cacheOid(entry, oid)
+		}
 	}
 
 	pointer := NewPointer(oid, size, exts)
-	return &cleanedAsset{tmp.Name(), pointer}, err
+	if err = f.finishPointer(pointer, tmp.Name(), fileName); err != nil {
+		return nil, err
+	}
+	return &cleanedAsset{tmp.Name(), pointer}, nil
+}
+
+// cleanFromCache returns the pointer to the object "oid", which is already in
+// local storage, as recorded in the clean cache for "fileName".  The contents
+// of the file have been read, and found to be those of the file, so they are
+// not hashed or copied again.
+func (f *GitFilter) cleanFromCache(fileName, oid string, entry *cleanCacheEntry) (*cleanedAsset, error) {
+	if !entry.unchanged() {
+		return nil, errors.Errorf("%s changed while being cleaned", fileName)
+	}
+
+	pointer := NewPointer(oid, entry.size, nil)
+	if err := f.finishPointer(pointer, f.fs.ObjectPathname(oid), fileName); err != nil {
+		return nil, err
+	}
+	return &cleanedAsset{"", pointer}, nil
+}
+
+// finishPointer records any configured metadata in a new pointer to the
+// object at "objectPath", and signs it if pointers are to be signed.
+func (f *GitFilter) finishPointer(pointer *Pointer, objectPath, fileName string) error {
+	if len(pointer.Extensions) == 0 && f.cfg.PointerMetadata() {
+		// Metadata is not recorded for objects transformed by
+		// extensions, since it describes the original contents and
+		// may reveal what an extension such as "crypt" would hide.
+		if err := setPointerMetadata(pointer, objectPath, fileName); err != nil {
+			return err
+		}
+	}
+	if SignsPointers(f.cfg) {
+		return SignPointer(f.cfg, pointer, indexPointer(fileName))
+	}
+	return nil
+}
+
+// indexPointer returns the pointer staged in the index at "fileName", if any.
+func indexPointer(fileName string) *Pointer {
+	if len(fileName) == 0 || filepath.IsAbs(fileName) {
+		return nil
+	}
+
+	data, err := git.IndexBlob(fileName, blobSizeCutoff)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	p, err := DecodePointer(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// setPointerMetadata records the content type of the object at "objectPath",
+// which may be compressed, in the given pointer's metadata. The name of the
+// file is used only to detect the content type, and is not recorded, so that
+// pointers do not change when their files are renamed.
+func setPointerMetadata(pointer *Pointer, objectPath, fileName string) error {
+	f, err := fs.OpenObjectFile(objectPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, tools.ContentTypeSniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	pointer.SetMetadata(MetaContentType, tools.DetectContentType(fileName, buf[:n]))
+	return nil
 }
 
 func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCallback) (oid string, size int64, tmp *os.File, err error) {
+	oidHash := tools.NewLfsContentHashAlgorithm(f.cfg.HashAlgorithm())
+	if oidHash == nil {
+		err = errors.Errorf("unsupported lfs.hashalgorithm: %q", f.cfg.HashAlgorithm())
+		return
+	}
+
 	tmp, err = TempFile(f.cfg, "")
 	if err != nil {
 		return
@@ -67,7 +200,6 @@ func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCa
 
 	defer tmp.Close()
 
-	oidHash := sha256.New()
 	writer := io.MultiWriter(oidHash, tmp)
 
 	if fileSize <= 0 {
@@ -103,5 +235,10 @@ func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCa
 }
 
 func (a *cleanedAsset) Teardown() error {
+	if len(a.Filename) == 0 {
+		// The object was found in the clean cache, so no
+		// temporary file was written.
+		return nil
+	}
 	return os.Remove(a.Filename)
 }
@@ -5,37 +5,48 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 func (f *GitFilter) SmudgeToFile(filename string, ptr *Pointer, download bool, manifest *tq.Manifest, cb tools.CopyCallback) error {
-	tools.MkdirAll(filepath.Dir(filename), f.cfg)
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return fmt.Errorf("could not produce absolute path for %q", filename)
+	}
+	// Working tree files may be nested deeply enough to exceed MAX_PATH
+	// on Windows.
+	abs = tools.LongPath(abs)
+
+	tools.MkdirAll(filepath.Dir(abs), f.cfg)
 
-	if stat, _ := os.Stat(filename); stat != nil && stat.Mode()&0200 == 0 {
-		if err := os.Chmod(filename, stat.Mode()|0200); err != nil {
+	if stat, _ := os.Stat(abs); stat != nil && stat.Mode()&0200 == 0 {
+		if err := os.Chmod(abs, stat.Mode()|0200); err != nil {
 			return errors.Wrap(err,
 				"Could not restore write permission")
 		}
 
 		// When we're done, return the file back to its normal
 		// permission bits.
-		defer os.Chmod(filename, stat.Mode())
+		defer os.Chmod(abs, stat.Mode())
 	}
 
-	abs, err := filepath.Abs(filename)
-	if err != nil {
-		return fmt.Errorf("could not produce absolute path for %q", filename)
+	if f.cloneToFile(abs, ptr) {
+		return nil
 	}
 
-	file, err := os.Create(abs)
+	// Editors and virus scanners may briefly hold the file open on
+	// Windows, so retry while they do.
+	file, err := tools.RobustCreate(abs)
 	if err != nil {
-		return fmt.Errorf("could not create working directory file: %v", err)
+		return errors.Wrap(err, "could not create working directory file")
 	}
 	defer file.Close()
 	if _, err := f.Smudge(file, ptr, filename, download, manifest, cb); err != nil {
@@ -52,6 +63,12 @@ func (f *GitFilter) SmudgeToFile(filename string, ptr *Pointer, download bool, m
 }
 
 func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, download bool, manifest *tq.Manifest, cb tools.CopyCallback) (int64, error) {
+	if ptr.Size > 0 && VerifiesPointerSignatures(f.cfg) {
+		if err := VerifyPointerSignature(f.cfg, ptr); err != nil {
+			return 0, errors.Wrapf(err, "Unable to check out %s", workingfile)
+		}
+	}
+
 	mediafile, err := f.ObjectPath(ptr.Oid)
 	if err != nil {
 		return 0, err
@@ -59,21 +76,18 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 
 	LinkOrCopyFromReference(f.cfg, ptr.Oid, ptr.Size)
 
-	stat, statErr := os.Stat(mediafile)
-	if statErr == nil && stat != nil {
-		fileSize := stat.Size()
-		if fileSize != ptr.Size {
-			tracerx.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
-			os.RemoveAll(mediafile)
-			stat = nil
-		}
+	fileSize, statErr := f.cfg.ObjectStore().ObjectSize(ptr.Oid)
+	if statErr == nil && fileSize != ptr.Size {
+		tracelog.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
+		f.cfg.ObjectStore().RemoveObject(ptr.Oid)
+		statErr = os.ErrNotExist
 	}
 
 	var n int64
 
 	if ptr.Size == 0 {
 		return 0, nil
-	} else if statErr != nil || stat == nil {
+	} else if statErr != nil {
 		if download {
 			n, err = f.downloadFile(writer, ptr, workingfile, mediafile, manifest, cb)
 		} else {
@@ -90,6 +104,50 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 	return n, nil
 }
 
+// cloneToFile writes the object for "ptr" to "filename" as a copy-on-write
+// clone of the object in the local store, so that the two share storage, if it
+// is present and the platform and file system support it. It returns whether
+// the object was cloned; if not, it should be smudged normally instead.
+func (f *GitFilter) cloneToFile(filename string, ptr *Pointer) bool {
+	if ptr.Size == 0 || len(ptr.Extensions) > 0 || !f.cfg.LFSObjectExists(ptr.Oid, ptr.Size) {
+		return false
+	}
+	if _, ok := f.cfg.ObjectStore().(*fs.Filesystem); !ok {
+		return false
+	}
+	if VerifiesPointerSignatures(f.cfg) && VerifyPointerSignature(f.cfg, ptr) != nil {
+		return false
+	}
+
+	// Compressed objects cannot be cloned, since their contents differ from
+	// those of the working tree file.
+	mediafile := f.cfg.Filesystem().ObjectPathname(ptr.Oid)
+	if _, compressed, err := fs.ObjectFileSize(mediafile); err != nil || compressed {
+		return false
+	}
+
+	if ok, err := tools.CloneFileByPath(filename, mediafile); !ok || err != nil {
+		tracelog.Printf("unable to clone %s to %s: %v", mediafile, filename, err)
+		return false
+	}
+	tracelog.Printf("cloned %s to %s", mediafile, filename)
+
+	f.markUsed(mediafile)
+	return true
+}
+
+// markUsed records that the object at "mediafile" was used, so that less
+// recently used objects are evicted before it when the local object store is
+// limited in size.
+func (f *GitFilter) markUsed(mediafile string) {
+	if f.cfg.CacheMaxSize() > 0 {
+		if file, _, err := fs.ObjectFile(mediafile); err == nil {
+			now := time.Now()
+			os.Chtimes(file, now, now)
+		}
+	}
+}
+
 func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, mediafile string, manifest *tq.Manifest, cb tools.CopyCallback) (int64, error) {
 	fmt.Fprintf(os.Stderr, "Downloading %s (%s)\n", workingfile, humanize.FormatBytes(uint64(ptr.Size)))
 
@@ -119,19 +177,51 @@ func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, me
 		return 0, errors.Wrapf(multiErr, "Error downloading %s (%s)", workingfile, ptr.Oid)
 	}
 
+	if err := f.storeDownloadedFile(ptr, mediafile); err != nil {
+		return 0, err
+	}
+
 	return f.readLocalFile(writer, ptr, mediafile, workingfile, nil)
 }
 
+// storeDownloadedFile moves the object downloaded to "mediafile" into the
+// configured object store, unless that is the local object store on disk, in
+// which case it is already in place, or the manifest's download adapters have
+// moved it there already.
+func (f *GitFilter) storeDownloadedFile(ptr *Pointer, mediafile string) error {
+	store := f.cfg.ObjectStore()
+	if _, ok := store.(*fs.Filesystem); ok {
+		return nil
+	}
+
+	file, err := os.Open(mediafile)
+	if os.IsNotExist(err) && store.ObjectExists(ptr.Oid, ptr.Size) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = store.WriteObject(ptr.Oid, file)
+	file.Close()
+	if err != nil {
+		return errors.Wrapf(err, "Error storing %s", ptr.Oid)
+	}
+	return os.Remove(mediafile)
+}
+
 func (f *GitFilter) readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile string, cb tools.CopyCallback) (int64, error) {
-	reader, err := tools.RobustOpen(mediafile)
+	store := f.cfg.ObjectStore()
+	reader, err := store.OpenObject(ptr.Oid)
 	if err != nil {
 		return 0, errors.Wrapf(err, "error opening media file")
 	}
 	defer reader.Close()
 
+	f.markUsed(mediafile)
+
 	if ptr.Size == 0 {
-		if stat, _ := os.Stat(mediafile); stat != nil {
-			ptr.Size = stat.Size()
+		if size, err := store.ObjectSize(ptr.Oid); err == nil {
+			ptr.Size = size
 		}
 	}
 
@@ -159,7 +249,7 @@ func (f *GitFilter) readLocalFile(writer io.Writer, ptr *Pointer, mediafile stri
 			extsR = append(extsR, ext)
 		}
 
-		request := &pipeRequest{"smudge", reader, workingfile, extsR}
+		request := &pipeRequest{"smudge", reader, workingfile, extsR, ptr.OidType}
 
 		response, err := pipeExtensions(f.cfg, request)
 		if err != nil {
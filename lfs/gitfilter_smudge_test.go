@@ -0,0 +1,93 @@
+package lfs_test // avoid import cycle
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfs"
+	test "github.com/git-lfs/git-lfs/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an fs.ObjectStore which keeps objects in memory.
+type memoryStore map[string][]byte
+
+func (s memoryStore) OpenObject(oid string) (io.ReadCloser, error) {
+	data, ok := s[oid]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: oid, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s memoryStore) WriteObject(oid string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s[oid] = data
+	return nil
+}
+
+func (s memoryStore) ObjectExists(oid string, size int64) bool {
+	data, ok := s[oid]
+	return ok && int64(len(data)) == size
+}
+
+func (s memoryStore) ObjectSize(oid string) (int64, error) {
+	data, ok := s[oid]
+	if !ok {
+		return 0, &os.PathError{Op: "stat", Path: oid, Err: os.ErrNotExist}
+	}
+	return int64(len(data)), nil
+}
+
+func (s memoryStore) RemoveObject(oid string) error {
+	if _, ok := s[oid]; !ok {
+		return &os.PathError{Op: "remove", Path: oid, Err: os.ErrNotExist}
+	}
+	delete(s, oid)
+	return nil
+}
+
+func (s memoryStore) EachObject(fn func(fs.Object) error) error {
+	for oid, data := range s {
+		if err := fn(fs.Object{Oid: oid, Size: int64(len(data))}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSmudgeFromObjectStore(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	contents := []byte("stored in memory\n")
+	oid := "2b6c6d1a8a9ff8a7e7a4f8d18e7b0bda1e11c4f4bd1e8f0c0e8a22a0d29e6c55"
+	store := memoryStore{oid: contents}
+
+	cfg := repo.Configuration()
+	cfg.SetObjectStore(store)
+	assert.True(t, cfg.LFSObjectExists(oid, int64(len(contents))))
+
+	var buf bytes.Buffer
+	ptr := lfs.NewPointer(oid, int64(len(contents)), nil)
+	n, err := lfs.NewGitFilter(cfg).Smudge(&buf, ptr, "a.dat", false, nil, nil)
+	require.Nil(t, err)
+	assert.EqualValues(t, len(contents), n)
+	assert.Equal(t, contents, buf.Bytes())
+
+	missing := lfs.NewPointer("3b6c6d1a8a9ff8a7e7a4f8d18e7b0bda1e11c4f4bd1e8f0c0e8a22a0d29e6c55", 10, nil)
+	_, err = lfs.NewGitFilter(cfg).Smudge(&buf, missing, "b.dat", false, nil, nil)
+	assert.NotNil(t, err)
+}
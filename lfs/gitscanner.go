@@ -8,7 +8,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/filepathfilter"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var missingCallbackErr = errors.New("no callback given")
@@ -58,7 +58,7 @@ func (s *GitScanner) Close() {
 	}
 
 	s.closed = true
-	tracerx.PerformanceSince("scan", s.started)
+	tracelog.PerformanceSince("scan", s.started)
 }
 
 // RemoteForPush sets up this *GitScanner to scan for objects to push to the
@@ -186,6 +186,18 @@ func (s *GitScanner) ScanRefByTree(ref string, cb GitScannerFoundPointer) error
 	return scanRefsByTree(s, callback, []string{ref}, []string{}, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
 }
 
+// ScanTreeForPointers scans through the given tree, such as that of the index
+// as written by git.WriteTree, reporting pointers and files which should have
+// been pointers, as ScanRefByTree does for the tree of each commit.
+func (s *GitScanner) ScanTreeForPointers(tree string, cb GitScannerFoundPointer) error {
+	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	if err != nil {
+		return err
+	}
+
+	return runScanTreeForPointers(callback, tree, s.cfg.GitEnv(), s.cfg.OSEnv(), newPromisorFetcher(s.cfg))
+}
+
 // ScanAll scans through all objects in the git repository.
 func (s *GitScanner) ScanAll(cb GitScannerFoundPointer) error {
 	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
@@ -206,7 +218,7 @@ func (s *GitScanner) ScanTree(ref string) error {
 	if err != nil {
 		return err
 	}
-	return runScanTree(callback, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv())
+	return runScanTree(callback, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv(), newPromisorFetcher(s.cfg))
 }
 
 // ScanUnpushed scans history for all LFS pointers which have been added but not
@@ -241,6 +253,13 @@ func (s *GitScanner) ScanPreviousVersions(ref string, since time.Time, cb GitSca
 	return logPreviousSHAs(callback, ref, since)
 }
 
+// ScanLastReferences scans the history of all refs, returning for each LFS
+// object the most recent commit which referenced it, and the path at which it
+// did so.
+func (s *GitScanner) ScanLastReferences() (map[string]*PointerReference, error) {
+	return logLastReferences(s.Filter)
+}
+
 // ScanIndex scans the git index for modified LFS objects.
 func (s *GitScanner) ScanIndex(ref string, cb GitScannerFoundPointer) error {
 	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
@@ -286,26 +305,8 @@ type ScanRefsOptions struct {
 	SkipDeletedBlobs bool
 	CommitsOnly      bool
 	skippedRefs      []string
-	nameMap          map[string]string
-	mutex            *sync.Mutex
-}
-
-func (o *ScanRefsOptions) GetName(sha string) (string, bool) {
-	o.mutex.Lock()
-	name, ok := o.nameMap[sha]
-	o.mutex.Unlock()
-	return name, ok
-}
-
-func (o *ScanRefsOptions) SetName(sha, name string) {
-	o.mutex.Lock()
-	o.nameMap[sha] = name
-	o.mutex.Unlock()
 }
 
 func newScanRefsOptions() *ScanRefsOptions {
-	return &ScanRefsOptions{
-		nameMap: make(map[string]string, 0),
-		mutex:   &sync.Mutex{},
-	}
+	return &ScanRefsOptions{}
 }
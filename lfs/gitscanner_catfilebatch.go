@@ -2,22 +2,23 @@ package lfs
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/tools"
 )
 
 // runCatFileBatch uses 'git cat-file --batch' to get the object contents of a
 // git object, given its sha1. The contents will be decoded into a Git LFS
-// pointer. Git Blob SHA1s are read from the sha1Ch channel and fed to STDIN.
+// pointer. Git Blobs are read from the revs channel and fed to STDIN.
 // Results are parsed from STDOUT, and any eligible LFS pointers are sent to
 // pointerCh. If a Git Blob is not an LFS pointer, check the lockableSet to see
 // if that blob is for a locked file. Any errors are sent to errCh. An error is
 // returned if the 'git cat-file' command fails to start.
-func runCatFileBatch(pointerCh chan *WrappedPointer, lockableCh chan string, lockableSet *lockableNameSet, revs *StringChannelWrapper, errCh chan error, gitEnv, osEnv config.Environment) error {
+func runCatFileBatch(pointerCh chan *WrappedPointer, lockableCh chan string, lockableSet *lockableNameSet, revs *revListEntryChannelWrapper, errCh chan error, gitEnv, osEnv config.Environment) error {
 	scanner, err := NewPointerScanner(gitEnv, osEnv)
 	if err != nil {
 		return err
@@ -26,15 +27,16 @@ func runCatFileBatch(pointerCh chan *WrappedPointer, lockableCh chan string, loc
 	go func() {
 		canScan := true
 		for r := range revs.Results {
-			canScan = scanner.Scan(r)
+			canScan = scanner.Scan(r.Sha1)
 
 			if err := scanner.Err(); err != nil {
 				errCh <- err
 			} else if p := scanner.Pointer(); p != nil {
+				p.Name = r.Name
 				pointerCh <- p
 			} else if b := scanner.BlobSHA(); git.HasValidObjectIDLength(b) {
-				if name, ok := lockableSet.Check(b); ok {
-					lockableCh <- name
+				if lockableSet.Check(r.Name) {
+					lockableCh <- r.Name
 				}
 			}
 
@@ -63,10 +65,15 @@ func runCatFileBatch(pointerCh chan *WrappedPointer, lockableCh chan string, loc
 
 type PointerScanner struct {
 	scanner *git.ObjectScanner
+	// algorithm is the hash algorithm of the contents of blobs which are
+	// not pointers, as new objects would be hashed, or empty for the
+	// default.
+	algorithm string
 
 	blobSha     string
 	contentsSha string
 	pointer     *WrappedPointer
+	malformed   error
 	err         error
 }
 
@@ -76,7 +83,8 @@ func NewPointerScanner(gitEnv, osEnv config.Environment) (*PointerScanner, error
 		return nil, err
 	}
 
-	return &PointerScanner{scanner: scanner}, nil
+	algorithm, _ := gitEnv.Get("lfs.hashalgorithm")
+	return &PointerScanner{scanner: scanner, algorithm: strings.ToLower(algorithm)}, nil
 }
 
 func (s *PointerScanner) BlobSHA() string {
@@ -91,12 +99,18 @@ func (s *PointerScanner) Pointer() *WrappedPointer {
 	return s.pointer
 }
 
+// Malformed returns the error from decoding the most recently scanned blob,
+// if it appears to be a pointer but could not be decoded as one.
+func (s *PointerScanner) Malformed() error {
+	return s.malformed
+}
+
 func (s *PointerScanner) Err() error {
 	return s.err
 }
 
 func (s *PointerScanner) Scan(sha string) bool {
-	s.pointer, s.err = nil, nil
+	s.pointer, s.malformed, s.err = nil, nil, nil
 	s.blobSha, s.contentsSha = "", ""
 
 	b, c, p, err := s.next(sha)
@@ -129,7 +143,10 @@ func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, err
 	blobSha := s.scanner.Sha1()
 	size := s.scanner.Size()
 
-	sha := sha256.New()
+	sha := tools.NewLfsContentHashAlgorithm(s.algorithm)
+	if sha == nil {
+		sha = tools.NewLfsContentHash()
+	}
 
 	var buf *bytes.Buffer
 	var to io.Writer = sha
@@ -153,6 +170,9 @@ func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, err
 	if size < blobSizeCutoff {
 		if p, err := DecodePointer(bytes.NewReader(buf.Bytes())); err != nil {
 			contentsSha = fmt.Sprintf("%x", sha.Sum(nil))
+			if looksLikePointer(buf.Bytes()) {
+				s.malformed = err
+			}
 		} else {
 			pointer = &WrappedPointer{
 				Sha1:    blobSha,
@@ -13,9 +13,9 @@ import (
 // runCatFileBatchCheck uses 'git cat-file --batch-check' to get the type and
 // size of a git object. Any object that isn't of type blob and under the
 // blobSizeCutoff will be ignored, unless it's a locked file. revs is a channel
-// over which strings containing git sha1s will be sent. It returns a channel
-// from which sha1 strings can be read.
-func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockableSet *lockableNameSet, revs *StringChannelWrapper, errCh chan error) error {
+// over which git objects will be sent, and the small blobs among them are sent
+// to smallRevCh with their names.
+func runCatFileBatchCheck(smallRevCh chan revListEntry, lockableCh chan string, lockableSet *lockableNameSet, revs *revListEntryChannelWrapper, errCh chan error) error {
 	cmd, err := git.CatFile()
 	if err != nil {
 		return err
@@ -24,15 +24,15 @@ func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockab
 	go func() {
 		scanner := &catFileBatchCheckScanner{s: bufio.NewScanner(cmd.Stdout), limit: blobSizeCutoff}
 		for r := range revs.Results {
-			cmd.Stdin.Write([]byte(r + "\n"))
+			cmd.Stdin.Write([]byte(r.Sha1 + "\n"))
 			hasNext := scanner.Scan()
 			if err := scanner.Err(); err != nil {
 				errCh <- err
 			} else if b := scanner.LFSBlobOID(); len(b) > 0 {
-				smallRevCh <- b
+				smallRevCh <- revListEntry{Sha1: b, Name: r.Name}
 			} else if b := scanner.GitBlobOID(); len(b) > 0 {
-				if name, ok := lockableSet.Check(b); ok {
-					lockableCh <- name
+				if lockableSet.Check(r.Name) {
+					lockableCh <- r.Name
 				}
 			}
 
@@ -31,14 +31,14 @@ func scanIndex(cb GitScannerFoundPointer, ref string, f *filepathfilter.Filter,
 	}
 
 	allRevsErr := make(chan error, 5) // can be multiple errors below
-	allRevsChan := make(chan string, 1)
-	allRevs := NewStringChannelWrapper(allRevsChan, allRevsErr)
+	allRevsChan := make(chan revListEntry, 1)
+	allRevs := newRevListEntryChannelWrapper(allRevsChan, allRevsErr)
 	go func() {
 		seenRevs := make(map[string]bool, 0)
 
 		for rev := range cachedRevs.Results {
 			if !seenRevs[rev] {
-				allRevsChan <- rev
+				allRevsChan <- revListEntry{Sha1: rev}
 				seenRevs[rev] = true
 			}
 		}
@@ -49,7 +49,7 @@ func scanIndex(cb GitScannerFoundPointer, ref string, f *filepathfilter.Filter,
 
 		for rev := range revs.Results {
 			if !seenRevs[rev] {
-				allRevsChan <- rev
+				allRevsChan <- revListEntry{Sha1: rev}
 				seenRevs[rev] = true
 			}
 		}
@@ -13,7 +13,7 @@ import (
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/subprocess"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // When scanning diffs e.g. parseLogOutputToPointers, which direction of diff to include
@@ -31,7 +31,7 @@ var (
 	logLfsSearchArgs = []string{
 		"--no-ext-diff",
 		"--no-textconv",
-		"-G", "oid sha256:", // only diffs which include an lfs file SHA change
+		"-G", "oid sha(256|512):", // only diffs which include an lfs file SHA change
 		"-p",                             // include diff so we can read the SHA
 		"-U12",                           // Make sure diff context is always big enough to support 10 extension lines to get whole pointer
 		`--format=lfs-commit-sha: %H %P`, // just a predictable commit header we can detect
@@ -171,6 +171,69 @@ func logPreviousSHAs(cb GitScannerFoundPointer, ref string, since time.Time) err
 	return nil
 }
 
+// PointerReference is a commit which referenced an LFS object, and the path at
+// which it did so.
+type PointerReference struct {
+	Commit string
+	Name   string
+}
+
+// logCommit is a commit header in log output, numbered by its position in the
+// log.
+type logCommit struct {
+	Sha    string
+	Parent string
+	Index  int
+}
+
+// logLastReferences scans the history of all refs, returning for each LFS
+// object the most recent commit to add or remove a pointer to it. For an
+// addition, that is the commit itself, and for a removal, its parent.
+func logLastReferences(filter *filepathfilter.Filter) (map[string]*PointerReference, error) {
+	refs := make(map[string]*PointerReference)
+	indexes := make(map[string]int)
+
+	for _, dir := range []LogDiffDirection{LogDiffAdditions, LogDiffDeletions} {
+		logArgs := []string{"--all", "--date-order"}
+		logArgs = append(logArgs, logLfsSearchArgs...)
+
+		cmd, err := git.Log(logArgs...)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdin.Close()
+
+		scanner := newLogScanner(dir, cmd.Stdout)
+		scanner.Filter = filter
+		for scanner.Scan() {
+			p := scanner.Pointer()
+			if p == nil {
+				continue
+			}
+
+			// Log output is newest first, so the first reference
+			// to an object in either direction is the latest.
+			commit := scanner.Commit()
+			if index, ok := indexes[p.Oid]; ok && index <= commit.Index {
+				continue
+			}
+
+			ref := &PointerReference{Commit: commit.Sha, Name: p.Name}
+			if dir == LogDiffDeletions {
+				ref.Commit = commit.Parent
+			}
+			refs[p.Oid] = ref
+			indexes[p.Oid] = commit.Index
+		}
+
+		stderr, _ := ioutil.ReadAll(cmd.Stderr)
+		if err := cmd.Wait(); err != nil {
+			return nil, fmt.Errorf("error in git log: %v %v", err, string(stderr))
+		}
+	}
+	return refs, nil
+}
+
 func parseLogOutputToPointers(log io.Reader, dir LogDiffDirection,
 	includePaths, excludePaths []string, results chan *WrappedPointer) {
 	scanner := newLogScanner(dir, log)
@@ -199,6 +262,12 @@ type logScanner struct {
 	currentFilename     string
 	currentFileIncluded bool
 
+	// currentCommit is the commit whose diff is being parsed, and
+	// pointerCommit the one in which the pointer returned by Pointer() was
+	// found.
+	currentCommit logCommit
+	pointerCommit logCommit
+
 	commitHeaderRegex    *regexp.Regexp
 	fileHeaderRegex      *regexp.Regexp
 	fileMergeHeaderRegex *regexp.Regexp
@@ -219,7 +288,7 @@ func newLogScanner(dir LogDiffDirection, r io.Reader) *logScanner {
 		commitHeaderRegex:    regexp.MustCompile(fmt.Sprintf(`^lfs-commit-sha: (%s)(?: (%s))*`, git.ObjectIDRegex, git.ObjectIDRegex)),
 		fileHeaderRegex:      regexp.MustCompile(`diff --git a\/(.+?)\s+b\/(.+)`),
 		fileMergeHeaderRegex: regexp.MustCompile(`diff --cc (.+)`),
-		pointerDataRegex:     regexp.MustCompile(`^([\+\- ])(version https://git-lfs|oid sha256|size|ext-).*$`),
+		pointerDataRegex:     regexp.MustCompile(`^([\+\- ])(version https://git-lfs|oid sha(?:256|512)|size|ext-).*$`),
 	}
 }
 
@@ -227,6 +296,12 @@ func (s *logScanner) Pointer() *WrappedPointer {
 	return s.pointer
 }
 
+// Commit returns the commit in whose diff the pointer returned by Pointer()
+// was found.
+func (s *logScanner) Commit() logCommit {
+	return s.pointerCommit
+}
+
 func (s *logScanner) Err() error {
 	return s.s.Err()
 }
@@ -248,9 +323,10 @@ func (s *logScanner) finishLastPointer() *WrappedPointer {
 	s.pointerData.Reset()
 
 	if err == nil {
+		s.pointerCommit = s.currentCommit
 		return &WrappedPointer{Name: s.currentFilename, Pointer: p}
 	} else {
-		tracerx.Printf("Unable to parse pointer from log: %v", err)
+		tracelog.Printf("Unable to parse pointer from log: %v", err)
 		return nil
 	}
 }
@@ -276,9 +352,17 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 		line := s.s.Text()
 
 		if match := s.commitHeaderRegex.FindStringSubmatch(line); match != nil {
-			// Currently we're not pulling out commit groupings, but could if we wanted
-			// This just acts as a delimiter for finishing a multiline pointer
-			if p := s.finishLastPointer(); p != nil {
+			// This acts as a delimiter for finishing a multiline
+			// pointer, which belongs to the previous commit
+			p := s.finishLastPointer()
+
+			s.currentCommit = logCommit{
+				Sha:    match[1],
+				Parent: match[2],
+				Index:  s.currentCommit.Index + 1,
+			}
+
+			if p != nil {
 				return p, true
 			}
 		} else if match := s.fileHeaderRegex.FindStringSubmatch(line); match != nil {
@@ -0,0 +1,382 @@
+package lfs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/gitattr"
+	"github.com/git-lfs/gitobj/v2"
+	gitobjerrors "github.com/git-lfs/gitobj/v2/errors"
+)
+
+// promisorFetchBatchSize is the number of missing blobs which may be pointers
+// that are fetched from the promisor remote in a single request.
+const promisorFetchBatchSize = 1000
+
+// promisorFetcher prepares the blobs of a partial clone to be scanned for
+// pointers. Blobs missing from the clone which may be pointers are fetched
+// from the promisor remote in batches, since Git would otherwise fetch each
+// one separately as it was read. Missing blobs which cannot be pointers,
+// because their paths do not have the "filter=lfs" attribute, are skipped
+// rather than fetched, so that scanning history does not download every blob
+// left out of the clone.
+type promisorFetcher struct {
+	remote     string
+	gitEnv     config.Environment
+	osEnv      config.Environment
+	workingDir string
+	gitDir     string
+}
+
+// newPromisorFetcher returns a promisorFetcher for the repository of cfg, or
+// nil if it is not a partial clone.
+func newPromisorFetcher(cfg *config.Configuration) *promisorFetcher {
+	if cfg == nil {
+		return nil
+	}
+	remote := cfg.PromisorRemote()
+	if len(remote) == 0 {
+		return nil
+	}
+	return &promisorFetcher{
+		remote:     remote,
+		gitEnv:     cfg.GitEnv(),
+		osEnv:      cfg.OSEnv(),
+		workingDir: cfg.LocalWorkingDir(),
+		gitDir:     cfg.LocalGitDir(),
+	}
+}
+
+// revListEntries returns the entries read from revs, less the blobs which are
+// missing and cannot be pointers, with the missing blobs which may be pointers
+// passed on once they have been fetched. The entries must have been listed
+// with missing objects printed, and the missing blobs are named from the trees
+// listed before them.
+func (f *promisorFetcher) revListEntries(revs *revListEntryChannelWrapper) (*revListEntryChannelWrapper, error) {
+	r, err := f.newResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(chan revListEntry, chanBufSize)
+	errs := make(chan error, 2)
+
+	go func() {
+		emit := func(b git.TreeBlob) {
+			entries <- revListEntry{Sha1: b.Oid, Name: b.Filename}
+		}
+
+		var err error
+		for rev := range revs.Results {
+			if err == nil {
+				err = r.add(git.TreeBlob{Oid: rev.Sha1, Size: -1, Filename: rev.Name}, emit)
+			}
+		}
+		if err == nil {
+			err = r.flush(emit)
+		}
+		r.Close()
+
+		if err != nil {
+			errs <- err
+		}
+		if err := revs.Wait(); err != nil {
+			errs <- err
+		}
+		close(entries)
+		close(errs)
+	}()
+
+	return newRevListEntryChannelWrapper(entries, errs), nil
+}
+
+// newResolver returns a promisorResolver which uses the attributes of the
+// working tree to tell which missing blobs may be pointers.
+func (f *promisorFetcher) newResolver() (*promisorResolver, error) {
+	db, err := f.objectDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &promisorResolver{
+		f:         f,
+		db:        db,
+		processor: gitattr.NewMacroProcessor(),
+		names:     make(map[string]string),
+	}
+	r.addAttributes(git.GetAttributePaths(r.processor, f.workingDir, f.gitDir))
+	return r, nil
+}
+
+// promisorResolver resolves the blobs of a partial clone one at a time, as
+// they are listed, with the promisorFetcher which made it.
+type promisorResolver struct {
+	f  *promisorFetcher
+	db *gitobj.ObjectDatabase
+
+	processor *gitattr.MacroProcessor
+	// patterns are those of the paths with the "filter=lfs" attribute
+	// found so far, and tracked matches them, or is nil if there are none.
+	patterns []filepathfilter.Pattern
+	tracked  *filepathfilter.Filter
+
+	// names maps the blobs in the trees seen so far to the first path at
+	// which they appear.
+	names map[string]string
+	// pending are the missing blobs which may be pointers, and are yet to
+	// be fetched.
+	pending []git.TreeBlob
+	// deferred are the missing blobs which cannot be pointers given the
+	// attributes found so far, but may be given those found later.
+	deferred []git.TreeBlob
+	// complete is true once all the ".gitattributes" files have been
+	// read, so that missing blobs need not be deferred.
+	complete bool
+}
+
+// add resolves the given blob, or other object, and passes it to emit if it is
+// present. If it is missing, it is skipped unless it may be a pointer, in which
+// case it is passed to emit after the next batch of such blobs is fetched, or
+// held back in case a ".gitattributes" file listed later shows that it may be.
+// Missing ".gitattributes" files are fetched at once, as the paths of pointers
+// are found from them.
+func (r *promisorResolver) add(b git.TreeBlob, emit func(git.TreeBlob)) error {
+	present, err := r.lookup(&b)
+	if err != nil {
+		return err
+	}
+	if present {
+		emit(b)
+		return nil
+	}
+
+	if len(b.Filename) == 0 {
+		// git-rev-list(1) lists the objects it finds missing after
+		// all the others, without their names, so they are named
+		// after the trees which hold them.  As every tree has been
+		// listed by then, so has every ".gitattributes" file, and
+		// the missing ones are fetched together.
+		b.Filename = r.names[b.Oid]
+		if !r.complete {
+			if err := r.fetchAttributes(); err != nil {
+				return err
+			}
+			r.complete = true
+
+			present, err := r.lookup(&b)
+			if err != nil {
+				return err
+			}
+			if present {
+				emit(b)
+				return nil
+			}
+		}
+	}
+
+	if path.Base(b.Filename) == ".gitattributes" {
+		if err := r.fetchBlobs([]git.TreeBlob{b}, emit); err != nil {
+			return err
+		}
+		return r.undefer(emit)
+	}
+
+	if len(b.Filename) == 0 {
+		return nil
+	}
+	if r.tracked == nil || !r.tracked.Allows(b.Filename) {
+		if !r.complete {
+			r.deferred = append(r.deferred, b)
+		}
+		return nil
+	}
+
+	r.pending = append(r.pending, b)
+	if len(r.pending) >= promisorFetchBatchSize {
+		return r.flush(emit)
+	}
+	return nil
+}
+
+// fetchAttributes fetches and reads the missing ".gitattributes" files in the
+// trees seen so far.
+func (r *promisorResolver) fetchAttributes() error {
+	var attributes []git.TreeBlob
+	for oid, name := range r.names {
+		if path.Base(name) != ".gitattributes" {
+			continue
+		}
+		b := git.TreeBlob{Oid: oid, Size: -1, Filename: name}
+		present, err := r.lookup(&b)
+		if err != nil {
+			return err
+		}
+		if !present {
+			attributes = append(attributes, b)
+		}
+	}
+	return r.fetchBlobs(attributes, func(git.TreeBlob) {})
+}
+
+// undefer queues the deferred blobs which may be pointers given the attributes
+// found since they were deferred.
+func (r *promisorResolver) undefer(emit func(git.TreeBlob)) error {
+	if r.tracked == nil {
+		return nil
+	}
+
+	deferred := r.deferred[:0]
+	for _, b := range r.deferred {
+		if r.tracked.Allows(b.Filename) {
+			r.pending = append(r.pending, b)
+		} else {
+			deferred = append(deferred, b)
+		}
+	}
+	r.deferred = deferred
+
+	if len(r.pending) >= promisorFetchBatchSize {
+		return r.flush(emit)
+	}
+	return nil
+}
+
+// flush fetches the pending blobs, and passes those which were fetched to emit.
+func (r *promisorResolver) flush(emit func(git.TreeBlob)) error {
+	pending := r.pending
+	r.pending = nil
+	return r.fetchBlobs(pending, emit)
+}
+
+// fetchBlobs fetches the given missing blobs, and passes those which were
+// fetched to emit.
+func (r *promisorResolver) fetchBlobs(blobs []git.TreeBlob, emit func(git.TreeBlob)) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+
+	oids := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		oids = append(oids, b.Oid)
+	}
+	if err := r.f.fetch(oids); err != nil {
+		return err
+	}
+	if err := r.reopen(); err != nil {
+		return err
+	}
+
+	for _, b := range blobs {
+		present, err := r.lookup(&b)
+		if err != nil {
+			return err
+		}
+		if present {
+			emit(b)
+		}
+	}
+	return nil
+}
+
+// lookup reads the given object from the repository, and returns whether it
+// is present. It sets the size of a blob, records the names of the blobs in a
+// tree, and reads the paths with the "filter=lfs" attribute from a
+// ".gitattributes" file.
+func (r *promisorResolver) lookup(b *git.TreeBlob) (bool, error) {
+	oid, err := hex.DecodeString(b.Oid)
+	if err != nil {
+		return false, fmt.Errorf("invalid object ID %q", b.Oid)
+	}
+
+	obj, err := r.db.Object(oid)
+	if err != nil {
+		if gitobjerrors.IsNoSuchObject(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	switch o := obj.(type) {
+	case *gitobj.Tree:
+		for _, te := range o.Entries {
+			if te.Type() != gitobj.BlobObjectType {
+				continue
+			}
+			id := hex.EncodeToString(te.Oid)
+			if _, ok := r.names[id]; !ok {
+				r.names[id] = path.Join(b.Filename, te.Name)
+			}
+		}
+	case *gitobj.Blob:
+		b.Size = o.Size
+		if path.Base(b.Filename) == ".gitattributes" {
+			r.addAttributes(git.AttrPathsFromReader(r.processor, b.Filename, "", o.Contents, b.Filename == ".gitattributes"))
+		}
+		o.Close()
+	}
+	return true, nil
+}
+
+// addAttributes adds the paths with the "filter=lfs" attribute among the given
+// ones to those which may hold pointers.
+func (r *promisorResolver) addAttributes(paths []git.AttributePath) {
+	caseFold := filepathfilter.CaseFold(config.IgnoreCase(r.f.gitEnv))
+
+	added := false
+	for _, p := range paths {
+		if p.Tracked {
+			r.patterns = append(r.patterns, filepathfilter.NewPattern(filepath.ToSlash(p.Path), filepathfilter.Strict(true), caseFold))
+			added = true
+		}
+	}
+	if added {
+		r.tracked = filepathfilter.NewFromPatterns(r.patterns, nil)
+	}
+}
+
+// reopen opens the object database again, so that objects fetched since it was
+// opened are found.
+func (r *promisorResolver) reopen() error {
+	db, err := r.f.objectDatabase()
+	if err != nil {
+		return err
+	}
+	r.db.Close()
+	r.db = db
+	return nil
+}
+
+// Close closes the object database.
+func (r *promisorResolver) Close() error {
+	return r.db.Close()
+}
+
+// fetch fetches the objects with the given IDs from the promisor remote.
+func (f *promisorFetcher) fetch(oids []string) error {
+	if len(oids) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(oids))
+	unique := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		if !seen[oid] {
+			seen[oid] = true
+			unique = append(unique, oid)
+		}
+	}
+	return git.FetchPromisorObjects(f.remote, unique)
+}
+
+func (f *promisorFetcher) objectDatabase() (*gitobj.ObjectDatabase, error) {
+	gitdir, err := git.GitCommonDir()
+	if err != nil {
+		return nil, err
+	}
+	return git.ObjectDatabase(f.osEnv, f.gitEnv, gitdir, "")
+}
@@ -9,25 +9,15 @@ import (
 )
 
 type lockableNameSet struct {
-	opt *ScanRefsOptions
 	set GitScannerSet
 }
 
-// Determines if the given blob sha matches a locked file.
-func (s *lockableNameSet) Check(blobSha string) (string, bool) {
-	if s == nil || s.opt == nil || s.set == nil {
-		return "", false
+// Determines if the given blob name matches a locked file.
+func (s *lockableNameSet) Check(name string) bool {
+	if s == nil || s.set == nil || len(name) == 0 {
+		return false
 	}
-
-	name, ok := s.opt.GetName(blobSha)
-	if !ok {
-		return name, ok
-	}
-
-	if s.set.Contains(name) {
-		return name, true
-	}
-	return name, false
+	return s.set.Contains(name)
 }
 
 func noopFoundLockable(name string) {}
@@ -41,12 +31,19 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 		panic("no scan ref options")
 	}
 
-	revs, err := revListShas(include, exclude, opt)
+	promisor := newPromisorFetcher(scanner.cfg)
+	revs, err := revListShas(include, exclude, opt, promisor != nil)
 	if err != nil {
 		return err
 	}
 
-	lockableSet := &lockableNameSet{opt: opt, set: scanner.PotentialLockables}
+	if promisor != nil {
+		if revs, err = promisor.revListEntries(revs); err != nil {
+			return err
+		}
+	}
+
+	lockableSet := &lockableNameSet{set: scanner.PotentialLockables}
 	smallShas, batchLockableCh, err := catFileBatchCheck(revs, lockableSet)
 	if err != nil {
 		return err
@@ -69,10 +66,6 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 	}
 
 	for p := range pointers.Results {
-		if name, ok := opt.GetName(p.Sha1); ok {
-			p.Name = name
-		}
-
 		if scanner.Filter.Allows(p.Name) {
 			pointerCb(p, nil)
 		}
@@ -115,11 +108,12 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 		panic("no scan ref options")
 	}
 
-	revs, err := revListShas(include, exclude, opt)
+	revs, err := revListShas(include, exclude, opt, false)
 	if err != nil {
 		return err
 	}
 
+	promisor := newPromisorFetcher(scanner.cfg)
 	errchan := make(chan error, 20) // multiple errors possible
 	wg := &sync.WaitGroup{}
 
@@ -127,11 +121,11 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 		wg.Add(1)
 		go func(rev string) {
 			defer wg.Done()
-			err := runScanTreeForPointers(pointerCb, rev, gitEnv, osEnv)
+			err := runScanTreeForPointers(pointerCb, rev, gitEnv, osEnv, promisor)
 			if err != nil {
 				errchan <- err
 			}
-		}(r)
+		}(r.Sha1)
 	}
 
 	wg.Wait()
@@ -145,34 +139,34 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 	return revs.Wait()
 }
 
-// revListShas uses git rev-list to return the list of object sha1s
-// for the given ref. If all is true, ref is ignored. It returns a
-// channel from which sha1 strings can be read.
-func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChannelWrapper, error) {
+// revListShas uses git rev-list to return the list of objects for the given
+// ref. If all is true, ref is ignored. It returns a channel from which each
+// object's sha1 can be read, along with its name, as soon as git lists it.
+// If printMissing is true, the objects missing from a partial clone are listed
+// without their names, rather than fetched.
+func revListShas(include, exclude []string, opt *ScanRefsOptions, printMissing bool) (*revListEntryChannelWrapper, error) {
 	scanner, err := git.NewRevListScanner(include, exclude, &git.ScanRefsOptions{
 		Mode:             git.ScanningMode(opt.ScanMode),
 		Remote:           opt.RemoteName,
 		SkipDeletedBlobs: opt.SkipDeletedBlobs,
 		SkippedRefs:      opt.skippedRefs,
-		Mutex:            opt.mutex,
-		Names:            opt.nameMap,
 		CommitsOnly:      opt.CommitsOnly,
+		PrintMissing:     printMissing,
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	revs := make(chan string, chanBufSize)
+	revs := make(chan revListEntry, chanBufSize)
 	errs := make(chan error, 5) // may be multiple errors
 
 	go func() {
 		for scanner.Scan() {
-			sha := hex.EncodeToString(scanner.OID())
-			if name := scanner.Name(); len(name) > 0 {
-				opt.SetName(sha, name)
+			revs <- revListEntry{
+				Sha1: hex.EncodeToString(scanner.OID()),
+				Name: scanner.Name(),
 			}
-			revs <- sha
 		}
 
 		if err = scanner.Err(); err != nil {
@@ -187,5 +181,5 @@ func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChanne
 		close(errs)
 	}()
 
-	return NewStringChannelWrapper(revs, errs), nil
+	return newRevListEntryChannelWrapper(revs, errs), nil
 }
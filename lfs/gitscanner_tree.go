@@ -13,10 +13,10 @@ import (
 	"github.com/git-lfs/git-lfs/git/gitattr"
 )
 
-func runScanTree(cb GitScannerFoundPointer, ref string, filter *filepathfilter.Filter, gitEnv, osEnv config.Environment) error {
-	// We don't use the nameMap approach here since that's imprecise when >1 file
+func runScanTree(cb GitScannerFoundPointer, ref string, filter *filepathfilter.Filter, gitEnv, osEnv config.Environment, promisor *promisorFetcher) error {
+	// We don't use the rev-list names here since they're imprecise when >1 file
 	// can be using the same content
-	treeShas, err := lsTreeBlobs(ref, func(t *git.TreeBlob) bool {
+	treeShas, err := lsTreeBlobs(ref, promisor, func(t *git.TreeBlob) bool {
 		return t != nil && t.Size < blobSizeCutoff && filter.Allows(t.Filename)
 	})
 	if err != nil {
@@ -94,8 +94,15 @@ func catFileBatchTree(treeblobs *TreeBlobChannelWrapper, gitEnv, osEnv config.En
 // Use ls-tree at ref to find a list of candidate tree blobs which might be lfs files
 // The returned channel will be sent these blobs which should be sent to catFileBatchTree
 // for final check & conversion to Pointer
-func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool) (*TreeBlobChannelWrapper, error) {
-	cmd, err := git.LsTree(ref)
+//
+// If promisor is not nil, the blobs missing from the partial clone which may be
+// pointers are fetched first, and the others are skipped.
+func lsTreeBlobs(ref string, promisor *promisorFetcher, predicate func(*git.TreeBlob) bool) (*TreeBlobChannelWrapper, error) {
+	if promisor != nil {
+		return lsTreePromisorBlobs(ref, promisor, predicate)
+	}
+
+	cmd, err := git.LsTree(ref, true)
 	if err != nil {
 		return nil, err
 	}
@@ -125,17 +132,71 @@ func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool) (*TreeBlobChann
 	return NewTreeBlobChannelWrapper(blobs, errchan), nil
 }
 
-func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEnv config.Environment) (map[string]*WrappedPointer, *filepathfilter.Filter, error) {
+// lsTreePromisorBlobs is like lsTreeBlobs, but lists the blobs without their
+// sizes, which would make Git fetch each missing blob in turn, and resolves them
+// with the given promisorFetcher before passing them to the predicate.
+func lsTreePromisorBlobs(ref string, promisor *promisorFetcher, predicate func(*git.TreeBlob) bool) (*TreeBlobChannelWrapper, error) {
+	r, err := promisor.newResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := git.LsTree(ref, false)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	cmd.Stdin.Close()
+
+	blobs := make(chan git.TreeBlob, chanBufSize)
+	errchan := make(chan error, 2)
+
+	go func() {
+		emit := func(b git.TreeBlob) {
+			if predicate(&b) {
+				blobs <- b
+			}
+		}
+
+		var err error
+		scanner := git.NewLsTreeScanner(cmd.Stdout)
+		for scanner.Scan() {
+			if err == nil {
+				err = r.add(*scanner.TreeBlob(), emit)
+			}
+		}
+		if err == nil {
+			err = r.flush(emit)
+		}
+		r.Close()
+
+		if err != nil {
+			errchan <- err
+		}
+		stderr, _ := ioutil.ReadAll(cmd.Stderr)
+		if err := cmd.Wait(); err != nil {
+			errchan <- fmt.Errorf("error in git ls-tree: %v %v", err, string(stderr))
+		}
+		close(blobs)
+		close(errchan)
+	}()
+
+	return NewTreeBlobChannelWrapper(blobs, errchan), nil
+}
+
+func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEnv config.Environment) (map[string]*WrappedPointer, map[string]error, *filepathfilter.Filter, error) {
 	pscanner, err := NewPointerScanner(gitEnv, osEnv)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	oscanner, err := git.NewObjectScanner(gitEnv, osEnv)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	pointers := make(map[string]*WrappedPointer)
+	malformed := make(map[string]error)
 
 	paths := make([]git.AttributePath, 0)
 	processor := gitattr.NewMacroProcessor()
@@ -156,7 +217,7 @@ func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEn
 			}
 
 			if err := oscanner.Err(); err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 		} else if t.Size < blobSizeCutoff {
 			hasNext = pscanner.Scan(t.Oid)
@@ -170,8 +231,12 @@ func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEn
 			}
 			pointers[t.Filename] = p
 
+			if err := pscanner.Malformed(); err != nil {
+				malformed[t.Filename] = err
+			}
+
 			if err := pscanner.Err(); err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 		} else {
 			pointers[t.Filename] = nil
@@ -188,44 +253,55 @@ func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEn
 		// Deal with nested error from incoming treeblobs
 		err := treeblobs.Wait()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if err = pscanner.Close(); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if err = oscanner.Close(); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	patterns := make([]filepathfilter.Pattern, 0, len(paths))
+	caseFold := filepathfilter.CaseFold(config.IgnoreCase(gitEnv))
 	for _, path := range paths {
 		// Convert all separators to `/` before creating a pattern to
 		// avoid characters being escaped in situations like `subtree\*.md`
-		patterns = append(patterns, filepathfilter.NewPattern(filepath.ToSlash(path.Path), filepathfilter.Strict(true)))
+		patterns = append(patterns, filepathfilter.NewPattern(filepath.ToSlash(path.Path), filepathfilter.Strict(true), caseFold))
 	}
 
-	return pointers, filepathfilter.NewFromPatterns(patterns, nil), nil
+	return pointers, malformed, filepathfilter.NewFromPatterns(patterns, nil), nil
 }
 
-func runScanTreeForPointers(cb GitScannerFoundPointer, tree string, gitEnv, osEnv config.Environment) error {
-	treeShas, err := lsTreeBlobs(tree, func(t *git.TreeBlob) bool {
+func runScanTreeForPointers(cb GitScannerFoundPointer, tree string, gitEnv, osEnv config.Environment, promisor *promisorFetcher) error {
+	treeShas, err := lsTreeBlobs(tree, promisor, func(t *git.TreeBlob) bool {
 		return t != nil
 	})
 	if err != nil {
 		return err
 	}
 
-	pointers, filter, err := catFileBatchTreeForPointers(treeShas, gitEnv, osEnv)
+	pointers, malformed, filter, err := catFileBatchTreeForPointers(treeShas, gitEnv, osEnv)
 	if err != nil {
 		return err
 	}
 
+	// Blobs which look like pointers but cannot be decoded are reported
+	// wherever they are, since they are damaged whether or not the
+	// .gitattributes patterns match them.
+	for name, err := range malformed {
+		cb(nil, errors.NewPointerScanError(errors.NewMalformedPointerError(err), tree, name))
+	}
+
 	for name, p := range pointers {
 		// This file matches the patterns in .gitattributes, so it
 		// should be a pointer.  If it is not, then it is a plain Git
 		// blob, which we report as an error.
+		if _, ok := malformed[name]; ok {
+			continue
+		}
 		if filter.Allows(name) {
 			if p == nil {
 				cb(nil, errors.NewPointerScanError(errors.NewNotAPointerError(nil), tree, name))
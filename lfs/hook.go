@@ -6,11 +6,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var (
@@ -79,11 +80,11 @@ func (h *Hook) Install(force bool) error {
 	}
 
 	if h.Exists() && !force {
-		tracerx.Printf(msg + ", upgrading...")
+		tracelog.Printf(msg + ", upgrading...")
 		return h.Upgrade()
 	}
 
-	tracerx.Printf(msg)
+	tracelog.Printf(msg)
 	return h.write()
 }
 
@@ -112,22 +113,77 @@ func (h *Hook) Upgrade() error {
 }
 
 // Uninstall removes the hook on disk so long as it matches the current version,
-// or any of the past versions of this hook.
+// or any of the past versions of this hook.  If the hook has other contents as
+// well, only the lines added for Git LFS are removed, so that the hooks of other
+// tools are left in place.
 func (h *Hook) Uninstall() error {
 	msg := fmt.Sprintf("Uninstall hook: %s, path=%s", h.Type, h.Path())
 
-	match, err := h.matchesCurrent()
+	if !h.Exists() {
+		tracelog.Printf(msg + ", doesn't exist...")
+		return nil
+	}
+
+	if match, _ := h.matchesCurrent(); match {
+		tracelog.Printf(msg)
+		return os.RemoveAll(h.Path())
+	}
+
+	by, err := ioutil.ReadFile(h.Path())
 	if err != nil {
 		return err
 	}
 
-	if !match {
-		tracerx.Printf(msg + ", doesn't match...")
+	remaining, ok := h.removeSection(string(by))
+	if !ok {
+		tracelog.Printf(msg + ", doesn't match...")
 		return nil
 	}
 
-	tracerx.Printf(msg)
-	return os.RemoveAll(h.Path())
+	if trimmed := strings.TrimSpace(remaining); len(trimmed) == 0 || trimmed == "#!/bin/sh" {
+		tracelog.Printf(msg + ", removing Git LFS section, nothing left...")
+		return os.RemoveAll(h.Path())
+	}
+
+	tracelog.Printf(msg + ", removing Git LFS section...")
+	return ioutil.WriteFile(h.Path(), []byte(remaining), 0755)
+}
+
+// removeSection returns the given contents of a hook without the lines which
+// run Git LFS, as given in the current version or any past version of this
+// hook, and whether there were any such lines.
+func (h *Hook) removeSection(contents string) (string, bool) {
+	sections := make([]string, 0, len(h.upgradeables)+1)
+	for _, c := range append([]string{h.Contents}, h.upgradeables...) {
+		if i := strings.Index(c, "\n"); i >= 0 && strings.HasPrefix(c, "#!") {
+			c = c[i+1:]
+		}
+		sections = append(sections, c)
+	}
+
+	// Look for longer sections first, so that the whole of the current
+	// section is removed, rather than only the line of an older one
+	// which it ends with.
+	sort.SliceStable(sections, func(i, j int) bool {
+		return len(sections[i]) > len(sections[j])
+	})
+
+	for _, section := range sections {
+		i := strings.Index(contents, section)
+		if i < 0 {
+			continue
+		}
+
+		// Only remove whole lines, in case another tool's line
+		// merely contains ours.
+		rest := contents[i+len(section):]
+		if (i > 0 && contents[i-1] != '\n') || (len(rest) > 0 && rest[0] != '\n' && rest[0] != '\r') {
+			continue
+		}
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "\r"), "\n")
+		return contents[:i] + rest, true
+	}
+	return contents, false
 }
 
 // matchesCurrent returns whether or not an existing git hook is able to be
@@ -0,0 +1,65 @@
+package lfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookUninstallRemovesMatchingHook(t *testing.T) {
+	dir := t.TempDir()
+	h := LoadHooks(dir, nil)[0]
+	require.Nil(t, ioutil.WriteFile(h.Path(), []byte(h.Contents+"\n"), 0755))
+
+	require.Nil(t, h.Uninstall())
+	assert.False(t, h.Exists())
+}
+
+func TestHookUninstallRemovesOnlyLFSSection(t *testing.T) {
+	dir := t.TempDir()
+	h := LoadHooks(dir, nil)[0]
+	contents := "#!/bin/sh\necho before\n" + h.Contents[len("#!/bin/sh\n"):] + "\necho after\n"
+	require.Nil(t, ioutil.WriteFile(h.Path(), []byte(contents), 0755))
+
+	require.Nil(t, h.Uninstall())
+
+	by, err := ioutil.ReadFile(h.Path())
+	require.Nil(t, err)
+	assert.Equal(t, "#!/bin/sh\necho before\necho after\n", string(by))
+}
+
+func TestHookUninstallRemovesOldLFSSection(t *testing.T) {
+	dir := t.TempDir()
+	h := LoadHooks(dir, nil)[0]
+	contents := "#!/bin/sh\nother-tool pre-push\ngit lfs pre-push \"$@\"\n"
+	require.Nil(t, ioutil.WriteFile(h.Path(), []byte(contents), 0755))
+
+	require.Nil(t, h.Uninstall())
+
+	by, err := ioutil.ReadFile(h.Path())
+	require.Nil(t, err)
+	assert.Equal(t, "#!/bin/sh\nother-tool pre-push\n", string(by))
+}
+
+func TestHookUninstallLeavesOtherHooks(t *testing.T) {
+	dir := t.TempDir()
+	h := LoadHooks(dir, nil)[0]
+	contents := "#!/bin/sh\ngit lfs pre-push \"$@\" || echo failed\n"
+	require.Nil(t, ioutil.WriteFile(h.Path(), []byte(contents), 0755))
+
+	require.Nil(t, h.Uninstall())
+
+	by, err := ioutil.ReadFile(h.Path())
+	require.Nil(t, err)
+	assert.Equal(t, contents, string(by))
+}
+
+func TestHookUninstallMissingHook(t *testing.T) {
+	h := LoadHooks(filepath.Join(os.TempDir(), "git-lfs-no-such-dir"), nil)[0]
+
+	assert.Nil(t, h.Uninstall())
+}
@@ -1,5 +1,35 @@
 // Package lfs brings together the core LFS functionality
-// NOTE: Subject to change, do not rely on this package from outside git-lfs source
+//
+// Along with the config, lfsapi, tq and fs packages, it can be used by other
+// Go programs to act as a Git LFS client.  Each part is constructed explicitly
+// from a *config.Configuration for the repository, and failures are returned
+// as errors rather than ending the process, so that several repositories can
+// be used at once:
+//
+//	cfg := config.NewIn(workdir, gitdir)
+//	client, err := lfsapi.NewClient(cfg)
+//	if err != nil {
+//		return err
+//	}
+//	manifest := tq.NewManifest(cfg.Filesystem(), client, "download", "origin")
+//	q := tq.NewTransferQueue(tq.Download, manifest, "origin")
+//
+// Some state is still shared by the whole process, rather than held by the
+// objects for each repository, and should be set up once by the program:
+//
+//   - lfshttp.UserAgent, the default User-Agent of new clients, which each
+//     lfshttp.Client may override;
+//   - the metrics exporters, set with metrics.Setup;
+//   - the trace log, set with tracelog.Configure, which also exports its
+//     correlation ID to child processes with os.Setenv.
+//
+// Warnings are traced unless a writer is given for them, with
+// lfsapi.Client.SetWarningOut and tq.Manifest.SetWarningOut.  The JSON
+// progress stream named by GIT_LFS_PROGRESS_JSON is opened by the program with
+// tq.NewProgressStreamFromEnv, and given to each tq.Manifest and GitFilter
+// which is to write to it.
+//
+// NOTE: The API is subject to change between releases.
 package lfs
 
 import (
@@ -9,21 +39,16 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/config"
-	"github.com/git-lfs/git-lfs/lfsapi"
-	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/tq"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 func Environ(cfg *config.Configuration, manifest *tq.Manifest, envOverrides map[string]string) []string {
 	osEnviron := os.Environ()
 	env := make([]string, 0, len(osEnviron)+7)
 
-	api, err := lfsapi.NewClient(cfg)
-	if err != nil {
-		// TODO(@ttaylorr): don't panic
-		panic(err.Error())
-	}
+	api := manifest.APIClient()
 
 	if envOverrides == nil {
 		envOverrides = make(map[string]string, 0)
@@ -76,23 +101,22 @@ func Environ(cfg *config.Configuration, manifest *tq.Manifest, envOverrides map[
 	}
 
 	for _, e := range osEnviron {
-		key := strings.SplitN(e, "=", 2)[0]
-		if !strings.HasPrefix(key, "GIT_") {
+		pieces := strings.SplitN(e, "=", 2)
+		key := pieces[0]
+		if !strings.HasPrefix(key, "GIT_") || len(pieces) < 2 {
 			continue
 		}
-		if val, ok := envOverrides[key]; ok {
-			env = append(env, fmt.Sprintf("%s=%s", key, val))
-		} else {
-			env = append(env, e)
+		val := pieces[1]
+		if override, ok := envOverrides[key]; ok {
+			val = override
 		}
+		env = append(env, fmt.Sprintf("%s=%s", key, RedactEnv(key, val)))
 	}
 
 	return env
 }
 
 func init() {
-	tracerx.DefaultKey = "GIT"
-	tracerx.Prefix = "trace git-lfs: "
 	if len(os.Getenv("GIT_TRACE")) < 1 {
 		if tt := os.Getenv("GIT_TRANSFER_TRACE"); len(tt) > 0 {
 			os.Setenv("GIT_TRACE", tt)
@@ -117,13 +141,22 @@ func LinkOrCopyFromReference(cfg *config.Configuration, oid string, size int64)
 		return err
 	}
 	for _, altMediafile := range altMediafiles {
-		tracerx.Printf("altMediafile: %s", altMediafile)
-		if altMediafile != "" && tools.FileExistsOfSize(altMediafile, size) {
-			err = LinkOrCopy(cfg, altMediafile, mediafile)
+		tracelog.Printf("altMediafile: %s", altMediafile)
+		if altMediafile == "" {
+			continue
+		}
+		if altSize, compressed, err := fs.ObjectFileSize(altMediafile); err == nil && altSize == size {
+			src, dst := altMediafile, mediafile
+			if compressed {
+				src, dst = fs.CompressedObjectPath(src), fs.CompressedObjectPath(dst)
+			}
+			err = LinkOrCopy(cfg, src, dst)
 			if err == nil {
-				break
+				return nil
 			}
 		}
 	}
+
+	cfg.Filesystem().CopyFromSecondary(oid, size)
 	return err
 }
@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/gitobj/v2"
 )
 
@@ -24,11 +25,12 @@ var (
 		"https://git-lfs.github.com/spec/v1", // public launch
 	}
 	latest      = "https://git-lfs.github.com/spec/v1"
-	oidType     = "sha256"
-	oidRE       = regexp.MustCompile(`\A[0-9a-f]{64}\z`)
+	oidRE       = regexp.MustCompile(`\A[0-9a-f]+\z`)
 	matcherRE   = regexp.MustCompile("git-media|hawser|git-lfs")
 	extRE       = regexp.MustCompile(`\Aext-\d{1}-\w+`)
+	metaRE      = regexp.MustCompile(`\Ameta\.[a-z0-9.-]+\z`)
 	pointerKeys = []string{"version", "oid", "size"}
+	utf8BOM     = []byte("\xef\xbb\xbf")
 )
 
 type Pointer struct {
@@ -37,9 +39,19 @@ type Pointer struct {
 	Size       int64
 	OidType    string
 	Extensions []*PointerExtension
-	Canonical  bool
+	// Metadata holds optional "meta.*" keys describing the object, such
+	// as its content type. They are keyed by their full name, including
+	// the "meta." prefix.
+	Metadata  map[string]string
+	Canonical bool
 }
 
+const (
+	// MetaContentType is the pointer metadata key holding the media type
+	// of the object's contents.
+	MetaContentType = "meta.content-type"
+)
+
 // A PointerExtension is parsed from the Git LFS Pointer file.
 type PointerExtension struct {
 	Name     string
@@ -54,12 +66,15 @@ func (p ByPriority) Len() int           { return len(p) }
 func (p ByPriority) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p ByPriority) Less(i, j int) bool { return p[i].Priority < p[j].Priority }
 
+// NewPointer returns a Pointer to the object with the given OID and size. The
+// pointer's OID type is determined by the length of the OID, so that it is
+// "sha512" for SHA-512 OIDs, and "sha256" otherwise.
 func NewPointer(oid string, size int64, exts []*PointerExtension) *Pointer {
-	return &Pointer{latest, oid, size, oidType, exts, true}
+	return &Pointer{latest, oid, size, tools.OidHashAlgorithm(oid), exts, nil, true}
 }
 
 func NewPointerExtension(name string, priority int, oid string) *PointerExtension {
-	return &PointerExtension{name, priority, oid, oidType}
+	return &PointerExtension{name, priority, oid, tools.OidHashAlgorithm(oid)}
 }
 
 func (p *Pointer) Encode(writer io.Writer) (int, error) {
@@ -76,11 +91,41 @@ func (p *Pointer) Encoded() string {
 	for _, ext := range p.Extensions {
 		buffer.WriteString(fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
 	}
+	for _, key := range p.metadataKeys() {
+		buffer.WriteString(fmt.Sprintf("%s %s\n", key, p.Metadata[key]))
+	}
 	buffer.WriteString(fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
 	buffer.WriteString(fmt.Sprintf("size %d\n", p.Size))
 	return buffer.String()
 }
 
+// metadataKeys returns the keys of the pointer's metadata in the sorted order
+// in which they are encoded.
+func (p *Pointer) metadataKeys() []string {
+	keys := make([]string, 0, len(p.Metadata))
+	for key := range p.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetMetadata sets the metadata "key" to "value", or removes it if "value" is
+// empty. Values are limited to a single line, so any line breaks are replaced
+// with spaces.
+func (p *Pointer) SetMetadata(key, value string) {
+	value = strings.TrimSpace(strings.NewReplacer("\r", " ", "\n", " ").Replace(value))
+	if len(value) == 0 {
+		delete(p.Metadata, key)
+		return
+	}
+
+	if p.Metadata == nil {
+		p.Metadata = make(map[string]string)
+	}
+	p.Metadata[key] = value
+}
+
 func EmptyPointer() *Pointer {
 	oid := hex.EncodeToString(sha256.New().Sum(nil))
 	return NewPointer(oid, 0, nil)
@@ -99,6 +144,8 @@ func DecodePointerFromBlob(b *gitobj.Blob) (*Pointer, error) {
 }
 
 func DecodePointerFromFile(file string) (*Pointer, error) {
+	file = tools.LongPath(file)
+
 	// Check size before reading
 	stat, err := os.Stat(file)
 	if err != nil {
@@ -150,6 +197,77 @@ func DecodeFrom(reader io.Reader) (*Pointer, io.Reader, error) {
 	return p, contents, err
 }
 
+// PointerDamage is a set of ways in which a pointer's encoding may have been
+// damaged by tools which treat it as ordinary text.
+type PointerDamage int
+
+const (
+	// PointerDamageCRLF indicates that the pointer's line endings were
+	// converted to CRLF.
+	PointerDamageCRLF PointerDamage = 1 << iota
+	// PointerDamageBOM indicates that a UTF-8 byte order mark was inserted
+	// at the start of the pointer.
+	PointerDamageBOM
+)
+
+func (d PointerDamage) String() string {
+	var kinds []string
+	if d&PointerDamageCRLF != 0 {
+		kinds = append(kinds, "CRLF line endings")
+	}
+	if d&PointerDamageBOM != 0 {
+		kinds = append(kinds, "byte order mark")
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// DecodePointerLenient decodes a pointer like DecodePointer, but also
+// recognizes pointers damaged by CRLF conversion or byte order mark insertion,
+// which DecodePointer either rejects or reports only as non-canonical. It
+// returns the ways in which the pointer was damaged, if any.
+//
+// A damaged pointer is never canonical, and is repaired by writing its
+// canonical encoding, as returned by Encoded, in its place.
+func DecodePointerLenient(reader io.Reader) (*Pointer, PointerDamage, error) {
+	// Allow for the extra bytes that the damage itself adds.
+	buf := make([]byte, 2*blobSizeCutoff)
+	n, err := io.ReadFull(reader, buf)
+	if err == nil {
+		return nil, 0, errors.NewNotAPointerError(errors.New("pointer size exceeds lfs pointer size cutoff"))
+	} else if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, 0, err
+	}
+
+	data := buf[:n]
+
+	var damage PointerDamage
+	if bytes.HasPrefix(data, utf8BOM) {
+		damage |= PointerDamageBOM
+		data = data[len(utf8BOM):]
+	}
+	if bytes.Contains(data, []byte("\r\n")) {
+		damage |= PointerDamageCRLF
+		data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	}
+
+	p, err := DecodePointer(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	p.Canonical = p.Canonical && damage == 0
+	return p, damage, nil
+}
+
+// looksLikePointer returns whether the given data appears to be meant as a
+// pointer, whether or not it can be decoded as one.
+func looksLikePointer(data []byte) bool {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	return bytes.HasPrefix(line, []byte("version ")) && matcherRE.Match(line)
+}
+
 func verifyVersion(version string) error {
 	if len(version) == 0 {
 		return errors.NewNotAPointerError(errors.New("Missing version"))
@@ -165,7 +283,7 @@ func verifyVersion(version string) error {
 }
 
 func decodeKV(data []byte) (*Pointer, error) {
-	kvps, exts, err := decodeKVData(data)
+	kvps, exts, meta, err := decodeKVData(data)
 	if err != nil {
 		if errors.IsBadPointerKeyError(err) {
 			return nil, errors.StandardizeBadPointerError(err)
@@ -208,7 +326,9 @@ func decodeKV(data []byte) (*Pointer, error) {
 		sort.Sort(ByPriority(extensions))
 	}
 
-	return NewPointer(oid, size, extensions), nil
+	p := NewPointer(oid, size, extensions)
+	p.Metadata = meta
+	return p, nil
 }
 
 func parseOid(value string) (string, error) {
@@ -216,11 +336,12 @@ func parseOid(value string) (string, error) {
 	if len(parts) != 2 {
 		return "", errors.New("Invalid Oid value: " + value)
 	}
-	if parts[0] != oidType {
+	h := tools.NewLfsContentHashAlgorithm(parts[0])
+	if h == nil {
 		return "", errors.New("Invalid Oid type: " + parts[0])
 	}
 	oid := parts[1]
-	if !oidRE.Match([]byte(oid)) {
+	if len(oid) != h.Size()*2 || !oidRE.Match([]byte(oid)) {
 		return "", errors.New("Invalid Oid: " + oid)
 	}
 	return oid, nil
@@ -258,7 +379,7 @@ func validatePointerExtensions(exts []*PointerExtension) error {
 	return nil
 }
 
-func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string, err error) {
+func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string, meta map[string]string, err error) {
 	kvps = make(map[string]string)
 
 	if !matcherRE.Match(data) {
@@ -290,6 +411,13 @@ func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string,
 		}
 
 		if expected := pointerKeys[line]; key != expected {
+			if expected == "oid" && metaRE.MatchString(key) {
+				if meta == nil {
+					meta = make(map[string]string)
+				}
+				meta[key] = value
+				continue
+			}
 			if !extRE.Match([]byte(key)) {
 				err = errors.NewBadPointerKeyError(expected, key)
 				return
@@ -3,6 +3,7 @@ package lfs
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"reflect"
 	"strings"
@@ -74,6 +75,22 @@ func assertLine(t *testing.T, r *bufio.Reader, expected string) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestLooksLikePointer(t *testing.T) {
+	for _, ex := range []struct {
+		Data     string
+		Expected bool
+	}{
+		{"version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 12\n", true},
+		{"version https://git-lfs.github.com/spec/v1", true},
+		{"version https://hawser.github.com/spec/v1\nsize x\n", true},
+		{"version 1.0\nlfs is not mentioned here\n", false},
+		{"# version https://git-lfs.github.com/spec/v1\n", false},
+		{"", false},
+	} {
+		assert.Equal(t, ex.Expected, looksLikePointer([]byte(ex.Data)), "%q", ex.Data)
+	}
+}
+
 func TestDecodeTinyFile(t *testing.T) {
 	ex := "this is not a git-lfs file!"
 	p, err := DecodePointer(bytes.NewBufferString(ex))
@@ -99,6 +116,19 @@ size 12345`
 	assertEqualWithExample(t, ex, int64(12345), p.Size)
 }
 
+func TestDecodeSHA512(t *testing.T) {
+	oid := strings.Repeat("4d7a2146", 16)
+	ex := fmt.Sprintf(`version https://git-lfs.github.com/spec/v1
+oid sha512:%s
+size 12345`, oid)
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assertEqualWithExample(t, ex, nil, err)
+	assertEqualWithExample(t, ex, oid, p.Oid)
+	assertEqualWithExample(t, ex, "sha512", p.OidType)
+	assertEqualWithExample(t, ex, ex+"\n", p.Encoded())
+}
+
 func TestDecodeExtensions(t *testing.T) {
 	ex := `version https://git-lfs.github.com/spec/v1
 ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
@@ -127,6 +157,40 @@ size 12345`
 	assertEqualWithExample(t, ex, "sha256", p.Extensions[2].OidType)
 }
 
+func TestDecodeMetadata(t *testing.T) {
+	ex := `version https://git-lfs.github.com/spec/v1
+ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+meta.content-type image/png
+meta.x-label my picture
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345`
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assertEqualWithExample(t, ex, nil, err)
+	assertEqualWithExample(t, ex, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid)
+	assertEqualWithExample(t, ex, 1, len(p.Extensions))
+	assertEqualWithExample(t, ex, "image/png", p.Metadata[MetaContentType])
+	assertEqualWithExample(t, ex, "my picture", p.Metadata["meta.x-label"])
+	assertEqualWithExample(t, ex, ex+"\n", p.Encoded())
+}
+
+func TestEncodeMetadata(t *testing.T) {
+	p := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+	p.SetMetadata("meta.x-label", "a\nb")
+	p.SetMetadata(MetaContentType, "text/plain; charset=utf-8")
+	p.SetMetadata("meta.empty", "")
+
+	assert.Equal(t, `version https://git-lfs.github.com/spec/v1
+meta.content-type text/plain; charset=utf-8
+meta.x-label a b
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`, p.Encoded())
+
+	p.SetMetadata("meta.x-label", "")
+	assert.NotContains(t, p.Encoded(), "meta.x-label")
+}
+
 func TestDecodeExtensionsSort(t *testing.T) {
 	ex := `version https://git-lfs.github.com/spec/v1
 ext-2-baz sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
@@ -233,6 +297,35 @@ size 12345
 	}
 }
 
+func TestDecodePointerLenient(t *testing.T) {
+	canonical := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	crlf := strings.Replace(canonical, "\n", "\r\n", -1)
+
+	examples := map[string]PointerDamage{
+		canonical:                  0,
+		crlf:                       PointerDamageCRLF,
+		"\xef\xbb\xbf" + canonical: PointerDamageBOM,
+		"\xef\xbb\xbf" + crlf:      PointerDamageCRLF | PointerDamageBOM,
+	}
+
+	for ex, damage := range examples {
+		p, d, err := DecodePointerLenient(strings.NewReader(ex))
+		if assert.Nil(t, err, "%q", ex) {
+			assert.Equal(t, damage, d, "%q", ex)
+			assert.Equal(t, damage == 0, p.Canonical, "%q", ex)
+			assert.Equal(t, canonical, p.Encoded(), "%q", ex)
+		}
+	}
+
+	assert.Equal(t, "CRLF line endings, byte order mark", (PointerDamageCRLF | PointerDamageBOM).String())
+
+	_, err := DecodePointer(strings.NewReader("\xef\xbb\xbf" + canonical))
+	assert.True(t, errors.IsNotAPointerError(err))
+
+	_, _, err = DecodePointerLenient(strings.NewReader("not a pointer"))
+	assert.True(t, errors.IsNotAPointerError(err))
+}
+
 func TestDecodeInvalid(t *testing.T) {
 	examples := []string{
 		"invalid stuff",
@@ -243,6 +336,17 @@ func TestDecodeInvalid(t *testing.T) {
 		// bad oid
 		`version https://git-lfs.github.com/spec/v1
 oid sha256:boom
+size 12345`,
+
+		// sha256 oid with sha512 type
+		`version https://git-lfs.github.com/spec/v1
+oid sha512:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345`,
+
+		// metadata after oid
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+meta.name foo.bin
 size 12345`,
 
 		// bad oid type
@@ -0,0 +1,55 @@
+package lfs
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secret values reported by Environ.
+const redacted = "<redacted>"
+
+// secretEnvironWords are the words which, as one of the underscore-separated
+// parts of an environment variable's name, mark its value as secret, as in
+// GIT_LFS_TOKEN and GIT_LFS_CRYPT_KEY.
+var secretEnvironWords = map[string]bool{
+	"TOKEN":    true,
+	"KEY":      true,
+	"PASSWORD": true,
+	"SECRET":   true,
+}
+
+// RedactEnv returns the value of the environment variable "key" as it may be
+// shown to the user, with secrets removed: the whole value if the variable
+// holds a token or key, or else any user information in a URL, such as the
+// password of a proxy.
+func RedactEnv(key, value string) string {
+	if len(value) > 0 {
+		for _, word := range strings.Split(strings.ToUpper(key), "_") {
+			if secretEnvironWords[word] {
+				return redacted
+			}
+		}
+	}
+	return RedactURL(value)
+}
+
+// RedactURL returns "rawurl" without any user information, such as
+// "user:password@", which it contains.  Values which are not URLs, and URLs
+// without a scheme, as proxies are often given, are handled too.
+func RedactURL(rawurl string) string {
+	if !strings.Contains(rawurl, "@") {
+		return rawurl
+	}
+
+	prefix := ""
+	if !strings.Contains(rawurl, "://") {
+		prefix = "http://"
+	}
+
+	u, err := url.Parse(prefix + rawurl)
+	if err != nil || u.User == nil || len(u.Host) == 0 {
+		return rawurl
+	}
+	u.User = nil
+	return strings.TrimPrefix(u.String(), prefix)
+}
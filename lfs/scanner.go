@@ -30,26 +30,35 @@ type WrappedPointer struct {
 	*Pointer
 }
 
+// revListEntry is a git object to be scanned, along with its name, if it has
+// one.  Names are carried through the scanning pipeline with their objects,
+// rather than being looked up afterwards, so that the names of every object
+// in history need not be held in memory at once.
+type revListEntry struct {
+	Sha1 string
+	Name string
+}
+
 // catFileBatchCheck uses git cat-file --batch-check to get the type
 // and size of a git object. Any object that isn't of type blob and
 // under the blobSizeCutoff will be ignored. revs is a channel over
-// which strings containing git sha1s will be sent. It returns a channel
-// from which sha1 strings can be read.
-func catFileBatchCheck(revs *StringChannelWrapper, lockableSet *lockableNameSet) (*StringChannelWrapper, chan string, error) {
-	smallRevCh := make(chan string, chanBufSize)
+// which git objects will be sent. It returns a channel from which the
+// small blobs can be read.
+func catFileBatchCheck(revs *revListEntryChannelWrapper, lockableSet *lockableNameSet) (*revListEntryChannelWrapper, chan string, error) {
+	smallRevCh := make(chan revListEntry, chanBufSize)
 	lockableCh := make(chan string, chanBufSize)
 	errCh := make(chan error, 2) // up to 2 errors, one from each goroutine
 	if err := runCatFileBatchCheck(smallRevCh, lockableCh, lockableSet, revs, errCh); err != nil {
 		return nil, nil, err
 	}
-	return NewStringChannelWrapper(smallRevCh, errCh), lockableCh, nil
+	return newRevListEntryChannelWrapper(smallRevCh, errCh), lockableCh, nil
 }
 
 // catFileBatch uses git cat-file --batch to get the object contents
 // of a git object, given its sha1. The contents will be decoded into
-// a Git LFS pointer. revs is a channel over which strings containing Git SHA1s
-// will be sent. It returns a channel from which point.Pointers can be read.
-func catFileBatch(revs *StringChannelWrapper, lockableSet *lockableNameSet, gitEnv, osEnv config.Environment) (*PointerChannelWrapper, chan string, error) {
+// a Git LFS pointer. revs is a channel over which git objects will be sent.
+// It returns a channel from which point.Pointers can be read.
+func catFileBatch(revs *revListEntryChannelWrapper, lockableSet *lockableNameSet, gitEnv, osEnv config.Environment) (*PointerChannelWrapper, chan string, error) {
 	pointerCh := make(chan *WrappedPointer, chanBufSize)
 	lockableCh := make(chan string, chanBufSize)
 	errCh := make(chan error, 5) // shared by 2 goroutines & may add more detail errors?
@@ -87,6 +96,19 @@ func NewStringChannelWrapper(stringChan <-chan string, errorChan <-chan error) *
 	return &StringChannelWrapper{tools.NewBaseChannelWrapper(errorChan), stringChan}
 }
 
+// ChannelWrapper for revListEntry channel functions to more easily return async error data via Wait()
+// See newRevListEntryChannelWrapper for construction / use
+type revListEntryChannelWrapper struct {
+	*tools.BaseChannelWrapper
+	Results <-chan revListEntry
+}
+
+// Construct a new channel wrapper for revListEntry
+// Caller can use s.Results directly for normal processing then call Wait() to finish & check for errors
+func newRevListEntryChannelWrapper(entryChan <-chan revListEntry, errorChan <-chan error) *revListEntryChannelWrapper {
+	return &revListEntryChannelWrapper{tools.NewBaseChannelWrapper(errorChan), entryChan}
+}
+
 // ChannelWrapper for TreeBlob channel functions to more easily return async error data via Wait()
 // See NewTreeBlobChannelWrapper for construction / use
 type TreeBlobChannelWrapper struct {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/git"
 	. "github.com/git-lfs/git-lfs/lfs"
 	test "github.com/git-lfs/git-lfs/t/cmd/util"
 	"github.com/stretchr/testify/assert"
@@ -192,6 +193,54 @@ func TestScanPreviousVersions(t *testing.T) {
 	assert.Equal(t, expected, pointers)
 }
 
+func TestScanLastReferences(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	now := time.Now()
+
+	inputs := []*test.CommitInput{
+		{ // 0
+			CommitDate: now.AddDate(0, 0, -3),
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+				{Filename: "file2.txt", Size: 30},
+			},
+		},
+		{ // 1
+			CommitDate: now.AddDate(0, 0, -2),
+			Files: []*test.FileInput{
+				{Filename: "file2.txt", Size: 22},
+			},
+		},
+		{ // 2
+			NewBranch:  "other",
+			CommitDate: now.AddDate(0, 0, -1),
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 12},
+			},
+		},
+	}
+	outputs := repo.AddCommits(inputs)
+
+	refs, err := NewGitScanner(config.New(), nil).ScanLastReferences()
+	assert.Nil(t, err)
+
+	// Replaced objects were last referenced by the parent of the commit
+	// replacing them, and others by the commit adding them.
+	expected := map[string]*PointerReference{
+		outputs[0].Files[0].Oid: {Commit: outputs[1].Sha, Name: "file1.txt"},
+		outputs[0].Files[1].Oid: {Commit: outputs[0].Sha, Name: "file2.txt"},
+		outputs[1].Files[0].Oid: {Commit: outputs[1].Sha, Name: "file2.txt"},
+		outputs[2].Files[0].Oid: {Commit: outputs[2].Sha, Name: "file1.txt"},
+	}
+	assert.Equal(t, expected, refs)
+}
+
 func scanPreviousVersions(t *testing.T, ref string, since time.Time) ([]*WrappedPointer, error) {
 	pointers := make([]*WrappedPointer, 0, 10)
 	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
@@ -205,3 +254,116 @@ func scanPreviousVersions(t *testing.T, ref string, since time.Time) ([]*Wrapped
 	err := gitscanner.ScanPreviousVersions(ref, since, nil)
 	return pointers, err
 }
+
+func TestScanRefsNamesPointers(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	inputs := []*test.CommitInput{
+		{ // 0
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+				{Filename: "dir/file2.txt", Size: 30},
+			},
+		},
+		{ // 1
+			NewBranch: "other",
+			Files: []*test.FileInput{
+				{Filename: "dir/file3.txt", Size: 40},
+			},
+		},
+	}
+	outputs := repo.AddCommits(inputs)
+
+	names := make(map[string]string)
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		names[p.Oid] = p.Name
+	})
+	defer gitscanner.Close()
+
+	assert.Nil(t, gitscanner.ScanAll(nil))
+	assert.Equal(t, map[string]string{
+		outputs[0].Files[0].Oid: "file1.txt",
+		outputs[0].Files[1].Oid: "dir/file2.txt",
+		outputs[1].Files[0].Oid: "dir/file3.txt",
+	}, names)
+
+	// Objects reachable from excluded refs are not reported again.
+	names = make(map[string]string)
+	assert.Nil(t, gitscanner.ScanRefs([]string{"other"}, []string{"master"}, nil))
+	assert.Equal(t, map[string]string{
+		outputs[1].Files[0].Oid: "dir/file3.txt",
+	}, names)
+}
+
+func TestScanRefsInSHA256Repo(t *testing.T) {
+	if !git.IsGitVersionAtLeast("2.29.0") {
+		t.Skip("git version does not support SHA-256 repositories")
+	}
+
+	repo := test.NewRepoWithObjectFormat(t, "sha256")
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	now := time.Now()
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{ // 0
+			CommitDate: now.AddDate(0, 0, -2),
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+				{Filename: "dir/file2.txt", Size: 30},
+			},
+		},
+		{ // 1
+			CommitDate: now.AddDate(0, 0, -1),
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 25},
+			},
+		},
+	})
+
+	names := make(map[string]string)
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		names[p.Oid] = p.Name
+	})
+	defer gitscanner.Close()
+
+	assert.Nil(t, gitscanner.ScanAll(nil))
+	assert.Equal(t, map[string]string{
+		outputs[0].Files[0].Oid: "file1.txt",
+		outputs[0].Files[1].Oid: "dir/file2.txt",
+		outputs[1].Files[0].Oid: "file1.txt",
+	}, names)
+
+	names = make(map[string]string)
+	assert.Nil(t, gitscanner.ScanTree("HEAD"))
+	assert.Equal(t, map[string]string{
+		outputs[0].Files[1].Oid: "dir/file2.txt",
+		outputs[1].Files[0].Oid: "file1.txt",
+	}, names)
+
+	pointers, err := scanPreviousVersions(t, "master", now.AddDate(0, 0, -7))
+	assert.Nil(t, err)
+	assert.Equal(t, []*WrappedPointer{
+		{Name: "file1.txt", Pointer: outputs[0].Files[0]},
+	}, pointers)
+
+	refs, err := NewGitScanner(config.New(), nil).ScanLastReferences()
+	assert.Nil(t, err)
+	assert.Equal(t, &PointerReference{Commit: outputs[0].Sha, Name: "file1.txt"}, refs[outputs[0].Files[0].Oid])
+}
@@ -305,3 +305,30 @@ func TestLogScannerDeletionsFilterExclude(t *testing.T) {
 
 	assertScannerDone(t, scanner)
 }
+
+func TestLogScannerSHA512Pointers(t *testing.T) {
+	r := strings.NewReader(`lfs-commit-sha: 637908bf28b38ab238e1b5e6a5bfbfb2e513a0df 07d571b413957508679042e45508af5945b3f1e5
+
+diff --git a/big.bin b/big.bin
+new file mode 100644
+index 0000000..2fe5451
+--- /dev/null
++++ b/big.bin
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha512:1f9720f871674c18e5fecff61d92c1355cd4bfac25699fb7ddfe7717c9669b4d085193982402156122dfaa706885fd64741704649795c65b2a5bdec40347e28a
++size 6
+`)
+	scanner := newLogScanner(LogDiffAdditions, r)
+
+	assertNextScan(t, scanner)
+	p := scanner.Pointer()
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "big.bin", p.Name)
+		assert.Equal(t, "1f9720f871674c18e5fecff61d92c1355cd4bfac25699fb7ddfe7717c9669b4d085193982402156122dfaa706885fd64741704649795c65b2a5bdec40347e28a", p.Oid)
+		assert.Equal(t, "sha512", p.OidType)
+		assert.Equal(t, int64(6), p.Size)
+	}
+
+	assertScannerDone(t, scanner)
+}
@@ -0,0 +1,204 @@
+package lfs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/subprocess"
+)
+
+// MetaSignature is the pointer metadata key holding a base64-encoded,
+// detached GPG signature of the rest of the pointer.
+const MetaSignature = "meta.gpgsig"
+
+// SignsPointers returns whether new pointers should be signed, as given by
+// "lfs.pointer.sign".
+func SignsPointers(cfg *config.Configuration) bool {
+	return cfg.Git.Bool("lfs.pointer.sign", false)
+}
+
+// VerifiesPointerSignatures returns whether pointers must carry a valid
+// signature to be smudged, as given by "lfs.pointer.verifysignatures".
+func VerifiesPointerSignatures(cfg *config.Configuration) bool {
+	return cfg.Git.Bool("lfs.pointer.verifysignatures", false)
+}
+
+// AllowedPointerSigners returns the key IDs or fingerprints of the keys whose
+// signatures of pointers are accepted, as given by the values of
+// "lfs.pointer.allowedsigners", in upper case and without any "0x" prefix or
+// spaces.
+func AllowedPointerSigners(cfg *config.Configuration) []string {
+	var signers []string
+	for _, value := range cfg.Git.GetAll("lfs.pointer.allowedsigners") {
+		for _, signer := range strings.Split(value, ",") {
+			signer = strings.ToUpper(strings.Join(strings.Fields(signer), ""))
+			signer = strings.TrimPrefix(signer, "0X")
+			if len(signer) > 0 {
+				signers = append(signers, signer)
+			}
+		}
+	}
+	return signers
+}
+
+// SignedContent returns the encoded pointer without its signature, which is
+// the content covered by that signature.
+func (p *Pointer) SignedContent() []byte {
+	unsigned := *p
+	unsigned.Metadata = make(map[string]string, len(p.Metadata))
+	for key, value := range p.Metadata {
+		if key != MetaSignature {
+			unsigned.Metadata[key] = value
+		}
+	}
+	return []byte(unsigned.Encoded())
+}
+
+// SignPointer signs the given pointer with the key named by "user.signingkey",
+// or GPG's default key if it is unset, and records the signature in its
+// metadata.
+//
+// If "previous" is a validly signed pointer with the same content, its
+// signature is reused instead, since GPG signatures include the time at which
+// they were made, and the clean filter must produce the same pointer each time
+// it is run on the same file. If any signers are allowed, the signature is
+// only reused if it was made by one of them.
+func SignPointer(cfg *config.Configuration, p, previous *Pointer) error {
+	content := p.SignedContent()
+	if previous != nil && len(previous.Metadata[MetaSignature]) > 0 && bytes.Equal(content, previous.SignedContent()) {
+		if err := verifyPointerSignature(cfg, previous, AllowedPointerSigners(cfg)); err == nil {
+			p.SetMetadata(MetaSignature, previous.Metadata[MetaSignature])
+			return nil
+		}
+	}
+
+	args := []string{"--batch", "--no-tty", "--detach-sign"}
+	if key, _ := cfg.Git.Get("user.signingkey"); len(key) > 0 {
+		args = append(args, "--local-user", key)
+	}
+
+	cmd := subprocess.ExecCommand(gpgProgram(cfg), args...)
+	cmd.Stdin = bytes.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	sig, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "gpg failed to sign the pointer: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	p.SetMetadata(MetaSignature, base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// VerifyPointerSignature verifies the signature recorded in the given
+// pointer's metadata, returning an error if it is missing or invalid, or if it
+// was not made by one of the signers allowed by "lfs.pointer.allowedsigners".
+// A good signature from any other key in the user's keyring is not enough, as
+// it would not show who created the pointer.
+func VerifyPointerSignature(cfg *config.Configuration, p *Pointer) error {
+	allowed := AllowedPointerSigners(cfg)
+	if len(allowed) == 0 {
+		return errors.New("no pointer signers are allowed by lfs.pointer.allowedsigners")
+	}
+	return verifyPointerSignature(cfg, p, allowed)
+}
+
+// verifyPointerSignature verifies the signature recorded in the given pointer's
+// metadata, and that it was made by one of the "allowed" signers, or by any key
+// if none are given.
+func verifyPointerSignature(cfg *config.Configuration, p *Pointer, allowed []string) error {
+	encoded, ok := p.Metadata[MetaSignature]
+	if !ok {
+		return errors.New("pointer is not signed")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.Wrap(err, "pointer signature is not valid base64")
+	}
+
+	tmp, err := TempFile(cfg, "signature")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(sig)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd := subprocess.ExecCommand(gpgProgram(cfg), "--batch", "--no-tty", "--status-fd=1", "--verify", tmp.Name(), "-")
+	cmd.Stdin = bytes.NewReader(p.SignedContent())
+
+	status, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("pointer signature for %s is not valid", p.Oid)
+	}
+	keys, ok := parseGPGVerifyStatus(status)
+	if !ok {
+		return fmt.Errorf("pointer signature for %s is not valid", p.Oid)
+	}
+	if len(allowed) > 0 && !signerAllowed(keys, allowed) {
+		return fmt.Errorf("pointer signature for %s was made by %s, which is not an allowed signer", p.Oid, keys[0])
+	}
+	return nil
+}
+
+// parseGPGVerifyStatus returns the fingerprints of the signing key, and of its
+// primary key, from the status output of "gpg --verify", and whether it
+// reports exactly one good signature.
+func parseGPGVerifyStatus(status []byte) ([]string, bool) {
+	var keys []string
+	good := 0
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "[GNUPG:]" {
+			continue
+		}
+
+		switch fields[1] {
+		case "GOODSIG":
+			good++
+		case "BADSIG", "ERRSIG", "EXPKEYSIG", "REVKEYSIG":
+			return nil, false
+		case "VALIDSIG":
+			if len(fields) > 2 {
+				keys = append(keys, strings.ToUpper(fields[2]))
+			}
+			if len(fields) > 11 {
+				keys = append(keys, strings.ToUpper(fields[11]))
+			}
+		}
+	}
+	return keys, good == 1 && len(keys) > 0
+}
+
+// signerAllowed returns whether any of the given key fingerprints matches one of
+// the "allowed" signers, which may be fingerprints or the key IDs at their end.
+func signerAllowed(keys, allowed []string) bool {
+	for _, key := range keys {
+		for _, signer := range allowed {
+			if len(signer) >= 8 && strings.HasSuffix(key, signer) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gpgProgram(cfg *config.Configuration) string {
+	if program, _ := cfg.Git.Get("gpg.program"); len(program) > 0 {
+		return program
+	}
+	return "gpg"
+}
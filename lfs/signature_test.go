@@ -0,0 +1,126 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointerSignedContentOmitsSignature(t *testing.T) {
+	p := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+	p.SetMetadata(MetaContentType, "application/octet-stream")
+	unsigned := string(p.SignedContent())
+
+	p.SetMetadata(MetaSignature, "c2lnbmF0dXJl")
+
+	assert.Equal(t, unsigned, string(p.SignedContent()))
+	assert.Contains(t, p.Encoded(), "meta.gpgsig c2lnbmF0dXJl\n")
+	assert.Equal(t, "c2lnbmF0dXJl", p.Metadata[MetaSignature])
+}
+
+func TestVerifyPointerSignatureUnsigned(t *testing.T) {
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.pointer.allowedsigners": []string{"0123456789ABCDEF"},
+		},
+	})
+	p := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+
+	err := VerifyPointerSignature(cfg, p)
+	if assert.Error(t, err) {
+		assert.Equal(t, "pointer is not signed", err.Error())
+	}
+}
+
+func TestVerifyPointerSignatureNoAllowedSigners(t *testing.T) {
+	cfg := config.NewFrom(config.Values{})
+	p := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+	p.SetMetadata(MetaSignature, "c2lnbmF0dXJl")
+
+	err := VerifyPointerSignature(cfg, p)
+	if assert.Error(t, err) {
+		assert.Equal(t, "no pointer signers are allowed by lfs.pointer.allowedsigners", err.Error())
+	}
+}
+
+func TestVerifyPointerSignatureInvalidEncoding(t *testing.T) {
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.pointer.allowedsigners": []string{"0123456789ABCDEF"},
+		},
+	})
+	p := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+	p.SetMetadata(MetaSignature, "not base64!")
+
+	assert.Error(t, VerifyPointerSignature(cfg, p))
+}
+
+func TestPointerSigningConfig(t *testing.T) {
+	cfg := config.NewFrom(config.Values{})
+	assert.False(t, SignsPointers(cfg))
+	assert.False(t, VerifiesPointerSignatures(cfg))
+
+	cfg = config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.pointer.sign":             []string{"true"},
+			"lfs.pointer.verifysignatures": []string{"true"},
+		},
+	})
+	assert.True(t, SignsPointers(cfg))
+	assert.True(t, VerifiesPointerSignatures(cfg))
+}
+
+func TestAllowedPointerSigners(t *testing.T) {
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.pointer.allowedsigners": []string{
+				"0x89abcdef01234567",
+				"0123 4567 89AB CDEF 0123  4567 89AB CDEF 0123 4567, deadbeef",
+			},
+		},
+	})
+
+	assert.Equal(t, []string{
+		"89ABCDEF01234567",
+		"0123456789ABCDEF0123456789ABCDEF01234567",
+		"DEADBEEF",
+	}, AllowedPointerSigners(cfg))
+}
+
+func TestParseGPGVerifyStatus(t *testing.T) {
+	status := `[GNUPG:] NEWSIG
+[GNUPG:] KEY_CONSIDERED 0123456789ABCDEF0123456789ABCDEF01234567 0
+[GNUPG:] SIG_ID abcdefghijklmnopqrstuvwxyz0 2026-10-15 1792051200
+[GNUPG:] GOODSIG 89ABCDEF01234567 Git LFS Tester <lfs@example.com>
+[GNUPG:] VALIDSIG FEDCBA9876543210FEDCBA9876543210FEDCBA98 2026-10-15 1792051200 0 4 0 22 10 00 0123456789ABCDEF0123456789ABCDEF01234567
+[GNUPG:] TRUST_ULTIMATE 0 pgp
+`
+
+	keys, ok := parseGPGVerifyStatus([]byte(status))
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		"FEDCBA9876543210FEDCBA9876543210FEDCBA98",
+		"0123456789ABCDEF0123456789ABCDEF01234567",
+	}, keys)
+
+	_, ok = parseGPGVerifyStatus([]byte("[GNUPG:] BADSIG 89ABCDEF01234567 Git LFS Tester\n"))
+	assert.False(t, ok)
+
+	_, ok = parseGPGVerifyStatus([]byte("[GNUPG:] GOODSIG 89ABCDEF01234567 Git LFS Tester\n"))
+	assert.False(t, ok)
+}
+
+func TestSignerAllowed(t *testing.T) {
+	keys := []string{
+		"FEDCBA9876543210FEDCBA9876543210FEDCBA98",
+		"0123456789ABCDEF0123456789ABCDEF01234567",
+	}
+
+	assert.True(t, signerAllowed(keys, []string{"0123456789ABCDEF0123456789ABCDEF01234567"}))
+	assert.True(t, signerAllowed(keys, []string{"89ABCDEF01234567"}))
+	assert.True(t, signerAllowed(keys, []string{"00000000", "FEDCBA98"}))
+	assert.False(t, signerAllowed(keys, []string{"4567"}))
+	assert.False(t, signerAllowed(keys, []string{"0123456789ABCDEF"}))
+	assert.False(t, signerAllowed(keys, nil))
+}
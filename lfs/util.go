@@ -10,6 +10,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tq"
 )
 
 type Platform int
@@ -29,11 +30,23 @@ func join(parts ...string) string {
 }
 
 func (f *GitFilter) CopyCallbackFile(event, filename string, index, totalFiles int) (tools.CopyCallback, *os.File, error) {
-	logPath, _ := f.cfg.Os.Get("GIT_LFS_PROGRESS")
-	if len(logPath) == 0 || len(filename) == 0 || len(event) == 0 {
+	if len(filename) == 0 || len(event) == 0 {
 		return nil, nil, nil
 	}
 
+	// Smudging is logged as a "download" in GIT_LFS_PROGRESS, but the
+	// JSON progress stream names the phase after the filter.
+	phase := event
+	if event == "download" {
+		phase = "smudge"
+	}
+	streamCb := f.progressStream.CopyCallback(phase, filename)
+
+	logPath, _ := f.cfg.Os.Get("GIT_LFS_PROGRESS")
+	if len(logPath) == 0 {
+		return streamCb, nil, nil
+	}
+
 	if !filepath.IsAbs(logPath) {
 		return nil, nil, fmt.Errorf("GIT_LFS_PROGRESS must be an absolute path")
 	}
@@ -48,17 +61,33 @@ func (f *GitFilter) CopyCallbackFile(event, filename string, index, totalFiles i
 		return nil, file, wrapProgressError(err, event, logPath)
 	}
 
+	logger, err := tq.NewProgressLog(file, tq.ProgressLogVersion(f.cfg.Os))
+	if err != nil {
+		return nil, file, wrapProgressError(err, event, logPath)
+	}
+
 	var prevWritten int64
 
 	cb := tools.CopyCallback(func(total int64, written int64, current int) error {
-		if written != prevWritten {
-			_, err := file.Write([]byte(fmt.Sprintf("%s %d/%d %d/%d %s\n", event, index, totalFiles, written, total, filename)))
-			file.Sync()
-			prevWritten = written
-			return wrapProgressError(err, event, logPath)
+		if streamCb != nil {
+			streamCb(total, written, current)
+		}
+		if written == prevWritten {
+			return nil
 		}
 
-		return nil
+		if prevWritten == 0 {
+			if err := logger.Totals(event, int64(totalFiles), total); err != nil {
+				return wrapProgressError(err, event, logPath)
+			}
+		}
+		prevWritten = written
+
+		err := logger.Progress(event, int64(index), int64(totalFiles), written, total, filename)
+		if err == nil && written >= total {
+			err = logger.Complete(event, int64(index), int64(totalFiles), total, filename)
+		}
+		return wrapProgressError(err, event, logPath)
 	})
 
 	return cb, file, nil
@@ -209,7 +238,7 @@ func CopyFileContents(cfg *config.Configuration, src string, dst string) error {
 	if err != nil {
 		return err
 	}
-	return os.Rename(tmp.Name(), dst)
+	return cfg.Filesystem().CommitFile(tmp.Name(), dst)
 }
 
 func LinkOrCopy(cfg *config.Configuration, src string, dst string) error {
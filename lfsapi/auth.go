@@ -11,11 +11,7 @@ import (
 	"github.com/git-lfs/git-lfs/creds"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/lfshttp"
-	"github.com/rubyist/tracerx"
-)
-
-var (
-	defaultEndpointFinder = NewEndpointFinder(nil)
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // DoWithAuth sends an HTTP request to get an HTTP response. It attempts to add
@@ -31,7 +27,7 @@ func (c *Client) DoWithAuth(remote string, access creds.Access, req *http.Reques
 			// not count this against our redirection
 			// maximum.
 			newAccess := c.Endpoints.AccessFor(access.URL())
-			tracerx.Printf("api: http response indicates %q authentication. Resubmitting...", newAccess.Mode())
+			tracelog.Printf("api: http response indicates %q authentication. Resubmitting...", newAccess.Mode())
 			return c.DoWithAuth(remote, newAccess, req)
 		}
 	}
@@ -87,11 +83,17 @@ func (c *Client) doWithAuth(remote string, access creds.Access, req *http.Reques
 }
 
 func (c *Client) doWithCreds(req *http.Request, credWrapper creds.CredentialHelperWrapper, access creds.Access, via []*http.Request) (*http.Response, error) {
+	if err := c.breaker.Allow(req); err != nil {
+		return nil, err
+	}
+
 	if access.Mode() == creds.NegotiateAccess {
-		return c.doWithNegotiate(req, credWrapper)
+		res, err := c.doWithNegotiate(req, credWrapper)
+		c.breaker.Record(req, res, err)
+		return res, err
 	}
 
-	req.Header.Set("User-Agent", lfshttp.UserAgent)
+	req.Header.Set("User-Agent", c.client.UserAgent)
 
 	client, err := c.client.HttpClient(req.URL, access.Mode())
 	if err != nil {
@@ -99,6 +101,7 @@ func (c *Client) doWithCreds(req *http.Request, credWrapper creds.CredentialHelp
 	}
 
 	redirectedReq, res, err := c.client.DoWithRedirect(client, req, "", via)
+	c.breaker.Record(req, res, err)
 	if err != nil || res != nil {
 		return res, err
 	}
@@ -115,9 +118,11 @@ func (c *Client) doWithCreds(req *http.Request, credWrapper creds.CredentialHelp
 //
 // 1. NTLM access is handled elsewhere.
 // 2. Existing Authorization or ?token query tells LFS that the request is ready.
-// 3. Netrc based on the hostname.
-// 4. URL authentication on the Endpoint URL or the Git Remote URL.
-// 5. Git Credential Helper, potentially prompting the user.
+// 3. A bearer token from the GIT_LFS_TOKEN_<REMOTE> or GIT_LFS_TOKEN
+//    environment variables, for requests to the LFS API host.
+// 4. Netrc based on the hostname.
+// 5. URL authentication on the Endpoint URL or the Git Remote URL.
+// 6. Git Credential Helper, potentially prompting the user.
 //
 // There are three URLs in play, that make this a little confusing.
 //
@@ -134,14 +139,14 @@ func (c *Client) doWithCreds(req *http.Request, credWrapper creds.CredentialHelp
 func (c *Client) getCreds(remote string, access creds.Access, req *http.Request) (creds.CredentialHelperWrapper, error) {
 	ef := c.Endpoints
 	if ef == nil {
-		ef = defaultEndpointFinder
+		ef = NewEndpointFinder(c.context)
 	}
 
 	operation := getReqOperation(req)
 	apiEndpoint := ef.Endpoint(operation, remote)
 
 	if access.Mode() != creds.NegotiateAccess {
-		if requestHasAuth(req) || access.Mode() == creds.NoneAccess {
+		if requestHasAuth(req) || c.setRequestAuthFromEnv(remote, apiEndpoint, req) || access.Mode() == creds.NoneAccess {
 			return creds.CredentialHelperWrapper{CredentialHelper: creds.NullCreds, Input: nil, Url: nil, Creds: nil}, nil
 		}
 
@@ -157,7 +162,7 @@ func (c *Client) getCreds(remote string, access creds.Access, req *http.Request)
 		credWrapper := c.getGitCredsWrapper(ef, req, credsURL)
 		err = credWrapper.FillCreds()
 		if err == nil {
-			tracerx.Printf("Filled credentials for %s", credsURL)
+			tracelog.Printf("Filled credentials for %s", credsURL)
 			setRequestAuth(req, credWrapper.Creds["username"], credWrapper.Creds["password"])
 		}
 		return credWrapper, err
@@ -289,6 +294,60 @@ func setRequestAuthFromURL(req *http.Request, u *url.URL) bool {
 	return false
 }
 
+// setRequestAuthFromEnv sets a bearer token from the environment as the
+// Authorization header of the given request, provided that the request is
+// destined for the LFS API host, so that the token is never sent to storage
+// hosts returned by the server. It returns whether a token was set.
+func (c *Client) setRequestAuthFromEnv(remote string, apiEndpoint lfshttp.Endpoint, req *http.Request) bool {
+	token := c.envToken(remote)
+	if len(token) == 0 {
+		return false
+	}
+
+	apiURL, err := url.Parse(apiEndpoint.Url)
+	if err != nil || req.URL.Scheme != apiURL.Scheme || req.URL.Host != apiURL.Host {
+		return false
+	}
+
+	tracelog.Printf("api: using bearer token from environment for %s", req.URL.Host)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return true
+}
+
+// envToken returns the bearer token configured in the environment for the
+// given remote. A remote-specific token in GIT_LFS_TOKEN_<REMOTE>, where
+// <REMOTE> is the remote name upper-cased with any character other than a
+// letter or digit replaced by an underscore, takes precedence over
+// GIT_LFS_TOKEN.
+func (c *Client) envToken(remote string) string {
+	if c.context == nil {
+		return ""
+	}
+
+	osEnv := c.context.OSEnv()
+	if len(remote) > 0 {
+		if token, ok := osEnv.Get("GIT_LFS_TOKEN_" + envTokenSuffix(remote)); ok && len(token) > 0 {
+			return token
+		}
+	}
+
+	token, _ := osEnv.Get("GIT_LFS_TOKEN")
+	return token
+}
+
+func envTokenSuffix(remote string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, remote)
+}
+
 func setRequestAuth(req *http.Request, user, pass string) {
 	if len(user) == 0 && len(pass) == 0 {
 		return
@@ -749,3 +749,72 @@ func TestClientRedirectReauthenticate(t *testing.T) {
 	assert.EqualValues(t, 2, called1)
 	assert.EqualValues(t, 1, called2)
 }
+
+func TestDoWithAuthEnvToken(t *testing.T) {
+	var called uint32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddUint32(&called, 1)
+		assert.Equal(t, "Bearer remote-token", req.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	cred := newMockCredentialHelper()
+	c, err := NewClient(lfshttp.NewContext(git.NewReadOnlyConfig("", ""),
+		map[string]string{
+			"GIT_LFS_TOKEN":             "global-token",
+			"GIT_LFS_TOKEN_MY_REMOTE_1": "remote-token",
+		}, map[string]string{
+			"remote.my-remote.1.url": srv.URL + "/repo",
+		},
+	))
+	require.Nil(t, err)
+	c.Credentials = cred
+
+	req, err := http.NewRequest("GET", srv.URL+"/repo.git/info/lfs/foo", nil)
+	require.Nil(t, err)
+
+	res, err := c.DoWithAuth("my-remote.1", c.Endpoints.AccessFor(srv.URL+"/repo.git/info/lfs"), req)
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, 1, called)
+	assert.Empty(t, cred.Approved)
+}
+
+func TestGetCredsEnvTokenFallback(t *testing.T) {
+	c, err := NewClient(lfshttp.NewContext(git.NewReadOnlyConfig("", ""),
+		map[string]string{
+			"GIT_LFS_TOKEN":       "global-token",
+			"GIT_LFS_TOKEN_OTHER": "other-token",
+		}, map[string]string{
+			"lfs.url": "https://git-server.com/repo.git/info/lfs",
+		},
+	))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", "https://git-server.com/repo.git/info/lfs/locks", nil)
+	require.Nil(t, err)
+
+	_, err = c.getCreds("origin", creds.NewAccess(creds.BasicAccess, "https://git-server.com/repo.git/info/lfs"), req)
+	require.Nil(t, err)
+	assert.Equal(t, "Bearer global-token", req.Header.Get("Authorization"))
+}
+
+func TestGetCredsEnvTokenOtherHost(t *testing.T) {
+	c, err := NewClient(lfshttp.NewContext(git.NewReadOnlyConfig("", ""),
+		map[string]string{
+			"GIT_LFS_TOKEN": "global-token",
+		}, map[string]string{
+			"lfs.url": "https://git-server.com/repo.git/info/lfs",
+		},
+	))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", "https://storage-server.com/objects/oid", nil)
+	require.Nil(t, err)
+
+	_, err = c.getCreds("origin", creds.NewAccess(creds.NoneAccess, "https://git-server.com/repo.git/info/lfs"), req)
+	require.Nil(t, err)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
@@ -0,0 +1,122 @@
+package lfsapi
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// circuitBreaker keeps track of consecutive connection failures per host.
+// Once a host has failed "threshold" times in a row without any successful
+// response in between, the circuit for that host is opened, and all further
+// requests to it fail immediately for the remainder of the process.
+type circuitBreaker struct {
+	threshold int
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+type hostCircuit struct {
+	failures int
+	lastErr  error
+	open     bool
+}
+
+// newCircuitBreaker returns a circuitBreaker which opens after "threshold"
+// consecutive failures, or nil if "threshold" is less than 1, disabling the
+// circuit breaker altogether.
+func newCircuitBreaker(threshold int) *circuitBreaker {
+	if threshold < 1 {
+		return nil
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		hosts:     make(map[string]*hostCircuit),
+	}
+}
+
+// Allow returns an error if the circuit for the host of the given request is
+// open, and nil otherwise.
+func (b *circuitBreaker) Allow(req *http.Request) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if h, ok := b.hosts[req.URL.Host]; ok && h.open {
+		return &circuitOpenError{
+			host:     req.URL.Host,
+			failures: h.failures,
+			lastErr:  h.lastErr,
+		}
+	}
+	return nil
+}
+
+// Record updates the state of the circuit for the host of the given request
+// based on the outcome of performing it. Any HTTP response, regardless of its
+// status, counts as a success, since it means the host is reachable.
+func (b *circuitBreaker) Record(req *http.Request, res *http.Response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	host := req.URL.Host
+	if res != nil || err == nil {
+		delete(b.hosts, host)
+		return
+	}
+
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostCircuit{}
+		b.hosts[host] = h
+	}
+
+	h.failures++
+	h.lastErr = err
+	if !h.open && h.failures >= b.threshold {
+		tracelog.Printf("api: opening circuit for %s after %d consecutive connection failures", host, h.failures)
+		h.open = true
+	}
+}
+
+// circuitOpenError is returned for requests to a host whose circuit has been
+// opened by repeated connection failures.
+type circuitOpenError struct {
+	host     string
+	failures int
+	lastErr  error
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("LFS: %s is unreachable after %d consecutive connection failures, giving up: %v",
+		e.host, e.failures, e.lastErr)
+}
+
+// IsCircuitOpenError returns whether the given error was caused by a request
+// to a host that has been given up on after repeated connection failures.
+// Such errors should not be retried.
+func IsCircuitOpenError(err error) bool {
+	_, ok := errors.Cause(err).(*circuitOpenError)
+	return ok
+}
+
+// CircuitOpenHost returns the host which caused the given error, if it was
+// caused by a request to a host that has been given up on, and whether that
+// was the case.
+func CircuitOpenHost(err error) (string, bool) {
+	if e, ok := errors.Cause(err).(*circuitOpenError); ok {
+		return e.host, true
+	}
+	return "", false
+}
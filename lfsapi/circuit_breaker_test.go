@@ -0,0 +1,91 @@
+package lfsapi
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/creds"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	assert.Nil(t, newCircuitBreaker(0))
+
+	var b *circuitBreaker
+	req, err := http.NewRequest("GET", "https://example.com", nil)
+	require.Nil(t, err)
+
+	b.Record(req, nil, errors.New("boom"))
+	assert.Nil(t, b.Allow(req))
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(2)
+
+	req, err := http.NewRequest("GET", "https://example.com", nil)
+	require.Nil(t, err)
+	other, err := http.NewRequest("GET", "https://other.example.com", nil)
+	require.Nil(t, err)
+
+	b.Record(req, nil, errors.New("boom"))
+	assert.Nil(t, b.Allow(req))
+
+	b.Record(req, nil, errors.New("boom"))
+	err = b.Allow(req)
+	require.NotNil(t, err)
+	assert.True(t, IsCircuitOpenError(err))
+	assert.True(t, IsCircuitOpenError(errors.NewRetriableError(err)))
+
+	host, ok := CircuitOpenHost(errors.Wrap(err, "wrapped"))
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", host)
+
+	assert.Nil(t, b.Allow(other))
+}
+
+func TestCircuitBreakerResetsOnResponse(t *testing.T) {
+	b := newCircuitBreaker(2)
+
+	req, err := http.NewRequest("GET", "https://example.com", nil)
+	require.Nil(t, err)
+
+	b.Record(req, nil, errors.New("boom"))
+	b.Record(req, &http.Response{StatusCode: 500}, errors.New("server error"))
+	b.Record(req, nil, errors.New("boom"))
+
+	assert.Nil(t, b.Allow(req))
+}
+
+func TestDoWithAuthCircuitBreaker(t *testing.T) {
+	// Find an address that nothing is listening on.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	srv.Close()
+
+	c, err := NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.circuitbreaker": "2",
+	}))
+	require.Nil(t, err)
+
+	access := creds.NewAccess(creds.NoneAccess, "")
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "http://"+addr.String()+"/object", nil)
+		require.Nil(t, err)
+
+		_, err = c.DoWithAuth("", access, req)
+		require.NotNil(t, err)
+		assert.False(t, IsCircuitOpenError(err))
+	}
+
+	req, err := http.NewRequest("GET", "http://"+addr.String()+"/object", nil)
+	require.Nil(t, err)
+
+	_, err = c.DoWithAuth("", access, req)
+	require.NotNil(t, err)
+	assert.True(t, IsCircuitOpenError(err))
+}
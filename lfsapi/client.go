@@ -3,6 +3,7 @@ package lfsapi
 import (
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/creds"
@@ -46,10 +47,28 @@ func (c *Client) ConcurrentTransfers() int {
 	return c.client.ConcurrentTransfers
 }
 
+// SetWarningOut sets the writer to which warnings for the user, such as when a
+// server's limits are nearly reached, are written.
+func (c *Client) SetWarningOut(w io.Writer) {
+	c.client.WarningOut = w
+}
+
 func (c *Client) LogHTTPStats(w io.WriteCloser) {
 	c.client.LogHTTPStats(w)
 }
 
+// Limits returns the most recent quota and rate limits advertised by each
+// host, keyed by host.
+func (c *Client) Limits() map[string][]*lfshttp.Limit {
+	return c.client.Limits()
+}
+
+// ProxyFor returns the proxy which would be used for requests to the given
+// URL, or nil if none would be used.
+func (c *Client) ProxyFor(u *url.URL) (*url.URL, error) {
+	return c.client.ProxyFor(u)
+}
+
 func (c *Client) Close() error {
 	return c.client.Close()
 }
@@ -12,7 +12,7 @@ import (
 	"github.com/git-lfs/git-lfs/creds"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfshttp"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 const (
@@ -117,6 +117,13 @@ func (e *endpointGitFinder) RemoteEndpoint(operation, remote string) lfshttp.End
 	}
 
 	// finally fall back on git remote url (also supports pushurl)
+	if operation == "upload" {
+		if url, ok := e.gitEnv.Get("remote." + remote + ".pushurl"); ok {
+			// As in Git, `url.*.pushinsteadof` aliases do not
+			// apply to a remote with an explicit push URL.
+			return e.newEndpointFromCloneURL(e.ReplaceUrlAlias("download", url))
+		}
+	}
 	if url := e.GitRemoteURL(remote, operation == "upload"); url != "" {
 		return e.NewEndpointFromCloneURL(operation, url)
 	}
@@ -145,7 +152,13 @@ func (e *endpointGitFinder) GitRemoteURL(remote string, forpush bool) string {
 }
 
 func (e *endpointGitFinder) NewEndpointFromCloneURL(operation, rawurl string) lfshttp.Endpoint {
-	ep := e.NewEndpoint(operation, rawurl)
+	return e.newEndpointFromCloneURL(e.ReplaceUrlAlias(operation, rawurl))
+}
+
+// newEndpointFromCloneURL is like NewEndpointFromCloneURL, for a clone URL
+// whose `url.*.insteadof` aliases have already been replaced.
+func (e *endpointGitFinder) newEndpointFromCloneURL(rawurl string) lfshttp.Endpoint {
+	ep := e.newEndpoint(rawurl)
 	if ep.Url == lfshttp.UrlUnknown {
 		return ep
 	}
@@ -169,7 +182,12 @@ func (e *endpointGitFinder) NewEndpointFromCloneURL(operation, rawurl string) lf
 }
 
 func (e *endpointGitFinder) NewEndpoint(operation, rawurl string) lfshttp.Endpoint {
-	rawurl = e.ReplaceUrlAlias(operation, rawurl)
+	return e.newEndpoint(e.ReplaceUrlAlias(operation, rawurl))
+}
+
+// newEndpoint is like NewEndpoint, for a URL whose `url.*.insteadof` aliases
+// have already been replaced.
+func (e *endpointGitFinder) newEndpoint(rawurl string) lfshttp.Endpoint {
 	if strings.HasPrefix(rawurl, "/") {
 		return lfshttp.EndpointFromLocalPath(rawurl)
 	}
@@ -181,12 +199,14 @@ func (e *endpointGitFinder) NewEndpoint(operation, rawurl string) lfshttp.Endpoi
 	switch u.Scheme {
 	case "ssh", "git+ssh", "ssh+git":
 		return lfshttp.EndpointFromSshUrl(u)
-	case "http", "https":
+	case "http", "https", lfshttp.UnixSocketScheme:
 		return lfshttp.EndpointFromHttpUrl(u)
 	case "git":
 		return endpointFromGitUrl(u, e)
 	case "file":
 		return lfshttp.EndpointFromFileUrl(u)
+	case "oci", "oci+http":
+		return lfshttp.EndpointFromOCIUrl(u)
 	case "":
 		// If it looks like a local path, it probably is.
 		if _, err := os.Stat(rawurl); err == nil {
@@ -231,7 +251,7 @@ func (e *endpointGitFinder) AccessFor(rawurl string) creds.Access {
 
 func (e *endpointGitFinder) SetAccess(access creds.Access) {
 	key := fmt.Sprintf("lfs.%s.access", access.URL())
-	tracerx.Printf("setting repository access to %s", access.Mode())
+	tracelog.Printf("setting repository access to %s", access.Mode())
 
 	e.accessMu.Lock()
 	defer e.accessMu.Unlock()
@@ -404,6 +404,21 @@ func TestLocalPathEndpointRemovesDotGitForBareRepo(t *testing.T) {
 	os.RemoveAll(path)
 }
 
+func TestOCIEndpointIsPassedThrough(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url": "https://example.com/foo/bar.git",
+		"lfs.url":           "oci://registry.example.com/foo/bar-lfs",
+	}))
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "oci://registry.example.com/foo/bar-lfs", e.Url)
+
+	finder = NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.lfsurl": "oci+http://localhost:5000/lfs",
+	}))
+	e = finder.Endpoint("upload", "origin")
+	assert.Equal(t, "oci+http://localhost:5000/lfs", e.Url)
+}
+
 func TestAccessConfig(t *testing.T) {
 	type accessTest struct {
 		AccessMode    string
@@ -709,6 +724,32 @@ func TestInsteadOf(t *testing.T) {
 	}
 }
 
+func TestInsteadOfWithTrailingSlash(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                  "ex:git-lfs/git-lfs/",
+		"url.https://example.com/.insteadof": "ex:",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "https://example.com/git-lfs/git-lfs.git/info/lfs", e.Url)
+}
+
+func TestPushInsteadOfIgnoredForExplicitPushURL(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                    "ex:git-lfs/git-lfs.git",
+		"remote.origin.pushurl":                "ex:git-lfs/other.git",
+		"url.https://example.com/.insteadof":   "ex:",
+		"url.ssh://example.com/.pushinsteadof": "ex:",
+	}))
+
+	e := finder.Endpoint("upload", "")
+	assert.Equal(t, "https://example.com/git-lfs/other.git/info/lfs", e.Url)
+	assert.Equal(t, "", e.SSHMetadata.UserAndHost)
+
+	e = finder.Endpoint("download", "")
+	assert.Equal(t, "https://example.com/git-lfs/git-lfs.git/info/lfs", e.Url)
+}
+
 func TestNewEndpointFromCloneURLWithConfig(t *testing.T) {
 	expected := "https://foo/bar.git/info/lfs"
 	tests := []string{
@@ -726,3 +767,13 @@ func TestNewEndpointFromCloneURLWithConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestUnixSocketEndpointIsNotRewritten(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": "http+unix:///tmp/lfs.sock:/foo/bar.git/info/lfs",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "http+unix:///tmp/lfs.sock:/foo/bar.git/info/lfs", e.Url)
+	assert.Equal(t, "", e.SSHMetadata.UserAndHost)
+}
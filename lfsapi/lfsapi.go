@@ -7,7 +7,7 @@ import (
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/lfshttp"
 	"github.com/git-lfs/git-lfs/ssh"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type Client struct {
@@ -18,6 +18,8 @@ type Client struct {
 
 	client  *lfshttp.Client
 	context lfshttp.Context
+
+	breaker *circuitBreaker
 }
 
 func NewClient(ctx lfshttp.Context) (*Client, error) {
@@ -38,6 +40,7 @@ func NewClient(ctx lfshttp.Context) (*Client, error) {
 		client:      httpClient,
 		context:     ctx,
 		credContext: creds.NewCredentialHelperContext(gitEnv, osEnv),
+		breaker:     newCircuitBreaker(gitEnv.Int("lfs.circuitbreaker", 10)),
 	}
 
 	return c, nil
@@ -59,10 +62,10 @@ func (c *Client) SSHTransfer(operation, remote string) *ssh.SSHTransfer {
 		return nil
 	}
 	ctx := c.Context()
-	tracerx.Printf("attempting pure SSH protocol connection")
+	tracelog.Printf("attempting pure SSH protocol connection")
 	sshTransfer, err := ssh.NewSSHTransfer(ctx.OSEnv(), ctx.GitEnv(), &endpoint.SSHMetadata, operation)
 	if err != nil {
-		tracerx.Printf("pure SSH protocol connection failed: %s", err)
+		tracelog.Printf("pure SSH protocol connection failed: %s", err)
 		return nil
 	}
 	return sshTransfer
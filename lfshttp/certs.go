@@ -11,7 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // isCertVerificationDisabledForHost returns whether SSL certificate verification
@@ -49,7 +49,7 @@ func decryptPEMBlock(c *Client, block *pem.Block, path string, key []byte) ([]by
 
 	creds, err := credWrapper.CredentialHelper.Fill(credWrapper.Input)
 	if err != nil {
-		tracerx.Printf("Error filling credentials for %q: %v", fileurl, err)
+		tracelog.Printf("Error filling credentials for %q: %v", fileurl, err)
 		return nil, err
 	}
 	pass := creds["password"]
@@ -74,12 +74,12 @@ func getClientCertForHost(c *Client, host string) *tls.Certificate {
 
 	cert, err := ioutil.ReadFile(hostSslCert)
 	if err != nil {
-		tracerx.Printf("Error reading client cert file %q: %v", hostSslCert, err)
+		tracelog.Printf("Error reading client cert file %q: %v", hostSslCert, err)
 		return nil
 	}
 	key, err := ioutil.ReadFile(hostSslKey)
 	if err != nil {
-		tracerx.Printf("Error reading client key file %q: %v", hostSslKey, err)
+		tracelog.Printf("Error reading client key file %q: %v", hostSslKey, err)
 		return nil
 	}
 
@@ -87,14 +87,14 @@ func getClientCertForHost(c *Client, host string) *tls.Certificate {
 	if x509.IsEncryptedPEMBlock(block) {
 		key, err = decryptPEMBlock(c, block, hostSslKey, key)
 		if err != nil {
-			tracerx.Printf("Unable to decrypt client key file %q: %v", hostSslKey, err)
+			tracelog.Printf("Unable to decrypt client key file %q: %v", hostSslKey, err)
 			return nil
 		}
 	}
 
 	certobj, err := tls.X509KeyPair(cert, key)
 	if err != nil {
-		tracerx.Printf("Error reading client cert/key %v", err)
+		tracelog.Printf("Error reading client cert/key %v", err)
 		return nil
 	}
 	return &certobj
@@ -152,11 +152,11 @@ func appendRootCAsForHostFromGitconfig(osEnv, gitEnv config.Environment, pool *x
 func appendCertsFromFilesInDir(pool *x509.CertPool, dir string) *x509.CertPool {
 	dirpath, errpath := tools.TranslateCygwinPath(dir)
 	if errpath != nil {
-		tracerx.Printf("Error reading cert dir %q: %v", dirpath, errpath)
+		tracelog.Printf("Error reading cert dir %q: %v", dirpath, errpath)
 	}
 	files, err := ioutil.ReadDir(dirpath)
 	if err != nil {
-		tracerx.Printf("Error reading cert dir %q: %v", dir, err)
+		tracelog.Printf("Error reading cert dir %q: %v", dir, err)
 		return pool
 	}
 	for _, f := range files {
@@ -168,11 +168,11 @@ func appendCertsFromFilesInDir(pool *x509.CertPool, dir string) *x509.CertPool {
 func appendCertsFromFile(pool *x509.CertPool, filename string) *x509.CertPool {
 	filenamepath, errfile := tools.TranslateCygwinPath(filename)
 	if errfile != nil {
-		tracerx.Printf("Error reading cert dir %q: %v", filenamepath, errfile)
+		tracelog.Printf("Error reading cert dir %q: %v", filenamepath, errfile)
 	}
 	data, err := ioutil.ReadFile(filenamepath)
 	if err != nil {
-		tracerx.Printf("Error reading cert file %q: %v", filename, err)
+		tracelog.Printf("Error reading cert file %q: %v", filename, err)
 		return pool
 	}
 	// Firstly, try parsing as binary certificate
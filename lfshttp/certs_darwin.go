@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/subprocess"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 func appendRootCAsForHostFromPlatform(pool *x509.CertPool, host string) *x509.CertPool {
@@ -22,7 +22,7 @@ func appendRootCAsForHostFromPlatform(pool *x509.CertPool, host string) *x509.Ce
 	cmd := subprocess.ExecCommand("/usr/bin/security", "list-keychains")
 	kcout, err := cmd.Output()
 	if err != nil {
-		tracerx.Printf("Error listing keychains: %v", err)
+		tracelog.Printf("Error listing keychains: %v", err)
 		return nil
 	}
 
@@ -57,7 +57,7 @@ func appendRootCAsFromKeychain(pool *x509.CertPool, name, keychain string) *x509
 	cmd := subprocess.ExecCommand("/usr/bin/security", "find-certificate", "-a", "-p", "-c", name, keychain)
 	data, err := cmd.Output()
 	if err != nil {
-		tracerx.Printf("Error reading keychain %q: %v", keychain, err)
+		tracelog.Printf("Error reading keychain %q: %v", keychain, err)
 		return pool
 	}
 	return appendCertsFromPEMData(pool, data)
@@ -21,15 +21,17 @@ import (
 	"github.com/git-lfs/git-lfs/creds"
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 	"golang.org/x/net/http2"
 )
 
 const MediaType = "application/vnd.git-lfs+json; charset=utf-8"
 
 var (
-	UserAgent = "git-lfs"
-	httpRE    = regexp.MustCompile(`\Ahttps?://`)
+	// UserAgent is the default User-Agent of each Client, which may be
+	// replaced by setting Client.UserAgent.
+	UserAgent = config.VersionDesc
+	httpRE    = regexp.MustCompile(`\A(https?|http\+unix)://`)
 )
 
 var hintFileUrl = strings.TrimSpace(`
@@ -46,16 +48,25 @@ type hostData struct {
 type Client struct {
 	SSH SSHResolver
 
-	DialTimeout         int
-	KeepaliveTimeout    int
-	TLSTimeout          int
-	ConcurrentTransfers int
-	SkipSSLVerify       bool
+	DialTimeout           int
+	KeepaliveTimeout      int
+	TLSTimeout            int
+	ResponseHeaderTimeout int
+	IdleConnTimeout       int
+	ConcurrentTransfers   int
+	SkipSSLVerify         bool
+
+	// UserAgent is sent in the User-Agent header of each request.
+	UserAgent string
 
 	Verbose          bool
 	DebuggingVerbose bool
 	VerboseOut       io.Writer
 
+	// WarningOut receives warnings for the user, such as when a server's
+	// limits are nearly reached.  If it is nil, warnings are only traced.
+	WarningOut io.Writer
+
 	hostClients map[hostData]*http.Client
 	clientMu    sync.Mutex
 
@@ -67,6 +78,10 @@ type Client struct {
 
 	credHelperContext *creds.CredentialHelperContext
 
+	limits      map[string]map[string]*Limit
+	limitWarned map[string]bool
+	limitsMu    sync.Mutex
+
 	sshTries int
 }
 
@@ -85,19 +100,22 @@ func NewClient(ctx Context) (*Client, error) {
 	}
 
 	c := &Client{
-		SSH:                 sshResolver,
-		DialTimeout:         gitEnv.Int("lfs.dialtimeout", 0),
-		KeepaliveTimeout:    gitEnv.Int("lfs.keepalive", 0),
-		TLSTimeout:          gitEnv.Int("lfs.tlstimeout", 0),
-		ConcurrentTransfers: gitEnv.Int("lfs.concurrenttransfers", 8),
-		SkipSSLVerify:       !gitEnv.Bool("http.sslverify", true) || osEnv.Bool("GIT_SSL_NO_VERIFY", false),
-		Verbose:             osEnv.Bool("GIT_CURL_VERBOSE", false),
-		DebuggingVerbose:    osEnv.Bool("LFS_DEBUG_HTTP", false),
-		gitEnv:              gitEnv,
-		osEnv:               osEnv,
-		uc:                  config.NewURLConfig(gitEnv),
-		sshTries:            gitEnv.Int("lfs.ssh.retries", 5),
-		credHelperContext:   creds.NewCredentialHelperContext(gitEnv, osEnv),
+		SSH:                   sshResolver,
+		DialTimeout:           gitEnv.Int("lfs.dialtimeout", 0),
+		KeepaliveTimeout:      gitEnv.Int("lfs.keepalive", 0),
+		TLSTimeout:            gitEnv.Int("lfs.tlstimeout", 0),
+		ResponseHeaderTimeout: gitEnv.Int("lfs.responseheadertimeout", 0),
+		IdleConnTimeout:       gitEnv.Int("lfs.idletimeout", 0),
+		ConcurrentTransfers:   gitEnv.Int("lfs.concurrenttransfers", 8),
+		SkipSSLVerify:         !gitEnv.Bool("http.sslverify", true) || osEnv.Bool("GIT_SSL_NO_VERIFY", false),
+		UserAgent:             UserAgent,
+		Verbose:               osEnv.Bool("GIT_CURL_VERBOSE", false),
+		DebuggingVerbose:      osEnv.Bool("LFS_DEBUG_HTTP", false),
+		gitEnv:                gitEnv,
+		osEnv:                 osEnv,
+		uc:                    config.NewURLConfig(gitEnv),
+		sshTries:              gitEnv.Int("lfs.ssh.retries", 5),
+		credHelperContext:     creds.NewCredentialHelperContext(gitEnv, osEnv),
 	}
 
 	return c, nil
@@ -186,7 +204,7 @@ func (c *Client) DoWithAccess(req *http.Request, mode creds.AccessMode) (*http.R
 // as defined in c.handleResponse. Notably, it does not alter the headers for
 // the request argument in any way.
 func (c *Client) do(req *http.Request, remote string, via []*http.Request, mode creds.AccessMode) (*http.Response, error) {
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.UserAgent)
 
 	client, err := c.HttpClient(req.URL, mode)
 	if err != nil {
@@ -217,7 +235,7 @@ func (c *Client) sshResolveWithRetries(e Endpoint, method string) (*sshAuthRespo
 			return &sshRes, nil
 		}
 
-		tracerx.Printf(
+		tracelog.Printf(
 			"ssh: %s failed, error: %s, message: %s (try: %d/%d)",
 			e.SSHMetadata.UserAndHost, err.Error(), sshRes.Message, i,
 			requests,
@@ -312,10 +330,11 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 	}
 
 	if res.Uncompressed {
-		tracerx.Printf("http: decompressed gzipped response")
+		tracelog.Printf("http: decompressed gzipped response")
 	}
 
 	c.traceResponse(req, tracedReq, res)
+	c.recordLimits(req, res)
 
 	if res.StatusCode != 301 &&
 		res.StatusCode != 302 &&
@@ -401,17 +420,17 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		concurrentTransfers = 8
 	}
 
-	dialtime := c.DialTimeout
+	dialtime := c.timeoutFor(u, "dialtimeout", c.DialTimeout)
 	if dialtime < 1 {
 		dialtime = 30
 	}
 
-	keepalivetime := c.KeepaliveTimeout
+	keepalivetime := c.timeoutFor(u, "keepalive", c.KeepaliveTimeout)
 	if keepalivetime < 1 {
 		keepalivetime = 1800
 	}
 
-	tlstime := c.TLSTimeout
+	tlstime := c.timeoutFor(u, "tlstimeout", c.TLSTimeout)
 	if tlstime < 1 {
 		tlstime = 30
 	}
@@ -421,6 +440,15 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		MaxIdleConnsPerHost: concurrentTransfers,
 	}
 
+	// Unlike the timeouts above, the response header and idle connection
+	// timeouts are disabled unless explicitly configured.
+	if headertime := c.timeoutFor(u, "responseheadertimeout", c.ResponseHeaderTimeout); headertime > 0 {
+		tr.ResponseHeaderTimeout = time.Duration(headertime) * time.Second
+	}
+	if idletime := c.timeoutFor(u, "idletimeout", c.IdleConnTimeout); idletime > 0 {
+		tr.IdleConnTimeout = time.Duration(idletime) * time.Second
+	}
+
 	activityTimeout := 30
 	if v, ok := c.uc.Get("lfs", u.String(), "activitytimeout"); ok {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -436,10 +464,26 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		DualStack: true,
 	}
 
+	dial := dialer.DialContext
+	if IsUnixSocketURL(u) {
+		socket, _, err := splitUnixSocketURL(u)
+		if err != nil {
+			return nil, err
+		}
+
+		// Connections to a Unix socket are never proxied, and are
+		// always dialed to the socket regardless of the address that
+		// net/http asks for.
+		tr.Proxy = nil
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
 	if activityTimeout > 0 {
 		activityDuration := time.Duration(activityTimeout) * time.Second
 		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-			c, err := dialer.DialContext(ctx, network, addr)
+			c, err := dial(ctx, network, addr)
 			if c == nil {
 				return c, err
 			}
@@ -450,7 +494,7 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 			return &deadlineConn{Timeout: activityDuration, Conn: c}, err
 		}
 	} else {
-		tr.DialContext = dialer.DialContext
+		tr.DialContext = dial
 	}
 
 	tr.TLSClientConfig = &tls.Config{
@@ -458,7 +502,7 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 	}
 
 	if isClientCertEnabledForHost(c, host) {
-		tracerx.Printf("http: client cert for %s", host)
+		tracelog.Printf("http: client cert for %s", host)
 		cert := getClientCertForHost(c, host)
 		if cert != nil {
 			tr.TLSClientConfig.Certificates = []tls.Certificate{*cert}
@@ -476,12 +520,28 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		return nil, err
 	}
 
+	var rt http.RoundTripper = tr
 	if access == creds.NegotiateAccess {
 		// This technically copies a mutex, but we know since we've just created
 		// the object that this mutex is unlocked.
-		return &spnego.Transport{Transport: *tr}, nil
+		rt = &spnego.Transport{Transport: *tr}
 	}
-	return tr, nil
+	if IsUnixSocketURL(u) {
+		rt = &unixSocketTransport{rt: rt}
+	}
+	return rt, nil
+}
+
+// timeoutFor returns the timeout, in seconds, configured for the given URL as
+// "lfs.<url>.<key>", falling back to def if no such URL-specific setting
+// exists.
+func (c *Client) timeoutFor(u *url.URL, key string, def int) int {
+	if v, ok := c.uc.Get("lfs", u.String(), key); ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
 }
 
 func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client, error) {
@@ -489,6 +549,15 @@ func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client,
 	defer c.clientMu.Unlock()
 
 	host := u.Host
+	if IsUnixSocketURL(u) {
+		// All requests to a Unix socket share an empty host, so key
+		// their clients by the socket they are dialed to instead.
+		socket, _, err := splitUnixSocketURL(u)
+		if err != nil {
+			return nil, err
+		}
+		host = UnixSocketScheme + ":" + socket
+	}
 
 	if c.hostClients == nil {
 		c.hostClients = make(map[hostData]*http.Client)
@@ -513,11 +582,11 @@ func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client,
 	}
 
 	if isCookieJarEnabledForHost(c, host) {
-		tracerx.Printf("http: cookieFile for %s", host)
+		tracelog.Printf("http: cookieFile for %s", host)
 		if cookieJar, err := getCookieJarForHost(c, host); err == nil {
 			httpClient.Jar = cookieJar
 		} else {
-			tracerx.Printf("http: error while reading cookieFile: %s", err.Error())
+			tracelog.Printf("http: error while reading cookieFile: %s", err.Error())
 		}
 	}
 
@@ -557,7 +626,7 @@ func newRequestForRetry(req *http.Request, location string) (*http.Request, erro
 
 	oldestURL := strings.SplitN(req.URL.String(), "?", 2)[0]
 	newURL := strings.SplitN(newReq.URL.String(), "?", 2)[0]
-	tracerx.Printf("api: redirect %s %s to %s", req.Method, oldestURL, newURL)
+	tracelog.Printf("api: redirect %s %s to %s", req.Method, oldestURL, newURL)
 
 	// This body will have already been rewound from a call to
 	// lfsapi.Client.traceRequest().
@@ -590,10 +659,6 @@ func (c *deadlineConn) Write(b []byte) (int, error) {
 	return c.Conn.Write(b)
 }
 
-func init() {
-	UserAgent = config.VersionDesc
-}
-
 type testEnv map[string]string
 
 func (e testEnv) Get(key string) (v string, ok bool) {
@@ -7,9 +7,12 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/git-lfs/git-lfs/creds"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -409,3 +412,47 @@ func TestHttpVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestTransportTimeouts(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.dialtimeout":           "5",
+		"lfs.tlstimeout":            "6",
+		"lfs.responseheadertimeout": "7",
+		"lfs.idletimeout":           "8",
+		"lfs.https://slow.example.com.responseheadertimeout": "70",
+	}))
+	require.Nil(t, err)
+
+	u, err := url.Parse("https://example.com/repo.git/info/lfs")
+	require.Nil(t, err)
+
+	rt, err := c.Transport(u, creds.NoneAccess)
+	require.Nil(t, err)
+	tr := rt.(*http.Transport)
+	assert.Equal(t, 6*time.Second, tr.TLSHandshakeTimeout)
+	assert.Equal(t, 7*time.Second, tr.ResponseHeaderTimeout)
+	assert.Equal(t, 8*time.Second, tr.IdleConnTimeout)
+
+	u, err = url.Parse("https://slow.example.com/repo.git/info/lfs")
+	require.Nil(t, err)
+
+	rt, err = c.Transport(u, creds.NoneAccess)
+	require.Nil(t, err)
+	tr = rt.(*http.Transport)
+	assert.Equal(t, 70*time.Second, tr.ResponseHeaderTimeout)
+}
+
+func TestTransportTimeoutsDefaults(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, nil))
+	require.Nil(t, err)
+
+	u, err := url.Parse("https://example.com/repo.git/info/lfs")
+	require.Nil(t, err)
+
+	rt, err := c.Transport(u, creds.NoneAccess)
+	require.Nil(t, err)
+	tr := rt.(*http.Transport)
+	assert.Equal(t, 30*time.Second, tr.TLSHandshakeTimeout)
+	assert.Equal(t, time.Duration(0), tr.ResponseHeaderTimeout)
+	assert.Equal(t, time.Duration(0), tr.IdleConnTimeout)
+}
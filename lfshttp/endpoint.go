@@ -113,3 +113,9 @@ func EndpointFromFileUrl(u *url.URL) Endpoint {
 	// just pass this straight through
 	return Endpoint{Url: u.String()}
 }
+
+// Construct a new endpoint from an OCI registry URL
+func EndpointFromOCIUrl(u *url.URL) Endpoint {
+	// just pass this straight through
+	return Endpoint{Url: u.String()}
+}
@@ -35,6 +35,10 @@ func (e *ClientError) Error() string {
 	return e.Message
 }
 
+func (e *ClientError) ErrorCode() errors.Code {
+	return statusErrorCode(e.response)
+}
+
 func (c *Client) handleResponse(res *http.Response) error {
 	if res.StatusCode < 400 {
 		return nil
@@ -99,6 +103,29 @@ func (e *statusCodeError) HTTPResponse() *http.Response {
 	return e.response
 }
 
+func (e *statusCodeError) ErrorCode() errors.Code {
+	return statusErrorCode(e.response)
+}
+
+// statusErrorCode returns the errors.Code for the HTTP status of the given
+// response, or an empty Code if it has none.
+func statusErrorCode(res *http.Response) errors.Code {
+	if res == nil {
+		return ""
+	}
+
+	switch res.StatusCode {
+	case 401, 403:
+		return errors.CodeAuth
+	case 404, 410:
+		return errors.CodeNotFound
+	case 507, 509:
+		return errors.CodeQuota
+	default:
+		return ""
+	}
+}
+
 var (
 	defaultErrors = map[int]string{
 		400: "Client error: %s",
@@ -125,5 +152,23 @@ func defaultError(res *http.Response) error {
 		msgFmt = defaultErrors[500] + fmt.Sprintf(" from HTTP %d", res.StatusCode)
 	}
 
-	return errors.Errorf(msgFmt, res.Request.URL)
+	return &defaultStatusError{
+		message: fmt.Sprintf(msgFmt, res.Request.URL),
+		code:    statusErrorCode(res),
+	}
+}
+
+// defaultStatusError is the error for an HTTP status given by the server
+// without a message of its own.
+type defaultStatusError struct {
+	message string
+	code    errors.Code
+}
+
+func (e *defaultStatusError) Error() string {
+	return e.message
+}
+
+func (e *defaultStatusError) ErrorCode() errors.Code {
+	return e.code
 }
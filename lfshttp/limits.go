@@ -0,0 +1,155 @@
+package lfshttp
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// limitWarningPercent is the percentage of a limit remaining below which a
+// warning is shown to the user.
+const limitWarningPercent = 10
+
+// Limit describes a quota or rate limit advertised by a server in the headers
+// of its responses.
+type Limit struct {
+	// Name identifies the kind of limit: "rate", "storage", or
+	// "bandwidth".
+	Name string `json:"name"`
+	// Limit is the total amount available, in requests for the "rate"
+	// limit, or in bytes otherwise.
+	Limit int64 `json:"limit"`
+	// Remaining is the amount of the limit still available.
+	Remaining int64 `json:"remaining"`
+	// Reset is the time at which the limit resets, if the server specified
+	// it.
+	Reset time.Time `json:"reset"`
+}
+
+// Low returns whether less than limitWarningPercent of the limit remains.
+func (l *Limit) Low() bool {
+	return l.Limit > 0 && l.Remaining*100 < l.Limit*limitWarningPercent
+}
+
+func (l *Limit) String() string {
+	var s string
+	if l.Name == "rate" {
+		s = fmt.Sprintf("%d/%d requests remaining", l.Remaining, l.Limit)
+	} else {
+		s = fmt.Sprintf("%s/%s remaining",
+			humanize.FormatBytes(uint64(tools.MaxInt64(l.Remaining, 0))),
+			humanize.FormatBytes(uint64(l.Limit)))
+	}
+
+	if !l.Reset.IsZero() {
+		s += fmt.Sprintf(", resets at %s", l.Reset.Format(time.RFC3339))
+	}
+	return s
+}
+
+// limitHeaders maps the name of each kind of limit to the prefix of the
+// headers describing it. Each prefix is followed by "Limit", either
+// "Remaining" or "Used", and optionally "Reset", which holds a Unix
+// timestamp.
+var limitHeaders = []struct {
+	name   string
+	prefix string
+}{
+	{"rate", "X-Ratelimit-"},
+	{"storage", "X-Lfs-Storage-"},
+	{"bandwidth", "X-Lfs-Bandwidth-"},
+}
+
+// ParseLimits returns the limits advertised in the given response headers,
+// ignoring any which are absent or malformed.
+func ParseLimits(h http.Header) []*Limit {
+	var limits []*Limit
+	for _, lh := range limitHeaders {
+		limit, err := strconv.ParseInt(h.Get(lh.prefix+"Limit"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		l := &Limit{Name: lh.name, Limit: limit}
+		if remaining, err := strconv.ParseInt(h.Get(lh.prefix+"Remaining"), 10, 64); err == nil {
+			l.Remaining = remaining
+		} else if used, err := strconv.ParseInt(h.Get(lh.prefix+"Used"), 10, 64); err == nil {
+			l.Remaining = limit - used
+		} else {
+			continue
+		}
+
+		if reset, err := strconv.ParseInt(h.Get(lh.prefix+"Reset"), 10, 64); err == nil {
+			l.Reset = time.Unix(reset, 0).UTC()
+		}
+
+		limits = append(limits, l)
+	}
+	return limits
+}
+
+// Limits returns the most recent limits advertised by each host, keyed by
+// host.
+func (c *Client) Limits() map[string][]*Limit {
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+
+	limits := make(map[string][]*Limit, len(c.limits))
+	for host, byName := range c.limits {
+		for _, l := range byName {
+			limits[host] = append(limits[host], l)
+		}
+		sort.Slice(limits[host], func(i, j int) bool {
+			return limits[host][i].Name < limits[host][j].Name
+		})
+	}
+	return limits
+}
+
+// recordLimits remembers the limits advertised in the given response, and
+// warns the first time any of them runs low for a given host.
+func (c *Client) recordLimits(req *http.Request, res *http.Response) {
+	limits := ParseLimits(res.Header)
+	if len(limits) == 0 {
+		return
+	}
+
+	host := req.URL.Host
+
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+
+	if c.limits == nil {
+		c.limits = make(map[string]map[string]*Limit)
+		c.limitWarned = make(map[string]bool)
+	}
+	if c.limits[host] == nil {
+		c.limits[host] = make(map[string]*Limit)
+	}
+
+	for _, l := range limits {
+		tracelog.Printf("http: %s %s limit: %s", host, l.Name, l)
+		c.limits[host][l.Name] = l
+
+		key := host + " " + l.Name
+		if l.Low() && !c.limitWarned[key] {
+			c.limitWarned[key] = true
+			c.warnf("warning: %s is near its %s limit: %s", host, l.Name, l)
+		}
+	}
+}
+
+// warnf writes a warning to c.WarningOut, or traces it if there is none.
+func (c *Client) warnf(format string, args ...interface{}) {
+	if c.WarningOut == nil {
+		tracelog.Printf("http: "+format, args...)
+		return
+	}
+	fmt.Fprintf(c.WarningOut, format+"\n", args...)
+}
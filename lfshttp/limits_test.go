@@ -0,0 +1,115 @@
+package lfshttp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	h := make(http.Header)
+	h.Set("X-RateLimit-Limit", "5000")
+	h.Set("X-RateLimit-Remaining", "4990")
+	h.Set("X-RateLimit-Reset", "1700000000")
+	h.Set("X-LFS-Storage-Limit", "1000")
+	h.Set("X-LFS-Storage-Used", "950")
+	h.Set("X-LFS-Bandwidth-Limit", "2000")
+
+	limits := ParseLimits(h)
+	require.Len(t, limits, 2)
+
+	assert.Equal(t, "rate", limits[0].Name)
+	assert.EqualValues(t, 5000, limits[0].Limit)
+	assert.EqualValues(t, 4990, limits[0].Remaining)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), limits[0].Reset)
+	assert.False(t, limits[0].Low())
+
+	assert.Equal(t, "storage", limits[1].Name)
+	assert.EqualValues(t, 1000, limits[1].Limit)
+	assert.EqualValues(t, 50, limits[1].Remaining)
+	assert.True(t, limits[1].Reset.IsZero())
+	assert.True(t, limits[1].Low())
+}
+
+func TestParseLimitsIgnoresMalformed(t *testing.T) {
+	h := make(http.Header)
+	h.Set("X-RateLimit-Limit", "many")
+	h.Set("X-RateLimit-Remaining", "4990")
+
+	assert.Empty(t, ParseLimits(h))
+}
+
+func TestLimitLow(t *testing.T) {
+	assert.True(t, (&Limit{Name: "rate", Limit: 100, Remaining: 9}).Low())
+	assert.False(t, (&Limit{Name: "rate", Limit: 100, Remaining: 10}).Low())
+	assert.True(t, (&Limit{Name: "storage", Limit: 100, Remaining: -5}).Low())
+	assert.False(t, (&Limit{Name: "storage", Limit: 0, Remaining: 0}).Low())
+}
+
+func TestLimitString(t *testing.T) {
+	assert.Equal(t, "9/100 requests remaining",
+		(&Limit{Name: "rate", Limit: 100, Remaining: 9}).String())
+	assert.Equal(t, "0 B/2.0 KB remaining, resets at 2023-11-14T22:13:20Z",
+		(&Limit{Name: "bandwidth", Limit: 2000, Remaining: -1, Reset: time.Unix(1700000000, 0).UTC()}).String())
+}
+
+func TestClientRecordsLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+
+	limits := c.Limits()
+	require.Len(t, limits, 1)
+
+	host := req.URL.Host
+	require.Len(t, limits[host], 1)
+	assert.Equal(t, "rate", limits[host][0].Name)
+	assert.EqualValues(t, 60, limits[host][0].Limit)
+	assert.EqualValues(t, 59, limits[host][0].Remaining)
+}
+
+func TestClientWarnsOfLowLimitsOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "5")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+	var warnings bytes.Buffer
+	c.WarningOut = &warnings
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		require.Nil(t, err)
+
+		res, err := c.Do(req)
+		require.Nil(t, err)
+		res.Body.Close()
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	assert.Equal(t, fmt.Sprintf("warning: %s is near its rate limit: 5/60 requests remaining\n", host), warnings.String())
+}
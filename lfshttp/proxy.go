@@ -89,3 +89,9 @@ func getProxyServers(u *url.URL, urlCfg *config.URLConfig, osEnv config.Environm
 
 	return
 }
+
+// ProxyFor returns the proxy which would be used for requests to the given
+// URL, or nil if none would be used.
+func (c *Client) ProxyFor(u *url.URL) (*url.URL, error) {
+	return proxyFromClient(c)(&http.Request{URL: u})
+}
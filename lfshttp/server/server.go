@@ -0,0 +1,595 @@
+// Package server implements a minimal Git LFS server, which serves the batch,
+// basic transfer and locks APIs from a local directory.
+//
+// Objects are stored in the same layout as a repository's ".git/lfs"
+// directory, and locks in a "locks.json" file alongside them. The server does
+// not authenticate its clients: the owner of a lock is the user name given in
+// the request's basic credentials, if any, or the server's default owner.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/git-lfs/git-lfs/locking"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// defaultLockLimit is the number of locks returned in a single page when the
+// client does not ask for a limit.
+const defaultLockLimit = 100
+
+// oidRE matches the OIDs of SHA-256 and SHA-512 objects, which are told apart
+// by their length.
+var oidRE = regexp.MustCompile(`\A(?:[0-9a-f]{64}|[0-9a-f]{128})\z`)
+
+// Server is an http.Handler serving the Git LFS API from a local directory.
+type Server struct {
+	fs *fs.Filesystem
+	// locksPath is the path of the file holding the current locks, newest
+	// first.
+	locksPath string
+	// owner is the owner of locks created by requests without credentials.
+	owner string
+
+	// mu guards the locks file.
+	mu sync.Mutex
+}
+
+// New returns a Server storing its objects and locks in dir, which is created
+// with the given permissions if it does not exist. Locks created by clients
+// which do not send credentials are owned by owner.
+func New(env fs.Environment, dir string, perms os.FileMode, owner string) (*Server, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, tools.ExecutablePermissions(perms)); err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		fs:        fs.New(env, dir, "", dir, perms),
+		locksPath: filepath.Join(dir, "locks.json"),
+		owner:     owner,
+	}, nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tracelog.Printf("serve: %s %s", r.Method, r.URL.Path)
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "objects" && parts[1] == "batch":
+		s.onlyMethod(w, r, "POST", s.batch)
+	case len(parts) == 2 && parts[0] == "objects":
+		switch r.Method {
+		case "GET":
+			s.download(w, r, parts[1])
+		case "PUT":
+			s.upload(w, r, parts[1])
+		default:
+			writeMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(parts) == 1 && parts[0] == "locks":
+		switch r.Method {
+		case "GET":
+			s.listLocks(w, r)
+		case "POST":
+			s.createLock(w, r)
+		default:
+			writeMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(parts) == 2 && parts[0] == "locks" && parts[1] == "verify":
+		s.onlyMethod(w, r, "POST", s.verifyLocks)
+	case len(parts) == 3 && parts[0] == "locks" && parts[2] == "unlock":
+		s.onlyMethod(w, r, "POST", func(w http.ResponseWriter, r *http.Request) {
+			s.unlock(w, r, parts[1])
+		})
+	case len(parts) == 3 && parts[0] == "locks" && parts[2] == "renew":
+		s.onlyMethod(w, r, "POST", func(w http.ResponseWriter, r *http.Request) {
+			s.renew(w, r, parts[1])
+		})
+	default:
+		writeMessage(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) onlyMethod(w http.ResponseWriter, r *http.Request, method string, fn http.HandlerFunc) {
+	if r.Method != method {
+		writeMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	fn(w, r)
+}
+
+type batchRequest struct {
+	Operation     string        `json:"operation"`
+	Transfers     []string      `json:"transfers,omitempty"`
+	Objects       []batchObject `json:"objects"`
+	HashAlgorithm string        `json:"hash_algo,omitempty"`
+}
+
+type batchObject struct {
+	Oid           string                  `json:"oid"`
+	Size          int64                   `json:"size"`
+	Authenticated bool                    `json:"authenticated,omitempty"`
+	Actions       map[string]*batchAction `json:"actions,omitempty"`
+	Error         *batchError             `json:"error,omitempty"`
+}
+
+type batchAction struct {
+	Href string `json:"href"`
+}
+
+type batchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type batchResponse struct {
+	Transfer      string        `json:"transfer"`
+	Objects       []batchObject `json:"objects"`
+	HashAlgorithm string        `json:"hash_algo,omitempty"`
+}
+
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid batch request: %s", err))
+		return
+	}
+	if req.Operation != "upload" && req.Operation != "download" {
+		writeMessage(w, http.StatusUnprocessableEntity, fmt.Sprintf("unknown operation: %q", req.Operation))
+		return
+	}
+	if req.HashAlgorithm != "" && tools.NewLfsContentHashAlgorithm(req.HashAlgorithm) == nil {
+		writeMessage(w, http.StatusConflict, fmt.Sprintf("unsupported hash algorithm: %q", req.HashAlgorithm))
+		return
+	}
+
+	res := &batchResponse{
+		Transfer: "basic",
+		Objects:  make([]batchObject, 0, len(req.Objects)),
+	}
+	for _, o := range req.Objects {
+		obj := batchObject{Oid: o.Oid, Size: o.Size, Authenticated: true}
+		switch {
+		case !oidRE.MatchString(o.Oid) || o.Size < 0,
+			req.HashAlgorithm != "" && tools.OidHashAlgorithm(o.Oid) != req.HashAlgorithm:
+			obj.Error = &batchError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "invalid object",
+			}
+		case s.fs.ObjectExists(o.Oid, o.Size):
+			if req.Operation == "download" {
+				obj.Actions = map[string]*batchAction{
+					"download": {Href: objectURL(r, o.Oid)},
+				}
+			}
+		case req.Operation == "upload":
+			obj.Actions = map[string]*batchAction{
+				"upload": {Href: objectURL(r, o.Oid)},
+			}
+		default:
+			obj.Error = &batchError{
+				Code:    http.StatusNotFound,
+				Message: "object does not exist",
+			}
+		}
+		res.Objects = append(res.Objects, obj)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// objectURL returns the URL of the object with the given OID on the server
+// which received r.
+func objectURL(r *http.Request, oid string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/objects/%s", scheme, r.Host, oid)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request, oid string) {
+	if !oidRE.MatchString(oid) {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid object ID")
+		return
+	}
+
+	size, err := s.fs.ObjectSize(oid)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeMessage(w, http.StatusNotFound, "object does not exist")
+		} else {
+			writeMessage(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	f, err := s.fs.OpenObject(oid)
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, f)
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, oid string) {
+	if !oidRE.MatchString(oid) {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid object ID")
+		return
+	}
+
+	tmp, err := ioutil.TempFile(s.fs.TempDir(), oid)
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := tools.NewLfsContentHashFor(oid)
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != oid {
+		writeMessage(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("object contents do not match OID: expected %s, got %s", oid, actual))
+		return
+	}
+
+	path, err := s.fs.ObjectPath(oid)
+	if err == nil {
+		err = s.fs.CommitFile(tmp.Name(), path)
+	}
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type lockRef struct {
+	Name string `json:"name,omitempty"`
+}
+
+type lockRequest struct {
+	Path string   `json:"path"`
+	Ref  *lockRef `json:"ref,omitempty"`
+}
+
+type unlockRequest struct {
+	Force bool     `json:"force"`
+	Ref   *lockRef `json:"ref,omitempty"`
+}
+
+type verifyRequest struct {
+	Ref    *lockRef `json:"ref,omitempty"`
+	Cursor string   `json:"cursor,omitempty"`
+	Limit  int      `json:"limit,omitempty"`
+}
+
+type lockResponse struct {
+	Lock    *locking.Lock `json:"lock,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+type lockListResponse struct {
+	Locks      []locking.Lock `json:"locks"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type verifyResponse struct {
+	Ours       []locking.Lock `json:"ours"`
+	Theirs     []locking.Lock `json:"theirs"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) createLock(w http.ResponseWriter, r *http.Request) {
+	var req lockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Path) == 0 {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid lock request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locks, err := s.readLocks()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i, l := range locks {
+		if l.Path == req.Path {
+			writeJSON(w, http.StatusConflict, &lockResponse{
+				Lock:    &locks[i],
+				Message: "lock already created",
+			})
+			return
+		}
+	}
+
+	id, err := newLockID()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	lock := locking.Lock{
+		Id:       id,
+		Path:     req.Path,
+		Owner:    &locking.User{Name: s.requester(r)},
+		LockedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := s.writeLocks(append([]locking.Lock{lock}, locks...)); err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, &lockResponse{Lock: &lock})
+}
+
+func (s *Server) listLocks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	locks, err := s.readLocks()
+	s.mu.Unlock()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	path, id := query.Get("path"), query.Get("id")
+	filtered := make([]locking.Lock, 0, len(locks))
+	for _, l := range locks {
+		if (len(path) == 0 || l.Path == path) && (len(id) == 0 || l.Id == id) {
+			filtered = append(filtered, l)
+		}
+	}
+
+	page, next, err := paginate(filtered, query.Get("cursor"), limit)
+	if err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, &lockListResponse{Locks: page, NextCursor: next})
+}
+
+func (s *Server) verifyLocks(w http.ResponseWriter, r *http.Request) {
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid verify request")
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLockLimit
+	}
+
+	s.mu.Lock()
+	locks, err := s.readLocks()
+	s.mu.Unlock()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	page, next, err := paginate(locks, req.Cursor, limit)
+	if err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res := &verifyResponse{
+		Ours:       make([]locking.Lock, 0, len(page)),
+		Theirs:     make([]locking.Lock, 0, len(page)),
+		NextCursor: next,
+	}
+	requester := s.requester(r)
+	for _, l := range page {
+		if l.Owner != nil && l.Owner.Name == requester {
+			res.Ours = append(res.Ours, l)
+		} else {
+			res.Theirs = append(res.Theirs, l)
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) unlock(w http.ResponseWriter, r *http.Request, id string) {
+	var req unlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid unlock request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locks, err := s.readLocks()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i, l := range locks {
+		if l.Id != id {
+			continue
+		}
+		if !req.Force && (l.Owner == nil || l.Owner.Name != s.requester(r)) {
+			writeMessage(w, http.StatusForbidden, "lock is owned by another user")
+			return
+		}
+		if err := s.writeLocks(append(locks[:i:i], locks[i+1:]...)); err != nil {
+			writeMessage(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, &lockResponse{Lock: &l})
+		return
+	}
+	writeMessage(w, http.StatusNotFound, "unable to find lock")
+}
+
+// renew responds with the lock with the given ID unchanged, since locks held
+// by this server do not expire.
+func (s *Server) renew(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	locks, err := s.readLocks()
+	s.mu.Unlock()
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i, l := range locks {
+		if l.Id == id {
+			writeJSON(w, http.StatusOK, &lockResponse{Lock: &locks[i]})
+			return
+		}
+	}
+	writeMessage(w, http.StatusNotFound, "unable to find lock")
+}
+
+// requester returns the name of the user making the request r.
+func (s *Server) requester(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && len(user) > 0 {
+		return user
+	}
+	return s.owner
+}
+
+// readLocks returns the current locks, newest first. The caller must hold
+// s.mu.
+func (s *Server) readLocks() ([]locking.Lock, error) {
+	data, err := ioutil.ReadFile(s.locksPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var locks []locking.Lock
+	if err := json.Unmarshal(data, &locks); err != nil {
+		return nil, fmt.Errorf("invalid locks file %q: %s", s.locksPath, err)
+	}
+	return locks, nil
+}
+
+// writeLocks replaces the current locks with the given ones. The caller must
+// hold s.mu.
+func (s *Server) writeLocks(locks []locking.Lock) error {
+	if locks == nil {
+		locks = []locking.Lock{}
+	}
+	data, err := json.Marshal(locks)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(s.fs.TempDir(), "locks")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return s.fs.CommitFile(tmp.Name(), s.locksPath)
+}
+
+func newLockID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parseLimit(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultLockLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit: %q", s)
+	}
+	return limit, nil
+}
+
+// paginate returns at most limit locks, starting at the one whose ID is
+// cursor, or at the first if cursor is empty, along with the cursor of the
+// next page, if any.
+func paginate(locks []locking.Lock, cursor string, limit int) ([]locking.Lock, string, error) {
+	start := 0
+	if len(cursor) > 0 {
+		start = -1
+		for i, l := range locks {
+			if l.Id == cursor {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, "", fmt.Errorf("invalid cursor: %q", cursor)
+		}
+	}
+
+	end := start + limit
+	if end >= len(locks) {
+		return locks[start:], "", nil
+	}
+	return locks[start:end], locks[end].Id, nil
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", lfshttp.MediaType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/locking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testContents = "hello\n"
+	testOid      = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	testSHA512Oid = "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629"
+)
+
+type testEnv map[string]string
+
+func (e testEnv) Get(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	dir, err := ioutil.TempDir("", "git-lfs-serve")
+	require.Nil(t, err)
+
+	s, err := New(testEnv{}, dir, 0666, "owner")
+	require.Nil(t, err)
+	return httptest.NewServer(s), dir
+}
+
+func doRequest(t *testing.T, method, url, body string, v interface{}) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.Nil(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+
+	if v != nil {
+		require.Nil(t, json.NewDecoder(res.Body).Decode(v))
+	}
+	return res.StatusCode
+}
+
+func TestServerTransfersObjects(t *testing.T) {
+	srv, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	batch := fmt.Sprintf(`{"operation":"%%s","objects":[{"oid":"%s","size":6}]}`, testOid)
+
+	var res batchResponse
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/objects/batch", fmt.Sprintf(batch, "download"), &res))
+	require.Len(t, res.Objects, 1)
+	require.NotNil(t, res.Objects[0].Error)
+	assert.Equal(t, 404, res.Objects[0].Error.Code)
+
+	res = batchResponse{}
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/objects/batch", fmt.Sprintf(batch, "upload"), &res))
+	require.Len(t, res.Objects, 1)
+	upload := res.Objects[0].Actions["upload"]
+	require.NotNil(t, upload)
+
+	assert.Equal(t, 422, doRequest(t, "PUT", upload.Href, "goodbye\n", nil))
+	assert.Equal(t, 200, doRequest(t, "PUT", upload.Href, testContents, nil))
+
+	res = batchResponse{}
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/objects/batch", fmt.Sprintf(batch, "upload"), &res))
+	require.Len(t, res.Objects, 1)
+	assert.Empty(t, res.Objects[0].Actions)
+
+	res = batchResponse{}
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/objects/batch", fmt.Sprintf(batch, "download"), &res))
+	require.Len(t, res.Objects, 1)
+	download := res.Objects[0].Actions["download"]
+	require.NotNil(t, download)
+
+	r, err := http.Get(download.Href)
+	require.Nil(t, err)
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	require.Nil(t, err)
+	assert.Equal(t, testContents, string(body))
+}
+
+func TestServerTransfersSHA512Objects(t *testing.T) {
+	srv, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	batch := fmt.Sprintf(`{"operation":"upload","hash_algo":"sha512","objects":[{"oid":"%s","size":6},{"oid":"%s","size":6}]}`, testSHA512Oid, testOid)
+
+	var res batchResponse
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/objects/batch", batch, &res))
+	require.Len(t, res.Objects, 2)
+	upload := res.Objects[0].Actions["upload"]
+	require.NotNil(t, upload)
+	require.NotNil(t, res.Objects[1].Error)
+	assert.Equal(t, 422, res.Objects[1].Error.Code)
+
+	assert.Equal(t, 200, doRequest(t, "PUT", upload.Href, testContents, nil))
+
+	res = batchResponse{}
+	assert.Equal(t, 409, doRequest(t, "POST", srv.URL+"/objects/batch",
+		strings.Replace(batch, "sha512", "md5", 1), nil))
+}
+
+func TestServerLocks(t *testing.T) {
+	srv, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	var lock lockResponse
+	assert.Equal(t, 201, doRequest(t, "POST", srv.URL+"/locks", `{"path":"a.dat"}`, &lock))
+	require.NotNil(t, lock.Lock)
+	assert.Equal(t, "a.dat", lock.Lock.Path)
+	assert.Equal(t, "owner", lock.Lock.Owner.Name)
+	id := lock.Lock.Id
+
+	var conflict lockResponse
+	assert.Equal(t, 409, doRequest(t, "POST", srv.URL+"/locks", `{"path":"a.dat"}`, &conflict))
+	require.NotNil(t, conflict.Lock)
+	assert.Equal(t, id, conflict.Lock.Id)
+
+	assert.Equal(t, 201, doRequest(t, "POST", srv.URL+"/locks", `{"path":"b.dat"}`, &lock))
+
+	var list lockListResponse
+	assert.Equal(t, 200, doRequest(t, "GET", srv.URL+"/locks?limit=1", "", &list))
+	require.Len(t, list.Locks, 1)
+	assert.Equal(t, "b.dat", list.Locks[0].Path)
+	assert.Equal(t, id, list.NextCursor)
+
+	list = lockListResponse{}
+	assert.Equal(t, 200, doRequest(t, "GET", srv.URL+"/locks?path=a.dat", "", &list))
+	assert.Equal(t, []locking.Lock{*conflict.Lock}, list.Locks)
+
+	var unlock lockResponse
+	assert.Equal(t, 200, doRequest(t, "POST", srv.URL+"/locks/"+id+"/unlock", `{}`, &unlock))
+	require.NotNil(t, unlock.Lock)
+	assert.Equal(t, id, unlock.Lock.Id)
+
+	assert.Equal(t, 404, doRequest(t, "POST", srv.URL+"/locks/"+id+"/unlock", `{}`, nil))
+}
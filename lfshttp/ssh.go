@@ -10,7 +10,7 @@ import (
 	"github.com/git-lfs/git-lfs/ssh"
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type SSHResolver interface {
@@ -37,11 +37,11 @@ func (c *sshCache) Resolve(e Endpoint, method string) (sshAuthResponse, error) {
 	key := strings.Join([]string{e.SSHMetadata.UserAndHost, e.SSHMetadata.Port, e.SSHMetadata.Path, method}, "//")
 	if res, ok := c.endpoints[key]; ok {
 		if _, expired := res.IsExpiredWithin(5 * time.Second); !expired {
-			tracerx.Printf("ssh cache: %s git-lfs-authenticate %s %s",
+			tracelog.Printf("ssh cache: %s git-lfs-authenticate %s %s",
 				e.SSHMetadata.UserAndHost, e.SSHMetadata.Path, endpointOperation(e, method))
 			return *res, nil
 		} else {
-			tracerx.Printf("ssh cache expired: %s git-lfs-authenticate %s %s",
+			tracelog.Printf("ssh cache expired: %s git-lfs-authenticate %s %s",
 				e.SSHMetadata.UserAndHost, e.SSHMetadata.Path, endpointOperation(e, method))
 		}
 	}
@@ -0,0 +1,492 @@
+package standalone
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/creds"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociEmptyMediaType    = "application/vnd.oci.empty.v1+json"
+	// ociObjectMediaType is the media type of the layer holding an LFS
+	// object, and the artifact type of the manifest which refers to it.
+	ociObjectMediaType = "application/vnd.git-lfs.object"
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// ociTagPrefix is prefixed to the OID of an object to make the tag of
+	// its manifest.
+	ociTagPrefix = "lfs-"
+)
+
+// ociEmpty is the empty JSON object used as the config blob of every manifest,
+// as ORAS does for artifacts with no config of their own.
+var ociEmpty = []byte("{}")
+
+// ociDescriptor describes a blob in an OCI manifest.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is the OCI image manifest which stores an LFS object as an
+// artifact, with the object as its only layer.
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	ArtifactType  string          `json:"artifactType"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+// ociTokenResponse is the response of a registry's token service.
+type ociTokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// ociHandler stores objects in, and retrieves them from, a repository in an
+// OCI container registry. Each object is stored as a blob whose digest is
+// derived from its OID, and referred to by a manifest tagged "lfs-<oid>" so
+// that the registry does not garbage collect it.
+type ociHandler struct {
+	client  *lfsapi.Client
+	base    *url.URL
+	name    string
+	output  *os.File
+	tempdir string
+
+	// token is the bearer token given by the registry's token service.
+	token string
+	// useCreds is set once an anonymous token has not been enough, so
+	// that tokens are then requested with the user's credentials.
+	useCreds bool
+	// basic is set once the registry has asked for basic authentication.
+	basic bool
+	// pushedConfig is set once the empty config blob is known to exist.
+	pushedConfig bool
+}
+
+// ociRegistryFromUrl returns the base URL of the registry, and the name of the
+// repository within it, for the given "oci://" or "oci+http://" URL.
+func ociRegistryFromUrl(u *url.URL) (*url.URL, string, error) {
+	base := &url.URL{Scheme: "https", Host: u.Host}
+	if u.Scheme == "oci+http" {
+		base.Scheme = "http"
+	}
+
+	name := strings.Trim(u.Path, "/")
+	if len(u.Host) == 0 || len(name) == 0 {
+		return nil, "", errors.Errorf("invalid OCI URL %q: expected oci://<registry>/<repository>", u.String())
+	}
+	return base, name, nil
+}
+
+// newOCIHandler creates a new handler for the protocol, for the registry of the
+// remote.
+func newOCIHandler(cfg *config.Configuration, output *os.File, msg *inputMessage) (*ociHandler, error) {
+	apiClient, err := lfsapi.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := urlFromRemote(cfg, apiClient, msg.Remote, msg.Operation, "oci://", "oci+http://")
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.New("no valid oci:// URLs found")
+	}
+
+	base, name, err := ociRegistryFromUrl(u)
+	if err != nil {
+		return nil, err
+	}
+
+	tempdir, err := ioutil.TempDir(cfg.TempDir(), "lfs-standalone-oci-*")
+	if err != nil {
+		return nil, err
+	}
+
+	tracelog.Printf("using repository %q in OCI registry %q", name, base)
+
+	return &ociHandler{
+		client:  apiClient,
+		base:    base,
+		name:    name,
+		output:  output,
+		tempdir: tempdir,
+	}, nil
+}
+
+// dispatch dispatches the event depending on the message type, and returns
+// whether further events should be read.
+func (h *ociHandler) dispatch(msg *inputMessage) (bool, error) {
+	switch msg.Event {
+	case "init":
+		fmt.Fprintln(h.output, "{}")
+	case "upload":
+		h.respond(h.upload(msg.Oid, msg.Size, msg.Path))
+	case "download":
+		h.respond(h.download(msg.Oid, msg.Size))
+	case "terminate":
+		return false, nil
+	default:
+		return false, errors.Errorf("unknown event %q", msg.Event)
+	}
+	return true, nil
+}
+
+// cleanup removes the handler's temporary directory.
+func (h *ociHandler) cleanup() {
+	os.RemoveAll(h.tempdir)
+}
+
+// respond sends a response to an upload or download command, using the return
+// values from those functions.
+func (h *ociHandler) respond(oid string, path string, err error) {
+	respond(h.output, oid, path, err)
+}
+
+// upload performs the upload action for the given OID, size, and path. It
+// returns arguments suitable for the respond method.
+func (h *ociHandler) upload(oid string, size int64, path string) (string, string, error) {
+	return oid, "", h.push(oid, size, path)
+}
+
+// push pushes the object with the given OID and size from path as an
+// artifact, unless the registry already has its manifest, pushing its blob and
+// the empty config blob first if the registry does not already have them.
+func (h *ociHandler) push(oid string, size int64, path string) error {
+	tag := ociTagPrefix + oid
+	if ok, err := h.exists("manifests/" + tag); err != nil || ok {
+		return err
+	}
+
+	digest := ociDigest(oid)
+	if err := h.pushBlob(digest, size, func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}); err != nil {
+		return err
+	}
+
+	if !h.pushedConfig {
+		if err := h.pushBlob(ociDigestOf(ociEmpty), int64(len(ociEmpty)), func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(ociEmpty)), nil
+		}); err != nil {
+			return err
+		}
+		h.pushedConfig = true
+	}
+
+	manifest, err := json.Marshal(&ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  ociObjectMediaType,
+		Config: ociDescriptor{
+			MediaType: ociEmptyMediaType,
+			Digest:    ociDigestOf(ociEmpty),
+			Size:      int64(len(ociEmpty)),
+		},
+		Layers: []ociDescriptor{{
+			MediaType:   ociObjectMediaType,
+			Digest:      digest,
+			Size:        size,
+			Annotations: map[string]string{ociTitleAnnotation: oid},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := h.do(func() (*http.Request, error) {
+		req, err := h.newRequest("PUT", h.repositoryUrl("manifests/"+tag), ioutil.NopCloser(bytes.NewReader(manifest)))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", ociManifestMediaType)
+		req.ContentLength = int64(len(manifest))
+		return req, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to push manifest for %s", oid)
+	}
+	res.Body.Close()
+	return nil
+}
+
+// pushBlob uploads the blob with the given digest and size, read from the
+// reader returned by open, unless the registry already has it. It makes a
+// monolithic upload: a POST to start the upload, and a single PUT of the
+// blob's content to the location the registry returns.
+func (h *ociHandler) pushBlob(digest string, size int64, open func() (io.ReadCloser, error)) error {
+	if ok, err := h.exists("blobs/" + digest); err != nil || ok {
+		return err
+	}
+
+	res, err := h.do(func() (*http.Request, error) {
+		return h.newRequest("POST", h.repositoryUrl("blobs/uploads/"), nil)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to start upload of %s", digest)
+	}
+	res.Body.Close()
+
+	location, err := res.Request.URL.Parse(res.Header.Get("Location"))
+	if err != nil || len(res.Header.Get("Location")) == 0 {
+		return errors.Errorf("invalid upload location %q for %s", res.Header.Get("Location"), digest)
+	}
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+
+	res, err = h.do(func() (*http.Request, error) {
+		body, err := open()
+		if err != nil {
+			return nil, err
+		}
+		req, err := h.newRequest("PUT", location, body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.ContentLength = size
+		return req, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to upload %s", digest)
+	}
+	res.Body.Close()
+	return nil
+}
+
+// download downloads the object with the given OID and size into a temporary
+// file, checking its content against the OID. It returns arguments suitable
+// for the respond method.
+func (h *ociHandler) download(oid string, size int64) (string, string, error) {
+	res, err := h.do(func() (*http.Request, error) {
+		return h.newRequest("GET", h.repositoryUrl("blobs/"+ociDigest(oid)), nil)
+	})
+	if err != nil {
+		if res != nil && res.StatusCode == 404 {
+			tracelog.Printf("missing object in %q (%s)", h.name, oid)
+			return oid, "", errors.Errorf("remote missing object %s", oid)
+		}
+		return oid, "", err
+	}
+	defer res.Body.Close()
+
+	tmp, err := ioutil.TempFile(h.tempdir, "download")
+	if err != nil {
+		return oid, "", err
+	}
+	defer tmp.Close()
+
+	hasher := tools.NewLfsContentHashFor(oid)
+	n, err := io.Copy(io.MultiWriter(tmp, hasher), res.Body)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return oid, "", errors.Wrapf(err, "unable to download %s", oid)
+	}
+	if n != size || hex.EncodeToString(hasher.Sum(nil)) != oid {
+		os.Remove(tmp.Name())
+		return oid, "", errors.Errorf("registry returned corrupt content for %s", oid)
+	}
+	return oid, tmp.Name(), nil
+}
+
+// exists returns whether a HEAD request for the given path in the repository
+// succeeds, or false if the registry does not have it.
+func (h *ociHandler) exists(path string) (bool, error) {
+	res, err := h.do(func() (*http.Request, error) {
+		req, err := h.newRequest("HEAD", h.repositoryUrl(path), nil)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(path, "manifests/") {
+			req.Header.Set("Accept", ociManifestMediaType)
+		}
+		return req, nil
+	})
+	if err != nil {
+		if res != nil && res.StatusCode == 404 {
+			return false, nil
+		}
+		return false, err
+	}
+	res.Body.Close()
+	return true, nil
+}
+
+// repositoryUrl returns the URL of the given path under the repository, such
+// as "blobs/<digest>".
+func (h *ociHandler) repositoryUrl(path string) *url.URL {
+	u := *h.base
+	u.Path = "/v2/" + h.name + "/" + path
+	return &u
+}
+
+func (h *ociHandler) newRequest(method string, u *url.URL, body io.ReadCloser) (*http.Request, error) {
+	if body == nil {
+		return http.NewRequest(method, u.String(), nil)
+	}
+	return http.NewRequest(method, u.String(), body)
+}
+
+// do sends the request made by newReq to the registry. If the registry asks
+// for authentication, it is made again, either with a bearer token from the
+// registry's token service (first anonymously, then with the credentials
+// from Git's credential helpers), or with those credentials directly.
+func (h *ociHandler) do(newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		if h.basic {
+			return h.client.DoWithAuth("", creds.NewAccess(creds.BasicAccess, h.base.String()), req)
+		}
+		if len(h.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+h.token)
+		}
+
+		res, err := h.client.Do(req)
+		if !errors.IsAuthError(err) || res == nil || attempt == 2 {
+			return res, err
+		}
+		res.Body.Close()
+
+		challenge, ok := parseBearerChallenge(res.Header.Get("Www-Authenticate"))
+		if !ok {
+			h.basic = true
+			continue
+		}
+		if attempt > 0 {
+			h.useCreds = true
+		}
+		if err := h.fetchToken(challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchToken fetches a bearer token from the token service named by the given
+// challenge from the registry.
+func (h *ociHandler) fetchToken(challenge map[string]string) error {
+	realm, err := url.Parse(challenge["realm"])
+	if err != nil || len(challenge["realm"]) == 0 {
+		return errors.Errorf("invalid token realm %q", challenge["realm"])
+	}
+	q := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v, ok := challenge[key]; ok {
+			q.Set(key, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	tracelog.Printf("requesting OCI registry token from %q", realm.Host)
+
+	var res *http.Response
+	if h.useCreds {
+		res, err = h.client.DoWithAuth("", creds.NewAccess(creds.BasicAccess, realm.String()), req)
+	} else {
+		res, err = h.client.Do(req)
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to get OCI registry token")
+	}
+	defer res.Body.Close()
+
+	var token ociTokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return errors.Wrap(err, "unable to decode OCI registry token")
+	}
+
+	h.token = token.Token
+	if len(h.token) == 0 {
+		h.token = token.AccessToken
+	}
+	return nil
+}
+
+// parseBearerChallenge returns the parameters of a "WWW-Authenticate: Bearer"
+// challenge, such as its realm, service and scope, and whether the challenge
+// was one.
+func parseBearerChallenge(header string) (map[string]string, bool) {
+	pieces := strings.SplitN(header, " ", 2)
+	if len(pieces) != 2 || !strings.EqualFold(pieces[0], "bearer") {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	rest := strings.TrimSpace(pieces[1])
+	for len(rest) > 0 {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return params, true
+}
+
+// ociDigest returns the OCI digest of the content of the object with the given
+// OID.
+func ociDigest(oid string) string {
+	return tools.OidHashAlgorithm(oid) + ":" + oid
+}
+
+// ociDigestOf returns the OCI digest of the given content.
+func ociDigestOf(data []byte) string {
+	hasher := tools.NewLfsContentHash()
+	hasher.Write(data)
+	return ociDigest(hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// ProcessOCIData is the endpoint for processing data with the standalone
+// transfer agent for OCI registries. It reads input from the specified input
+// file and produces output to the specified output file.
+func ProcessOCIData(cfg *config.Configuration, input *os.File, output *os.File) error {
+	return processData(input, func(msg *inputMessage) (handler, error) {
+		return newOCIHandler(cfg, output, msg)
+	})
+}
@@ -0,0 +1,182 @@
+package standalone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry is a minimal OCI registry, which requires a bearer token from
+// its token service for every request.
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newTestRegistry(t *testing.T) (*testRegistry, *httptest.Server) {
+	r := &testRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string][]byte),
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			assert.Equal(t, "registry.test", req.URL.Query().Get("service"))
+			json.NewEncoder(w).Encode(&ociTokenResponse{Token: "t0ken"})
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="`+srv.URL+`/token",service="registry.test",scope="repository:org/repo:pull,push"`)
+			w.WriteHeader(401)
+			return
+		}
+		r.serve(t, w, req)
+	}))
+	return r, srv
+}
+
+func (r *testRegistry) serve(t *testing.T, w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/org/repo/")
+	switch {
+	case req.Method == "POST" && path == "blobs/uploads/":
+		w.Header().Set("Location", "/v2/org/repo/blobs/uploads/1?state=abc")
+		w.WriteHeader(202)
+	case req.Method == "PUT" && path == "blobs/uploads/1":
+		assert.Equal(t, "abc", req.URL.Query().Get("state"))
+		data, _ := ioutil.ReadAll(req.Body)
+		r.blobs[req.URL.Query().Get("digest")] = data
+		r.uploads++
+		w.WriteHeader(201)
+	case strings.HasPrefix(path, "blobs/"):
+		data, ok := r.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		if req.Method == "GET" {
+			w.Write(data)
+		}
+	case req.Method == "PUT" && strings.HasPrefix(path, "manifests/"):
+		data, _ := ioutil.ReadAll(req.Body)
+		r.manifests[strings.TrimPrefix(path, "manifests/")] = data
+		w.WriteHeader(201)
+	case strings.HasPrefix(path, "manifests/"):
+		if _, ok := r.manifests[strings.TrimPrefix(path, "manifests/")]; !ok {
+			w.WriteHeader(404)
+		}
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func TestOCIHandlerUploadAndDownload(t *testing.T) {
+	registry, srv := newTestRegistry(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "standalone-oci")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	content := []byte("hello, registry")
+	sum := sha256.Sum256(content)
+	oid := hex.EncodeToString(sum[:])
+	path := filepath.Join(dir, "object")
+	require.Nil(t, ioutil.WriteFile(path, content, 0644))
+
+	output, err := os.Create(filepath.Join(dir, "output"))
+	require.Nil(t, err)
+	defer output.Close()
+
+	cfg := config.NewFrom(config.Values{})
+	remote := strings.Replace(srv.URL, "http://", "oci+http://", 1) + "/org/repo"
+	h, err := newOCIHandler(cfg, output, &inputMessage{Remote: remote, Operation: "upload"})
+	require.Nil(t, err)
+	defer h.cleanup()
+
+	_, _, err = h.upload(oid, int64(len(content)), path)
+	require.Nil(t, err)
+	assert.Equal(t, content, registry.blobs["sha256:"+oid])
+	assert.Equal(t, ociEmpty, registry.blobs[ociDigestOf(ociEmpty)])
+	assert.Equal(t, 2, registry.uploads)
+
+	var manifest ociManifest
+	require.Nil(t, json.Unmarshal(registry.manifests["lfs-"+oid], &manifest))
+	assert.Equal(t, ociObjectMediaType, manifest.ArtifactType)
+	assert.Equal(t, ociEmptyMediaType, manifest.Config.MediaType)
+	require.Len(t, manifest.Layers, 1)
+	assert.Equal(t, "sha256:"+oid, manifest.Layers[0].Digest)
+	assert.Equal(t, int64(len(content)), manifest.Layers[0].Size)
+
+	// Objects whose manifest exists are not uploaded again.
+	_, _, err = h.upload(oid, int64(len(content)), path)
+	require.Nil(t, err)
+	assert.Equal(t, 2, registry.uploads)
+
+	_, downloaded, err := h.download(oid, int64(len(content)))
+	require.Nil(t, err)
+	data, err := ioutil.ReadFile(downloaded)
+	require.Nil(t, err)
+	assert.Equal(t, content, data)
+
+	missing := strings.Repeat("0", 64)
+	_, _, err = h.download(missing, 1)
+	require.NotNil(t, err)
+	assert.Equal(t, "remote missing object "+missing, err.Error())
+
+	registry.blobs["sha256:"+oid] = []byte("corrupted")
+	_, _, err = h.download(oid, int64(len(content)))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "corrupt content")
+}
+
+func TestOCIRegistryFromUrl(t *testing.T) {
+	for _, tc := range []struct {
+		url, base, name string
+	}{
+		{"oci://ghcr.io/org/repo", "https://ghcr.io", "org/repo"},
+		{"oci+http://localhost:5000/lfs/", "http://localhost:5000", "lfs"},
+	} {
+		u, err := urlFromRemote(config.NewFrom(config.Values{}), nil, tc.url, "download", "oci://", "oci+http://")
+		require.Nil(t, err)
+		base, name, err := ociRegistryFromUrl(u)
+		require.Nil(t, err)
+		assert.Equal(t, tc.base, base.String())
+		assert.Equal(t, tc.name, name)
+	}
+
+	u, err := urlFromRemote(config.NewFrom(config.Values{}), nil, "oci://ghcr.io", "download", "oci://")
+	require.Nil(t, err)
+	_, _, err = ociRegistryFromUrl(u)
+	assert.NotNil(t, err)
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	params, ok := parseBearerChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:org/repo:pull,push"`)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:org/repo:pull,push",
+	}, params)
+
+	_, ok = parseBearerChallenge(`Basic realm="registry"`)
+	assert.False(t, ok)
+}
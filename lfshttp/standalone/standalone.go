@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -14,11 +15,12 @@ import (
 
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // inputMessage represents a message from Git LFS to the standalone transfer
@@ -59,23 +61,29 @@ type fileHandler struct {
 //
 // In this situation, we only accept file URLs.
 func fileUrlFromRemote(cfg *config.Configuration, name string, direction string) (*url.URL, error) {
-	if strings.HasPrefix(name, "file://") {
-		if url, err := url.Parse(name); err == nil {
-			return url, nil
-		}
-	}
-
 	apiClient, err := lfsapi.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
+	return urlFromRemote(cfg, apiClient, name, direction, "file://")
+}
+
+// urlFromRemote looks up the URL depending on the remote, which can be a
+// literal URL or the name of a remote, accepting only URLs with one of the
+// given prefixes.
+func urlFromRemote(cfg *config.Configuration, apiClient *lfsapi.Client, name string, direction string, prefixes ...string) (*url.URL, error) {
+	if hasAnyPrefix(name, prefixes) {
+		if url, err := url.Parse(name); err == nil {
+			return url, nil
+		}
+	}
 
 	for _, remote := range cfg.Remotes() {
 		if remote != name {
 			continue
 		}
 		remoteEndpoint := apiClient.Endpoints.Endpoint(direction, remote)
-		if !strings.HasPrefix(remoteEndpoint.Url, "file://") {
+		if !hasAnyPrefix(remoteEndpoint.Url, prefixes) {
 			return nil, nil
 		}
 		return url.Parse(remoteEndpoint.Url)
@@ -83,6 +91,15 @@ func fileUrlFromRemote(cfg *config.Configuration, name string, direction string)
 	return nil, nil
 }
 
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // gitDirAtPath finds the .git directory corresponding to the given path, which
 // may be the .git directory itself, the working tree, or the root of a bare
 // repository.
@@ -183,7 +200,7 @@ func newHandler(cfg *config.Configuration, output *os.File, msg *inputMessage) (
 		return nil, err
 	}
 
-	tracerx.Printf("using %q as remote git directory", gitdir)
+	tracelog.Printf("using %q as remote git directory", gitdir)
 
 	return &fileHandler{
 		remotePath:   path,
@@ -194,8 +211,9 @@ func newHandler(cfg *config.Configuration, output *os.File, msg *inputMessage) (
 	}, nil
 }
 
-// dispatch dispatches the event depending on the message type.
-func (h *fileHandler) dispatch(msg *inputMessage) bool {
+// dispatch dispatches the event depending on the message type, and returns
+// whether further events should be read.
+func (h *fileHandler) dispatch(msg *inputMessage) (bool, error) {
 	switch msg.Event {
 	case "init":
 		fmt.Fprintln(h.output, "{}")
@@ -204,16 +222,27 @@ func (h *fileHandler) dispatch(msg *inputMessage) bool {
 	case "download":
 		h.respond(h.download(msg.Oid, msg.Size))
 	case "terminate":
-		return false
+		return false, nil
 	default:
-		standaloneFailure(fmt.Sprintf("unknown event %q", msg.Event), nil)
+		return false, errors.Errorf("unknown event %q", msg.Event)
 	}
-	return true
+	return true, nil
+}
+
+// cleanup removes the handler's temporary directory.
+func (h *fileHandler) cleanup() {
+	os.RemoveAll(h.tempdir)
 }
 
 // respond sends a response to an upload or download command, using the return
 // values from those functions.
 func (h *fileHandler) respond(oid string, path string, err error) {
+	respond(h.output, oid, path, err)
+}
+
+// respond writes a completion response for the given OID and path to output,
+// with the message of err, if any.
+func respond(output *os.File, oid string, path string, err error) {
 	response := &completeMessage{
 		Event: "complete",
 		Oid:   oid,
@@ -222,7 +251,7 @@ func (h *fileHandler) respond(oid string, path string, err error) {
 	if err != nil {
 		response.Error = &errorMessage{Message: err.Error()}
 	}
-	json.NewEncoder(h.output).Encode(response)
+	json.NewEncoder(output).Encode(response)
 }
 
 // upload performs the upload action for the given OID, size, and path. It
@@ -243,7 +272,7 @@ func (h *fileHandler) upload(oid string, size int64, path string) (string, strin
 // arguments suitable for the respond method.
 func (h *fileHandler) download(oid string, size int64) (string, string, error) {
 	if !h.remoteConfig.LFSObjectExists(oid, size) {
-		tracerx.Printf("missing object in %q (%s)", h.remotePath, oid)
+		tracelog.Printf("missing object in %q (%s)", h.remotePath, oid)
 		return oid, "", errors.Errorf("remote missing object %s", oid)
 	}
 
@@ -259,20 +288,57 @@ func (h *fileHandler) download(oid string, size int64) (string, string, error) {
 	tmp.Close()
 	os.Remove(tmp.Name())
 	path := tmp.Name()
+
+	// Objects stored compressed in the remote are copied decompressed.
+	if _, compressed, err := fs.ObjectFile(src); err == nil && compressed {
+		return oid, path, copyObject(src, path)
+	}
 	return oid, path, lfs.LinkOrCopy(h.config, src, path)
 }
 
-// standaloneFailure reports a fatal error.
-func standaloneFailure(msg string, err error) {
-	fmt.Fprintf(os.Stderr, "%s: %s\n", msg, err)
-	os.Exit(2)
+// copyObject writes the uncompressed contents of the object stored at "src",
+// or compressed alongside it, to a new file at "dst".
+func copyObject(src, dst string) error {
+	r, err := fs.OpenObjectFile(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// handler handles the messages of the standalone transfer agent protocol for
+// a particular kind of URL.
+type handler interface {
+	// dispatch dispatches the event depending on the message type, and
+	// returns whether further events should be read.
+	dispatch(msg *inputMessage) (bool, error)
+	// cleanup removes any temporary files the handler created.
+	cleanup()
 }
 
 // ProcessStandaloneData is the primary endpoint for processing data with a
 // standalone transfer agent. It reads input from the specified input file and
 // produces output to the specified output file.
 func ProcessStandaloneData(cfg *config.Configuration, input *os.File, output *os.File) error {
-	var handler *fileHandler
+	return processData(input, func(msg *inputMessage) (handler, error) {
+		return newHandler(cfg, output, msg)
+	})
+}
+
+// processData reads messages from input, and dispatches them to the handler
+// returned by newHandler for the first of them.
+func processData(input *os.File, newHandler func(msg *inputMessage) (handler, error)) error {
+	var h handler
 
 	scanner := bufio.NewScanner(input)
 	for scanner.Scan() {
@@ -280,19 +346,24 @@ func ProcessStandaloneData(cfg *config.Configuration, input *os.File, output *os
 		if err := json.NewDecoder(strings.NewReader(scanner.Text())).Decode(&msg); err != nil {
 			return errors.Wrapf(err, "error decoding json")
 		}
-		if handler == nil {
+		if h == nil {
 			var err error
-			handler, err = newHandler(cfg, output, &msg)
+			h, err = newHandler(&msg)
 			if err != nil {
 				return errors.Wrapf(err, "error creating handler")
 			}
 		}
-		if !handler.dispatch(&msg) {
+		more, err := h.dispatch(&msg)
+		if err != nil {
+			h.cleanup()
+			return err
+		}
+		if !more {
 			break
 		}
 	}
-	if handler != nil {
-		os.RemoveAll(handler.tempdir)
+	if h != nil {
+		h.cleanup()
 	}
 	if err := scanner.Err(); err != nil {
 		return errors.Wrapf(err, "error reading input")
@@ -38,7 +38,7 @@ type statsContextKey string
 const transferKey = statsContextKey("transfer")
 
 func (c *Client) LogHTTPStats(w io.WriteCloser) {
-	fmt.Fprintf(w, "concurrent=%d time=%d version=%s\n", c.ConcurrentTransfers, time.Now().Unix(), UserAgent)
+	fmt.Fprintf(w, "concurrent=%d time=%d version=%s\n", c.ConcurrentTransfers, time.Now().Unix(), c.UserAgent)
 	c.httpLogger = newSyncLogger(w)
 }
 
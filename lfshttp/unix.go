@@ -0,0 +1,76 @@
+package lfshttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+// UnixSocketScheme is the URL scheme used for LFS endpoints that are served
+// over a Unix domain socket instead of a TCP connection.
+//
+// The path of such a URL contains the path to the socket, followed by a colon
+// and the path of the resource on the server, e.g.:
+//
+//   http+unix:///var/run/lfs.sock:/repo.git/info/lfs
+//
+const UnixSocketScheme = "http+unix"
+
+// IsUnixSocketURL returns whether or not the given URL refers to an endpoint
+// reachable over a Unix domain socket.
+func IsUnixSocketURL(u *url.URL) bool {
+	return u != nil && u.Scheme == UnixSocketScheme
+}
+
+// splitUnixSocketURL splits the path of an "http+unix" URL into the path to
+// the socket and the path of the resource served behind it.
+func splitUnixSocketURL(u *url.URL) (socket, path string, err error) {
+	parts := strings.SplitN(u.Path, ":", 2)
+	if len(parts[0]) == 0 {
+		return "", "", errors.Errorf("lfshttp: missing socket path in %q", u.String())
+	}
+
+	socket = parts[0]
+	path = "/"
+	if len(parts) > 1 && len(parts[1]) > 0 {
+		path = parts[1]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	return socket, path, nil
+}
+
+// unixSocketTransport is an http.RoundTripper that rewrites "http+unix"
+// requests into plain HTTP requests before handing them off to a transport
+// whose connections are dialed to the socket.
+type unixSocketTransport struct {
+	rt http.RoundTripper
+}
+
+func (t *unixSocketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsUnixSocketURL(req.URL) {
+		return t.rt.RoundTrip(req)
+	}
+
+	_, path, err := splitUnixSocketURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *req.URL
+	u.Scheme = "http"
+	u.Host = "localhost"
+	u.Path = path
+	u.RawPath = ""
+
+	// Per the http.RoundTripper contract, the original request must not be
+	// modified, so send a shallow copy pointing at the rewritten URL.
+	rewritten := req.Clone(req.Context())
+	rewritten.URL = &u
+	rewritten.Host = u.Host
+
+	return t.rt.RoundTrip(rewritten)
+}
@@ -0,0 +1,70 @@
+package lfshttp
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitUnixSocketURL(t *testing.T) {
+	for rawurl, expected := range map[string][]string{
+		"http+unix:///tmp/lfs.sock:/repo.git/info/lfs": {"/tmp/lfs.sock", "/repo.git/info/lfs"},
+		"http+unix:///tmp/lfs.sock:repo.git":           {"/tmp/lfs.sock", "/repo.git"},
+		"http+unix:///tmp/lfs.sock":                    {"/tmp/lfs.sock", "/"},
+	} {
+		req, err := http.NewRequest("GET", rawurl, nil)
+		require.Nil(t, err)
+
+		socket, path, err := splitUnixSocketURL(req.URL)
+		require.Nil(t, err, rawurl)
+		assert.Equal(t, expected[0], socket, rawurl)
+		assert.Equal(t, expected[1], path, rawurl)
+	}
+}
+
+func TestSplitUnixSocketURLWithoutSocket(t *testing.T) {
+	req, err := http.NewRequest("GET", "http+unix://", nil)
+	require.Nil(t, err)
+
+	_, _, err = splitUnixSocketURL(req.URL)
+	assert.NotNil(t, err)
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping Unix socket test on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "lfshttp-unix")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "lfs.sock")
+	listener, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	defer listener.Close()
+
+	var paths []string
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(200)
+	}))
+
+	c, err := NewClient(NewContext(nil, nil, nil))
+	require.Nil(t, err)
+
+	req, err := c.NewRequest("POST", Endpoint{Url: "http+unix://" + socket + ":/repo.git/info/lfs"}, "objects/batch", nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, []string{"/repo.git/info/lfs/objects/batch"}, paths)
+}
@@ -9,11 +9,11 @@ import (
 	"net/http/httputil"
 	"strings"
 
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 func (c *Client) traceRequest(req *http.Request) (*tracedRequest, error) {
-	tracerx.Printf("HTTP: %s", traceReq(req))
+	tracelog.Printf("HTTP: %s", traceReq(req))
 
 	if c.Verbose {
 		if dump, err := httputil.DumpRequest(req, false); err == nil {
@@ -63,7 +63,7 @@ func (c *Client) traceResponse(req *http.Request, tracedReq *tracedRequest, res
 		return
 	}
 
-	tracerx.Printf("HTTP: %d", res.StatusCode)
+	tracelog.Printf("HTTP: %d", res.StatusCode)
 
 	verboseBody := isTraceableContent(res.Header)
 	res.Body = &tracedResponse{
@@ -117,7 +117,7 @@ func tracedRead(r io.Reader, b []byte, verboseOut io.Writer, gitTrace, verbose b
 		if n > 0 && (gitTrace || verbose) {
 			chunk := string(b[0:n])
 			if gitTrace {
-				tracerx.Printf("HTTP: %s", chunk)
+				tracelog.Printf("HTTP: %s", chunk)
 			}
 
 			if verbose {
@@ -13,6 +13,7 @@ import (
 type lockClient interface {
 	Lock(remote string, lockReq *lockRequest) (*lockResponse, int, error)
 	Unlock(ref *git.Ref, remote, id string, force bool) (*unlockResponse, int, error)
+	Renew(ref *git.Ref, remote, id string) (*lockResponse, int, error)
 	Search(remote string, searchReq *lockSearchRequest) (*lockList, int, error)
 	SearchVerifiable(remote string, vreq *lockVerifiableRequest) (*lockVerifiableList, int, error)
 }
@@ -139,6 +140,45 @@ func (c *httpLockClient) Unlock(ref *git.Ref, remote, id string, force bool) (*u
 	return unlockRes, res.StatusCode, nil
 }
 
+// renewRequest encapsulates the data sent in an API request to extend the
+// lease of a lock.
+type renewRequest struct {
+	Ref *lockRef `json:"ref,omitempty"`
+}
+
+// Renew asks the server to extend the lease of the lock with the given ID. The
+// server replies with the lock and its new expiry, as it does when creating a
+// lock.
+func (c *httpLockClient) Renew(ref *git.Ref, remote, id string) (*lockResponse, int, error) {
+	e := c.Endpoints.Endpoint("upload", remote)
+	suffix := fmt.Sprintf("locks/%s/renew", id)
+	req, err := c.NewRequest("POST", e, suffix, &renewRequest{
+		Ref: &lockRef{Name: ref.Refspec()},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req = c.Client.LogRequest(req, "lfs.locks.renew")
+	res, err := c.DoAPIRequestWithAuth(remote, req)
+	if err != nil {
+		if res != nil {
+			return nil, res.StatusCode, err
+		}
+		return nil, 0, err
+	}
+
+	renewRes := &lockResponse{}
+	err = lfshttp.DecodeJSON(res, renewRes)
+	if err != nil {
+		return nil, res.StatusCode, err
+	}
+	if renewRes.Lock == nil && len(renewRes.Message) == 0 {
+		return nil, res.StatusCode, fmt.Errorf("invalid server response")
+	}
+	return renewRes, res.StatusCode, nil
+}
+
 // Filter represents a single qualifier to apply against a set of locks.
 type lockFilter struct {
 	// Property is the property to search against.
@@ -339,9 +379,10 @@ func (c *genericLockClient) getClient(remote, operation string) lockClient {
 	if client := c.lclients[info]; client != nil {
 		return client
 	}
-	transfer := c.client.SSHTransfer(operation, remote)
 	var lclient lockClient
-	if transfer != nil {
+	if helper := findLockHelper(c.client, operation, remote); len(helper) > 0 {
+		lclient = &helperLockClient{helper: helper, Client: c.client}
+	} else if transfer := c.client.SSHTransfer(operation, remote); transfer != nil {
 		lclient = &sshLockClient{transfer: transfer, Client: c.client}
 	} else {
 		lclient = &httpLockClient{Client: c.client}
@@ -358,6 +399,10 @@ func (c *genericLockClient) Unlock(ref *git.Ref, remote, id string, force bool)
 	return c.getClient(remote, "upload").Unlock(ref, remote, id, force)
 }
 
+func (c *genericLockClient) Renew(ref *git.Ref, remote, id string) (*lockResponse, int, error) {
+	return c.getClient(remote, "upload").Renew(ref, remote, id)
+}
+
 func (c *genericLockClient) Search(remote string, searchReq *lockSearchRequest) (*lockList, int, error) {
 	return c.getClient(remote, "download").Search(remote, searchReq)
 }
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfsapi"
@@ -120,6 +121,60 @@ func TestAPIUnlock(t *testing.T) {
 	assert.Equal(t, "response", unlockRes.Lock.Path)
 }
 
+func TestAPIRenew(t *testing.T) {
+	expiresAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/locks/123/renew" {
+			w.WriteHeader(404)
+			return
+		}
+
+		assert.Equal(t, "POST", r.Method)
+		renewReq := &renewRequest{}
+		err := json.NewDecoder(r.Body).Decode(renewReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "refs/heads/master", renewReq.Ref.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		resLoader, resWriter := gojsonschema.NewWriterLoader(w)
+		err = json.NewEncoder(resWriter).Encode(&lockResponse{
+			Lock: &Lock{
+				Id:        "123",
+				Path:      "response",
+				ExpiresAt: &expiresAt,
+			},
+		})
+		assert.Nil(t, err)
+		assertSchema(t, createResSchema, resLoader)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	ref := &git.Ref{
+		Name: "master",
+		Sha:  "6161616161616161616161616161616161616161",
+		Type: git.RefTypeLocalBranch,
+	}
+
+	lc := &httpLockClient{Client: c}
+	renewRes, status, err := lc.Renew(ref, "", "123")
+	require.Nil(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "123", renewRes.Lock.Id)
+	require.NotNil(t, renewRes.Lock.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*renewRes.Lock.ExpiresAt))
+
+	_, status, err = lc.Renew(ref, "", "456")
+	assert.NotNil(t, err)
+	assert.Equal(t, 404, status)
+}
+
 func TestAPISearch(t *testing.T) {
 	require.NotNil(t, listResSchema)
 
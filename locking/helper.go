@@ -0,0 +1,163 @@
+package locking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// helperNotImplementedStatus is the exit status with which a lock helper
+// reports that it does not support the requested operation.
+const helperNotImplementedStatus = 2
+
+// helperLockClient implements the locking API by running an external helper
+// program, configured by "lfs.lockhelper", for each request. This allows locks
+// to be managed on servers which don't implement the HTTPS locks API.
+type helperLockClient struct {
+	helper string
+	*lfsapi.Client
+}
+
+// helperRequest is the message written to the standard input of a lock
+// helper. The helper replies on its standard output with the JSON body the
+// HTTPS locks API would return for the same request.
+type helperRequest struct {
+	// Operation is one of "lock", "unlock", "renew", "search" or "verify".
+	Operation string `json:"operation"`
+	Remote    string `json:"remote"`
+	URL       string `json:"url"`
+	// Id is the ID of the lock to release or renew, for the "unlock" and
+	// "renew" operations.
+	Id string `json:"id,omitempty"`
+	// Query holds the query parameters of the "search" operation.
+	Query map[string]string `json:"query,omitempty"`
+	// Request holds the request body of the other operations.
+	Request interface{} `json:"request,omitempty"`
+}
+
+// findLockHelper returns the lock helper configured for the given operation
+// and remote, or the empty string if locks should be managed over the usual
+// transports.
+func findLockHelper(client *lfsapi.Client, operation, remote string) string {
+	ep := client.Endpoints.Endpoint(operation, remote)
+	uc := config.NewURLConfig(client.GitEnv())
+	if v, ok := uc.Get("lfs", ep.Url, "lockhelper"); ok {
+		return v
+	}
+	return ""
+}
+
+func (c *helperLockClient) run(operation, endpoint string, req *helperRequest, res interface{}) (int, error) {
+	req.Operation = operation
+	req.URL = c.Endpoints.Endpoint(endpoint, req.Remote).Url
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmdName, cmdArgs := subprocess.FormatForShell(c.helper, "")
+	cmd := subprocess.ExecCommand(cmdName, cmdArgs...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	tracelog.Printf("locking: running lock helper %q for %s", c.helper, operation)
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) == 0 {
+			msg = err.Error()
+		}
+		status := 0
+		if e, ok := err.(*exec.ExitError); ok {
+			if ws, ok := e.ProcessState.Sys().(syscall.WaitStatus); ok && ws.ExitStatus() == helperNotImplementedStatus {
+				status = http.StatusNotImplemented
+			}
+		}
+		return status, fmt.Errorf("lock helper %q failed: %s", c.helper, msg)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), res); err != nil {
+		return 0, fmt.Errorf("invalid response from lock helper %q: %v", c.helper, err)
+	}
+	return http.StatusOK, nil
+}
+
+func (c *helperLockClient) Lock(remote string, lockReq *lockRequest) (*lockResponse, int, error) {
+	res := &lockResponse{}
+	status, err := c.run("lock", "upload", &helperRequest{
+		Remote:  remote,
+		Request: lockReq,
+	}, res)
+	if err != nil {
+		return nil, status, err
+	}
+	if res.Lock == nil && len(res.Message) == 0 {
+		return nil, status, fmt.Errorf("invalid response from lock helper %q", c.helper)
+	}
+	return res, status, nil
+}
+
+func (c *helperLockClient) Unlock(ref *git.Ref, remote, id string, force bool) (*unlockResponse, int, error) {
+	res := &unlockResponse{}
+	status, err := c.run("unlock", "upload", &helperRequest{
+		Remote: remote,
+		Id:     id,
+		Request: &unlockRequest{
+			Force: force,
+			Ref:   &lockRef{Name: ref.Refspec()},
+		},
+	}, res)
+	if err != nil {
+		return nil, status, err
+	}
+	if res.Lock == nil && len(res.Message) == 0 {
+		return nil, status, fmt.Errorf("invalid response from lock helper %q", c.helper)
+	}
+	return res, status, nil
+}
+
+func (c *helperLockClient) Renew(ref *git.Ref, remote, id string) (*lockResponse, int, error) {
+	res := &lockResponse{}
+	status, err := c.run("renew", "upload", &helperRequest{
+		Remote:  remote,
+		Id:      id,
+		Request: &renewRequest{Ref: &lockRef{Name: ref.Refspec()}},
+	}, res)
+	if err != nil {
+		return nil, status, err
+	}
+	if res.Lock == nil && len(res.Message) == 0 {
+		return nil, status, fmt.Errorf("invalid response from lock helper %q", c.helper)
+	}
+	return res, status, nil
+}
+
+func (c *helperLockClient) Search(remote string, searchReq *lockSearchRequest) (*lockList, int, error) {
+	res := &lockList{}
+	status, err := c.run("search", "download", &helperRequest{
+		Remote: remote,
+		Query:  searchReq.QueryValues(),
+	}, res)
+	return res, status, err
+}
+
+func (c *helperLockClient) SearchVerifiable(remote string, vreq *lockVerifiableRequest) (*lockVerifiableList, int, error) {
+	res := &lockVerifiableList{}
+	status, err := c.run("verify", "upload", &helperRequest{
+		Remote:  remote,
+		Request: vreq,
+	}, res)
+	return res, status, err
+}
@@ -0,0 +1,180 @@
+package locking
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLockHelper writes a lock helper script which saves its request to
+// request.json in dir, writes response to its standard output and exits with
+// the given status, and returns the command to run it.
+func newTestLockHelper(t *testing.T, dir, response string, status int) string {
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "response.json"), []byte(response), 0644))
+
+	script := filepath.Join(dir, "helper.sh")
+	require.Nil(t, ioutil.WriteFile(script, []byte("#!/bin/sh\n"+
+		"cat > \""+filepath.Join(dir, "request.json")+"\"\n"+
+		"cat \""+filepath.Join(dir, "response.json")+"\"\n"+
+		"echo 'helper error' >&2\n"+
+		"exit "+strconv.Itoa(status)+"\n"), 0755))
+	return "sh " + script
+}
+
+func readTestLockHelperRequest(t *testing.T, dir string) *helperRequest {
+	by, err := ioutil.ReadFile(filepath.Join(dir, "request.json"))
+	require.Nil(t, err)
+
+	req := &helperRequest{}
+	require.Nil(t, json.Unmarshal(by, req))
+	return req
+}
+
+func TestHelperLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-helper")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":        "https://example.com/repo.git/info/lfs",
+		"lfs.lockhelper": newTestLockHelper(t, dir, `{"lock":{"id":"1","path":"response"}}`, 0),
+	}))
+	require.Nil(t, err)
+
+	lc := newGenericLockClient(c)
+	lockRes, status, err := lc.Lock("origin", &lockRequest{Path: "request", Ref: &lockRef{Name: "refs/heads/master"}})
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "1", lockRes.Lock.Id)
+	assert.Equal(t, "response", lockRes.Lock.Path)
+
+	req := readTestLockHelperRequest(t, dir)
+	assert.Equal(t, "lock", req.Operation)
+	assert.Equal(t, "origin", req.Remote)
+	assert.Equal(t, "https://example.com/repo.git/info/lfs", req.URL)
+	assert.Equal(t, map[string]interface{}{
+		"path": "request",
+		"ref":  map[string]interface{}{"name": "refs/heads/master"},
+	}, req.Request)
+}
+
+func TestHelperUnlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-helper")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":        "https://example.com/repo.git/info/lfs",
+		"lfs.lockhelper": newTestLockHelper(t, dir, `{"lock":{"id":"123"}}`, 0),
+	}))
+	require.Nil(t, err)
+
+	lc := &helperLockClient{helper: findLockHelper(c, "upload", ""), Client: c}
+	unlockRes, status, err := lc.Unlock(&git.Ref{Name: "master", Sha: "6161616161616161616161616161616161616161", Type: git.RefTypeLocalBranch}, "", "123", true)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "123", unlockRes.Lock.Id)
+
+	req := readTestLockHelperRequest(t, dir)
+	assert.Equal(t, "unlock", req.Operation)
+	assert.Equal(t, "123", req.Id)
+	assert.Equal(t, map[string]interface{}{
+		"force": true,
+		"ref":   map[string]interface{}{"name": "refs/heads/master"},
+	}, req.Request)
+}
+
+func TestHelperSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-helper")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":        "https://example.com/repo.git/info/lfs",
+		"lfs.lockhelper": newTestLockHelper(t, dir, `{"locks":[{"id":"1"},{"id":"2"}],"next_cursor":"3"}`, 0),
+	}))
+	require.Nil(t, err)
+
+	lc := newGenericLockClient(c)
+	locks, status, err := lc.Search("", &lockSearchRequest{
+		Filters: []lockFilter{{Property: "path", Value: "a.dat"}},
+		Limit:   2,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, locks.Locks, 2)
+	assert.Equal(t, "1", locks.Locks[0].Id)
+	assert.Equal(t, "2", locks.Locks[1].Id)
+	assert.Equal(t, "3", locks.NextCursor)
+
+	req := readTestLockHelperRequest(t, dir)
+	assert.Equal(t, "search", req.Operation)
+	assert.Equal(t, map[string]string{"path": "a.dat", "limit": "2"}, req.Query)
+}
+
+func TestHelperSearchVerifiableNotImplemented(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-helper")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":        "https://example.com/repo.git/info/lfs",
+		"lfs.lockhelper": newTestLockHelper(t, dir, "", helperNotImplementedStatus),
+	}))
+	require.Nil(t, err)
+
+	lc := newGenericLockClient(c)
+	_, status, err := lc.SearchVerifiable("", &lockVerifiableRequest{})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotImplemented, status)
+	assert.Contains(t, err.Error(), "helper error")
+
+	req := readTestLockHelperRequest(t, dir)
+	assert.Equal(t, "verify", req.Operation)
+}
+
+func TestHelperFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-helper")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":        "https://example.com/repo.git/info/lfs",
+		"lfs.lockhelper": newTestLockHelper(t, dir, "", 1),
+	}))
+	require.Nil(t, err)
+
+	lc := newGenericLockClient(c)
+	_, status, err := lc.Lock("", &lockRequest{Path: "request"})
+	require.NotNil(t, err)
+	assert.Equal(t, 0, status)
+	assert.Contains(t, err.Error(), "helper error")
+}
+
+func TestFindLockHelperByURL(t *testing.T) {
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                                   "https://example.com/repo.git/info/lfs",
+		"lfs.https://example.com/.lockhelper":       "example-helper",
+		"lfs.https://other.example.com/.lockhelper": "other-helper",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, "example-helper", findLockHelper(c, "upload", ""))
+
+	c, err = lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": "https://example.com/repo.git/info/lfs",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, "", findLockHelper(c, "upload", ""))
+}
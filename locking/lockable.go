@@ -47,7 +47,7 @@ func (c *Client) refreshLockablePatterns() {
 			c.lockablePatterns = append(c.lockablePatterns, filepath.ToSlash(p.Path))
 		}
 	}
-	c.lockableFilter = filepathfilter.New(c.lockablePatterns, nil, filepathfilter.DefaultValue(false))
+	c.lockableFilter = filepathfilter.New(c.lockablePatterns, nil, filepathfilter.DefaultValue(false), filepathfilter.IgnoreCase(c.cfg.IgnoreCase()))
 }
 
 // IsFileLockable returns whether a specific file path is marked as Lockable,
@@ -100,10 +100,10 @@ func (c *Client) FixFileWriteFlagsInDir(dir string, lockablePatterns, unlockable
 	var lockableFilter *filepathfilter.Filter
 	var unlockableFilter *filepathfilter.Filter
 	if lockablePatterns != nil {
-		lockableFilter = filepathfilter.New(lockablePatterns, nil)
+		lockableFilter = filepathfilter.New(lockablePatterns, nil, filepathfilter.IgnoreCase(c.cfg.IgnoreCase()))
 	}
 	if unlockablePatterns != nil {
-		unlockableFilter = filepathfilter.New(unlockablePatterns, nil)
+		unlockableFilter = filepathfilter.New(unlockablePatterns, nil, filepathfilter.IgnoreCase(c.cfg.IgnoreCase()))
 	}
 
 	return c.fixFileWriteFlags(absPath, c.LocalWorkingDir, lockableFilter, unlockableFilter)
@@ -113,7 +113,7 @@ func (c *Client) FixFileWriteFlagsInDir(dir string, lockablePatterns, unlockable
 func (c *Client) fixFileWriteFlags(absPath, workingDir string, lockable, unlockable *filepathfilter.Filter) error {
 
 	// Build a list of files
-	lsFiles, err := git.NewLsFiles(workingDir, !c.ModifyIgnoredFiles)
+	lsFiles, err := git.NewLsFiles(workingDir, !c.ModifyIgnoredFiles, true)
 	if err != nil {
 		return err
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,7 +18,7 @@ import (
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/kv"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 var (
@@ -100,19 +101,19 @@ func (c *Client) Close() error {
 // path must be relative to the root of the repository
 // Returns the lock id if successful, or an error
 func (c *Client) LockFile(path string) (Lock, error) {
-	lockRes, _, err := c.client.Lock(c.Remote, &lockRequest{
+	lockRes, status, err := c.client.Lock(c.Remote, &lockRequest{
 		Path: path,
 		Ref:  &lockRef{Name: c.RemoteRef.Refspec()},
 	})
 	if err != nil {
-		return Lock{}, errors.Wrap(err, "api")
+		return Lock{}, lockConflictError(errors.Wrap(err, "api"), status)
 	}
 
 	if len(lockRes.Message) > 0 {
 		if len(lockRes.RequestID) > 0 {
-			tracerx.Printf("Server Request ID: %s", lockRes.RequestID)
+			tracelog.Printf("Server Request ID: %s", lockRes.RequestID)
 		}
-		return Lock{}, fmt.Errorf("server unable to create lock: %s", lockRes.Message)
+		return Lock{}, lockConflictError(fmt.Errorf("server unable to create lock: %s", lockRes.Message), status)
 	}
 
 	lock := *lockRes.Lock
@@ -165,16 +166,16 @@ func (c *Client) UnlockFile(path string, force bool) error {
 // UnlockFileById attempts to unlock a lock with a given id on the current remote
 // Force causes the file to be unlocked from other users as well
 func (c *Client) UnlockFileById(id string, force bool) error {
-	unlockRes, _, err := c.client.Unlock(c.RemoteRef, c.Remote, id, force)
+	unlockRes, status, err := c.client.Unlock(c.RemoteRef, c.Remote, id, force)
 	if err != nil {
-		return errors.Wrap(err, "api")
+		return lockConflictError(errors.Wrap(err, "api"), status)
 	}
 
 	if len(unlockRes.Message) > 0 {
 		if len(unlockRes.RequestID) > 0 {
-			tracerx.Printf("Server Request ID: %s", unlockRes.RequestID)
+			tracelog.Printf("Server Request ID: %s", unlockRes.RequestID)
 		}
-		return fmt.Errorf("server unable to unlock: %s", unlockRes.Message)
+		return lockConflictError(fmt.Errorf("server unable to unlock: %s", unlockRes.Message), status)
 	}
 
 	if err := c.cache.RemoveById(id); err != nil {
@@ -196,6 +197,44 @@ func (c *Client) UnlockFileById(id string, force bool) error {
 	return nil
 }
 
+// lockConflictError gives err the code errors.CodeLockConflict if the server
+// responded with the given status because of another user's lock.
+func lockConflictError(err error, status int) error {
+	if status == http.StatusConflict {
+		return errors.NewCodedError(err, errors.CodeLockConflict)
+	}
+	return err
+}
+
+// RenewLock extends the lease of the lock with the given ID, which must be one
+// of our own, and returns the lock with its new expiry.
+func (c *Client) RenewLock(id string) (Lock, error) {
+	renewRes, status, err := c.client.Renew(c.RemoteRef, c.Remote, id)
+	switch status {
+	case http.StatusNotFound, http.StatusNotImplemented:
+		return Lock{}, errors.NewNotImplementedError(err)
+	}
+	if err != nil {
+		return Lock{}, errors.Wrap(err, "api")
+	}
+
+	if len(renewRes.Message) > 0 {
+		if len(renewRes.RequestID) > 0 {
+			tracelog.Printf("Server Request ID: %s", renewRes.RequestID)
+		}
+		return Lock{}, fmt.Errorf("server unable to renew lock: %s", renewRes.Message)
+	}
+
+	lock := *renewRes.Lock
+	if err := c.cache.RemoveById(id); err != nil {
+		return Lock{}, errors.Wrap(err, "lock cache")
+	}
+	if err := c.cache.Add(lock); err != nil {
+		return Lock{}, errors.Wrap(err, "lock cache")
+	}
+	return lock, nil
+}
+
 // Lock is a record of a locked file
 type Lock struct {
 	// Id is the unique identifier corresponding to this particular Lock. It
@@ -208,6 +247,20 @@ type Lock struct {
 	Owner *User `json:"owner,omitempty"`
 	// LockedAt is the time at which this lock was acquired.
 	LockedAt time.Time `json:"locked_at"`
+	// ExpiresAt is the time at which the lease of this lock runs out, for
+	// servers which grant locks for a limited time. It is nil if the lock
+	// does not expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExpiresIn returns the time left before the lease of the lock runs out at the
+// given time, which is negative once the lock has expired, and whether the
+// lock expires at all.
+func (l Lock) ExpiresIn(now time.Time) (time.Duration, bool) {
+	if l.ExpiresAt == nil {
+		return 0, false
+	}
+	return l.ExpiresAt.Sub(now), true
 }
 
 // SearchLocks returns a channel of locks which match the given name/value filter
@@ -284,7 +337,7 @@ func (c *Client) SearchLocksVerifiable(limit int, cached bool) (ourLocks, theirL
 
 			if list.Message != "" {
 				if len(list.RequestID) > 0 {
-					tracerx.Printf("Server Request ID: %s", list.RequestID)
+					tracelog.Printf("Server Request ID: %s", list.RequestID)
 				}
 				return ourLocks, theirLocks, fmt.Errorf("server error searching locks: %s", list.Message)
 			}
@@ -298,7 +351,6 @@ func (c *Client) SearchLocksVerifiable(limit int, cached bool) (ourLocks, theirL
 			}
 
 			for _, l := range list.Theirs {
-				c.cache.Add(l)
 				theirLocks = append(theirLocks, l)
 				if limit > 0 && (len(ourLocks)+len(theirLocks)) >= limit {
 					return ourLocks, theirLocks, nil
@@ -322,6 +374,38 @@ func (c *Client) SearchLocksVerifiable(limit int, cached bool) (ourLocks, theirL
 	}
 }
 
+// RefreshCache replaces the locally cached locks with those the server reports
+// as verifiable, and returns the number of cached locks which the server no
+// longer holds. If the server cannot be queried, the cache is left unchanged.
+func (c *Client) RefreshCache() (int, error) {
+	cached := c.cache.Locks()
+
+	ourLocks, theirLocks, err := c.SearchLocksVerifiable(0, false)
+	if err != nil {
+		c.cache.Clear()
+		for _, l := range cached {
+			c.cache.Add(l)
+		}
+		return 0, err
+	}
+
+	current := make(map[string]bool, len(ourLocks)+len(theirLocks))
+	for _, l := range ourLocks {
+		current[l.Id] = true
+	}
+	for _, l := range theirLocks {
+		current[l.Id] = true
+	}
+
+	var removed int
+	for _, l := range cached {
+		if !current[l.Id] {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 func (c *Client) searchLocalLocks(filter map[string]string, limit int) ([]Lock, error) {
 	cachedlocks := c.cache.Locks()
 	path, filterByPath := filter["path"]
@@ -331,7 +415,8 @@ func (c *Client) searchLocalLocks(filter map[string]string, limit int) ([]Lock,
 	for _, l := range cachedlocks {
 		// Manually filter by Path/Id
 		if (filterByPath && path != l.Path) ||
-			(filterById && id != l.Id) {
+			(filterById && id != l.Id) ||
+			!lockMatchesClientFilters(l, filter) {
 			continue
 		}
 		locks = append(locks, l)
@@ -344,6 +429,24 @@ func (c *Client) searchLocalLocks(filter map[string]string, limit int) ([]Lock,
 }
 
 func (c *Client) searchRemoteLocks(filter map[string]string, limit int) ([]Lock, error) {
+	locks, _, err := c.SearchLocksFrom(filter, limit, "")
+	return locks, err
+}
+
+// SearchLocksFrom searches the server for at most "limit" locks matching
+// "filter", starting from the given "cursor", if any, as returned by a
+// previous search.  It returns the cursor from which to continue the search
+// if "limit" was reached before the server ran out of locks.  The results are
+// not cached.
+//
+// All of the filters are sent to the server, so that one which supports them
+// applies them, and "limit", before returning any locks.  Those in
+// clientLockFilters are applied again to the locks returned, as servers need
+// not support them, and "limit" counts only the locks which match them.  With
+// a server which ignores them, finding "limit" matching locks may therefore
+// take many requests, and all of the server's locks are requested if fewer
+// match.
+func (c *Client) SearchLocksFrom(filter map[string]string, limit int, cursor string) ([]Lock, string, error) {
 	locks := make([]Lock, 0, limit)
 
 	apifilters := make([]lockFilter, 0, len(filter))
@@ -354,27 +457,39 @@ func (c *Client) searchRemoteLocks(filter map[string]string, limit int) ([]Lock,
 	query := &lockSearchRequest{
 		Filters: apifilters,
 		Limit:   limit,
+		Cursor:  cursor,
 		Refspec: c.RemoteRef.Refspec(),
 	}
 
 	for {
+		// Request no more locks than are still needed, so that the
+		// cursor returned by the server resumes after the last lock
+		// returned here.
+		if limit > 0 {
+			query.Limit = limit - len(locks)
+		}
+
 		list, _, err := c.client.Search(c.Remote, query)
 		if err != nil {
-			return locks, errors.Wrap(err, "locking")
+			return locks, "", errors.Wrap(err, "locking")
 		}
 
 		if list.Message != "" {
 			if len(list.RequestID) > 0 {
-				tracerx.Printf("Server Request ID: %s", list.RequestID)
+				tracelog.Printf("Server Request ID: %s", list.RequestID)
 			}
-			return locks, fmt.Errorf("server error searching for locks: %s", list.Message)
+			return locks, "", fmt.Errorf("server error searching for locks: %s", list.Message)
 		}
 
 		for _, l := range list.Locks {
+			if !lockMatchesClientFilters(l, filter) {
+				continue
+			}
+
 			locks = append(locks, l)
 			if limit > 0 && len(locks) >= limit {
 				// Exit outer loop too
-				return locks, nil
+				return locks, list.NextCursor, nil
 			}
 		}
 
@@ -385,7 +500,31 @@ func (c *Client) searchRemoteLocks(filter map[string]string, limit int) ([]Lock,
 		}
 	}
 
-	return locks, nil
+	return locks, "", nil
+}
+
+// clientLockFilters are the filters which are not part of the locks API, and
+// which are also applied to the locks returned by the server, in case it does
+// not support them.
+var clientLockFilters = map[string]func(l Lock, value string) bool{
+	// owner matches locks held by the user with the given name.
+	"owner": func(l Lock, value string) bool {
+		return l.Owner != nil && l.Owner.Name == value
+	},
+	// path_prefix matches locks on the given path or any path beneath it.
+	"path_prefix": func(l Lock, value string) bool {
+		value = strings.TrimSuffix(value, "/")
+		return l.Path == value || strings.HasPrefix(l.Path, value+"/")
+	},
+}
+
+func lockMatchesClientFilters(l Lock, filter map[string]string) bool {
+	for k, v := range filter {
+		if matches, ok := clientLockFilters[k]; ok && !matches(l, v) {
+			return false
+		}
+	}
+	return true
 }
 
 // lockIdFromPath makes a call to the LFS API and resolves the ID for the locked
@@ -424,7 +563,7 @@ func (c *Client) IsFileLockedByCurrentCommitter(path string) bool {
 	filter := map[string]string{"path": path}
 	locks, err := c.searchLocalLocks(filter, 1)
 	if err != nil {
-		tracerx.Printf("Error searching cached locks: %s\nForcing remote search", err)
+		tracelog.Printf("Error searching cached locks: %s\nForcing remote search", err)
 		locks, _ = c.searchRemoteLocks(filter, 1)
 	}
 	return len(locks) > 0
@@ -129,6 +129,75 @@ func TestRemoteLocksWithCache(t *testing.T) {
 	assert.Equal(t, expectedLocks, locks)
 }
 
+func TestSearchLocksFrom(t *testing.T) {
+	all := []Lock{
+		Lock{Id: "100", Path: "folder/test1.dat", Owner: &User{Name: "Alice"}},
+		Lock{Id: "101", Path: "folder/test2.dat", Owner: &User{Name: "Charles"}},
+		Lock{Id: "102", Path: "folder/sub/test3.dat", Owner: &User{Name: "Alice"}},
+		Lock{Id: "103", Path: "folderish.dat", Owner: &User{Name: "Alice"}},
+		Lock{Id: "104", Path: "folder/test5.dat", Owner: &User{Name: "Alice"}},
+		Lock{Id: "105", Path: "folder/test6.dat", Owner: &User{Name: "Alice"}},
+	}
+
+	var limits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/locks", r.URL.Path)
+		// The filters are sent, but this server ignores them.
+		assert.Equal(t, "Alice", r.URL.Query().Get("owner"))
+		assert.Equal(t, "folder/", r.URL.Query().Get("path_prefix"))
+		limits = append(limits, r.URL.Query().Get("limit"))
+
+		// Return pages of at most two locks, each cursor naming the
+		// first lock of the next page.
+		start := 0
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			for i, l := range all {
+				if l.Id == cursor {
+					start = i
+				}
+			}
+		}
+		end := start + 2
+		if end > len(all) {
+			end = len(all)
+		}
+		list := &lockList{Locks: all[start:end]}
+		if end < len(all) {
+			list.NextCursor = all[end].Id
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.Nil(t, json.NewEncoder(w).Encode(list))
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	client.RemoteRef = &git.Ref{Name: "refs/heads/master"}
+
+	filter := map[string]string{"owner": "Alice", "path_prefix": "folder/"}
+	locks, cursor, err := client.SearchLocksFrom(filter, 2, "")
+	assert.Nil(t, err)
+	require.Len(t, locks, 2)
+	assert.Equal(t, "100", locks[0].Id)
+	assert.Equal(t, "102", locks[1].Id)
+	assert.Equal(t, "104", cursor)
+	assert.Equal(t, []string{"2", "1"}, limits)
+
+	locks, cursor, err = client.SearchLocksFrom(filter, 0, cursor)
+	assert.Nil(t, err)
+	require.Len(t, locks, 2)
+	assert.Equal(t, "104", locks[0].Id)
+	assert.Equal(t, "105", locks[1].Id)
+	assert.Empty(t, cursor)
+}
+
 func TestRefreshCache(t *testing.T) {
 	var err error
 	tempDir, err := ioutil.TempDir("", "testCacheLock")
@@ -188,9 +257,67 @@ func TestRefreshCache(t *testing.T) {
 		Lock{Path: "folder/test1.dat", Id: "101", Owner: &User{Name: "Fred"}, LockedAt: zeroTime},
 		Lock{Path: "folder/test2.dat", Id: "102", Owner: &User{Name: "Fred"}, LockedAt: zeroTime},
 		Lock{Path: "root.dat", Id: "103", Owner: &User{Name: "Fred"}, LockedAt: zeroTime},
-		Lock{Path: "other/test1.dat", Id: "199", Owner: &User{Name: "Charles"}, LockedAt: zeroTime},
-		Lock{Path: "folder/test3.dat", Id: "99", Owner: &User{Name: "Alice"}, LockedAt: zeroTime},
 	}, locks)
+
+	// Locks held by others must not be mistaken for our own
+	assert.False(t, client.IsFileLockedByCurrentCommitter("folder/test3.dat"))
+	assert.True(t, client.IsFileLockedByCurrentCommitter("folder/test1.dat"))
+}
+
+func TestRefreshCacheRemovesStaleLocks(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testCacheLock")
+	assert.Nil(t, err)
+
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/locks/verify", r.URL.Path)
+		if fail {
+			w.WriteHeader(500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.Nil(t, json.NewEncoder(w).Encode(lockVerifiableList{
+			Ours: []Lock{
+				Lock{Id: "101", Path: "folder/test1.dat"},
+			},
+		}))
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":    srv.URL + "/api",
+		"user.name":  "Fred",
+		"user.email": "fred@bloggs.com",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	assert.Nil(t, err)
+	assert.Nil(t, client.SetupFileCache(tempDir))
+
+	client.cache.Add(Lock{Id: "101", Path: "folder/test1.dat"})
+	client.cache.Add(Lock{Id: "102", Path: "folder/test2.dat"})
+
+	removed, err := client.RefreshCache()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, removed)
+
+	locks, err := client.SearchLocks(nil, 0, true, false)
+	assert.Nil(t, err)
+	if assert.Len(t, locks, 1) {
+		assert.Equal(t, "101", locks[0].Id)
+	}
+
+	// The cache is kept if the server cannot be queried.
+	fail = true
+	removed, err = client.RefreshCache()
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, removed)
+
+	locks, err = client.SearchLocks(nil, 0, true, false)
+	assert.Nil(t, err)
+	assert.Len(t, locks, 1)
 }
 
 func TestSearchLocksVerifiableWithCache(t *testing.T) {
@@ -314,3 +441,19 @@ func TestSearchLocksVerifiableWithCache(t *testing.T) {
 	sort.Sort(LocksById(theirLocks))
 	assert.Equal(t, expectedTheirLocks, theirLocks)
 }
+
+func TestLockExpiresIn(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, ok := Lock{Id: "1"}.ExpiresIn(now)
+	assert.False(t, ok)
+
+	expiresAt := now.Add(90 * time.Minute)
+	left, ok := Lock{Id: "2", ExpiresAt: &expiresAt}.ExpiresIn(now)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Minute, left)
+
+	left, ok = Lock{Id: "2", ExpiresAt: &expiresAt}.ExpiresIn(now.Add(2 * time.Hour))
+	assert.True(t, ok)
+	assert.True(t, left < 0)
+}
@@ -0,0 +1,55 @@
+package locking_test // to avoid import cycles
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	. "github.com/git-lfs/git-lfs/locking"
+	test "github.com/git-lfs/git-lfs/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockServerLocks(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL,
+	}))
+	require.Nil(t, err)
+	lc, err := NewClient("origin", cli, repo.Configuration())
+	require.Nil(t, err)
+	defer lc.Close()
+
+	theirs := srv.AddLock("theirs.dat", "Someone Else")
+
+	lock, err := lc.LockFile("ours.dat")
+	require.Nil(t, err)
+	assert.Equal(t, "ours.dat", lock.Path)
+	assert.Equal(t, "Git LFS Tests", lock.Owner.Name)
+
+	_, err = lc.LockFile("theirs.dat")
+	require.NotNil(t, err)
+	assert.Equal(t, errors.CodeLockConflict, errors.ErrorCode(err))
+
+	ours, their, err := lc.SearchLocksVerifiable(0, false)
+	require.Nil(t, err)
+	assert.Equal(t, []Lock{lock}, ours)
+	assert.Equal(t, []Lock{theirs}, their)
+
+	srv.Fail(test.MockUnlock, 1, http.StatusInternalServerError, "try again")
+	assert.NotNil(t, lc.UnlockFileById(lock.Id, false))
+	require.Nil(t, lc.UnlockFileById(lock.Id, false))
+	assert.Equal(t, []Lock{theirs}, srv.Locks())
+	assert.Equal(t, 2, srv.Requests(test.MockUnlock))
+}
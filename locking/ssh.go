@@ -2,6 +2,7 @@ package locking
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -43,6 +44,12 @@ func (c *sshLockClient) parseLockResponse(status int, args []string, lines []str
 					return lock, "", fmt.Errorf("lock response: invalid locked-at: %s", entry)
 				}
 				seen["locked-at"] = struct{}{}
+			} else if strings.HasPrefix(entry, "expires-at=") {
+				expiresAt, err := time.Parse(time.RFC3339, entry[11:])
+				if err != nil {
+					return lock, "", fmt.Errorf("lock response: invalid expires-at: %s", entry)
+				}
+				lock.ExpiresAt = &expiresAt
 			}
 		}
 		if len(seen) != 4 {
@@ -118,6 +125,12 @@ func (c *sshLockClient) parseListLockResponse(status int, args []string, lines [
 					if err != nil {
 						return nil, nil, nil, "", "", fmt.Errorf("lock response: invalid locked-at: %s", entry)
 					}
+				case "expires-at":
+					expiresAt, err := time.Parse(time.RFC3339, values[2])
+					if err != nil {
+						return nil, nil, nil, "", "", fmt.Errorf("lock response: invalid expires-at: %s", entry)
+					}
+					last.lock.ExpiresAt = &expiresAt
 				}
 			}
 		}
@@ -183,6 +196,12 @@ func (c *sshLockClient) Unlock(ref *git.Ref, remote, id string, force bool) (*un
 	return &lock, status, err
 }
 
+// Renew is not part of the SSH protocol, so leases of locks taken over SSH
+// can't be renewed.
+func (c *sshLockClient) Renew(ref *git.Ref, remote, id string) (*lockResponse, int, error) {
+	return nil, http.StatusNotImplemented, fmt.Errorf("lock renewal is not supported over SSH")
+}
+
 func (c *sshLockClient) Search(remote string, searchReq *lockSearchRequest) (*lockList, int, error) {
 	values := searchReq.QueryValues()
 	args := make([]string, 0, len(values))
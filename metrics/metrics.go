@@ -0,0 +1,125 @@
+// Package metrics emits counters and timings of the work Git LFS does, such as
+// batch API requests, transfers, retries and cache hits, to monitoring systems
+// which are enabled by configuration, so that the behavior of many clients can
+// be monitored together.  Without any Exporter, emitting a metric does nothing.
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// Tags are the names and values of the dimensions of a metric, such as the
+// direction of a transfer.
+type Tags map[string]string
+
+// Exporter sends metrics to a monitoring system.  Its methods may be called
+// from many goroutines at once.
+type Exporter interface {
+	// Count adds value to the counter with the given name and tags.
+	Count(name string, value int64, tags Tags)
+	// Timing records the duration of an operation with the given name and
+	// tags.
+	Timing(name string, d time.Duration, tags Tags)
+	// Flush sends any metrics which have been held back.
+	Flush() error
+}
+
+var (
+	mu        sync.Mutex
+	setupFn   func() []Exporter
+	exporters []Exporter
+	ready     bool
+)
+
+// Setup sets the function which returns the exporters metrics are sent to.  It
+// is called when the first metric is emitted, so that commands which emit no
+// metrics do not read the configuration of the exporters.
+func Setup(fn func() []Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	setupFn = fn
+	exporters = nil
+	ready = false
+}
+
+// Count adds value to the counter with the given name and tags.
+func Count(name string, value int64, tags Tags) {
+	for _, e := range current() {
+		e.Count(name, value, tags)
+	}
+}
+
+// Timing records the duration of an operation with the given name and tags.
+func Timing(name string, d time.Duration, tags Tags) {
+	for _, e := range current() {
+		e.Timing(name, d, tags)
+	}
+}
+
+// Since records the time since start as the duration of an operation with the
+// given name and tags.
+func Since(name string, start time.Time, tags Tags) {
+	Timing(name, time.Since(start), tags)
+}
+
+// Flush sends any metrics held back by the exporters, which should be done
+// before Git LFS exits.  Errors are traced, rather than returned, since the
+// failure to send metrics should not fail a command.
+func Flush() {
+	mu.Lock()
+	exps := exporters
+	mu.Unlock()
+
+	for _, e := range exps {
+		if err := e.Flush(); err != nil {
+			tracelog.Printf("metrics: unable to send metrics: %s", err)
+		}
+	}
+}
+
+func current() []Exporter {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !ready {
+		ready = true
+		if setupFn != nil {
+			exporters = setupFn()
+		}
+	}
+	return exporters
+}
+
+// merge returns the tags of both sets, preferring those of b.
+func merge(a, b Tags) Tags {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+
+	tags := make(Tags, len(a)+len(b))
+	for k, v := range a {
+		tags[k] = v
+	}
+	for k, v := range b {
+		tags[k] = v
+	}
+	return tags
+}
+
+// keys returns the names of the given tags in order.
+func (t Tags) keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingExporter struct {
+	mu      sync.Mutex
+	records []string
+	flushes int
+}
+
+func (e *recordingExporter) Count(name string, value int64, tags Tags) {
+	e.record("count %s %d %v", name, value, tags)
+}
+
+func (e *recordingExporter) Timing(name string, d time.Duration, tags Tags) {
+	e.record("timing %s %s %v", name, d, tags)
+}
+
+func (e *recordingExporter) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.flushes++
+	return nil
+}
+
+func (e *recordingExporter) record(format string, args ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.records = append(e.records, fmt.Sprintf(format, args...))
+}
+
+func TestMetricsWithoutSetup(t *testing.T) {
+	Setup(nil)
+
+	Count("ignored", 1, nil)
+	Timing("ignored", time.Second, nil)
+	Flush()
+}
+
+func TestSetupIsCalledOnFirstMetric(t *testing.T) {
+	defer Setup(nil)
+
+	e := &recordingExporter{}
+	calls := 0
+	Setup(func() []Exporter {
+		calls++
+		return []Exporter{e}
+	})
+
+	Flush()
+	assert.Equal(t, 0, calls)
+
+	Count("batch.requests", 2, Tags{"operation": "upload"})
+	Timing("batch.duration", time.Second, nil)
+	Flush()
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{
+		"count batch.requests 2 map[operation:upload]",
+		"timing batch.duration 1s map[]",
+	}, e.records)
+	assert.Equal(t, 1, e.flushes)
+}
+
+func TestMerge(t *testing.T) {
+	a := Tags{"command": "push", "direction": "download"}
+	b := Tags{"direction": "upload"}
+
+	assert.Equal(t, Tags{"command": "push", "direction": "upload"}, merge(a, b))
+	assert.Equal(t, a, merge(a, nil))
+	assert.Equal(t, b, merge(nil, b))
+}
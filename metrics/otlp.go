@@ -0,0 +1,295 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// otlpExporter aggregates metrics in memory, and sends them when flushed to
+// an OpenTelemetry collector, using the JSON encoding of OTLP over HTTP.
+// Counters are sent as monotonic sums, and timings as histograms in
+// milliseconds, each with the delta since the previous flush.
+type otlpExporter struct {
+	url    string
+	prefix string
+	tags   Tags
+	client *http.Client
+
+	mu       sync.Mutex
+	start    time.Time
+	counters map[string]*otlpCounter
+	timings  map[string]*otlpTiming
+}
+
+type otlpCounter struct {
+	name  string
+	tags  Tags
+	value int64
+}
+
+type otlpTiming struct {
+	name     string
+	tags     Tags
+	count    int64
+	sum      float64
+	min, max float64
+}
+
+// NewOTLPExporter returns an Exporter sending metrics to the OTLP/HTTP metrics
+// endpoint at the given URL, such as "http://localhost:4318/v1/metrics",
+// prefixing their names with prefix and a dot, if it is not empty, and giving
+// them the given tags as well as their own.
+func NewOTLPExporter(url, prefix string, tags Tags) Exporter {
+	return &otlpExporter{
+		url:      url,
+		prefix:   prefix,
+		tags:     tags,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		start:    time.Now(),
+		counters: make(map[string]*otlpCounter),
+		timings:  make(map[string]*otlpTiming),
+	}
+}
+
+func (e *otlpExporter) Count(name string, value int64, tags Tags) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := otlpKey(name, tags)
+	c, ok := e.counters[key]
+	if !ok {
+		c = &otlpCounter{name: name, tags: tags}
+		e.counters[key] = c
+	}
+	c.value += value
+}
+
+func (e *otlpExporter) Timing(name string, d time.Duration, tags Tags) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ms := float64(d) / float64(time.Millisecond)
+	key := otlpKey(name, tags)
+	t, ok := e.timings[key]
+	if !ok {
+		t = &otlpTiming{name: name, tags: tags, min: ms, max: ms}
+		e.timings[key] = t
+	}
+	t.count++
+	t.sum += ms
+	if ms < t.min {
+		t.min = ms
+	}
+	if ms > t.max {
+		t.max = ms
+	}
+}
+
+// Flush sends the metrics emitted since the last flush, if there are any.
+func (e *otlpExporter) Flush() error {
+	e.mu.Lock()
+	if len(e.counters) == 0 && len(e.timings) == 0 {
+		e.mu.Unlock()
+		return nil
+	}
+	body := e.request(time.Now())
+	e.counters = make(map[string]*otlpCounter)
+	e.timings = make(map[string]*otlpTiming)
+	e.mu.Unlock()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.client.Post(e.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%s: HTTP %d", e.url, res.StatusCode)
+	}
+	return nil
+}
+
+// request returns the body of the export request for the held metrics, which
+// are the deltas from e.start to now.  It must be called with e.mu held.
+func (e *otlpExporter) request(now time.Time) *otlpRequest {
+	start := strconv.FormatInt(e.start.UnixNano(), 10)
+	end := strconv.FormatInt(now.UnixNano(), 10)
+	e.start = now
+
+	counterKeys := make([]string, 0, len(e.counters))
+	for key := range e.counters {
+		counterKeys = append(counterKeys, key)
+	}
+	sort.Strings(counterKeys)
+
+	timingKeys := make([]string, 0, len(e.timings))
+	for key := range e.timings {
+		timingKeys = append(timingKeys, key)
+	}
+	sort.Strings(timingKeys)
+
+	var metrics []*otlpMetric
+	for _, key := range counterKeys {
+		c := e.counters[key]
+		metrics = append(metrics, &otlpMetric{
+			Name: e.name(c.name),
+			Unit: "1",
+			Sum: &otlpSum{
+				AggregationTemporality: otlpDelta,
+				IsMonotonic:            true,
+				DataPoints: []*otlpDataPoint{{
+					Attributes:        otlpAttributes(merge(e.tags, c.tags)),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      end,
+					AsInt:             strconv.FormatInt(c.value, 10),
+				}},
+			},
+		})
+	}
+	for _, key := range timingKeys {
+		t := e.timings[key]
+		metrics = append(metrics, &otlpMetric{
+			Name: e.name(t.name),
+			Unit: "ms",
+			Histogram: &otlpHistogram{
+				AggregationTemporality: otlpDelta,
+				DataPoints: []*otlpDataPoint{{
+					Attributes:        otlpAttributes(merge(e.tags, t.tags)),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      end,
+					Count:             strconv.FormatInt(t.count, 10),
+					Sum:               &t.sum,
+					Min:               &t.min,
+					Max:               &t.max,
+					BucketCounts:      []string{strconv.FormatInt(t.count, 10)},
+				}},
+			},
+		})
+	}
+
+	return &otlpRequest{
+		ResourceMetrics: []*otlpResourceMetrics{{
+			Resource: &otlpResource{
+				Attributes: otlpAttributes(Tags{"service.name": "git-lfs"}),
+			},
+			ScopeMetrics: []*otlpScopeMetrics{{
+				Scope:   &otlpScope{Name: "git-lfs"},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+func (e *otlpExporter) name(name string) string {
+	if len(e.prefix) == 0 {
+		return name
+	}
+	return e.prefix + "." + name
+}
+
+// otlpKey returns the key of the metric with the given name and tags, under
+// which it is aggregated.
+func otlpKey(name string, tags Tags) string {
+	var key strings.Builder
+	key.WriteString(name)
+	for _, k := range tags.keys() {
+		fmt.Fprintf(&key, "\x00%s=%s", k, tags[k])
+	}
+	return key.String()
+}
+
+// The types below are the parts of the JSON encoding of an OTLP metrics export
+// request which are used.  64-bit integers are encoded as strings.
+
+// otlpDelta is the aggregation temporality of values which are the changes
+// since the previous export.
+const otlpDelta = 1
+
+type otlpRequest struct {
+	ResourceMetrics []*otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     *otlpResource       `json:"resource"`
+	ScopeMetrics []*otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []*otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   *otlpScope    `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int              `json:"aggregationTemporality"`
+	IsMonotonic            bool             `json:"isMonotonic"`
+	DataPoints             []*otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int              `json:"aggregationTemporality"`
+	DataPoints             []*otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []*otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	TimeUnixNano      string           `json:"timeUnixNano"`
+
+	// AsInt is the value of a sum.
+	AsInt string `json:"asInt,omitempty"`
+
+	// The rest are the values of a histogram, which has a single bucket.
+	Count          string    `json:"count,omitempty"`
+	Sum            *float64  `json:"sum,omitempty"`
+	Min            *float64  `json:"min,omitempty"`
+	Max            *float64  `json:"max,omitempty"`
+	BucketCounts   []string  `json:"bucketCounts,omitempty"`
+	ExplicitBounds []float64 `json:"explicitBounds,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func otlpAttributes(tags Tags) []*otlpAttribute {
+	attrs := make([]*otlpAttribute, 0, len(tags))
+	for _, k := range tags.keys() {
+		attrs = append(attrs, &otlpAttribute{
+			Key:   k,
+			Value: otlpAttributeValue{StringValue: tags[k]},
+		})
+	}
+	return attrs
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var requests []*otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		req := &otlpRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(req))
+		requests = append(requests, req)
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(srv.URL+"/v1/metrics", "git_lfs", Tags{"command": "push"})
+
+	// Nothing is sent until there are metrics.
+	require.Nil(t, e.Flush())
+	require.Len(t, requests, 0)
+
+	e.Count("transfer.objects", 2, Tags{"direction": "upload"})
+	e.Count("transfer.objects", 3, Tags{"direction": "upload"})
+	e.Timing("transfer.duration", 2*time.Millisecond, Tags{"direction": "upload"})
+	e.Timing("transfer.duration", 4*time.Millisecond, Tags{"direction": "upload"})
+	require.Nil(t, e.Flush())
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceMetrics, 1)
+	rm := requests[0].ResourceMetrics[0]
+	assert.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	assert.Equal(t, "git-lfs", rm.Resource.Attributes[0].Value.StringValue)
+
+	metrics := rm.ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 2)
+
+	sum := metrics[0]
+	assert.Equal(t, "git_lfs.transfer.objects", sum.Name)
+	require.NotNil(t, sum.Sum)
+	assert.True(t, sum.Sum.IsMonotonic)
+	assert.Equal(t, otlpDelta, sum.Sum.AggregationTemporality)
+	assert.Equal(t, "5", sum.Sum.DataPoints[0].AsInt)
+	assert.Equal(t, []*otlpAttribute{
+		{Key: "command", Value: otlpAttributeValue{StringValue: "push"}},
+		{Key: "direction", Value: otlpAttributeValue{StringValue: "upload"}},
+	}, sum.Sum.DataPoints[0].Attributes)
+
+	hist := metrics[1]
+	assert.Equal(t, "git_lfs.transfer.duration", hist.Name)
+	assert.Equal(t, "ms", hist.Unit)
+	require.NotNil(t, hist.Histogram)
+	dp := hist.Histogram.DataPoints[0]
+	assert.Equal(t, "2", dp.Count)
+	assert.Equal(t, 6.0, *dp.Sum)
+	assert.Equal(t, 2.0, *dp.Min)
+	assert.Equal(t, 4.0, *dp.Max)
+	assert.Equal(t, []string{"2"}, dp.BucketCounts)
+
+	// Each export holds the changes since the previous one.
+	e.Count("transfer.objects", 1, Tags{"direction": "upload"})
+	require.Nil(t, e.Flush())
+	require.Len(t, requests, 2)
+	next := requests[1].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, next, 1)
+	assert.Equal(t, "1", next[0].Sum.DataPoints[0].AsInt)
+	assert.Equal(t, dp.TimeUnixNano, next[0].Sum.DataPoints[0].StartTimeUnixNano)
+}
+
+func TestOTLPExporterReportsHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(srv.URL, "", nil)
+	e.Count("batch.requests", 1, nil)
+
+	err := e.Flush()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "HTTP 500")
+}
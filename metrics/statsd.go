@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// statsdExporter sends each metric as soon as it is emitted, as a UDP packet
+// in the statsd line format, with tags in the DogStatsD extension of it, which
+// is also understood by Telegraf and the StatsD exporter of Prometheus.
+type statsdExporter struct {
+	conn   net.Conn
+	prefix string
+	tags   Tags
+}
+
+// NewStatsdExporter returns an Exporter sending metrics to the statsd server at
+// the given "host:port" address, prefixing their names with prefix and a dot,
+// if it is not empty, and giving them the given tags as well as their own.
+func NewStatsdExporter(addr, prefix string, tags Tags) (Exporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &statsdExporter{conn: conn, prefix: prefix, tags: tags}, nil
+}
+
+func (e *statsdExporter) Count(name string, value int64, tags Tags) {
+	e.send(name, fmt.Sprintf("%d|c", value), tags)
+}
+
+func (e *statsdExporter) Timing(name string, d time.Duration, tags Tags) {
+	e.send(name, fmt.Sprintf("%.3f|ms", float64(d)/float64(time.Millisecond)), tags)
+}
+
+// Flush does nothing, since metrics are sent as they are emitted.
+func (e *statsdExporter) Flush() error {
+	return nil
+}
+
+func (e *statsdExporter) send(name, value string, tags Tags) {
+	var line strings.Builder
+	if len(e.prefix) > 0 {
+		line.WriteString(e.prefix)
+		line.WriteByte('.')
+	}
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+
+	tags = merge(e.tags, tags)
+	for i, k := range tags.keys() {
+		if i == 0 {
+			line.WriteString("|#")
+		} else {
+			line.WriteByte(',')
+		}
+		line.WriteString(statsdEscape(k))
+		line.WriteByte(':')
+		line.WriteString(statsdEscape(tags[k]))
+	}
+
+	// Metrics are sent on a best effort basis; a statsd server which is
+	// down must not slow or fail the work being measured.
+	e.conn.Write([]byte(line.String()))
+}
+
+// statsdEscape replaces the characters which separate the fields of a statsd
+// line.
+func statsdEscape(s string) string {
+	return strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "\n", "_").Replace(s)
+}
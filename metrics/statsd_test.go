@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsdExporter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	e, err := NewStatsdExporter(conn.LocalAddr().String(), "git_lfs", Tags{"command": "fetch"})
+	require.Nil(t, err)
+
+	e.Count("batch.requests", 1, Tags{"operation": "download", "status": "ok"})
+	e.Timing("batch.duration", 1500*time.Microsecond, nil)
+	e.Count("weird", 1, Tags{"a:b": "c|d,e"})
+
+	buf := make([]byte, 1024)
+	var packets []string
+	for i := 0; i < 3; i++ {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.Nil(t, err)
+		packets = append(packets, string(buf[:n]))
+	}
+
+	assert.Equal(t, []string{
+		"git_lfs.batch.requests:1|c|#command:fetch,operation:download,status:ok",
+		"git_lfs.batch.duration:1.500|ms|#command:fetch",
+		"git_lfs.weird:1|c|#a_b:c_d_e,command:fetch",
+	}, packets)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	verbose = flag.Bool("verbose", false, "Show verbose output.")
+)
+
+func infof(w io.Writer, format string, a ...interface{}) {
+	if !*verbose {
+		return
+	}
+	fmt.Fprintf(w, format, a...)
+}
+
+func warnf(w io.Writer, format string, a ...interface{}) {
+	fmt.Fprintf(w, format, a...)
+}
+
+// entry is a message of a .po file, with its translations.
+type entry struct {
+	msgid  string
+	plural string
+	msgstr map[int]*string
+	fuzzy  bool
+}
+
+// translations returns the translations of the entry in the order of their
+// plural forms.
+func (e *entry) translations() []string {
+	strs := make([]string, 0, len(e.msgstr))
+	for i := 0; i < len(e.msgstr); i++ {
+		if s, ok := e.msgstr[i]; ok {
+			strs = append(strs, *s)
+		} else {
+			strs = append(strs, "")
+		}
+	}
+	return strs
+}
+
+// translated returns whether the entry should be included in the catalog: it
+// is not the header, is not marked fuzzy, and has a translation.
+func (e *entry) translated() bool {
+	if len(e.msgid) == 0 || e.fuzzy {
+		return false
+	}
+	for _, s := range e.msgstr {
+		if len(*s) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// readPo reads the entries of the given .po file, in the subset of the
+// gettext format written by xgettext and msgmerge which Git LFS uses.
+func readPo(path string) ([]*entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*entry
+	var cur *entry
+	var field *string
+	fuzzy := false
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(line) == 0:
+			field = nil
+			continue
+		case strings.HasPrefix(line, "#,"):
+			fuzzy = fuzzy || strings.Contains(line, "fuzzy")
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("%s:%d: unexpected string", path, n)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+			}
+			*field += s
+			continue
+		}
+
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: malformed line", path, n)
+		}
+		keyword := line[:i]
+		s, err := strconv.Unquote(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+
+		switch {
+		case keyword == "msgid":
+			cur = &entry{msgid: s, msgstr: make(map[int]*string), fuzzy: fuzzy}
+			entries = append(entries, cur)
+			field = &cur.msgid
+			fuzzy = false
+			continue
+		case cur == nil:
+			return nil, fmt.Errorf("%s:%d: %s before msgid", path, n, keyword)
+		case keyword == "msgid_plural":
+			cur.plural = s
+			field = &cur.plural
+			continue
+		}
+
+		form := 0
+		if keyword != "msgstr" {
+			if !strings.HasPrefix(keyword, "msgstr[") || !strings.HasSuffix(keyword, "]") {
+				return nil, fmt.Errorf("%s:%d: unknown keyword %q", path, n, keyword)
+			}
+			form, err = strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+			}
+		}
+		cur.msgstr[form] = &s
+		field = &s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Reads all .po files and converts their translations to a map literal,
+// triggered by the "go generate" comment in the tr package.
+func main() {
+	flag.Parse()
+
+	infof(os.Stderr, "Converting message catalogs into code...\n")
+	rootDir := ".."
+	paths, err := filepath.Glob(filepath.Join(rootDir, "po", "*.po"))
+	if err != nil || len(paths) == 0 {
+		warnf(os.Stderr, "Failed to find message catalogs: %v\n", err)
+		os.Exit(2)
+	}
+	sort.Strings(paths)
+
+	out := &bytes.Buffer{}
+	out.WriteString("package tr\n\n")
+	out.WriteString("// THIS FILE IS GENERATED, DO NOT EDIT\n")
+	out.WriteString("// Use 'go generate ./tr' to update\n\n")
+	out.WriteString("var catalogs = map[string]map[string][]string{\n")
+	for _, path := range paths {
+		infof(os.Stderr, "%v\n", filepath.Base(path))
+		entries, err := readPo(path)
+		if err != nil {
+			warnf(os.Stderr, "Failed to read %v: %v\n", path, err)
+			os.Exit(2)
+		}
+
+		language := strings.TrimSuffix(filepath.Base(path), ".po")
+		fmt.Fprintf(out, "\t%q: {\n", language)
+		for _, e := range entries {
+			if !e.translated() {
+				continue
+			}
+			fmt.Fprintf(out, "\t\t%q: {", e.msgid)
+			for i, s := range e.translations() {
+				if i > 0 {
+					out.WriteString(", ")
+				}
+				fmt.Fprintf(out, "%q", s)
+			}
+			out.WriteString("},\n")
+		}
+		out.WriteString("\t},\n")
+	}
+	out.WriteString("}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		warnf(os.Stderr, "Failed to format go file: %v\n", err)
+		os.Exit(2)
+	}
+	if err := ioutil.WriteFile(filepath.Join(rootDir, "tr", "tr_gen.go"), src, 0644); err != nil {
+		warnf(os.Stderr, "Failed to create go file: %v\n", err)
+		os.Exit(2)
+	}
+	infof(os.Stderr, "Successfully processed %d message catalogs.\n", len(paths))
+}
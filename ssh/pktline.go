@@ -3,8 +3,8 @@ package ssh
 import (
 	"io"
 
+	"github.com/git-lfs/git-lfs/tracelog"
 	"github.com/git-lfs/pktline"
-	"github.com/rubyist/tracerx"
 )
 
 func pktlineReader(p Pktline) io.Reader {
@@ -37,9 +37,9 @@ func (tp *TraceablePktline) ReadPacketList() ([]string, error) {
 			return nil, err
 		}
 		if pktLen <= 1 {
-			tracerx.Printf("packet %02x < %04x", tp.id, pktLen)
+			tracelog.Printf("packet %02x < %04x", tp.id, pktLen)
 		} else {
-			tracerx.Printf("packet %02x < %s", tp.id, data)
+			tracelog.Printf("packet %02x < %s", tp.id, data)
 		}
 
 		if pktLen == 0 {
@@ -59,9 +59,9 @@ func (tp *TraceablePktline) ReadPacketTextWithLength() (string, int, error) {
 	}
 
 	if pktLen <= 1 {
-		tracerx.Printf("packet %02x < %04x", tp.id, pktLen)
+		tracelog.Printf("packet %02x < %04x", tp.id, pktLen)
 	} else {
-		tracerx.Printf("packet %02x < %s", tp.id, s)
+		tracelog.Printf("packet %02x < %s", tp.id, s)
 	}
 	return s, pktLen, nil
 }
@@ -72,16 +72,16 @@ func (tp *TraceablePktline) WritePacket(b []byte) error {
 }
 
 func (tp *TraceablePktline) WritePacketText(s string) error {
-	tracerx.Printf("packet %02x > %s", tp.id, s)
+	tracelog.Printf("packet %02x > %s", tp.id, s)
 	return tp.pl.WritePacketText(s)
 }
 
 func (tp *TraceablePktline) WriteDelim() error {
-	tracerx.Printf("packet %02x > 0001", tp.id)
+	tracelog.Printf("packet %02x > 0001", tp.id)
 	return tp.pl.WriteDelim()
 }
 
 func (tp *TraceablePktline) WriteFlush() error {
-	tracerx.Printf("packet %02x > 0000", tp.id)
+	tracelog.Printf("packet %02x > 0000", tp.id)
 	return tp.pl.WriteFlush()
 }
@@ -12,7 +12,7 @@ import (
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/subprocess"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type sshVariant string
@@ -42,7 +42,7 @@ func GetLFSExeAndArgs(osEnv config.Environment, gitEnv config.Environment, meta
 	exe, args, needShell := GetExeAndArgs(osEnv, gitEnv, meta, multiplexDesired)
 	args = append(args, fmt.Sprintf("%s %s %s", command, meta.Path, operation))
 	exe, args = FormatArgs(exe, args, needShell)
-	tracerx.Printf("run_command: %s %s", exe, strings.Join(args, " "))
+	tracelog.Printf("run_command: %s %s", exe, strings.Join(args, " "))
 	return exe, args
 }
 
@@ -150,9 +150,15 @@ func GetExeAndArgs(osEnv config.Environment, gitEnv config.Environment, meta *SS
 
 	args := make([]string, 0, 7)
 
-	if variant == variantTortoise {
-		// TortoisePlink requires the -batch argument to behave like ssh/plink
+	nonInteractive := osEnv.Bool(config.NonInteractiveEnv, false)
+	if variant == variantTortoise || (variant == variantPutty && nonInteractive) {
+		// TortoisePlink requires the -batch argument to behave like
+		// ssh/plink, and it stops plink from prompting.
 		args = append(args, "-batch")
+	} else if variant == variantSSH && nonInteractive {
+		// Fail rather than prompt for passwords, passphrases or unknown
+		// host keys.
+		args = append(args, "-oBatchMode=yes")
 	}
 
 	multiplexEnabled := gitEnv.Bool("lfs.ssh.automultiplex", true)
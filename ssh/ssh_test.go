@@ -159,6 +159,40 @@ func TestSSHGetExeAndArgsPlinkCustomPortExplicitEnvironmentSsh(t *testing.T) {
 	assert.Equal(t, []string{"-p", "8888", "--", "user@foo.com"}, args)
 }
 
+func TestSSHGetExeAndArgsSshNonInteractive(t *testing.T) {
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND":              "",
+		"GIT_SSH":                      "",
+		"GIT_LFS_FORCE_NONINTERACTIVE": "1",
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+
+	exe, args := ssh.FormatArgs(ssh.GetExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, false))
+	assert.Equal(t, "ssh", exe)
+	assert.Equal(t, []string{"-oBatchMode=yes", "--", "user@foo.com"}, args)
+}
+
+func TestSSHGetExeAndArgsPlinkNonInteractive(t *testing.T) {
+	plink := filepath.Join("Users", "joebloggs", "bin", "plink.exe")
+
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND":              "",
+		"GIT_SSH":                      plink,
+		"GIT_LFS_FORCE_NONINTERACTIVE": "1",
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+
+	exe, args := ssh.FormatArgs(ssh.GetExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, false))
+	assert.Equal(t, plink, exe)
+	assert.Equal(t, []string{"-batch", "user@foo.com"}, args)
+}
+
 func TestSSHGetExeAndArgsTortoisePlink(t *testing.T) {
 	plink := filepath.Join("Users", "joebloggs", "bin", "tortoiseplink.exe")
 
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // BufferedExec starts up a command and creates a stdin pipe and a buffered
@@ -112,7 +112,7 @@ func FormatForShellQuotedArgs(name string, args []string) (string, []string) {
 }
 
 func Trace(name string, args ...string) {
-	tracerx.Printf("exec: %s %s", name, quotedArgs(args))
+	tracelog.Printf("exec: %s %s", name, quotedArgs(args))
 }
 
 func quotedArgs(args []string) string {
@@ -9,6 +9,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -18,6 +19,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
@@ -95,6 +97,7 @@ func main() {
 	mux.HandleFunc("/storage/", storageHandler)
 	mux.HandleFunc("/verify", verifyHandler)
 	mux.HandleFunc("/redirect307/", redirect307Handler)
+	mux.HandleFunc("/otlp/", otlpHandler)
 	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "%s\n", time.Now().String())
 	})
@@ -345,6 +348,7 @@ type batchReq struct {
 	Operation string      `json:"operation"`
 	Objects   []lfsObject `json:"objects"`
 	Ref       *Ref        `json:"ref,omitempty"`
+	HashAlgo  string      `json:"hash_algo,omitempty"`
 }
 
 func (r *batchReq) RefName() string {
@@ -397,6 +401,16 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 		log.Fatal(err)
 	}
 
+	for _, obj := range objs.Objects {
+		if hashAlgoForOid(obj.Oid) != objs.HashAlgo {
+			w.WriteHeader(422)
+			json.NewEncoder(w).Encode(struct {
+				Message string `json:"message"`
+			}{fmt.Sprintf("Object %s does not match hash algorithm %q", obj.Oid, objs.HashAlgo)})
+			return
+		}
+	}
+
 	if strings.HasSuffix(repo, "branch-required") {
 		parts := strings.Split(repo, "-")
 		lenParts := len(parts)
@@ -598,6 +612,36 @@ var (
 	verifyRetryRe = regexp.MustCompile(`verify-fail-(\d+)-times?$`)
 )
 
+var (
+	omu         sync.Mutex
+	otlpExports = make(map[string][][]byte)
+)
+
+// otlpHandler records the OTLP metrics exports POSTed to "/otlp/<name>", and
+// writes those recorded under the name, one per line, in response to a GET.
+func otlpHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/otlp/")
+
+	omu.Lock()
+	defer omu.Unlock()
+
+	switch r.Method {
+	case "POST":
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(500)
+			return
+		}
+		otlpExports[name] = append(otlpExports[name], body)
+	case "GET":
+		for _, body := range otlpExports[name] {
+			fmt.Fprintf(w, "%s\n", body)
+		}
+	default:
+		w.WriteHeader(405)
+	}
+}
+
 func verifyHandler(w http.ResponseWriter, r *http.Request) {
 	repo := r.Header.Get("repo")
 	var payload struct {
@@ -710,7 +754,7 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		hash := sha256.New()
+		hash := newOidHash(r.URL.Path)
 		buf := &bytes.Buffer{}
 
 		io.Copy(io.MultiWriter(hash, buf), r.Body)
@@ -858,7 +902,7 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(400)
 			return
 		}
-		hash := sha256.New()
+		hash := newOidHash(oid)
 		buf := &bytes.Buffer{}
 		out := io.MultiWriter(hash, buf)
 
@@ -1011,6 +1055,42 @@ type Lock struct {
 	Path     string    `json:"path"`
 	Owner    User      `json:"owner"`
 	LockedAt time.Time `json:"locked_at"`
+	// ExpiresAt is set for locks in repositories whose names contain
+	// "expiring", which grant locks on paths containing "soon" for five
+	// minutes and others for two hours.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// lockLease returns the expiry of a lock on the given path taken or renewed
+// now, or nil if locks in the repository don't expire.
+func lockLease(repo, path string) *time.Time {
+	if !strings.Contains(repo, "expiring") {
+		return nil
+	}
+
+	lease := 2 * time.Hour
+	if strings.Contains(path, "soon") {
+		lease = 5 * time.Minute
+	}
+	expiresAt := time.Now().Add(lease).Truncate(time.Second)
+	return &expiresAt
+}
+
+// renewLock extends the lease of the lock with the given ID, returning the
+// renewed lock or nil if there is no such lock.
+func renewLock(repo, id string) *Lock {
+	lmu.Lock()
+	defer lmu.Unlock()
+
+	for i, l := range repoLocks[repo] {
+		if l.Id == id {
+			// Renewals always grant the full lease.
+			repoLocks[repo][i].ExpiresAt = lockLease(repo, "")
+			renewed := repoLocks[repo][i]
+			return &renewed
+		}
+	}
+	return nil
 }
 
 type LockRequest struct {
@@ -1137,13 +1217,16 @@ func getFilteredLocks(repo, path, cursor, limit string) ([]Lock, string, error)
 			return nil, "", errors.New("unable to parse limit amount")
 		}
 
-		size = int(math.Min(float64(len(locks)), 3))
-		if size < 0 {
-			return nil, "", nil
+		// Return at most three locks at a time, or fewer if asked.
+		pageSize := 3
+		if size > 0 && size < pageSize {
+			pageSize = size
 		}
 
-		if size+1 < len(locks) {
-			return locks[:size], locks[size+1].Id, nil
+		size = int(math.Min(float64(len(locks)), float64(pageSize)))
+
+		if size < len(locks) {
+			return locks[:size], locks[size].Id, nil
 		}
 	}
 
@@ -1176,6 +1259,7 @@ func (c LocksByCreatedAt) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 var (
 	lockRe   = regexp.MustCompile(`/locks/?$`)
 	unlockRe = regexp.MustCompile(`locks/([^/]+)/unlock\z`)
+	renewRe  = regexp.MustCompile(`locks/([^/]+)/renew\z`)
 )
 
 func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
@@ -1270,6 +1354,21 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			return
 		}
 
+		if matches := renewRe.FindStringSubmatch(r.URL.Path); len(matches) > 1 {
+			if !strings.Contains(repo, "expiring") {
+				w.WriteHeader(404)
+				enc.Encode(&LockResponse{Message: "not found"})
+				return
+			}
+
+			if l := renewLock(repo, matches[1]); l != nil {
+				enc.Encode(&LockResponse{Lock: l})
+			} else {
+				enc.Encode(&LockResponse{Message: "unable to find lock"})
+			}
+			return
+		}
+
 		if strings.HasSuffix(r.URL.Path, "/locks/verify") {
 			if strings.HasSuffix(repo, "verify-5xx") {
 				w.WriteHeader(500)
@@ -1358,7 +1457,8 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 
 			for _, l := range getLocks(repo) {
 				if l.Path == lockRequest.Path {
-					enc.Encode(&LockResponse{Message: "lock already created"})
+					w.WriteHeader(http.StatusConflict)
+					enc.Encode(&LockResponse{Lock: &l, Message: "lock already created"})
 					return
 				}
 			}
@@ -1367,10 +1467,11 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			rand.Read(id[:])
 
 			lock := &Lock{
-				Id:       fmt.Sprintf("%x", id[:]),
-				Path:     lockRequest.Path,
-				Owner:    User{Name: "Git LFS Tests"},
-				LockedAt: time.Now(),
+				Id:        fmt.Sprintf("%x", id[:]),
+				Path:      lockRequest.Path,
+				Owner:     User{Name: "Git LFS Tests"},
+				LockedAt:  time.Now(),
+				ExpiresAt: lockLease(repo, lockRequest.Path),
 			}
 
 			addLocks(repo, *lock)
@@ -1604,6 +1705,25 @@ func init() {
 	}
 }
 
+// hashAlgoForOid returns the value of "hash_algo" expected in batch requests
+// for the given object ID.
+func hashAlgoForOid(oid string) string {
+	if len(oid) == sha512.Size*2 {
+		return "sha512"
+	}
+	return ""
+}
+
+// newOidHash returns a hash of the type used to compute the object ID which
+// ends the given path.
+func newOidHash(path string) hash.Hash {
+	parts := strings.Split(path, "/")
+	if len(parts[len(parts)-1]) == sha512.Size*2 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
 func debug(reqid, msg string, args ...interface{}) {
 	fullargs := make([]interface{}, len(args)+1)
 	fullargs[0] = reqid
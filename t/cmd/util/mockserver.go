@@ -0,0 +1,474 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/git-lfs/git-lfs/locking"
+)
+
+// MockEndpoint names a group of requests to a MockServer, so that responses
+// can be programmed for them.
+type MockEndpoint string
+
+const (
+	// Batch API requests
+	MockBatch = MockEndpoint("batch")
+	// Basic transfer downloads of objects
+	MockDownload = MockEndpoint("download")
+	// Basic transfer uploads of objects
+	MockUpload = MockEndpoint("upload")
+	// Requests to create or list locks
+	MockLocks = MockEndpoint("locks")
+	// Requests to verify locks before a push
+	MockVerifyLocks = MockEndpoint("verify-locks")
+	// Requests to remove a lock
+	MockUnlock = MockEndpoint("unlock")
+)
+
+// MockServer is an in-process Git LFS server for Go tests, which serves the
+// batch, basic transfer and locks APIs from memory. Its responses can be
+// programmed to fail, to throttle clients, or to hand out actions whose tokens
+// expire, so that clients' handling of them can be tested deterministically.
+//
+// Clients should use its URL as their "lfs.url".
+type MockServer struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// Content of stored objects, by OID
+	objects map[string][]byte
+	// Current locks, newest first
+	locks      []locking.Lock
+	nextLockId int
+	// Errors given for objects in batch responses, by OID
+	objectErrors map[string]*mockObjectError
+	// Responses programmed for the next requests to each endpoint
+	failures map[MockEndpoint][]*mockFailure
+	// Lifetime of the tokens in batch actions, or zero for no tokens
+	tokenLifetime time.Duration
+	// Expiry time of each token handed out, by token
+	tokens    map[string]time.Time
+	nextToken int
+	// Number of tokens still to be handed out already expired
+	expireNext int
+	// Number of requests made to each endpoint
+	requests map[MockEndpoint]int
+}
+
+type mockObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mockFailure struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+// NewMockServer starts a new MockServer, with no objects or locks. It must be
+// closed with Close once the test is done with it.
+func NewMockServer() *MockServer {
+	s := &MockServer{
+		objects:      make(map[string][]byte),
+		objectErrors: make(map[string]*mockObjectError),
+		failures:     make(map[MockEndpoint][]*mockFailure),
+		tokens:       make(map[string]time.Time),
+		requests:     make(map[MockEndpoint]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddObject stores the given content on the server, and returns its OID.
+func (s *MockServer) AddObject(data []byte) string {
+	sum := sha256.Sum256(data)
+	oid := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[oid] = data
+	return oid
+}
+
+// Object returns the content of the object with the given OID, and whether the
+// server has it.
+func (s *MockServer) Object(oid string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[oid]
+	return data, ok
+}
+
+// SetObjectError makes batch responses give the object with the given OID an
+// error with the given code and message, instead of actions.
+func (s *MockServer) SetObjectError(oid string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objectErrors[oid] = &mockObjectError{Code: code, Message: message}
+}
+
+// Fail makes the next n requests to the endpoint fail with the given HTTP
+// status and message.
+func (s *MockServer) Fail(endpoint MockEndpoint, n int, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures[endpoint] = append(s.failures[endpoint], &mockFailure{
+			status:  status,
+			message: message,
+		})
+	}
+}
+
+// Throttle makes the next n requests to the endpoint fail with "429 Too Many
+// Requests", asking the client to retry after the given delay.
+func (s *MockServer) Throttle(endpoint MockEndpoint, n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures[endpoint] = append(s.failures[endpoint], &mockFailure{
+			status:     http.StatusTooManyRequests,
+			message:    "rate limit exceeded",
+			retryAfter: retryAfter,
+		})
+	}
+}
+
+// ExpireActionsAfter makes batch responses give actions with a token which
+// expires after the given lifetime, as their "expires_in". Transfers made
+// with an expired token fail with "403 Forbidden", as they would with an
+// expired pre-signed URL. A lifetime of zero stops the use of tokens.
+func (s *MockServer) ExpireActionsAfter(lifetime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenLifetime = lifetime
+}
+
+// ExpireTokens makes the next n tokens handed out expire as soon as they are,
+// although their actions still claim the full lifetime, as if the client's
+// clock were wrong. Only a fresh batch request gives the client a valid one.
+func (s *MockServer) ExpireTokens(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireNext += n
+}
+
+// AddLock creates a lock on the given path owned by the given user, and
+// returns it.
+func (s *MockServer) AddLock(path, owner string) locking.Lock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addLock(path, owner)
+}
+
+// Locks returns the current locks, newest first.
+func (s *MockServer) Locks() []locking.Lock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]locking.Lock(nil), s.locks...)
+}
+
+// Requests returns the number of requests made to the endpoint, including
+// those which failed.
+func (s *MockServer) Requests(endpoint MockEndpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func (s *MockServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	var endpoint MockEndpoint
+	switch {
+	case len(parts) == 2 && parts[0] == "objects" && parts[1] == "batch" && r.Method == "POST":
+		endpoint = MockBatch
+	case len(parts) == 2 && parts[0] == "objects" && r.Method == "GET":
+		endpoint = MockDownload
+	case len(parts) == 2 && parts[0] == "objects" && r.Method == "PUT":
+		endpoint = MockUpload
+	case len(parts) == 1 && parts[0] == "locks":
+		endpoint = MockLocks
+	case len(parts) == 2 && parts[0] == "locks" && parts[1] == "verify" && r.Method == "POST":
+		endpoint = MockVerifyLocks
+	case len(parts) == 3 && parts[0] == "locks" && parts[2] == "unlock" && r.Method == "POST":
+		endpoint = MockUnlock
+	default:
+		writeMockMessage(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[endpoint]++
+	if failures := s.failures[endpoint]; len(failures) > 0 {
+		f := failures[0]
+		s.failures[endpoint] = failures[1:]
+		if f.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter/time.Second)))
+		}
+		writeMockMessage(w, f.status, f.message)
+		return
+	}
+
+	switch endpoint {
+	case MockBatch:
+		s.batch(w, r)
+	case MockDownload:
+		s.download(w, r, parts[1])
+	case MockUpload:
+		s.upload(w, r, parts[1])
+	case MockLocks:
+		if r.Method == "POST" {
+			s.createLock(w, r)
+		} else {
+			s.listLocks(w, r)
+		}
+	case MockVerifyLocks:
+		s.verifyLocks(w, r)
+	case MockUnlock:
+		s.unlock(w, r, parts[1])
+	}
+}
+
+type mockBatchObject struct {
+	Oid           string                      `json:"oid"`
+	Size          int64                       `json:"size"`
+	Authenticated bool                        `json:"authenticated,omitempty"`
+	Actions       map[string]*mockBatchAction `json:"actions,omitempty"`
+	Error         *mockObjectError            `json:"error,omitempty"`
+}
+
+type mockBatchAction struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresIn int               `json:"expires_in,omitempty"`
+}
+
+func (s *MockServer) batch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Operation string             `json:"operation"`
+		Objects   []*mockBatchObject `json:"objects"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMockMessage(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid batch request: %s", err))
+		return
+	}
+
+	objects := make([]*mockBatchObject, 0, len(req.Objects))
+	for _, o := range req.Objects {
+		obj := &mockBatchObject{Oid: o.Oid, Size: o.Size, Authenticated: true}
+		_, exists := s.objects[o.Oid]
+		switch {
+		case s.objectErrors[o.Oid] != nil:
+			obj.Error = s.objectErrors[o.Oid]
+		case req.Operation == "upload" && !exists:
+			obj.Actions = map[string]*mockBatchAction{"upload": s.newAction(o.Oid)}
+		case req.Operation == "download" && exists:
+			obj.Actions = map[string]*mockBatchAction{"download": s.newAction(o.Oid)}
+		case req.Operation == "download":
+			obj.Error = &mockObjectError{Code: http.StatusNotFound, Message: "object does not exist"}
+		}
+		objects = append(objects, obj)
+	}
+
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{
+		"transfer": "basic",
+		"objects":  objects,
+	})
+}
+
+// newAction returns a transfer action for the object with the given OID,
+// with a new token if tokens are in use. The caller must hold s.mu.
+func (s *MockServer) newAction(oid string) *mockBatchAction {
+	action := &mockBatchAction{Href: s.URL + "/objects/" + oid}
+	if s.tokenLifetime > 0 {
+		s.nextToken++
+		token := fmt.Sprintf("token-%d", s.nextToken)
+		s.tokens[token] = time.Now().Add(s.tokenLifetime)
+		if s.expireNext > 0 {
+			s.tokens[token] = time.Now()
+			s.expireNext--
+		}
+		action.Header = map[string]string{"Authorization": "RemoteAuth " + token}
+		action.ExpiresIn = int(s.tokenLifetime / time.Second)
+	}
+	return action
+}
+
+// authorized returns whether the transfer request carries a valid token, if
+// tokens are in use, writing an error response if not. The caller must hold
+// s.mu.
+func (s *MockServer) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.tokenLifetime <= 0 {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "RemoteAuth ")
+	expiry, ok := s.tokens[token]
+	if !ok {
+		writeMockMessage(w, http.StatusUnauthorized, "missing or unknown token")
+		return false
+	}
+	if !time.Now().Before(expiry) {
+		writeMockMessage(w, http.StatusForbidden, "token expired")
+		return false
+	}
+	return true
+}
+
+func (s *MockServer) download(w http.ResponseWriter, r *http.Request, oid string) {
+	if !s.authorized(w, r) {
+		return
+	}
+	data, ok := s.objects[oid]
+	if !ok {
+		writeMockMessage(w, http.StatusNotFound, "object does not exist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (s *MockServer) upload(w http.ResponseWriter, r *http.Request, oid string) {
+	if !s.authorized(w, r) {
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeMockMessage(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != oid {
+		writeMockMessage(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("object contents do not match OID: expected %s, got %s", oid, actual))
+		return
+	}
+	s.objects[oid] = data
+	w.WriteHeader(http.StatusOK)
+}
+
+// mockRequester returns the user making the request, from its basic credentials,
+// or "Git LFS Tests", the user of repos made by NewRepo.
+func mockRequester(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && len(user) > 0 {
+		return user
+	}
+	return "Git LFS Tests"
+}
+
+// addLock creates a lock. The caller must hold s.mu.
+func (s *MockServer) addLock(path, owner string) locking.Lock {
+	s.nextLockId++
+	lock := locking.Lock{
+		Id:       strconv.Itoa(s.nextLockId),
+		Path:     path,
+		Owner:    &locking.User{Name: owner},
+		LockedAt: time.Now().UTC().Round(time.Second),
+	}
+	s.locks = append([]locking.Lock{lock}, s.locks...)
+	return lock
+}
+
+func (s *MockServer) createLock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Path) == 0 {
+		writeMockMessage(w, http.StatusUnprocessableEntity, "invalid lock request")
+		return
+	}
+
+	for _, l := range s.locks {
+		if l.Path == req.Path {
+			lock := l
+			writeMockJSON(w, http.StatusConflict, map[string]interface{}{
+				"lock":    &lock,
+				"message": "already created lock",
+			})
+			return
+		}
+	}
+
+	lock := s.addLock(req.Path, mockRequester(r))
+	writeMockJSON(w, http.StatusCreated, map[string]interface{}{"lock": &lock})
+}
+
+func (s *MockServer) listLocks(w http.ResponseWriter, r *http.Request) {
+	path, id := r.URL.Query().Get("path"), r.URL.Query().Get("id")
+
+	locks := make([]locking.Lock, 0, len(s.locks))
+	for _, l := range s.locks {
+		if (len(path) > 0 && l.Path != path) || (len(id) > 0 && l.Id != id) {
+			continue
+		}
+		locks = append(locks, l)
+	}
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{"locks": locks})
+}
+
+func (s *MockServer) verifyLocks(w http.ResponseWriter, r *http.Request) {
+	user := mockRequester(r)
+
+	ours, theirs := make([]locking.Lock, 0), make([]locking.Lock, 0)
+	for _, l := range s.locks {
+		if l.Owner != nil && l.Owner.Name == user {
+			ours = append(ours, l)
+		} else {
+			theirs = append(theirs, l)
+		}
+	}
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{
+		"ours":   ours,
+		"theirs": theirs,
+	})
+}
+
+func (s *MockServer) unlock(w http.ResponseWriter, r *http.Request, id string) {
+	var req struct {
+		Force bool `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMockMessage(w, http.StatusUnprocessableEntity, "invalid unlock request")
+		return
+	}
+
+	for i, l := range s.locks {
+		if l.Id != id {
+			continue
+		}
+		if !req.Force && (l.Owner == nil || l.Owner.Name != mockRequester(r)) {
+			writeMockMessage(w, http.StatusForbidden, "lock is owned by another user")
+			return
+		}
+		s.locks = append(s.locks[:i:i], s.locks[i+1:]...)
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{"lock": &l})
+		return
+	}
+	writeMockMessage(w, http.StatusNotFound, "unable to find lock")
+}
+
+func writeMockMessage(w http.ResponseWriter, status int, message string) {
+	writeMockJSON(w, status, map[string]string{"message": message})
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", lfshttp.MediaType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -75,6 +75,9 @@ var (
 
 type RepoCreateSettings struct {
 	RepoType RepoType
+	// Hash algorithm of the repo's object IDs, "sha1" or "sha256"; Git's
+	// default if empty
+	ObjectFormat string
 }
 
 // Callback interface (testing.T compatible)
@@ -183,6 +186,23 @@ func NewRepo(callback RepoCallback) *Repo {
 	})
 }
 
+// NewBareRepo creates a new bare git repo (no working copy) in a new temp dir
+func NewBareRepo(callback RepoCallback) *Repo {
+	return newRepo(callback, &RepoCreateSettings{
+		RepoType: RepoTypeBare,
+	})
+}
+
+// NewRepoWithObjectFormat creates a new git repo in a new temp dir whose
+// objects are named by the given hash algorithm, e.g. "sha256". It requires
+// Git 2.29 or later.
+func NewRepoWithObjectFormat(callback RepoCallback, format string) *Repo {
+	return newRepo(callback, &RepoCreateSettings{
+		RepoType:     RepoTypeNormal,
+		ObjectFormat: format,
+	})
+}
+
 // newRepo creates a new git repo in a new temp dir with more control over settings
 func newRepo(callback RepoCallback, settings *RepoCreateSettings) *Repo {
 	ret := &Repo{
@@ -212,6 +232,9 @@ func newRepo(callback RepoCallback, settings *RepoCreateSettings) *Repo {
 	default:
 		ret.GitDir = filepath.Join(ret.Path, ".git")
 	}
+	if settings.ObjectFormat != "" {
+		args = append(args, "--object-format="+settings.ObjectFormat)
+	}
 
 	args = append(args, path)
 	cmd := exec.Command("git", args...)
@@ -225,15 +248,58 @@ func newRepo(callback RepoCallback, settings *RepoCreateSettings) *Repo {
 	ret.fs = ret.cfg.Filesystem()
 	ret.gitfilter = lfs.NewGitFilter(ret.cfg)
 
-	// Configure default user/email so not reliant on env
-	ret.Pushd()
-	RunGitCommand(callback, true, "config", "user.name", "Git LFS Tests")
-	RunGitCommand(callback, true, "config", "user.email", "git-lfs@example.com")
-	ret.Popd()
+	ret.configureUser()
+
+	return ret
+}
+
+// Configure default user/email so not reliant on env
+func (r *Repo) configureUser() {
+	RunGitCommand(r.callback, true, "-C", r.Path, "config", "user.name", "Git LFS Tests")
+	RunGitCommand(r.callback, true, "-C", r.Path, "config", "user.email", "git-lfs@example.com")
+}
+
+// Clone clones the repo (typically a bare one, which cannot have commits
+// added directly) into a new non-bare repo in a new temp dir, whose "origin"
+// remote is this repo. The clone is not cleaned up with this repo.
+func (r *Repo) Clone() *Repo {
+	path, err := ioutil.TempDir("", "lfsRepo")
+	if err != nil {
+		r.callback.Fatalf("Can't create temp dir for git repo: %v", err)
+	}
+
+	RunGitCommand(r.callback, true, "clone", "--quiet", r.Path, path)
 
+	ret := WrapRepo(r.callback, path)
+	ret.Remotes = map[string]*Repo{}
+	ret.Settings.ObjectFormat = r.Settings.ObjectFormat
+	ret.configureUser()
 	return ret
 }
 
+// ConfigureSigning generates a throwaway SSH key and configures the repo to
+// use it to sign commits and tags, and to trust it when verifying them, so
+// that CommitInput.Signed may be used. It requires Git 2.34 or later.
+func (r *Repo) ConfigureSigning() {
+	key := filepath.Join(r.GitDir, "lfs-test-signing-key")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "git-lfs@example.com", "-f", key).CombinedOutput(); err != nil {
+		r.callback.Fatalf("Unable to generate signing key: %v %v", err, string(out))
+	}
+
+	pub, err := ioutil.ReadFile(key + ".pub")
+	if err != nil {
+		r.callback.Fatalf("Unable to read signing key: %v", err)
+	}
+	signers := filepath.Join(r.GitDir, "lfs-test-allowed-signers")
+	if err := ioutil.WriteFile(signers, []byte("git-lfs@example.com "+string(pub)), 0644); err != nil {
+		r.callback.Fatalf("Unable to write allowed signers: %v", err)
+	}
+
+	RunGitCommand(r.callback, true, "-C", r.Path, "config", "gpg.format", "ssh")
+	RunGitCommand(r.callback, true, "-C", r.Path, "config", "user.signingkey", key)
+	RunGitCommand(r.callback, true, "-C", r.Path, "config", "gpg.ssh.allowedSignersFile", signers)
+}
+
 // WrapRepo creates a new Repo instance for an existing git repo
 func WrapRepo(c RepoCallback, path string) *Repo {
 	cfg := config.NewIn(path, "")
@@ -343,12 +409,34 @@ type CommitInput struct {
 	NewBranch string
 	// Names of any tags we should create at this commit (optional)
 	Tags []string
+	// Names of any lightweight tags we should create at this commit (optional)
+	LightweightTags []string
+	// Submodules to add in this commit (optional)
+	Submodules []*SubmoduleInput
+	// Whether to sign this commit and its annotated tags (requires
+	// Repo.ConfigureSigning to have been called)
+	Signed bool
 	// Name of committer
 	CommitterName string
 	// Email of committer
 	CommitterEmail string
 }
 
+// Input data for a submodule added in a commit
+type SubmoduleInput struct {
+	// Path of the submodule within the working copy (required)
+	Path string
+	// Repo to add as the submodule; it must have at least one commit (required)
+	Repo *Repo
+}
+
+func (insub *SubmoduleInput) AddToIndex(repo *Repo) {
+	// Git refuses to clone submodules from local paths by default since
+	// 2.38.1.
+	RunGitCommand(repo.callback, true, "-c", "protocol.file.allow=always",
+		"submodule", "--quiet", "add", insub.Repo.Path, insub.Path)
+}
+
 // Output struct with details of commits created for test
 type CommitOutput struct {
 	Sha     string
@@ -356,13 +444,16 @@ type CommitOutput struct {
 	Files   []*lfs.Pointer
 }
 
-func commitAtDate(atDate time.Time, committerName, committerEmail, msg string) error {
+func commitAtDate(atDate time.Time, committerName, committerEmail, msg string, signed bool) error {
 	var args []string
 	if committerName != "" && committerEmail != "" {
 		args = append(args, "-c", fmt.Sprintf("user.name=%v", committerName))
 		args = append(args, "-c", fmt.Sprintf("user.email=%v", committerEmail))
 	}
 	args = append(args, "commit", "--allow-empty", "-m", msg)
+	if signed {
+		args = append(args, "-S")
+	}
 	cmd := exec.Command("git", args...)
 	env := os.Environ()
 	// set GIT_COMMITTER_DATE environment var e.g. "Fri Jun 21 20:26:41 2013 +0900"
@@ -423,9 +514,13 @@ func (repo *Repo) AddCommits(inputs []*CommitInput) []*CommitOutput {
 		for _, infile := range input.Files {
 			infile.AddToIndex(output, repo)
 		}
+		// Any submodules to add?
+		for _, insub := range input.Submodules {
+			insub.AddToIndex(repo)
+		}
 		// Now commit
 		err = commitAtDate(input.CommitDate, input.CommitterName, input.CommitterEmail,
-			fmt.Sprintf("Test commit %d", i))
+			fmt.Sprintf("Test commit %d", i), input.Signed)
 		if err != nil {
 			repo.callback.Fatalf("Error committing: %v", err)
 		}
@@ -438,7 +533,14 @@ func (repo *Repo) AddCommits(inputs []*CommitInput) []*CommitOutput {
 		// tags
 		for _, tag := range input.Tags {
 			// Use annotated tags, assume full release tags (also tag objects have edge cases)
-			RunGitCommand(repo.callback, true, "tag", "-a", "-m", "Added tag", tag)
+			if input.Signed {
+				RunGitCommand(repo.callback, true, "tag", "-s", "-m", "Added tag", tag)
+			} else {
+				RunGitCommand(repo.callback, true, "tag", "-a", "-m", "Added tag", tag)
+			}
+		}
+		for _, tag := range input.LightweightTags {
+			RunGitCommand(repo.callback, true, "tag", tag)
 		}
 
 		output.Sha = commit.Sha
@@ -461,7 +563,8 @@ func (r *Repo) AddRemote(name string) *Repo {
 		r.callback.Fatalf("Remote %v already exists", name)
 	}
 	remote := newRepo(r.callback, &RepoCreateSettings{
-		RepoType: RepoTypeBare,
+		RepoType:     RepoTypeBare,
+		ObjectFormat: r.Settings.ObjectFormat,
 	})
 	r.Remotes[name] = remote
 	RunGitCommand(r.callback, true, "remote", "add", name, remote.Path)
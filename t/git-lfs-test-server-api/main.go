@@ -186,7 +186,7 @@ func buildTestData(repo *t.Repo, manifest *tq.Manifest) (oidsExist, oidsMissing
 		tasklog.ForceProgress(false),
 	)
 	meter := tq.NewMeter(repo.Configuration())
-	meter.Logger = meter.LoggerFromEnv(repo.OSEnv())
+	meter.Logger, _ = meter.LoggerFromEnv(repo.OSEnv())
 	logger.Enqueue(meter)
 	commit := t.CommitInput{CommitterName: "A N Other", CommitterEmail: "noone@somewhere.com"}
 	for i := 0; i < oidCount; i++ {
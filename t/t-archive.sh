@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "archive: writes the contents of Git LFS files"
+(
+  set -e
+
+  reponame="archive-contents"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="archived a"
+  contents_a_oid="$(calc_oid "$contents_a")"
+  contents_b="archived b"
+  contents_b_oid="$(calc_oid "$contents_b")"
+  mkdir dir
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > dir/b.dat
+  printf "plain" > plain.txt
+  git add .gitattributes a.dat dir/b.dat plain.txt
+  git commit -m "add files"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+  refute_local_object "$contents_a_oid"
+  refute_local_object "$contents_b_oid"
+
+  export GIT_LFS_SKIP_SMUDGE=1
+  git archive main | tar -xO a.dat | grep "oid sha256:$contents_a_oid"
+
+  git lfs archive main 2> archive.log > archive.tar
+  cat archive.log
+  grep "Downloading LFS objects: 100% (2/2)" archive.log
+  [ "$contents_a" = "$(tar -xOf archive.tar a.dat)" ]
+  [ "$contents_b" = "$(tar -xOf archive.tar dir/b.dat)" ]
+  [ "plain" = "$(tar -xOf archive.tar plain.txt)" ]
+  assert_local_object "$contents_a_oid" 10
+  assert_local_object "$contents_b_oid" 10
+
+  git lfs archive --format=zip --prefix=project/ -o "$TRASHDIR/archive.zip" main 2>&1 | tee archive.log
+  [ "$contents_a" = "$(unzip -p "$TRASHDIR/archive.zip" project/a.dat)" ]
+  [ "$contents_b" = "$(unzip -p "$TRASHDIR/archive.zip" project/dir/b.dat)" ]
+)
+end_test
+
+begin_test "archive: only fetches objects in the given paths"
+(
+  set -e
+
+  reponame="archive-paths"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="archived a"
+  contents_a_oid="$(calc_oid "$contents_a")"
+  contents_b="archived b"
+  contents_b_oid="$(calc_oid "$contents_b")"
+  mkdir dir
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > dir/b.dat
+  git add .gitattributes a.dat dir/b.dat
+  git commit -m "add files"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+
+  git lfs archive -o archive.tar main dir
+  [ "dir/ dir/b.dat" = "$(tar -tf archive.tar | xargs)" ]
+  [ "$contents_b" = "$(tar -xOf archive.tar dir/b.dat)" ]
+  assert_local_object "$contents_b_oid" 10
+  refute_local_object "$contents_a_oid"
+)
+end_test
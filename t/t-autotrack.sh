@@ -0,0 +1,88 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "autotrack: large files are tracked when added"
+(
+  set -e
+
+  reponame="autotrack-large"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config -f .lfsconfig lfs.autotrack.size 1KB
+  git lfs track --autotrack "*.bin" | tee track.log
+  grep "Tracking \"\*.bin\"" track.log
+  grep -x "\*.bin filter=lfs diff=lfs merge=lfs -text lfs-autotrack" .gitattributes
+  git lfs track "*.dat"
+
+  printf "small" > small.bin
+  printf "tracked" > a.dat
+  mkdir dir
+  head -c 2048 /dev/zero | tr "\\0" "x" > "dir/big file.bin"
+  big_oid="$(calc_oid_file "dir/big file.bin")"
+
+  cp .gitattributes attributes.before
+  git add .lfsconfig .gitattributes small.bin a.dat dir
+  cmp .gitattributes attributes.before
+  git commit -m "add files"
+
+  [ "small" = "$(git cat-file -p :small.bin)" ]
+  git cat-file -p :a.dat | grep "oid sha256:$(calc_oid "tracked")"
+  git cat-file -p ":dir/big file.bin" | grep "oid sha256:$big_oid"
+  assert_local_object "$big_oid" 2048
+  [ -z "$(git status --porcelain --untracked-files=no)" ]
+
+  git lfs track | tee track.log
+  grep "\*.bin \[autotrack\] (.gitattributes)" track.log
+  grep "\*.dat (.gitattributes)" track.log
+
+  git push origin main
+
+  cd ..
+  git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  [ "small" = "$(cat small.bin)" ]
+  [ "$big_oid" = "$(calc_oid_file "dir/big file.bin")" ]
+  [ -z "$(git status --porcelain)" ]
+)
+end_test
+
+begin_test "autotrack: later patterns take precedence"
+(
+  set -e
+
+  reponame="autotrack-precedence"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.autotrack.size 1KB
+  printf "* filter=lfs diff=lfs merge=lfs -text lfs-autotrack\n*.iso -filter\n" > .gitattributes
+  head -c 2048 /dev/zero | tr "\\0" "x" > disk.iso
+  head -c 2048 /dev/zero | tr "\\0" "y" > big.bin
+  git add .gitattributes disk.iso big.bin
+  git commit -m "add files"
+
+  git cat-file -p :disk.iso | cmp - disk.iso
+  git cat-file -p :big.bin | grep "oid sha256:$(calc_oid_file big.bin)"
+  [ 2 -eq "$(wc -l < .gitattributes)" ]
+  [ ! -e .git/info/attributes ] || [ 0 -eq "$(grep -c "lfs-autotrack" .git/info/attributes)" ]
+)
+end_test
+
+begin_test "autotrack: without a size all files are tracked"
+(
+  set -e
+
+  reponame="autotrack-no-size"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track --autotrack "*.bin"
+  printf "small" > small.bin
+  git add .gitattributes small.bin
+  git commit -m "add small.bin"
+
+  git cat-file -p :small.bin | grep "oid sha256:$(calc_oid "small")"
+)
+end_test
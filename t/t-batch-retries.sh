@@ -33,6 +33,39 @@ begin_test "batch storage upload causes retries"
 )
 end_test
 
+begin_test "batch storage upload retries per remote"
+(
+  set -e
+
+  reponame="batch-storage-upload-retry-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-upload-remote
+
+  contents="storage-upload-retry"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  # Too few retries for any remote but origin.
+  git config --local lfs.transfer.maxretries 1
+  git config --local lfs.origin.transfer.maxretries 3
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin main\` to succeed ..."
+    exit 1
+  fi
+
+  actual_count="$(grep -c "tq: retrying object $oid: Fatal error: Server error" push.log)"
+  [ "2" = "$actual_count" ]
+
+  assert_server_object "$reponame" "$oid"
+)
+end_test
+
 begin_test "batch storage download causes retries"
 (
   set -e
@@ -0,0 +1,131 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "bundle: create and clone from a bundle"
+(
+  set -e
+
+  reponame="bundle-clone"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="bundled a"
+  contents_a_oid="$(calc_oid "$contents_a")"
+  contents_b="bundled b"
+  contents_b_oid="$(calc_oid "$contents_b")"
+  printf "%s" "$contents_a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  printf "%s" "$contents_b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git lfs bundle create "$TRASHDIR/repo.bundle" --all 2>&1 | tee bundle.log
+  grep "bundle: wrote 2 Git LFS object(s)" bundle.log
+  [ -f "$TRASHDIR/repo.bundle" ]
+  tar -tf "$TRASHDIR/repo.bundle.lfs" | sort | tee archive.log
+  grep "objects/${contents_a_oid:0:2}/${contents_a_oid:2:2}/$contents_a_oid" archive.log
+  grep "objects/${contents_b_oid:0:2}/${contents_b_oid:2:2}/$contents_b_oid" archive.log
+
+  cd "$TRASHDIR"
+  git clone --no-checkout repo.bundle "$reponame-copy"
+  cd "$reponame-copy"
+  git lfs bundle unbundle "$TRASHDIR/repo.bundle" 2>&1 | tee unbundle.log
+  grep "bundle: imported 2 Git LFS object(s)" unbundle.log
+  assert_local_object "$contents_a_oid" 9
+  assert_local_object "$contents_b_oid" 9
+
+  git checkout main
+  [ "$contents_a" = "$(cat a.dat)" ]
+  [ "$contents_b" = "$(cat b.dat)" ]
+
+  git lfs bundle unbundle "$TRASHDIR/repo.bundle" 2>&1 | tee unbundle.log
+  grep "bundle: imported 0 Git LFS object(s) (0 B), 2 already present" unbundle.log
+)
+end_test
+
+begin_test "bundle: create with prerequisites"
+(
+  set -e
+
+  reponame="bundle-prerequisites"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "old" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git tag last-sync
+
+  contents="new"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git lfs bundle create "$TRASHDIR/update.bundle" last-sync..main 2>&1 | tee bundle.log
+  grep "bundle: wrote 1 Git LFS object(s)" bundle.log
+  [ "objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid" = "$(tar -tf "$TRASHDIR/update.bundle.lfs")" ]
+)
+end_test
+
+begin_test "bundle: create with missing objects"
+(
+  set -e
+
+  reponame="bundle-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="missing"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  rm -rf .git/lfs/objects
+
+  set +e
+  git lfs bundle create "$TRASHDIR/missing.bundle" main 2> bundle.log
+  res=$?
+  set -e
+
+  cat bundle.log
+  [ "$res" -eq 6 ]
+  grep "bundle: missing object: a.dat ($contents_oid)" bundle.log
+  [ ! -e "$TRASHDIR/missing.bundle" ]
+  [ ! -e "$TRASHDIR/missing.bundle.lfs" ]
+)
+end_test
+
+begin_test "bundle: unbundle rejects corrupt objects"
+(
+  set -e
+
+  reponame="bundle-corrupt"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="corrupt"
+  contents_oid="$(calc_oid "$contents")"
+  mkdir -p "archive/objects/${contents_oid:0:2}/${contents_oid:2:2}"
+  printf "tampered" > "archive/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  git commit --allow-empty -m "initial commit"
+  git bundle create "$TRASHDIR/corrupt.bundle" main
+  tar -C archive -cf "$TRASHDIR/corrupt.bundle.lfs" objects
+
+  set +e
+  git lfs bundle unbundle "$TRASHDIR/corrupt.bundle" 2> unbundle.log
+  res=$?
+  set -e
+
+  cat unbundle.log
+  [ "$res" -eq 6 ]
+  grep "object $contents_oid in bundle is corrupt" unbundle.log
+  refute_local_object "$contents_oid"
+)
+end_test
@@ -0,0 +1,68 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "cat"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="contents of a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  printf "not stored with Git LFS" > b.txt
+  git add .gitattributes a.dat b.txt
+  git commit -m "initial commit"
+
+  printf "changed" > a.dat
+  git add a.dat
+  git commit -m "change a.dat"
+  git push origin main
+
+  [ "changed" = "$(git lfs cat HEAD:a.dat)" ]
+  [ "$contents" = "$(git lfs cat HEAD~1:a.dat)" ]
+  [ "$contents" = "$(git lfs cat "$contents_oid")" ]
+  [ "not stored with Git LFS" = "$(git lfs cat main:b.txt)" ]
+
+  git lfs cat HEAD:missing.dat 2>&1 | tee cat.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "does not name a file" cat.log
+
+  git lfs cat HEAD 2>&1 | tee cat.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "is a commit, not a file" cat.log
+)
+end_test
+
+begin_test "cat downloads missing objects"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  contents="contents of a"
+  contents_oid="$(calc_oid "$contents")"
+  refute_local_object "$contents_oid"
+
+  git lfs cat HEAD~1:a.dat > cat.out 2> cat.err
+  [ "$contents" = "$(cat cat.out)" ]
+  grep "Downloading HEAD~1:a.dat" cat.err
+  assert_local_object "$contents_oid" "${#contents}"
+
+  changed_oid="$(calc_oid "changed")"
+  refute_local_object "$changed_oid"
+  [ "changed" = "$(git lfs cat "$changed_oid")" ]
+  assert_local_object "$changed_oid" 7
+
+  unknown_oid="$(calc_oid "unknown")"
+  git lfs cat "$unknown_oid" 2>&1 | tee cat.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "neither present locally nor referenced" cat.log
+)
+end_test
@@ -250,3 +250,34 @@ begin_test "checkout: GIT_WORK_TREE"
   [ "$contents" = "$(cat "$reponame/file1.dat")" ]
 )
 end_test
+
+begin_test "checkout: many files with workers"
+(
+  set -e
+
+  reponame="checkout-workers"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p a b c
+  for i in $(seq 1 60); do
+    printf "contents %d" "$i" > "$(printf "abc" | cut -c $((i % 3 + 1)))/$i.dat"
+  done
+  git add .gitattributes a b c
+  git commit -m "add files"
+
+  for workers in 1 4; do
+    rm -rf a b c
+    git checkout -- .gitattributes
+
+    git -c lfs.checkout.workers=$workers lfs checkout 2>&1 | tee checkout.log
+    grep "Checking out LFS objects: 100% (60/60)" checkout.log
+
+    for i in $(seq 1 60); do
+      [ "contents $i" = "$(cat "$(printf "abc" | cut -c $((i % 3 + 1)))/$i.dat")" ]
+    done
+    [ -z "$(git status --porcelain --untracked-files=no)" ]
+  done
+)
+end_test
@@ -87,3 +87,94 @@ begin_test "clean stdin"
   fi
 )
 end_test
+
+begin_test "clean unchanged file from cache"
+(
+  set -e
+
+  reponame="clean-cache"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.cleancache true
+  git lfs track "*.dat"
+  base64 /dev/urandom | head -c 2048 > a.dat
+  touch -t 202001010000 a.dat
+  oid="$(calc_oid_file "a.dat")"
+
+  git add a.dat
+  [ -n "$(ls .git/lfs/cache/clean)" ]
+
+  # Discard the index, so that Git cleans the file again.
+  rm .git/index
+  GIT_TRACE=1 git add a.dat 2>&1 | tee trace.log
+  grep "clean cache: .*a.dat is unchanged as $oid" trace.log
+  [ "$(pointer "$oid" 2048)" = "$(git cat-file -p :a.dat)" ]
+
+  GIT_TRACE=1 git -c lfs.cleancache=false lfs clean a.dat < a.dat 2>&1 >/dev/null | tee trace.log
+  if grep "clean cache" trace.log; then
+    echo >&2 "fatal: expected lfs.cleancache=false to disable the cache"
+    exit 1
+  fi
+
+  base64 /dev/urandom | head -c 2048 > a.dat
+  oid="$(calc_oid_file "a.dat")"
+
+  GIT_TRACE=1 git lfs clean a.dat < a.dat 2>trace.log | tee clean.log
+  if grep "clean cache" trace.log; then
+    echo >&2 "fatal: expected modified file to be hashed again"
+    exit 1
+  fi
+  [ "$(pointer "$oid" 2048)" = "$(cat clean.log)" ]
+)
+end_test
+
+begin_test "clean cache: other contents of the same size"
+(
+  set -e
+
+  reponame="clean-cache-other-contents"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.cleancache true
+  git lfs track "*.bin"
+  printf "aaaa" > a.bin
+  printf "bbbb" > b.bin
+  touch -t 202001010000 a.bin
+  git add .gitattributes a.bin
+  git commit -m "add a.bin"
+
+  # The contents given are those of b.bin, not of a.bin.
+  git hash-object -w --stdin --path=a.bin < b.bin > hash.log
+  [ "$(git hash-object --stdin --path=b.bin < b.bin)" = "$(cat hash.log)" ]
+  [ "$(pointer "$(calc_oid bbbb)" 4)" = "$(git cat-file -p "$(cat hash.log)")" ]
+
+  git lfs clean a.bin < a.bin | tee clean.log
+  [ "$(pointer "$(calc_oid aaaa)" 4)" = "$(cat clean.log)" ]
+)
+end_test
+
+begin_test "clean cache: migrate import of contents of the same size"
+(
+  set -e
+
+  reponame="clean-cache-migrate"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.cleancache true
+  printf "aaaa" > a.bin
+  git add a.bin
+  git commit -m "first"
+  printf "bbbb" > a.bin
+  touch -t 202001010000 a.bin
+  git add a.bin
+  git commit -m "second"
+
+  git lfs migrate import --everything --include="*.bin"
+
+  [ "$(pointer "$(calc_oid aaaa)" 4)" = "$(git cat-file -p HEAD~1:a.bin)" ]
+  [ "$(pointer "$(calc_oid bbbb)" 4)" = "$(git cat-file -p HEAD:a.bin)" ]
+)
+end_test
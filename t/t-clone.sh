@@ -46,7 +46,7 @@ begin_test "clone"
   newclonedir="testclone1"
   git lfs clone "$GITSERVER/$reponame" "$newclonedir" 2>&1 | tee lfsclone.log
   grep "Cloning into" lfsclone.log
-  grep "Downloading LFS objects:" lfsclone.log
+  grep "Downloading and checking out LFS objects:" lfsclone.log
   # should be no filter errors
   [ ! $(grep "filter" lfsclone.log) ]
   [ ! $(grep "error" lfsclone.log) ]
@@ -67,7 +67,7 @@ begin_test "clone"
   rm -rf "$reponame"
   git lfs clone "$GITSERVER/$reponame" 2>&1 | tee lfsclone.log
   grep "Cloning into" lfsclone.log
-  grep "Downloading LFS objects:" lfsclone.log
+  grep "Downloading and checking out LFS objects:" lfsclone.log
   # should be no filter errors
   [ ! $(grep "filter" lfsclone.log) ]
   [ ! $(grep "error" lfsclone.log) ]
@@ -635,6 +635,37 @@ begin_test "clone in current directory"
 )
 end_test
 
+begin_test "clone with lockable files"
+(
+  set -e
+
+  reponame="clone_lockable"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat" --lockable
+  printf "lockable" > a.dat
+  printf "not lockable" > b.txt
+  git add .gitattributes a.dat b.txt
+  git commit -m "add lockable file"
+  git push origin main
+
+  cd "$TRASHDIR"
+  git lfs clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  [ "lockable" = "$(cat a.dat)" ]
+  refute_file_writeable a.dat
+  assert_file_writeable b.txt
+
+  cd "$TRASHDIR"
+  git config --global lfs.setlockablereadonly false
+  git lfs clone "$GITSERVER/$reponame" "$reponame-clone-writeable"
+  git config --global --unset lfs.setlockablereadonly
+  assert_file_writeable "$reponame-clone-writeable/a.dat"
+)
+end_test
+
 begin_test "clone empty repository"
 (
   set -e
@@ -715,7 +746,7 @@ begin_test "clone (HTTP server/proxy require cookies)"
   newclonedir="require-cookie-test1"
   git lfs clone "$GITSERVER/$reponame" "$newclonedir" 2>&1 | tee lfsclone.log
   grep "Cloning into" lfsclone.log
-  grep "Downloading LFS objects:" lfsclone.log
+  grep "Downloading and checking out LFS objects:" lfsclone.log
   # should be no filter errors
   [ ! $(grep "filter" lfsclone.log) ]
   [ ! $(grep "error" lfsclone.log) ]
@@ -736,7 +767,7 @@ begin_test "clone (HTTP server/proxy require cookies)"
   rm -rf "$reponame"
   git lfs clone "$GITSERVER/$reponame" 2>&1 | tee lfsclone.log
   grep "Cloning into" lfsclone.log
-  grep "Downloading LFS objects:" lfsclone.log
+  grep "Downloading and checking out LFS objects:" lfsclone.log
   # should be no filter errors
   [ ! $(grep "filter" lfsclone.log) ]
   [ ! $(grep "error" lfsclone.log) ]
@@ -0,0 +1,79 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# complete prints the bash completions of the given command line, whose last
+# word is the one being completed.
+complete_words() {
+  bash -c '
+    source <(git lfs completion bash)
+    COMP_WORDS=("$@")
+    COMP_CWORD=$(($# - 1))
+    __git_lfs_complete
+    printf "%s\n" "${COMPREPLY[@]}"
+  ' complete "$@"
+}
+
+begin_test "completion bash: commands and flags"
+(
+  set -e
+
+  complete_words git lfs pu > completions.log
+  [ "pull push" = "$(sort completions.log | tr '\n' ' ' | sed 's/ $//')" ]
+
+  complete_words git-lfs push --dry > completions.log
+  [ "--dry-run" = "$(cat completions.log)" ]
+
+  complete_words git -c foo=bar lfs track --lock > completions.log
+  [ "--lockable" = "$(cat completions.log)" ]
+
+  complete_words git lfs completion "" > completions.log
+  [ "bash fish powershell zsh" = "$(tr '\n' ' ' < completions.log | sed 's/ $//')" ]
+)
+end_test
+
+begin_test "completion bash: remotes and patterns"
+(
+  set -e
+
+  reponame="completion-dynamic"
+  git init "$reponame"
+  cd "$reponame"
+
+  git remote add origin https://example.com/origin.git
+  git remote add other https://example.com/other.git
+  git lfs track "*.dat" "*.bin"
+  mkdir sub
+  (cd sub && git lfs track "*.psd")
+
+  complete_words git lfs push "" > completions.log
+  [ "origin other" = "$(sort completions.log | tr '\n' ' ' | sed 's/ $//')" ]
+
+  complete_words git lfs lock --remote o > completions.log
+  [ "origin other" = "$(sort completions.log | tr '\n' ' ' | sed 's/ $//')" ]
+
+  complete_words git lfs untrack "" > completions.log
+  [ "*.bin *.dat sub/*.psd" = "$(sort completions.log | tr '\n' ' ' | sed 's/ $//')" ]
+
+  complete_words git lfs untrack "*.d" > completions.log
+  [ "*.dat" = "$(cat completions.log)" ]
+)
+end_test
+
+begin_test "completion: all shells"
+(
+  set -e
+
+  for shell in bash fish powershell zsh; do
+    git lfs completion "$shell" > "completion.$shell"
+    grep "verify" "completion.$shell"
+    grep "dry-run" "completion.$shell"
+  done
+
+  bash -n completion.bash
+
+  git lfs completion tcsh 2>&1 | tee completion.log
+  [ "2" -eq "${PIPESTATUS[0]}" ]
+  grep "Unsupported shell \"tcsh\"" completion.log
+)
+end_test
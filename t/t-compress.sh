@@ -0,0 +1,105 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "compress"
+(
+  set -e
+
+  reponame="compress"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="$(head -c 10000 /dev/zero | tr "\\0" "x")"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git lfs compress 2>&1 | tee compress.log
+  grep "compressed 1 object(s)" compress.log
+
+  objectfile=".git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ ! -e "$objectfile" ]
+  [ "git-lfs-zstd" = "$(head -c 12 "$objectfile.zst")" ]
+  [ "$(wc -c < "$objectfile.zst")" -lt 10000 ]
+
+  rm a.dat
+  git checkout -- a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+  [ -z "$(git status --porcelain a.dat)" ]
+
+  git lfs fsck
+
+  git lfs compress --decompress 2>&1 | tee decompress.log
+  grep "decompressed 1 object(s)" decompress.log
+  assert_local_object "$contents_oid" 10000
+  [ "$contents" = "$(cat "$objectfile")" ]
+  [ ! -e "$objectfile.zst" ]
+)
+end_test
+
+begin_test "compress with lfs.compressobjects"
+(
+  set -e
+
+  reponame="compress-config"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.compressobjects true
+
+  git lfs track "*.dat"
+  contents="$(head -c 10000 /dev/zero | tr "\\0" "y")"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  objectfile=".git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ ! -e "$objectfile" ]
+  [ "git-lfs-zstd" = "$(head -c 12 "$objectfile.zst")" ]
+
+  git push origin main
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  git -c lfs.compressobjects=true clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  [ "$contents" = "$(cat a.dat)" ]
+
+  git -c lfs.compressobjects=true lfs fetch --all
+  objectfile=".git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ ! -e "$objectfile" ]
+  [ "git-lfs-zstd" = "$(head -c 12 "$objectfile.zst")" ]
+
+  rm a.dat
+  git lfs checkout a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "compress does not misread objects with a compressed header"
+(
+  set -e
+
+  reponame="compress-header"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf 'git-lfs-zstd\0\377\377\377\377\377\377\377\377not compressed' > a.dat
+  contents_oid="$(calc_oid_file a.dat)"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git lfs fsck
+  git push origin main
+  assert_server_object "$reponame" "$contents_oid"
+
+  rm a.dat
+  git checkout -- a.dat
+  [ -z "$(git status --porcelain a.dat)" ]
+)
+end_test
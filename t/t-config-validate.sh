@@ -0,0 +1,57 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "config --validate"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.concurrenttransfers 4
+  git config lfs.fetchinclude "*.dat"
+  git lfs config --validate 2>&1 | tee validate.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+  grep "config: no problems found" validate.log
+)
+end_test
+
+begin_test "config --validate reports problems"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-problems"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.concurentransfers 4
+  git config lfs.fetchrecentalways sometimes
+  git config -f .lfsconfig lfs.locksverify maybe
+  git config lfs.basictransfersonly true
+  git config lfs.tustransfers true
+
+  git lfs config --validate 2>&1 | tee validate.log
+  [ "1" -eq "${PIPESTATUS[0]}" ]
+  grep "warning: lfs.concurentransfers: unknown setting; did you mean lfs.concurrenttransfers? (from local (file:.git/config))" validate.log
+  grep "warning: lfs.fetchrecentalways: \"sometimes\" is not a boolean, and is treated as false (from local" validate.log
+  grep "warning: lfs.locksverify: \"maybe\" is not a boolean, and is treated as false (from lfsconfig" validate.log
+  grep "warning: lfs.tustransfers: has no effect, since lfs.basictransfersonly is set" validate.log
+  grep "config: 4 problem(s) found" validate.log
+
+  git lfs config --validate --json 2>&1 | tee validate.json
+  grep '"event":"problem","data":{"key":"lfs.concurentransfers","value":"4","scope":"local","source":"file:.git/config","message":"unknown setting; did you mean lfs.concurrenttransfers?"}' validate.json
+  grep '"type":"result","data":{"problems":4}' validate.json
+)
+end_test
+
+begin_test "config without --validate"
+(
+  set -e
+
+  git lfs config 2>&1 | tee config.log
+  [ "2" -eq "${PIPESTATUS[0]}" ]
+  grep "Usage: git lfs config --validate" config.log
+)
+end_test
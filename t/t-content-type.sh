@@ -24,6 +24,58 @@ begin_test "content-type: is enabled by default"
 )
 end_test
 
+begin_test "content-type: detected from magic numbers and extensions"
+(
+  set -e
+
+  reponame="content-type-detected"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.psd" "*.json" "*.bin"
+  printf "8BPS\000\001layers" > a.psd
+  printf '{"a": 1}' > a.json
+  printf "\000\001\002" > a.bin
+
+  git add .gitattributes a.psd a.json a.bin
+  git commit -m "initial commit"
+  GIT_CURL_VERBOSE=1 git push origin main 2>&1 | tee push.log
+
+  [ 1 -eq "$(grep -c "> Content-Type: image/vnd.adobe.photoshop" push.log)" ]
+  [ 1 -eq "$(grep -c "> Content-Type: application/json" push.log)" ]
+  [ 1 -eq "$(grep -c "> Content-Type: application/octet-stream" push.log)" ]
+)
+end_test
+
+begin_test "content-type: shown by ls-files --json"
+(
+  set -e
+
+  reponame="content-type-ls-files"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat" "*.svg"
+  printf "\211PNG\r\n\032\n" > image.dat
+  printf '<svg xmlns="http://www.w3.org/2000/svg"/>' > icon.svg
+  git add .gitattributes image.dat icon.svg
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs ls-files --json | tee ls.log
+  grep '"name":"image.dat",.*"content_type":"image/png"' ls.log
+  grep '"name":"icon.svg",.*"content_type":"image/svg+xml"' ls.log
+
+  # Without the object, only the extension can be used.
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git lfs ls-files --json | tee ls.log
+  grep '"name":"image.dat",.*"content_type":"application/octet-stream"' ls.log
+  grep '"name":"icon.svg",.*"content_type":"image/svg+xml"' ls.log
+)
+end_test
+
 begin_test "content-type: is disabled by configuration"
 (
   set -e
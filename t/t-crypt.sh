@@ -0,0 +1,102 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "crypt: encrypts objects and decrypts them on checkout"
+(
+  set -e
+
+  reponame="crypt-round-trip"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs crypt generate-key "$TRASHDIR/crypt.key"
+  git config lfs.crypt.keyfile "$TRASHDIR/crypt.key"
+  git lfs crypt install | tee install.log
+  grep "Git LFS crypt extension installed." install.log
+  [ "git-lfs crypt clean %f" = "$(git config lfs.extension.crypt.clean)" ]
+  [ "git-lfs crypt smudge %f" = "$(git config lfs.extension.crypt.smudge)" ]
+  [ "0" = "$(git config lfs.extension.crypt.priority)" ]
+
+  git lfs track "*.dat"
+  contents="top secret contents"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git cat-file -p HEAD:a.dat | tee pointer.txt
+  plain_oid="$(calc_oid "$contents")"
+  grep "ext-0-crypt sha256:$plain_oid" pointer.txt
+
+  oid="$(grep "^oid" pointer.txt | cut -d: -f2)"
+  [ "$plain_oid" != "$oid" ]
+  object="$(git lfs env | grep LocalMediaDir | cut -d= -f2)/${oid:0:2}/${oid:2:2}/$oid"
+  [ -f "$object" ]
+  if grep -q "$contents" "$object"; then
+    echo >&2 "fatal: expected object to be encrypted"
+    exit 1
+  fi
+
+  # Cleaning the same contents again must yield the same pointer.
+  [ -z "$(git status --porcelain a.dat)" ]
+
+  git push origin main
+
+  cd ..
+  git init "$reponame-clone"
+  cd "$reponame-clone"
+  git remote add origin "$GITSERVER/$reponame"
+  git config lfs.crypt.keyfile "$TRASHDIR/crypt.key"
+  git lfs crypt install
+  git pull origin main
+
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "crypt: fails to smudge with the wrong key"
+(
+  set -e
+
+  reponame="crypt-wrong-key"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs crypt generate-key "$TRASHDIR/right.key"
+  git config lfs.crypt.keyfile "$TRASHDIR/right.key"
+  git lfs crypt install
+
+  git lfs track "*.dat"
+  printf "secret" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  cd ..
+  git init "$reponame-clone"
+  cd "$reponame-clone"
+  git remote add origin "$GITSERVER/$reponame"
+  git lfs crypt generate-key "$TRASHDIR/wrong.key"
+  git config lfs.crypt.keyfile "$TRASHDIR/wrong.key"
+  git lfs crypt install
+
+  git pull origin main 2>&1 | tee pull.log
+  grep "failed authentication" pull.log
+  [ "secret" != "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "crypt: generate-key does not overwrite an existing key"
+(
+  set -e
+
+  echo "existing" > "$TRASHDIR/existing.key"
+  git lfs crypt generate-key "$TRASHDIR/existing.key" 2>&1 | tee generate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected generate-key to fail"
+    exit 1
+  fi
+  grep "already exists" generate.log
+  [ "existing" = "$(cat "$TRASHDIR/existing.key")" ]
+)
+end_test
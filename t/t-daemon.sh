@@ -0,0 +1,99 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "daemon: status, lock and locks"
+(
+  set -e
+
+  reponame="daemon-locks"
+  setup_remote_repo_with_file "$reponame" "a.dat"
+  clone_repo "$reponame" "$reponame"
+
+  echo "changed" > a.dat
+  git add a.dat
+
+  printf '%s\n' \
+    '{"jsonrpc":"2.0","id":1,"method":"status"}' \
+    '{"jsonrpc":"2.0","id":2,"method":"lock","params":{"path":"a.dat"}}' \
+    '{"jsonrpc":"2.0","id":3,"method":"shutdown"}' |
+    git lfs daemon > daemon.log
+  cat daemon.log
+
+  grep '"id":1,"result":{"files":{"a.dat":{"status":"M"}},"staged":{"a.dat":{"status":"M"}},"unstaged":{}' daemon.log
+  grep '"id":2,"result":{"id":"[^"]*","path":"a.dat"' daemon.log
+  grep '"id":3,"result":null' daemon.log
+
+  printf '%s\n' \
+    '{"jsonrpc":"2.0","id":"locks","method":"locks"}' \
+    '{"jsonrpc":"2.0","id":"relock","method":"lock","params":{"path":"a.dat"}}' |
+    git lfs daemon > daemon.log
+  cat daemon.log
+
+  grep '"id":"locks","result":\[{"id":"[^"]*","path":"a.dat"' daemon.log
+  grep '"id":"relock","error":{"code":-32000,.*"data":{"exit_code":7,"code":"lock-conflict"}}' daemon.log
+)
+end_test
+
+begin_test "daemon: fetch with progress"
+(
+  set -e
+
+  reponame="daemon-fetch"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="fetch me"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+  refute_local_object "$contents_oid"
+
+  printf '%s\n' \
+    '{"jsonrpc":"2.0","id":7,"method":"fetch","params":{"remote":"origin","refs":["main"]}}' |
+    git lfs daemon > daemon.log
+  cat daemon.log
+
+  grep '"method":"progress","params":{"id":7,"progress":{"version":1,"event":"done","phase":"download","name":"a.dat"' daemon.log
+  grep '"id":7,"result":null' daemon.log
+  assert_local_object "$contents_oid" "${#contents}"
+
+  # Remotes and refs are never taken as options.
+  printf '%s\n' \
+    '{"jsonrpc":"2.0","id":8,"method":"fetch","params":{"remote":"--all"}}' |
+    git lfs daemon > daemon.log
+  cat daemon.log
+
+  grep '"id":8,"error":{"code":-32000' daemon.log
+)
+end_test
+
+begin_test "daemon: invalid requests"
+(
+  set -e
+
+  reponame="daemon-invalid"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf '%s\n' \
+    'not json' \
+    '{"id":1,"method":"status"}' \
+    '{"jsonrpc":"2.0","id":2,"method":"frobnicate"}' \
+    '{"jsonrpc":"2.0","id":3,"method":"unlock"}' \
+    '{"jsonrpc":"2.0","method":"status"}' |
+    git lfs daemon > daemon.log
+  cat daemon.log
+
+  grep '"id":null,"error":{"code":-32700' daemon.log
+  grep '"id":1,"error":{"code":-32600' daemon.log
+  grep '"id":2,"error":{"code":-32601' daemon.log
+  grep '"id":3,"error":{"code":-32602' daemon.log
+  [ 4 -eq "$(wc -l < daemon.log)" ]
+)
+end_test
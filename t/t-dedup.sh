@@ -105,3 +105,29 @@ begin_test "dedup dirty workdir"
   echo "$result" | grep 'Working tree is dirty. Please commit or reset your change.'
 )
 end_test
+
+begin_test "dedup checkout"
+(
+  set -e
+
+  reponame="dedup_checkout"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  rm a.dat
+  GIT_TRACE=1 git lfs checkout a.dat 2>&1 | tee checkout.log
+  [ "test data" = "$(cat a.dat)" ]
+
+  if ! git lfs dedup --test; then
+    exit
+  fi
+
+  # Checking out a file clones it from the local object store.
+  grep "cloned .*a.dat" checkout.log
+)
+end_test
@@ -0,0 +1,90 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "doctor"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+  git lfs install --local
+
+  git lfs doctor 2>&1 | tee doctor.log
+  grep "ok: Git LFS filters are configured" doctor.log
+  grep "ok: Git LFS hooks are installed" doctor.log
+  grep "ok: reached $GITSERVER/$reponame.git/info/lfs" doctor.log
+  grep "doctor: no problems found" doctor.log
+)
+end_test
+
+begin_test "doctor reports problems"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-problems"
+  git init "$reponame"
+  cd "$reponame"
+  git lfs install --local
+
+  git lfs track "*.dat"
+  printf "a" > A.txt
+  printf "b" > a.txt
+  git add .gitattributes A.txt a.txt
+  git commit -m "initial commit"
+
+  printf "#!/bin/sh\necho custom\n" > .git/hooks/post-merge
+  rm .git/hooks/post-commit
+
+  git lfs doctor --offline 2>&1 | tee doctor.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+  grep "warning: these Git LFS hooks are not installed: post-commit" doctor.log
+  grep "warning: the post-merge hook does not run Git LFS" doctor.log
+  grep "fix: add \`git lfs post-merge \"\$@\"\` to" doctor.log
+  grep "warning: these paths differ only in case.*: A.txt, a.txt" doctor.log
+  grep "doctor: 0 error(s), 3 warning(s)" doctor.log
+  [ "0" -eq "$(grep -c "reached" doctor.log)" ]
+
+  git config filter.lfs.process ""
+  git config filter.lfs.smudge ""
+  git lfs doctor --offline 2>&1 | tee doctor.log
+  [ "1" -eq "${PIPESTATUS[0]}" ]
+  grep "error: the Git LFS filters are not configured (missing filter.lfs.smudge, filter.lfs.process)" doctor.log
+  grep "fix: run \`git lfs install\`" doctor.log
+)
+end_test
+
+begin_test "doctor reports conflicting attributes"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-attributes"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "*.dat filter=other" > .git/info/attributes
+
+  git lfs doctor --offline 2>&1 | tee doctor.log
+  grep "warning: \"\*.dat\" is tracked by Git LFS in .gitattributes, but given another filter in .git/info/attributes" doctor.log
+)
+end_test
+
+begin_test "doctor reports unreachable endpoints"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-unreachable"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs doctor 2>&1 | tee doctor.log
+  grep "warning: no Git LFS endpoint is configured for \"origin\"" doctor.log
+
+  git config lfs.url "http://127.0.0.1:1/unreachable"
+  git lfs doctor 2>&1 | tee doctor.log
+  [ "1" -eq "${PIPESTATUS[0]}" ]
+  grep "error: unable to reach http://127.0.0.1:1/unreachable" doctor.log
+)
+end_test
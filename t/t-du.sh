@@ -0,0 +1,70 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "du"
+(
+  set -e
+
+  reponame="du"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+
+  git lfs track "*.dat"
+  printf "old" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "old version"
+
+  printf "new contents" > a.dat
+  git add a.dat
+  git commit -m "new version"
+
+  # Nothing has been pushed, so every object is retained.
+  git lfs du 2>&1 | tee du.log
+  grep "objects:   2 (15 B)" du.log
+  grep "retained:  2 (15 B)" du.log
+  grep "prunable:  0 (0 B)" du.log
+
+  git push origin main
+
+  git lfs du 2>&1 | tee du.log
+  grep "objects:   2 (15 B)" du.log
+  grep "retained:  1 (12 B)" du.log
+  grep "prunable:  1 (3 B)" du.log
+
+  git lfs prune
+  git lfs du 2>&1 | tee du.log
+  grep "objects:   1 (12 B)" du.log
+  grep "prunable:  0 (0 B)" du.log
+)
+end_test
+
+begin_test "du with fetchexclude"
+(
+  set -e
+
+  reponame="du-fetchexclude"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "excluded" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git push origin main
+
+  # Objects of excluded paths are not retained, as by prune.
+  git config lfs.fetchexclude "b.dat"
+  git lfs du 2>&1 | tee du.log
+  grep "objects:   2 (9 B)" du.log
+  grep "retained:  1 (1 B)" du.log
+  grep "prunable:  1 (8 B)" du.log
+
+  git lfs prune --dry-run 2>&1 | tee prune.log
+  grep "prune: 1 file(s) would be pruned (8 B)" prune.log
+)
+end_test
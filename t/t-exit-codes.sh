@@ -0,0 +1,196 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "exit codes: objects missing on the server"
+(
+  set -e
+
+  reponame="exit-codes-missing-on-server"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  GIT_LFS_SKIP_PUSH=1 git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  set +e
+  git lfs fetch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  git lfs pull 2>&1 | tee pull.log
+  res2="${PIPESTATUS[0]}"
+  set -e
+
+  [ "5" -eq "$res" ]
+  [ "5" -eq "$res2" ]
+  grep "failed to fetch some objects" fetch.log
+)
+end_test
+
+begin_test "exit codes: objects missing from their storage URL"
+(
+  set -e
+
+  reponame="exit-codes-missing-from-href"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+  rm -rf .git/lfs/objects
+
+  # The batch API finds the object, but its rewritten storage URL does not.
+  git config url."$GITSERVER/storage/invalid".insteadOf "$GITSERVER/storage/"
+  git config lfs.transfer.enablehrefrewrite true
+
+  set +e
+  git lfs fetch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "5" -eq "$res" ]
+  grep "LFS: Repository or object not found: $GITSERVER/storage/invalid" fetch.log
+  refute_local_object "$contents_oid"
+)
+end_test
+
+begin_test "exit codes: authentication failure"
+(
+  set -e
+
+  reponame="requirecreds-exit-codes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config credential.helper ""
+  printf "a" > a.dat
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  set +e
+  git lfs push --noninteractive origin main 2>&1 | tee push.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "4" -eq "$res" ]
+)
+end_test
+
+begin_test "exit codes: some objects failed"
+(
+  set -e
+
+  reponame="exit-codes-transfer-failed"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "status-batch-500" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  set +e
+  git lfs push origin main 2>&1 | tee push.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "3" -eq "$res" ]
+)
+end_test
+
+begin_test "exit codes: local corruption"
+(
+  set -e
+
+  reponame="exit-codes-local-corruption"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  corrupt_local_object "$contents_oid"
+
+  set +e
+  git lfs fsck --objects --dry-run 2>&1 | tee fsck.log
+  res="${PIPESTATUS[0]}"
+  git lfs push origin main 2>&1 | tee push.log
+  res2="${PIPESTATUS[0]}"
+  set -e
+
+  [ "6" -eq "$res" ]
+  [ "6" -eq "$res2" ]
+  grep "(corrupt) a.dat" push.log
+)
+end_test
+
+begin_test "exit codes: lock conflict"
+(
+  set -e
+
+  reponame="exit-codes-lock-conflict"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat
+
+  set +e
+  git lfs lock a.dat 2>&1 | tee lock.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "7" -eq "$res" ]
+  grep "lock already created" lock.log
+)
+end_test
+
+begin_test "exit codes: error codes in json output"
+(
+  set -e
+
+  reponame="exit-codes-json"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "json" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  GIT_LFS_SKIP_PUSH=1 git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  set +e
+  git lfs fetch --json > fetch.json
+  res="$?"
+  set -e
+  cat fetch.json
+
+  [ "5" -eq "$res" ]
+  grep '"type":"error",.*"code":"not-found"' fetch.json
+)
+end_test
@@ -83,3 +83,55 @@ begin_test "fetch: include second matching file"
   assert_local_object "$contents_oid" "8"
 )
 end_test
+
+begin_test "fetch: include with negated paths"
+(
+  set -e
+
+  mkdir clone-negated
+  cd clone-negated
+  git init
+  git lfs install --local --skip-smudge
+  git remote add origin $GITSERVER/$reponame
+  git pull origin main
+
+  contents2_oid=$(calc_oid "big file 2")
+  refute_local_object "$contents_oid"
+  refute_local_object "$contents2_oid"
+
+  git lfs fetch --include="big/,!big/a/,!big/b/"
+
+  refute_local_object "$contents_oid"
+  assert_local_object "$contents2_oid" "10"
+
+  git config lfs.fetchinclude "big/,!big/*.big"
+  git lfs fetch
+
+  assert_local_object "$contents_oid" "8"
+)
+end_test
+
+begin_test "fetch: include with lfs.ignorecase"
+(
+  set -e
+
+  mkdir clone-ignorecase
+  cd clone-ignorecase
+  git init
+  git lfs install --local --skip-smudge
+  git remote add origin $GITSERVER/$reponame
+  git pull origin main
+
+  refute_local_object "$contents_oid"
+
+  git config lfs.ignorecase false
+  git lfs fetch --include="BIG/A"
+
+  refute_local_object "$contents_oid"
+
+  git config lfs.ignorecase true
+  git lfs fetch --include="BIG/A"
+
+  assert_local_object "$contents_oid" "8"
+)
+end_test
@@ -197,3 +197,63 @@ begin_test "fetch-recent remote refs"
   refute_local_object "$oid1"
 )
 end_test
+
+begin_test "fetch-recent command line overrides"
+(
+  set -e
+
+  cd clone
+  rm -rf .git/lfs/objects
+
+  git config lfs.fetchrecentalways false
+  git config lfs.fetchrecentremoterefs true
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+
+  # The options imply --recent, and override the configuration without
+  # changing it.
+  git lfs fetch --recent-refs-days=6 --recent-days=7 origin
+  assert_local_object "$oid2" "${#content2}"
+  assert_local_object "$oid3" "${#content3}"
+  assert_local_object "$oid1" "${#content1}"
+  assert_local_object "$oid4" "${#content4}"
+  refute_local_object "$oid0"
+
+  [ "0" = "$(git config lfs.fetchrecentrefsdays)" ]
+  [ "0" = "$(git config lfs.fetchrecentcommitsdays)" ]
+
+  git lfs fetch --recent-days=-1 origin 2>&1 | tee fetch.log
+  grep "Invalid --recent-days value -1: must not be negative" fetch.log
+)
+end_test
+
+begin_test "fetch-recent tags"
+(
+  set -e
+
+  cd clone
+  rm -rf .git/lfs/objects
+
+  git config lfs.fetchrecentremoterefs false
+  git config lfs.fetchrecentrefsdays 6
+  git config lfs.fetchrecentcommitsdays 0
+  git tag -a -m "annotated" recent-tag origin/other_branch
+
+  # Tags are not recent refs unless asked for.
+  git lfs fetch --recent origin
+  refute_local_object "$oid4"
+
+  git lfs fetch --include-tags origin 2>&1 | tee fetch.log
+  grep "fetch: Fetching reference recent-tag" fetch.log
+  assert_local_object "$oid4" "${#content4}"
+
+  rm -rf .git/lfs/objects
+  git config lfs.fetchrecenttags true
+  git lfs fetch --recent origin
+  assert_local_object "$oid4" "${#content4}"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch --include-tags=false origin
+  refute_local_object "$oid4"
+)
+end_test
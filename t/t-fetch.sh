@@ -71,6 +71,56 @@ begin_test "fetch"
 )
 end_test
 
+begin_test "fetch --dry-run"
+(
+  set -e
+  cd clone
+
+  git checkout newbranch
+  git checkout main
+
+  rm -rf .git/lfs/objects
+
+  git lfs fetch --dry-run origin main newbranch 2>&1 | tee fetch.log
+  grep "fetch $contents_oid => a.dat (1 B)" fetch.log
+  grep "fetch $b_oid => b.dat (1 B)" fetch.log
+  grep "fetch: dry run would download 2 object(s), 2 B" fetch.log
+  refute_local_object "$contents_oid"
+  refute_local_object "$b_oid"
+
+  git lfs fetch origin main
+  git lfs fetch --dry-run origin main newbranch 2>&1 | tee fetch.log
+  grep "fetch $contents_oid" fetch.log && exit 1
+  grep "fetch $b_oid => b.dat (1 B)" fetch.log
+  grep "fetch: dry run would download 1 object(s), 1 B" fetch.log
+  refute_local_object "$b_oid"
+
+  git lfs fetch --dry-run --all origin 2>&1 | tee fetch.log
+  grep "fetch $b_oid => b.dat (1 B)" fetch.log
+  grep "fetch: dry run would download 1 object(s), 1 B" fetch.log
+  refute_local_object "$b_oid"
+
+  # A dry run records no checkpoint, so the next run fetches every ref.
+  git lfs fetch --all origin 2>&1 | tee fetch.log
+  grep "Skipping" fetch.log && exit 1
+  assert_local_object "$b_oid" 1
+)
+end_test
+
+begin_test "fetch with lfs.fsyncobjects"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  git -c lfs.fsyncobjects=true lfs fetch
+  assert_local_object "$contents_oid" 1
+
+  git lfs fsck 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+)
+end_test
+
 begin_test "fetch (shared repository)"
 (
   set -e
@@ -152,6 +202,43 @@ begin_test "fetch with newbranch commit sha1"
 )
 end_test
 
+begin_test "fetch with annotated tag"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  git tag -a -m "annotated" fetch-tag origin/newbranch
+  git lfs fetch origin fetch-tag
+  assert_local_object "$contents_oid" 1
+  assert_local_object "$b_oid" 1
+  git tag -d fetch-tag
+)
+end_test
+
+begin_test "fetch with unknown commit"
+(
+  set -e
+  cd clone
+
+  set +e
+  git lfs fetch origin 0123456789012345678901234567890123456789 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "$res" -eq 2 ]
+  grep 'Invalid ref argument: "0123456789012345678901234567890123456789" is not a commit in this repository' fetch.log
+
+  set +e
+  git lfs fetch origin no-such-branch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "$res" -eq 2 ]
+  grep 'Invalid ref argument: "no-such-branch" is not a commit in this repository' fetch.log
+)
+end_test
+
 begin_test "fetch with include filters in gitconfig"
 (
   set -e
@@ -458,6 +545,63 @@ begin_test "fetch-all"
 )
 end_test
 
+begin_test "fetch-all resumes from checkpoint"
+(
+  set -e
+
+  reponame="fetch-all-resume"
+  setup_remote_repo "$reponame"
+
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \"\*.dat\"" track.log
+
+  contents_main="main"
+  contents_main_oid=$(calc_oid "$contents_main")
+  contents_other="other"
+  contents_other_oid=$(calc_oid "$contents_other")
+
+  printf "%s" "$contents_main" > main.dat
+  git add .gitattributes main.dat
+  git commit -m "add main.dat"
+
+  # "other" shares no history with "main", which would otherwise be skipped
+  # along with it.
+  git checkout --orphan other
+  git rm -q --cached main.dat
+  rm main.dat
+  printf "%s" "$contents_other" > other.dat
+  git add .gitattributes other.dat
+  git commit -m "add other.dat"
+
+  git push origin main other
+  assert_server_object "$reponame" "$contents_main_oid"
+  assert_server_object "$reponame" "$contents_other_oid"
+
+  git checkout main
+  git update-ref -d refs/remotes/origin/other
+  rm -rf .git/lfs/objects
+
+  # Pretend that a previous run was interrupted after fetching "other".
+  printf "%s refs/heads/other\n" "$(git rev-parse other)" > .git/lfs/fetch-all-checkpoint
+
+  git lfs fetch --all origin 2>&1 | tee fetch.log
+  grep "Skipping 1 reference(s) fetched by a previous run" fetch.log
+  grep "Fetched reference refs/heads/main" fetch.log
+
+  assert_local_object "$contents_main_oid" "${#contents_main}"
+  refute_local_object "$contents_other_oid"
+
+  # A complete run discards the checkpoint, so the next run fetches all refs.
+  [ ! -e .git/lfs/fetch-all-checkpoint ]
+
+  git lfs fetch --all origin 2>&1 | tee fetch.log
+  grep "Fetched reference refs/heads/other" fetch.log
+  assert_local_object "$contents_other_oid" "${#contents_other}"
+)
+end_test
+
 begin_test "fetch: outside git repository"
 (
   set +e
@@ -588,6 +732,59 @@ begin_test "fetch --prune"
 )
 end_test
 
+begin_test "fetch --prune with --recent"
+(
+  set -e
+
+  reponame="fetch_prune_recent"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \"\*.dat\"" track.log
+
+  content_head="HEAD content"
+  content_commit2="Content for commit 2 (recent)"
+  content_commit1="Content for commit 1 (prune)"
+  oid_head=$(calc_oid "$content_head")
+  oid_commit2=$(calc_oid "$content_commit2")
+  oid_commit1=$(calc_oid "$content_commit1")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -50d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_commit1}, \"Data\":\"$content_commit1\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -35d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_commit2}, \"Data\":\"$content_commit2\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -25d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  git push origin main
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 5
+
+  # Objects within the recent window (5 days, plus the default prune offset
+  # of 3 days, before HEAD) are fetched and kept; older ones are pruned.
+  delete_local_object "$oid_head"
+  delete_local_object "$oid_commit2"
+  git lfs fetch --recent --prune
+  assert_local_object "$oid_head" "${#content_head}"
+  assert_local_object "$oid_commit2" "${#content_commit2}"
+  refute_local_object "$oid_commit1"
+)
+end_test
+
 begin_test "fetch raw remote url"
 (
   set -e
@@ -639,3 +836,55 @@ begin_test "fetch fails when LFS directory has wrong permissions"
   grep "error trying to create local storage directory" fetch.log
 )
 end_test
+
+begin_test "fetch evicts least recently used objects beyond lfs.cache.maxsize"
+(
+  set -e
+
+  reponame="fetch-cache-maxsize"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  for version in 1 2 3; do
+    head -c 100 /dev/zero | tr "\\0" "$version" > a.dat
+    git add a.dat
+    git commit -m "version $version of a.dat"
+    eval "oid$version=\$(calc_oid_file a.dat)"
+  done
+  git push origin main
+
+  clone_repo "$reponame" "$reponame-clone"
+  git lfs fetch --all
+  assert_local_object "$oid1" 100
+  assert_local_object "$oid2" 100
+  assert_local_object "$oid3" 100
+
+  touch -d "2 days ago" ".git/lfs/objects/${oid1:0:2}/${oid1:2:2}/$oid1"
+  touch -d "1 day ago" ".git/lfs/objects/${oid2:0:2}/${oid2:2:2}/$oid2"
+  touch -d "3 days ago" ".git/lfs/objects/${oid3:0:2}/${oid3:2:2}/$oid3"
+
+  git config lfs.cache.maxsize 250
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "cache: evicted 1 object(s) (100 B)" fetch.log
+
+  # The object in the current checkout is retained, even though it is the
+  # least recently used.
+  refute_local_object "$oid1"
+  assert_local_object "$oid2" 100
+  assert_local_object "$oid3" 100
+
+  git config lfs.cache.maxsize 50
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "cache: evicted 1 object(s) (100 B)" fetch.log
+  grep "cache: 100 B of objects are in use, more than lfs.cache.maxsize (50 B)" fetch.log
+
+  refute_local_object "$oid2"
+  assert_local_object "$oid3" 100
+
+  [ "$(head -c 100 /dev/zero | tr "\\0" "3")" = "$(cat a.dat)" ]
+)
+end_test
@@ -65,6 +65,36 @@ begin_test "filter process: checking out a branch"
 )
 end_test
 
+begin_test "filter process: delayed smudges share one batch"
+(
+  set -e
+
+  reponame="filter_process_delayed_batch"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo-delayed-batch
+
+  git lfs track "*.dat"
+  for i in 1 2 3 4 5; do
+    printf "contents %d" "$i" > "$i.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add five files"
+  git push origin main
+
+  cd ..
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" "$reponame-assert" 2>&1 | tee clone.log
+  cd "$reponame-assert"
+
+  # Every object is requested in the same batch, rather than one at a time.
+  [ 1 -eq "$(grep -c "api: batch 5 files" ../clone.log)" ]
+  [ 0 -eq "$(grep -c "api: batch 1 files" ../clone.log)" ]
+
+  for i in 1 2 3 4 5; do
+    [ "contents $i" = "$(cat "$i.dat")" ]
+  done
+)
+end_test
+
 begin_test "filter process: adding a file"
 (
   set -e
@@ -137,3 +167,104 @@ begin_test "filter process: hash-object --stdin --path does not hang"
   git add .
 )
 end_test
+
+# start_filter_process runs "git lfs filter-process" in the background with its
+# input connected to file descriptor 3, and completes the handshake with it as
+# Git would.
+start_filter_process() {
+  mkfifo filter-input
+  git lfs filter-process <filter-input >filter-output 2>filter-error &
+  exec 3>filter-input
+  printf '0016git-filter-client\n000eversion=2\n0000' >&3
+  printf '0015capability=clean\n0016capability=smudge\n0000' >&3
+}
+
+# wait_for_filter_status waits for "git lfs filter-process --status" to report
+# a filter process in the given state.
+wait_for_filter_status() {
+  local state="$1"
+  for i in $(seq 1 50); do
+    git lfs filter-process --status | tee status.log
+    grep -q "	$state for " status.log && return 0
+    sleep 0.1
+  done
+  return 1
+}
+
+begin_test "filter process: --status reports running processes"
+(
+  set -e
+
+  mkdir repo-filter-status
+  cd repo-filter-status
+  git init
+  git lfs track "*.dat"
+
+  git lfs filter-process --status | tee status.log
+  grep "No filter processes are running" status.log
+
+  start_filter_process
+  pid=$!
+  printf '0012command=clean\n0013pathname=a.dat\n00000007hi\n0000' >&3
+
+  wait_for_filter_status "idle"
+  grep "	idle for .*	requests: 1	delayed: 0	$(pwd)" status.log
+  # Git runs "git-lfs" in a process of its own.
+  lfspid="$(cut -f 1 status.log)"
+
+  git lfs --json filter-process --status | tee status.json
+  grep "\"pid\":$lfspid," status.json
+  grep "\"requests\":1," status.json
+
+  exec 3>&-
+  wait "$pid"
+  git lfs filter-process --status | grep "No filter processes are running"
+  [ -z "$(ls .git/lfs/filter-process)" ]
+)
+end_test
+
+begin_test "filter process: stops gracefully on SIGTERM"
+(
+  set -e
+
+  mkdir repo-filter-sigterm
+  cd repo-filter-sigterm
+  git init
+  git lfs track "*.dat"
+
+  start_filter_process
+  pid=$!
+  wait_for_filter_status "idle"
+
+  kill -TERM "$(cut -f 1 status.log)"
+  set +e
+  wait "$pid"
+  res=$?
+  set -e
+  exec 3>&-
+
+  cat filter-error
+  [ "$res" = "0" ]
+  git lfs filter-process --status | grep "No filter processes are running"
+  [ -z "$(ls .git/lfs/filter-process)" ]
+)
+end_test
+
+begin_test "filter process: stops after lfs.filterprocess.idletimeout"
+(
+  set -e
+
+  mkdir repo-filter-idle
+  cd repo-filter-idle
+  git init
+  git lfs track "*.dat"
+  git config lfs.filterprocess.idletimeout 1s
+
+  start_filter_process
+  pid=$!
+  wait "$pid"
+  exec 3>&-
+
+  git lfs filter-process --status | grep "No filter processes are running"
+)
+end_test
@@ -173,13 +173,107 @@ begin_test "fsck detects invalid pointers"
   RET2=$?
   set -e
 
-  [ "$RET" -eq 1 ]
-  [ "$RET2" -eq 1 ]
+  [ "$RET" -eq 6 ]
+  [ "$RET2" -eq 6 ]
   [ $(grep -c 'pointer: nonCanonicalPointer: Pointer.*was not canonical' test.log) -eq 2 ]
   [ $(grep -c 'pointer: unexpectedGitObject: "large.dat".*should have been a pointer but was not' test.log) -eq 2 ]
 )
 end_test
 
+begin_test "fsck --pointers detects malformed pointers"
+(
+  set -e
+
+  reponame="fsck-malformed-pointers"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  printf "version https://git-lfs.github.com/spec/v1\noid sha256:not-an-oid\nsize 9\n" > bad.dat
+  printf "version https://git-lfs.github.com/spec/v1\nsize twelve\n" > notes.txt
+  git add .gitattributes a.dat
+  # Add the malformed files as they are, rather than cleaning them.
+  git \
+    -c "filter.lfs.process=" \
+    -c "filter.lfs.clean=cat" \
+    -c "filter.lfs.required=false" \
+    add bad.dat notes.txt
+  git commit -m "first commit"
+
+  git lfs fsck --pointers >fsck.log 2>&1 && exit 1
+  cat fsck.log
+  grep 'pointer: malformedPointer: "bad.dat" (treeish [0-9a-f]*) is a malformed pointer' fsck.log
+  grep 'pointer: malformedPointer: "notes.txt" (treeish [0-9a-f]*) is a malformed pointer' fsck.log
+  [ 0 -eq "$(grep -c 'unexpectedGitObject' fsck.log)" ]
+  [ 0 -eq "$(grep -c 'a.dat' fsck.log)" ]
+)
+end_test
+
+begin_test "fsck --pointers checks the index"
+(
+  set -e
+
+  reponame="fsck-pointers-index"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --pointers)" ]
+
+  # Stage a plain Git blob for a file which should be stored in Git LFS.
+  echo "plain data" > b.dat
+  git \
+    -c "filter.lfs.process=" \
+    -c "filter.lfs.clean=cat" \
+    -c "filter.lfs.required=false" \
+    add b.dat
+
+  git lfs fsck --pointers >fsck.log 2>&1 && exit 1
+  cat fsck.log
+  grep 'pointer: unexpectedGitObject: "b.dat".*should have been a pointer but was not' fsck.log
+
+  # Only the index is affected, not HEAD.
+  git lfs fsck --pointers HEAD
+)
+end_test
+
+begin_test "fsck --pointers detects objects missing locally and remotely"
+(
+  set -e
+
+  reponame="fsck-pointers-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "pushed" > pushed.dat
+  git add .gitattributes pushed.dat
+  git commit -m "first commit"
+  git push origin main
+
+  echo "unpushed" > unpushed.dat
+  git add unpushed.dat
+  git commit -m "second commit"
+
+  pushed_oid="$(calc_oid_file pushed.dat)"
+  unpushed_oid="$(calc_oid_file unpushed.dat)"
+  delete_local_object "$pushed_oid"
+  delete_local_object "$unpushed_oid"
+
+  git lfs fsck --pointers >fsck.log 2>&1 && exit 1
+  cat fsck.log
+  grep "pointer: missingObject: unpushed.dat ($unpushed_oid) is missing locally and from origin" fsck.log
+  [ 0 -eq "$(grep -c "pushed.dat ($pushed_oid)" fsck.log)" ]
+
+  git lfs fsck --pointers HEAD^
+)
+end_test
+
 begin_test "fsck operates on specified refs"
 (
   set -e
@@ -204,3 +298,87 @@ begin_test "fsck operates on specified refs"
   true
 )
 end_test
+
+begin_test "fsck detects truncated objects"
+(
+  set -e
+
+  reponame="fsck-truncated"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  aOid=$(calc_oid_file a.dat)
+  head -c 4 a.dat > ".git/lfs/objects/${aOid:0:2}/${aOid:2:2}/$aOid"
+
+  [ "objects: truncatedObject: a.dat ($aOid) is truncated: 4 of 10 bytes present" = "$(git lfs fsck --dry-run)" ]
+)
+end_test
+
+begin_test "fsck --repair"
+(
+  set -e
+
+  reponame="fsck-repair"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  echo "test data 2" > b.dat
+  echo "test data 3" > c.dat
+  git add .gitattributes *.dat
+  git commit -m "first commit"
+  git push origin main
+
+  aOid=$(calc_oid_file a.dat)
+  bOid=$(calc_oid_file b.dat)
+  aPath=".git/lfs/objects/${aOid:0:2}/${aOid:2:2}/$aOid"
+  bPath=".git/lfs/objects/${bOid:0:2}/${bOid:2:2}/$bOid"
+
+  echo "CORRUPTION" >> "$aPath"
+  head -c 4 b.dat > "$bPath"
+
+  git lfs fsck --repair 2>&1 | tee fsck.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+
+  grep "objects: corruptObject: a.dat ($aOid) is corrupt" fsck.log
+  grep "objects: truncatedObject: b.dat ($bOid) is truncated" fsck.log
+  grep "objects: repair: repaired 2 of 2 object(s)" fsck.log
+
+  [ -e ".git/lfs/bad/$aOid" ]
+  [ "$aOid" = "$(calc_oid_file "$aPath")" ]
+  [ "$bOid" = "$(calc_oid_file "$bPath")" ]
+  [ "Git LFS fsck OK" = "$(git lfs fsck)" ]
+
+  git lfs fsck --repair --dry-run 2>&1 | tee fsck.log
+  [ "2" -eq "${PIPESTATUS[0]}" ]
+  grep "Cannot combine --repair with --dry-run" fsck.log
+)
+end_test
+
+begin_test "fsck --repair with object missing from remote"
+(
+  set -e
+
+  reponame="fsck-repair-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "test data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  aOid=$(calc_oid_file a.dat)
+  echo "CORRUPTION" >> ".git/lfs/objects/${aOid:0:2}/${aOid:2:2}/$aOid"
+
+  git lfs fsck --repair >fsck.log 2>&1 && exit 1
+  grep "objects: repair: repaired 0 of 1 object(s)" fsck.log
+  [ -e ".git/lfs/bad/$aOid" ]
+)
+end_test
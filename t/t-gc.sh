@@ -0,0 +1,141 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "gc"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="$(head -c 10000 /dev/zero | tr "\\0" "x")"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  # An object which is not referenced by any commit.
+  orphan_oid="$(calc_oid "orphan")"
+  printf "orphan" > orphan.dat
+  git add orphan.dat
+  git rm --cached orphan.dat
+  rm orphan.dat
+  assert_local_object "$orphan_oid" 6
+
+  # Let the automatic daily cleanup run before leaving stale files behind.
+  git lfs ls-files >/dev/null
+  mkdir -p .git/lfs/incomplete
+  printf "abc" > .git/lfs/incomplete/to-destroy
+  touch -d "8 days ago" .git/lfs/incomplete/to-destroy
+
+  git lfs gc --dry-run 2>&1 | tee gc.log
+  grep "prune: 1 file(s) would be pruned" gc.log
+  assert_local_object "$orphan_oid" 6
+  [ -f .git/lfs/incomplete/to-destroy ]
+
+  git lfs gc --compress 2>&1 | tee gc.log
+  grep "prune: removed 1 stale temporary file(s)" gc.log
+  grep "gc: compressed 1 object(s)" gc.log
+  refute_local_object "$orphan_oid"
+  [ ! -f .git/lfs/incomplete/to-destroy ]
+
+  objectfile=".git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ "git-lfs-zstd" = "$(head -c 12 "$objectfile.zst")" ]
+  git lfs fsck
+)
+end_test
+
+begin_test "gc --no-prune"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-no-prune"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  orphan_oid="$(calc_oid "orphan")"
+  printf "orphan" > orphan.dat
+  git add orphan.dat
+  git rm --cached orphan.dat
+
+  git lfs ls-files >/dev/null
+  mkdir -p .git/lfs/incomplete
+  printf "abc" > .git/lfs/incomplete/to-destroy
+  touch -d "8 days ago" .git/lfs/incomplete/to-destroy
+
+  git lfs gc --no-prune 2>&1 | tee gc.log
+  grep "gc: removed 1 stale temporary file(s)" gc.log
+  [ ! -f .git/lfs/incomplete/to-destroy ]
+  assert_local_object "$orphan_oid" 6
+)
+end_test
+
+begin_test "gc with lfs.gc.compress"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-compress-config"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="$(head -c 10000 /dev/zero | tr "\\0" "y")"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git config lfs.gc.compress true
+  git lfs gc 2>&1 | tee gc.log
+  grep "gc: compressed 1 object(s)" gc.log
+
+  objectfile=".git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ "git-lfs-zstd" = "$(head -c 12 "$objectfile.zst")" ]
+)
+end_test
+
+begin_test "gc refreshes the lock cache"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-locks"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track --lockable "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat b.dat
+  [ "2" -eq "$(git lfs locks --local | wc -l)" ]
+
+  # Release one lock from elsewhere, leaving it in the cache.
+  id="$(git lfs locks --json --path a.dat | tr -d '\n' | sed -e 's/.*"id":"\([^"]*\)".*/\1/')"
+  cd ..
+  clone_repo "$reponame" "$reponame-other"
+  git lfs unlock --force --id "$id"
+  cd "../$reponame"
+
+  git lfs locks --local | grep "a.dat"
+
+  git lfs gc --dry-run 2>&1 | tee gc.log
+  [ "0" -eq "$(grep -c "stale cached lock" gc.log)" ]
+
+  git lfs gc 2>&1 | tee gc.log
+  grep "gc: removed 1 stale cached lock(s)" gc.log
+  git lfs locks --local | tee locks.log
+  [ "0" -eq "$(grep -c "a.dat" locks.log)" ]
+  grep "b.dat" locks.log
+)
+end_test
@@ -0,0 +1,87 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "hash algorithm: sha512 pointers round-trip"
+(
+  set -e
+
+  reponame="hash-algorithm-sha512"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.hashalgorithm sha512
+  git lfs track "*.dat"
+
+  contents="sha512 contents"
+  oid="$(printf "%s" "$contents" | shasum -a 512 | cut -f 1 -d " ")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git cat-file -p HEAD:a.dat | tee pointer.txt
+  grep "oid sha512:$oid" pointer.txt
+  assert_local_object "$oid" "${#contents}"
+  git lfs fsck
+
+  git lfs pointer --file=a.dat 2>/dev/null | grep "oid sha512:$oid"
+
+  git push origin main 2>&1 | tee push.log
+  grep "Uploading LFS objects: 100% (1/1)" push.log
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git lfs pull
+  [ "$contents" = "$(cat a.dat)" ]
+  assert_local_object "$oid" "${#contents}"
+)
+end_test
+
+begin_test "hash algorithm: sha256 pointers remain readable"
+(
+  set -e
+
+  reponame="hash-algorithm-mixed"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "sha256" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.hashalgorithm sha512
+  printf "sha512" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git cat-file -p HEAD:a.dat | grep "oid sha256:$(calc_oid "sha256")"
+  git cat-file -p HEAD:b.dat | grep "oid sha512:"
+
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git lfs pull
+  [ "sha256" = "$(cat a.dat)" ]
+  [ "sha512" = "$(cat b.dat)" ]
+)
+end_test
+
+begin_test "hash algorithm: unsupported algorithm"
+(
+  set -e
+
+  reponame="hash-algorithm-unsupported"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.hashalgorithm md5
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add a.dat 2>&1 | tee add.log
+  grep 'unsupported lfs.hashalgorithm: "md5"' add.log
+)
+end_test
@@ -0,0 +1,47 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "help: -h prints short usage"
+(
+  set -e
+
+  git lfs track -h > help.log 2>&1
+  grep "usage: git lfs track \[options\] \[<pattern>...\]" help.log
+  grep "   or: git lfs track --query <path>..." help.log
+  grep "View or add Git LFS paths to Git attributes" help.log
+  grep "    --dry-run -d" help.log
+  grep "See 'git lfs help track' for more details." help.log
+  [ 0 -eq "$(grep -c "## " help.log)" ]
+
+  git lfs track --help > help-long.log 2>&1
+  diff -u help.log help-long.log
+)
+end_test
+
+begin_test "help: help prints full documentation"
+(
+  set -e
+
+  # Output which is not written to a terminal is never paged.
+  GIT_PAGER=false git lfs help track > help.log 2>&1
+  grep "git lfs track --query <path>..." help.log
+  grep "Start tracking the given patterns(s) through Git LFS." help.log
+  grep "^Examples" help.log
+  [ 0 -eq "$(grep -c "usage:" help.log)" ]
+
+  git lfs help > help.log 2>&1
+  grep "Git LFS is a system for managing and versioning large files" help.log
+)
+end_test
+
+begin_test "help: git lfs lists commands"
+(
+  set -e
+
+  git lfs > usage.log 2>&1
+  grep "usage: git lfs <command> \[<args>\]" usage.log
+  grep "^   track  *View or add Git LFS paths to Git attributes" usage.log
+  grep "See 'git lfs help <command>' for more details." usage.log
+)
+end_test
@@ -0,0 +1,147 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "import"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  git push origin main
+
+  oid_a="$(calc_oid "a")"
+  oid_b="$(calc_oid "b")"
+  oid_c="$(calc_oid "c")"
+
+  # The source repository lacks one of the objects.
+  rm -f ".git/lfs/objects/${oid_b:0:2}/${oid_b:2:2}/$oid_b"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs import --from "../$reponame" 2>&1 | tee import.log
+  grep "import: imported 2 of 3 missing object(s)" import.log
+  assert_local_object "$oid_a" 1
+  assert_local_object "$oid_c" 1
+  refute_local_object "$oid_b"
+
+  # Objects are hard linked by default.
+  [ ".git/lfs/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" -ef \
+    "../$reponame/.git/lfs/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" ]
+
+  # Only the remaining object is downloaded.
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "Downloading LFS objects: 100% (1/1)" fetch.log
+  assert_local_object "$oid_b" 1
+
+  git lfs import --from "../$reponame" 2>&1 | tee import.log
+  grep "import: imported 0 of 0 missing object(s)" import.log
+)
+end_test
+
+begin_test "import with --copy, refs and filters"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-copy"
+  cd "$reponame-copy"
+
+  oid_a="$(calc_oid "a")"
+  oid_c="$(calc_oid "c")"
+
+  git lfs import --copy --from "../$reponame/.git" --exclude "c.dat" main 2>&1 | tee import.log
+  grep "import: imported 1 of 2 missing object(s)" import.log
+  assert_local_object "$oid_a" 1
+  refute_local_object "$oid_c"
+  [ ! ".git/lfs/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" -ef \
+    "../$reponame/.git/lfs/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" ]
+
+  git lfs import --from "../$reponame" HEAD~1 2>&1 | tee import.log
+  grep "import: imported 0 of 1 missing object(s)" import.log
+
+  git lfs import --from . 2>&1 | tee import.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "same object store" import.log
+
+  git lfs import --from ../does-not-exist 2>&1 | tee import.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "Cannot import from ../does-not-exist: no Git LFS object store found" import.log
+)
+end_test
+
+begin_test "import skips corrupt objects"
+(
+  set -e
+
+  reponame="import-corrupt"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  oid_a="$(calc_oid "a")"
+  oid_b="$(calc_oid "b")"
+
+  # Replace an object with other contents of the same size.
+  objpath=".git/lfs/objects/${oid_b:0:2}/${oid_b:2:2}/$oid_b"
+  chmod u+w "$objpath"
+  printf "x" > "$objpath"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs import --from "../$reponame" 2>&1 | tee import.log
+  grep "import: unable to import $oid_b: object $oid_b is corrupt" import.log
+  grep "import: imported 1 of 2 missing object(s)" import.log
+  assert_local_object "$oid_a" 1
+  refute_local_object "$oid_b"
+)
+end_test
+
+begin_test "import honors lfs.storage of the other repository"
+(
+  set -e
+
+  reponame="import-storage"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.storage "$TRASHDIR/$reponame-storage"
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  oid_a="$(calc_oid "a")"
+  [ -f "$TRASHDIR/$reponame-storage/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" ]
+  [ ! -d .git/lfs/objects ]
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs import --from "../$reponame" 2>&1 | tee import.log
+  grep "import: imported 1 of 1 missing object(s)" import.log
+  assert_local_object "$oid_a" 1
+)
+end_test
@@ -166,3 +166,41 @@ begin_test "install --worktree with conflicting scope"
   [ "0" != "$res" ]
 )
 end_test
+
+begin_test "install --worktree --skip-smudge in one of multiple working trees"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-skip-smudge"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs install --local
+  git lfs track "*.dat"
+  contents="worktree"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  git config core.repositoryformatversion 1
+  git config extensions.worktreeConfig true
+
+  treename="$TRASHDIR/$reponame-ci"
+  git worktree add --no-checkout -b ci "$treename"
+
+  pushd "$treename"
+    git lfs install --worktree --skip-smudge
+    [ "git-lfs smudge --skip -- %f" = "$(git config --worktree filter.lfs.smudge)" ]
+
+    git reset --hard
+    grep "oid sha256:$(calc_oid "$contents")" a.dat
+  popd
+
+  # the main working tree keeps smudging objects
+  [ "git-lfs smudge -- %f" = "$(git config filter.lfs.smudge)" ]
+  rm a.dat
+  git checkout a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
@@ -0,0 +1,67 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "json: typed results and events"
+(
+  set -e
+
+  reponame="json-typed"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git lfs --json du > du.json
+  [ "1" -eq "$(wc -l < du.json)" ]
+  grep '"version":1,"command":"du","type":"result"' du.json
+  grep '"objects":{"count":1,"size":1}' du.json
+
+  git lfs verify --json 2>&1 | tee verify.json
+  [ "5" -eq "${PIPESTATUS[0]}" ]
+  grep "\"command\":\"verify\",\"type\":\"event\",\"event\":\"missing\",\"data\":{\"path\":\"a.dat\",\"oid\":\"$contents_oid\"}" verify.json
+  grep '"type":"result","data":{"ref":"[^"]*","remote":"origin","objects":1,"missing":1}' verify.json
+
+  git lfs doctor --offline --json > doctor.json
+  grep '"event":"check","data":{"status":"ok","message":"Git LFS filters are configured"}' doctor.json
+  grep '"type":"result","data":{"errors":0,"warnings":0}' doctor.json
+)
+end_test
+
+begin_test "json: text output and errors"
+(
+  set -e
+
+  reponame="json-text"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs --json track "*.dat" > track.json
+  grep '"version":1,"command":"track","type":"event","event":"message","data":{"message":"Tracking \\"\*.dat\\""}' track.json
+
+  git lfs import --json > import.json 2> import.err && exit 1
+  grep '"version":1,"command":"import","type":"error","error":"Usage: git lfs import' import.json
+  [ ! -s import.err ]
+)
+end_test
+
+begin_test "json: results of commands with their own formats"
+(
+  set -e
+
+  reponame="json-own"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs --json status > status.json
+  [ '{"version":1,"command":"status","type":"result","data":{"files":{},"staged":{},"unstaged":{}}}' = "$(cat status.json)" ]
+
+  git lfs ls-files --json > ls-files.json
+  [ '{"version":1,"command":"ls-files","type":"result","data":{"files":[]}}' = "$(cat ls-files.json)" ]
+)
+end_test
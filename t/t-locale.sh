@@ -0,0 +1,79 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "locale: messages translated from environment"
+(
+  set -e
+
+  reponame="locale-environment"
+  git init "$reponame"
+  cd "$reponame"
+
+  LC_ALL=de_DE.UTF-8 git lfs track "*.dat" > track.log 2>&1
+  grep 'Verfolge "\*.dat"' track.log
+
+  LC_ALL= LANG=fr_FR.UTF-8 git lfs track "*.bin" > track.log 2>&1
+  grep 'Suivi de "\*.bin"' track.log
+
+  # Languages without a catalog, and the C locale, are left in English.
+  LC_ALL=xx_XX.UTF-8 git lfs track "*.png" > track.log 2>&1
+  grep 'Tracking "\*.png"' track.log
+
+  git lfs track "*.jpg" > track.log 2>&1
+  grep 'Tracking "\*.jpg"' track.log
+)
+end_test
+
+begin_test "locale: lfs.locale overrides environment"
+(
+  set -e
+
+  reponame="locale-config"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.locale fr_FR.UTF-8
+  LC_ALL=de_DE.UTF-8 git lfs track "*.dat" > track.log 2>&1
+  grep 'Suivi de "\*.dat"' track.log
+
+  git lfs untrack "*.dat" > untrack.log 2>&1
+  grep 'Fin du suivi de "\*.dat"' untrack.log
+)
+end_test
+
+begin_test "locale: progress translated"
+(
+  set -e
+
+  reponame="locale-progress"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  LC_ALL=de_DE.UTF-8 git push origin main > push.log 2>&1
+  grep "Lade LFS-Objekte hoch: 100% (1/1), 1 B" push.log
+)
+end_test
+
+begin_test "locale: --json output not translated"
+(
+  set -e
+
+  reponame="locale-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  LC_ALL=de_DE.UTF-8 git lfs --json track "*.dat" > track.json 2>&1
+  grep 'Tracking \\"\*.dat\\"' track.json
+  [ "0" -eq "$(grep -c "Verfolge" track.json)" ]
+
+  cd ..
+  LC_ALL=de_DE.UTF-8 git lfs --json status > status.json 2>&1 && exit 1
+  grep "Not in a git repository" status.json
+)
+end_test
@@ -89,9 +89,52 @@ begin_test "lock multiple files"
   git commit -m "add dat files"
   git push origin main:other
 
-  git lfs lock *.dat >log 2>&1 && exit 1
+  git lfs lock *.dat | tee lock.log
+  grep "Locked a.dat" lock.log
+  grep "Locked b.dat" lock.log
+  grep "Locked 2 files." lock.log
+
+  for f in a.dat b.dat; do
+    git lfs locks --json --path "$f" | tee locks.json
+    assert_server_lock "$reponame" "$(assert_lock locks.json "$f")"
+  done
+)
+end_test
+
+begin_test "lock multiple files (glob and conflicts)"
+(
+  set -e
+
+  reponame="lock-multiple-files-glob"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p assets/sub
+  for f in a b c; do
+    echo "$f" > "assets/$f.dat"
+  done
+  echo "d" > assets/sub/d.dat
+  git add .gitattributes assets
+  git commit -m "add dat files"
+  git push origin main:other
+
+  git lfs lock --json assets/b.dat | tee lock.json
+  assert_server_lock "$reponame" "$(assert_lock lock.json assets/b.dat)"
 
-  grep "Usage:" log
+  git lfs lock "assets/*" >lock.log 2>&1 && exit 1
+  cat lock.log
+  grep "Locked assets/a.dat" lock.log
+  grep "Locked assets/c.dat" lock.log
+  grep "Unable to lock assets/b.dat: .*lock already created" lock.log
+  grep "Locked 2 of 3 files; 1 failed." lock.log
+  [ 0 -eq "$(grep -c "assets/sub" lock.log)" ]
+
+  (cd assets && git lfs lock --json "sub/*.dat") | tee lock.json
+  assert_server_lock "$reponame" "$(assert_lock lock.json assets/sub/d.dat)"
+
+  git lfs lock "nothing/*.dat" 2>&1 | tee lock.log
+  grep "no files match" lock.log
 )
 end_test
 
@@ -356,3 +399,37 @@ begin_test "lock with git-lfs-transfer"
   assert_server_lock_ssh "$reponame" "$id" "refs/heads/main"
 )
 end_test
+
+begin_test "lock with lock helper"
+(
+  set -e
+
+  reponame="lock-with-helper"
+  setup_remote_repo_with_file "$reponame" "a.dat"
+  clone_repo "$reponame" "$reponame"
+
+  cat > ../lock-helper.sh <<-EOF
+	#!/bin/sh
+	cat > "$(pwd)/../lock-helper-request.json"
+	echo '{"lock":{"id":"helper-1","path":"a.dat","locked_at":"2020-01-01T00:00:00Z","owner":{"name":"Helper"}}}'
+	EOF
+  git config lfs.lockhelper "sh '$(pwd)/../lock-helper.sh'"
+
+  git lfs lock --json "a.dat" | tee lock.json
+  grep '"id":"helper-1"' lock.json
+  grep '"operation":"lock"' ../lock-helper-request.json
+  grep '"remote":"origin"' ../lock-helper-request.json
+  grep '"path":"a.dat"' ../lock-helper-request.json
+
+  # The lock was taken by the helper, not the server.
+  git config --unset lfs.lockhelper
+  [ "0" -eq "$(git lfs locks | wc -l)" ]
+  git lfs locks --local | grep "helper-1"
+
+  # A helper failure is reported along with its error output.
+  printf '#!/bin/sh\necho "no locks here" >&2\nexit 1\n' > ../lock-helper.sh
+  git config lfs.lockhelper "sh '$(pwd)/../lock-helper.sh'"
+  git lfs lock "a.dat" 2>&1 | tee lock.log
+  grep "no locks here" lock.log
+)
+end_test
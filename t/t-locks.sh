@@ -189,6 +189,104 @@ begin_test "list locks with pagination"
 )
 end_test
 
+begin_test "list locks with a cursor"
+(
+  set -e
+
+  reponame="locks_list_cursor"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  for i in $(seq 1 5); do
+    echo "$i" > "c_$i.dat"
+  done
+
+  git add *.dat ".gitattributes"
+  git commit -m "add files"
+  git push origin main 2>&1 | tee push.log
+  grep "main -> main" push.log
+
+  for i in $(seq 1 5); do
+    git lfs lock --json "c_$i.dat" | tee lock.log
+    assert_server_lock "$reponame" "$(assert_lock "lock.log" "c_$i.dat")"
+  done
+
+  git lfs locks --limit 2 >locks.log 2>locks.err
+  cat locks.log locks.err
+  [ $(wc -l < locks.log) -eq 2 ]
+  cursor="$(grep -o -- "--cursor=[^ ]*[^ .]" locks.err | cut -d= -f2)"
+  [ -n "$cursor" ]
+
+  git lfs locks --limit 2 --json >locks.json
+  cat locks.json
+  grep "\"type\":\"event\",\"event\":\"cursor\",\"data\":{\"cursor\":\"$cursor\"}" locks.json
+
+  git lfs locks --limit 2 --cursor "$cursor" >next.log 2>next.err
+  cat next.log next.err
+  [ $(wc -l < next.log) -eq 2 ]
+  cursor="$(grep -o -- "--cursor=[^ ]*[^ .]" next.err | cut -d= -f2)"
+
+  git lfs locks --cursor "$cursor" --json >last.log 2>last.err
+  cat last.log last.err
+  [ 1 -eq "$(grep -o '"path"' last.log | wc -l)" ]
+  [ 0 -eq "$(grep -c "More locks are available" last.err)" ]
+
+  cat locks.log next.log | awk '{print $1}' | sort >seen.log
+  grep -o '"path":"[^"]*"' last.log | cut -d'"' -f4 >>seen.log
+  [ 5 -eq "$(sort -u seen.log | wc -l)" ]
+
+  git lfs locks --cursor "$cursor" --local 2>&1 | tee locks.log
+  grep "can't be combined with --local" locks.log
+)
+end_test
+
+begin_test "list locks by owner and path prefix"
+(
+  set -e
+
+  reponame="locks_list_owner_prefix"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p dir/sub dirty
+  echo "a" > a.dat
+  echo "b" > dir/b.dat
+  echo "c" > dir/sub/c.dat
+  echo "d" > dirty/d.dat
+
+  git add -A
+  git commit -m "add files"
+  git push origin main 2>&1 | tee push.log
+  grep "main -> main" push.log
+
+  for f in a.dat dir/b.dat dir/sub/c.dat dirty/d.dat; do
+    git lfs lock --json "$f" | tee lock.log
+    assert_server_lock "$reponame" "$(assert_lock "lock.log" "$f")"
+  done
+
+  git lfs locks --path-prefix dir | tee locks.log
+  [ 2 -eq "$(wc -l < locks.log)" ]
+  grep "dir/b.dat" locks.log
+  grep "dir/sub/c.dat" locks.log
+
+  (cd dir && git lfs locks --path-prefix sub) | tee locks.log
+  [ 1 -eq "$(wc -l < locks.log)" ]
+  grep "dir/sub/c.dat" locks.log
+
+  git lfs locks --owner "Git LFS Tests" | tee locks.log
+  [ 4 -eq "$(wc -l < locks.log)" ]
+
+  git lfs locks --owner "Someone Else" | tee locks.log
+  [ 0 -eq "$(wc -l < locks.log)" ]
+
+  git lfs locks --owner "Git LFS Tests" --path-prefix dir --limit 1 2>/dev/null | tee locks.log
+  [ 1 -eq "$(wc -l < locks.log)" ]
+  grep "dir/" locks.log
+)
+end_test
+
 begin_test "cached locks"
 (
   set -e
@@ -231,3 +329,180 @@ begin_test "cached locks"
   [ $(wc -l < locks.log) -eq 1 ]
 )
 end_test
+
+begin_test "sync locks"
+(
+  set -e
+
+  reponame="sync_locks"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track --lockable "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat b.dat
+  assert_file_writeable a.dat
+  assert_file_writeable b.dat
+
+  # Release one lock from elsewhere and take another, neither of which the
+  # local cache knows about.
+  id="$(git lfs locks --json --path a.dat | tr -d '\n' | sed -e 's/.*"id":"\([^"]*\)".*/\1/')"
+  cd ..
+  clone_repo "$reponame" "$reponame-other"
+  git lfs unlock --force --id "$id"
+  cd "../$reponame"
+
+  git lfs locks --local | grep "a.dat"
+  assert_file_writeable a.dat
+
+  git lfs locks --sync 2>sync.err | tee locks.log
+  grep "Removed 1 stale cached lock(s)" sync.err
+  [ "1" -eq "$(wc -l < locks.log)" ]
+  grep "O b.dat" locks.log
+
+  git lfs locks --local | tee locks.log
+  [ "0" -eq "$(grep -c "a.dat" locks.log)" ]
+  grep "b.dat" locks.log
+
+  refute_file_writeable a.dat
+  assert_file_writeable b.dat
+
+  # The synchronized list is available offline.
+  git lfs locks --cached --verify | tee locks.log
+  grep "O b.dat" locks.log
+
+  git lfs locks --sync --local 2>&1 | tee sync.log
+  grep "\-\-sync option can't be combined with \-\-local" sync.log
+  git lfs locks --sync --cached 2>&1 | tee sync.log
+  grep "\-\-sync option can't be combined with \-\-cached" sync.log
+)
+end_test
+
+begin_test "verify locks marks our own and those blocking a push"
+(
+  set -e
+
+  reponame="verify_locks"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > mine.dat
+  printf "b" > a_theirs.dat
+  printf "c" > b_theirs.dat
+  git add .gitattributes mine.dat a_theirs.dat b_theirs.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  # any lock path with "theirs" is returned as "their" lock by /locks/verify
+  git lfs lock mine.dat a_theirs.dat b_theirs.dat
+
+  git lfs locks --verify | tee locks.log
+  [ "3" -eq "$(wc -l < locks.log)" ]
+  grep "^O mine.dat" locks.log
+  grep "^  a_theirs.dat" locks.log
+  grep "^  b_theirs.dat" locks.log
+
+  printf "changed" > a_theirs.dat
+  printf "changed" > mine.dat
+  git add a_theirs.dat mine.dat
+  git commit -m "change locked files"
+
+  git lfs locks --verify | tee locks.log
+  grep "^O mine.dat" locks.log
+  grep "^B a_theirs.dat" locks.log
+  grep "^  b_theirs.dat" locks.log
+
+  git lfs locks --verify --json | tee locks.json
+  grep '"blocking":\[{[^]]*"path":"a_theirs.dat"' locks.json
+  [ "0" -eq "$(grep -c '"blocking":\[[^]]*b_theirs.dat' locks.json)" ]
+)
+end_test
+
+begin_test "locks with expiry"
+(
+  set -e
+
+  reponame="locks_expiring"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > soon.dat
+  git add .gitattributes a.dat soon.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat | tee lock.log
+  grep "Locked a.dat (expires in 2h)" lock.log
+  git lfs lock soon.dat | tee lock.log
+  grep "Locked soon.dat (expires in 5m)" lock.log
+
+  git lfs locks 2>locks.err | tee locks.log
+  grep "a.dat.*expires in 2h" locks.log
+  grep "soon.dat.*expires in 5m" locks.log
+  grep "Warning: your lock on soon.dat expires in 5m" locks.err
+  [ "0" -eq "$(grep -c "a.dat" locks.err)" ]
+
+  git lfs locks --json 2>/dev/null | tee locks.json
+  grep '"expires_at":' locks.json
+
+  # Nothing is renewed while the locked files are unchanged.
+  git lfs locks --renew | tee renew.log
+  grep "No locks to renew" renew.log
+
+  printf "changed" > soon.dat
+  git lfs locks --renew | tee renew.log
+  grep "Renewed lock on soon.dat, which expires in 2h" renew.log
+  [ "0" -eq "$(grep -c "a.dat" renew.log)" ]
+
+  git lfs locks 2>locks.err | tee locks.log
+  grep "soon.dat.*expires in 2h" locks.log
+  [ "0" -eq "$(grep -c "Warning" locks.err)" ]
+
+  # Committed changes still need the lock until they are pushed.
+  git add soon.dat
+  git commit -m "change soon.dat"
+  git lfs locks --renew | tee renew.log
+  grep "Renewed lock on soon.dat" renew.log
+
+  git push origin main
+  git lfs locks --renew-every=1s | tee renew.log
+  grep "No locks to renew" renew.log
+
+  git lfs locks --renew --local 2>&1 | tee renew.log
+  grep "\-\-renew option can't be combined with other options" renew.log
+)
+end_test
+
+begin_test "locks without expiry"
+(
+  set -e
+
+  reponame="locks_permanent"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat | tee lock.log
+  [ "Locked a.dat" = "$(cat lock.log)" ]
+
+  git lfs locks | tee locks.log
+  [ "0" -eq "$(grep -c "expire" locks.log)" ]
+
+  printf "changed" > a.dat
+  git lfs locks --renew | tee renew.log
+  grep "No locks to renew" renew.log
+)
+end_test
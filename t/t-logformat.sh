@@ -0,0 +1,91 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "logformat: json trace messages"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.logformat json
+  git lfs track "*.dat"
+  echo "json" > a.dat
+
+  GIT_TRACE="$(pwd)/trace.json" GIT_LFS_CORRELATION_ID=abc123 git add a.dat
+  cat trace.json
+
+  # Every message is a record, including those written before lfs.logformat
+  # was read, and those of the filter run by Git share the correlation id.
+  # The other lines are Git's own trace messages.
+  [ "0" -eq "$(grep -c "trace git-lfs:" trace.json)" ]
+  [ "0" -eq "$(grep '^{"time":"' trace.json | grep -vc '"correlation_id":"abc123"')" ]
+  grep '"level":"trace","command":"filter-process","pid":[0-9]*,"correlation_id":"abc123","message":"Initialize filter-process"' trace.json
+  grep "exec: git 'version'" trace.json
+)
+end_test
+
+begin_test "logformat: json performance messages"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-performance"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "performance" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.logformat json
+  GIT_TRACE=0 GIT_TRACE_PERFORMANCE=1 git lfs ls-files 2> perf.log
+  cat perf.log
+
+  grep '"level":"performance","command":"ls-files",.*"seconds":' perf.log
+  [ "0" -eq "$(grep -c '"level":"trace"' perf.log)" ]
+)
+end_test
+
+begin_test "logformat: text by default"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-text"
+  git init "$reponame"
+  cd "$reponame"
+
+  GIT_TRACE=1 git lfs env 2> trace.log >/dev/null
+  grep "trace git-lfs: exec: git 'version'" trace.log
+  [ "0" -eq "$(grep -c '"correlation_id"' trace.log)" ]
+)
+end_test
+
+begin_test "logformat: json error logs"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-errors"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.logformat json
+
+  set +e
+  GIT_TRACE=1 git lfs logs boomtown 2> trace.log
+  boomtownExit=$?
+  set -e
+  [ "$boomtownExit" = "2" ]
+  cat trace.log
+
+  grep '"level":"error","command":"boomtown",.*"message":"Error: Inner error message!"' trace.log
+
+  logfile=".git/lfs/logs/$(ls .git/lfs/logs)"
+  cat "$logfile"
+  grep '^{"time":"' "$logfile"
+  grep '"command":"boomtown"' "$logfile"
+  grep '"args":\["git-lfs","logs","boomtown"\]' "$logfile"
+)
+end_test
@@ -61,6 +61,37 @@ begin_test "ls-files: --size"
 )
 end_test
 
+begin_test "ls-files: --json"
+(
+  set -e
+
+  reponame="ls-files-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir dir
+  printf '*.bin filter=lfs diff=lfs merge=lfs -text\n' > dir/.gitattributes
+  git add .gitattributes dir/.gitattributes
+  git commit -m "initial commit"
+
+  contents="contents"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  printf "%s" "$contents" > dir/b.bin
+
+  git add a.dat dir/b.bin
+  git commit -m "add a.dat and dir/b.bin"
+
+  git lfs ls-files --json 2>&1 | tee ls.log
+  expected="{\"version\":1,\"command\":\"ls-files\",\"type\":\"result\",\"data\":{\"files\":[{\"name\":\"a.dat\",\"size\":8,\"checkout\":true,\"downloaded\":true,\"oid_type\":\"sha256\",\"oid\":\"$oid\",\"version\":\"https://git-lfs.github.com/spec/v1\",\"content_type\":\"text/plain; charset=utf-8\",\"pattern\":\"*.dat\"},{\"name\":\"dir/b.bin\",\"size\":8,\"checkout\":true,\"downloaded\":true,\"oid_type\":\"sha256\",\"oid\":\"$oid\",\"version\":\"https://git-lfs.github.com/spec/v1\",\"content_type\":\"text/plain; charset=utf-8\",\"pattern\":\"dir/*.bin\"}]}}"
+  [ "$expected" = "$(cat ls.log)" ]
+
+  git lfs ls-files --json --include="*.txt" 2>&1 | tee ls.log
+  [ '{"version":1,"command":"ls-files","type":"result","data":{"files":[]}}' = "$(cat ls.log)" ]
+)
+end_test
+
 begin_test "ls-files: indexed files without tree"
 (
   set -e
@@ -515,4 +546,47 @@ begin_test "ls-files: not affected by lfs.fetchexclude"
   git config lfs.fetchexclude '*'
   [ "6bbd052ab0 * missing.dat" = "$(git lfs ls-files)" ]
 )
-end_test
\ No newline at end of file
+end_test
+
+begin_test "ls-files: working tree state from index with fsmonitor"
+(
+  set -e
+
+  reponame="ls-files-fsmonitor"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "checked out" > a.dat
+  echo "replaced by pointer" > b.dat
+  echo "deleted" > c.dat
+  git add .gitattributes a.dat b.dat c.dat
+  git commit -m "initial commit"
+
+  # This hook reports every file as changed, so that Git examines each of
+  # them when it refreshes the index.
+  hook="$(pwd)/.git/hooks/fsmonitor-test"
+  printf '#!/bin/sh\nprintf "token\\0/\\0"\n' > "$hook"
+  chmod +x "$hook"
+  git config core.fsmonitor "$hook"
+
+  git cat-file -p :b.dat > b.dat
+  rm c.dat
+
+  index="$(git hash-object .git/index)"
+  GIT_TRACE=1 git lfs ls-files 2>trace.log | tee ls.log
+  grep "ls-files.*--cached.*--debug" trace.log
+  [ "$index" = "$(git hash-object .git/index)" ]
+  grep "a.dat" ls.log | grep -F "*"
+  grep "b.dat" ls.log | grep -F -- "-"
+  grep "c.dat" ls.log | grep -F -- "-"
+
+  git config --unset core.fsmonitor
+  GIT_TRACE=1 git lfs ls-files 2>trace.log | tee ls-nofsmonitor.log
+  if grep "ls-files.*--cached.*--debug" trace.log; then
+    echo >&2 "fatal: expected the index not to be read without core.fsmonitor"
+    exit 1
+  fi
+  diff -u ls.log ls-nofsmonitor.log
+)
+end_test
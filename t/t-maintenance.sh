@@ -0,0 +1,171 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# setup_fake_crontab puts a crontab(1) on the PATH which keeps the crontab in
+# "$TRASHDIR/crontab", and has Git use it for its own schedule too.
+setup_fake_crontab() {
+  mkdir -p "$TRASHDIR/bin"
+  cat > "$TRASHDIR/bin/crontab" <<EOF
+#!/bin/sh
+if [ "\$1" = "-l" ]; then
+  cat "$TRASHDIR/crontab" 2>/dev/null
+else
+  cp "\$1" "$TRASHDIR/crontab"
+fi
+EOF
+  chmod +x "$TRASHDIR/bin/crontab"
+  export PATH="$TRASHDIR/bin:$PATH"
+  export GIT_TEST_MAINT_SCHEDULER="crontab:crontab"
+}
+
+begin_test "maintenance run: prefetch, temp and prune"
+(
+  set -e
+
+  reponame="maintenance-run"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="maintained"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  refute_local_object "$contents_oid"
+
+  git lfs maintenance run --task=prefetch 2>&1 | tee maintenance.log
+  assert_local_object "$contents_oid" ${#contents}
+
+  # Let the automatic daily cleanup run before leaving stale files behind.
+  git lfs ls-files >/dev/null
+  mkdir -p .git/lfs/incomplete
+  printf "abc" > .git/lfs/incomplete/to-destroy
+  touch -d "8 days ago" .git/lfs/incomplete/to-destroy
+
+  git lfs maintenance run --task=temp 2>&1 | tee maintenance.log
+  grep "maintenance: removed 1 stale temporary file(s)" maintenance.log
+  [ ! -f .git/lfs/incomplete/to-destroy ]
+
+  orphan_oid="$(calc_oid "orphan")"
+  printf "orphan" > orphan.dat
+  git add orphan.dat
+  git rm --cached orphan.dat
+  rm orphan.dat
+  assert_local_object "$orphan_oid" 6
+
+  git lfs maintenance run --task=prune 2>&1 | tee maintenance.log
+  refute_local_object "$orphan_oid"
+  assert_local_object "$contents_oid" ${#contents}
+)
+end_test
+
+begin_test "maintenance run: schedules and enabled tasks"
+(
+  set -e
+
+  reponame="maintenance-schedule"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="scheduled"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs ls-files >/dev/null
+  mkdir -p .git/lfs/incomplete
+  printf "abc" > .git/lfs/incomplete/to-destroy
+  touch -d "8 days ago" .git/lfs/incomplete/to-destroy
+
+  # Disabled tasks are not run on a schedule.
+  git config lfs.maintenance.prefetch.enabled false
+  git lfs maintenance run --schedule=hourly
+  refute_local_object "$contents_oid"
+  [ -f .git/lfs/incomplete/to-destroy ]
+
+  # Hourly runs include only hourly tasks; daily runs include those too.
+  git config --unset lfs.maintenance.prefetch.enabled
+  git lfs maintenance run --schedule=hourly
+  assert_local_object "$contents_oid" ${#contents}
+  [ -f .git/lfs/incomplete/to-destroy ]
+
+  git lfs maintenance run --schedule=daily
+  [ ! -f .git/lfs/incomplete/to-destroy ]
+
+  git lfs maintenance run --schedule=monthly > maintenance.log 2>&1 && exit 1
+  grep "Invalid schedule \"monthly\"" maintenance.log
+  git lfs maintenance run --task=gc > maintenance.log 2>&1 && exit 1
+  grep "Invalid maintenance task \"gc\"" maintenance.log
+  git lfs maintenance run --task=temp --schedule=daily > maintenance.log 2>&1 && exit 1
+  grep "Cannot combine --task with --schedule" maintenance.log
+)
+end_test
+
+begin_test "maintenance register and unregister"
+(
+  set -e
+
+  reponame="maintenance-register"
+  git init "$reponame"
+  cd "$reponame"
+  repo="$(pwd)"
+
+  git lfs maintenance register
+  git lfs maintenance register
+  [ "$repo" = "$(git config --global --get-all lfs.maintenance.repo)" ]
+  git config --global --get-all maintenance.repo | grep -x "$repo"
+
+  git lfs maintenance unregister
+  [ -z "$(git config --global --get-all lfs.maintenance.repo)" ]
+  ! git config --global --get-all maintenance.repo | grep -x "$repo"
+)
+end_test
+
+begin_test "maintenance start and stop"
+(
+  set -e
+
+  setup_fake_crontab
+  printf "0 0 * * * other-job\n" > "$TRASHDIR/crontab"
+
+  reponame="maintenance-start"
+  git init "$reponame"
+  cd "$reponame"
+  repo="$(pwd)"
+
+  git lfs maintenance start
+  cat "$TRASHDIR/crontab"
+  [ "$repo" = "$(git config --global --get-all lfs.maintenance.repo)" ]
+  grep "other-job" "$TRASHDIR/crontab"
+  grep "BEGIN GIT MAINTENANCE SCHEDULE" "$TRASHDIR/crontab"
+  grep "BEGIN GIT LFS MAINTENANCE SCHEDULE" "$TRASHDIR/crontab"
+  for schedule in hourly daily weekly; do
+    grep "for-each-repo --config=lfs.maintenance.repo lfs maintenance run --schedule=$schedule" "$TRASHDIR/crontab"
+  done
+
+  # Starting again replaces the schedule rather than adding another.
+  git lfs maintenance start
+  [ 1 -eq "$(grep -c "BEGIN GIT LFS MAINTENANCE SCHEDULE" "$TRASHDIR/crontab")" ]
+
+  git lfs maintenance stop
+  cat "$TRASHDIR/crontab"
+  grep "other-job" "$TRASHDIR/crontab"
+  ! grep "GIT LFS MAINTENANCE" "$TRASHDIR/crontab"
+  ! grep "GIT MAINTENANCE" "$TRASHDIR/crontab"
+  [ "$repo" = "$(git config --global --get-all lfs.maintenance.repo)" ]
+)
+end_test
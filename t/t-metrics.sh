@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "metrics: otlp"
+(
+  set -e
+
+  reponame="metrics-otlp"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  printf "defgh" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  git config lfs.metrics.otlp "$GITSERVER/otlp/$reponame-push"
+  git lfs push origin main
+
+  curl -s "$GITSERVER/otlp/$reponame-push" | tee push.json
+  [ 1 -eq "$(wc -l < push.json)" ]
+  grep '"name":"git_lfs.batch.requests","unit":"1","sum":{"aggregationTemporality":1,"isMonotonic":true' push.json
+  grep '"name":"git_lfs.transfer.objects".*"key":"direction","value":{"stringValue":"upload"}.*"asInt":"2"' push.json
+  grep '"name":"git_lfs.transfer.bytes".*"asInt":"8"' push.json
+  grep '"name":"git_lfs.transfer.duration","unit":"ms","histogram":' push.json
+  grep '"key":"command","value":{"stringValue":"push"}' push.json
+
+  rm -rf .git/lfs/objects
+  git config lfs.metrics.otlp "$GITSERVER/otlp/$reponame-fetch"
+  git config lfs.metrics.prefix ""
+  git lfs fetch origin main
+  git lfs fetch origin main
+
+  curl -s "$GITSERVER/otlp/$reponame-fetch" | tee fetch.json
+  [ 2 -eq "$(wc -l < fetch.json)" ]
+  head -n 1 fetch.json > first.json
+  grep '"name":"objects.cache".*"key":"result","value":{"stringValue":"miss"}[^]]*\],[^]]*"asInt":"2"' first.json
+  grep '"name":"transfer.objects".*"key":"direction","value":{"stringValue":"download"}.*"asInt":"2"' first.json
+
+  # Objects already present are counted as cache hits, and nothing else
+  # happens.
+  tail -n 1 fetch.json > second.json
+  grep '"name":"objects.cache".*"key":"result","value":{"stringValue":"hit"}.*"asInt":"2"' second.json
+  [ 0 -eq "$(grep -c '"name":"batch.requests"' second.json)" ]
+)
+end_test
+
+begin_test "metrics: unreachable collector"
+(
+  set -e
+
+  reponame="metrics-unreachable"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # A collector which cannot be reached does not fail the command.
+  git config lfs.metrics.otlp "http://127.0.0.1:1/v1/metrics"
+  GIT_TRACE=1 git lfs push origin main 2>&1 | tee push.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+  grep "metrics: unable to send metrics: " push.log
+)
+end_test
+
+begin_test "metrics: transfer errors"
+(
+  set -e
+
+  reponame="metrics-errors"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  GIT_LFS_SKIP_PUSH=1 git push origin main
+
+  rm -rf .git/lfs/objects
+  git config lfs.metrics.otlp "$GITSERVER/otlp/$reponame"
+
+  set +e
+  git lfs fetch origin main
+  res=$?
+  set -e
+  [ "5" -eq "$res" ]
+
+  curl -s "$GITSERVER/otlp/$reponame" | tee fetch.json
+  grep '"name":"git_lfs.transfer.errors".*"key":"code","value":{"stringValue":"not-found"}.*"asInt":"1"' fetch.json
+)
+end_test
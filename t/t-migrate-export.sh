@@ -384,6 +384,30 @@ begin_test "migrate export (--object-map)"
 )
 end_test
 
+begin_test "migrate export (compressed objects)"
+(
+  set -e
+
+  setup_single_local_branch_tracked
+
+  # Objects are only stored compressed if they shrink.
+  head -c 10000 /dev/zero | tr "\\0" "x" > a.md
+  git add a.md
+  git commit -m "make a.md compressible"
+
+  md_oid="$(calc_oid_file a.md)"
+  cp a.md "$TRASHDIR/a.md.orig"
+
+  git lfs compress
+  [ "$(dd if=.git/lfs/objects/${md_oid:0:2}/${md_oid:2:2}/$md_oid.zst bs=12 count=1 2>/dev/null)" = "git-lfs-zstd" ]
+
+  git lfs migrate export --include="*.md"
+
+  refute_pointer "refs/heads/main" "a.md"
+  git cat-file -p "refs/heads/main:a.md" | cmp - "$TRASHDIR/a.md.orig"
+)
+end_test
+
 begin_test "migrate export (--verbose)"
 (
   set -e
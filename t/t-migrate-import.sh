@@ -645,6 +645,36 @@ begin_test "migrate import (prefix include(s))"
 )
 end_test
 
+begin_test "migrate import (negated include)"
+(
+  set -e
+
+  setup_single_local_branch_deep_trees
+
+  base64 < /dev/urandom | head -c 140 > foo/bar/b.txt
+  git add foo/bar/b.txt
+  git commit -m "add b.txt"
+
+  a_oid="$(calc_oid "$(git cat-file -p :foo/bar/baz/a.txt)")"
+  b_oid="$(calc_oid "$(git cat-file -p :foo/bar/b.txt)")"
+
+  git lfs migrate import --include="foo/**,!foo/bar/baz/**"
+
+  assert_pointer "refs/heads/main" "foo/bar/b.txt" "$b_oid" 140
+  refute_pointer "refs/heads/main" "foo/bar/baz/a.txt"
+  refute_local_object "$a_oid"
+
+  attrs="$(git cat-file -p :.gitattributes)"
+  echo "$attrs" | grep -x "foo/\*\* filter=lfs diff=lfs merge=lfs -text"
+  echo "$attrs" | grep -x "foo/bar/baz/\*\* !text -filter -merge -diff"
+  [ "0" -eq "$(echo "$attrs" | grep -c "^!")" ]
+
+  # Git applies the later, negating line to the excluded files.
+  [ "lfs" = "$(git check-attr filter -- foo/bar/b.txt | awk '{ print $3 }')" ]
+  [ "unset" = "$(git check-attr filter -- foo/bar/baz/a.txt | awk '{ print $3 }')" ]
+)
+end_test
+
 begin_test "migrate import (--everything)"
 (
   set -e
@@ -829,6 +859,40 @@ begin_test "migrate import (--object-map)"
 )
 end_test
 
+begin_test "migrate import (--above, --include-ref, --object-map)"
+(
+  set -e
+
+  setup_multiple_local_branches
+
+  md_oid="$(calc_oid "$(git cat-file -p "refs/heads/main:a.md")")"
+  txt_oid="$(calc_oid "$(git cat-file -p "refs/heads/main:a.txt")")"
+  main="$(git rev-parse refs/heads/main)"
+  feature_old="$(git rev-parse refs/heads/my-feature)"
+
+  output_dir=$(mktemp -d)
+
+  git lfs migrate import --above 121B --include-ref=refs/heads/my-feature \
+    --object-map "${output_dir}/object-map.txt"
+
+  # main points into the rewritten history, so it is updated along with it.
+  feature_new="$(git rev-parse refs/heads/my-feature)"
+  [ "$feature_old" != "$feature_new" ]
+  [ "$(git rev-parse refs/heads/my-feature^)" = "$(git rev-parse refs/heads/main)" ]
+
+  # a.md is above the threshold in the first commit only, and a.txt never is.
+  assert_pointer "refs/heads/my-feature^" "a.md" "$md_oid" "140"
+  assert_local_object "$md_oid" "140"
+  refute_pointer "refs/heads/my-feature^" "a.txt" "$txt_oid" "120"
+  refute_local_object "$txt_oid" "120"
+
+  # Each rewritten commit is recorded against its original.
+  grep "^$feature_old,$feature_new\$" "${output_dir}/object-map.txt"
+  grep "^$main,$(git rev-parse refs/heads/my-feature^)\$" "${output_dir}/object-map.txt"
+  [ 2 -eq "$(wc -l < "${output_dir}/object-map.txt")" ]
+)
+end_test
+
 begin_test "migrate import (--include with space)"
 (
   set -e
@@ -0,0 +1,109 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# can_mount succeeds if FUSE file systems may be mounted here, either directly
+# as root or with fusermount(1).
+can_mount() {
+  [ "$(uname -s)" = "Linux" ] && [ -c /dev/fuse ] &&
+    { [ "$(id -u)" -eq 0 ] || command -v fusermount >/dev/null; }
+}
+
+# unmount_dir unmounts the FUSE file system at the given directory.
+unmount_dir() {
+  if [ "$(id -u)" -eq 0 ]; then
+    umount "$1"
+  else
+    fusermount -u "$1"
+  fi
+}
+
+# wait_for_mount waits for "git lfs mount" to report that it has mounted a tree
+# in the given log file.
+wait_for_mount() {
+  for i in $(seq 1 50); do
+    grep -q "Mounted" "$1" && return 0
+    sleep 0.1
+  done
+  cat "$1"
+  return 1
+}
+
+begin_test "mount: browse a ref and download on first read"
+(
+  set -e
+  if ! can_mount; then
+    echo "skip: FUSE mounts are not available"
+    exit 0
+  fi
+
+  reponame="mount-browse"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="mounted a"
+  contents_a_oid="$(calc_oid "$contents_a")"
+  contents_b="mounted b"
+  contents_b_oid="$(calc_oid "$contents_b")"
+  mkdir dir
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > dir/b.dat
+  printf "plain" > plain.txt
+  printf "#!/bin/sh\n" > run.sh
+  chmod +x run.sh
+  ln -s dir/b.dat link.dat
+  git add .gitattributes a.dat dir/b.dat plain.txt run.sh link.dat
+  git commit -m "add files"
+  git push origin main
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  refute_local_object "$contents_a_oid"
+  refute_local_object "$contents_b_oid"
+
+  mkdir "$TRASHDIR/mnt"
+  git lfs mount main "$TRASHDIR/mnt" > mount.log 2>&1 &
+  pid=$!
+  trap 'unmount_dir "$TRASHDIR/mnt" 2>/dev/null || true' EXIT
+  wait_for_mount mount.log
+
+  [ "$(ls "$TRASHDIR/mnt" | tr '\n' ' ')" = "a.dat dir link.dat plain.txt run.sh " ]
+  [ "$(stat -c %s "$TRASHDIR/mnt/a.dat")" -eq ${#contents_a} ]
+  [ -x "$TRASHDIR/mnt/run.sh" ]
+  [ "dir/b.dat" = "$(readlink "$TRASHDIR/mnt/link.dat")" ]
+  [ "plain" = "$(cat "$TRASHDIR/mnt/plain.txt")" ]
+
+  # Only the files which are read are downloaded.
+  [ "$contents_a" = "$(cat "$TRASHDIR/mnt/a.dat")" ]
+  assert_local_object "$contents_a_oid" ${#contents_a}
+  refute_local_object "$contents_b_oid"
+
+  [ "$contents_b" = "$(cat "$TRASHDIR/mnt/link.dat")" ]
+  assert_local_object "$contents_b_oid" ${#contents_b}
+
+  [ ! -w "$TRASHDIR/mnt/a.dat" ]
+  ! printf "x" > "$TRASHDIR/mnt/a.dat"
+
+  unmount_dir "$TRASHDIR/mnt"
+  wait "$pid"
+  grep "Downloading a.dat" mount.log
+  [ -z "$(ls "$TRASHDIR/mnt")" ]
+)
+end_test
+
+begin_test "mount: invalid ref"
+(
+  set -e
+
+  reponame="mount-invalid-ref"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir mnt
+  git lfs mount not-a-ref mnt > mount.log 2>&1 && exit 1
+  cat mount.log
+  grep "Could not resolve \"not-a-ref\"" mount.log
+)
+end_test
@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "noninteractive: push fails instead of prompting for credentials"
+(
+  set -e
+
+  reponame="noninteractive-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "hello" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  export LFS_ASKPASS_USERNAME="user"
+  export LFS_ASKPASS_PASSWORD="pass"
+  git config "credential.helper" ""
+
+  GIT_ASKPASS="lfs-askpass" GIT_TRACE=1 git lfs push --noninteractive origin main 2>&1 | tee push.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "are required, but GIT_LFS_FORCE_NONINTERACTIVE is set" push.log
+  [ "0" -eq "$(grep -c "filling with GIT_ASKPASS" push.log)" ]
+  refute_server_object "$reponame" "$(calc_oid "hello\n")"
+
+  GIT_ASKPASS="lfs-askpass" GIT_LFS_FORCE_NONINTERACTIVE=1 git lfs push origin main 2>&1 | tee push.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "are required, but GIT_LFS_FORCE_NONINTERACTIVE is set" push.log
+
+  GIT_ASKPASS="lfs-askpass" git lfs push origin main
+  assert_server_object "$reponame" "$(calc_oid "hello\n")"
+)
+end_test
+
+begin_test "noninteractive: migrate fails instead of asking to override changes"
+(
+  set -e
+
+  reponame="noninteractive-migrate"
+  git init "$reponame"
+  cd "$reponame"
+
+  echo "a" > a.dat
+  git add a.dat
+  git commit -m "initial commit"
+  echo "b" > a.dat
+
+  git lfs migrate import --noninteractive --include="*.dat" 2>&1 </dev/null | tee migrate.log
+  [ "2" -eq "${PIPESTATUS[0]}" ]
+  grep "migrate: working copy must not be dirty; pass --yes" migrate.log
+  [ "b" = "$(cat a.dat)" ]
+)
+end_test
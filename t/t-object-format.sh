@@ -0,0 +1,96 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+ensure_git_version_isnt $VERSION_LOWER "2.29.0"
+
+begin_test "object format: sha256 push and clone"
+(
+  set -e
+
+  export GIT_DEFAULT_HASH=sha256
+
+  reponame="object-format-sha256"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  [ "$(git config extensions.objectformat)" = "sha256" ]
+  [ "$(zero_oid | wc -c)" -eq 65 ]
+
+  git lfs track "*.dat"
+  contents="sha256 repo"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git tag -a -m "tag" v1.0
+
+  git lfs ls-files | tee ls-files.log
+  grep "${oid:0:10} \* a.dat" ls-files.log
+  git lfs ls-files --long HEAD | grep "$oid \* a.dat"
+  git lfs fsck
+
+  git lfs push --dry-run origin main 2>&1 | tee push.log
+  grep "push $oid => a.dat" push.log
+
+  git push origin main v1.0 2>&1 | tee push.log
+  grep "Uploading LFS objects: 100% (1/1)" push.log
+  assert_server_object "$reponame" "$oid"
+
+  cd ..
+  clone_repo "$reponame" "$reponame-clone"
+  [ "$contents" = "$(cat a.dat)" ]
+  assert_local_object "$oid" "${#contents}"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch --all origin
+  assert_local_object "$oid" "${#contents}"
+)
+end_test
+
+begin_test "object format: sha256 pre-push with new branch"
+(
+  set -e
+
+  export GIT_DEFAULT_HASH=sha256
+
+  reponame="object-format-sha256-pre-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="pre-push"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  echo "refs/heads/main $(git rev-parse HEAD) refs/heads/main $(zero_oid)" |
+    git lfs pre-push --dry-run origin "$GITSERVER/$reponame" 2>&1 |
+    tee push.log
+  grep "push $oid => a.dat" push.log
+)
+end_test
+
+begin_test "object format: sha256 migrate import"
+(
+  set -e
+
+  reponame="object-format-sha256-migrate"
+  git init --object-format=sha256 "$reponame"
+  cd "$reponame"
+
+  printf "a" > a.dat
+  git add a.dat
+  git commit -m "add a.dat"
+  printf "b" > a.dat
+  git commit -am "update a.dat"
+
+  git lfs migrate import --everything --include="*.dat"
+
+  git lfs ls-files --all | tee ls-files.log
+  grep "$(calc_oid "a" | cut -c 1-10) - a.dat" ls-files.log
+  grep "$(calc_oid "b" | cut -c 1-10) [-*] a.dat" ls-files.log
+  [ "$(git rev-parse HEAD | wc -c)" -eq 65 ]
+)
+end_test
@@ -0,0 +1,124 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# setup_partial_clone_repo creates a remote repository which allows partial
+# clones, holding three versions of a Git LFS file "a.dat" and one version
+# of each of the plain files "t1.txt" and "t2.txt".
+setup_partial_clone_repo() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  git config uploadpack.allowfilter true
+  git config uploadpack.allowanysha1inwant true
+
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a1" > a.dat
+  printf "t1" > t1.txt
+  git add .gitattributes a.dat t1.txt
+  git commit -m "add a.dat, t1.txt"
+  printf "a2" > a.dat
+  printf "t2" > t2.txt
+  git add a.dat t2.txt
+  git commit -m "update a.dat, add t2.txt"
+  printf "a3" > a.dat
+  git add a.dat
+  git commit -m "update a.dat"
+  git push origin main
+}
+
+# missing_objects prints the number of objects missing from the partial clone
+# in the current directory.
+missing_objects() {
+  git rev-list --objects --all --missing=print | grep -c "^?" || true
+}
+
+begin_test "partial clone: scan history without fetching plain blobs"
+(
+  set -e
+
+  reponame="partial-clone-scan"
+  setup_partial_clone_repo "$reponame"
+
+  cd "$TRASHDIR"
+  git clone --filter=blob:none --no-checkout "$GITSERVER/$reponame" "$reponame-partial"
+  cd "$reponame-partial"
+  [ "$(missing_objects)" -eq 6 ]
+
+  git lfs ls-files --all 2>&1 | tee ls-files.log
+  [ "$(grep -c "a.dat" ls-files.log)" -eq 3 ]
+  grep "$(calc_oid "a1" | cut -b 1-10) - a.dat" ls-files.log
+  grep "$(calc_oid "a3" | cut -b 1-10) - a.dat" ls-files.log
+
+  # The pointers and .gitattributes were fetched, but not the plain files.
+  [ "$(missing_objects)" -eq 2 ]
+  git rev-list --objects --all --missing=print | grep "^?$(git rev-parse HEAD:t1.txt)"
+  git rev-list --objects --all --missing=print | grep "^?$(git rev-parse HEAD:t2.txt)"
+)
+end_test
+
+begin_test "partial clone: scan tree"
+(
+  set -e
+
+  reponame="partial-clone-tree"
+  setup_partial_clone_repo "$reponame"
+
+  cd "$TRASHDIR"
+  git clone --filter=blob:none --no-checkout "$GITSERVER/$reponame" "$reponame-partial"
+  cd "$reponame-partial"
+
+  git lfs ls-files HEAD~1 2>&1 | tee ls-files.log
+  grep "$(calc_oid "a2" | cut -b 1-10) - a.dat" ls-files.log
+  [ "$(missing_objects)" -eq 4 ]
+)
+end_test
+
+begin_test "partial clone: scan tree with files listed before .gitattributes"
+(
+  set -e
+
+  reponame="partial-clone-tree-order"
+  setup_partial_clone_repo "$reponame"
+
+  # "-b.dat" is listed before ".gitattributes", which tracks it.
+  printf "b1" > -b.dat
+  printf "t3" > -t3.txt
+  git add -- -b.dat -t3.txt
+  git commit -m "add -b.dat, -t3.txt"
+  git push origin main
+
+  cd "$TRASHDIR"
+  git clone --filter=blob:none --no-checkout "$GITSERVER/$reponame" "$reponame-partial"
+  cd "$reponame-partial"
+
+  git lfs ls-files HEAD 2>&1 | tee ls-files.log
+  grep "$(calc_oid "a3" | cut -b 1-10) - a.dat" ls-files.log
+  grep "$(calc_oid "b1" | cut -b 1-10) - -b.dat" ls-files.log
+  git rev-list --objects --all --missing=print | grep "^?$(git rev-parse HEAD:-t3.txt)"
+)
+end_test
+
+begin_test "partial clone: fetch and checkout"
+(
+  set -e
+
+  reponame="partial-clone-fetch"
+  setup_partial_clone_repo "$reponame"
+
+  cd "$TRASHDIR"
+  git clone --filter=blob:none --no-checkout "$GITSERVER/$reponame" "$reponame-partial"
+  cd "$reponame-partial"
+
+  git lfs fetch --all 2>&1 | tee fetch.log
+  assert_local_object "$(calc_oid "a1")" 2
+  assert_local_object "$(calc_oid "a2")" 2
+  assert_local_object "$(calc_oid "a3")" 2
+
+  git checkout main
+  [ "a3" = "$(cat a.dat)" ]
+  [ "t2" = "$(cat t2.txt)" ]
+)
+end_test
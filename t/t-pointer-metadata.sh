@@ -0,0 +1,80 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "pointer metadata: disabled by default"
+(
+  set -e
+
+  reponame="pointer-metadata-default"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git cat-file -p HEAD:a.dat | tee pointer.txt
+  [ 0 -eq "$(grep -c "^meta\." pointer.txt)" ]
+)
+end_test
+
+begin_test "pointer metadata: clean records content type"
+(
+  set -e
+
+  reponame="pointer-metadata-clean"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.pointer.metadata true
+  git lfs track "*.dat"
+
+  mkdir dir
+  printf "some text" > "dir/a file.dat"
+  git add .gitattributes dir
+  git commit -m "add a file.dat"
+
+  git cat-file -p "HEAD:dir/a file.dat" | tee pointer.txt
+  grep "^meta.content-type text/plain; charset=utf-8$" pointer.txt
+  [ 0 -eq "$(grep -c "^meta.name" pointer.txt)" ]
+
+  git lfs ls-files --debug | tee ls-files.log
+  grep "    type: text/plain; charset=utf-8" ls-files.log
+  [ 0 -eq "$(grep -c "    name:" ls-files.log)" ]
+
+  GIT_CURL_VERBOSE=1 git push origin main 2>&1 | tee push.log
+  grep "Content-Type: text/plain; charset=utf-8" push.log
+  assert_server_object "$reponame" "$(calc_oid "some text")"
+)
+end_test
+
+begin_test "pointer metadata: upload uses recorded content type"
+(
+  set -e
+
+  reponame="pointer-metadata-upload"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="custom contents"
+  oid="$(calc_oid "$contents")"
+  mkdir -p ".git/lfs/objects/${oid:0:2}/${oid:2:2}"
+  printf "%s" "$contents" > ".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+
+  printf "version https://git-lfs.github.com/spec/v1
+meta.content-type application/x-custom
+oid sha256:%s
+size %d
+" "$oid" "${#contents}" > a.dat
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git cat-file -p HEAD:a.dat | grep "^meta.content-type application/x-custom$"
+
+  GIT_CURL_VERBOSE=1 git push origin main 2>&1 | tee push.log
+  grep "Content-Type: application/x-custom" push.log
+  assert_server_object "$reponame" "$oid"
+)
+end_test
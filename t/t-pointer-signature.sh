@@ -0,0 +1,135 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+if ! command -v gpg >/dev/null 2>&1
+then
+  echo "No gpg.  Skipping..."
+  exit 0
+fi
+
+setup_signing_key() {
+  export GNUPGHOME="$TRASHDIR/gnupg"
+  if [ ! -d "$GNUPGHOME" ]; then
+    mkdir -m 700 "$GNUPGHOME"
+    gpg --batch --passphrase "" --quick-gen-key "Git LFS Tester <lfs@example.com>" ed25519 sign never
+  fi
+  fingerprint="$(gpg --with-colons --list-keys lfs@example.com | awk -F: '/^fpr/ { print $10; exit }')"
+}
+
+begin_test "pointer signature: clean signs pointers"
+(
+  set -e
+  setup_signing_key
+
+  reponame="pointer-signature-clean"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.pointer.sign true
+  git lfs track "*.dat"
+  printf "signed" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git cat-file -p :a.dat | tee pointer.txt
+  grep "^meta.gpgsig " pointer.txt
+  grep "oid sha256:$(calc_oid "signed")" pointer.txt
+
+  # Cleaning the same file again reuses the existing signature.
+  touch a.dat
+  [ -z "$(git status --porcelain --untracked-files=no)" ]
+  git add a.dat
+  git cat-file -p :a.dat | cmp - pointer.txt
+
+  git config lfs.pointer.verifysignatures true
+  git config lfs.pointer.allowedsigners "$fingerprint"
+  git lfs fsck --pointers | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  git push origin main
+
+  cd ..
+  git -c lfs.pointer.verifysignatures=true -c lfs.pointer.allowedsigners="$fingerprint" \
+    clone "$GITSERVER/$reponame" "$reponame-clone"
+  [ "signed" = "$(cat "$reponame-clone/a.dat")" ]
+)
+end_test
+
+begin_test "pointer signature: tampered and unsigned pointers are rejected"
+(
+  set -e
+  setup_signing_key
+
+  reponame="pointer-signature-tampered"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.pointer.sign true
+  git config lfs.pointer.allowedsigners "${fingerprint: -16}"
+  git lfs track "*.dat"
+  printf "signed" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # Change the pointer without re-signing it.
+  git cat-file -p :a.dat | sed -e "s/^oid /meta.name other.dat\noid /" > pointer.txt
+  git update-index --add --cacheinfo 100644 "$(git hash-object -w pointer.txt)" b.dat
+  git config --unset lfs.pointer.sign
+  printf "unsigned" > c.dat
+  git add c.dat
+  git commit -m "add b.dat and c.dat"
+
+  git lfs fsck --pointers 2>&1 | tee fsck.log
+  grep "badSignature: Pointer for $(calc_oid "signed")" fsck.log
+  [ 0 -eq "$(grep -c "$(calc_oid "unsigned")" fsck.log)" ]
+
+  git config lfs.pointer.verifysignatures true
+  git lfs fsck --pointers 2>&1 | tee fsck.log
+  grep "badSignature: Pointer for $(calc_oid "unsigned").*pointer is not signed" fsck.log
+
+  rm -f a.dat b.dat c.dat
+  git checkout -- a.dat
+  [ "signed" = "$(cat a.dat)" ]
+  git checkout -- b.dat >checkout.log 2>&1 && exit 1
+  cat checkout.log
+  grep "pointer signature for $(calc_oid "signed") is not valid" checkout.log
+  git checkout -- c.dat >checkout.log 2>&1 && exit 1
+  cat checkout.log
+  grep "pointer is not signed" checkout.log
+)
+end_test
+
+begin_test "pointer signature: signatures by keys which are not allowed are rejected"
+(
+  set -e
+  setup_signing_key
+
+  reponame="pointer-signature-not-allowed"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.pointer.sign true
+  git lfs track "*.dat"
+  printf "signed" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.pointer.verifysignatures true
+  rm -f a.dat
+  git checkout -- a.dat >checkout.log 2>&1 && exit 1
+  cat checkout.log
+  grep "no pointer signers are allowed by lfs.pointer.allowedsigners" checkout.log
+
+  git config lfs.pointer.allowedsigners "0x0123456789ABCDEF"
+  git checkout -- a.dat >checkout.log 2>&1 && exit 1
+  cat checkout.log
+  grep "pointer signature for $(calc_oid "signed") was made by $fingerprint, which is not an allowed signer" checkout.log
+  git lfs fsck --pointers 2>&1 | tee fsck.log
+  grep "badSignature: Pointer for $(calc_oid "signed")" fsck.log
+
+  git config --add lfs.pointer.allowedsigners "$fingerprint"
+  git checkout -- a.dat
+  [ "signed" = "$(cat a.dat)" ]
+)
+end_test
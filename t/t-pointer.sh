@@ -263,6 +263,29 @@ size 7"
 )
 end_test
 
+begin_test "pointer --file=-"
+(
+  set -e
+
+  expected="Git LFS pointer for STDIN
+
+version https://git-lfs.github.com/spec/v1
+oid sha256:6c17f2007cbe934aee6e309b28b2dba3c119c5dff2ef813ed124699efe319868
+size 7"
+
+  [ "$expected" = "$(echo "simple" | git lfs pointer --file=- 2>&1)" ]
+
+  # Large contents are streamed rather than buffered.
+  head -c 10485760 /dev/zero | git lfs pointer --file=- > pointer.txt
+  grep "oid sha256:e5b844cc57f57094ea4585e235f36c78c1cd222262bb89d53c94dcb4d6b3e55d" pointer.txt
+  grep "size 10485760" pointer.txt
+
+  echo "simple" | git lfs pointer --file=- --stdin 2>&1 | tee pointer.log
+  [ "0" -ne "${PIPESTATUS[1]}" ]
+  grep "cannot read both --file=- and --stdin" pointer.log
+)
+end_test
+
 begin_test "pointer without args"
 (
   output=$(git lfs pointer 2>&1)
@@ -414,6 +437,20 @@ begin_test "pointer --check (with CRLF endings)"
 )
 end_test
 
+begin_test "pointer --check --file=-"
+(
+  set -e
+
+  echo "simple" | git lfs pointer --file=- > valid.txt
+
+  git lfs pointer --check --file=- < valid.txt
+  echo "not a pointer" | git lfs pointer --check --file=- && exit 1
+  [ "1" -eq "$?" ]
+
+  true
+)
+end_test
+
 begin_test "pointer --check (with invalid arguments)"
 (
   set -e
@@ -437,3 +474,44 @@ begin_test "pointer --check (with invalid arguments)"
   true
 )
 end_test
+
+begin_test "pointer --fix"
+(
+  set -e
+
+  reponame="pointer---fix"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf '%s\n' \
+    'version https://git-lfs.github.com/spec/v1' \
+    'oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393' \
+    'size 12345' \
+    >good.ptr
+
+  printf '\357\273\277' >damaged.ptr
+  sed -e 's/$/\r/' good.ptr >>damaged.ptr
+
+  # A byte order mark prevents the pointer from being recognized at all.
+  git lfs pointer --check --file damaged.ptr && exit 1
+
+  git lfs pointer --fix --stdin <damaged.ptr >fixed.ptr 2>fix.log
+  cmp good.ptr fixed.ptr
+  grep "Repaired pointer from STDIN (CRLF line endings, byte order mark)" fix.log
+
+  git lfs pointer --fix --file damaged.ptr 2>fix.log
+  cmp good.ptr damaged.ptr
+  grep "Repaired pointer from damaged.ptr" fix.log
+  git lfs pointer --check --strict --file damaged.ptr
+
+  # Fixing a canonical pointer leaves it unchanged and reports nothing.
+  git lfs pointer --fix --file good.ptr 2>fix.log
+  [ ! -s fix.log ]
+
+  echo "not-a-pointer" >bad.ptr
+  git lfs pointer --fix --file bad.ptr && exit 1
+  git lfs pointer --fix --file bad.ptr --stdin && exit 1
+  git lfs pointer --fix --check --file good.ptr && exit 1
+  [ "not-a-pointer" = "$(cat bad.ptr)" ]
+)
+end_test
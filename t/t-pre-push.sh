@@ -137,7 +137,7 @@ begin_test "pre-push dry-run"
     git lfs pre-push --dry-run origin "$GITSERVER/$reponame" |
     tee push.log
 
-  [ "" = "$(cat push.log)" ]
+  [ "push: dry run would upload 0 object(s), 0 B" = "$(cat push.log)" ]
 
   git lfs track "*.dat"
   echo "dry" > hi.dat
@@ -150,9 +150,10 @@ begin_test "pre-push dry-run"
   echo "refs/heads/main main refs/heads/main 0000000000000000000000000000000000000000" |
     git lfs pre-push --dry-run origin "$GITSERVER/$reponame" |
     tee push.log
-  grep "push 2840e0eafda1d0760771fe28b91247cf81c76aa888af28a850b5648a338dc15b => hi.dat" push.log
+  grep "push 2840e0eafda1d0760771fe28b91247cf81c76aa888af28a850b5648a338dc15b => hi.dat (4 B)" push.log
+  grep "push: dry run would upload 1 object(s), 4 B" push.log
   cat push.log
-  [ `wc -l < push.log` = 1 ]
+  [ `wc -l < push.log` = 2 ]
 
   refute_server_object "$reponame" 2840e0eafda1d0760771fe28b91247cf81c76aa888af28a850b5648a338dc15b
 )
@@ -445,7 +446,7 @@ begin_test "pre-push reject missing pointers (lfs.allowincompletepush default)"
     git lfs pre-push origin "$GITSERVER/$reponame" 2>&1 |
     tee push.log
 
-  if [ "2" -ne "${PIPESTATUS[1]}" ]; then
+  if [ "6" -ne "${PIPESTATUS[1]}" ]; then
     echo >&2 "fatal: expected \`git lfs pre-push origin $GITSERVER/$reponame\` to fail..."
     exit 1
   fi
@@ -756,6 +757,55 @@ begin_test "pre-push with their lock on lfs file"
 )
 end_test
 
+begin_test "pre-push with their lock on deleted lfs file"
+(
+  set -e
+
+  reponame="pre_push_unowned_lock_deleted"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "locked contents" > locked_theirs.dat
+  printf "other contents" > other.dat
+  git add .gitattributes locked_theirs.dat other.dat
+  git commit -m "add locked_theirs.dat"
+
+  git push origin main
+
+  git lfs lock --json "locked_theirs.dat" | tee lock.log
+  id=$(assert_lock lock.log locked_theirs.dat)
+  assert_server_lock $id
+
+  pushd "$TRASHDIR" >/dev/null
+    clone_repo "$reponame" "$reponame-assert"
+    git config lfs.locksverify true
+
+    # Renaming the file deletes it at its locked path.
+    git mv locked_theirs.dat renamed.dat
+    git rm other.dat
+    git commit --no-verify -m "rename locked file"
+
+    git push origin main 2>&1 | tee push.log
+    res="${PIPESTATUS[0]}"
+    if [ "0" -eq "$res" ]; then
+      echo "push should fail"
+      exit 1
+    fi
+
+    grep "Unable to push locked files" push.log
+    [ 1 -eq "$(grep -c "\* locked_theirs.dat - Git LFS Tests" push.log)" ]
+    [ 0 -eq "$(grep -c "other.dat" push.log)" ]
+    grep "ERROR: Cannot update locked files." push.log
+
+    # Without verification, the push goes ahead.
+    git config lfs.locksverify false
+    git push origin main 2>&1 | tee push.log
+    grep "main -> main" push.log
+  popd >/dev/null
+)
+end_test
+
 begin_test "pre-push with their lock on non-lfs lockable file"
 (
   set -e
@@ -29,6 +29,7 @@ begin_test "GIT_LFS_PROGRESS"
   grep "download 3/5" progress.log
   grep "download 4/5" progress.log
   grep "download 5/5" progress.log
+  [ "0" -eq "$(grep -c -E "^(version|totals|complete|skip) " progress.log)" ]
 
   GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" clone2
   cd clone2
@@ -41,6 +42,7 @@ begin_test "GIT_LFS_PROGRESS"
   grep "download 3/5" ../progress.log
   grep "download 4/5" ../progress.log
   grep "download 5/5" ../progress.log
+  [ "0" -eq "$(grep -c -E "^(version|totals|complete|skip) " ../progress.log)" ]
 
   rm -rf "$TRASHDIR/progress.log"
   GIT_LFS_PROGRESS="$TRASHDIR/progress.log" git lfs checkout
@@ -50,5 +52,85 @@ begin_test "GIT_LFS_PROGRESS"
   grep "checkout 3/5" ../progress.log
   grep "checkout 4/5" ../progress.log
   grep "checkout 5/5" ../progress.log
+  [ "5" -eq "$(grep -c "^checkout [1-5]/5 2/10 [a-e].dat$" ../progress.log)" ]
+)
+end_test
+
+begin_test "GIT_LFS_PROGRESS with GIT_LFS_PROGRESS_VERSION=2"
+(
+  set -e
+  reponame="$(basename "$0" ".sh")-version-2"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo-version-2
+
+  git lfs track "*.dat"
+  for f in a b c d e; do
+    echo "$f" > "$f.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" clone-version-2
+  cd clone-version-2
+
+  export GIT_LFS_PROGRESS_VERSION=2
+
+  rm -rf "$TRASHDIR/progress.log" .git/lfs/objects
+  GIT_LFS_PROGRESS="$TRASHDIR/progress.log" git lfs fetch --all
+  cat ../progress.log
+  [ "version 2" = "$(head -n 1 ../progress.log)" ]
+  grep "totals download 5 10" ../progress.log
+  [ "5" -eq "$(grep -c "^complete download [1-5]/5 2 [a-e].dat$" ../progress.log)" ]
+
+  rm -rf "$TRASHDIR/progress.log"
+  GIT_LFS_PROGRESS="$TRASHDIR/progress.log" git lfs checkout
+  cat ../progress.log
+  [ "version 2" = "$(head -n 1 ../progress.log)" ]
+  grep "totals checkout 5 10" ../progress.log
+  grep "checkout 1/5 2/2 [a-e].dat" ../progress.log
+  [ "5" -eq "$(grep -c "^complete checkout [1-5]/5 2 [a-e].dat$" ../progress.log)" ]
+
+  rm -rf "$TRASHDIR/progress.log"
+  echo "f" > f.dat
+  GIT_LFS_PROGRESS="$TRASHDIR/progress.log" git add f.dat
+  cat ../progress.log
+  [ "version 2" = "$(head -n 1 ../progress.log)" ]
+  grep "totals clean 1 2" ../progress.log
+  grep "clean 1/1 2/2 f.dat" ../progress.log
+  grep "complete clean 1/1 2 f.dat" ../progress.log
+)
+end_test
+
+begin_test "GIT_LFS_PROGRESS_JSON"
+(
+  set -e
+  reponame="$(basename "$0" ".sh")-json"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo-json
+
+  git lfs track "*.dat"
+  echo "a" > a.dat
+  echo "b" > b.dat
+  GIT_LFS_PROGRESS_JSON="$TRASHDIR/clean.json" git add .gitattributes *.dat
+  git commit -m "add files"
+  cat "$TRASHDIR/clean.json"
+  grep '"event":"done","phase":"clean","name":"a.dat"' "$TRASHDIR/clean.json"
+
+  GIT_LFS_PROGRESS_JSON="$TRASHDIR/push.json" git push origin main
+  cat "$TRASHDIR/push.json"
+  grep '"event":"done","phase":"upload","name":"[ab].dat"' "$TRASHDIR/push.json"
+  grep '"event":"finish","phase":"upload","files_done":2,"files_total":2,"bytes_done":4,"bytes_total":4' "$TRASHDIR/push.json"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" clone-json
+  cd clone-json
+
+  GIT_LFS_PROGRESS_JSON=3 git lfs pull 3> "$TRASHDIR/pull.json"
+  cat "$TRASHDIR/pull.json"
+  grep '"event":"progress","phase":"download","name":"a.dat","bytes":2,"size":2' "$TRASHDIR/pull.json"
+  grep '"event":"finish","phase":"download","files_done":2,"files_total":2' "$TRASHDIR/pull.json"
+  [ "2" -eq "$(grep -c '"event":"done","phase":"download"' "$TRASHDIR/pull.json")" ]
 )
 end_test
@@ -96,6 +96,46 @@ begin_test "prune unreferenced and old"
 )
 end_test
 
+begin_test "prune keep recent sha512 objects"
+(
+  set -e
+
+  reponame="prune_recent_sha512"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git config lfs.hashalgorithm sha512
+  git lfs track "*.dat"
+
+  content_old="Keep: replaced within the recent window"
+  content_new="Keep: current"
+  oid_old="$(printf "%s" "$content_old" | shasum -a 512 | cut -f 1 -d " ")"
+  oid_new="$(printf "%s" "$content_new" | shasum -a 512 | cut -f 1 -d " ")"
+
+  printf "%s" "$content_old" > a.dat
+  git add .gitattributes a.dat
+  GIT_COMMITTER_DATE="$(get_date -20d)" git commit -m "add a.dat" --date="$(get_date -20d)"
+
+  printf "%s" "$content_new" > a.dat
+  git add a.dat
+  GIT_COMMITTER_DATE="$(get_date -1d)" git commit -m "replace a.dat" --date="$(get_date -1d)"
+  git cat-file -p HEAD~1:a.dat | grep "oid sha512:$oid_old"
+
+  git push origin main
+
+  git config lfs.fetchrecentrefsdays 5
+  git config lfs.fetchrecentcommitsdays 3
+  git config lfs.pruneoffsetdays 2
+
+  # The old object was current until a commit within the recent window, so
+  # it is retained, as a SHA-256 object would be.
+  git lfs prune --verbose 2>&1 | tee prune.log
+  grep "prune: 2 local object(s), 2 retained" prune.log
+  assert_local_object "$oid_old" "${#content_old}"
+  assert_local_object "$oid_new" "${#content_new}"
+)
+end_test
+
 begin_test "prune keep unpushed"
 (
   set -e
@@ -1041,3 +1081,117 @@ begin_test "prune does not invoke external diff programs"
   git lfs prune
 )
 end_test
+
+begin_test "prune with shared storage"
+(
+  set -e
+
+  reponame="prune_shared_storage"
+  setup_remote_repo "remote_$reponame"
+
+  storage="$TRASHDIR/shared-storage-$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+  git config lfs.storage "$storage"
+
+  git lfs track "*.dat"
+
+  content_a="shared by both repositories"
+  content_b="only referenced by the other repository"
+  content_c="only referenced by this repository"
+  oid_a=$(calc_oid "$content_a")
+  oid_b=$(calc_oid "$content_b")
+  oid_c=$(calc_oid "$content_c")
+
+  printf '%s' "$content_a" > a.dat
+  printf '%s' "$content_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+  git push origin main
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/remote_$reponame" "other_$reponame"
+  cd "other_$reponame"
+  git config lfs.storage "$storage"
+  git lfs pull
+  [ "$content_b" = "$(cat b.dat)" ]
+
+  cd "$TRASHDIR/clone_$reponame"
+  git rm b.dat
+  printf '%s' "$content_c" > c.dat
+  git add c.dat
+  git commit -m "replace b.dat with c.dat"
+  git push origin main
+
+  # Everything has been pushed, so --force would prune every object from this
+  # repository alone, but the other repository still needs a.dat and b.dat.
+  git lfs prune --force
+
+  assert_local_object "$oid_a" "${#content_a}"
+  assert_local_object "$oid_b" "${#content_b}"
+  refute_local_object "$oid_c"
+
+  # Once the other repository has gone, it no longer retains anything.
+  rm -rf "$TRASHDIR/other_$reponame"
+  git lfs prune --force
+
+  refute_local_object "$oid_a"
+  refute_local_object "$oid_b"
+  [ 1 -eq "$(ls "$storage/repos" | wc -l)" ]
+)
+end_test
+
+begin_test "prune dry-run report"
+(
+  set -e
+
+  reponame="prune_dry_run_report"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \"\*.dat\"" track.log
+
+  content_old="old content"
+  content_head="HEAD content"
+  content_unpushed="unpushed content"
+  oid_old=$(calc_oid "$content_old")
+  oid_head=$(calc_oid "$content_head")
+  oid_unpushed=$(calc_oid "$content_unpushed")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -50d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_old}, \"Data\":\"$content_old\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -40d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  git push origin main
+  old_commit="$(git rev-parse --short=7 HEAD^)"
+
+  git checkout -b unpushed
+  printf "%s" "$content_unpushed" > unpushed.dat
+  git add unpushed.dat
+  git commit -m "add unpushed.dat"
+  git checkout main
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+
+  git lfs prune --dry-run --verify-remote --verbose 2>&1 | tee prune.log
+  grep "prune: 1 file(s) would be pruned" prune.log
+  grep " \* $oid_old (11 B): file.dat, last referenced in $old_commit, verified on remote" prune.log
+  grep "prune: 2 file(s) would be retained" prune.log
+  grep " \* $oid_head (12 B): at HEAD" prune.log
+  grep " \* $oid_unpushed (16 B): not pushed to origin" prune.log
+
+  assert_local_object "$oid_old" "${#content_old}"
+)
+end_test
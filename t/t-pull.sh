@@ -149,6 +149,57 @@ begin_test "pull"
 )
 end_test
 
+begin_test "pull with paths"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-paths"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+
+  mkdir -p assets/textures assets/models
+  printf "a" > a.dat
+  printf "texture" > assets/textures/t.dat
+  printf "model" > assets/models/m.dat
+  git add .
+  git commit -m "add files"
+  git push origin main
+
+  oid_a="$(calc_oid "a")"
+  oid_texture="$(calc_oid "texture")"
+  oid_model="$(calc_oid "model")"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs pull origin -- assets/textures
+  assert_local_object "$oid_texture" 7
+  refute_local_object "$oid_a"
+  refute_local_object "$oid_model"
+  [ "texture" = "$(cat assets/textures/t.dat)" ]
+  grep "oid sha256:$oid_model" assets/models/m.dat
+
+  # Paths are relative to the current directory.
+  cd assets/models
+  git lfs pull -- "*.dat"
+  assert_local_object "$oid_model" 5
+  refute_local_object "$oid_a"
+  [ "model" = "$(cat m.dat)" ]
+  cd ../..
+
+  git lfs pull --include="a.dat" -- a.dat 2>&1 | tee pull.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "Cannot use --include with paths" pull.log
+
+  git lfs pull origin a.dat 2>&1 | tee pull.log
+  [ "0" -ne "${PIPESTATUS[0]}" ]
+  grep "paths to pull must follow" pull.log
+)
+end_test
+
 begin_test "pull without clean filter"
 (
   set -e
@@ -273,7 +324,7 @@ begin_test "pull with invalid insteadof"
   res=$?
 
   set -e
-  [ "$res" = "2" ]
+  [ "$res" = "5" ]
 
   # check rewritten href is used to download LFS object.
   grep "LFS: Repository or object not found: $GITSERVER/storage/invalid" pull.log
@@ -310,7 +361,8 @@ begin_test "pull with merge conflict"
   # This will exit nonzero because of the merge conflict.
   GIT_LFS_SKIP_SMUDGE=1 git merge def || true
   git lfs pull > pull.log 2>&1
-  [ ! -s pull.log ]
+  # Only the progress of checking out the objects present already is shown.
+  [ -z "$(grep -v "Downloading and checking out LFS objects:" pull.log)" ]
 )
 end_test
 
@@ -342,6 +394,53 @@ begin_test "pull: with missing object"
 )
 end_test
 
+begin_test "pull: with ref"
+(
+  set -e
+
+  reponame="pull-with-ref"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  first="$(git rev-parse HEAD)"
+
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  # The objects of an older commit are downloaded, but not checked out,
+  # since the working tree holds the files of another commit.
+  git lfs pull origin "$first"
+  assert_local_object "$(calc_oid a)" 1
+  refute_local_object "$(calc_oid b)"
+  grep "oid sha256:$(calc_oid a)" a.dat
+  [ "$(git status --porcelain)" = "" ]
+
+  # The objects of the checked out commit are checked out, too.
+  git lfs pull origin main
+  assert_local_object "$(calc_oid b)" 1
+  [ "a" = "$(cat a.dat)" ]
+  [ "b" = "$(cat b.dat)" ]
+
+  set +e
+  git lfs pull origin main "$first" 2>&1 | tee pull.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "$res" -eq 2 ]
+  grep "Only one ref can be pulled at a time" pull.log
+)
+end_test
+
 begin_test "pull: outside git repository"
 (
   set +e
@@ -82,8 +82,10 @@ begin_test "push"
   git commit -m "add a.dat"
 
   git lfs push --dry-run origin main 2>&1 | tee push.log
-  grep "push 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340 => a.dat" push.log
+  grep "push 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340 => a.dat (7 B)" push.log
+  grep "push: dry run would upload 1 object(s), 7 B" push.log
   [ $(grep -c "^push " push.log) -eq 1 ]
+  refute_server_object "$reponame" 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340
 
   git lfs push origin main 2>&1 | tee push.log
   grep "Uploading LFS objects: 100% (1/1), 7 B" push.log
@@ -93,10 +95,11 @@ begin_test "push"
   git add b.dat
   git commit -m "add b.dat"
 
+  # a.dat is not listed, since the server already has it
   git lfs push --dry-run origin push-b 2>&1 | tee push.log
-  grep "push 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340 => a.dat" push.log
-  grep "push 82be50ad35070a4ef3467a0a650c52d5b637035e7ad02c36652e59d01ba282b7 => b.dat" push.log
-  [ $(grep -c "^push " < push.log) -eq 2 ]
+  grep "push 82be50ad35070a4ef3467a0a650c52d5b637035e7ad02c36652e59d01ba282b7 => b.dat (7 B)" push.log
+  grep "push: dry run would upload 1 object(s), 7 B" push.log
+  [ $(grep -c "^push " < push.log) -eq 1 ]
 
   # simulate remote ref
   mkdir -p .git/refs/remotes/origin
@@ -220,13 +223,13 @@ begin_test "push --all (no ref args)"
 
   echo "dry run missing local object that exists on server"
   git lfs push --dry-run --all origin 2>&1 | tee push.log
-  grep "push $oid1 => file1.dat" push.log
+  grep "push $oid1 => file1.dat" push.log && exit 1
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
   grep "push $oid4 => file1.dat" push.log
   grep "push $oid5 => file1.dat" push.log
   grep "push $extraoid => file2.dat" push.log
-  [ $(grep -c "^push " push.log) -eq 6 ]
+  [ $(grep -c "^push " push.log) -eq 5 ]
 
   git push --all origin 2>&1 | tee push.log
   grep "Uploading LFS objects: 100% (6/6)" push.log
@@ -271,12 +274,12 @@ begin_test "push --all (1 ref arg)"
   refute_server_object "$reponame-$suffix-2" "$extraoid"
   rm ".git/lfs/objects/${oid1:0:2}/${oid1:2:2}/$oid1"
 
-  # dry run doesn't change
+  # dry run lists only the objects which the server does not have
   git lfs push --dry-run --all origin branch 2>&1 | tee push.log
-  grep "push $oid1 => file1.dat" push.log
+  grep "push $oid1 => file1.dat" push.log && exit 1
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
-  [ $(grep -c "^push " push.log) -eq 3 ]
+  [ $(grep -c "^push " push.log) -eq 2 ]
 
   git push origin branch 2>&1 | tee push.log
   [ $(grep -c "Uploading LFS objects: 100% (3/3)" push.log) -eq 1 ]
@@ -322,13 +325,13 @@ begin_test "push --all (multiple ref args)"
   refute_server_object "$reponame-$suffix-2" "$extraoid"
   rm ".git/lfs/objects/${oid1:0:2}/${oid1:2:2}/$oid1"
 
-  # dry run doesn't change
+  # dry run lists only the objects which the server does not have
   git lfs push --dry-run --all origin branch tag 2>&1 | tee push.log
-  grep "push $oid1 => file1.dat" push.log
+  grep "push $oid1 => file1.dat" push.log && exit 1
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
   grep "push $oid4 => file1.dat" push.log
-  [ $(grep -c "^push " push.log) -eq 4 ]
+  [ $(grep -c "^push " push.log) -eq 3 ]
 
   git push origin branch refs/tags/tag 2>&1 | tee push.log
   [ $(grep -c "Uploading LFS objects: 100% (4/4)" push.log) -eq 1 ]
@@ -375,14 +378,14 @@ begin_test "push --all (ref with deleted files)"
   refute_server_object "$reponame-$suffix-2" "$extraoid"
   rm ".git/lfs/objects/${oid1:0:2}/${oid1:2:2}/$oid1"
 
-  # dry run doesn't change
+  # dry run lists only the objects which the server does not have
   git lfs push --dry-run --all origin main 2>&1 | tee push.log
-  grep "push $oid1 => file1.dat" push.log
+  grep "push $oid1 => file1.dat" push.log && exit 1
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid4 => file1.dat" push.log
   grep "push $oid5 => file1.dat" push.log
   grep "push $extraoid => file2.dat" push.log
-  [ $(grep -c "^push " push.log) -eq 5 ]
+  [ $(grep -c "^push " push.log) -eq 4 ]
 
   git push origin main 2>&1 | tee push.log
   [ $(grep -c "Uploading LFS objects: 100% (5/5)" push.log) -eq 1 ]
@@ -426,6 +429,34 @@ begin_test "push object id(s)"
 )
 end_test
 
+begin_test "push object id(s) via stdin"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-stdin"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "push a" > a.dat
+  echo "push b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  oid_a="4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340"
+  oid_b="82be50ad35070a4ef3467a0a650c52d5b637035e7ad02c36652e59d01ba282b7"
+
+  printf "%s\n\n%s\n" "$oid_a" "$oid_b" | git lfs push --object-id --stdin origin 2>&1 | tee push.log
+  grep "Uploading LFS objects: 100% (2/2), 14 B" push.log
+
+  assert_server_object "$reponame" "$oid_a"
+  assert_server_object "$reponame" "$oid_b"
+
+  echo "$oid_a" | git lfs push --object-id --stdin origin "$oid_b" 2>&1 | tee push.log
+  grep "Further command line arguments are ignored with --stdin" push.log
+)
+end_test
+
 begin_test "push modified files"
 (
   set -e
@@ -681,7 +712,7 @@ begin_test "push with invalid pushInsteadof"
   res=$?
 
   set -e
-  [ "$res" = "2" ]
+  [ "$res" = "4" ]
 
   # check rewritten href is used to upload LFS object.
   grep "LFS: Authorization error: $GITSERVER/storage/invalid" push.log
@@ -872,3 +903,30 @@ begin_test "storage upload with compression"
   popd
 )
 end_test
+
+begin_test "push --dry-run does not clean missing objects"
+(
+  set -e
+
+  reponame="push-dry-run-clean"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="dry run"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # The object is missing from the local store, but could be cleaned again
+  # from the working tree.
+  rm -rf .git/lfs/objects
+  refute_local_object "$oid"
+
+  git lfs push --dry-run origin main 2>&1 | tee push.log
+  grep "push $oid => a.dat (7 B)" push.log
+  refute_local_object "$oid"
+  refute_server_object "$reponame" "$oid"
+)
+end_test
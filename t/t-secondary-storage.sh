@@ -0,0 +1,114 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "secondary storage"
+(
+  set -e
+
+  reponame="secondary-storage"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="secondary"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  secondary="$TRASHDIR/secondary"
+  mkdir -p "$secondary"
+  cp -R .git/lfs/objects "$secondary/objects"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.secondarystorage "$secondary"
+
+  # The server is unreachable, so the object must come from secondary
+  # storage.
+  git -c lfs.url="http://127.0.0.1:1/nonexistent" lfs pull
+  assert_local_object "$contents_oid" 9
+  [ "$contents" = "$(cat a.dat)" ]
+  [ -f "$secondary/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid" ]
+)
+end_test
+
+begin_test "secondary storage with corrupt object"
+(
+  set -e
+
+  reponame="secondary-storage-corrupt"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="secondary"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  secondary="$TRASHDIR/secondary-corrupt"
+  objectdir="$secondary/objects/${contents_oid:0:2}/${contents_oid:2:2}"
+  mkdir -p "$objectdir"
+  printf "corrupted" > "$objectdir/$contents_oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.secondarystorage "$secondary"
+
+  git -c lfs.url="http://127.0.0.1:1/nonexistent" lfs fetch && exit 1
+  refute_local_object "$contents_oid"
+
+  git lfs pull
+  assert_local_object "$contents_oid" 9
+  [ "$contents" = "$(cat a.dat)" ]
+  [ "corrupted" = "$(cat "$objectdir/$contents_oid")" ]
+)
+end_test
+
+begin_test "secondary storage verifies many objects with hash workers"
+(
+  set -e
+
+  reponame="secondary-storage-workers"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for i in $(seq 1 20); do
+    printf "secondary %d" "$i" > "$i.dat"
+  done
+  # One object is corrupt in secondary storage, and must be downloaded.
+  printf "corrupt" > corrupt.dat
+  corrupt_oid="$(calc_oid "corrupt")"
+  git add .gitattributes *.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  secondary="$TRASHDIR/secondary-workers"
+  mkdir -p "$secondary"
+  cp -R .git/lfs/objects "$secondary/objects"
+  objectdir="$secondary/objects/${corrupt_oid:0:2}/${corrupt_oid:2:2}"
+  chmod u+w "$objectdir/$corrupt_oid"
+  printf "CORRUPT" > "$objectdir/$corrupt_oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.secondarystorage "$secondary"
+
+  git -c lfs.hash.workers=4 lfs fetch 2>&1 | tee fetch.log
+  grep "Downloading LFS objects: 100% (1/1), 7 B" fetch.log
+  for i in $(seq 1 20); do
+    contents="secondary $i"
+    assert_local_object "$(calc_oid "$contents")" "${#contents}"
+  done
+  assert_local_object "$corrupt_oid" 7
+)
+end_test
@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# start_serve starts "git lfs serve" on a free port with the given directory,
+# and sets "lfsurl" to the URL on which it is listening, and "servepid" to its
+# process ID.
+start_serve() {
+  local dir="$1"
+
+  git lfs serve --listen 127.0.0.1:0 "$dir" > serve.log 2>&1 &
+  servepid="$!"
+
+  for i in $(seq 1 50); do
+    lfsurl="$(grep -o "http://[0-9.:]*" serve.log || true)"
+    [ -n "$lfsurl" ] && return 0
+    sleep 0.1
+  done
+
+  cat serve.log
+  echo >&2 "fatal: git lfs serve did not start"
+  exit 1
+}
+
+begin_test "serve: push and fetch objects"
+(
+  set -e
+
+  reponame="serve-objects"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  start_serve "$TRASHDIR/serve-objects-store"
+  trap 'kill "$servepid"' EXIT
+  git config lfs.url "$lfsurl"
+
+  git lfs track "*.dat"
+  contents="served"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main 2>&1 | tee push.log
+  grep "Uploading LFS objects: 100% (1/1)" push.log
+
+  [ -f "$TRASHDIR/serve-objects-store/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid" ]
+
+  rm -rf .git/lfs/objects
+  refute_local_object "$contents_oid"
+
+  git lfs fetch origin main 2>&1 | tee fetch.log
+  assert_local_object "$contents_oid" 6
+
+  missing_oid="$(calc_oid "missing")"
+  rm -rf .git/lfs/objects
+  curl -s -X POST -H "Content-Type: application/vnd.git-lfs+json" \
+    -d "{\"operation\":\"download\",\"objects\":[{\"oid\":\"$missing_oid\",\"size\":7}]}" \
+    "$lfsurl/objects/batch" | tee batch.json
+  grep '"error":{"code":404' batch.json
+
+  status="$(curl -s -o /dev/null -w "%{http_code}" -X PUT --data-binary "wrong" \
+    "$lfsurl/objects/$missing_oid")"
+  [ "422" -eq "$status" ]
+  [ ! -e "$TRASHDIR/serve-objects-store/objects/${missing_oid:0:2}/${missing_oid:2:2}/$missing_oid" ]
+)
+end_test
+
+begin_test "serve: lock, locks and unlock"
+(
+  set -e
+
+  reponame="serve-locks"
+  setup_remote_repo_with_file "$reponame" "a.dat"
+  clone_repo "$reponame" "$reponame"
+
+  start_serve "$TRASHDIR/serve-locks-store"
+  trap 'kill "$servepid"' EXIT
+  git config lfs.url "$lfsurl"
+
+  git lfs lock --json "a.dat" | tee lock.json
+  id="$(assert_lock lock.json a.dat)"
+
+  git lfs lock "a.dat" > relock.log 2>&1 && exit 1
+  cat relock.log
+  grep "lock already created" relock.log
+
+  git lfs locks --json | tee locks.json
+  grep "\"id\":\"$id\",\"path\":\"a.dat\"" locks.json
+
+  git lfs locks --verify | tee verify.log
+  grep "O a.dat" verify.log
+
+  git lfs unlock --id "$id"
+  [ '{"version":1,"command":"locks","type":"result","data":[]}' = "$(git lfs locks --json)" ]
+  [ "[]" = "$(cat "$TRASHDIR/serve-locks-store/locks.json")" ]
+)
+end_test
@@ -177,6 +177,45 @@ begin_test "smudge clone with include/exclude"
 )
 end_test
 
+begin_test "smudge checkout with include/exclude"
+(
+  set -e
+
+  reponame="smudge_checkout_include_exclude"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "repo_$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat, b.dat"
+  git push origin main
+
+  clone="$TRASHDIR/clone_$reponame"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$clone"
+  cd "$clone"
+  git config lfs.fetchexclude "a*"
+
+  # A checkout through the filter process leaves the excluded path as a
+  # pointer, and downloads the rest.
+  rm a.dat b.dat
+  git checkout -- a.dat b.dat
+  git cat-file -p :a.dat | cmp - a.dat
+  [ "b" = "$(cat b.dat)" ]
+  refute_local_object "$(calc_oid "a")"
+  assert_local_object "$(calc_oid "b")" 1
+
+  # Excluded paths whose objects are already present are checked out in
+  # full, as with the smudge filter.
+  git -c lfs.fetchexclude= lfs fetch
+  rm a.dat
+  git checkout -- a.dat
+  [ "a" = "$(cat a.dat)" ]
+  git lfs smudge a.dat < <(git cat-file -p :a.dat) | grep -x "a"
+)
+end_test
+
 begin_test "smudge skip download failure"
 (
   set -e
@@ -0,0 +1,111 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "stats"
+(
+  set -e
+
+  reponame="stats"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs stats 2>&1 | tee stats.log
+  grep "No transfers recorded" stats.log
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  printf "defgh" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  # A dry run transfers nothing, so is not recorded.
+  git lfs push --dry-run origin main
+  git lfs stats 2>&1 | tee stats.log
+  grep "No transfers recorded" stats.log
+
+  git push origin main
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main
+
+  git lfs stats 2>&1 | tee stats.log
+  grep -E "pre-push +upload +basic +2 object\(s\), 8 B in " stats.log
+  grep -E "fetch +download +basic +2 object\(s\), 8 B in " stats.log
+  grep "upload:   1 run(s), 2 object(s), 8 B in " stats.log
+  grep "download: 1 run(s), 2 object(s), 8 B in " stats.log
+  grep ", 0 retries, 0 error(s)" stats.log
+
+  git lfs stats --limit=1 2>&1 | tee stats.log
+  [ 0 -eq "$(grep -c "pre-push" stats.log)" ]
+  grep -E "fetch +download" stats.log
+
+  git lfs --json stats 2>&1 | tee stats.json
+  [ 2 -eq "$(grep -c '"event":"transfer"' stats.json)" ]
+  grep '"command":"pre-push","direction":"upload","remote":"origin","adapter":"basic","objects":2,"bytes":8,"errors":0,"retries":0' stats.json
+  grep '"totals":\[{"direction":"upload","runs":1,"objects":2,"bytes":8,' stats.json
+)
+end_test
+
+begin_test "stats: failed transfers"
+(
+  set -e
+
+  reponame="stats-failed"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  # The object is missing from the server, so cannot be fetched.
+  printf "missing" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git push origin main
+  delete_server_object "$reponame" "$(calc_oid missing)"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main 2>&1 && exit 1
+
+  git lfs stats 2>&1 | tee stats.log
+  grep -E "fetch +download +.* 1 object\(s\), 3 B in .*, 1 error\(s\)" stats.log
+)
+end_test
+
+begin_test "stats: disabled"
+(
+  set -e
+
+  reponame="stats-disabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.transferhistory false
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  git lfs stats 2>&1 | tee stats.log
+  grep "No transfers recorded" stats.log
+  [ ! -e .git/lfs/transfer-history.log ]
+)
+end_test
+
+begin_test "stats: outside repository"
+(
+  set +e
+  cd "$TRASHDIR"
+  git lfs stats 2>&1 > stats.log
+  res=$?
+  set -e
+
+  [ "$res" -eq 128 ]
+)
+end_test
@@ -99,14 +99,21 @@ begin_test "status --json"
 
   echo "other data" > file1.dat
 
-  expected='{"files":{"file1.dat":{"status":"M"}}}'
+  expected='{"version":1,"command":"status","type":"result","data":{"files":{"file1.dat":{"status":"M"}},"staged":{},"unstaged":{"file1.dat":{"status":"M"}}}}'
+  [ "$expected" = "$(git lfs status --json)" ]
+
+  git add file1.dat
+
+  # A file with both staged and unstaged changes is reported in each.
+  echo "more data" > file1.dat
+  expected='{"version":1,"command":"status","type":"result","data":{"files":{"file1.dat":{"status":"M"}},"staged":{"file1.dat":{"status":"M"}},"unstaged":{"file1.dat":{"status":"M"}}}}'
   [ "$expected" = "$(git lfs status --json)" ]
 
   git add file1.dat
   git commit -m "file1.dat changed"
   git mv file1.dat file2.dat
 
-  expected='{"files":{"file2.dat":{"status":"R","from":"file1.dat"}}}'
+  expected='{"version":1,"command":"status","type":"result","data":{"files":{"file2.dat":{"status":"R","from":"file1.dat"}},"staged":{"file2.dat":{"status":"R","from":"file1.dat"}},"unstaged":{}}}'
   [ "$expected" = "$(git lfs status --json)" ]
 
   git commit -m "file1.dat -> file2.dat"
@@ -114,11 +121,79 @@ begin_test "status --json"
   # Ensure status --json does not include non-lfs files
   echo hi > test1.txt
   git add test1.txt
-  expected='{"files":{}}'
+  expected='{"version":1,"command":"status","type":"result","data":{"files":{},"staged":{},"unstaged":{}}}'
   [ "$expected" = "$(git lfs status --json)" ]
 )
 end_test
 
+begin_test "status --json with objects to push"
+(
+  set -e
+
+  reponame="status-json-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+  git push origin main
+
+  contents_a="a"
+  contents_b="b"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > b.dat
+  git add a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  printf "c" > c.dat
+  git add c.dat
+  rm b.dat ".git/lfs/objects/${oid_b:0:2}/${oid_b:2:2}/$oid_b"
+
+  expected="{\"version\":1,\"command\":\"status\",\"type\":\"result\",\"data\":{\"files\":{\"b.dat\":{\"status\":\"D\"},\"c.dat\":{\"status\":\"A\"}},\"staged\":{\"c.dat\":{\"status\":\"A\"}},\"unstaged\":{\"b.dat\":{\"status\":\"D\"}},\"push\":{\"remote\":\"origin/main\",\"objects\":[{\"name\":\"a.dat\",\"oid\":\"$oid_a\",\"size\":1},{\"name\":\"b.dat\",\"oid\":\"$oid_b\",\"size\":1}]},\"missing\":[{\"name\":\"b.dat\",\"oid\":\"$oid_b\",\"size\":1}]}}"
+  [ "$expected" = "$(git lfs status --json)" ]
+)
+end_test
+
+begin_test "status with fsmonitor"
+(
+  set -e
+
+  reponame="status-fsmonitor"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "unchanged" > a.dat
+  echo "original" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+
+  # This hook reports every file as changed, so that Git examines each of
+  # them.
+  hook="$(pwd)/.git/hooks/fsmonitor-test"
+  printf '#!/bin/sh\nprintf "token\\0/\\0"\n' > "$hook"
+  chmod +x "$hook"
+  git config core.fsmonitor "$hook"
+
+  # a.dat's stat data is out of date, but it has not changed.
+  touch -t 202001010000 a.dat
+  echo "modified" > b.dat
+
+  index="$(git hash-object .git/index)"
+  GIT_TRACE=1 git lfs status --porcelain 2>trace.log | tee status.log
+  grep "status.*--porcelain" trace.log
+  [ " M b.dat" = "$(cat status.log)" ]
+  [ "$index" = "$(git hash-object .git/index)" ]
+
+  git lfs status 2>&1 | tee status.log
+  grep -A2 "Objects not staged for commit:" status.log | grep "b.dat"
+  [ "0" -eq "$(grep -c "a.dat" status.log)" ]
+)
+end_test
+
 begin_test "status in a sub-directory"
 (
   set -e
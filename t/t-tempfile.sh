@@ -32,3 +32,61 @@ begin_test "cleans only temp files and directories older than an hour"
   [ ! -f "$tmpdir/to-destroy" ]
 )
 end_test
+
+begin_test "cleans stale partial downloads at most once a day"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-incomplete"
+  git init "$reponame"
+  cd "$reponame"
+
+  incompletedir=.git/lfs/incomplete
+  mkdir -p "$incompletedir"
+
+  touch "$incompletedir/to-preserve"
+  touch -d "8 days ago" "$incompletedir/to-destroy"
+
+  git lfs ls-files >/dev/null
+
+  [ -f "$incompletedir/to-preserve" ]
+  [ ! -f "$incompletedir/to-destroy" ]
+  [ -f .git/lfs/cleanup-stamp ]
+
+  # Cleanup has run today, so it is not repeated.
+  touch -d "8 days ago" "$incompletedir/to-destroy"
+  git lfs ls-files >/dev/null
+  [ -f "$incompletedir/to-destroy" ]
+
+  touch -d "2 days ago" .git/lfs/cleanup-stamp
+  git -c lfs.incompletemaxage=24h lfs ls-files >/dev/null
+  [ ! -f "$incompletedir/to-destroy" ]
+  [ -f "$incompletedir/to-preserve" ]
+)
+end_test
+
+begin_test "prune removes stale temporary files"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-prune"
+  git init "$reponame"
+  cd "$reponame"
+
+  git commit --allow-empty -m "initial commit"
+
+  mkdir -p .git/lfs/tmp .git/lfs/incomplete
+  printf "abc" > .git/lfs/tmp/to-destroy
+  touch -d "2 hours ago" .git/lfs/tmp/to-destroy
+  printf "abcdef" > .git/lfs/incomplete/to-destroy
+  touch -d "2 days ago" .git/lfs/incomplete/to-destroy
+  printf "abc" > .git/lfs/incomplete/to-preserve
+
+  git -c lfs.incompletemaxage=24h lfs prune 2>&1 | tee prune.log
+  grep "prune: removed 2 stale temporary file(s) (9 B)" prune.log
+
+  [ ! -f .git/lfs/tmp/to-destroy ]
+  [ ! -f .git/lfs/incomplete/to-destroy ]
+  [ -f .git/lfs/incomplete/to-preserve ]
+)
+end_test
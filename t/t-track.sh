@@ -710,3 +710,68 @@ begin_test "track: escaped glob pattern with spaces in .gitattributes"
   assert_pointer "main" "$filename" "$contents_oid" 15
 )
 end_test
+
+begin_test "track --dirname"
+(
+  set -e
+
+  # None of these characters are valid in the Win32 subsystem.
+  [ "$IS_WINDOWS" -eq 1 ] && exit 0
+
+  reponame="track-dirname"
+  git init "$reponame"
+  cd "$reponame"
+
+  dirname="assets [old]"
+  contents="dirname"
+  contents_oid=$(calc_oid "$contents")
+
+  mkdir -p "$dirname/sub"
+  printf "%s" "$contents" > "$dirname/sub/a.psd"
+  printf "%s" "$contents" > "assets o.psd"
+
+  git lfs track --dirname --lockable "$dirname/"
+  git lfs track --dirname --lockable "$dirname" | grep 'already supported'
+  cat .gitattributes
+  grep -F 'assets[[:space:]]\[old\]/** filter=lfs diff=lfs merge=lfs -text lockable' .gitattributes
+  [ "set" = "$(git check-attr lockable -- "$dirname/sub/a.psd" | cut -d' ' -f4)" ]
+
+  git add .
+  git commit -m "add files"
+
+  assert_pointer "main" "$dirname/sub/a.psd" "$contents_oid" 7
+  refute_pointer "main" "assets o.psd"
+
+  git lfs track --dirname --filename "$dirname" 2>&1 | tee track.log
+  grep "Only one of --filename and --dirname options can be specified." track.log
+)
+end_test
+
+begin_test "track --query"
+(
+  set -e
+
+  reponame="track-query"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir -p dir/sub
+  git lfs track "*.bin" "*.dat"
+  printf '*.bin -filter\n' > dir/.gitattributes
+
+  git lfs track --query a.bin dir/a.bin dir/sub/a.bin dir/sub/b.dat c.txt | tee query.log
+  grep '^a.bin: tracked by "\*.bin" (.gitattributes)$' query.log
+  grep '^dir/sub/a.bin: not tracked, overridden by "dir/\*.bin" (dir/.gitattributes)$' query.log
+  grep '^dir/a.bin: not tracked, overridden by "dir/\*.bin" (dir/.gitattributes)$' query.log
+  grep '^dir/sub/b.dat: tracked by "\*.dat" (.gitattributes)$' query.log
+  grep '^c.txt: not tracked by any pattern$' query.log
+
+  cd dir
+  git lfs track --query ../a.bin a.bin | tee query.log
+  grep '^../a.bin: tracked by "\*.bin" (.gitattributes)$' query.log
+  grep '^a.bin: not tracked, overridden by "dir/\*.bin" (dir/.gitattributes)$' query.log
+
+  git lfs track --query 2>&1 | tee query.log
+  grep -- "--query requires at least one path." query.log
+)
+end_test
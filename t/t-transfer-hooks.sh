@@ -0,0 +1,133 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# write_hook writes a hook to "$TRASHDIR/<name>" which saves the JSON it is
+# given to "$TRASHDIR/<name>.json", and exits with the given status.
+write_hook() {
+  local name="$1" status="$2"
+  rm -f "$TRASHDIR/$name.json"
+  cat > "$TRASHDIR/$name" <<EOF
+#!/bin/sh
+cat > "$TRASHDIR/$name.json"
+echo "$name hook ran"
+exit $status
+EOF
+  chmod +x "$TRASHDIR/$name"
+}
+
+begin_test "transfer hooks: push"
+(
+  set -e
+
+  reponame="transfer-hooks-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  write_hook pre 0
+  write_hook post 0
+  git config lfs.hooks.pretransfer "$TRASHDIR/pre"
+  git config lfs.hooks.posttransfer "$TRASHDIR/post"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "bb" > b.dat
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "bb")"
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  git push origin main > push.log 2>&1
+  cat push.log
+  grep "pre hook ran" push.log
+  grep "post hook ran" push.log
+  assert_server_object "$reponame" "$a_oid"
+  assert_server_object "$reponame" "$b_oid"
+
+  cat "$TRASHDIR/pre.json"
+  grep '"event":"pretransfer"' "$TRASHDIR/pre.json"
+  grep '"operation":"upload"' "$TRASHDIR/pre.json"
+  grep '"remote":"origin"' "$TRASHDIR/pre.json"
+  grep "{\"oid\":\"$a_oid\",\"size\":1,\"name\":\"a.dat\"}" "$TRASHDIR/pre.json"
+  grep "{\"oid\":\"$b_oid\",\"size\":2,\"name\":\"b.dat\"}" "$TRASHDIR/pre.json"
+
+  cat "$TRASHDIR/post.json"
+  grep '"event":"posttransfer"' "$TRASHDIR/post.json"
+  grep "{\"oid\":\"$a_oid\",\"size\":1,\"name\":\"a.dat\",\"completed\":true}" "$TRASHDIR/post.json"
+  grep "{\"oid\":\"$b_oid\",\"size\":2,\"name\":\"b.dat\",\"completed\":true}" "$TRASHDIR/post.json"
+
+  # Dry runs do not run the hooks.
+  rm "$TRASHDIR/pre.json" "$TRASHDIR/post.json"
+  git lfs push --dry-run --all origin
+  [ ! -e "$TRASHDIR/pre.json" ]
+  [ ! -e "$TRASHDIR/post.json" ]
+)
+end_test
+
+begin_test "transfer hooks: pretransfer hook refuses push"
+(
+  set -e
+
+  reponame="transfer-hooks-refuse"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  write_hook pre 1
+  write_hook post 0
+  git config lfs.hooks.pretransfer "$TRASHDIR/pre"
+  git config lfs.hooks.posttransfer "$TRASHDIR/post"
+
+  git lfs track "*.dat"
+  printf "refused" > a.dat
+  oid="$(calc_oid "refused")"
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  set +e
+  git lfs push origin main > push.log 2>&1
+  res=$?
+  set -e
+  cat push.log
+  [ "$res" -eq 9 ]
+  grep "pretransfer hook refused the upload of 1 object(s)" push.log
+  refute_server_object "$reponame" "$oid"
+
+  cat "$TRASHDIR/post.json"
+  grep "{\"oid\":\"$oid\",\"size\":7,\"name\":\"a.dat\",\"completed\":false}" "$TRASHDIR/post.json"
+  grep '"errors":\["pretransfer hook refused' "$TRASHDIR/post.json"
+)
+end_test
+
+begin_test "transfer hooks: fetch and per remote"
+(
+  set -e
+
+  reponame="transfer-hooks-fetch"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "fetched" > a.dat
+  oid="$(calc_oid "fetched")"
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  write_hook pre 0
+  git config lfs.other.hooks.pretransfer "$TRASHDIR/pre"
+  git lfs fetch origin
+  [ ! -e "$TRASHDIR/pre.json" ]
+  rm -rf .git/lfs/objects
+
+  git config lfs.origin.hooks.pretransfer "$TRASHDIR/pre"
+  git lfs fetch origin
+  assert_local_object "$oid" 7
+  cat "$TRASHDIR/pre.json"
+  grep '"operation":"download"' "$TRASHDIR/pre.json"
+  grep "\"oid\":\"$oid\"" "$TRASHDIR/pre.json"
+)
+end_test
@@ -263,3 +263,105 @@ begin_test "uninstall --local"
   [ "" = "$(git config --local filter.lfs.process)" ]
 )
 end_test
+
+begin_test "uninstall --hooks-only"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-hooks-only"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git lfs install --local
+
+  git lfs uninstall --local --hooks-only 2>&1 | tee uninstall.log
+  grep "Hooks for this repository have been removed." uninstall.log
+  grep "configuration has been removed" uninstall.log && exit 1
+
+  [ ! -e .git/hooks/pre-push ]
+  [ ! -e .git/hooks/post-checkout ]
+  [ "git-lfs smudge -- %f" = "$(git config --local filter.lfs.smudge)" ]
+  [ "git-lfs clean -- %f" = "$(git config --local filter.lfs.clean)" ]
+  [ "git-lfs filter-process" = "$(git config --local filter.lfs.process)" ]
+)
+end_test
+
+begin_test "uninstall --filters-only"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-filters-only"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git lfs install --local
+
+  git lfs uninstall --local --filters-only 2>&1 | tee uninstall.log
+  grep "Hooks for this repository have been removed." uninstall.log && exit 1
+
+  [ -f .git/hooks/pre-push ]
+  [ -f .git/hooks/post-checkout ]
+  [ "" = "$(git config --local filter.lfs.smudge)" ]
+  [ "" = "$(git config --local filter.lfs.clean)" ]
+  [ "" = "$(git config --local filter.lfs.process)" ]
+  [ "$(grep -c 'filter "lfs"' .git/config)" = "0" ]
+
+  set +e
+  git lfs uninstall --hooks-only --filters-only 2>err.log
+  res=$?
+  set -e
+
+  [ "Only one of --hooks-only and --filters-only options can be specified." = "$(cat err.log)" ]
+  [ "0" != "$res" ]
+)
+end_test
+
+begin_test "uninstall removes only the Git LFS sections of hooks"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-hook-sections"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git lfs install --local
+
+  # another tool's hook, which also runs Git LFS
+  {
+    echo "#!/bin/sh"
+    echo "other-tool pre-push"
+    tail -n +2 .git/hooks/pre-push
+  } > pre-push.new
+  mv pre-push.new .git/hooks/pre-push
+  chmod +x .git/hooks/pre-push
+  grep "git lfs pre-push" .git/hooks/pre-push
+
+  git lfs uninstall --local
+
+  [ "$(printf '#!/bin/sh\nother-tool pre-push')" = "$(cat .git/hooks/pre-push)" ]
+  [ -x .git/hooks/pre-push ]
+  [ ! -e .git/hooks/post-checkout ]
+)
+end_test
+
+begin_test "uninstall leaves filter settings it did not make"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-filter-settings"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git lfs install --local
+  git config --local filter.lfs.clean "other-tool clean %f"
+  git config --local filter.lfs.other "value"
+
+  git lfs uninstall --local
+
+  [ "other-tool clean %f" = "$(git config --local filter.lfs.clean)" ]
+  [ "value" = "$(git config --local filter.lfs.other)" ]
+  [ "" = "$(git config --local filter.lfs.smudge)" ]
+  [ "" = "$(git config --local filter.lfs.process)" ]
+  [ "" = "$(git config --local filter.lfs.required)" ]
+)
+end_test
@@ -134,11 +134,98 @@ begin_test "unlock multiple files"
   git commit -m "add dat files"
   git push origin main:other
 
-  git lfs lock a.dat
-  git lfs lock b.dat
-  git lfs unlock *.dat >log 2>&1 && exit 1
+  git lfs lock --json a.dat | tee lock.json
+  id_a=$(assert_lock lock.json a.dat)
+  git lfs lock --json b.dat | tee lock.json
+  id_b=$(assert_lock lock.json b.dat)
+
+  git lfs unlock *.dat | tee unlock.log
+  grep "Unlocked a.dat" unlock.log
+  grep "Unlocked b.dat" unlock.log
+  grep "Unlocked 2 locks." unlock.log
+  refute_server_lock "$reponame" "$id_a"
+  refute_server_lock "$reponame" "$id_b"
+)
+end_test
+
+begin_test "unlock multiple ids"
+(
+  set -e
+
+  reponame="unlock-multiple-ids"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "a" > a.dat
+  echo "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add dat files"
+  git push origin main:other
+
+  git lfs lock --json a.dat | tee lock.json
+  id_a=$(assert_lock lock.json a.dat)
+  git lfs lock --json b.dat | tee lock.json
+  id_b=$(assert_lock lock.json b.dat)
+
+  echo "modified" > b.dat
+  git lfs unlock --json --id "$id_a" --id "$id_b" >unlock.json 2>unlock.log && exit 1
+  cat unlock.json unlock.log
+  grep '"type":"error","error":"Unable to unlock b.dat: Cannot unlock file with uncommitted changes' unlock.json
+  grep '"type":"error","error":"Unlocked 1 of 2 locks; 1 failed."' unlock.json
+  grep "\"id\":\"$id_a\",\"path\":\"a.dat\",\"unlocked\":true" unlock.json
+  grep "\"unlocked\":false,\"error\":\"Cannot unlock file" unlock.json
+  refute_server_lock "$reponame" "$id_a"
+  assert_server_lock "$reponame" "$id_b"
+)
+end_test
+
+begin_test "unlock by pattern with --force"
+(
+  set -e
+
+  reponame="unlock-pattern-force"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p art/sub
+  for f in art/ours.dat art/theirs.dat art/sub/deep.dat other.dat; do
+    echo "$f" > "$f"
+  done
+  git add .gitattributes art other.dat
+  git commit -m "add dat files"
+  git push origin main:other
 
-  grep "Usage:" log
+  git lfs lock art/*.dat art/sub/deep.dat other.dat
+  lock_id() {
+    git lfs locks --json --path "$1" > locks.json
+    assert_lock locks.json "$1"
+  }
+  id_ours=$(lock_id art/ours.dat)
+  id_theirs=$(lock_id art/theirs.dat)
+  id_deep=$(lock_id art/sub/deep.dat)
+  id_other=$(lock_id other.dat)
+
+  # Without --force, a pattern only matches our own locks.
+  git lfs unlock "art/*.dat" | tee unlock.log
+  grep "Unlocked art/ours.dat" unlock.log
+  grep "Unlocked 1 locks." unlock.log
+  refute_server_lock "$reponame" "$id_ours"
+  assert_server_lock "$reponame" "$id_theirs"
+  assert_server_lock "$reponame" "$id_deep"
+
+  git lfs unlock "nothing/*.dat" 2>&1 | tee unlock.log
+  grep "No locks match" unlock.log
+
+  # With --force, it matches any lock, even if the file is missing.
+  git rm -q art/theirs.dat
+  git commit -m "remove art/theirs.dat"
+  (cd art && git lfs unlock --force "*.dat") | tee unlock.log
+  grep "Unlocked art/theirs.dat" unlock.log
+  refute_server_lock "$reponame" "$id_theirs"
+  assert_server_lock "$reponame" "$id_deep"
+  assert_server_lock "$reponame" "$id_other"
 )
 end_test
 
@@ -176,3 +176,97 @@ begin_test "untrack works with GIT_WORK_TREE"
   [ ! -s "$reponame/.gitattributes" ]
 )
 end_test
+
+begin_test "untrack removes patterns from nested .gitattributes"
+(
+  set -e
+
+  reponame="untrack-nested"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir -p dir/sub
+  git lfs track "*.bin"
+  cd dir
+  git lfs track "*.bin" "*.dat"
+  cd ..
+
+  # a pattern relative to the root does not match one in dir/.gitattributes
+  git lfs untrack "*.dat" | tee untrack.log
+  [ ! -s untrack.log ]
+  grep -F '*.dat filter=lfs' dir/.gitattributes
+
+  git lfs untrack "dir/*.bin" | tee untrack.log
+  grep 'Untracking "\*.bin" (dir/.gitattributes)' untrack.log
+  grep -F '*.bin filter=lfs' .gitattributes
+  [ 0 -eq "$(grep -cF '*.bin' dir/.gitattributes)" ]
+
+  cd dir/sub
+  git lfs untrack "../*.dat" | tee untrack.log
+  grep 'Untracking "\*.dat" (dir/.gitattributes)' untrack.log
+  [ ! -s ../.gitattributes ]
+
+  git lfs untrack "../../*.bin" | tee untrack.log
+  grep 'Untracking "\*.bin" (.gitattributes)' untrack.log
+  [ ! -s ../../.gitattributes ]
+)
+end_test
+
+begin_test "untrack honors core.ignorecase"
+(
+  set -e
+
+  reponame="untrack-ignorecase"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config core.ignorecase false
+  git lfs track "*.PNG"
+  git lfs untrack "*.png"
+  grep -F '*.PNG filter=lfs' .gitattributes
+
+  git config core.ignorecase true
+  git lfs untrack "*.png" | grep 'Untracking "\*.PNG"'
+  [ ! -s .gitattributes ]
+)
+end_test
+
+begin_test "untrack --restore"
+(
+  set -e
+
+  reponame="untrack-restore"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat" "*.bin"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  printf "c" > c.dat
+  printf "x" > x.bin
+  git add .gitattributes *.dat x.bin
+  git commit -m "initial commit"
+
+  # b.dat is a pointer in the working tree, whose object is present
+  git lfs pointer --file=b.dat > b.ptr && mv b.ptr b.dat
+
+  # c.dat is a pointer in the working tree, whose object is missing
+  c_oid="$(calc_oid "c")"
+  git lfs pointer --file=c.dat > c.ptr && mv c.ptr c.dat
+  delete_local_object "$c_oid"
+
+  git lfs untrack --restore "*.dat" 2>&1 | tee untrack.log
+  grep 'Untracking "\*.dat"' untrack.log
+  grep 'Restoring "a.dat"' untrack.log
+  grep 'Restoring "b.dat"' untrack.log
+  grep "Unable to restore \"c.dat\": object $c_oid not found locally" untrack.log
+
+  [ "a" = "$(git cat-file -p :a.dat)" ]
+  [ "b" = "$(git cat-file -p :b.dat)" ]
+  [ "b" = "$(cat b.dat)" ]
+  git cat-file -p :c.dat | grep "oid sha256:$c_oid"
+
+  # files tracked by other patterns are untouched
+  git cat-file -p :x.bin | grep "oid sha256:$(calc_oid "x")"
+)
+end_test
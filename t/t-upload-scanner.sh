@@ -0,0 +1,110 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# write_scanner writes a scanner to "$TRASHDIR/scanner" which rejects objects
+# containing "SECRET", and logs the name of every object it scans to
+# "$TRASHDIR/scanned.log".
+write_scanner() {
+  rm -f "$TRASHDIR/scanned.log"
+  cat > "$TRASHDIR/scanner" <<EOF
+#!/bin/sh
+echo "\$GIT_LFS_NAME \$GIT_LFS_OID \$GIT_LFS_SIZE" >> "$TRASHDIR/scanned.log"
+if grep -q SECRET "\$1"; then
+  echo "\$GIT_LFS_NAME: secrets may not be uploaded"
+  exit 1
+fi
+EOF
+  chmod +x "$TRASHDIR/scanner"
+}
+
+begin_test "upload scanner: rejects objects"
+(
+  set -e
+
+  reponame="upload-scanner-reject"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  write_scanner
+  git config lfs.uploadscanner.path "$TRASHDIR/scanner"
+
+  git lfs track "*.dat"
+  printf "SECRET" > secret.dat
+  printf "public" > public.dat
+  secret_oid="$(calc_oid "SECRET")"
+  public_oid="$(calc_oid "public")"
+  git add .gitattributes secret.dat public.dat
+  git commit -m "add files"
+
+  set +e
+  git lfs push origin main > push.log 2>&1
+  res=$?
+  set -e
+  cat push.log
+  [ "$res" -eq 9 ]
+  grep "rejected object: secret.dat ($secret_oid): secret.dat: secrets may not be uploaded" push.log
+  grep "secret.dat $secret_oid 6" "$TRASHDIR/scanned.log"
+  grep "public.dat $public_oid 6" "$TRASHDIR/scanned.log"
+
+  refute_server_object "$reponame" "$secret_oid"
+  assert_server_object "$reponame" "$public_oid"
+
+  git push origin main 2>&1 | tee push.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep "secrets may not be uploaded" push.log
+  refute_server_object "$reponame" "$secret_oid"
+)
+end_test
+
+begin_test "upload scanner: only scans objects which are uploaded"
+(
+  set -e
+
+  reponame="upload-scanner-present"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "SECRET" > secret.dat
+  secret_oid="$(calc_oid "SECRET")"
+  git add .gitattributes secret.dat
+  git commit -m "add secret.dat"
+  git push origin main
+  assert_server_object "$reponame" "$secret_oid"
+
+  # Objects which the server already has are not uploaded, and so are not
+  # scanned.
+  write_scanner
+  git config lfs.uploadscanner.path "$TRASHDIR/scanner"
+  git checkout -b other
+  cp secret.dat copy.dat
+  git add copy.dat
+  git commit -m "add copy.dat"
+  git push origin other
+  [ ! -e "$TRASHDIR/scanned.log" ]
+)
+end_test
+
+begin_test "upload scanner: per remote"
+(
+  set -e
+
+  reponame="upload-scanner-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  write_scanner
+  git config lfs.other.uploadscanner.path "$TRASHDIR/scanner"
+
+  git lfs track "*.dat"
+  printf "SECRET" > secret.dat
+  secret_oid="$(calc_oid "SECRET")"
+  git add .gitattributes secret.dat
+  git commit -m "add secret.dat"
+
+  git push origin main
+  assert_server_object "$reponame" "$secret_oid"
+  [ ! -e "$TRASHDIR/scanned.log" ]
+)
+end_test
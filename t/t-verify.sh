@@ -123,3 +123,66 @@ begin_test "verify with retries (bad .gitconfig)"
   [ "2" -eq "$(grep -c "verify $contents_short_oid attempt" push.log)" ]
 )
 end_test
+
+begin_test "verify"
+(
+  set -e
+
+  reponame="verify"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir dir
+  printf "a" > a.dat
+  printf "b" > dir/b.dat
+  printf "b" > dir/copy.dat
+  git add .gitattributes a.dat dir
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs verify 2>&1 | tee verify.log
+  grep "All 2 object(s) in main are present on origin" verify.log
+
+  oid_b="$(calc_oid "b")"
+  delete_server_object "$reponame" "$oid_b"
+
+  git lfs verify origin >verify.log 2>&1 && exit 1
+  cat verify.log
+  grep "missing: dir/b.dat ($oid_b)" verify.log
+  grep "missing: dir/copy.dat ($oid_b)" verify.log
+  grep "1 of 2 object(s) in main are missing from origin" verify.log
+  [ 0 -eq "$(grep -c "a.dat" verify.log)" ]
+)
+end_test
+
+begin_test "verify --ref"
+(
+  set -e
+
+  reponame="verify-ref"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "old" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "old version"
+  git push origin main
+
+  git checkout -b feature
+  printf "new" > a.dat
+  git add a.dat
+  git commit -m "new version"
+
+  git lfs verify >verify.log 2>&1 && exit 1
+  cat verify.log
+  grep "missing: a.dat ($(calc_oid "new"))" verify.log
+
+  git lfs verify --ref main 2>&1 | tee verify.log
+  grep "All 1 object(s) in main are present on origin" verify.log
+
+  git lfs verify --ref main not-a-remote 2>&1 | tee verify.log
+  grep "Invalid remote name" verify.log
+)
+end_test
@@ -21,3 +21,25 @@ begin_test "git lfs --version is a synonym of git lfs version"
   fi
 )
 end_test
+
+begin_test "git lfs version --json"
+(
+  set -e
+
+  reponame="git-lfs-version-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.customtransfer.testcustom.path "testcustom"
+  git config lfs.customtransfer.testcustom.direction "download"
+
+  git lfs version --json >version.json
+  version="$(git lfs version | sed -e 's/^git-lfs\/\([^ ]*\) .*/\1/')"
+  grep "\"version\":\"$version\"" version.json
+  grep "\"user_agent\":\"$(git lfs version)\"" version.json
+  grep '"download":\["basic","lfs-standalone-file","lfs-standalone-oci","ssh","testcustom"\]' version.json
+  grep '"upload":\["basic","lfs-standalone-file","lfs-standalone-oci","ssh"\]' version.json
+  grep '"hash_algorithms":\["sha256","sha512"\]' version.json
+  grep '"features":\[.*"locking".*\]' version.json
+)
+end_test
@@ -47,8 +47,8 @@ PruneRemoteName=origin
 LfsStorageDir=$(canonical_path_escaped "$TRASHDIR/$reponame/.git/lfs")
 AccessDownload=none
 AccessUpload=none
-DownloadTransfers=basic,lfs-standalone-file,ssh
-UploadTransfers=basic,lfs-standalone-file,ssh
+DownloadTransfers=basic,lfs-standalone-file,lfs-standalone-oci,ssh
+UploadTransfers=basic,lfs-standalone-file,lfs-standalone-oci,ssh
 $(escape_path "$(env | grep "^GIT")")
 %s
 " "$(git lfs version)" "$(git version)" "$envInitConfig")
@@ -83,8 +83,8 @@ PruneRemoteName=origin
 LfsStorageDir=$(canonical_path_escaped "$TRASHDIR/$reponame/.git/lfs")
 AccessDownload=none
 AccessUpload=none
-DownloadTransfers=basic,lfs-standalone-file,ssh
-UploadTransfers=basic,lfs-standalone-file,ssh
+DownloadTransfers=basic,lfs-standalone-file,lfs-standalone-oci,ssh
+UploadTransfers=basic,lfs-standalone-file,lfs-standalone-oci,ssh
 $(escape_path "$(env | grep "^GIT")")
 %s
 " "$(git lfs version)" "$(git version)" "$envInitConfig")
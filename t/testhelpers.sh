@@ -306,6 +306,12 @@ dot_git_dir() {
   echo "$(git_root)/.git"
 }
 
+# zero_oid prints the all-zeros object ID of the current repository's hash
+# algorithm, which is 40 characters long for SHA-1 and 64 for SHA-256.
+zero_oid() {
+  git hash-object -t blob --stdin </dev/null | sed -e 's/./0/g'
+}
+
 assert_hooks() {
   local git_root="$1"
 
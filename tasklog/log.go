@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/git-lfs/git-lfs/tr"
 	isatty "github.com/mattn/go-isatty"
 	"github.com/olekukonko/ts"
 )
@@ -265,7 +266,7 @@ func (l *Logger) logTask(task Task) {
 		// If a task sent no updates, the last recorded update will be
 		// nil. Given this, only log a message when there was at least
 		// (1) update.
-		l.log(fmt.Sprintf("%s, done.\n", update.S))
+		l.log(tr.Tr.Get("%s, done.\n", update.S))
 	}
 
 	if v, ok := task.(interface {
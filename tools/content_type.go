@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ContentTypeSniffLen is the number of leading bytes of a file which
+// DetectContentType considers.
+const ContentTypeSniffLen = 512
+
+// defaultContentType is the content type of data which could not be
+// identified.
+const defaultContentType = "application/octet-stream"
+
+// contentSignatures are the magic numbers of formats often stored with Git LFS
+// which net/http does not recognize.
+var contentSignatures = []struct {
+	prefix      []byte
+	contentType string
+}{
+	{[]byte("8BPS"), "image/vnd.adobe.photoshop"},
+	{[]byte("II*\x00"), "image/tiff"},
+	{[]byte("MM\x00*"), "image/tiff"},
+	{[]byte("glTF"), "model/gltf-binary"},
+	{[]byte("BLENDER"), "application/x-blender"},
+	{[]byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{[]byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{[]byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{[]byte("BZh"), "application/x-bzip2"},
+	{[]byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+}
+
+// contentTypesByExtension are the types of the formats often stored with Git
+// LFS, keyed by the extension of their files' names.  The table is built in,
+// rather than read from the system's MIME database as by mime.TypeByExtension,
+// so that every client records the same type in the pointer of a file, and so
+// produces the same pointer.
+var contentTypesByExtension = map[string]string{
+	".7z":      "application/x-7z-compressed",
+	".aac":     "audio/aac",
+	".ai":      "application/postscript",
+	".apk":     "application/vnd.android.package-archive",
+	".avi":     "video/x-msvideo",
+	".blend":   "application/x-blender",
+	".bmp":     "image/bmp",
+	".bz2":     "application/x-bzip2",
+	".csv":     "text/csv",
+	".dll":     "application/vnd.microsoft.portable-executable",
+	".docx":    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".exe":     "application/vnd.microsoft.portable-executable",
+	".flac":    "audio/flac",
+	".gif":     "image/gif",
+	".glb":     "model/gltf-binary",
+	".gltf":    "model/gltf+json",
+	".gz":      "application/gzip",
+	".h5":      "application/x-hdf5",
+	".ico":     "image/vnd.microsoft.icon",
+	".iso":     "application/x-iso9660-image",
+	".jar":     "application/java-archive",
+	".jpeg":    "image/jpeg",
+	".jpg":     "image/jpeg",
+	".js":      "text/javascript; charset=utf-8",
+	".json":    "application/json",
+	".m4a":     "audio/mp4",
+	".mkv":     "video/x-matroska",
+	".mov":     "video/quicktime",
+	".mp3":     "audio/mpeg",
+	".mp4":     "video/mp4",
+	".obj":     "model/obj",
+	".odt":     "application/vnd.oasis.opendocument.text",
+	".ogg":     "audio/ogg",
+	".otf":     "font/otf",
+	".parquet": "application/vnd.apache.parquet",
+	".pdf":     "application/pdf",
+	".png":     "image/png",
+	".pptx":    "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".psd":     "image/vnd.adobe.photoshop",
+	".sqlite":  "application/vnd.sqlite3",
+	".stl":     "model/stl",
+	".svg":     "image/svg+xml",
+	".tar":     "application/x-tar",
+	".tga":     "image/x-tga",
+	".tif":     "image/tiff",
+	".tiff":    "image/tiff",
+	".ttf":     "font/ttf",
+	".wasm":    "application/wasm",
+	".wav":     "audio/wav",
+	".webm":    "video/webm",
+	".webp":    "image/webp",
+	".woff":    "font/woff",
+	".woff2":   "font/woff2",
+	".xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xml":     "text/xml; charset=utf-8",
+	".xz":      "application/x-xz",
+	".zip":     "application/zip",
+	".zst":     "application/zstd",
+}
+
+// genericContentTypes are the types which sniffing gives to data in
+// containers or encodings shared by many formats, for which the extension of
+// the file's name is a better guide.
+var genericContentTypes = map[string]bool{
+	defaultContentType:          true,
+	"text/plain; charset=utf-8": true,
+	"text/xml; charset=utf-8":   true,
+	"application/zip":           true,
+}
+
+// DetectContentType returns the media type of a file with the given name which
+// begins with data, of which at most ContentTypeSniffLen bytes are considered.
+// Its magic number is preferred, but if it is not recognized, or is one which
+// many formats share, such as that of a zip archive or plain text, the type
+// of the extension of the file's name, as listed in contentTypesByExtension, is
+// given instead. If neither identifies the file, "application/octet-stream" is
+// returned.
+func DetectContentType(name string, data []byte) string {
+	if len(data) > ContentTypeSniffLen {
+		data = data[:ContentTypeSniffLen]
+	}
+
+	sniffed := defaultContentType
+	if len(data) > 0 {
+		sniffed = http.DetectContentType(data)
+		if sniffed == defaultContentType {
+			for _, sig := range contentSignatures {
+				if bytes.HasPrefix(data, sig.prefix) {
+					sniffed = sig.contentType
+					break
+				}
+			}
+		}
+	}
+	if !genericContentTypes[sniffed] {
+		return sniffed
+	}
+
+	ext := strings.ToLower(path.Ext(filepath.ToSlash(name)))
+	if byExt, ok := contentTypesByExtension[ext]; ok {
+		return byExt
+	}
+	return sniffed
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectContentType(t *testing.T) {
+	for desc, c := range map[string]struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		"magic number":              {"image.dat", "\x89PNG\r\n\x1a\n", "image/png"},
+		"magic number over ext":     {"image.pdf", "\x89PNG\r\n\x1a\n", "image/png"},
+		"extra magic number":        {"layers.psd", "8BPS\x00\x01", "image/vnd.adobe.photoshop"},
+		"extra magic number no ext": {"model", "glTF\x02\x00\x00\x00", "model/gltf-binary"},
+		"ext for plain text":        {"data.json", `{"a": 1}`, "application/json"},
+		"ext for xml":               {"icon.svg", `<?xml version="1.0"?><svg/>`, "image/svg+xml"},
+		"ext for unknown data":      {"a/b/doc.PDF", "\x00\x01\x02", "application/pdf"},
+		"ext without data":          {"image.png", "", "image/png"},
+		"ext for zip container":     {"report.docx", "PK\x03\x04", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		"ext for model":             {"scene.gltf", "\x00\x01\x02", "model/gltf+json"},
+		"octet-stream ext":          {"data.bin", "hello", "text/plain; charset=utf-8"},
+		"plain text":                {"README", "hello", "text/plain; charset=utf-8"},
+		"unknown":                   {"blob.unknown-ext", "\x00\x01\x02", "application/octet-stream"},
+		"nothing":                   {"", "", "application/octet-stream"},
+	} {
+		assert.Equal(t, c.Expected, DetectContentType(c.Name, []byte(c.Data)), desc)
+	}
+}
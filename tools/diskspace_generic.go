@@ -0,0 +1,14 @@
+// +build !linux
+// +build !darwin
+// +build !freebsd
+// +build !windows
+
+package tools
+
+import "errors"
+
+// AvailableDiskSpace is not supported on this platform, and always returns an
+// error.
+func AvailableDiskSpace(dir string) (uint64, error) {
+	return 0, errors.New("checking available disk space is not supported on this platform")
+}
@@ -0,0 +1,15 @@
+// +build linux darwin freebsd
+
+package tools
+
+import "golang.org/x/sys/unix"
+
+// AvailableDiskSpace returns the number of bytes available to the current
+// user on the file system containing dir.
+func AvailableDiskSpace(dir string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
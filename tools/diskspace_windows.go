@@ -0,0 +1,20 @@
+// +build windows
+
+package tools
+
+import "golang.org/x/sys/windows"
+
+// AvailableDiskSpace returns the number of bytes available to the current
+// user on the volume containing dir.
+func AvailableDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(LongPath(dir))
+	if err != nil {
+		return 0, err
+	}
+
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
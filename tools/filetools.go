@@ -86,6 +86,19 @@ func RenameFileCopyPermissions(srcfile, destfile string) error {
 	return nil
 }
 
+// SyncFile flushes the contents of the file "path" to disk.
+func SyncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // CleanPaths splits the given `paths` argument by the delimiter argument, and
 // then "cleans" that path according to the path.Clean function (see
 // https://golang.org/pkg/path#Clean).
@@ -222,7 +235,7 @@ func VerifyFileHash(oid, path string) error {
 	}
 	defer f.Close()
 
-	h := NewLfsContentHash()
+	h := NewLfsContentHashFor(oid)
 	_, err = io.Copy(h, f)
 	if err != nil {
 		return err
@@ -427,6 +440,7 @@ func join(paths ...string) string {
 // All other bits are unaffected.
 // On Windows, all the write bits are set since Windows doesn't support Unix permissions.
 func SetFileWriteFlag(path string, writeEnabled bool) error {
+	path = LongPath(path)
 	stat, err := os.Stat(path)
 	if err != nil {
 		return err
@@ -456,7 +470,7 @@ func SetFileWriteFlag(path string, writeEnabled bool) error {
 // This function is designed to handle only temporary files that will be renamed
 // into place later somewhere within the Git repository.
 func TempFile(dir, pattern string, cfg repositoryPermissionFetcher) (*os.File, error) {
-	tmp, err := ioutil.TempFile(dir, pattern)
+	tmp, err := ioutil.TempFile(LongPath(dir), pattern)
 	if err != nil {
 		return nil, err
 	}
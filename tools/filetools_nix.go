@@ -2,7 +2,10 @@
 
 package tools
 
-import "path/filepath"
+import (
+	"os"
+	"path/filepath"
+)
 
 func CanonicalizeSystemPath(path string) (string, error) {
 	path, err := filepath.Abs(path)
@@ -11,3 +14,14 @@ func CanonicalizeSystemPath(path string) (string, error) {
 	}
 	return filepath.EvalSymlinks(path)
 }
+
+// SyncDir flushes the directory "dir" to disk, so that the creation, removal,
+// and renaming of files within it survive a crash.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
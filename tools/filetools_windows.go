@@ -7,7 +7,7 @@ import (
 )
 
 func openSymlink(path string) (windows.Handle, error) {
-	p, err := windows.UTF16PtrFromString(path)
+	p, err := windows.UTF16PtrFromString(LongPath(path))
 	if err != nil {
 		return 0, err
 	}
@@ -51,3 +51,9 @@ func CanonicalizeSystemPath(path string) (string, error) {
 	}
 	return s, nil
 }
+
+// SyncDir does nothing on Windows, where directories cannot be flushed to disk,
+// and where NTFS journals changes to directories itself.
+func SyncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package fuse serves a read-only tree of files through a FUSE mount, opening
+// each file only when it is first read.
+package fuse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrNotSupported is returned by Mount on platforms where FUSE mounts are not
+// supported.
+var ErrNotSupported = errors.New("fuse: mounting is not supported on this platform")
+
+// File is a regular file opened for reading by Node.Open.
+type File interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Node is a directory, regular file or symbolic link in a mounted tree.
+type Node struct {
+	// Mode holds the type and permission bits of the node.
+	Mode os.FileMode
+	// Size is the size of a regular file.
+	Size int64
+	// Target is the target of a symbolic link.
+	Target string
+	// Open opens a regular file for reading. It is called each time the
+	// file is opened, and blocks only the process opening it.
+	Open func() (File, error)
+
+	ino      uint64
+	names    []string
+	children map[string]*Node
+}
+
+// NewDir returns an empty directory, to be used as the root of a tree.
+func NewDir() *Node {
+	return &Node{Mode: os.ModeDir | 0555, children: make(map[string]*Node)}
+}
+
+// Add adds n to the tree rooted at the directory d at the slash-separated path
+// p, creating the directories leading to it which do not yet exist.
+func (d *Node) Add(p string, n *Node) error {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	dir := d
+	for i, name := range parts {
+		if len(name) == 0 || name == "." || name == ".." {
+			return fmt.Errorf("fuse: invalid path %q", p)
+		}
+		if !dir.Mode.IsDir() {
+			return fmt.Errorf("fuse: %q is not a directory", strings.Join(parts[:i], "/"))
+		}
+
+		if i == len(parts)-1 {
+			if _, ok := dir.children[name]; ok {
+				return fmt.Errorf("fuse: %q already exists", p)
+			}
+			if n.Mode.IsDir() && n.children == nil {
+				n.children = make(map[string]*Node)
+			}
+			dir.add(name, n)
+			return nil
+		}
+
+		child, ok := dir.children[name]
+		if !ok {
+			child = NewDir()
+			dir.add(name, child)
+		}
+		dir = child
+	}
+	return nil
+}
+
+// Lookup returns the node at the slash-separated path p within the tree rooted
+// at the directory d, or nil if there is none.
+func (d *Node) Lookup(p string) *Node {
+	n := d
+	for _, name := range strings.Split(strings.Trim(p, "/"), "/") {
+		if len(name) == 0 {
+			continue
+		}
+		if n = n.children[name]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// Names returns the names of the entries of the directory d, in sorted order.
+func (d *Node) Names() []string {
+	return d.names
+}
+
+func (d *Node) add(name string, n *Node) {
+	d.children[name] = n
+
+	i := sort.SearchStrings(d.names, name)
+	d.names = append(d.names, "")
+	copy(d.names[i+1:], d.names[i:])
+	d.names[i] = name
+}
@@ -0,0 +1,39 @@
+package fuse
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeAdd(t *testing.T) {
+	root := NewDir()
+	a := &Node{Mode: 0644, Size: 1}
+	b := &Node{Mode: 0755, Size: 2}
+	link := &Node{Mode: os.ModeSymlink, Target: "b"}
+
+	require.Nil(t, root.Add("dir/sub/a", a))
+	require.Nil(t, root.Add("dir/b", b))
+	require.Nil(t, root.Add("dir/a-link", link))
+
+	assert.Equal(t, []string{"dir"}, root.Names())
+	assert.Equal(t, []string{"a-link", "b", "sub"}, root.Lookup("dir").Names())
+	assert.True(t, root.Lookup("dir/sub").Mode.IsDir())
+	assert.Equal(t, a, root.Lookup("dir/sub/a"))
+	assert.Equal(t, b, root.Lookup("/dir/b"))
+	assert.Equal(t, link, root.Lookup("dir/a-link"))
+	assert.Equal(t, root, root.Lookup(""))
+	assert.Nil(t, root.Lookup("dir/c"))
+}
+
+func TestNodeAddInvalid(t *testing.T) {
+	root := NewDir()
+	require.Nil(t, root.Add("a", &Node{Mode: 0644}))
+
+	assert.EqualError(t, root.Add("a", &Node{Mode: 0644}), `fuse: "a" already exists`)
+	assert.EqualError(t, root.Add("a/b", &Node{Mode: 0644}), `fuse: "a" is not a directory`)
+	assert.EqualError(t, root.Add("c/../d", &Node{Mode: 0644}), `fuse: invalid path "c/../d"`)
+	assert.EqualError(t, root.Add("c//d", &Node{Mode: 0644}), `fuse: invalid path "c//d"`)
+}
@@ -0,0 +1,557 @@
+// +build linux
+
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/git-lfs/git-lfs/subprocess"
+	"golang.org/x/sys/unix"
+)
+
+// The FUSE operations handled by a Server, from <linux/fuse.h>. All others are
+// answered with ENOSYS.
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opReadlink    = 5
+	opOpen        = 14
+	opRead        = 15
+	opStatfs      = 17
+	opRelease     = 18
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opAccess      = 34
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+)
+
+const (
+	// kernelMajor and kernelMinor give the version of the FUSE protocol
+	// spoken by a Server. Version 7.12 is the oldest whose request and
+	// reply layouts are used here.
+	kernelMajor = 7
+	kernelMinor = 12
+
+	inHeaderSize  = 40
+	outHeaderSize = 16
+
+	// readBufferSize is the size of the buffer into which requests are
+	// read, which the kernel requires to hold at least 8KiB, and which
+	// must hold the largest WRITE request, although none are expected.
+	readBufferSize = 128*1024 + 4096
+
+	// cacheTimeout is how long the kernel may cache names and attributes,
+	// which never change.
+	cacheTimeout = time.Hour
+
+	fopenKeepCache = 1 << 1
+)
+
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 0 {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// Server serves a tree of nodes through a FUSE mount.
+type Server struct {
+	dir        string
+	fd         int
+	fusermount bool
+
+	nodes    []*Node
+	uid, gid uint32
+	mtime    time.Time
+
+	mu         sync.Mutex
+	handles    map[uint64]File
+	nextHandle uint64
+}
+
+// Mount mounts the tree rooted at the directory root read-only at dir. The
+// mount is made directly if the process may do so, and with fusermount(1)
+// otherwise. Requests are not answered until Serve is called.
+func Mount(dir string, root *Node) (*Server, error) {
+	if !root.Mode.IsDir() {
+		return nil, fmt.Errorf("fuse: root is not a directory")
+	}
+
+	s := &Server{
+		dir:     dir,
+		uid:     uint32(os.Getuid()),
+		gid:     uint32(os.Getgid()),
+		mtime:   time.Now(),
+		handles: make(map[uint64]File),
+	}
+	s.number(root)
+
+	fd, err := unix.Open("/dev/fuse", unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("fuse: could not open /dev/fuse: %v", err)
+	}
+
+	opts := fmt.Sprintf("fd=%d,rootmode=%o,user_id=%d,group_id=%d,default_permissions", fd, unix.S_IFDIR, s.uid, s.gid)
+	err = unix.Mount("git-lfs", dir, "fuse.git-lfs", unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, opts)
+	if err == unix.EPERM {
+		unix.Close(fd)
+		fd, err = fusermount(dir)
+		s.fusermount = true
+	}
+	if err != nil {
+		if !s.fusermount {
+			unix.Close(fd)
+		}
+		return nil, fmt.Errorf("fuse: could not mount %s: %v", dir, err)
+	}
+
+	s.fd = fd
+	return s, nil
+}
+
+// Serve answers requests until the tree is unmounted.
+func (s *Server) Serve() error {
+	defer s.closeHandles()
+	defer unix.Close(s.fd)
+
+	for {
+		buf := make([]byte, readBufferSize)
+		n, err := unix.Read(s.fd, buf)
+		switch err {
+		case nil:
+		case unix.EINTR, unix.EAGAIN, unix.ENOENT:
+			// ENOENT means the request was interrupted
+			// before it was read.
+			continue
+		case unix.ENODEV:
+			return nil
+		default:
+			return fmt.Errorf("fuse: could not read request: %v", err)
+		}
+		if n < inHeaderSize {
+			return fmt.Errorf("fuse: short request of %d byte(s)", n)
+		}
+
+		req := parseRequest(buf[:n])
+		switch req.opcode {
+		case opInit:
+			s.init(req)
+		case opDestroy:
+			s.reply(req, 0, nil)
+			return nil
+		case opForget, opBatchForget, opInterrupt:
+			// These are not answered.
+		default:
+			// Opening and reading files may be slow, so each
+			// request is answered independently.
+			go s.handle(req)
+		}
+	}
+}
+
+// Unmount unmounts the tree, after which Serve returns. The tree is detached at
+// once, even if it is in use; processes using it may go on doing so until they
+// are done, and Serve returns only then.
+func (s *Server) Unmount() error {
+	if s.fusermount {
+		out, err := subprocess.ExecCommand("fusermount", "-u", "-z", "--", s.dir).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("fuse: could not unmount %s: %v: %s", s.dir, err, bytes.TrimSpace(out))
+		}
+		return nil
+	}
+	if err := unix.Unmount(s.dir, unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("fuse: could not unmount %s: %v", s.dir, err)
+	}
+	return nil
+}
+
+// number assigns each node in the tree rooted at n its inode number, which is
+// also its FUSE node ID and its index in s.nodes plus one.
+func (s *Server) number(n *Node) {
+	s.nodes = append(s.nodes, n)
+	n.ino = uint64(len(s.nodes))
+	for _, name := range n.names {
+		s.number(n.children[name])
+	}
+}
+
+type request struct {
+	opcode uint32
+	unique uint64
+	nodeid uint64
+	body   []byte
+}
+
+func parseRequest(buf []byte) *request {
+	return &request{
+		opcode: nativeEndian.Uint32(buf[4:]),
+		unique: nativeEndian.Uint64(buf[8:]),
+		nodeid: nativeEndian.Uint64(buf[16:]),
+		body:   buf[inHeaderSize:],
+	}
+}
+
+func (s *Server) init(req *request) {
+	if len(req.body) < 16 {
+		s.reply(req, unix.EINVAL, nil)
+		return
+	}
+	major := nativeEndian.Uint32(req.body[0:])
+	maxReadahead := nativeEndian.Uint32(req.body[8:])
+	if major < kernelMajor {
+		s.reply(req, unix.EPROTO, nil)
+		return
+	}
+
+	var out encoder
+	out.u32(kernelMajor)
+	out.u32(kernelMinor)
+	out.u32(maxReadahead)
+	out.u32(0) // flags
+	out.u16(0) // max_background
+	out.u16(0) // congestion_threshold
+	out.u32(4096)
+	s.reply(req, 0, out.b)
+}
+
+func (s *Server) handle(req *request) {
+	var n *Node
+	if req.nodeid > 0 && req.nodeid <= uint64(len(s.nodes)) {
+		n = s.nodes[req.nodeid-1]
+	}
+
+	switch req.opcode {
+	case opLookup:
+		name := string(bytes.TrimRight(req.body, "\x00"))
+		if n == nil || !n.Mode.IsDir() {
+			s.reply(req, unix.ENOTDIR, nil)
+		} else if child, ok := n.children[name]; !ok {
+			s.reply(req, unix.ENOENT, nil)
+		} else {
+			var out encoder
+			out.u64(child.ino)
+			out.u64(0) // generation
+			out.duration(cacheTimeout)
+			out.duration(cacheTimeout)
+			out.u32(0)
+			out.u32(0)
+			s.attr(&out, child)
+			s.reply(req, 0, out.b)
+		}
+	case opGetattr:
+		if n == nil {
+			s.reply(req, unix.ENOENT, nil)
+			return
+		}
+		var out encoder
+		out.duration(cacheTimeout)
+		out.u32(0)
+		out.u32(0) // dummy
+		s.attr(&out, n)
+		s.reply(req, 0, out.b)
+	case opReadlink:
+		if n == nil || n.Mode&os.ModeSymlink == 0 {
+			s.reply(req, unix.EINVAL, nil)
+			return
+		}
+		s.reply(req, 0, []byte(n.Target))
+	case opOpen:
+		s.open(req, n)
+	case opRead:
+		s.read(req)
+	case opRelease:
+		if len(req.body) >= 8 {
+			fh := nativeEndian.Uint64(req.body)
+			s.mu.Lock()
+			f := s.handles[fh]
+			delete(s.handles, fh)
+			s.mu.Unlock()
+			if f != nil {
+				f.Close()
+			}
+		}
+		s.reply(req, 0, nil)
+	case opOpendir:
+		if n == nil || !n.Mode.IsDir() {
+			s.reply(req, unix.ENOTDIR, nil)
+			return
+		}
+		var out encoder
+		out.u64(0) // fh
+		out.u32(fopenKeepCache)
+		out.u32(0)
+		s.reply(req, 0, out.b)
+	case opReaddir:
+		s.readdir(req, n)
+	case opReleasedir, opAccess:
+		s.reply(req, 0, nil)
+	case opStatfs:
+		var out encoder
+		for i := 0; i < 5; i++ {
+			out.u64(0) // blocks, bfree, bavail, files, ffree
+		}
+		out.u32(4096) // bsize
+		out.u32(255)  // namelen
+		out.u32(4096) // frsize
+		for i := 0; i < 7; i++ {
+			out.u32(0) // padding, spare
+		}
+		s.reply(req, 0, out.b)
+	default:
+		// This includes FLUSH, which the kernel stops sending once
+		// it is refused.
+		s.reply(req, unix.ENOSYS, nil)
+	}
+}
+
+func (s *Server) open(req *request, n *Node) {
+	if n == nil || !n.Mode.IsRegular() || n.Open == nil {
+		s.reply(req, unix.EISDIR, nil)
+		return
+	}
+	if len(req.body) < 4 {
+		s.reply(req, unix.EINVAL, nil)
+		return
+	}
+	if nativeEndian.Uint32(req.body)&unix.O_ACCMODE != unix.O_RDONLY {
+		s.reply(req, unix.EROFS, nil)
+		return
+	}
+
+	f, err := n.Open()
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.reply(req, unix.ENOENT, nil)
+		} else {
+			s.reply(req, unix.EIO, nil)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	s.nextHandle++
+	fh := s.nextHandle
+	s.handles[fh] = f
+	s.mu.Unlock()
+
+	var out encoder
+	out.u64(fh)
+	out.u32(fopenKeepCache)
+	out.u32(0)
+	s.reply(req, 0, out.b)
+}
+
+func (s *Server) read(req *request) {
+	if len(req.body) < 20 {
+		s.reply(req, unix.EINVAL, nil)
+		return
+	}
+	fh := nativeEndian.Uint64(req.body[0:])
+	offset := nativeEndian.Uint64(req.body[8:])
+	size := nativeEndian.Uint32(req.body[16:])
+
+	s.mu.Lock()
+	f := s.handles[fh]
+	s.mu.Unlock()
+	if f == nil {
+		s.reply(req, unix.EBADF, nil)
+		return
+	}
+
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, int64(offset))
+	if err != nil && err != io.EOF {
+		s.reply(req, unix.EIO, nil)
+		return
+	}
+	s.reply(req, 0, buf[:n])
+}
+
+// readdir lists the entries of a directory from the offset given, which is the
+// index of the next entry to list.
+func (s *Server) readdir(req *request, n *Node) {
+	if n == nil || !n.Mode.IsDir() {
+		s.reply(req, unix.ENOTDIR, nil)
+		return
+	}
+	if len(req.body) < 20 {
+		s.reply(req, unix.EINVAL, nil)
+		return
+	}
+	offset := nativeEndian.Uint64(req.body[8:])
+	size := int(nativeEndian.Uint32(req.body[16:]))
+
+	var out encoder
+	for i := offset; i < uint64(len(n.names)); i++ {
+		name := n.names[i]
+		child := n.children[name]
+
+		entlen := 24 + len(name)
+		padded := (entlen + 7) &^ 7
+		if len(out.b)+padded > size {
+			break
+		}
+		out.u64(child.ino)
+		out.u64(i + 1) // offset of the next entry
+		out.u32(uint32(len(name)))
+		out.u32(fileMode(child.Mode) >> 12)
+		out.b = append(out.b, name...)
+		out.b = append(out.b, make([]byte, padded-entlen)...)
+	}
+	s.reply(req, 0, out.b)
+}
+
+// attr encodes the attributes of n as a "struct fuse_attr".
+func (s *Server) attr(out *encoder, n *Node) {
+	nlink := uint32(1)
+	size := n.Size
+	if n.Mode.IsDir() {
+		nlink = 2
+		size = 4096
+	} else if n.Mode&os.ModeSymlink != 0 {
+		size = int64(len(n.Target))
+	}
+	mtime := uint64(s.mtime.Unix())
+	nsec := uint32(s.mtime.Nanosecond())
+
+	out.u64(n.ino)
+	out.u64(uint64(size))
+	out.u64(uint64((size + 511) / 512)) // blocks
+	out.u64(mtime)                      // atime
+	out.u64(mtime)                      // mtime
+	out.u64(mtime)                      // ctime
+	out.u32(nsec)
+	out.u32(nsec)
+	out.u32(nsec)
+	out.u32(fileMode(n.Mode))
+	out.u32(nlink)
+	out.u32(s.uid)
+	out.u32(s.gid)
+	out.u32(0)    // rdev
+	out.u32(4096) // blksize
+	out.u32(0)    // padding
+}
+
+func (s *Server) reply(req *request, errno syscall.Errno, body []byte) {
+	var out encoder
+	out.u32(uint32(outHeaderSize + len(body)))
+	out.u32(uint32(-int32(errno)))
+	out.u64(req.unique)
+	out.b = append(out.b, body...)
+
+	// The request may have been interrupted, in which case the reply
+	// is refused with ENOENT, and there is nothing more to do.
+	unix.Write(s.fd, out.b)
+}
+
+func (s *Server) closeHandles() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for fh, f := range s.handles {
+		f.Close()
+		delete(s.handles, fh)
+	}
+}
+
+// fileMode returns the Unix mode of a node with the given mode. Nodes are
+// read-only, and directories and files may be read by everyone.
+func fileMode(mode os.FileMode) uint32 {
+	perm := uint32(mode.Perm()) &^ 0222
+	switch {
+	case mode.IsDir():
+		return unix.S_IFDIR | perm
+	case mode&os.ModeSymlink != 0:
+		return unix.S_IFLNK | 0777
+	default:
+		return unix.S_IFREG | perm
+	}
+}
+
+// fusermount mounts a FUSE file system at dir with fusermount(1), which may be
+// used by unprivileged users, and returns the file descriptor through which it
+// is served.
+func fusermount(dir string) (int, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return -1, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "fusermount")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount")
+	defer local.Close()
+	defer remote.Close()
+
+	var stderr bytes.Buffer
+	cmd := subprocess.ExecCommand("fusermount", "-o", "ro,nosuid,nodev,default_permissions,fsname=git-lfs,subtype=git-lfs", "--", dir)
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	buf := make([]byte, 4)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, rerr := unix.Recvmsg(int(local.Fd()), buf, oob, 0)
+	if err := cmd.Wait(); err != nil {
+		return -1, fmt.Errorf("%v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if rerr != nil {
+		return -1, rerr
+	}
+
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return -1, fmt.Errorf("no file descriptor received from fusermount")
+	}
+	received, err := unix.ParseUnixRights(&msgs[0])
+	if err != nil || len(received) == 0 {
+		return -1, fmt.Errorf("no file descriptor received from fusermount")
+	}
+	return received[0], nil
+}
+
+// encoder encodes the fields of FUSE replies in the native byte order.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) u16(v uint16) {
+	var buf [2]byte
+	nativeEndian.PutUint16(buf[:], v)
+	e.b = append(e.b, buf[:]...)
+}
+
+func (e *encoder) u32(v uint32) {
+	var buf [4]byte
+	nativeEndian.PutUint32(buf[:], v)
+	e.b = append(e.b, buf[:]...)
+}
+
+func (e *encoder) u64(v uint64) {
+	var buf [8]byte
+	nativeEndian.PutUint64(buf[:], v)
+	e.b = append(e.b, buf[:]...)
+}
+
+// duration encodes the whole seconds of d. The nanoseconds are encoded
+// separately, after the other whole seconds of the same structure.
+func (e *encoder) duration(d time.Duration) {
+	e.u64(uint64(d / time.Second))
+}
@@ -0,0 +1,105 @@
+// +build linux
+
+package fuse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+type testFile struct {
+	*bytes.Reader
+}
+
+func (f *testFile) Close() error { return nil }
+
+// readFile reads the file at path without registering it with the runtime's
+// poller, as os.Open does, which would make the kernel send this process a
+// FUSE request it cannot answer while the registration blocks.
+func readFile(path string) ([]byte, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	var data []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := unix.Read(fd, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return data, nil
+		}
+		data = append(data, buf[:n]...)
+	}
+}
+
+func TestServerMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuse-mount")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var opened int32
+	contents := []byte("contents of a\n")
+
+	root := NewDir()
+	require.Nil(t, root.Add("dir/a.txt", &Node{
+		Mode: 0644,
+		Size: int64(len(contents)),
+		Open: func() (File, error) {
+			atomic.AddInt32(&opened, 1)
+			return &testFile{bytes.NewReader(contents)}, nil
+		},
+	}))
+	require.Nil(t, root.Add("dir/missing.txt", &Node{
+		Mode: 0644,
+		Size: 1,
+		Open: func() (File, error) { return nil, os.ErrNotExist },
+	}))
+	require.Nil(t, root.Add("link", &Node{Mode: os.ModeSymlink, Target: "dir/a.txt"}))
+
+	s, err := Mount(dir, root)
+	if err != nil {
+		t.Skipf("could not mount: %v", err)
+	}
+	served := make(chan error)
+	go func() { served <- s.Serve() }()
+	defer func() {
+		require.Nil(t, s.Unmount())
+		require.Nil(t, <-served)
+	}()
+
+	infos, err := ioutil.ReadDir(filepath.Join(dir, "dir"))
+	require.Nil(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "a.txt", infos[0].Name())
+	assert.Equal(t, int64(len(contents)), infos[0].Size())
+	assert.Equal(t, os.FileMode(0444), infos[0].Mode())
+	assert.EqualValues(t, 0, atomic.LoadInt32(&opened))
+
+	data, err := readFile(filepath.Join(dir, "link"))
+	require.Nil(t, err)
+	assert.Equal(t, contents, data)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&opened))
+
+	target, err := os.Readlink(filepath.Join(dir, "link"))
+	require.Nil(t, err)
+	assert.Equal(t, "dir/a.txt", target)
+
+	_, err = readFile(filepath.Join(dir, "dir", "missing.txt"))
+	assert.Equal(t, unix.ENOENT, err)
+
+	_, err = unix.Open(filepath.Join(dir, "dir", "a.txt"), unix.O_WRONLY, 0)
+	assert.Equal(t, unix.EROFS, err)
+}
@@ -0,0 +1,22 @@
+// +build !linux
+
+package fuse
+
+// Server serves a tree of nodes through a FUSE mount.
+type Server struct{}
+
+// Mount mounts the tree rooted at the directory root read-only at dir. It is
+// not supported on this platform, and returns ErrNotSupported.
+func Mount(dir string, root *Node) (*Server, error) {
+	return nil, ErrNotSupported
+}
+
+// Serve answers requests until the tree is unmounted.
+func (s *Server) Serve() error {
+	return ErrNotSupported
+}
+
+// Unmount unmounts the tree, after which Serve returns.
+func (s *Server) Unmount() error {
+	return ErrNotSupported
+}
@@ -0,0 +1,17 @@
+// +build !windows
+
+package tools
+
+import (
+	"os"
+	"syscall"
+)
+
+// Inode returns the inode number of the file described by fi, or zero if it
+// is not known.
+func Inode(fi os.FileInfo) uint64 {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+	return 0
+}
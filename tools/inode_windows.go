@@ -0,0 +1,11 @@
+// +build windows
+
+package tools
+
+import "os"
+
+// Inode returns zero, since the file index which stands in for an inode number
+// on Windows is only available from an open handle, not from fi.
+func Inode(fi os.FileInfo) uint64 {
+	return 0
+}
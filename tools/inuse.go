@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+// FileInUseError is returned when a file could not be opened, created or
+// replaced, even after retrying, because another process holds it open without
+// sharing it, as editors and virus scanners may on Windows.
+type FileInUseError struct {
+	// Path is the path of the file in use.
+	Path string
+	// Processes describes the processes holding the file open, if they
+	// could be found, such as "notepad.exe (pid 1234)".
+	Processes []string
+	// Err is the error of the last attempt to use the file.
+	Err error
+}
+
+func (e *FileInUseError) Error() string {
+	holder := "another process"
+	if len(e.Processes) > 0 {
+		holder = strings.Join(e.Processes, ", ")
+	}
+	return fmt.Sprintf("%s is in use by %s: %v", e.Path, holder, e.Err)
+}
+
+// IsFileInUseError returns whether err, or the error it wraps, is a
+// *FileInUseError.
+func IsFileInUseError(err error) bool {
+	_, ok := errors.Cause(err).(*FileInUseError)
+	return ok
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileInUseErrorNamesProcesses(t *testing.T) {
+	err := &FileInUseError{
+		Path:      "a.psd",
+		Processes: []string{"Photoshop.exe (pid 12)", "MsMpEng.exe (pid 34)"},
+		Err:       fmt.Errorf("sharing violation"),
+	}
+	assert.Equal(t, "a.psd is in use by Photoshop.exe (pid 12), MsMpEng.exe (pid 34): sharing violation", err.Error())
+
+	err.Processes = nil
+	assert.Equal(t, "a.psd is in use by another process: sharing violation", err.Error())
+}
+
+func TestIsFileInUseError(t *testing.T) {
+	err := &FileInUseError{Path: "a.psd", Err: fmt.Errorf("sharing violation")}
+
+	assert.True(t, IsFileInUseError(err))
+	assert.True(t, IsFileInUseError(errors.Wrap(err, "could not create working directory file")))
+	assert.False(t, IsFileInUseError(fmt.Errorf("sharing violation")))
+	assert.False(t, IsFileInUseError(nil))
+}
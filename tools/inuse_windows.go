@@ -0,0 +1,100 @@
+// +build windows
+
+package tools
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The Restart Manager finds the processes which hold files open, as Windows
+// Explorer does when it reports a file as in use.
+var (
+	modrstrtmgr = windows.NewLazySystemDLL("rstrtmgr.dll")
+
+	procRmStartSession      = modrstrtmgr.NewProc("RmStartSession")
+	procRmRegisterResources = modrstrtmgr.NewProc("RmRegisterResources")
+	procRmGetList           = modrstrtmgr.NewProc("RmGetList")
+	procRmEndSession        = modrstrtmgr.NewProc("RmEndSession")
+)
+
+const (
+	rmSessionKeyLen = 32
+	rmMaxAppName    = 255
+	rmMaxSvcName    = 63
+)
+
+// rmUniqueProcess is an RM_UNIQUE_PROCESS structure.
+type rmUniqueProcess struct {
+	ProcessID        uint32
+	ProcessStartTime windows.Filetime
+}
+
+// rmProcessInfo is an RM_PROCESS_INFO structure.
+type rmProcessInfo struct {
+	Process          rmUniqueProcess
+	AppName          [rmMaxAppName + 1]uint16
+	ServiceShortName [rmMaxSvcName + 1]uint16
+	ApplicationType  uint32
+	AppStatus        uint32
+	TSSessionID      uint32
+	Restartable      int32
+}
+
+// processesUsing returns descriptions of the processes which hold any of the
+// files with the given paths open, such as "notepad.exe (pid 1234)".  It
+// returns nil if they cannot be found.
+func processesUsing(paths ...string) []string {
+	if modrstrtmgr.Load() != nil {
+		return nil
+	}
+
+	var session uint32
+	var key [rmSessionKeyLen + 1]uint16
+	if r, _, _ := procRmStartSession.Call(uintptr(unsafe.Pointer(&session)), 0, uintptr(unsafe.Pointer(&key[0]))); r != 0 {
+		return nil
+	}
+	defer procRmEndSession.Call(uintptr(session))
+
+	names := make([]*uint16, 0, len(paths))
+	for _, path := range paths {
+		name, err := windows.UTF16PtrFromString(LongPath(path))
+		if err != nil {
+			return nil
+		}
+		names = append(names, name)
+	}
+	if r, _, _ := procRmRegisterResources.Call(uintptr(session), uintptr(len(names)), uintptr(unsafe.Pointer(&names[0])), 0, 0, 0, 0); r != 0 {
+		return nil
+	}
+
+	// Ask for the number of processes first, and ask again if more
+	// processes open the files in the meantime.
+	var infos []rmProcessInfo
+	var count uint32
+	for attempt := 0; ; attempt++ {
+		var needed, reasons uint32
+		var ptr uintptr
+		count = uint32(len(infos))
+		if count > 0 {
+			ptr = uintptr(unsafe.Pointer(&infos[0]))
+		}
+		r, _, _ := procRmGetList.Call(uintptr(session), uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&count)), ptr, uintptr(unsafe.Pointer(&reasons)))
+		if r == 0 {
+			break
+		}
+		if windows.Errno(r) != windows.ERROR_MORE_DATA || attempt >= 3 {
+			return nil
+		}
+		infos = make([]rmProcessInfo, needed)
+	}
+
+	processes := make([]string, 0, count)
+	for _, info := range infos[:count] {
+		processes = append(processes, fmt.Sprintf("%s (pid %d)",
+			windows.UTF16ToString(info.AppName[:]), info.Process.ProcessID))
+	}
+	return processes
+}
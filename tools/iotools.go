@@ -3,6 +3,7 @@ package tools
 import (
 	"bytes"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"hash"
 	"io"
@@ -19,6 +20,15 @@ const (
 	memoryBufferLimit = 1024
 )
 
+const (
+	// HashAlgorithmSHA256 is the name of the default hash algorithm used
+	// to compute LFS object IDs.
+	HashAlgorithmSHA256 = "sha256"
+	// HashAlgorithmSHA512 is the name of the alternative SHA-512 hash
+	// algorithm used to compute LFS object IDs.
+	HashAlgorithmSHA512 = "sha512"
+)
+
 // CopyWithCallback copies reader to writer while performing a progress callback
 func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb CopyCallback) (int64, error) {
 	if success, _ := CloneFile(writer, reader); success {
@@ -39,11 +49,42 @@ func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb Co
 	return io.Copy(writer, cbReader)
 }
 
-// Get a new Hash instance of the type used to hash LFS content
+// Get a new Hash instance of the type used to hash LFS content.  The standard
+// library's implementation chooses the fastest instructions the CPU supports
+// when the program starts, so no other implementation is used.
 func NewLfsContentHash() hash.Hash {
 	return sha256.New()
 }
 
+// NewLfsContentHashAlgorithm returns a new Hash instance of the named
+// algorithm, or nil if the algorithm is not supported.
+func NewLfsContentHashAlgorithm(algo string) hash.Hash {
+	switch algo {
+	case HashAlgorithmSHA256:
+		return sha256.New()
+	case HashAlgorithmSHA512:
+		return sha512.New()
+	default:
+		return nil
+	}
+}
+
+// OidHashAlgorithm returns the name of the hash algorithm which produced the
+// given LFS object ID. Object IDs from different algorithms are told apart by
+// their length, so that they can share the same object store and API.
+func OidHashAlgorithm(oid string) string {
+	if len(oid) == sha512.Size*2 {
+		return HashAlgorithmSHA512
+	}
+	return HashAlgorithmSHA256
+}
+
+// NewLfsContentHashFor returns a new Hash instance of the type used to compute
+// the given LFS object ID.
+func NewLfsContentHashFor(oid string) hash.Hash {
+	return NewLfsContentHashAlgorithm(OidHashAlgorithm(oid))
+}
+
 // HashingReader wraps a reader and calculates the hash of the data as it is read
 type HashingReader struct {
 	reader io.Reader
@@ -54,6 +95,12 @@ func NewHashingReader(r io.Reader) *HashingReader {
 	return &HashingReader{r, NewLfsContentHash()}
 }
 
+// NewHashingReaderFor returns a HashingReader which computes the hash of the
+// type used to compute the given LFS object ID.
+func NewHashingReaderFor(r io.Reader, oid string) *HashingReader {
+	return &HashingReader{r, NewLfsContentHashFor(oid)}
+}
+
 func NewHashingReaderPreloadHash(r io.Reader, hash hash.Hash) *HashingReader {
 	return &HashingReader{r, hash}
 }
@@ -3,6 +3,7 @@ package tools_test
 import (
 	"bytes"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/git-lfs/git-lfs/errors"
@@ -74,3 +75,15 @@ type ErrReader struct {
 func (e *ErrReader) Read(p []byte) (n int, err error) {
 	return 0, e.err
 }
+
+func TestOidHashAlgorithm(t *testing.T) {
+	assert.Equal(t, tools.HashAlgorithmSHA256, tools.OidHashAlgorithm(strings.Repeat("a", 64)))
+	assert.Equal(t, tools.HashAlgorithmSHA512, tools.OidHashAlgorithm(strings.Repeat("a", 128)))
+	assert.Equal(t, tools.HashAlgorithmSHA256, tools.OidHashAlgorithm("a"))
+}
+
+func TestNewLfsContentHashAlgorithm(t *testing.T) {
+	assert.Equal(t, 32, tools.NewLfsContentHashAlgorithm("sha256").Size())
+	assert.Equal(t, 64, tools.NewLfsContentHashAlgorithm("sha512").Size())
+	assert.Nil(t, tools.NewLfsContentHashAlgorithm("md5"))
+}
@@ -0,0 +1,9 @@
+// +build !windows
+
+package tools
+
+// LongPath returns path, since only Windows limits the length of paths to
+// MAX_PATH characters.
+func LongPath(path string) string {
+	return path
+}
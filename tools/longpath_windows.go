@@ -0,0 +1,37 @@
+// +build windows
+
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is the length of the longest path, excluding the directory
+// separator and file name that may follow it, which Windows accepts without
+// the extended-length prefix.  It is the length used by package os.
+const maxShortPath = 248
+
+// LongPath returns a form of path which Windows accepts even if it is longer
+// than MAX_PATH characters: if the absolute form of path is too long, that
+// form with the `\\?\` extended-length prefix, or `\\?\UNC\` for a UNC path,
+// and otherwise path itself.
+//
+// Package os already extends long absolute paths, but not relative ones, which
+// Windows resolves against the working directory before applying the limit,
+// and paths given to the Windows API directly are never extended.
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < maxShortPath {
+		return path
+	}
+
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
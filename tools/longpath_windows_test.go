@@ -0,0 +1,57 @@
+// +build windows
+
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongPathLeavesShortPaths(t *testing.T) {
+	assert.Equal(t, `a\b.dat`, LongPath(`a\b.dat`))
+	assert.Equal(t, `C:\a\b.dat`, LongPath(`C:\a\b.dat`))
+}
+
+func TestLongPathLeavesExtendedPaths(t *testing.T) {
+	path := `\\?\C:\` + strings.Repeat(`a\`, 200) + `b.dat`
+	assert.Equal(t, path, LongPath(path))
+}
+
+func TestLongPathExtendsLongPaths(t *testing.T) {
+	rel := strings.Repeat(`directory\`, 30) + `b.dat`
+	abs, err := filepath.Abs(rel)
+	require.Nil(t, err)
+
+	assert.Equal(t, `\\?\`+abs, LongPath(rel))
+	assert.Equal(t, `\\?\`+abs, LongPath(abs))
+}
+
+func TestLongPathExtendsUNCPaths(t *testing.T) {
+	path := `\\server\share\` + strings.Repeat(`directory\`, 30) + `b.dat`
+	assert.Equal(t, `\\?\UNC\server\share\`+strings.Repeat(`directory\`, 30)+`b.dat`, LongPath(path))
+}
+
+func TestLongPathCreatesDeepFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "longpath")
+	require.Nil(t, err)
+	defer os.RemoveAll(LongPath(dir))
+
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	rel := filepath.Join(strings.Repeat(`directory\`, 30), "b.dat")
+	require.Nil(t, os.MkdirAll(LongPath(filepath.Dir(rel)), 0755))
+	require.Nil(t, ioutil.WriteFile(LongPath(rel), []byte("b"), 0644))
+
+	data, err := ioutil.ReadFile(LongPath(rel))
+	require.Nil(t, err)
+	assert.Equal(t, "b", string(data))
+}
@@ -11,3 +11,9 @@ func RobustRename(oldpath, newpath string) error {
 func RobustOpen(name string) (*os.File, error) {
 	return os.Open(name)
 }
+
+// RobustCreate creates or truncates the named file, as os.Create does.  On
+// Windows, it retries while another process holds the file open.
+func RobustCreate(name string) (*os.File, error) {
+	return os.Create(name)
+}
@@ -4,11 +4,21 @@ package tools
 
 import (
 	"os"
+	"time"
 
 	"github.com/avast/retry-go"
 	"golang.org/x/sys/windows"
 )
 
+var (
+	// robustAttempts is the number of times a file in use is tried.
+	robustAttempts uint = 10
+	// robustDelay is the delay before the first retry of a file in use,
+	// which doubles with each further retry, up to robustMaxDelay.
+	robustDelay    = 100 * time.Millisecond
+	robustMaxDelay = 2 * time.Second
+)
+
 func underlyingError(err error) error {
 	switch err := err.(type) {
 	case *os.PathError:
@@ -30,25 +40,59 @@ func isEphemeralError(err error) bool {
 	return err == windows.ERROR_SHARING_VIOLATION
 }
 
-func RobustRename(oldpath, newpath string) error {
-	return retry.Do(
-		func() error {
-			return os.Rename(oldpath, newpath)
-		},
+// robustDelayType backs off exponentially from robustDelay, waiting no more
+// than robustMaxDelay between attempts.
+func robustDelayType(n uint, config *retry.Config) time.Duration {
+	if n >= 16 || robustDelay<<n > robustMaxDelay {
+		return robustMaxDelay
+	}
+	return robustDelay << n
+}
+
+// robustly calls fn until it succeeds, or fails with an error which waiting
+// would not resolve, or has been tried robustAttempts times.  If the files
+// with the given paths are still in use by another process, it returns a
+// *FileInUseError naming the processes which hold them open.
+func robustly(fn func() error, paths ...string) error {
+	err := retry.Do(fn,
 		retry.RetryIf(isEphemeralError),
 		retry.LastErrorOnly(true),
+		retry.Attempts(robustAttempts),
+		retry.Delay(robustDelay),
+		retry.DelayType(robustDelayType),
 	)
+	if err != nil && isEphemeralError(err) {
+		return &FileInUseError{
+			Path:      paths[len(paths)-1],
+			Processes: processesUsing(paths...),
+			Err:       err,
+		}
+	}
+	return err
+}
+
+func RobustRename(oldpath, newpath string) error {
+	return robustly(func() error {
+		return os.Rename(oldpath, newpath)
+	}, oldpath, newpath)
 }
 
 func RobustOpen(name string) (*os.File, error) {
 	var result *os.File
-	return result, retry.Do(
-		func() error {
-			f, err := os.Open(name)
-			result = f
-			return err
-		},
-		retry.RetryIf(isEphemeralError),
-		retry.LastErrorOnly(true),
-	)
+	return result, robustly(func() error {
+		f, err := os.Open(name)
+		result = f
+		return err
+	}, name)
+}
+
+// RobustCreate creates or truncates the named file, as os.Create does,
+// retrying while another process holds the file open.
+func RobustCreate(name string) (*os.File, error) {
+	var result *os.File
+	return result, robustly(func() error {
+		f, err := os.Create(name)
+		result = f
+		return err
+	}, name)
 }
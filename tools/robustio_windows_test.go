@@ -0,0 +1,80 @@
+// +build windows
+
+package tools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+// lockFile opens the file with the given path without sharing it, as some
+// editors do, returning its handle.
+func lockFile(t *testing.T, path string) windows.Handle {
+	name, err := windows.UTF16PtrFromString(path)
+	require.Nil(t, err)
+	h, err := windows.CreateFile(name, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	require.Nil(t, err)
+	return h
+}
+
+func withRobustRetries(attempts uint, delay time.Duration) func() {
+	oldAttempts, oldDelay := robustAttempts, robustDelay
+	robustAttempts, robustDelay = attempts, delay
+	return func() { robustAttempts, robustDelay = oldAttempts, oldDelay }
+}
+
+func TestRobustCreateReportsProcessHoldingFile(t *testing.T) {
+	defer withRobustRetries(2, time.Millisecond)()
+
+	dir, err := ioutil.TempDir("", "robustio")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.dat")
+	require.Nil(t, ioutil.WriteFile(path, []byte("a"), 0644))
+	h := lockFile(t, path)
+	defer windows.CloseHandle(h)
+
+	_, err = RobustCreate(path)
+	require.NotNil(t, err)
+	require.True(t, IsFileInUseError(err))
+
+	inUse := err.(*FileInUseError)
+	assert.Equal(t, path, inUse.Path)
+	assert.Contains(t, fmt.Sprint(inUse.Processes), fmt.Sprintf("(pid %d)", os.Getpid()))
+}
+
+func TestRobustCreateRetriesUntilFileIsReleased(t *testing.T) {
+	defer withRobustRetries(10, 10*time.Millisecond)()
+
+	dir, err := ioutil.TempDir("", "robustio")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.dat")
+	require.Nil(t, ioutil.WriteFile(path, []byte("a"), 0644))
+	h := lockFile(t, path)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		windows.CloseHandle(h)
+	}()
+
+	f, err := RobustCreate(path)
+	require.Nil(t, err)
+	f.Close()
+}
+
+func TestRobustDelayTypeBacksOffToMaximum(t *testing.T) {
+	assert.Equal(t, robustDelay, robustDelayType(0, nil))
+	assert.Equal(t, 2*robustDelay, robustDelayType(1, nil))
+	assert.Equal(t, robustMaxDelay, robustDelayType(10, nil))
+	assert.Equal(t, robustMaxDelay, robustDelayType(100, nil))
+}
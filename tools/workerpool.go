@@ -0,0 +1,39 @@
+package tools
+
+import "sync"
+
+// WorkerPool runs functions on a limited number of goroutines at once, such as
+// to hash several objects in parallel without reading more files at a time
+// than there are CPUs to hash them.
+type WorkerPool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// NewWorkerPool returns a WorkerPool which runs up to the given number of
+// functions at once, or one if it is less than one.
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &WorkerPool{sem: make(chan struct{}, workers)}
+}
+
+// Go runs fn on a new goroutine, first waiting until fewer than the pool's
+// number of workers are running.
+func (p *WorkerPool) Go(fn func()) {
+	p.wg.Add(1)
+	p.sem <- struct{}{}
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Wait returns once every function given to Go has returned.
+func (p *WorkerPool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPoolRunsEveryFunction(t *testing.T) {
+	pool := NewWorkerPool(4)
+
+	var sum int64
+	for i := 1; i <= 100; i++ {
+		n := int64(i)
+		pool.Go(func() {
+			atomic.AddInt64(&sum, n)
+		})
+	}
+	pool.Wait()
+
+	assert.EqualValues(t, 5050, sum)
+}
+
+func TestWorkerPoolLimitsConcurrency(t *testing.T) {
+	pool := NewWorkerPool(3)
+
+	var running, max int32
+	for i := 0; i < 50; i++ {
+		pool.Go(func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	pool.Wait()
+
+	assert.True(t, max <= 3, "expected at most 3 workers, got %d", max)
+}
+
+func TestWorkerPoolWithoutWorkers(t *testing.T) {
+	pool := NewWorkerPool(0)
+
+	var ran int32
+	pool.Go(func() {
+		atomic.StoreInt32(&ran, 1)
+	})
+	pool.Wait()
+
+	assert.EqualValues(t, 1, ran)
+}
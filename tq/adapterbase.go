@@ -3,13 +3,15 @@ package tq
 import (
 	"fmt"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
 
+	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfsapi"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // adapterBase implements the common functionality for core adapters which
@@ -17,6 +19,7 @@ import (
 // authentication to succeed on one worker before proceeding
 type adapterBase struct {
 	fs           *fs.Filesystem
+	store        fs.ObjectStore
 	name         string
 	direction    Direction
 	transferImpl transferImplementation
@@ -143,7 +146,7 @@ func (a *adapterBase) Trace(format string, args ...interface{}) {
 	if !a.debugging {
 		return
 	}
-	tracerx.Printf(format, args...)
+	tracelog.Printf(format, args...)
 }
 
 // worker function, many of these run per adapter
@@ -178,10 +181,16 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 		var err error
 		if t.Size < 0 {
 			err = fmt.Errorf("object %q has invalid size (got: %d)", t.Oid, t.Size)
+		} else if a.direction == Upload {
+			err = a.upload(ctx, t, authCallback)
 		} else {
 			err = a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
 		}
 
+		if err == nil && a.direction == Download {
+			err = a.storeObject(workerNum, t)
+		}
+
 		// Mark the job as completed, and alter all listeners
 		job.Done(err)
 
@@ -196,6 +205,54 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 	a.workerWait.Done()
 }
 
+// upload sends the object t, decompressing it first if it is stored compressed,
+// so that objects are only decompressed when they are actually sent.
+func (a *adapterBase) upload(ctx interface{}, t *Transfer, authCallback func()) error {
+	path, cleanup, err := fs.DecompressedObjectFile(t.Path, tempDir(a.fs))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	orig := t.Path
+	t.Path = path
+	defer func() { t.Path = orig }()
+	return a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
+}
+
+func (a *adapterBase) setObjectStore(store fs.ObjectStore) {
+	a.store = store
+}
+
+// storeObject moves the object downloaded to t.Path into the object store.  If
+// that is the local object store on disk, the object is already in place, and
+// is only compressed if new objects are stored compressed.
+func (a *adapterBase) storeObject(workerNum int, t *Transfer) error {
+	f, ok := a.store.(*fs.Filesystem)
+	if a.store == nil || ok {
+		if f == nil {
+			f = a.fs
+		}
+		if f != nil && f.Compress {
+			if _, err := f.CompressObject(t.Oid); err != nil {
+				a.Trace("xfer: adapter %q worker %d unable to compress %q: %s", a.Name(), workerNum, t.Oid, err)
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(t.Path)
+	if err != nil {
+		return err
+	}
+	err = a.store.WriteObject(t.Oid, file)
+	file.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to store %s", t.Oid)
+	}
+	return os.Remove(t.Path)
+}
+
 var httpRE = regexp.MustCompile(`\Ahttps?://`)
 
 func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request, error) {
@@ -7,7 +7,9 @@ import (
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/lfshttp"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/metrics"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type tqClient struct {
@@ -24,6 +26,7 @@ type batchRequest struct {
 	Objects              []*Transfer `json:"objects"`
 	TransferAdapterNames []string    `json:"transfers,omitempty"`
 	Ref                  *batchRef   `json:"ref"`
+	HashAlgorithm        string      `json:"hash_algo,omitempty"`
 }
 
 type BatchResponse struct {
@@ -37,12 +40,42 @@ func Batch(m *Manifest, dir Direction, remote string, remoteRef *git.Ref, object
 		return &BatchResponse{}, nil
 	}
 
-	return m.batchClient().Batch(remote, &batchRequest{
-		Operation:            dir.String(),
-		Objects:              objects,
-		TransferAdapterNames: m.GetAdapterNames(dir),
-		Ref:                  &batchRef{Name: remoteRef.Refspec()},
-	})
+	// Objects named by different hash algorithms are requested in separate
+	// batches, since each batch names a single algorithm.
+	var algos []string
+	byAlgo := make(map[string][]*Transfer)
+	for _, o := range objects {
+		algo := tools.OidHashAlgorithm(o.Oid)
+		if _, ok := byAlgo[algo]; !ok {
+			algos = append(algos, algo)
+		}
+		byAlgo[algo] = append(byAlgo[algo], o)
+	}
+
+	var bRes *BatchResponse
+	for _, algo := range algos {
+		bReq := &batchRequest{
+			Operation:            dir.String(),
+			Objects:              byAlgo[algo],
+			TransferAdapterNames: m.GetAdapterNames(dir),
+			Ref:                  &batchRef{Name: remoteRef.Refspec()},
+		}
+		if algo != tools.HashAlgorithmSHA256 {
+			bReq.HashAlgorithm = algo
+		}
+
+		res, err := m.batchClient().Batch(remote, bReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if bRes == nil {
+			bRes = res
+		} else {
+			bRes.Objects = append(bRes.Objects, res.Objects...)
+		}
+	}
+	return bRes, nil
 }
 
 type BatchClient interface {
@@ -82,12 +115,19 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		return nil, errors.Wrap(err, "batch request")
 	}
 
-	tracerx.Printf("api: batch %d files", len(bReq.Objects))
+	tracelog.Printf("api: batch %d files", len(bReq.Objects))
 
 	req = c.Client.LogRequest(req, "lfs.batch")
 	res, err := c.DoAPIRequestWithAuth(remote, lfshttp.WithRetries(req, c.MaxRetries()))
+	tags := metrics.Tags{"operation": bReq.Operation, "status": "ok"}
+	if err != nil {
+		tags["status"] = "error"
+	}
+	metrics.Count("batch.requests", 1, tags)
+	metrics.Count("batch.objects", int64(len(bReq.Objects)), tags)
+	metrics.Since("batch.duration", requestedAt, tags)
 	if err != nil {
-		tracerx.Printf("api error: %s", err)
+		tracelog.Printf("api error: %s", err)
 		return nil, errors.Wrap(err, "batch response")
 	}
 
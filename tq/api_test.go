@@ -187,3 +187,51 @@ func assertSchema(t *testing.T, schema *sourcedSchema, dataLoader gojsonschema.J
 		t.Errorf("Schema: %s\n%s", schema.Source, strings.Join(valErrors, "\n"))
 	}
 }
+
+func TestBatchSplitsByHashAlgorithm(t *testing.T) {
+	sha256Oid := strings.Repeat("a", 64)
+	sha512Oid := strings.Repeat("b", 128)
+
+	var requests []*batchRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyLoader, body := gojsonschema.NewReaderLoader(r.Body)
+		bReq := &batchRequest{}
+		err := json.NewDecoder(body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assertSchema(t, batchReqSchema, bodyLoader)
+		requests = append(requests, bReq)
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{
+			TransferAdapterName: "basic",
+			Objects:             bReq.Objects,
+		})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "", "")
+	bRes, err := Batch(m, Download, "remote", nil, []*Transfer{
+		&Transfer{Oid: sha256Oid, Size: 1},
+		&Transfer{Oid: sha512Oid, Size: 2},
+	})
+	require.Nil(t, err)
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "", requests[0].HashAlgorithm)
+	require.Len(t, requests[0].Objects, 1)
+	assert.Equal(t, sha256Oid, requests[0].Objects[0].Oid)
+	assert.Equal(t, "sha512", requests[1].HashAlgorithm)
+	require.Len(t, requests[1].Objects, 1)
+	assert.Equal(t, sha512Oid, requests[1].Objects[0].Oid)
+
+	require.Len(t, bRes.Objects, 2)
+	assert.Equal(t, sha256Oid, bRes.Objects[0].Oid)
+	assert.Equal(t, sha512Oid, bRes.Objects[1].Oid)
+}
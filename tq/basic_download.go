@@ -12,7 +12,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // Adapter for basic HTTP downloads, includes resuming via HTTP Range
@@ -69,7 +69,7 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 	}
 
 	// Read any existing data into hash
-	hash := tools.NewLfsContentHash()
+	hash := tools.NewLfsContentHashFor(t.Oid)
 	fromByte, err := io.Copy(hash, f)
 	if err != nil {
 		return err
@@ -78,7 +78,7 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 	// Ensure that partial file seems valid
 	if fromByte > 0 {
 		if fromByte < t.Size-1 {
-			tracerx.Printf("xfer: Attempting to resume download of %q from byte %d", t.Oid, fromByte)
+			tracelog.Printf("xfer: Attempting to resume download of %q from byte %d", t.Oid, fromByte)
 		} else {
 			// Somehow we have more data than expected. Let's retry from the beginning.
 			if _, err := f.Seek(0, io.SeekStart); err != nil {
@@ -140,7 +140,7 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 
 		// Special-case status code 416 () - fall back
 		if fromByte > 0 && dlFile != nil && res.StatusCode == 416 {
-			tracerx.Printf("xfer: server rejected resume download request for %q from byte %d; re-downloading from start", t.Oid, fromByte)
+			tracelog.Printf("xfer: server rejected resume download request for %q from byte %d; re-downloading from start", t.Oid, fromByte)
 			if _, err := dlFile.Seek(0, io.SeekStart); err != nil {
 				return err
 			}
@@ -190,11 +190,11 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 			failReason = fmt.Sprintf("expected status code 206, received %d", res.StatusCode)
 		}
 		if rangeRequestOk {
-			tracerx.Printf("xfer: server accepted resume download request: %q from byte %d", t.Oid, fromByte)
+			tracelog.Printf("xfer: server accepted resume download request: %q from byte %d", t.Oid, fromByte)
 			advanceCallbackProgress(cb, t, fromByte)
 		} else {
 			// Abort resume, perform regular download
-			tracerx.Printf("xfer: failed to resume download for %q from byte %d: %s. Re-downloading from start", t.Oid, fromByte, failReason)
+			tracelog.Printf("xfer: failed to resume download for %q from byte %d: %s. Re-downloading from start", t.Oid, fromByte, failReason)
 
 			if _, err := dlFile.Seek(0, io.SeekStart); err != nil {
 				return err
@@ -228,7 +228,7 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		// pre-load hashing reader with previous content
 		hasher = tools.NewHashingReaderPreloadHash(httpReader, hash)
 	} else {
-		hasher = tools.NewHashingReader(httpReader)
+		hasher = tools.NewHashingReaderFor(httpReader, t.Oid)
 	}
 
 	dlfilename := dlFile.Name()
@@ -252,7 +252,7 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		return fmt.Errorf("can't close tempfile %q: %v", dlfilename, err)
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
+	err = a.fs.CommitFile(dlfilename, t.Path)
 	if _, err2 := os.Stat(t.Path); err2 == nil {
 		// Target file already exists, possibly was downloaded by other git-lfs process
 		return nil
@@ -68,7 +68,7 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	}
 	defer f.Close()
 
-	if err := a.setContentTypeFor(req, f); err != nil {
+	if err := a.setContentTypeFor(req, t, f); err != nil {
 		return err
 	}
 
@@ -154,7 +154,7 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	return verifyUpload(a.apiClient, a.remote, t)
 }
 
-func (a *adapterBase) setContentTypeFor(req *http.Request, r io.ReadSeeker) error {
+func (a *adapterBase) setContentTypeFor(req *http.Request, t *Transfer, r io.ReadSeeker) error {
 	uc := config.NewURLConfig(a.apiClient.GitEnv())
 	disabled := !uc.Bool("lfs", req.URL.String(), "contenttype", true)
 	if len(req.Header.Get("Content-Type")) != 0 {
@@ -163,14 +163,16 @@ func (a *adapterBase) setContentTypeFor(req *http.Request, r io.ReadSeeker) erro
 
 	var contentType string
 
-	if !disabled {
-		buffer := make([]byte, 512)
+	if !disabled && len(t.ContentType) > 0 {
+		contentType = t.ContentType
+	} else if !disabled {
+		buffer := make([]byte, tools.ContentTypeSniffLen)
 		n, err := r.Read(buffer)
 		if err != nil && err != io.EOF {
 			return errors.Wrap(err, "content type detect")
 		}
 
-		contentType = http.DetectContentType(buffer[:n])
+		contentType = tools.DetectContentType(t.Name, buffer[:n])
 		if _, err := r.Seek(0, io.SeekStart); err != nil {
 			return errors.Wrap(err, "content type rewind")
 		}
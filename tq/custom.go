@@ -16,7 +16,7 @@ import (
 	"github.com/git-lfs/git-lfs/tools"
 
 	"github.com/git-lfs/git-lfs/subprocess"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 // Adapter for custom transfer via external process
@@ -46,7 +46,7 @@ func (t *traceWriter) Flush() {
 		var s string
 		s, err = t.buf.ReadString('\n')
 		if len(s) > 0 {
-			tracerx.Printf("xfer[%v]: %v", t.processName, strings.TrimSpace(s))
+			tracelog.Printf("xfer[%v]: %v", t.processName, strings.TrimSpace(s))
 		}
 	}
 }
@@ -241,13 +241,13 @@ func (a *customAdapter) abortWorkerProcess(ctx *customAdapterWorkerContext) {
 func (a *customAdapter) WorkerEnding(workerNum int, ctx interface{}) {
 	customCtx, ok := ctx.(*customAdapterWorkerContext)
 	if !ok {
-		tracerx.Printf("Context object for custom transfer %q was of the wrong type", a.name)
+		tracelog.Printf("Context object for custom transfer %q was of the wrong type", a.name)
 		return
 	}
 
 	err := a.shutdownWorkerProcess(customCtx)
 	if err != nil {
-		tracerx.Printf("xfer: error finishing up custom transfer process %q worker %d, aborting: %v", a.path, customCtx.workerNum, err)
+		tracelog.Printf("xfer: error finishing up custom transfer process %q worker %d, aborting: %v", a.path, customCtx.workerNum, err)
 		a.abortWorkerProcess(customCtx)
 	}
 }
@@ -312,7 +312,7 @@ func (a *customAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCall
 					return fmt.Errorf("downloaded file failed checks: %v", err)
 				}
 				// Move file to final location
-				if err = tools.RenameFileCopyPermissions(resp.Path, t.Path); err != nil {
+				if err = a.fs.CommitFile(resp.Path, t.Path); err != nil {
 					return fmt.Errorf("failed to copy downloaded file: %v", err)
 				}
 			} else if a.direction == Upload {
@@ -345,6 +345,7 @@ func newCustomAdapter(f *fs.Filesystem, name string, dir Direction, path, args s
 
 const (
 	standaloneFileName = "lfs-standalone-file"
+	standaloneOCIName  = "lfs-standalone-oci"
 )
 
 func configureDefaultCustomAdapters(git Env, m *Manifest) {
@@ -354,6 +355,13 @@ func configureDefaultCustomAdapters(git Env, m *Manifest) {
 	}
 	m.RegisterNewAdapterFunc(standaloneFileName, Download, newfunc)
 	m.RegisterNewAdapterFunc(standaloneFileName, Upload, newfunc)
+
+	ocifunc := func(name string, dir Direction) Adapter {
+		standalone := m.standaloneTransferAgent != ""
+		return newCustomAdapter(m.fs, standaloneOCIName, dir, "git-lfs", "standalone-oci", false, standalone)
+	}
+	m.RegisterNewAdapterFunc(standaloneOCIName, Download, ocifunc)
+	m.RegisterNewAdapterFunc(standaloneOCIName, Upload, ocifunc)
 }
 
 // Initialise custom adapters based on current config
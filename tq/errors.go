@@ -1,6 +1,10 @@
 package tq
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
 
 type MalformedObjectError struct {
 	Name string
@@ -27,3 +31,19 @@ func (e MalformedObjectError) Error() string {
 	}
 	return fmt.Sprintf("missing object: %s (%s)", e.Name, e.Oid)
 }
+
+func (e MalformedObjectError) ErrorCode() errors.Code { return errors.CodeCorruption }
+
+// MissingSourceError is returned when an object which the server asked for
+// cannot be uploaded, because it is missing from the local repository.
+type MissingSourceError struct {
+	Oid string
+}
+
+func (e *MissingSourceError) Error() string {
+	return fmt.Sprintf("Unable to find source for object %v (try running git lfs fetch --all)", e.Oid)
+}
+
+func (e *MissingSourceError) ErrorCode() errors.Code {
+	return errors.CodeCorruption
+}
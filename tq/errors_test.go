@@ -21,3 +21,9 @@ func TestCorruptObjectErrorsAreRecognizable(t *testing.T) {
 	assert.Equal(t, "some-oid", err.Oid)
 	assert.True(t, err.Corrupt())
 }
+
+func TestMissingSourceErrorsAreRecognizable(t *testing.T) {
+	err := &MissingSourceError{Oid: "some-oid"}
+
+	assert.Equal(t, "Unable to find source for object some-oid (try running git lfs fetch --all)", err.Error())
+}
@@ -0,0 +1,141 @@
+package tq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+const (
+	preTransferEvent  = "pretransfer"
+	postTransferEvent = "posttransfer"
+)
+
+// transferHooks are the commands set by "lfs.hooks.pretransfer" and
+// "lfs.hooks.posttransfer", which are run before a set of objects is
+// transferred, and after it has been, so that caches can be warmed,
+// notifications sent, or large transfers approved without writing a custom
+// transfer adapter. Each is run by the shell, with a description of the
+// transfer as JSON on its standard input.
+type transferHooks struct {
+	pre  string
+	post string
+}
+
+// newTransferHooks returns the transfer hooks configured for the remote, or nil
+// if there are none.
+func newTransferHooks(git config.Environment, remote string) *transferHooks {
+	pre, _ := remoteSetting(git, remote, "hooks.pretransfer")
+	post, _ := remoteSetting(git, remote, "hooks.posttransfer")
+	if len(pre) == 0 && len(post) == 0 {
+		return nil
+	}
+	return &transferHooks{pre: pre, post: post}
+}
+
+// transferHookObject is an object in a transferHookEvent.
+type transferHookObject struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+	Name string `json:"name,omitempty"`
+	// Completed is set only for the "posttransfer" event.
+	Completed *bool `json:"completed,omitempty"`
+}
+
+// transferHookEvent is the JSON given to a transfer hook.
+type transferHookEvent struct {
+	Event     string                `json:"event"`
+	Operation string                `json:"operation"`
+	Remote    string                `json:"remote,omitempty"`
+	Objects   []*transferHookObject `json:"objects"`
+	// Errors are the messages of the errors of the transfer, and are set
+	// only for the "posttransfer" event.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// run runs the hook command with the JSON for ev on its standard input. Its
+// output, on both standard output and standard error, is written to out, or
+// discarded if out is nil.
+func (h *transferHooks) run(command string, ev *transferHookEvent, out io.Writer) error {
+	in, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	tracelog.Printf("tq: running %s hook for %d object(s): %s", ev.Event, len(ev.Objects), command)
+
+	cmdName, cmdArgs := subprocess.FormatForShell(command, "")
+	cmd := subprocess.ExecCommand(cmdName, cmdArgs...)
+	cmd.Stdin = bytes.NewReader(append(in, '\n'))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// PreTransfer runs the "pretransfer" hook, if there is one, on the objects to
+// be transferred. If the hook fails, an error with the code CodePolicy is
+// returned, and none of the objects should be transferred.
+func (h *transferHooks) PreTransfer(direction Direction, remote string, objs []*objectTuple, out io.Writer) error {
+	if len(h.pre) == 0 || len(objs) == 0 {
+		return nil
+	}
+
+	ev := &transferHookEvent{
+		Event:     preTransferEvent,
+		Operation: direction.String(),
+		Remote:    remote,
+		Objects:   make([]*transferHookObject, 0, len(objs)),
+	}
+	for _, o := range objs {
+		ev.Objects = append(ev.Objects, &transferHookObject{
+			Oid:  o.Oid,
+			Size: o.Size,
+			Name: o.Name,
+		})
+	}
+
+	if err := h.run(h.pre, ev, out); err != nil {
+		return errors.NewCodedError(fmt.Errorf("pretransfer hook refused the %s of %d object(s): %v",
+			direction, len(objs), err), errors.CodePolicy)
+	}
+	return nil
+}
+
+// PostTransfer runs the "posttransfer" hook, if there is one, on the objects
+// which were to be transferred, noting which of them completed, and the errors
+// of the transfer. Since the transfer is already over, the error of a failed
+// hook should only be reported as a warning.
+func (h *transferHooks) PostTransfer(direction Direction, remote string, objs []*objects, errs []error, out io.Writer) error {
+	if len(h.post) == 0 || len(objs) == 0 {
+		return nil
+	}
+
+	ev := &transferHookEvent{
+		Event:     postTransferEvent,
+		Operation: direction.String(),
+		Remote:    remote,
+		Objects:   make([]*transferHookObject, 0, len(objs)),
+	}
+	for _, o := range objs {
+		first := o.First()
+		completed := o.completed
+		ev.Objects = append(ev.Objects, &transferHookObject{
+			Oid:       first.Oid,
+			Size:      first.Size,
+			Name:      first.Name,
+			Completed: &completed,
+		})
+	}
+	for _, err := range errs {
+		ev.Errors = append(ev.Errors, strings.TrimSpace(err.Error()))
+	}
+
+	return h.run(h.post, ev, out)
+}
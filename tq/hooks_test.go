@@ -0,0 +1,103 @@
+package tq
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferHooksAreConfiguredPerRemote(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.hooks.posttransfer":         "notify",
+		"lfs.external.hooks.pretransfer": "approve",
+	}))
+	require.Nil(t, err)
+
+	h := NewManifest(nil, cli, "upload", "origin").transferHooks
+	require.NotNil(t, h)
+	assert.Equal(t, "", h.pre)
+	assert.Equal(t, "notify", h.post)
+
+	h = NewManifest(nil, cli, "upload", "external").transferHooks
+	require.NotNil(t, h)
+	assert.Equal(t, "approve", h.pre)
+	assert.Equal(t, "notify", h.post)
+
+	cli, err = lfsapi.NewClient(lfshttp.NewContext(nil, nil, nil))
+	require.Nil(t, err)
+	assert.Nil(t, NewManifest(nil, cli, "upload", "origin").transferHooks)
+}
+
+func TestTransferHooksPreTransfer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transfer-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "event.json")
+	objs := []*objectTuple{
+		{Oid: "abc", Size: 3, Name: "a.dat"},
+		{Oid: "def", Size: 5, Name: "b.dat"},
+	}
+
+	h := &transferHooks{pre: "cat > '" + out + "'"}
+	require.Nil(t, h.PreTransfer(Upload, "origin", objs, nil))
+
+	data, err := ioutil.ReadFile(out)
+	require.Nil(t, err)
+	var ev transferHookEvent
+	require.Nil(t, json.Unmarshal(data, &ev))
+	assert.Equal(t, "pretransfer", ev.Event)
+	assert.Equal(t, "upload", ev.Operation)
+	assert.Equal(t, "origin", ev.Remote)
+	require.Len(t, ev.Objects, 2)
+	assert.Equal(t, "abc", ev.Objects[0].Oid)
+	assert.Equal(t, int64(3), ev.Objects[0].Size)
+	assert.Equal(t, "a.dat", ev.Objects[0].Name)
+	assert.Nil(t, ev.Objects[0].Completed)
+	assert.Equal(t, "def", ev.Objects[1].Oid)
+
+	h.pre = "exit 1"
+	err = h.PreTransfer(Upload, "origin", objs, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, errors.CodePolicy, errors.ErrorCode(err))
+	assert.Contains(t, err.Error(), "pretransfer hook refused the upload of 2 object(s)")
+
+	// Nothing is run when there is nothing to transfer.
+	assert.Nil(t, h.PreTransfer(Upload, "origin", nil, nil))
+}
+
+func TestTransferHooksPostTransfer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transfer-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "event.json")
+	objs := []*objects{
+		{completed: true, objects: []*objectTuple{{Oid: "abc", Size: 3, Name: "a.dat"}}},
+		{objects: []*objectTuple{{Oid: "def", Size: 5, Name: "b.dat"}}},
+	}
+
+	h := &transferHooks{post: "cat > '" + out + "'"}
+	require.Nil(t, h.PostTransfer(Download, "origin", objs, []error{errors.New("b.dat failed")}, nil))
+
+	data, err := ioutil.ReadFile(out)
+	require.Nil(t, err)
+	var ev transferHookEvent
+	require.Nil(t, json.Unmarshal(data, &ev))
+	assert.Equal(t, "posttransfer", ev.Event)
+	assert.Equal(t, "download", ev.Operation)
+	require.Len(t, ev.Objects, 2)
+	require.NotNil(t, ev.Objects[0].Completed)
+	assert.True(t, *ev.Objects[0].Completed)
+	require.NotNil(t, ev.Objects[1].Completed)
+	assert.False(t, *ev.Objects[1].Completed)
+	assert.Equal(t, []string{"b.dat failed"}, ev.Errors)
+}
@@ -1,6 +1,9 @@
 package tq
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
 
@@ -8,7 +11,7 @@ import (
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/ssh"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 const (
@@ -30,9 +33,15 @@ type Manifest struct {
 	downloadAdapterFuncs    map[string]NewAdapterFunc
 	uploadAdapterFuncs      map[string]NewAdapterFunc
 	fs                      *fs.Filesystem
+	store                   fs.ObjectStore
 	apiClient               *lfsapi.Client
 	sshTransfer             *ssh.SSHTransfer
 	batchClientAdapter      BatchClient
+	statsFunc               StatsFunc
+	uploadScanner           *uploadScanner
+	transferHooks           *transferHooks
+	warningOut              io.Writer
+	progressStream          *ProgressStream
 	mu                      sync.Mutex
 }
 
@@ -67,7 +76,7 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 	if apiClient == nil {
 		cli, err := lfsapi.NewClient(nil)
 		if err != nil {
-			tracerx.Printf("unable to init tq.Manifest: %s", err)
+			tracelog.Printf("unable to init tq.Manifest: %s", err)
 			return nil
 		}
 		apiClient = cli
@@ -86,20 +95,22 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 
 	var tusAllowed bool
 	if git := apiClient.GitEnv(); git != nil {
-		if v := git.Int("lfs.transfer.maxretries", 0); v > 0 {
+		if v := remoteSettingInt(git, remote, "transfer.maxretries", 0); v > 0 {
 			m.maxRetries = v
 		}
-		if v := git.Int("lfs.transfer.maxretrydelay", -1); v > -1 {
+		if v := remoteSettingInt(git, remote, "transfer.maxretrydelay", -1); v > -1 {
 			m.maxRetryDelay = v
 		}
-		if v := git.Int("lfs.concurrenttransfers", 0); v > 0 {
+		if v := remoteSettingInt(git, remote, "concurrenttransfers", 0); v > 0 {
 			m.concurrentTransfers = v
 		}
-		m.basicTransfersOnly = git.Bool("lfs.basictransfersonly", false)
+		m.basicTransfersOnly = remoteSettingBool(git, remote, "basictransfersonly", false)
 		m.standaloneTransferAgent = findStandaloneTransfer(
 			apiClient, operation, remote,
 		)
-		tusAllowed = git.Bool("lfs.tustransfers", false)
+		tusAllowed = remoteSettingBool(git, remote, "tustransfers", false)
+		m.uploadScanner = newUploadScanner(git, remote)
+		m.transferHooks = newTransferHooks(git, remote)
 		configureCustomAdapters(git, m)
 	}
 
@@ -131,10 +142,36 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 	return m
 }
 
+// remoteSetting returns the value of the transfer setting "lfs.<key>" for the
+// given remote, which may be overridden for that remote alone by
+// "lfs.<remote>.<key>", so that, for instance, transfers to a fast mirror and a
+// slow external server from the same repository can be tuned separately.
+func remoteSetting(git config.Environment, remote, key string) (string, bool) {
+	if len(remote) > 0 {
+		if v, ok := git.Get("lfs." + remote + "." + key); ok {
+			return v, true
+		}
+	}
+	return git.Get("lfs." + key)
+}
+
+func remoteSettingInt(git config.Environment, remote, key string, def int) int {
+	v, _ := remoteSetting(git, remote, key)
+	return config.Int(v, def)
+}
+
+func remoteSettingBool(git config.Environment, remote, key string, def bool) bool {
+	v, _ := remoteSetting(git, remote, key)
+	return config.Bool(v, def)
+}
+
 func findDefaultStandaloneTransfer(url string) string {
 	if strings.HasPrefix(url, "file://") {
 		return standaloneFileName
 	}
+	if strings.HasPrefix(url, "oci://") || strings.HasPrefix(url, "oci+http://") {
+		return standaloneOCIName
+	}
 	return ""
 }
 
@@ -144,6 +181,10 @@ func findStandaloneTransfer(client *lfsapi.Client, operation, remote string) str
 		return v
 	}
 
+	if v, ok := client.GitEnv().Get("lfs." + remote + ".standalonetransferagent"); ok {
+		return v
+	}
+
 	ep := client.Endpoints.Endpoint(operation, remote)
 	aep := client.Endpoints.Endpoint(operation, remote)
 	uc := config.NewURLConfig(client.GitEnv())
@@ -215,7 +256,7 @@ func (m *Manifest) NewAdapterOrDefault(name string, dir Direction) Adapter {
 
 	a := m.NewAdapter(name, dir)
 	if a == nil {
-		tracerx.Printf("Defaulting to basic transfer adapter since %q did not exist", name)
+		tracelog.Printf("Defaulting to basic transfer adapter since %q did not exist", name)
 		a = m.NewAdapter(BasicAdapterName, dir)
 	}
 	return a
@@ -233,12 +274,72 @@ func (m *Manifest) NewAdapter(name string, dir Direction) Adapter {
 		}
 	case Download:
 		if d, ok := m.downloadAdapterFuncs[name]; ok {
-			return d(name, dir)
+			a := d(name, dir)
+			if s, ok := a.(objectStoreSetter); ok && m.store != nil {
+				s.setObjectStore(m.store)
+			}
+			return a
 		}
 	}
 	return nil
 }
 
+// tempDir returns the directory of "f" in which temporary files are created,
+// or the system's if "f" is nil.
+func tempDir(f *fs.Filesystem) string {
+	if f == nil {
+		return os.TempDir()
+	}
+	return f.TempDir()
+}
+
+// objectStoreSetter is implemented by download adapters which move the objects
+// they download into an object store.
+type objectStoreSetter interface {
+	setObjectStore(store fs.ObjectStore)
+}
+
+// SetObjectStore sets the store into which the download adapters created with
+// this manifest move the objects they download, once verified.  By default,
+// objects are left in the local object store on disk where they are staged.
+func (m *Manifest) SetObjectStore(store fs.ObjectStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store = store
+}
+
+// SetWarningOut sets the writer to which the warnings of the transfer queues
+// created with this manifest, and the output of their transfer hooks, are
+// written.  If it is not set, warnings are only traced, and the output of the
+// hooks is discarded.
+func (m *Manifest) SetWarningOut(w io.Writer) {
+	m.warningOut = w
+}
+
+// warnf writes a warning to the manifest's warning writer, or traces it if
+// there is none.
+func (m *Manifest) warnf(format string, args ...interface{}) {
+	if m.warningOut == nil {
+		tracelog.Printf("tq: "+format, args...)
+		return
+	}
+	fmt.Fprintf(m.warningOut, format+"\n", args...)
+}
+
+// SetProgressStream sets the stream to which the Meters of the transfer queues
+// created with this manifest write their progress, unless they have a Stream
+// of their own.
+func (m *Manifest) SetProgressStream(s *ProgressStream) {
+	m.progressStream = s
+}
+
+// ProgressStream returns the stream set with SetProgressStream, or nil if there
+// is none.
+func (m *Manifest) ProgressStream() *ProgressStream {
+	return m.progressStream
+}
+
 // Create a new download adapter by name, or BasicAdapterName if doesn't exist
 func (m *Manifest) NewDownloadAdapter(name string) Adapter {
 	return m.NewAdapterOrDefault(name, Download)
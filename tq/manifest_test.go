@@ -38,3 +38,52 @@ func TestManifestIgnoresNonInts(t *testing.T) {
 	m := NewManifest(nil, cli, "", "")
 	assert.Equal(t, 8, m.MaxRetries())
 }
+
+func TestManifestPerRemoteSettings(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.concurrenttransfers":          "4",
+		"lfs.transfer.maxretries":          "3",
+		"lfs.mirror.concurrenttransfers":   "32",
+		"lfs.mirror.transfer.maxretries":   "1",
+		"lfs.mirror.basictransfersonly":    "true",
+		"lfs.external.transfer.maxretries": "12",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "mirror")
+	assert.Equal(t, 32, m.ConcurrentTransfers())
+	assert.Equal(t, 1, m.MaxRetries())
+	assert.Equal(t, []string{BasicAdapterName}, m.GetDownloadAdapterNames())
+
+	m = NewManifest(nil, cli, "download", "external")
+	assert.Equal(t, 4, m.ConcurrentTransfers())
+	assert.Equal(t, 12, m.MaxRetries())
+
+	m = NewManifest(nil, cli, "download", "origin")
+	assert.Equal(t, 4, m.ConcurrentTransfers())
+	assert.Equal(t, 3, m.MaxRetries())
+}
+
+func TestManifestPerRemoteStandaloneTransferAgent(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.mirror.url":                  "https://mirror.example.com/repo.git",
+		"remote.origin.url":                  "https://example.com/repo.git",
+		"lfs.mirror.standalonetransferagent": "mirror-agent",
+		"lfs.standalonetransferagent":        "default-agent",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, "mirror-agent", findStandaloneTransfer(cli, "download", "mirror"))
+	assert.Equal(t, "default-agent", findStandaloneTransfer(cli, "download", "origin"))
+}
+
+func TestManifestOCIStandaloneTransferAgent(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url":    "https://example.com/repo.git",
+		"remote.origin.lfsurl": "oci://registry.example.com/org/repo",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, "lfs-standalone-oci", findStandaloneTransfer(cli, "upload", "origin"))
+	assert.NotNil(t, NewManifest(nil, cli, "upload", "origin").NewAdapter("lfs-standalone-oci", Upload))
+}
@@ -5,95 +5,160 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/olekukonko/ts"
 )
 
 // Meter provides a progress bar type output for the TransferQueue. It
 // is given an estimated file count and size up front and tracks the number of
 // files and bytes transferred as well as the number of files and bytes that
 // get skipped because the transfer is unnecessary.
+//
+// A single Meter may be shared by the phases of a command, such as downloading
+// objects and checking out files, in which case it shows their combined
+// progress.
 type Meter struct {
 	finishedFiles     int64 // int64s must come first for struct alignment
 	transferringFiles int64
 	estimatedBytes    int64
-	lastBytes         int64
 	currentBytes      int64
-	sampleCount       uint64
-	avgBytes          float64
-	lastAvg           time.Time
 	estimatedFiles    int32
 	paused            uint32
-	fileIndex         map[string]int64 // Maps a file name to its transfer number
+	finished          uint32
+	fileIndex         map[string]int64     // Maps a file name to its transfer number
+	fileSize          map[string]int64     // Maps a file name to its size, when logged
+	fileDirection     map[string]Direction // Maps a file name to its phase, when logged
 	fileIndexMutex    *sync.Mutex
+	loggedFiles       int64 // The totals last written to Logger
+	loggedBytes       int64
+	phases            []Direction // Phases besides Direction, guarded by fileIndexMutex
 	updates           chan *tasklog.Update
 	cfg               *config.Configuration
+	streamMu          sync.Mutex
+	lastStream        time.Time
+
+	// rateMu guards the throughput, which is sampled as bytes are
+	// transferred by several workers at once.
+	rateMu      sync.Mutex
+	lastBytes   int64
+	sampleCount uint64
+	avgBytes    float64
+	rateBytes   float64
+	lastAvg     time.Time
+
+	// widthFn returns the width of the terminal, or zero if it is not
+	// known.
+	widthFn func() int
 
 	DryRun    bool
-	Logger    *tools.SyncWriter
+	Logger    *ProgressLog
+	Stream    *ProgressStream
 	Direction Direction
 }
 
+// progressStreamInterval is the shortest interval between "progress" events
+// written to the Meter's Stream, except for those which complete an object.
+const progressStreamInterval = 100 * time.Millisecond
+
 type env interface {
 	Get(key string) (val string, ok bool)
 }
 
-func (m *Meter) LoggerFromEnv(os env) *tools.SyncWriter {
+// LoggerFromEnv opens the ProgressLog named by GIT_LFS_PROGRESS, or returns nil
+// if it is not set.
+func (m *Meter) LoggerFromEnv(os env) (*ProgressLog, error) {
 	name, _ := os.Get("GIT_LFS_PROGRESS")
 	if len(name) < 1 {
-		return nil
+		return nil, nil
 	}
-	return m.LoggerToFile(name)
+	return m.LoggerToFile(name, ProgressLogVersion(os))
 }
 
-func (m *Meter) LoggerToFile(name string) *tools.SyncWriter {
-	printErr := func(err string) {
-		fmt.Fprintf(os.Stderr, "Error creating progress logger: %s\n", err)
-	}
-
+func (m *Meter) LoggerToFile(name string, version int) (*ProgressLog, error) {
 	if !filepath.IsAbs(name) {
-		printErr("GIT_LFS_PROGRESS must be an absolute path")
-		return nil
+		return nil, errors.New("GIT_LFS_PROGRESS must be an absolute path")
 	}
 
 	if err := tools.MkdirAll(filepath.Dir(name), m.cfg); err != nil {
-		printErr(err.Error())
-		return nil
+		return nil, err
 	}
 
 	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		printErr(err.Error())
-		return nil
+		return nil, err
 	}
 
-	return tools.NewSyncWriter(file)
+	logger, err := NewProgressLog(file, version)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return logger, nil
 }
 
 // NewMeter creates a new Meter.
 func NewMeter(cfg *config.Configuration) *Meter {
 	m := &Meter{
 		fileIndex:      make(map[string]int64),
+		fileSize:       make(map[string]int64),
+		fileDirection:  make(map[string]Direction),
 		fileIndexMutex: &sync.Mutex{},
 		updates:        make(chan *tasklog.Update),
 		cfg:            cfg,
+		widthFn:        terminalWidth,
 	}
 
 	return m
 }
 
+// terminalWidth returns the width of the terminal, or zero if there is none.
+func terminalWidth() int {
+	size, err := ts.GetSize()
+	if err != nil {
+		return 0
+	}
+	return size.Col()
+}
+
+// AddPhase adds a phase of the command, besides the meter's Direction, whose
+// progress the meter also shows, so that one meter covers all of the work.
+// Files and bytes added for either phase count towards the same totals.
+func (m *Meter) AddPhase(d Direction) {
+	if m == nil {
+		return
+	}
+
+	m.fileIndexMutex.Lock()
+	defer m.fileIndexMutex.Unlock()
+
+	if d == m.Direction {
+		return
+	}
+	for _, phase := range m.phases {
+		if phase == d {
+			return
+		}
+	}
+	m.phases = append(m.phases, d)
+}
+
 // Start begins sending status updates to the optional log file, and stdout.
 func (m *Meter) Start() {
 	if m == nil {
 		return
 	}
 	atomic.StoreUint32(&m.paused, 0)
+	m.logTotals()
 }
 
 // Pause stops sending status updates temporarily, until Start() is called again.
@@ -127,6 +192,7 @@ func (m *Meter) Skip(size int64) {
 	defer m.update(false)
 	atomic.AddInt64(&m.finishedFiles, 1)
 	atomic.AddInt64(&m.currentBytes, size)
+	m.logSkip(size)
 }
 
 // StartTransfer tells the progress meter that a transferring file is being
@@ -151,23 +217,27 @@ func (m *Meter) TransferBytes(direction, name string, read, total int64, current
 
 	defer m.update(false)
 
-	now := time.Now()
-	since := now.Sub(m.lastAvg)
 	atomic.AddInt64(&m.currentBytes, int64(current))
-	atomic.AddInt64(&m.lastBytes, int64(current))
 
+	m.rateMu.Lock()
+	now := time.Now()
+	since := now.Sub(m.lastAvg)
+	m.lastBytes += int64(current)
 	if since > time.Second {
 		m.lastAvg = now
 
 		bps := float64(m.lastBytes) / since.Seconds()
 
+		m.rateBytes = bps
 		m.avgBytes = (m.avgBytes*float64(m.sampleCount) + bps) / (float64(m.sampleCount) + 1.0)
 
-		atomic.StoreInt64(&m.lastBytes, 0)
-		atomic.AddUint64(&m.sampleCount, 1)
+		m.lastBytes = 0
+		m.sampleCount++
 	}
+	m.rateMu.Unlock()
 
 	m.logBytes(direction, name, read, total)
+	m.streamBytes(name, read, total)
 }
 
 // FinishTransfer increments the finished transfer count
@@ -178,9 +248,15 @@ func (m *Meter) FinishTransfer(name string) {
 
 	defer m.update(false)
 	atomic.AddInt64(&m.finishedFiles, 1)
+	m.logComplete(name)
 	m.fileIndexMutex.Lock()
+	phase := m.fileDirectionLocked(name)
 	delete(m.fileIndex, name)
+	delete(m.fileSize, name)
+	delete(m.fileDirection, name)
 	m.fileIndexMutex.Unlock()
+
+	m.stream(&ProgressEvent{Event: "done", Name: name, Phase: phase.String()})
 }
 
 // Flush sends the latest progress update, while leaving the meter active.
@@ -198,8 +274,11 @@ func (m *Meter) Finish() {
 		return
 	}
 
+	atomic.StoreUint32(&m.finished, 1)
 	m.update(false)
 	close(m.updates)
+
+	m.stream(&ProgressEvent{Event: "finish"})
 }
 
 func (m *Meter) Updates() <-chan *tasklog.Update {
@@ -232,15 +311,142 @@ func (m *Meter) skipUpdate() bool {
 }
 
 func (m *Meter) str() string {
-	// (Uploading|Downloading) LFS objects: 100% (10/10) 100 MiB | 10 MiB/s
-	percentage := 100 * float64(m.finishedFiles) / float64(m.estimatedFiles)
+	// (Uploading|Downloading|Checking out) LFS objects:  50% (5/10), 50 MiB/100 MiB | 12 MiB/s, avg 10 MiB/s, ETA 5s | a.dat (+2)
+	finishedFiles := atomic.LoadInt64(&m.finishedFiles)
+	estimatedFiles := atomic.LoadInt32(&m.estimatedFiles)
+	percentage := 100 * float64(finishedFiles) / float64(estimatedFiles)
 
-	return fmt.Sprintf("%s LFS objects: %3.f%% (%d/%d), %s | %s",
-		m.Direction.Verb(),
+	str := tr.Tr.Get(m.progressFormat(),
 		percentage,
-		m.finishedFiles, m.estimatedFiles,
-		humanize.FormatBytes(clamp(m.currentBytes)),
-		humanize.FormatByteRate(clampf(m.avgBytes), time.Second))
+		finishedFiles, estimatedFiles,
+		humanize.FormatBytes(clamp(atomic.LoadInt64(&m.currentBytes))),
+		humanize.FormatBytes(clamp(atomic.LoadInt64(&m.estimatedBytes))))
+
+	rate, avg := m.rates()
+	if atomic.LoadUint32(&m.finished) == 1 {
+		return fmt.Sprintf("%s | %s", str,
+			humanize.FormatByteRate(clampf(avg), time.Second))
+	}
+
+	str = fmt.Sprintf("%s | %s", str, tr.Tr.Get("%s, avg %s",
+		humanize.FormatByteRate(clampf(rate), time.Second),
+		humanize.FormatByteRate(clampf(avg), time.Second)))
+	if eta, ok := m.eta(); ok {
+		str = tr.Tr.Get("%s, ETA %s", str, eta)
+	}
+	if name, others := m.inFlight(); len(name) > 0 {
+		suffix := ""
+		if others > 0 {
+			suffix = fmt.Sprintf(" (+%d)", others)
+		}
+		// The name is shortened to fit the rest of the line, if
+		// need be, or left out if there is no room for it.
+		if width := m.widthFn(); width > 0 {
+			name = truncateName(name, width-1-len(str)-len(" | ")-len(suffix))
+		}
+		if len(name) > 0 {
+			str = fmt.Sprintf("%s | %s%s", str, name, suffix)
+		}
+	}
+	return str
+}
+
+// rates returns the current and average throughput, in bytes per second.
+func (m *Meter) rates() (float64, float64) {
+	m.rateMu.Lock()
+	defer m.rateMu.Unlock()
+
+	return m.rateBytes, m.avgBytes
+}
+
+// minTruncatedName is the length of the shortest file name shown when names
+// are shortened to fit the terminal.
+const minTruncatedName = 8
+
+// truncateName shortens "name" to at most "width" characters, keeping its end,
+// which holds the file's base name, or returns the empty string if "width" is
+// too small for a useful part of it.
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	if width < minTruncatedName {
+		return ""
+	}
+	return "..." + string(runes[len(runes)-width+3:])
+}
+
+// progressFormat returns the format of the progress of the meter's direction,
+// which is given the percentage of files done, the number of files done and
+// their total, and the number of bytes done and their total.  Each direction
+// has a message of its own, so that it can be translated as a whole.
+func (m *Meter) progressFormat() string {
+	m.fileIndexMutex.Lock()
+	phases := append([]Direction{m.Direction}, m.phases...)
+	m.fileIndexMutex.Unlock()
+
+	if len(phases) > 1 {
+		if len(phases) == 2 && phases[0] == Download && phases[1] == Checkout {
+			return "Downloading and checking out LFS objects: %3.f%% (%d/%d), %s/%s"
+		}
+		verbs := make([]string, 0, len(phases))
+		for i, phase := range phases {
+			if i == 0 {
+				verbs = append(verbs, phase.Verb())
+			} else {
+				verbs = append(verbs, strings.ToLower(phase.Verb()))
+			}
+		}
+		return strings.Join(verbs, " and ") + " LFS objects: %3.f%% (%d/%d), %s/%s"
+	}
+
+	switch m.Direction {
+	case Checkout:
+		return "Checking out LFS objects: %3.f%% (%d/%d), %s/%s"
+	case Download:
+		return "Downloading LFS objects: %3.f%% (%d/%d), %s/%s"
+	case Upload:
+		return "Uploading LFS objects: %3.f%% (%d/%d), %s/%s"
+	default:
+		return m.Direction.Verb() + " LFS objects: %3.f%% (%d/%d), %s/%s"
+	}
+}
+
+// eta returns the estimated time remaining until all of the bytes added to
+// the meter have been transferred, at the average rate so far.  It returns
+// false if no estimate can be made yet.
+func (m *Meter) eta() (time.Duration, bool) {
+	remaining := atomic.LoadInt64(&m.estimatedBytes) - atomic.LoadInt64(&m.currentBytes)
+	_, avg := m.rates()
+	if avg <= 0 || remaining <= 0 {
+		return 0, false
+	}
+
+	secs := float64(remaining) / avg
+	if secs > float64(math.MaxInt64/int64(time.Second)) {
+		return 0, false
+	}
+	return time.Duration(math.Ceil(secs)) * time.Second, true
+}
+
+// inFlight returns the name of the file which has been transferring the
+// longest, and the number of other files being transferred alongside it.
+func (m *Meter) inFlight() (string, int) {
+	m.fileIndexMutex.Lock()
+	defer m.fileIndexMutex.Unlock()
+
+	var name string
+	var first int64
+	for n, idx := range m.fileIndex {
+		if len(name) == 0 || idx < first {
+			name, first = n, idx
+		}
+	}
+	if len(name) == 0 {
+		return "", 0
+	}
+	return name, len(m.fileIndex) - 1
 }
 
 // clamp clamps the given "x" within the acceptable domain of the uint64 integer
@@ -268,16 +474,124 @@ func clampf(x float64) uint64 {
 func (m *Meter) logBytes(direction, name string, read, total int64) {
 	m.fileIndexMutex.Lock()
 	idx := m.fileIndex[name]
+	m.fileSize[name] = total
+	if phase, ok := parseDirection(direction); ok {
+		m.fileDirection[name] = phase
+	}
+	m.fileIndexMutex.Unlock()
+
+	m.logTotals()
+	m.log(func(l *ProgressLog) error {
+		return l.Progress(direction, idx, int64(atomic.LoadInt32(&m.estimatedFiles)), read, total, name)
+	})
+}
+
+// logTotals writes the estimated totals to the Logger, if they have changed
+// since they were last written.
+func (m *Meter) logTotals() {
+	files := int64(atomic.LoadInt32(&m.estimatedFiles))
+	bytes := atomic.LoadInt64(&m.estimatedBytes)
+	if files == 0 {
+		return
+	}
+
+	m.fileIndexMutex.Lock()
+	if files == m.loggedFiles && bytes == m.loggedBytes {
+		m.fileIndexMutex.Unlock()
+		return
+	}
+	m.loggedFiles, m.loggedBytes = files, bytes
+	m.fileIndexMutex.Unlock()
+
+	m.log(func(l *ProgressLog) error {
+		return l.Totals(m.Direction.String(), files, bytes)
+	})
+}
+
+func (m *Meter) logComplete(name string) {
+	m.fileIndexMutex.Lock()
+	idx, size := m.fileIndex[name], m.fileSize[name]
+	phase := m.fileDirectionLocked(name)
+	m.fileIndexMutex.Unlock()
+
+	m.logTotals()
+	m.log(func(l *ProgressLog) error {
+		return l.Complete(phase.String(), idx, int64(atomic.LoadInt32(&m.estimatedFiles)), size, name)
+	})
+}
+
+// fileDirectionLocked returns the phase in which the file "name" was
+// transferred, or the meter's Direction if it is not known.  The caller must
+// hold fileIndexMutex.
+func (m *Meter) fileDirectionLocked(name string) Direction {
+	if phase, ok := m.fileDirection[name]; ok {
+		return phase
+	}
+	return m.Direction
+}
+
+func (m *Meter) logSkip(size int64) {
+	m.logTotals()
+	m.log(func(l *ProgressLog) error {
+		return l.Skip(m.Direction.String(), size)
+	})
+}
+
+// log calls the given function with the Logger, if there is one, and stops
+// logging if it fails.
+func (m *Meter) log(fn func(l *ProgressLog) error) {
+	m.fileIndexMutex.Lock()
 	logger := m.Logger
 	m.fileIndexMutex.Unlock()
 	if logger == nil {
 		return
 	}
 
-	line := fmt.Sprintf("%s %d/%d %d/%d %s\n", direction, idx, m.estimatedFiles, read, total, name)
-	if err := m.Logger.Write([]byte(line)); err != nil {
+	if err := fn(logger); err != nil {
 		m.fileIndexMutex.Lock()
 		m.Logger = nil
 		m.fileIndexMutex.Unlock()
 	}
 }
+
+// streamBytes writes a "progress" event for the given object to the Meter's
+// Stream, unless one was written too recently and the object is not complete.
+func (m *Meter) streamBytes(name string, read, total int64) {
+	if m.Stream == nil {
+		return
+	}
+
+	m.streamMu.Lock()
+	now := time.Now()
+	if read < total && now.Sub(m.lastStream) < progressStreamInterval {
+		m.streamMu.Unlock()
+		return
+	}
+	m.lastStream = now
+	m.streamMu.Unlock()
+
+	m.fileIndexMutex.Lock()
+	phase := m.fileDirectionLocked(name)
+	m.fileIndexMutex.Unlock()
+
+	m.stream(&ProgressEvent{Event: "progress", Name: name, Bytes: read, Size: total, Phase: phase.String()})
+}
+
+// stream fills in the totals of the given event, and its phase if it has none,
+// and writes it to the Meter's Stream.
+func (m *Meter) stream(ev *ProgressEvent) {
+	if m.Stream == nil || m.DryRun {
+		return
+	}
+
+	if len(ev.Phase) == 0 {
+		ev.Phase = m.Direction.String()
+	}
+	ev.FilesDone = atomic.LoadInt64(&m.finishedFiles)
+	ev.FilesTotal = int64(atomic.LoadInt32(&m.estimatedFiles))
+	ev.BytesDone = atomic.LoadInt64(&m.currentBytes)
+	ev.BytesTotal = atomic.LoadInt64(&m.estimatedBytes)
+	_, avg := m.rates()
+	ev.Rate = clampf(avg)
+	m.Stream.Write(ev)
+}
@@ -0,0 +1,130 @@
+package tq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/tr"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMeter(d Direction) *Meter {
+	m := NewMeter(config.NewFrom(config.Values{}))
+	m.Direction = d
+	m.widthFn = func() int { return 0 }
+	go func() {
+		for range m.Updates() {
+		}
+	}()
+	return m
+}
+
+func TestMeterStrInFlight(t *testing.T) {
+	m := newTestMeter(Download)
+	m.Add(10)
+	m.Add(20)
+	m.Add(30)
+	m.StartTransfer("a.dat")
+	m.StartTransfer("b.dat")
+	m.StartTransfer("c.dat")
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+
+	assert.Equal(t, "Downloading LFS objects:  33% (1/3), 10 B/60 B | 0 B/s, avg 0 B/s | b.dat (+1)", m.str())
+
+	m.FinishTransfer("b.dat")
+	assert.Equal(t, "Downloading LFS objects:  67% (2/3), 10 B/60 B | 0 B/s, avg 0 B/s | c.dat", m.str())
+}
+
+func TestMeterStrTruncatesInFlight(t *testing.T) {
+	m := newTestMeter(Download)
+	m.Add(10)
+	m.Add(20)
+	m.StartTransfer("some/long/directory/name/a.dat")
+	m.StartTransfer("b.dat")
+
+	// The line without the name is 64 characters long, and " | " and
+	// " (+1)" take eight more, leaving 17 for the name in a line of 90,
+	// as the last column is left empty.
+	m.widthFn = func() int { return 90 }
+	assert.Equal(t, "Downloading LFS objects:   0% (0/2), 0 B/30 B | 0 B/s, avg 0 B/s | ...ory/name/a.dat (+1)", m.str())
+
+	m.widthFn = func() int { return 70 }
+	assert.Equal(t, "Downloading LFS objects:   0% (0/2), 0 B/30 B | 0 B/s, avg 0 B/s", m.str())
+
+	m.widthFn = func() int { return 200 }
+	assert.Equal(t, "Downloading LFS objects:   0% (0/2), 0 B/30 B | 0 B/s, avg 0 B/s | some/long/directory/name/a.dat (+1)", m.str())
+}
+
+func TestMeterStrPhases(t *testing.T) {
+	m := newTestMeter(Download)
+	m.AddPhase(Checkout)
+	m.AddPhase(Checkout)
+	m.Add(10)
+	m.Add(20)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.StartTransfer("b.dat")
+	m.TransferBytes("checkout", "b.dat", 20, 20, 20)
+	m.FinishTransfer("b.dat")
+
+	assert.Equal(t, "Downloading and checking out LFS objects: 100% (2/2), 30 B/30 B | 0 B/s, avg 0 B/s", m.str())
+}
+
+func TestMeterStrETA(t *testing.T) {
+	m := newTestMeter(Upload)
+	m.Add(1000)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("upload", "a.dat", 250, 1000, 250)
+	m.avgBytes = 100
+	m.rateBytes = 200
+
+	assert.Equal(t, "Uploading LFS objects:   0% (0/1), 250 B/1.0 KB | 200 B/s, avg 100 B/s, ETA 8s | a.dat", m.str())
+}
+
+func TestMeterStrFinished(t *testing.T) {
+	m := newTestMeter(Checkout)
+	m.Add(5)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("checkout", "a.dat", 5, 5, 5)
+	m.FinishTransfer("a.dat")
+	m.avgBytes = 5
+	m.Finish()
+
+	assert.Equal(t, "Checking out LFS objects: 100% (1/1), 5 B/5 B | 5 B/s", m.str())
+}
+
+func TestMeterStrTranslated(t *testing.T) {
+	defer func(l *tr.Locale) { tr.Tr = l }(tr.Tr)
+	tr.Tr = tr.NewLocale("de_DE.UTF-8")
+
+	m := newTestMeter(Upload)
+	m.Add(1000)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("upload", "a.dat", 250, 1000, 250)
+	m.avgBytes = 100
+	m.rateBytes = 200
+
+	assert.Equal(t, "Lade LFS-Objekte hoch:   0% (0/1), 250 B/1.0 KB | 200 B/s, Durchschnitt 100 B/s, verbleibend 8s | a.dat", m.str())
+}
+
+func TestMeterETA(t *testing.T) {
+	m := newTestMeter(Download)
+	_, ok := m.eta()
+	assert.False(t, ok)
+
+	m.Add(3000)
+	_, ok = m.eta()
+	assert.False(t, ok)
+
+	m.avgBytes = 1000
+	eta, ok := m.eta()
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, eta)
+
+	m.Skip(3000)
+	_, ok = m.eta()
+	assert.False(t, ok)
+}
@@ -0,0 +1,244 @@
+package tq_test // to avoid import cycles
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	test "github.com/git-lfs/git-lfs/t/cmd/util"
+	. "github.com/git-lfs/git-lfs/tq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockQueue(t *testing.T, srv *test.MockServer, repo *test.Repo, dir Direction) *TransferQueue {
+	return NewTransferQueue(dir, newMockManifest(t, srv, repo, dir), "origin")
+}
+
+func newMockManifest(t *testing.T, srv *test.MockServer, repo *test.Repo, dir Direction) *Manifest {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL,
+	}))
+	require.Nil(t, err)
+
+	return NewManifest(repo.Filesystem(), cli, dir.String(), "origin")
+}
+
+// memoryStore is an fs.ObjectStore which keeps objects in memory.
+type memoryStore map[string][]byte
+
+func (s memoryStore) OpenObject(oid string) (io.ReadCloser, error) {
+	data, ok := s[oid]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: oid, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s memoryStore) WriteObject(oid string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s[oid] = data
+	return nil
+}
+
+func (s memoryStore) ObjectExists(oid string, size int64) bool {
+	data, ok := s[oid]
+	return ok && int64(len(data)) == size
+}
+
+func (s memoryStore) ObjectSize(oid string) (int64, error) {
+	data, ok := s[oid]
+	if !ok {
+		return 0, &os.PathError{Op: "stat", Path: oid, Err: os.ErrNotExist}
+	}
+	return int64(len(data)), nil
+}
+
+func (s memoryStore) RemoveObject(oid string) error {
+	delete(s, oid)
+	return nil
+}
+
+func (s memoryStore) EachObject(fn func(fs.Object) error) error {
+	for oid, data := range s {
+		if err := fn(fs.Object{Oid: oid, Size: int64(len(data))}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestMockServerUploadRetriesThrottledTransfer(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	content := []byte("uploaded")
+	path := filepath.Join(repo.Path, "a.dat")
+	require.Nil(t, ioutil.WriteFile(path, content, 0644))
+	sum := sha256.Sum256(content)
+	oid := hex.EncodeToString(sum[:])
+
+	srv.Throttle(test.MockUpload, 1, 0)
+
+	q := newMockQueue(t, srv, repo, Upload)
+	q.Add("a.dat", path, oid, int64(len(content)), false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, 2, srv.Requests(test.MockUpload))
+	data, ok := srv.Object(oid)
+	require.True(t, ok)
+	assert.Equal(t, content, data)
+}
+
+func TestMockServerDownloadRefreshesExpiredTokens(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	content := []byte("downloaded")
+	oid := srv.AddObject(content)
+
+	srv.ExpireActionsAfter(time.Hour)
+	srv.ExpireTokens(1)
+
+	path, err := repo.Filesystem().ObjectPath(oid)
+	require.Nil(t, err)
+
+	q := newMockQueue(t, srv, repo, Download)
+	q.Add("a.dat", path, oid, int64(len(content)), false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, 2, srv.Requests(test.MockDownload))
+	assert.True(t, repo.Filesystem().ObjectExists(oid, int64(len(content))))
+}
+
+func TestMockServerDownloadWritesToObjectStore(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	content := []byte("stored")
+	oid := srv.AddObject(content)
+
+	path, err := repo.Filesystem().ObjectPath(oid)
+	require.Nil(t, err)
+
+	store := memoryStore{}
+	m := newMockManifest(t, srv, repo, Download)
+	m.SetObjectStore(store)
+
+	q := NewTransferQueue(Download, m, "origin")
+	q.Add("a.dat", path, oid, int64(len(content)), false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, content, store[oid])
+	assert.False(t, repo.Filesystem().ObjectExists(oid, int64(len(content))))
+}
+
+func TestMockServerReportsObjectErrors(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	oid := srv.AddObject([]byte("forbidden"))
+	srv.SetObjectError(oid, http.StatusForbidden, "not allowed")
+
+	q := newMockQueue(t, srv, repo, Download)
+	q.Add("a.dat", filepath.Join(repo.Path, "a.dat"), oid, 9, false, nil)
+	q.Wait()
+
+	require.Len(t, q.Errors(), 1)
+	assert.Contains(t, q.Errors()[0].Error(), "not allowed")
+	assert.Equal(t, 0, srv.Requests(test.MockDownload))
+}
+
+func TestMockServerRunsPreTransferHookPerBatch(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	events := filepath.Join(repo.Path, "events.json")
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":               srv.URL,
+		"lfs.hooks.pretransfer": "cat >> '" + events + "'",
+	}))
+	require.Nil(t, err)
+	m := NewManifest(repo.Filesystem(), cli, "download", "origin")
+
+	q := NewTransferQueue(Download, m, "origin", WithBatchSize(2))
+	for i, name := range []string{"a.dat", "b.dat", "c.dat"} {
+		content := []byte(name)
+		oid := srv.AddObject(content)
+		path, err := repo.Filesystem().ObjectPath(oid)
+		require.Nil(t, err)
+		q.Add(name, path, oid, int64(len(content)), false, nil)
+
+		if i == 1 {
+			// The hook is run on the first batch as soon as it
+			// is complete, rather than once all of the objects
+			// have been added.
+			data, err := ioutil.ReadFile(events)
+			require.Nil(t, err)
+			assert.Equal(t, 1, bytes.Count(data, []byte("\n")))
+		}
+	}
+	q.Wait()
+	assert.Empty(t, q.Errors())
+
+	data, err := ioutil.ReadFile(events)
+	require.Nil(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Equal(t, 2, bytes.Count(lines[0], []byte(`"oid"`)))
+	assert.Equal(t, 1, bytes.Count(lines[1], []byte(`"oid"`)))
+}
+
+func TestMockServerWritesHookOutputToWarningOut(t *testing.T) {
+	repo := test.NewRepo(t)
+	defer repo.Cleanup()
+	srv := test.NewMockServer()
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                srv.URL,
+		"lfs.hooks.pretransfer":  "echo approved",
+		"lfs.hooks.posttransfer": "exit 1",
+	}))
+	require.Nil(t, err)
+	m := NewManifest(repo.Filesystem(), cli, "download", "origin")
+	var out bytes.Buffer
+	m.SetWarningOut(&out)
+
+	q := NewTransferQueue(Download, m, "origin")
+	content := []byte("a.dat")
+	oid := srv.AddObject(content)
+	path, err := repo.Filesystem().ObjectPath(oid)
+	require.Nil(t, err)
+	q.Add("a.dat", path, oid, int64(len(content)), false, nil)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+
+	assert.Equal(t, "approved\nwarning: posttransfer hook failed: exit status 1\n", out.String())
+}
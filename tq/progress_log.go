@@ -0,0 +1,120 @@
+package tq
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+// The versions of the format of the file named by GIT_LFS_PROGRESS.  Version
+// 1, the default, holds only progress records.  Later versions are written
+// only when asked for with GIT_LFS_PROGRESS_VERSION, so that programs which
+// read the original format are not confused by records they do not know.
+const (
+	progressLogVersion1      = 1
+	progressLogVersion2      = 2
+	progressLogLatestVersion = progressLogVersion2
+)
+
+// ProgressLog writes the records of the file named by GIT_LFS_PROGRESS, which
+// programs which run Git LFS read to show its progress.  Each record is a line
+// of fields separated by spaces, the last of which, if it is a file name, may
+// itself contain spaces.  Version 1 files hold only progress records:
+//
+//	<direction> <current>/<total files> <transferred>/<size> <name>
+//
+// Version 2 files start with a version header, and hold these records too:
+//
+//	version <version>
+//	totals <direction> <total files> <total bytes>
+//	complete <direction> <current>/<total files> <size> <name>
+//	skip <direction> <size>
+type ProgressLog struct {
+	mu      sync.Mutex
+	w       *tools.SyncWriter
+	version int
+}
+
+// ProgressLogVersion returns the version of the format of the file named by
+// GIT_LFS_PROGRESS which was asked for with GIT_LFS_PROGRESS_VERSION, or
+// version 1 if none, or one which is not known, was.
+func ProgressLogVersion(e env) int {
+	value, _ := e.Get("GIT_LFS_PROGRESS_VERSION")
+	version, err := strconv.Atoi(value)
+	if err != nil || version < progressLogVersion1 || version > progressLogLatestVersion {
+		return progressLogVersion1
+	}
+	return version
+}
+
+// NewProgressLog returns a ProgressLog which appends records of the given
+// version of the format to the file, writing the version header first if the
+// file is empty and the version has one.
+func NewProgressLog(file *os.File, version int) (*ProgressLog, error) {
+	l := &ProgressLog{w: tools.NewSyncWriter(file), version: version}
+	if version < progressLogVersion2 {
+		return l, nil
+	}
+	if fi, err := file.Stat(); err == nil && fi.Size() == 0 {
+		if err := l.writef("version %d", version); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Version returns the version of the format written, or version 1 if there is
+// no ProgressLog.
+func (l *ProgressLog) Version() int {
+	if l == nil {
+		return progressLogVersion1
+	}
+	return l.version
+}
+
+// Totals writes the number of files, and of bytes, which are expected to be
+// transferred in the given direction.  It is written before the progress
+// records which refer to the totals, and again whenever they grow.
+func (l *ProgressLog) Totals(direction string, files, bytes int64) error {
+	if l.version < progressLogVersion2 {
+		return nil
+	}
+	return l.writef("totals %s %d %d", direction, files, bytes)
+}
+
+// Progress writes the number of bytes of a file transferred so far.
+func (l *ProgressLog) Progress(direction string, index, files, transferred, size int64, name string) error {
+	return l.writef("%s %d/%d %d/%d %s", direction, index, files, transferred, size, name)
+}
+
+// Complete writes that a file has been transferred.
+func (l *ProgressLog) Complete(direction string, index, files, size int64, name string) error {
+	if l.version < progressLogVersion2 {
+		return nil
+	}
+	return l.writef("complete %s %d/%d %d %s", direction, index, files, size, name)
+}
+
+// Skip writes that a file of the given size did not need to be transferred,
+// and counts towards the totals as though it had been.
+func (l *ProgressLog) Skip(direction string, size int64) error {
+	if l.version < progressLogVersion2 {
+		return nil
+	}
+	return l.writef("skip %s %d", direction, size)
+}
+
+// Close closes the underlying file.
+func (l *ProgressLog) Close() error {
+	return l.w.Close()
+}
+
+func (l *ProgressLog) writef(format string, args ...interface{}) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write([]byte(fmt.Sprintf(format, args...) + "\n"))
+}
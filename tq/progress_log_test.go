@@ -0,0 +1,110 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressLogHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "progress-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "progress.log")
+	for i := 0; i < 2; i++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		require.Nil(t, err)
+		l, err := NewProgressLog(file, 2)
+		require.Nil(t, err)
+		require.Nil(t, l.Progress("clean", 1, 1, 3, 3, "a file.dat"))
+		require.Nil(t, l.Close())
+	}
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "version 2\n"+
+		"clean 1/1 3/3 a file.dat\n"+
+		"clean 1/1 3/3 a file.dat\n", string(data))
+}
+
+func TestMeterWritesProgressLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "progress-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "progress.log")
+	m := NewMeter(config.NewFrom(config.Values{}))
+	m.Direction = Download
+	m.Logger, err = m.LoggerToFile(path, 2)
+	require.Nil(t, err)
+	require.NotNil(t, m.Logger)
+	runProgressLogMeter(m)
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "version 2\n"+
+		"totals download 2 15\n"+
+		"skip download 5\n"+
+		"download 1/2 4/10 a.dat\n"+
+		"download 1/2 10/10 a.dat\n"+
+		"complete download 1/2 10 a.dat\n"+
+		"totals download 3 16\n"+
+		"download 2/3 1/1 b.dat\n"+
+		"complete download 2/3 1 b.dat\n", string(data))
+}
+
+func TestMeterWritesProgressLogVersion1ByDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "progress-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "progress.log")
+	m := NewMeter(config.NewFrom(config.Values{}))
+	m.Direction = Download
+	m.Logger, err = m.LoggerFromEnv(progressEnv{"GIT_LFS_PROGRESS": path})
+	require.Nil(t, err)
+	require.NotNil(t, m.Logger)
+	runProgressLogMeter(m)
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "download 1/2 4/10 a.dat\n"+
+		"download 1/2 10/10 a.dat\n"+
+		"download 2/3 1/1 b.dat\n", string(data))
+}
+
+func TestProgressLogVersion(t *testing.T) {
+	assert.Equal(t, 1, ProgressLogVersion(progressEnv{}))
+	assert.Equal(t, 1, ProgressLogVersion(progressEnv{"GIT_LFS_PROGRESS_VERSION": "1"}))
+	assert.Equal(t, 2, ProgressLogVersion(progressEnv{"GIT_LFS_PROGRESS_VERSION": "2"}))
+	assert.Equal(t, 1, ProgressLogVersion(progressEnv{"GIT_LFS_PROGRESS_VERSION": "3"}))
+	assert.Equal(t, 1, ProgressLogVersion(progressEnv{"GIT_LFS_PROGRESS_VERSION": "x"}))
+}
+
+// runProgressLogMeter transfers two files with the Meter, and skips a third.
+func runProgressLogMeter(m *Meter) {
+	go func() {
+		for range m.Updates() {
+		}
+	}()
+
+	m.Add(10)
+	m.Add(5)
+	m.Start()
+	m.Skip(5)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("download", "a.dat", 4, 10, 4)
+	m.TransferBytes("download", "a.dat", 10, 10, 6)
+	m.FinishTransfer("a.dat")
+	m.Add(1)
+	m.StartTransfer("b.dat")
+	m.TransferBytes("download", "b.dat", 1, 1, 1)
+	m.FinishTransfer("b.dat")
+	m.Finish()
+}
@@ -0,0 +1,168 @@
+package tq
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+// progressStreamVersion is the version of the format of the events written to
+// a ProgressStream.  It is incremented whenever a change is made to the format
+// which is not backwards compatible, such as the removal of a field.
+const progressStreamVersion = 1
+
+// ProgressEvent is a single line of a ProgressStream.
+type ProgressEvent struct {
+	Version int `json:"version"`
+	// Event is "progress" when bytes of an object have been transferred,
+	// "done" when an object has been completely transferred, and "finish"
+	// when all of the objects of a phase have been transferred.
+	Event string `json:"event"`
+	// Phase is "download", "upload" or "checkout" for objects transferred
+	// by a command, or "clean" or "smudge" for files filtered by Git.
+	Phase string `json:"phase"`
+	// Name is the path of the object in the repository.
+	Name string `json:"name,omitempty"`
+	// Bytes and Size are the number of bytes of the object transferred so
+	// far, and its total size.
+	Bytes int64 `json:"bytes,omitempty"`
+	Size  int64 `json:"size,omitempty"`
+	// FilesDone, FilesTotal, BytesDone and BytesTotal count the objects
+	// and bytes of the whole phase.  The totals may grow as objects are
+	// found to transfer.
+	FilesDone  int64 `json:"files_done"`
+	FilesTotal int64 `json:"files_total"`
+	BytesDone  int64 `json:"bytes_done"`
+	BytesTotal int64 `json:"bytes_total"`
+	// Rate is the average transfer rate, in bytes per second.
+	Rate uint64 `json:"rate"`
+}
+
+// ProgressStream writes progress events as JSON, one per line, to the file or
+// file descriptor named by GIT_LFS_PROGRESS_JSON, so that programs which run
+// Git LFS can show its progress without parsing the terminal output.
+type ProgressStream struct {
+	mu   sync.Mutex
+	file *os.File
+
+	// nowFn returns the current time, from which transfer rates are
+	// computed.  It is time.Now unless replaced in tests.
+	nowFn func() time.Time
+}
+
+// NewProgressStreamFromEnv opens the ProgressStream named by
+// GIT_LFS_PROGRESS_JSON, or returns nil if it is not set.  The value is either
+// an absolute path, to which events are appended, or the number of a file
+// descriptor inherited from the parent process.  Since a file descriptor can
+// only be opened once, a program should open the stream once, and give it to
+// each Manifest and GitFilter which is to write to it.
+func NewProgressStreamFromEnv(e env) (*ProgressStream, error) {
+	name, _ := e.Get("GIT_LFS_PROGRESS_JSON")
+	if len(name) == 0 {
+		return nil, nil
+	}
+
+	file, err := openProgressStream(name)
+	if err != nil {
+		return nil, err
+	}
+	return &ProgressStream{file: file, nowFn: time.Now}, nil
+}
+
+func openProgressStream(name string) (*os.File, error) {
+	if fd, err := strconv.ParseUint(name, 10, 0); err == nil {
+		return os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd)), nil
+	}
+
+	if !filepath.IsAbs(name) {
+		return nil, fmt.Errorf("GIT_LFS_PROGRESS_JSON must be an absolute path or a file descriptor")
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+}
+
+// Write writes the given event as a single line.  If writing fails, the stream
+// is closed, and later events are discarded.
+func (s *ProgressStream) Write(ev *ProgressEvent) {
+	if s == nil {
+		return
+	}
+
+	ev.Version = progressStreamVersion
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// CopyCallback returns a callback which writes "progress" events, and a "done"
+// event once complete, for a single file being filtered in the given phase,
+// such as "clean" or "smudge".  It returns nil if the stream is nil.
+func (s *ProgressStream) CopyCallback(phase, name string) tools.CopyCallback {
+	if s == nil {
+		return nil
+	}
+
+	var last time.Time
+	start := s.nowFn()
+	return func(total, written int64, current int) error {
+		now := s.nowFn()
+		if written < total && now.Sub(last) < progressStreamInterval {
+			return nil
+		}
+		last = now
+
+		// The rate is the average since the callback was made, which
+		// is when the file started to be filtered.
+		var rate uint64
+		if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+			rate = uint64(float64(written) / elapsed)
+		}
+
+		ev := &ProgressEvent{
+			Event:      "progress",
+			Phase:      phase,
+			Name:       name,
+			Bytes:      written,
+			Size:       total,
+			FilesTotal: 1,
+			BytesDone:  written,
+			BytesTotal: total,
+			Rate:       rate,
+		}
+		s.Write(ev)
+
+		if written >= total {
+			s.Write(&ProgressEvent{
+				Event:      "done",
+				Phase:      phase,
+				Name:       name,
+				FilesDone:  1,
+				FilesTotal: 1,
+				BytesDone:  written,
+				BytesTotal: total,
+				Rate:       rate,
+			})
+		}
+		return nil
+	}
+}
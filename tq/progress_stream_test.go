@@ -0,0 +1,140 @@
+package tq
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type progressEnv map[string]string
+
+func (e progressEnv) Get(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func readProgressEvents(t *testing.T, path string) []*ProgressEvent {
+	f, err := os.Open(path)
+	require.Nil(t, err)
+	defer f.Close()
+
+	var events []*ProgressEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev ProgressEvent
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &ev), scanner.Text())
+		events = append(events, &ev)
+	}
+	require.Nil(t, scanner.Err())
+	return events
+}
+
+func TestNewProgressStreamFromEnv(t *testing.T) {
+	s, err := NewProgressStreamFromEnv(progressEnv{})
+	assert.Nil(t, err)
+	assert.Nil(t, s)
+
+	s, err = NewProgressStreamFromEnv(progressEnv{"GIT_LFS_PROGRESS_JSON": "relative.json"})
+	assert.NotNil(t, err)
+	assert.Nil(t, s)
+
+	dir, err := ioutil.TempDir("", "progress-stream")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sub", "progress.json")
+	s, err = NewProgressStreamFromEnv(progressEnv{"GIT_LFS_PROGRESS_JSON": path})
+	require.Nil(t, err)
+	require.NotNil(t, s)
+	s.file.Close()
+}
+
+func TestMeterWritesProgressStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "progress-stream")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "meter.json")
+	m := NewMeter(config.NewFrom(config.Values{}))
+	m.Direction = Download
+	m.Stream, err = NewProgressStreamFromEnv(progressEnv{"GIT_LFS_PROGRESS_JSON": path})
+	require.Nil(t, err)
+	require.NotNil(t, m.Stream)
+
+	go func() {
+		for range m.Updates() {
+		}
+	}()
+
+	m.Add(10)
+	m.StartTransfer("a.dat")
+	m.TransferBytes("download", "a.dat", 4, 10, 4)
+	m.TransferBytes("download", "a.dat", 10, 10, 6)
+	m.FinishTransfer("a.dat")
+	m.Finish()
+
+	events := readProgressEvents(t, path)
+	require.Len(t, events, 4)
+
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "progress", Phase: "download", Name: "a.dat", Bytes: 4, Size: 10, FilesTotal: 1, BytesDone: 4, BytesTotal: 10}, events[0])
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "progress", Phase: "download", Name: "a.dat", Bytes: 10, Size: 10, FilesTotal: 1, BytesDone: 10, BytesTotal: 10}, events[1])
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "done", Phase: "download", Name: "a.dat", FilesDone: 1, FilesTotal: 1, BytesDone: 10, BytesTotal: 10}, events[2])
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "finish", Phase: "download", FilesDone: 1, FilesTotal: 1, BytesDone: 10, BytesTotal: 10}, events[3])
+}
+
+func TestProgressStreamCopyCallback(t *testing.T) {
+	var s *ProgressStream
+	assert.Nil(t, s.CopyCallback("clean", "a.dat"))
+
+	dir, err := ioutil.TempDir("", "progress-stream")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "filter.json")
+	s, err = NewProgressStreamFromEnv(progressEnv{"GIT_LFS_PROGRESS_JSON": path})
+	require.Nil(t, err)
+
+	now := time.Unix(1000, 0)
+	s.nowFn = func() time.Time { return now }
+
+	cb := s.CopyCallback("clean", "a.dat")
+	now = now.Add(2 * time.Second)
+	require.Nil(t, cb(10, 4, 4))
+	// Written too soon after the last event, and incomplete.
+	require.Nil(t, cb(10, 6, 2))
+	now = now.Add(3 * time.Second)
+	require.Nil(t, cb(10, 10, 4))
+
+	events := readProgressEvents(t, path)
+	require.Len(t, events, 3)
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "progress", Phase: "clean", Name: "a.dat", Bytes: 4, Size: 10, FilesTotal: 1, BytesDone: 4, BytesTotal: 10, Rate: 2}, events[0])
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "progress", Phase: "clean", Name: "a.dat", Bytes: 10, Size: 10, FilesTotal: 1, BytesDone: 10, BytesTotal: 10, Rate: 2}, events[1])
+	assert.Equal(t, &ProgressEvent{Version: 1, Event: "done", Phase: "clean", Name: "a.dat", FilesDone: 1, FilesTotal: 1, BytesDone: 10, BytesTotal: 10, Rate: 2}, events[2])
+}
+
+func TestProgressStreamClosedOnWriteError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "progress-stream")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "read-only.json")
+	require.Nil(t, ioutil.WriteFile(path, nil, 0644))
+	f, err := os.Open(path)
+	require.Nil(t, err)
+
+	s := &ProgressStream{file: f, nowFn: time.Now}
+	s.Write(&ProgressEvent{Event: "finish", Phase: "download"})
+
+	assert.Nil(t, s.file)
+	err = f.Close()
+	require.IsType(t, &os.PathError{}, err)
+	assert.Equal(t, os.ErrClosed, err.(*os.PathError).Err)
+}
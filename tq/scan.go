@@ -0,0 +1,89 @@
+package tq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tracelog"
+)
+
+// RejectedObjectError is returned for an object which was not uploaded because
+// the upload scanner rejected it.
+type RejectedObjectError struct {
+	Name string
+	Oid  string
+	// Message is the output of the scanner, which explains why the object
+	// was rejected.
+	Message string
+}
+
+func (e *RejectedObjectError) Error() string {
+	msg := fmt.Sprintf("rejected object: %s (%s)", e.Name, e.Oid)
+	if len(e.Message) > 0 {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *RejectedObjectError) ErrorCode() errors.Code { return errors.CodePolicy }
+
+// uploadScanner runs the program set by "lfs.uploadscanner.path" on each
+// object before it is uploaded, so that objects can be checked against
+// policies on their contents, such as virus or secret scans. The program is
+// given the path of the object's contents as its last argument, and
+// "GIT_LFS_OID", "GIT_LFS_SIZE" and "GIT_LFS_NAME" in its environment. An
+// object is uploaded only if the program exits successfully; otherwise, its
+// output is given as the reason for rejecting it.
+type uploadScanner struct {
+	path string
+	args string
+}
+
+// newUploadScanner returns the upload scanner configured for the remote, or
+// nil if there is none.
+func newUploadScanner(git config.Environment, remote string) *uploadScanner {
+	path, ok := remoteSetting(git, remote, "uploadscanner.path")
+	if !ok || len(path) == 0 {
+		return nil
+	}
+	args, _ := remoteSetting(git, remote, "uploadscanner.args")
+	return &uploadScanner{path: path, args: args}
+}
+
+// Scan runs the scanner on the contents of t, returning a *RejectedObjectError
+// if the scanner rejected it, or could not be run, in which case the object is
+// treated as rejected. Objects stored compressed are decompressed into
+// "tempdir" for the scanner to read.
+func (s *uploadScanner) Scan(t *Transfer, tempdir string) error {
+	path, cleanup, err := fs.DecompressedObjectFile(t.Path, tempdir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	args := strings.TrimSpace(s.args + " " + subprocess.ShellQuoteSingle(path))
+	cmdName, cmdArgs := subprocess.FormatForShell(subprocess.ShellQuoteSingle(s.path), args)
+	cmd := subprocess.ExecCommand(cmdName, cmdArgs...)
+	cmd.Env = append(append([]string(nil), cmd.Env...),
+		"GIT_LFS_OID="+t.Oid,
+		"GIT_LFS_SIZE="+strconv.FormatInt(t.Size, 10),
+		"GIT_LFS_NAME="+t.Name,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	msg := strings.TrimSpace(string(out))
+	if len(msg) == 0 {
+		msg = fmt.Sprintf("upload scanner %s failed: %v", s.path, err)
+	}
+	tracelog.Printf("tq: upload scanner rejected %s: %s", t.Oid, msg)
+	return &RejectedObjectError{Name: t.Name, Oid: t.Oid, Message: msg}
+}
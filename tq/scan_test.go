@@ -0,0 +1,59 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadScannerIsConfiguredPerRemote(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.external.uploadscanner.path": "scan",
+		"lfs.external.uploadscanner.args": "--strict",
+	}))
+	require.Nil(t, err)
+
+	assert.Nil(t, NewManifest(nil, cli, "upload", "origin").uploadScanner)
+
+	s := NewManifest(nil, cli, "upload", "external").uploadScanner
+	require.NotNil(t, s)
+	assert.Equal(t, "scan", s.path)
+	assert.Equal(t, "--strict", s.args)
+}
+
+func TestUploadScannerScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "upload-scanner")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "object")
+	require.Nil(t, ioutil.WriteFile(path, []byte("password=hunter2"), 0644))
+
+	// The object's path is the last argument, and so "$0" of the script.
+	s := &uploadScanner{
+		path: "sh",
+		args: `-c 'if grep -q password "$0"; then echo "$GIT_LFS_NAME contains a secret"; exit 1; fi'`,
+	}
+	tr := &Transfer{Name: "a.dat", Oid: "abc", Size: 16, Path: path}
+
+	err = s.Scan(tr, dir)
+	require.NotNil(t, err)
+	assert.Equal(t, "rejected object: a.dat (abc): a.dat contains a secret", err.Error())
+	assert.Equal(t, errors.CodePolicy, errors.ErrorCode(err))
+
+	s.args = `-c 'test "$GIT_LFS_SIZE" -eq 16'`
+	assert.Nil(t, s.Scan(tr, dir))
+
+	s.path = filepath.Join(dir, "missing-scanner")
+	s.args = ""
+	err = s.Scan(tr, dir)
+	require.NotNil(t, err)
+	assert.IsType(t, &RejectedObjectError{}, err)
+}
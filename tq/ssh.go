@@ -16,7 +16,7 @@ import (
 	"github.com/git-lfs/git-lfs/lfshttp"
 	"github.com/git-lfs/git-lfs/ssh"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 type SSHBatchClient struct {
@@ -53,7 +53,7 @@ func (a *SSHBatchClient) Batch(remote string, bReq *batchRequest) (*BatchRespons
 		batchLines = append(batchLines, fmt.Sprintf("%s %d", obj.Oid, obj.Size))
 	}
 
-	tracerx.Printf("api: batch %d files", len(bReq.Objects))
+	tracelog.Printf("api: batch %d files", len(bReq.Objects))
 
 	requestedAt := time.Now()
 	args := []string{"transfer=ssh"}
@@ -244,7 +244,7 @@ func (a *SSHAdapter) doDownload(t *Transfer, conn *ssh.PktlineConnection, f *os.
 		}
 		return nil
 	}
-	hasher := tools.NewHashingReader(data)
+	hasher := tools.NewHashingReaderFor(data, t.Oid)
 	written, err := tools.CopyWithCallback(f, hasher, t.Size, ccb)
 	if err != nil {
 		return errors.Wrapf(err, "cannot write data to tempfile %q", dlfilename)
@@ -258,7 +258,7 @@ func (a *SSHAdapter) doDownload(t *Transfer, conn *ssh.PktlineConnection, f *os.
 		return fmt.Errorf("can't close tempfile %q: %v", dlfilename, err)
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
+	err = a.fs.CommitFile(dlfilename, t.Path)
 	if _, err2 := os.Stat(t.Path); err2 == nil {
 		// Target file already exists, possibly was downloaded by other git-lfs process
 		return nil
@@ -384,7 +384,7 @@ func (a *SSHAdapter) Trace(format string, args ...interface{}) {
 	if !a.adapterBase.debugging {
 		return
 	}
-	tracerx.Printf(format, args...)
+	tracelog.Printf(format, args...)
 }
 
 func configureSSHAdapter(m *Manifest) {
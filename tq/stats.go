@@ -0,0 +1,123 @@
+package tq
+
+import (
+	"time"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/metrics"
+)
+
+// TransferStats summarizes the transfers made by a single TransferQueue.
+type TransferStats struct {
+	// Direction is "upload" or "download".
+	Direction string `json:"direction"`
+	// Remote is the name of the remote, or the URL, with which objects
+	// were transferred.
+	Remote string `json:"remote"`
+	// Adapter is the name of the transfer adapter last used, or the empty
+	// string if no object reached a transfer adapter.
+	Adapter string `json:"adapter"`
+	// Objects is the number of objects transferred successfully, and
+	// Bytes their total size.
+	Objects int   `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+	// Errors is the number of errors reported by the queue.
+	Errors int `json:"errors"`
+	// Retries is the number of times that transfers were retried.
+	Retries int `json:"retries"`
+	// Start is the time at which the queue was created, and Duration how
+	// long it took to finish.
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// StatsFunc is given the statistics of each TransferQueue when it finishes.
+type StatsFunc func(*TransferStats)
+
+// SetStatsFunc sets the function given the statistics of each TransferQueue
+// created with this manifest when it finishes.  Queues making a dry run are not
+// reported.
+func (m *Manifest) SetStatsFunc(fn StatsFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.statsFunc = fn
+}
+
+func (m *Manifest) getStatsFunc() StatsFunc {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.statsFunc
+}
+
+// Stats returns the statistics of the transfers made by the queue, which are
+// only complete once Wait has returned.
+func (q *TransferQueue) Stats() *TransferStats {
+	q.trMutex.Lock()
+	objects, bytes := q.completedObjects, q.completedBytes
+	q.trMutex.Unlock()
+
+	q.adapterInitMutex.Lock()
+	adapter := q.adapterName
+	q.adapterInitMutex.Unlock()
+
+	end := q.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	return &TransferStats{
+		Direction: q.direction.String(),
+		Remote:    q.remote,
+		Adapter:   adapter,
+		Objects:   objects,
+		Bytes:     bytes,
+		Errors:    len(q.errors),
+		Retries:   q.rc.Total(),
+		Start:     q.started,
+		Duration:  end.Sub(q.started),
+	}
+}
+
+// reportStats gives the statistics of the finished queue to the manifest's
+// StatsFunc, if it has one, and emits them as metrics.
+func (q *TransferQueue) reportStats() {
+	if q.dryRun {
+		return
+	}
+
+	stats := q.Stats()
+	if fn := q.manifest.getStatsFunc(); fn != nil {
+		fn(stats)
+	}
+	emitStatsMetrics(stats, q.errors)
+}
+
+// emitStatsMetrics emits the statistics of a finished queue, and the errors it
+// reported, as metrics.  Queues which transferred nothing emit none.
+func emitStatsMetrics(stats *TransferStats, errs []error) {
+	if stats.Objects == 0 && stats.Errors == 0 {
+		return
+	}
+
+	tags := metrics.Tags{"direction": stats.Direction, "adapter": stats.Adapter}
+	metrics.Count("transfer.objects", int64(stats.Objects), tags)
+	metrics.Count("transfer.bytes", stats.Bytes, tags)
+	if stats.Retries > 0 {
+		metrics.Count("transfer.retries", int64(stats.Retries), tags)
+	}
+	metrics.Timing("transfer.duration", stats.Duration, tags)
+
+	for _, err := range errs {
+		code := string(errors.ErrorCode(err))
+		if len(code) == 0 {
+			code = "other"
+		}
+		metrics.Count("transfer.errors", 1, metrics.Tags{
+			"direction": stats.Direction,
+			"adapter":   stats.Adapter,
+			"code":      code,
+		})
+	}
+}
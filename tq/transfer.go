@@ -46,6 +46,17 @@ func (d Direction) String() string {
 	}
 }
 
+// parseDirection returns the Direction named "s", as given by String, and
+// whether there is one.
+func parseDirection(s string) (Direction, bool) {
+	for _, d := range []Direction{Upload, Download, Checkout} {
+		if d.String() == s {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
 type Transfer struct {
 	Name          string       `json:"name,omitempty"`
 	Oid           string       `json:"oid,omitempty"`
@@ -56,6 +67,9 @@ type Transfer struct {
 	Error         *ObjectError `json:"error,omitempty"`
 	Path          string       `json:"path,omitempty"`
 	Missing       bool         `json:"-"`
+	// ContentType is the media type of the object, if known, and is sent
+	// as the Content-Type of basic uploads.
+	ContentType string `json:"-"`
 }
 
 func (t *Transfer) Rel(name string) (*Action, error) {
@@ -83,6 +97,21 @@ func (e *ObjectError) Error() string {
 	return fmt.Sprintf("[%d] %s", e.Code, e.Message)
 }
 
+// ErrorCode returns the errors.Code for the HTTP status code given by the
+// server for the object.
+func (e *ObjectError) ErrorCode() errors.Code {
+	switch e.Code {
+	case 401, 403:
+		return errors.CodeAuth
+	case 404, 410:
+		return errors.CodeNotFound
+	case 507, 509:
+		return errors.CodeQuota
+	default:
+		return ""
+	}
+}
+
 // newTransfer returns a copy of the given Transfer, with the name and path
 // values set.
 func newTransfer(tr *Transfer, name string, path string) *Transfer {
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/lfshttp"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 const (
@@ -57,6 +59,19 @@ func (r *retryCounter) CountFor(oid string) int {
 	return r.count[oid]
 }
 
+// Total returns the number of retries of all OIDs. It is safe to call across
+// multiple goroutines.
+func (r *retryCounter) Total() int {
+	r.cmu.Lock()
+	defer r.cmu.Unlock()
+
+	var total int
+	for _, n := range r.count {
+		total += n
+	}
+	return total
+}
+
 // CanRetry returns the current number of retries, and whether or not it exceeds
 // the maximum number of retries (see: retryCounter.MaxRetries).
 func (r *retryCounter) CanRetry(oid string) (int, bool) {
@@ -183,6 +198,7 @@ type TransferQueue struct {
 	remote            string
 	ref               *git.Ref
 	adapter           Adapter
+	adapterName       string
 	adapterInProgress bool
 	adapterInitMutex  sync.Mutex
 	dryRun            bool
@@ -205,6 +221,23 @@ type TransferQueue struct {
 	manifest *Manifest
 	rc       *retryCounter
 
+	// started and finished are the times at which the queue was created
+	// and finished waiting.  completedObjects and completedBytes count
+	// the objects transferred successfully, and are guarded by trMutex.
+	started          time.Time
+	finished         time.Time
+	completedObjects int
+	completedBytes   int64
+
+	// hooked holds the new objects added to the queue, in order, when
+	// transfer hooks are configured, and unhooked holds those of them on
+	// which the "pretransfer" hook is yet to be run.  Objects are held in
+	// unhooked instead of being sent to incoming until a batch of them has
+	// been added, or Wait is called, and the hook has been run on them.
+	// Both are guarded by trMutex.
+	hooked   []*objectTuple
+	unhooked []*objectTuple
+
 	// unsupportedContentType indicates whether the transfer queue ever saw
 	// an HTTP 422 response indicating that their upload destination does
 	// not support Content-Type detection.
@@ -243,16 +276,18 @@ type objectTuple struct {
 	Name, Path, Oid string
 	Size            int64
 	Missing         bool
+	ContentType     string
 	ReadyTime       time.Time
 }
 
 func (o *objectTuple) ToTransfer() *Transfer {
 	return &Transfer{
-		Name:    o.Name,
-		Path:    o.Path,
-		Oid:     o.Oid,
-		Size:    o.Size,
-		Missing: o.Missing,
+		Name:        o.Name,
+		Path:        o.Path,
+		Oid:         o.Oid,
+		Size:        o.Size,
+		Missing:     o.Missing,
+		ContentType: o.ContentType,
 	}
 }
 
@@ -302,6 +337,7 @@ func NewTransferQueue(dir Direction, manifest *Manifest, remote string, options
 		manifest:  manifest,
 		rc:        newRetryCounter(),
 		wait:      newAbortableWaitGroup(),
+		started:   time.Now(),
 	}
 
 	for _, opt := range options {
@@ -320,6 +356,9 @@ func NewTransferQueue(dir Direction, manifest *Manifest, remote string, options
 	}
 	if q.meter != nil {
 		q.meter.Direction = q.direction
+		if q.meter.Stream == nil {
+			q.meter.Stream = q.manifest.progressStream
+		}
 	}
 
 	q.incoming = make(chan *objectTuple, q.bufferDepth)
@@ -345,14 +384,29 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 		return
 	}
 
-	t := &objectTuple{
+	q.add(&objectTuple{
 		Name:    name,
 		Path:    path,
 		Oid:     oid,
 		Size:    size,
 		Missing: missing,
-	}
+	})
+}
+
+// AddTransfer adds the given *Transfer to the queue, as Add does, but also
+// carries over details such as its ContentType which Add does not accept.
+func (q *TransferQueue) AddTransfer(t *Transfer) {
+	q.add(&objectTuple{
+		Name:        t.Name,
+		Path:        t.Path,
+		Oid:         t.Oid,
+		Size:        t.Size,
+		Missing:     t.Missing,
+		ContentType: t.ContentType,
+	})
+}
 
+func (q *TransferQueue) add(t *objectTuple) {
 	if objs := q.remember(t); len(objs.objects) > 1 {
 		if objs.completed {
 			// If there is already a completed transfer chain for
@@ -365,7 +419,17 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 
 		// If the chain is not done, there is no reason to enqueue this
 		// transfer into 'q.incoming'.
-		tracerx.Printf("already transferring %q, skipping duplicate", t.Oid)
+		tracelog.Printf("already transferring %q, skipping duplicate", t.Oid)
+		return
+	}
+
+	if q.hooks() != nil {
+		q.trMutex.Lock()
+		q.hooked = append(q.hooked, t)
+		q.unhooked = append(q.unhooked, t)
+		q.trMutex.Unlock()
+
+		q.runPreTransferHook(q.batchSize)
 		return
 	}
 
@@ -519,7 +583,7 @@ func (q *TransferQueue) collectPendingUntil(done <-chan struct{}) (pending batch
 // processed.
 func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error) {
 	next := q.makeBatch()
-	tracerx.Printf("tq: sending batch of size %d", len(batch))
+	tracelog.Printf("tq: sending batch of size %d", len(batch))
 
 	enqueueRetry := func(t *objectTuple, err error, readyTime *time.Time) {
 		count := q.rc.Increment(t.Oid)
@@ -535,7 +599,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		if err != nil {
 			errMsg = fmt.Sprintf(": %s", err)
 		}
-		tracerx.Printf("tq: enqueue retry #%d after %.2fs for %q (size: %d)%s", count, delay, t.Oid, t.Size, errMsg)
+		tracelog.Printf("tq: enqueue retry #%d after %.2fs for %q (size: %d)%s", count, delay, t.Oid, t.Size, errMsg)
 		next = append(next, t)
 	}
 
@@ -591,7 +655,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// missing in that case, since we don't need to upload
 			// it.
 			if o.Missing && len(o.Actions) != 0 {
-				return nil, errors.Errorf("Unable to find source for object %v (try running git lfs fetch --all)", o.Oid)
+				return nil, &MissingSourceError{Oid: o.Oid}
 			}
 		}
 	}
@@ -626,6 +690,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// Pick t[0], since it will cover all transfers with the
 			// same OID.
 			tr := newTransfer(o, objects.First().Name, objects.First().Path)
+			tr.ContentType = objects.First().ContentType
 
 			if a, err := tr.Rel(q.direction.String()); err != nil {
 				if q.canRetryObject(tr.Oid, err) {
@@ -716,15 +781,20 @@ func (q *TransferQueue) partitionTransfers(transfers []*Transfer) (present []*Tr
 		if t.Size < 0 {
 			err = errors.Errorf("Git LFS: object %q has invalid size (got: %d)", t.Oid, t.Size)
 		} else {
-			fd, serr := os.Stat(t.Path)
+			size, _, serr := fs.ObjectFileSize(t.Path)
 			if serr != nil {
-				if os.IsNotExist(serr) {
-					err = newObjectMissingError(t.Name, t.Oid)
-				} else {
+				if !os.IsNotExist(serr) {
 					err = serr
+				} else if !q.dryRun || t.Missing {
+					// A dry run cleans nothing, so objects
+					// which would be cleaned from the
+					// working tree are not yet stored.
+					err = newObjectMissingError(t.Name, t.Oid)
 				}
-			} else if t.Size != fd.Size() {
+			} else if t.Size != size {
 				err = newCorruptObjectError(t.Name, t.Oid)
+			} else if q.manifest.uploadScanner != nil {
+				err = q.manifest.uploadScanner.Scan(t, tempDir(q.manifest.fs))
 			}
 		}
 
@@ -768,7 +838,7 @@ func (q *TransferQueue) handleTransferResult(
 			// If the object can't be retried now, but can be
 			// after a certain period of time, send it to
 			// the retry channel with a time when it's ready.
-			tracerx.Printf("tq: retrying object %s after %s seconds.", oid, time.Until(readyTime).Seconds())
+			tracelog.Printf("tq: retrying object %s after %s seconds.", oid, time.Until(readyTime).Seconds())
 			q.trMutex.Lock()
 			objects, ok := q.transfers[oid]
 			q.trMutex.Unlock()
@@ -784,7 +854,7 @@ func (q *TransferQueue) handleTransferResult(
 			// If the object can be retried, send it on the retries
 			// channel, where it will be read at the call-site and
 			// its retry count will be incremented.
-			tracerx.Printf("tq: retrying object %s: %s", oid, res.Error)
+			tracelog.Printf("tq: retrying object %s: %s", oid, res.Error)
 
 			q.trMutex.Lock()
 			objects, ok := q.transfers[oid]
@@ -812,6 +882,10 @@ func (q *TransferQueue) handleTransferResult(
 		q.trMutex.Lock()
 		objects := q.transfers[oid]
 		objects.completed = true
+		q.completedObjects++
+		if t := objects.First(); t != nil {
+			q.completedBytes += t.Size
+		}
 
 		// Otherwise, if the transfer was successful, notify all of the
 		// watchers, and mark it as finished.
@@ -851,6 +925,9 @@ func (q *TransferQueue) useAdapter(name string) {
 		q.finishAdapter()
 	}
 	q.adapter = q.manifest.NewAdapterOrDefault(name, q.direction)
+	if q.adapter != nil {
+		q.adapterName = q.adapter.Name()
+	}
 }
 
 func (q *TransferQueue) finishAdapter() {
@@ -892,7 +969,7 @@ func (q *TransferQueue) ensureAdapterBegun(e lfshttp.Endpoint) error {
 		return nil
 	}
 
-	tracerx.Printf("tq: starting transfer adapter %q", q.adapter.Name())
+	tracelog.Printf("tq: starting transfer adapter %q", q.adapter.Name())
 	err := q.adapter.Begin(q.toAdapterCfg(e), cb)
 	if err != nil {
 		return err
@@ -928,6 +1005,7 @@ var (
 // called, Add will no longer add transfers to the queue. Any failed
 // transfers will be automatically retried once.
 func (q *TransferQueue) Wait() {
+	q.runPreTransferHook(0)
 	close(q.incoming)
 
 	q.wait.Wait()
@@ -949,8 +1027,74 @@ func (q *TransferQueue) Wait() {
 
 	if q.unsupportedContentType {
 		for _, line := range contentTypeWarning {
-			fmt.Fprintf(os.Stderr, "info: %s\n", line)
+			q.manifest.warnf("info: %s", line)
+		}
+	}
+
+	q.finished = time.Now()
+	q.reportStats()
+	q.runPostTransferHook()
+}
+
+// hooks returns the transfer hooks to run, or nil if there are none, or the
+// queue is only making a dry run.
+func (q *TransferQueue) hooks() *transferHooks {
+	if q.dryRun {
+		return nil
+	}
+	return q.manifest.transferHooks
+}
+
+// runPreTransferHook runs the "pretransfer" hook on the objects held since
+// they were added, if there are at least "min" of them, and then queues them
+// for transfer, or, if the hook refused them, records its error and gives up
+// on them.
+func (q *TransferQueue) runPreTransferHook(min int) {
+	hooks := q.hooks()
+	if hooks == nil {
+		return
+	}
+
+	q.trMutex.Lock()
+	held := q.unhooked
+	if len(held) == 0 || len(held) < min {
+		q.trMutex.Unlock()
+		return
+	}
+	q.unhooked = nil
+	q.trMutex.Unlock()
+
+	if err := hooks.PreTransfer(q.direction, q.remote, held, q.manifest.warningOut); err != nil {
+		q.errorc <- err
+		for _, t := range held {
+			q.Skip(t.Size)
+			q.wait.Done()
 		}
+		return
+	}
+
+	for _, t := range held {
+		q.incoming <- t
+	}
+}
+
+// runPostTransferHook runs the "posttransfer" hook on the objects which were
+// held for the "pretransfer" hook, once they have been transferred.
+func (q *TransferQueue) runPostTransferHook() {
+	hooks := q.hooks()
+	if hooks == nil {
+		return
+	}
+
+	q.trMutex.Lock()
+	objs := make([]*objects, 0, len(q.hooked))
+	for _, t := range q.hooked {
+		objs = append(objs, q.transfers[t.Oid])
+	}
+	q.trMutex.Unlock()
+
+	if err := hooks.PostTransfer(q.direction, q.remote, objs, q.errors, q.manifest.warningOut); err != nil {
+		q.manifest.warnf("warning: posttransfer hook failed: %v", err)
 	}
 }
 
@@ -966,16 +1110,36 @@ func (q *TransferQueue) Watch() chan *Transfer {
 
 // This goroutine collects errors returned from transfers
 func (q *TransferQueue) errorCollector() {
+	// Errors caused by giving up on an unreachable host are collapsed
+	// into a single summary per host, rather than reporting the same
+	// failure once for each object.
+	circuitErrs := make(map[string]error)
+	circuitSkipped := make(map[string]int)
+	var circuitHosts []string
+
 	for err := range q.errorc {
+		if host, ok := lfsapi.CircuitOpenHost(err); ok {
+			if _, seen := circuitErrs[host]; !seen {
+				circuitErrs[host] = err
+				circuitHosts = append(circuitHosts, host)
+			}
+			circuitSkipped[host]++
+			continue
+		}
 		q.errors = append(q.errors, err)
 	}
+
+	for _, host := range circuitHosts {
+		q.errors = append(q.errors, errors.Wrapf(circuitErrs[host],
+			"%d transfer(s) failed without being attempted", circuitSkipped[host]))
+	}
 	q.errorwait.Done()
 }
 
 // run begins the transfer queue. It transfers files sequentially or
 // concurrently depending on the Config.ConcurrentTransfers() value.
 func (q *TransferQueue) run() {
-	tracerx.Printf("tq: running as batched queue, batch size of %d", q.batchSize)
+	tracelog.Printf("tq: running as batched queue, batch size of %d", q.batchSize)
 
 	go q.errorCollector()
 	go q.collectBatches()
@@ -983,6 +1147,9 @@ func (q *TransferQueue) run() {
 
 // canRetry returns whether or not the given error "err" is retriable.
 func (q *TransferQueue) canRetry(err error) bool {
+	if lfsapi.IsCircuitOpenError(err) {
+		return false
+	}
 	return errors.IsRetriableError(err)
 }
 
@@ -998,7 +1165,7 @@ func (q *TransferQueue) canRetryLater(err error) (time.Time, bool) {
 // given error "err" is retriable.
 func (q *TransferQueue) canRetryObject(oid string, err error) bool {
 	if count, ok := q.rc.CanRetry(oid); !ok {
-		tracerx.Printf("tq: refusing to retry %q, too many retries (%d)", oid, count)
+		tracelog.Printf("tq: refusing to retry %q, too many retries (%d)", oid, count)
 		return false
 	}
 
@@ -1007,7 +1174,7 @@ func (q *TransferQueue) canRetryObject(oid string, err error) bool {
 
 func (q *TransferQueue) canRetryObjectLater(oid string, err error) (time.Time, bool) {
 	if count, ok := q.rc.CanRetry(oid); !ok {
-		tracerx.Printf("tq: refusing to retry %q, too many retries (%d)", oid, count)
+		tracelog.Printf("tq: refusing to retry %q, too many retries (%d)", oid, count)
 		return time.Time{}, false
 	}
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestManifestDefaultsToFixedRetries(t *testing.T) {
@@ -80,3 +81,35 @@ func TestBatchSizeReturnsBatchSize(t *testing.T) {
 
 	assert.Equal(t, 3, q.BatchSize())
 }
+
+func TestRetryCounterTotalsRetriesOfAllObjects(t *testing.T) {
+	rc := newRetryCounter()
+	rc.Increment("oid1")
+	rc.Increment("oid1")
+	rc.Increment("oid2")
+
+	assert.Equal(t, 3, rc.Total())
+}
+
+func TestEmptyQueueReportsStats(t *testing.T) {
+	var stats *TransferStats
+	m := NewManifest(nil, nil, "", "")
+	m.SetStatsFunc(func(s *TransferStats) { stats = s })
+
+	q := NewTransferQueue(Upload, m, "origin")
+	q.Wait()
+
+	require.NotNil(t, stats)
+	assert.Equal(t, "upload", stats.Direction)
+	assert.Equal(t, "origin", stats.Remote)
+	assert.Equal(t, 0, stats.Objects)
+	assert.Equal(t, 0, stats.Errors)
+}
+
+func TestDryRunQueueDoesNotReportStats(t *testing.T) {
+	m := NewManifest(nil, nil, "", "")
+	m.SetStatsFunc(func(s *TransferStats) { t.Error("stats reported for dry run") })
+
+	q := NewTransferQueue(Upload, m, "origin", DryRun(true))
+	q.Wait()
+}
@@ -5,11 +5,11 @@ import (
 
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/tools"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tracelog"
 )
 
 const (
-	maxVerifiesConfigKey     = "lfs.transfer.maxverifies"
+	maxVerifiesConfigKey     = "transfer.maxverifies"
 	defaultMaxVerifyAttempts = 3
 )
 
@@ -41,12 +41,12 @@ func verifyUpload(c *lfsapi.Client, remote string, t *Transfer) error {
 		req.Header.Set(key, value)
 	}
 
-	mv := c.GitEnv().Int(maxVerifiesConfigKey, defaultMaxVerifyAttempts)
+	mv := remoteSettingInt(c.GitEnv(), remote, maxVerifiesConfigKey, defaultMaxVerifyAttempts)
 	mv = tools.MaxInt(defaultMaxVerifyAttempts, mv)
 	req = c.LogRequest(req, "lfs.verify")
 
 	for i := 1; i <= mv; i++ {
-		tracerx.Printf("tq: verify %s attempt #%d (max: %d)", t.Oid[:7], i, mv)
+		tracelog.Printf("tq: verify %s attempt #%d (max: %d)", t.Oid[:7], i, mv)
 
 		var res *http.Response
 		if t.Authenticated {
@@ -56,7 +56,7 @@ func verifyUpload(c *lfsapi.Client, remote string, t *Transfer) error {
 		}
 
 		if err != nil {
-			tracerx.Printf("tq: verify err: %+v", err.Error())
+			tracelog.Printf("tq: verify err: %+v", err.Error())
 		} else {
 			err = res.Body.Close()
 			break
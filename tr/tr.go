@@ -0,0 +1,138 @@
+// Package tr translates the messages Git LFS shows to its users, using the
+// message catalogs in the po directory of the source tree.
+package tr
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Populate message catalogs
+//go:generate go run ../po/i18n.go
+
+// The catalogs variable, which is generated from the message catalogs, maps
+// language names, such as "de" or "pt_BR", to the messages translated into
+// each language, keyed by their English text.  A message with plural forms is
+// keyed by its singular form, and holds one translation for each plural form
+// of the language; any other message holds exactly one.
+
+// pluralForms maps the languages whose plural forms differ from those of
+// English to a function returning the index of the form to use for a count.
+var pluralForms = map[string]func(n int) int{
+	"fr": func(n int) int {
+		if n > 1 {
+			return 1
+		}
+		return 0
+	},
+}
+
+// Locale translates messages into one language.  The zero value, like a Locale
+// for a language without a catalog, leaves messages in English.
+type Locale struct {
+	language string
+	messages map[string][]string
+}
+
+// Tr is the Locale used for the messages Git LFS shows.  It is chosen from the
+// environment when the program starts, and may be replaced by SetLocale.
+var Tr = NewLocale(FromEnvironment())
+
+// NewLocale returns a Locale for the given locale name, such as "de_DE.UTF-8".
+// The name's territory is ignored if there is no catalog for it, and English
+// is used if there is no catalog for its language either.
+func NewLocale(name string) *Locale {
+	language := Language(name)
+	if messages, ok := catalogs[language]; ok {
+		return &Locale{language: language, messages: messages}
+	}
+	if i := strings.IndexByte(language, '_'); i > 0 {
+		if messages, ok := catalogs[language[:i]]; ok {
+			return &Locale{language: language[:i], messages: messages}
+		}
+	}
+	return &Locale{}
+}
+
+// SetLocale replaces Tr with a Locale for the given locale name.  An empty
+// name leaves Tr unchanged.  It must be called before any other goroutines
+// translate messages.
+func SetLocale(name string) {
+	if len(name) > 0 {
+		Tr = NewLocale(name)
+	}
+}
+
+// FromEnvironment returns the name of the locale used for messages, given by
+// the first of the LC_ALL, LC_MESSAGES and LANG environment variables which is
+// set, as for other programs.
+func FromEnvironment() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+// Language returns the language, and territory if any, of the given locale
+// name, stripping its codeset and modifier, so that "de_DE.UTF-8@euro" becomes
+// "de_DE".  The "C" and "POSIX" locales have no language.
+func Language(name string) string {
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "C" || name == "POSIX" {
+		return ""
+	}
+	return strings.Replace(name, "-", "_", 1)
+}
+
+// Language returns the language messages are translated into, or the empty
+// string if they are left in English.
+func (l *Locale) Language() string {
+	return l.language
+}
+
+// Get returns the translation of the given message, or the message itself if
+// it has none.  If any args are given, the translation is formatted with them,
+// as by fmt.Sprintf.
+func (l *Locale) Get(msgid string, args ...interface{}) string {
+	msg := msgid
+	if t, ok := l.messages[msgid]; ok && len(t) > 0 && len(t[0]) > 0 {
+		msg = t[0]
+	}
+	return format(msg, args...)
+}
+
+// GetN returns the translation of the plural form of the given message used
+// for a count of n, falling back on the singular message if n is 1 and on
+// plural otherwise.  If any args are given, the translation is formatted with
+// them, as by fmt.Sprintf.
+func (l *Locale) GetN(singular, plural string, n int, args ...interface{}) string {
+	msg := plural
+	if n == 1 {
+		msg = singular
+	}
+
+	if t, ok := l.messages[singular]; ok {
+		form := 1
+		if f, ok := pluralForms[l.language]; ok {
+			form = f(n)
+		} else if n == 1 {
+			form = 0
+		}
+		if form < len(t) && len(t[form]) > 0 {
+			msg = t[form]
+		}
+	}
+	return format(msg, args...)
+}
+
+func format(msg string, args ...interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
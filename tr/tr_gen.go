@@ -0,0 +1,97 @@
+package tr
+
+// THIS FILE IS GENERATED, DO NOT EDIT
+// Use 'go generate ./tr' to update
+
+var catalogs = map[string]map[string][]string{
+	"de": {
+		"[y/N]": {"[j/N]"},
+		"y":     {"j"},
+		"yes":   {"ja"},
+		"Git LFS: not prompting, as %s is set: %s [assuming no]":            {"Git LFS: keine Rückfrage, da %s gesetzt ist: %s [nehme nein an]"},
+		"Git LFS: download %s (%s) from the remote?":                        {"Git LFS: %s (%s) vom Remote-Repository herunterladen?"},
+		"\nErrors logged to %s\nUse `git lfs logs last` to view the log.\n": {"\nFehler wurden in %s protokolliert\nVerwenden Sie `git lfs logs last`, um das Protokoll anzuzeigen.\n"},
+		"Not in a git repository.":                                          {"Nicht in einem Git-Repository."},
+		"This operation must be run in a work tree.":                        {"Dieser Vorgang muss in einem Arbeitsverzeichnis ausgeführt werden."},
+		"Current directory %q outside of git working directory %q.":         {"Das aktuelle Verzeichnis %q liegt außerhalb des Git-Arbeitsverzeichnisses %q."},
+		"Invalid remote name %q: %s":                                        {"Ungültiger Remote-Name %q: %s"},
+		"Invalid ref argument: %q is not a commit in this repository":       {"Ungültige Referenz: %q ist kein Commit in diesem Repository"},
+		"Error while retrieving locks: %v":                                  {"Fehler beim Abrufen der Sperren: %v"},
+		"Cannot combine --all with --include or --exclude":                  {"--all kann nicht mit --include oder --exclude kombiniert werden"},
+		"Git LFS initialized.":                                              {"Git LFS initialisiert."},
+		"Run `git lfs install --force` to reset git config.":                {"Führen Sie `git lfs install --force` aus, um die Git-Konfiguration zurückzusetzen."},
+		"Tracking %q":                  {"Verfolge %q"},
+		"Untracking %q":                {"Verfolge %q nicht mehr"},
+		"%q already supported":         {"%q wird bereits unterstützt"},
+		"Listing tracked patterns":     {"Verfolgte Muster"},
+		"Listing excluded patterns":    {"Ausgeschlossene Muster"},
+		"Locked %s%s":                  {"%s%s gesperrt"},
+		"Unlocked %s":                  {"%s entsperrt"},
+		"Unable to lock %s: %v":        {"%s kann nicht gesperrt werden: %v"},
+		"Unable to unlock %s: %s":      {"%s kann nicht entsperrt werden: %s"},
+		"Unable to push locked files:": {"Gesperrte Dateien können nicht gepusht werden:"},
+		"Consider unlocking your own locked files: (`git lfs unlock <path>`)": {"Entsperren Sie gegebenenfalls Ihre eigenen gesperrten Dateien: (`git lfs unlock <Pfad>`)"},
+		"fetch: Fetching reference %s":                                        {"fetch: Lade Referenz %s"},
+		"fetch: Fetching all references...":                                   {"fetch: Lade alle Referenzen ..."},
+		"fetch: Scanning references":                                          {"fetch: Durchsuche Referenzen"},
+		"fetch: %d object(s) found":                                           {"fetch: %d Objekt(e) gefunden"},
+		"prune: %d file(s) would be retained":                                 {"prune: %d Datei(en) würden behalten"},
+		"prune: %d file(s) would be pruned (%s)":                              {"prune: %d Datei(en) würden entfernt (%s)"},
+		"%s: removed %d stale temporary file(s) (%s)":                         {"%s: %d veraltete temporäre Datei(en) entfernt (%s)"},
+		"prune: Deleting objects":                                             {"prune: Lösche Objekte"},
+		"migrate: Fetching remote refs":                                       {"migrate: Lade Remote-Referenzen"},
+		"migrate: checkout":                                                   {"migrate: Auschecken"},
+		"Checking out LFS objects: %3.f%% (%d/%d), %s/%s":                     {"Checke LFS-Objekte aus: %3.f%% (%d/%d), %s/%s"},
+		"Downloading LFS objects: %3.f%% (%d/%d), %s/%s":                      {"Lade LFS-Objekte herunter: %3.f%% (%d/%d), %s/%s"},
+		"Downloading and checking out LFS objects: %3.f%% (%d/%d), %s/%s":     {"Lade LFS-Objekte herunter und checke sie aus: %3.f%% (%d/%d), %s/%s"},
+		"Uploading LFS objects: %3.f%% (%d/%d), %s/%s":                        {"Lade LFS-Objekte hoch: %3.f%% (%d/%d), %s/%s"},
+		"%s, avg %s":  {"%s, Durchschnitt %s"},
+		"%s, ETA %s":  {"%s, verbleibend %s"},
+		"%s, done.\n": {"%s, fertig.\n"},
+	},
+	"fr": {
+		"[y/N]": {"[o/N]"},
+		"y":     {"o"},
+		"yes":   {"oui"},
+		"Git LFS: not prompting, as %s is set: %s [assuming no]":            {"Git LFS : pas de question, car %s est défini : %s [réponse non]"},
+		"Git LFS: download %s (%s) from the remote?":                        {"Git LFS : télécharger %s (%s) depuis le dépôt distant ?"},
+		"\nErrors logged to %s\nUse `git lfs logs last` to view the log.\n": {"\nErreurs journalisées dans %s\nUtilisez `git lfs logs last` pour afficher le journal.\n"},
+		"Not in a git repository.":                                          {"Pas dans un dépôt git."},
+		"This operation must be run in a work tree.":                        {"Cette opération doit être lancée dans une copie de travail."},
+		"Current directory %q outside of git working directory %q.":         {"Le répertoire courant %q est en dehors de la copie de travail git %q."},
+		"Invalid remote name %q: %s":                                        {"Nom de dépôt distant invalide %q : %s"},
+		"Invalid ref argument: %q is not a commit in this repository":       {"Référence invalide : %q n'est pas un commit de ce dépôt"},
+		"Error while retrieving locks: %v":                                  {"Erreur lors de la récupération des verrous : %v"},
+		"Cannot combine --all with --include or --exclude":                  {"Impossible de combiner --all avec --include ou --exclude"},
+		"Git LFS initialized.":                                              {"Git LFS initialisé."},
+		"Run `git lfs install --force` to reset git config.":                {"Lancez `git lfs install --force` pour réinitialiser la configuration de git."},
+		"Tracking %q":                  {"Suivi de %q"},
+		"Untracking %q":                {"Fin du suivi de %q"},
+		"%q already supported":         {"%q est déjà pris en charge"},
+		"Listing tracked patterns":     {"Motifs suivis"},
+		"Listing excluded patterns":    {"Motifs exclus"},
+		"Locked %s%s":                  {"%s%s verrouillé"},
+		"Unlocked %s":                  {"%s déverrouillé"},
+		"Unable to lock %s: %v":        {"Impossible de verrouiller %s : %v"},
+		"Unable to unlock %s: %s":      {"Impossible de déverrouiller %s : %s"},
+		"Unable to push locked files:": {"Impossible de pousser les fichiers verrouillés :"},
+		"Consider unlocking your own locked files: (`git lfs unlock <path>`)": {"Pensez à déverrouiller vos propres fichiers verrouillés : (`git lfs unlock <chemin>`)"},
+		"fetch: Fetching reference %s":                                        {"fetch : Récupération de la référence %s"},
+		"fetch: Fetching all references...":                                   {"fetch : Récupération de toutes les références..."},
+		"fetch: Scanning references":                                          {"fetch : Analyse des références"},
+		"fetch: %d object(s) found":                                           {"fetch : %d objet(s) trouvé(s)"},
+		"prune: %d file(s) would be retained":                                 {"prune : %d fichier(s) seraient conservés"},
+		"prune: %d file(s) would be pruned (%s)":                              {"prune : %d fichier(s) seraient supprimés (%s)"},
+		"%s: removed %d stale temporary file(s) (%s)":                         {"%s : %d fichier(s) temporaire(s) obsolète(s) supprimé(s) (%s)"},
+		"prune: Deleting objects":                                             {"prune : Suppression des objets"},
+		"migrate: Fetching remote refs":                                       {"migrate : Récupération des références distantes"},
+		"migrate: checkout":                                                   {"migrate : extraction"},
+		"Checking out LFS objects: %3.f%% (%d/%d), %s/%s":                     {"Extraction des objets LFS : %3.f%% (%d/%d), %s/%s"},
+		"Downloading LFS objects: %3.f%% (%d/%d), %s/%s":                      {"Téléchargement des objets LFS : %3.f%% (%d/%d), %s/%s"},
+		"Downloading and checking out LFS objects: %3.f%% (%d/%d), %s/%s":     {"Téléchargement et extraction des objets LFS : %3.f%% (%d/%d), %s/%s"},
+		"Uploading LFS objects: %3.f%% (%d/%d), %s/%s":                        {"Envoi des objets LFS : %3.f%% (%d/%d), %s/%s"},
+		"%s, avg %s":  {"%s, moyenne %s"},
+		"%s, ETA %s":  {"%s, reste %s"},
+		"%s, done.\n": {"%s, fait.\n"},
+	},
+}
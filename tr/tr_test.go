@@ -0,0 +1,128 @@
+package tr
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withCatalog sets the catalog of the given language, returning a function
+// which restores its previous catalog.
+func withCatalog(language string, messages map[string][]string) func() {
+	old, ok := catalogs[language]
+	catalogs[language] = messages
+	if ok {
+		return func() { catalogs[language] = old }
+	}
+	return func() { delete(catalogs, language) }
+}
+
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	if ok {
+		return func() { os.Setenv(key, old) }
+	}
+	return func() { os.Unsetenv(key) }
+}
+
+func TestLanguage(t *testing.T) {
+	for name, language := range map[string]string{
+		"":                 "",
+		"C":                "",
+		"POSIX":            "",
+		"C.UTF-8":          "",
+		"de":               "de",
+		"de_DE.UTF-8":      "de_DE",
+		"de_DE.UTF-8@euro": "de_DE",
+		"pt-BR":            "pt_BR",
+	} {
+		assert.Equal(t, language, Language(name), "locale %q", name)
+	}
+}
+
+func TestNewLocaleFallsBackOnLanguage(t *testing.T) {
+	defer withCatalog("xx", map[string][]string{"hello": {"xx hello"}})()
+	defer withCatalog("xx_YY", map[string][]string{"hello": {"xx_YY hello"}})()
+
+	assert.Equal(t, "xx_YY", NewLocale("xx_YY.UTF-8").Language())
+	assert.Equal(t, "xx_YY hello", NewLocale("xx_YY.UTF-8").Get("hello"))
+	assert.Equal(t, "xx", NewLocale("xx_ZZ").Language())
+	assert.Equal(t, "xx hello", NewLocale("xx_ZZ").Get("hello"))
+	assert.Equal(t, "", NewLocale("zz_ZZ").Language())
+	assert.Equal(t, "hello", NewLocale("zz_ZZ").Get("hello"))
+}
+
+func TestLocaleGet(t *testing.T) {
+	defer withCatalog("xx", map[string][]string{
+		"Tracking %q":  {"Following %q"},
+		"untranslated": {""},
+	})()
+	l := NewLocale("xx")
+
+	assert.Equal(t, "Following %q", l.Get("Tracking %q"))
+	assert.Equal(t, `Following "a.dat"`, l.Get("Tracking %q", "a.dat"))
+	assert.Equal(t, "untranslated", l.Get("untranslated"))
+	assert.Equal(t, "Untracking 100%", l.Get("Untracking 100%"))
+	assert.Equal(t, "Untracking a.dat", l.Get("Untracking %s", "a.dat"))
+}
+
+func TestLocaleGetN(t *testing.T) {
+	defer withCatalog("xx", map[string][]string{
+		"%d file": {"%d xx-file", "%d xx-files"},
+	})()
+	defer withCatalog("fr", map[string][]string{
+		"%d file": {"%d fichier", "%d fichiers"},
+	})()
+
+	for _, tc := range []struct {
+		locale   string
+		n        int
+		expected string
+	}{
+		{"", 0, "0 files"},
+		{"", 1, "1 file"},
+		{"", 2, "2 files"},
+		{"xx", 0, "0 xx-files"},
+		{"xx", 1, "1 xx-file"},
+		{"xx", 2, "2 xx-files"},
+		{"fr", 0, "0 fichier"},
+		{"fr", 1, "1 fichier"},
+		{"fr", 2, "2 fichiers"},
+	} {
+		assert.Equal(t, tc.expected, NewLocale(tc.locale).GetN("%d file", "%d files", tc.n, tc.n), "%s: %d", tc.locale, tc.n)
+	}
+}
+
+func TestFromEnvironment(t *testing.T) {
+	defer setenv("LC_ALL", "")()
+	defer setenv("LC_MESSAGES", "")()
+	defer setenv("LANG", "de_DE.UTF-8")()
+	assert.Equal(t, "de_DE.UTF-8", FromEnvironment())
+
+	os.Setenv("LC_MESSAGES", "fr_FR.UTF-8")
+	assert.Equal(t, "fr_FR.UTF-8", FromEnvironment())
+
+	os.Setenv("LC_ALL", "C")
+	assert.Equal(t, "C", FromEnvironment())
+}
+
+func TestSetLocale(t *testing.T) {
+	defer func(l *Locale) { Tr = l }(Tr)
+	defer withCatalog("xx", map[string][]string{"hello": {"xx hello"}})()
+
+	SetLocale("xx")
+	assert.Equal(t, "xx hello", Tr.Get("hello"))
+
+	SetLocale("")
+	assert.Equal(t, "xx hello", Tr.Get("hello"))
+}
+
+func TestCatalogsTranslatePrompts(t *testing.T) {
+	for language := range catalogs {
+		l := NewLocale(language)
+		assert.NotEqual(t, "y", l.Get("y"), language)
+		assert.NotEqual(t, "[y/N]", l.Get("[y/N]"), language)
+	}
+}
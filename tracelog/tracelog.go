@@ -0,0 +1,286 @@
+// Package tracelog writes the trace messages of Git LFS, which are enabled by
+// GIT_TRACE and GIT_TRACE_PERFORMANCE, either as text, using package tracerx,
+// or as structured JSON records, one per line, so that they can be collected
+// by centralized logging systems.
+package tracelog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+// Format is the format trace messages are written in.
+type Format int
+
+const (
+	// TextFormat writes each message as a line of text, prefixed with the
+	// time it was written.
+	TextFormat Format = iota
+	// JSONFormat writes each message as a JSON Record.
+	JSONFormat
+)
+
+// ParseFormat returns the Format with the given name, as given by
+// lfs.logformat, and whether the name is known.
+func ParseFormat(name string) (Format, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "text":
+		return TextFormat, true
+	case "json":
+		return JSONFormat, true
+	}
+	return TextFormat, false
+}
+
+// CorrelationIDEnv is the environment variable holding the correlation id of
+// the Git LFS operation in progress.  It is set by the first Git LFS process
+// to configure its trace format, and inherited by those it runs, including
+// the filters Git runs for it, so that their records share the same id.
+const CorrelationIDEnv = "GIT_LFS_CORRELATION_ID"
+
+// Record is a single trace message written in JSONFormat.
+type Record struct {
+	Time          string `json:"time"`
+	Level         string `json:"level"`
+	Command       string `json:"command,omitempty"`
+	Pid           int    `json:"pid"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Message       string `json:"message"`
+	// Seconds is the duration of the operation given by Message, for
+	// records of the "performance" level.
+	Seconds float64 `json:"seconds,omitempty"`
+}
+
+type message struct {
+	at    time.Time
+	level string
+	msg   string
+	// since is the time the operation given by msg started, for messages
+	// of the "performance" level.
+	since time.Time
+}
+
+var (
+	mu sync.Mutex
+
+	held    bool
+	pending []*message
+
+	format        = TextFormat
+	command       string
+	correlationID string
+
+	sink        io.Writer
+	traced      bool
+	performance bool
+	sinkOnce    sync.Once
+)
+
+func init() {
+	tracerx.DefaultKey = "GIT"
+	tracerx.Prefix = "trace git-lfs: "
+}
+
+// Hold buffers messages until Configure is called, so that messages written
+// while the configuration which chooses their format is read are written in
+// that format.
+func Hold() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	held = true
+}
+
+// Configure sets the format of trace messages, and the name of the command
+// being run, which is included in JSON records.  Messages buffered since Hold
+// was called are written.
+func Configure(f Format, cmd string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	format = f
+	command = cmd
+	if f == JSONFormat && len(correlationID) == 0 {
+		correlationID = os.Getenv(CorrelationIDEnv)
+		if len(correlationID) == 0 {
+			correlationID = newCorrelationID()
+			os.Setenv(CorrelationIDEnv, correlationID)
+		}
+	}
+
+	held = false
+	for _, m := range pending {
+		write(m)
+	}
+	pending = nil
+}
+
+// Enabled returns whether GIT_TRACE or GIT_TRACE_PERFORMANCE enable any trace
+// messages to be written.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sinkOnce.Do(openSink)
+	return traced || performance
+}
+
+// CurrentFormat returns the format trace messages are written in.
+func CurrentFormat() Format {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return format
+}
+
+// CorrelationID returns the correlation id included in JSON records, or an
+// empty string if JSON records are not being written.
+func CorrelationID() string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return correlationID
+}
+
+// Printf writes a trace message, if tracing is enabled by GIT_TRACE.
+func Printf(f string, args ...interface{}) {
+	logf("trace", f, args...)
+}
+
+// Errorf writes an error message, if tracing is enabled by GIT_TRACE.
+func Errorf(f string, args ...interface{}) {
+	logf("error", f, args...)
+}
+
+// PerformanceSince writes the time taken by the given operation, which
+// started at the given time, if GIT_TRACE_PERFORMANCE is enabled.
+func PerformanceSince(what string, t time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m := &message{at: time.Now(), level: "performance", msg: what, since: t}
+	if held {
+		pending = append(pending, m)
+		return
+	}
+	write(m)
+}
+
+func logf(level, f string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !held && format == TextFormat {
+		// Leave formatting to tracerx, which skips it when tracing
+		// is disabled.
+		if level == "error" {
+			f = "error: " + f
+		}
+		tracerx.Printf(f, args...)
+		return
+	}
+
+	m := &message{at: time.Now(), level: level, msg: fmt.Sprintf(f, args...)}
+	if held {
+		pending = append(pending, m)
+		return
+	}
+	write(m)
+}
+
+// write writes the given message in the configured format.  It must be called
+// with mu held.
+func write(m *message) {
+	if format == TextFormat {
+		switch m.level {
+		case "performance":
+			tracerx.PerformanceSince(m.msg, m.since)
+		case "error":
+			tracerx.Printf("error: %s", m.msg)
+		default:
+			tracerx.Printf("%s", m.msg)
+		}
+		return
+	}
+
+	sinkOnce.Do(openSink)
+	if m.level == "performance" && !performance {
+		return
+	} else if m.level != "performance" && !traced {
+		return
+	}
+
+	r := &Record{
+		Time:          m.at.UTC().Format(time.RFC3339Nano),
+		Level:         m.level,
+		Command:       command,
+		Pid:           os.Getpid(),
+		CorrelationID: correlationID,
+		Message:       m.msg,
+	}
+	if m.level == "performance" {
+		r.Seconds = m.at.Sub(m.since).Seconds()
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	sink.Write(append(data, '\n'))
+}
+
+// openSink opens the destination named by GIT_TRACE, following the same rules
+// as package tracerx: "1", "2" or "true" for standard error, the number of
+// another file descriptor, or an absolute path to append to.  Performance
+// messages are written to the same destination, or to standard error if
+// tracing is disabled.
+func openSink() {
+	sink = os.Stderr
+	if v := os.Getenv("GIT_TRACE_PERFORMANCE"); v == "1" || strings.ToLower(v) == "true" {
+		performance = true
+	}
+
+	trace := os.Getenv("GIT_TRACE")
+	fd, err := strconv.Atoi(trace)
+	if err != nil {
+		if filepath.IsAbs(trace) {
+			f, err := os.OpenFile(trace, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Could not open '%s' for tracing: %s\nDefaulting to tracing on stderr...\n", trace, err)
+			} else {
+				sink = f
+			}
+			traced = true
+		} else if strings.ToLower(trace) == "true" {
+			traced = true
+		}
+		return
+	}
+
+	switch fd {
+	case 0:
+	case 1, 2:
+		traced = true
+	default:
+		sink, traced = os.NewFile(uintptr(fd), "trace"), true
+	}
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
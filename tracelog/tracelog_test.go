@@ -0,0 +1,92 @@
+package tracelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	for name, expected := range map[string]Format{
+		"":      TextFormat,
+		"text":  TextFormat,
+		"JSON":  JSONFormat,
+		" json": JSONFormat,
+	} {
+		format, ok := ParseFormat(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, expected, format, name)
+	}
+
+	_, ok := ParseFormat("xml")
+	assert.False(t, ok)
+}
+
+func TestJSONRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracelog")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trace.json")
+	for key, value := range map[string]string{
+		"GIT_TRACE":             path,
+		"GIT_TRACE_PERFORMANCE": "1",
+		CorrelationIDEnv:        "",
+	} {
+		old, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+		if ok {
+			defer os.Setenv(key, old)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+	defer Configure(TextFormat, "")
+
+	Hold()
+	Printf("held %d", 1)
+	Configure(JSONFormat, "test")
+	Errorf("failed: %s", "reason")
+	PerformanceSince("operation", time.Now().Add(-time.Second))
+
+	id := CorrelationID()
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, os.Getenv(CorrelationIDEnv))
+	assert.Equal(t, JSONFormat, CurrentFormat())
+
+	f, err := os.Open(path)
+	require.Nil(t, err)
+	defer f.Close()
+
+	var records []*Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &r), scanner.Text())
+		records = append(records, &r)
+	}
+	require.Len(t, records, 3)
+
+	for _, r := range records {
+		assert.Equal(t, "test", r.Command)
+		assert.Equal(t, os.Getpid(), r.Pid)
+		assert.Equal(t, id, r.CorrelationID)
+		_, err := time.Parse(time.RFC3339Nano, r.Time)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, "trace", records[0].Level)
+	assert.Equal(t, "held 1", records[0].Message)
+	assert.Equal(t, "error", records[1].Level)
+	assert.Equal(t, "failed: reason", records[1].Message)
+	assert.Equal(t, "performance", records[2].Level)
+	assert.Equal(t, "operation", records[2].Message)
+	assert.True(t, records[2].Seconds >= 1)
+}